@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"net/netip"
@@ -67,6 +68,10 @@ const (
 	MagicSSHSessionTypeVSCode = "vscode"
 	// MagicSSHSessionTypeJetBrains is set in the SSH config by the JetBrains extension to identify itself.
 	MagicSSHSessionTypeJetBrains = "jetbrains"
+
+	// DefaultReconnectingPTYBufferSize is the number of bytes of output each
+	// reconnecting PTY keeps for replay unless the template sets another size.
+	DefaultReconnectingPTYBufferSize = 64 << 10
 )
 
 type Options struct {
@@ -80,6 +85,10 @@ type Options struct {
 	Logger                 slog.Logger
 	AgentPorts             map[int]string
 	SSHMaxTimeout          time.Duration
+	// PersistReconnectingPTYScrollback writes the output of reconnecting
+	// PTYs to LogDir, so it's restored when a client reconnects to the same
+	// session after the agent restarts.
+	PersistReconnectingPTYScrollback bool
 }
 
 type Client interface {
@@ -90,6 +99,9 @@ type Client interface {
 	PostAppHealth(ctx context.Context, req agentsdk.PostAppHealthsRequest) error
 	PostStartup(ctx context.Context, req agentsdk.PostStartupRequest) error
 	PatchStartupLogs(ctx context.Context, req agentsdk.PatchStartupLogs) error
+	PostMetadata(ctx context.Context, key string, req agentsdk.PostMetadataRequest) error
+	PostSessionRecording(ctx context.Context, req agentsdk.PostSessionRecordingRequest) error
+	WatchPortShares(ctx context.Context) (<-chan codersdk.WorkspaceAgentPortShares, error)
 }
 
 func New(options Options) io.Closer {
@@ -130,6 +142,8 @@ func New(options Options) io.Closer {
 		ignorePorts:            options.AgentPorts,
 		connStatsChan:          make(chan *agentsdk.Stats, 1),
 		sshMaxTimeout:          options.SSHMaxTimeout,
+
+		persistReconnectingPTYScrollback: options.PersistReconnectingPTYScrollback,
 	}
 	a.init(ctx)
 	return a
@@ -147,8 +161,9 @@ type agent struct {
 	// are used by the agent, that the user does not care about.
 	ignorePorts map[int]string
 
-	reconnectingPTYs       sync.Map
-	reconnectingPTYTimeout time.Duration
+	reconnectingPTYs                 sync.Map
+	reconnectingPTYTimeout           time.Duration
+	persistReconnectingPTYScrollback bool
 
 	connCloseWait sync.WaitGroup
 	closeCancel   context.CancelFunc
@@ -157,7 +172,10 @@ type agent struct {
 
 	envVars map[string]string
 	// metadata is atomic because values can change after reconnection.
-	metadata      atomic.Value
+	metadata atomic.Value
+	// portShares are the ports shared on the agent. They're set from the
+	// metadata and updated by watching coderd for changes.
+	portShares    atomic.Pointer[codersdk.WorkspaceAgentPortShares]
 	sessionToken  atomic.Pointer[string]
 	sshServer     *ssh.Server
 	sshMaxTimeout time.Duration
@@ -183,6 +201,7 @@ type agent struct {
 // failure, you'll want the agent to reconnect.
 func (a *agent) runLoop(ctx context.Context) {
 	go a.reportLifecycleLoop(ctx)
+	go a.reportMetadataLoop(ctx)
 
 	for retrier := retry.New(100*time.Millisecond, 10*time.Second); retrier.Wait(ctx); {
 		a.logger.Info(ctx, "connecting to coderd")
@@ -303,6 +322,7 @@ func (a *agent) run(ctx context.Context) error {
 	}
 
 	oldMetadata := a.metadata.Swap(metadata)
+	a.portShares.Store(&metadata.PortShares)
 
 	// The startup script should only execute on the first run!
 	if oldMetadata == nil {
@@ -372,6 +392,7 @@ func (a *agent) run(ctx context.Context) error {
 	defer appReporterCtxCancel()
 	go NewWorkspaceAppHealthReporter(
 		a.logger, metadata.Apps, a.client.PostAppHealth)(appReporterCtx)
+	go a.watchPortShares(appReporterCtx)
 
 	a.closeMutex.Lock()
 	network := a.network
@@ -435,6 +456,9 @@ func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (_
 			network.Close()
 		}
 	}()
+	network.SetForwardTCPCallback(a.forwardTCPCallback)
+	// UDP packets to ports that may not be dialed are dropped.
+	network.SetForwardUDPCallback(a.portAllowed)
 
 	sshListener, err := network.Listen("tcp", ":"+strconv.Itoa(codersdk.WorkspaceAgentSSHPort))
 	if err != nil {
@@ -848,11 +872,18 @@ func (a *agent) init(ctx context.Context) {
 		},
 		HostSigners: []ssh.Signer{randomSigner},
 		LocalPortForwardingCallback: func(ctx ssh.Context, destinationHost string, destinationPort uint32) bool {
-			// Allow local port forwarding all!
+			// Ports of the workspace that aren't shared can't be forwarded,
+			// the same as when they're dialed through tailnet. Forwards to
+			// other hosts aren't restricted.
+			allowed := true
+			if isLocalHost(destinationHost) {
+				allowed = destinationPort <= math.MaxUint16 && a.portAllowed(uint16(destinationPort))
+			}
 			sshLogger.Debug(ctx, "local port forward",
 				slog.F("destination-host", destinationHost),
-				slog.F("destination-port", destinationPort))
-			return true
+				slog.F("destination-port", destinationPort),
+				slog.F("allowed", allowed))
+			return allowed
 		},
 		PtyCallback: func(ctx ssh.Context, pty ssh.Pty) bool {
 			return true
@@ -922,6 +953,13 @@ func (a *agent) init(ctx context.Context) {
 		MaxTimeout: a.sshMaxTimeout,
 	}
 
+	// Scrollback of sessions that would have timed out while the agent was
+	// down is never restored.
+	err = removeStaleReconnectingPTYScrollback(a.filesystem, a.logDir, a.reconnectingPTYTimeout)
+	if err != nil {
+		a.logger.Warn(ctx, "remove stale reconnecting pty scrollback", slog.Error(err))
+	}
+
 	go a.runLoop(ctx)
 }
 
@@ -1101,6 +1139,10 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 		if err != nil {
 			return xerrors.Errorf("start command: %w", err)
 		}
+		recorder := a.startSessionRecording(ctx, a.logger, codersdk.WorkspaceSessionTypeSSH, uint16(sshPty.Window.Width), uint16(sshPty.Window.Height), sshPty.Term)
+		// Deferred first so the recording is uploaded after the output
+		// has been copied.
+		defer a.uploadSessionRecording(a.logger, recorder)
 		var wg sync.WaitGroup
 		defer func() {
 			defer wg.Wait()
@@ -1119,12 +1161,19 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 				if resizeErr != nil && !errors.Is(resizeErr, pty.ErrClosed) {
 					a.logger.Warn(ctx, "failed to resize tty", slog.Error(resizeErr))
 				}
+				if recorder != nil {
+					recorder.Resize(uint16(win.Width), uint16(win.Height))
+				}
 			}
 		}()
 		// We don't add input copy to wait group because
 		// it won't return until the session is closed.
 		go func() {
-			_, _ = io.Copy(ptty.Input(), session)
+			var input io.Reader = session
+			if recorder != nil {
+				input = io.TeeReader(session, recorder.Input())
+			}
+			_, _ = io.Copy(ptty.Input(), input)
 		}()
 
 		// In low parallelism scenarios, the command may exit and we may close
@@ -1157,7 +1206,11 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 			stdout := ptyOutput()
 			defer stdout.Close()
 
-			_, _ = io.Copy(session, stdout)
+			var output io.Reader = stdout
+			if recorder != nil {
+				output = io.TeeReader(stdout, recorder)
+			}
+			_, _ = io.Copy(session, output)
 		}()
 		<-outputCopyStarted
 
@@ -1239,17 +1292,36 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 		}
 		cmd.Env = append(cmd.Env, "TERM=xterm-256color")
 
-		// Default to buffer 64KiB.
-		circularBuffer, err := circbuf.NewBuffer(64 << 10)
+		bufferSize := int64(DefaultReconnectingPTYBufferSize)
+		if metadata, ok := a.metadata.Load().(agentsdk.Metadata); ok && metadata.ReconnectingPTYBufferSize > 0 {
+			bufferSize = int64(metadata.ReconnectingPTYBufferSize)
+		}
+		circularBuffer, err := circbuf.NewBuffer(bufferSize)
 		if err != nil {
 			return xerrors.Errorf("create circular buffer: %w", err)
 		}
 
+		// Restore the output from before the agent restarted, so the
+		// session continues where it left off.
+		var (
+			scrollback *reconnectingPTYScrollback
+			written    int64
+		)
+		if a.persistReconnectingPTYScrollback {
+			scrollback, written, err = openReconnectingPTYScrollback(a.filesystem, reconnectingPTYScrollbackPath(a.logDir, msg.ID), circularBuffer)
+			if err != nil {
+				// The session works without it, so this isn't fatal.
+				logger.Warn(ctx, "open scrollback", slog.Error(err))
+			}
+		}
+
 		ptty, process, err := pty.Start(cmd)
 		if err != nil {
 			return xerrors.Errorf("start command: %w", err)
 		}
 
+		recorder := a.startSessionRecording(ctx, logger, codersdk.WorkspaceSessionTypeReconnectingPTY, msg.Width, msg.Height, "xterm-256color")
+
 		ctx, cancelFunc := context.WithCancel(ctx)
 		rpty = &reconnectingPTY{
 			activeConns: map[string]net.Conn{
@@ -1261,6 +1333,9 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 			// Timeouts created with an after func can be reset!
 			timeout:        time.AfterFunc(a.reconnectingPTYTimeout, cancelFunc),
 			circularBuffer: circularBuffer,
+			written:        written,
+			scrollback:     scrollback,
+			recorder:       recorder,
 		}
 		a.reconnectingPTYs.Store(msg.ID, rpty)
 		go func() {
@@ -1287,8 +1362,15 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 					break
 				}
 				part := buffer[:read]
+				if rpty.recorder != nil {
+					_, _ = rpty.recorder.Write(part)
+				}
 				rpty.circularBufferMutex.Lock()
 				_, err = rpty.circularBuffer.Write(part)
+				if err == nil {
+					rpty.written += int64(read)
+					rpty.persistScrollback(ctx, logger, part)
+				}
 				rpty.circularBufferMutex.Unlock()
 				if err != nil {
 					logger.Error(ctx, "write to circular buffer", slog.Error(err))
@@ -1306,6 +1388,17 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 			_ = process.Kill()
 			rpty.Close()
 			a.reconnectingPTYs.Delete(msg.ID)
+			a.uploadSessionRecording(logger, rpty.recorder)
+
+			// The scrollback is only kept when the session ends because
+			// the agent is closing. Otherwise the session is gone and so is
+			// its output.
+			if scrollback != nil && !a.isClosed() {
+				err := scrollback.Remove()
+				if err != nil {
+					logger.Warn(ctx, "remove scrollback", slog.Error(err))
+				}
+			}
 		}); err != nil {
 			return xerrors.Errorf("start routine: %w", err)
 		}
@@ -1316,10 +1409,23 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 		// We can continue after this, it's not fatal!
 		logger.Error(ctx, "resize", slog.Error(err))
 	}
+	if rpty.recorder != nil {
+		rpty.recorder.Resize(msg.Width, msg.Height)
+	}
 	// Write any previously stored data for the TTY.
 	rpty.circularBufferMutex.RLock()
 	prevBuf := slices.Clone(rpty.circularBuffer.Bytes())
+	written := rpty.written
 	rpty.circularBufferMutex.RUnlock()
+	if msg.Offset != nil {
+		// Skip the output the client already has, and tell it where the
+		// output starts in case the buffer no longer holds its offset.
+		var start int64
+		prevBuf, start = reconnectingPTYOutputFrom(prevBuf, written, *msg.Offset)
+		header := make([]byte, codersdk.ReconnectingPTYOffsetSize)
+		binary.LittleEndian.PutUint64(header, uint64(start))
+		prevBuf = append(header, prevBuf...)
+	}
 	// Note that there is a small race here between writing buffered
 	// data and storing conn in activeConns. This is likely a very minor
 	// edge case, but we should look into ways to avoid it. Holding
@@ -1353,6 +1459,7 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 			case <-heartbeat.C:
 			}
 			rpty.timeout.Reset(a.reconnectingPTYTimeout)
+			rpty.touchScrollback()
 		}
 	}()
 	defer func() {
@@ -1379,6 +1486,9 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 			logger.Warn(ctx, "write to pty", slog.Error(err))
 			return nil
 		}
+		if rpty.recorder != nil && req.Data != "" {
+			_, _ = rpty.recorder.Input().Write([]byte(req.Data))
+		}
 		// Check if a resize needs to happen!
 		if req.Height == 0 || req.Width == 0 {
 			continue
@@ -1388,6 +1498,9 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 			// We can continue after this, it's not fatal!
 			logger.Error(ctx, "resize", slog.Error(err))
 		}
+		if rpty.recorder != nil {
+			rpty.recorder.Resize(req.Width, req.Height)
+		}
 	}
 }
 
@@ -1583,8 +1696,39 @@ type reconnectingPTY struct {
 
 	circularBuffer      *circbuf.Buffer
 	circularBufferMutex sync.RWMutex
-	timeout             *time.Timer
-	ptty                pty.PTY
+	// written is the offset after the last byte of output, which is the
+	// total number of bytes the session has output.
+	written int64
+	// scrollback is nil unless the output is persisted.
+	scrollback *reconnectingPTYScrollback
+	// recorder is nil unless the session is recorded.
+	recorder *sessionRecorder
+	timeout  *time.Timer
+	ptty     pty.PTY
+}
+
+// persistScrollback writes output that has just been written to the circular
+// buffer to the scrollback file. circularBufferMutex must be held.
+func (r *reconnectingPTY) persistScrollback(ctx context.Context, logger slog.Logger, output []byte) {
+	if r.scrollback == nil {
+		return
+	}
+	err := r.scrollback.write(output, r.circularBuffer.Bytes(), r.written)
+	if err != nil {
+		// Stop persisting rather than leave a file with a gap in it.
+		logger.Warn(ctx, "write scrollback", slog.Error(err))
+		_ = r.scrollback.Remove()
+		r.scrollback = nil
+	}
+}
+
+// touchScrollback marks the scrollback file as in use.
+func (r *reconnectingPTY) touchScrollback() {
+	r.circularBufferMutex.RLock()
+	defer r.circularBufferMutex.RUnlock()
+	if r.scrollback != nil {
+		r.scrollback.touch()
+	}
 }
 
 // Close ends all connections to the reconnecting
@@ -1598,10 +1742,29 @@ func (r *reconnectingPTY) Close() {
 	_ = r.ptty.Close()
 	r.circularBufferMutex.Lock()
 	r.circularBuffer.Reset()
+	if r.scrollback != nil {
+		_ = r.scrollback.Close()
+	}
 	r.circularBufferMutex.Unlock()
 	r.timeout.Stop()
 }
 
+// reconnectingPTYOutputFrom returns the part of the buffered output starting
+// at offset, and the offset of its first byte. written is the offset after
+// the buffered output. The buffer only holds the most recent output, so the
+// returned offset is later than the requested one when the output at offset
+// has been discarded.
+func reconnectingPTYOutputFrom(buffered []byte, written, offset int64) ([]byte, int64) {
+	start := written - int64(len(buffered))
+	if offset <= start {
+		return buffered, start
+	}
+	if offset >= written {
+		return nil, written
+	}
+	return buffered[offset-start:], offset
+}
+
 // Bicopy copies all of the data between the two connections and will close them
 // after one or both of them are done writing. If the context is canceled, both
 // of the connections will be closed.
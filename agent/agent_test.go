@@ -912,6 +912,100 @@ func TestAgent_StartupScript(t *testing.T) {
 	})
 }
 
+func TestAgent_Metadata(t *testing.T) {
+	t.Parallel()
+
+	const echoHello = "echo hello"
+
+	t.Run("Once", func(t *testing.T) {
+		t.Parallel()
+
+		_, client, _, _, _ := setupAgent(t, agentsdk.Metadata{
+			Metadata: []codersdk.WorkspaceAgentMetadataDescription{{
+				Key:      "greeting",
+				Interval: 0,
+				Script:   echoHello,
+			}},
+		}, 0)
+
+		var results []agentsdk.PostMetadataRequest
+		require.Eventually(t, func() bool {
+			results = client.getMetadataResults("greeting")
+			return len(results) > 0
+		}, testutil.WaitShort, testutil.IntervalMedium)
+		require.Equal(t, "hello", results[0].Value)
+		require.Empty(t, results[0].Error)
+
+		// An interval of zero means the script only runs once.
+		time.Sleep(2 * time.Second)
+		require.Len(t, client.getMetadataResults("greeting"), 1)
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		t.Parallel()
+
+		_, client, _, _, _ := setupAgent(t, agentsdk.Metadata{
+			Metadata: []codersdk.WorkspaceAgentMetadataDescription{{
+				Key:      "greeting",
+				Interval: 1,
+				Script:   echoHello,
+			}},
+		}, 0)
+
+		require.Eventually(t, func() bool {
+			return len(client.getMetadataResults("greeting")) >= 2
+		}, testutil.WaitMedium, testutil.IntervalMedium)
+		results := client.getMetadataResults("greeting")
+		require.True(t, results[1].CollectedAt.After(results[0].CollectedAt))
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("sleep is not available on Windows")
+		}
+
+		_, client, _, _, _ := setupAgent(t, agentsdk.Metadata{
+			Metadata: []codersdk.WorkspaceAgentMetadataDescription{{
+				Key:     "slow",
+				Timeout: 1,
+				Script:  "sleep 10",
+			}},
+		}, 0)
+
+		var results []agentsdk.PostMetadataRequest
+		require.Eventually(t, func() bool {
+			results = client.getMetadataResults("slow")
+			return len(results) > 0
+		}, testutil.WaitShort, testutil.IntervalMedium)
+		require.Contains(t, results[0].Error, "timed out")
+	})
+
+	t.Run("TimeoutBackgroundProcess", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("sleep is not available on Windows")
+		}
+
+		// The background sleep keeps stdout open after the script is
+		// killed, which must not delay the result.
+		_, client, _, _, _ := setupAgent(t, agentsdk.Metadata{
+			Metadata: []codersdk.WorkspaceAgentMetadataDescription{{
+				Key:     "slow",
+				Timeout: 1,
+				Script:  "sleep 60 & sleep 10",
+			}},
+		}, 0)
+
+		var results []agentsdk.PostMetadataRequest
+		require.Eventually(t, func() bool {
+			results = client.getMetadataResults("slow")
+			return len(results) > 0
+		}, testutil.WaitShort, testutil.IntervalMedium)
+		require.Contains(t, results[0].Error, "timed out")
+	})
+}
+
 func TestAgent_Lifecycle(t *testing.T) {
 	t.Parallel()
 
@@ -1304,6 +1398,193 @@ func TestAgent_ReconnectingPTY(t *testing.T) {
 	expectLine(matchEchoOutput)
 }
 
+func TestAgent_ReconnectingPTYOffset(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	//nolint:dogsled
+	conn, _, _, _, _ := setupAgent(t, agentsdk.Metadata{}, 0)
+	id := uuid.New()
+	netConn, err := conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash", codersdk.ReconnectingPTYWithOffset(0))
+	require.NoError(t, err)
+	defer netConn.Close()
+
+	offset, err := codersdk.ReadReconnectingPTYOffset(netConn)
+	require.NoError(t, err)
+	require.Zero(t, offset)
+	bufRead := bufio.NewReader(netConn)
+
+	// Brief pause to reduce the likelihood that we send keystrokes while
+	// the shell is simultaneously sending a prompt.
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+		Data: "echo test\r\n",
+	})
+	require.NoError(t, err)
+	_, err = netConn.Write(data)
+	require.NoError(t, err)
+
+	// Keep track of the offset of the output that has been read.
+	expectLine := func(matcher func(string) bool) {
+		for {
+			line, err := bufRead.ReadString('\n')
+			require.NoError(t, err)
+			offset += int64(len(line))
+			require.NotContains(t, line, "echo again", "output after the offset")
+			if matcher(line) {
+				break
+			}
+		}
+	}
+	expectLine(func(line string) bool {
+		return strings.Contains(line, "test") && !strings.Contains(line, "echo")
+	})
+
+	_ = netConn.Close()
+	netConn, err = conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash", codersdk.ReconnectingPTYWithOffset(offset))
+	require.NoError(t, err)
+	defer netConn.Close()
+
+	start, err := codersdk.ReadReconnectingPTYOffset(netConn)
+	require.NoError(t, err)
+	require.Equal(t, offset, start)
+	bufRead = bufio.NewReader(netConn)
+
+	data, err = json.Marshal(codersdk.ReconnectingPTYRequest{
+		Data: "echo again\r\n",
+	})
+	require.NoError(t, err)
+	_, err = netConn.Write(data)
+	require.NoError(t, err)
+
+	// The output that was already read isn't replayed.
+	for {
+		line, err := bufRead.ReadString('\n')
+		require.NoError(t, err)
+		require.NotContains(t, line, "echo test")
+		if strings.Contains(line, "again") && !strings.Contains(line, "echo") {
+			break
+		}
+	}
+}
+
+func TestAgent_ReconnectingPTYBufferSize(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	//nolint:dogsled
+	conn, _, _, _, _ := setupAgent(t, agentsdk.Metadata{
+		ReconnectingPTYBufferSize: 1024,
+	}, 0)
+	id := uuid.New()
+	netConn, err := conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash")
+	require.NoError(t, err)
+	defer netConn.Close()
+	bufRead := bufio.NewReader(netConn)
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+		Data: "seq 1 1000\r\n",
+	})
+	require.NoError(t, err)
+	_, err = netConn.Write(data)
+	require.NoError(t, err)
+	for {
+		line, err := bufRead.ReadString('\n')
+		require.NoError(t, err)
+		if strings.TrimSpace(line) == "1000" {
+			break
+		}
+	}
+
+	_ = netConn.Close()
+	netConn, err = conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash", codersdk.ReconnectingPTYWithOffset(0))
+	require.NoError(t, err)
+	defer netConn.Close()
+
+	// The output is far larger than the buffer, so the start of it has been
+	// discarded.
+	start, err := codersdk.ReadReconnectingPTYOffset(netConn)
+	require.NoError(t, err)
+	require.Greater(t, start, int64(1024))
+}
+
+func TestAgent_ReconnectingPTYScrollback(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	fs := afero.NewMemMapFs()
+	persist := func(options *agent.Options) {
+		options.Filesystem = fs
+		options.LogDir = "/logs"
+		options.PersistReconnectingPTYScrollback = true
+	}
+	//nolint:dogsled
+	conn, _, _, _, closer := setupAgent(t, agentsdk.Metadata{}, 0, persist)
+	id := uuid.New()
+	netConn, err := conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash")
+	require.NoError(t, err)
+	defer netConn.Close()
+	bufRead := bufio.NewReader(netConn)
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+		Data: "echo test\r\n",
+	})
+	require.NoError(t, err)
+	_, err = netConn.Write(data)
+	require.NoError(t, err)
+
+	expectLine := func(matcher func(string) bool) {
+		for {
+			line, err := bufRead.ReadString('\n')
+			require.NoError(t, err)
+			if matcher(line) {
+				break
+			}
+		}
+	}
+	matchEchoOutput := func(line string) bool {
+		return strings.Contains(line, "test") && !strings.Contains(line, "echo")
+	}
+	expectLine(matchEchoOutput)
+
+	// Restart the agent, the scrollback is kept on disk.
+	_ = netConn.Close()
+	require.NoError(t, closer.Close())
+	files, err := afero.Glob(fs, "/logs/coder-reconnecting-pty-*.log")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	//nolint:dogsled
+	conn, _, _, _, _ = setupAgent(t, agentsdk.Metadata{}, 0, persist)
+	netConn, err = conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash")
+	require.NoError(t, err)
+	defer netConn.Close()
+	bufRead = bufio.NewReader(netConn)
+
+	// The output from before the restart is replayed.
+	expectLine(matchEchoOutput)
+}
+
 func TestAgent_Dial(t *testing.T) {
 	t.Parallel()
 
@@ -1368,6 +1649,179 @@ func TestAgent_Dial(t *testing.T) {
 	}
 }
 
+func TestAgent_PortShares(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	listen := func() (net.Listener, uint16) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = l.Close()
+		})
+		go func() {
+			for {
+				c, err := l.Accept()
+				if err != nil {
+					return
+				}
+				go testAccept(t, c)
+			}
+		}()
+		return l, uint16(l.Addr().(*net.TCPAddr).Port)
+	}
+	sharedListener, sharedPort := listen()
+	appListener, appPort := listen()
+	otherListener, otherPort := listen()
+
+	//nolint:dogsled
+	conn, agentClient, _, _, _ := setupAgent(t, agentsdk.Metadata{
+		Apps: []codersdk.WorkspaceApp{{
+			URL: fmt.Sprintf("http://localhost:%d", appPort),
+		}},
+		PortShares: codersdk.WorkspaceAgentPortShares{
+			Restricted: true,
+			Shares: []codersdk.WorkspaceAgentPortShare{{
+				Port:       sharedPort,
+				ShareLevel: codersdk.WorkspaceAppSharingLevelPublic,
+				Source:     codersdk.WorkspaceAgentPortShareSourceTemplate,
+			}},
+		},
+	}, 0)
+	require.True(t, conn.AwaitReachable(ctx))
+
+	// Shared ports and the ports of apps can be dialed.
+	for _, l := range []net.Listener{sharedListener, appListener} {
+		c, err := conn.DialContext(ctx, "tcp", l.Addr().String())
+		require.NoError(t, err)
+		testDial(t, c)
+		_ = c.Close()
+	}
+
+	// Other ports are rejected, both through tailnet and when they're
+	// forwarded over SSH.
+	c, err := conn.DialContext(ctx, "tcp", otherListener.Addr().String())
+	if err == nil {
+		_, err = c.Read(make([]byte, 1))
+		_ = c.Close()
+	}
+	require.Error(t, err)
+	sshClient, err := conn.SSHClient(ctx)
+	require.NoError(t, err)
+	defer sshClient.Close()
+	_, err = sshClient.Dial("tcp", otherListener.Addr().String())
+	require.Error(t, err)
+	c, err = sshClient.Dial("tcp", sharedListener.Addr().String())
+	require.NoError(t, err)
+	testDial(t, c)
+	_ = c.Close()
+
+	// UDP packets to ports that aren't shared are dropped, so nothing is
+	// echoed back. The UDP port is the same as the TCP port that's shared
+	// below.
+	udpListener, err := udp.Listen("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: int(otherPort)})
+	require.NoError(t, err)
+	defer udpListener.Close()
+	go func() {
+		for {
+			c, err := udpListener.Accept()
+			if err != nil {
+				return
+			}
+			go testAccept(t, c)
+		}
+	}()
+	c, err = conn.DialContext(ctx, "udp", udpListener.Addr().String())
+	require.NoError(t, err)
+	_, err = c.Write(dialTestPayload)
+	require.NoError(t, err)
+	require.NoError(t, c.SetReadDeadline(time.Now().Add(testutil.IntervalSlow)))
+	_, err = c.Read(make([]byte, len(dialTestPayload)))
+	require.Error(t, err)
+	_ = c.Close()
+
+	// Forwards to other hosts aren't restricted.
+	if hostListener := listenNonLoopback(t); hostListener != nil {
+		go func() {
+			c, err := hostListener.Accept()
+			if err != nil {
+				return
+			}
+			testAccept(t, c)
+		}()
+		c, err = sshClient.Dial("tcp", hostListener.Addr().String())
+		require.NoError(t, err)
+		testDial(t, c)
+		_ = c.Close()
+	}
+
+	// Ports can be dialed once coderd shares them.
+	err = agentClient.setPortShares(ctx, codersdk.WorkspaceAgentPortShares{
+		Restricted: true,
+		Shares: []codersdk.WorkspaceAgentPortShare{{
+			Port:       otherPort,
+			ShareLevel: codersdk.WorkspaceAppSharingLevelOwner,
+			Source:     codersdk.WorkspaceAgentPortShareSourceOwner,
+		}},
+	})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		c, err := sshClient.Dial("tcp", otherListener.Addr().String())
+		if err != nil {
+			return false
+		}
+		testDial(t, c)
+		_ = c.Close()
+		return true
+	}, testutil.WaitShort, testutil.IntervalFast)
+	c, err = conn.DialContext(ctx, "tcp", otherListener.Addr().String())
+	require.NoError(t, err)
+	testDial(t, c)
+	_ = c.Close()
+	c, err = conn.DialContext(ctx, "udp", udpListener.Addr().String())
+	require.NoError(t, err)
+	testDial(t, c)
+	_ = c.Close()
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	// Ports that can't be dialed aren't listed. The port shared by the
+	// template was replaced by the update above.
+	ports, err := conn.ListeningPorts(ctx)
+	require.NoError(t, err)
+	listed := map[uint16]bool{}
+	for _, port := range ports.Ports {
+		listed[port.Port] = true
+	}
+	require.False(t, listed[sharedPort])
+	require.True(t, listed[otherPort])
+}
+
+// listenNonLoopback listens on a non-loopback address of the host, or returns
+// nil if the host doesn't have one.
+func listenNonLoopback(t *testing.T) net.Listener {
+	t.Helper()
+	addrs, err := net.InterfaceAddrs()
+	require.NoError(t, err)
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.To4() == nil {
+			continue
+		}
+		l, err := net.Listen("tcp", net.JoinHostPort(ipNet.IP.String(), "0"))
+		if err != nil {
+			continue
+		}
+		t.Cleanup(func() {
+			_ = l.Close()
+		})
+		return l
+	}
+	return nil
+}
+
 func TestAgent_Speedtest(t *testing.T) {
 	t.Parallel()
 	t.Skip("This test is relatively flakey because of Tailscale's speedtest code...")
@@ -1384,6 +1838,131 @@ func TestAgent_Speedtest(t *testing.T) {
 	t.Logf("%.2f MBits/s", res[len(res)-1].MBitsPerSecond())
 }
 
+func TestAgent_Files(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	dir := t.TempDir()
+	//nolint:dogsled
+	conn, _, _, fs, _ := setupAgent(t, agentsdk.Metadata{
+		Directory: dir,
+	}, 0)
+	defer conn.Close()
+	require.NoError(t, fs.MkdirAll(dir, 0o755))
+
+	// Relative paths are resolved against the agent directory, and missing
+	// parent directories are created.
+	info, err := conn.WriteFile(ctx, filepath.Join("foo", "bar.txt"), 0o600, strings.NewReader("hello"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "foo", "bar.txt"), info.Path)
+	require.Equal(t, int64(5), info.Size)
+	require.Equal(t, os.FileMode(0o600), info.FileMode().Perm())
+
+	content, err := afero.ReadFile(fs, info.Path)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(content))
+
+	// Overwriting a file keeps its mode.
+	info, err = conn.WriteFile(ctx, info.Path, 0, strings.NewReader("hello world"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.FileMode().Perm())
+
+	rc, err := conn.ReadFile(ctx, filepath.Join("foo", "bar.txt"))
+	require.NoError(t, err)
+	content, err = io.ReadAll(rc)
+	_ = rc.Close()
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(content))
+
+	info, err = conn.StatFile(ctx, "foo")
+	require.NoError(t, err)
+	require.True(t, info.IsDir)
+
+	list, err := conn.ListFiles(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, dir, list.Path)
+	require.Len(t, list.Files, 1)
+	require.Equal(t, "foo", list.Files[0].Name)
+	require.True(t, list.Files[0].IsDir)
+
+	_, err = conn.ReadFile(ctx, "missing")
+	var sdkErr *codersdk.Error
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusNotFound, sdkErr.StatusCode())
+
+	_, err = conn.ReadFile(ctx, "foo")
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+
+	// Paths can't leave the agent directory.
+	for _, path := range []string{
+		"..",
+		filepath.Join("..", "outside.txt"),
+		filepath.Join("foo", "..", "..", "outside.txt"),
+		filepath.Dir(dir),
+		filepath.Join(filepath.Dir(dir), "outside.txt"),
+	} {
+		_, err = conn.WriteFile(ctx, path, 0, strings.NewReader("hello"))
+		require.ErrorAs(t, err, &sdkErr, path)
+		require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode(), path)
+		_, err = conn.ListFiles(ctx, path)
+		require.ErrorAs(t, err, &sdkErr, path)
+		require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode(), path)
+	}
+	_, err = fs.Stat(filepath.Join(filepath.Dir(dir), "outside.txt"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestAgent_FilesSymlink(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires elevated privileges on Windows")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	dir := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("hello"), 0o600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "secret.txt")))
+	require.NoError(t, os.Symlink("file.txt", filepath.Join(dir, "link.txt")))
+
+	//nolint:dogsled
+	conn, _, _, _, _ := setupAgent(t, agentsdk.Metadata{
+		Directory: dir,
+	}, 0, func(o *agent.Options) {
+		o.Filesystem = afero.NewOsFs()
+	})
+	defer conn.Close()
+
+	// Symlinks within the agent directory can be followed.
+	rc, err := conn.ReadFile(ctx, "link.txt")
+	require.NoError(t, err)
+	content, err := io.ReadAll(rc)
+	_ = rc.Close()
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(content))
+
+	// Symlinks pointing outside of it can't.
+	var sdkErr *codersdk.Error
+	_, err = conn.ReadFile(ctx, "secret.txt")
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+
+	_, err = conn.ListFiles(ctx, "escape")
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+
+	_, err = conn.WriteFile(ctx, filepath.Join("escape", "new.txt"), 0, strings.NewReader("hello"))
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+	_, err = os.Stat(filepath.Join(outside, "new.txt"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
 func TestAgent_Reconnect(t *testing.T) {
 	t.Parallel()
 	// After the agent is disconnected from a coordinator, it's supposed
@@ -1504,6 +2083,145 @@ func setupSSHCommand(t *testing.T, beforeArgs []string, afterArgs []string) *exe
 	return exec.Command("ssh", args...)
 }
 
+func TestAgent_SessionRecording(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+
+	// readRecording parses an asciicast v2 recording into its header and
+	// events.
+	readRecording := func(t *testing.T, data []byte) (map[string]any, [][]any) {
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		var header map[string]any
+		err := json.Unmarshal([]byte(lines[0]), &header)
+		require.NoError(t, err)
+		events := make([][]any, 0, len(lines)-1)
+		for _, line := range lines[1:] {
+			var event []any
+			err := json.Unmarshal([]byte(line), &event)
+			require.NoError(t, err)
+			require.Len(t, event, 3)
+			events = append(events, event)
+		}
+		return header, events
+	}
+
+	t.Run("SSH", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		//nolint:dogsled
+		conn, client, _, _, _ := setupAgent(t, agentsdk.Metadata{
+			SessionRecording: true,
+		}, 0)
+		sshClient, err := conn.SSHClient(ctx)
+		require.NoError(t, err)
+		defer sshClient.Close()
+		session, err := sshClient.NewSession()
+		require.NoError(t, err)
+		defer session.Close()
+		err = session.RequestPty("xterm", 24, 80, ssh.TerminalModes{})
+		require.NoError(t, err)
+		var stdout bytes.Buffer
+		session.Stdout = &stdout
+		err = session.Run("echo recorded")
+		require.NoError(t, err)
+		require.Contains(t, stdout.String(), "recorded")
+
+		require.Eventually(t, func() bool {
+			return len(client.getRecordings()) == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+		recording := client.getRecordings()[0]
+		require.Equal(t, codersdk.WorkspaceSessionTypeSSH, recording.Type)
+		require.False(t, recording.EndedAt.Before(recording.StartedAt))
+
+		header, events := readRecording(t, recording.data)
+		require.EqualValues(t, 2, header["version"])
+		require.EqualValues(t, 80, header["width"])
+		require.EqualValues(t, 24, header["height"])
+		var output string
+		for _, event := range events {
+			if event[1] == "o" {
+				output += event[2].(string)
+			}
+		}
+		require.Contains(t, output, "recorded")
+	})
+
+	t.Run("ReconnectingPTY", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		//nolint:dogsled
+		conn, client, _, _, _ := setupAgent(t, agentsdk.Metadata{
+			SessionRecording: true,
+		}, 0)
+		netConn, err := conn.ReconnectingPTY(ctx, uuid.New(), 30, 100, "/bin/bash")
+		require.NoError(t, err)
+		defer netConn.Close()
+
+		time.Sleep(100 * time.Millisecond)
+
+		data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+			Data:   "echo secret\r\nexit\r\n",
+			Height: 40,
+			Width:  120,
+		})
+		require.NoError(t, err)
+		_, err = netConn.Write(data)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return len(client.getRecordings()) == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+		recording := client.getRecordings()[0]
+		require.Equal(t, codersdk.WorkspaceSessionTypeReconnectingPTY, recording.Type)
+
+		header, events := readRecording(t, recording.data)
+		require.EqualValues(t, 100, header["width"])
+		require.EqualValues(t, 30, header["height"])
+		var (
+			inputs  int
+			resized bool
+		)
+		for _, event := range events {
+			switch event[1] {
+			case "i":
+				inputs++
+				// Only the timing of input is recorded.
+				require.Empty(t, event[2])
+			case "r":
+				resized = resized || event[2] == "120x40"
+			}
+		}
+		require.Equal(t, 1, inputs)
+		require.True(t, resized)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		//nolint:dogsled
+		conn, client, _, _, _ := setupAgent(t, agentsdk.Metadata{}, 0)
+		sshClient, err := conn.SSHClient(ctx)
+		require.NoError(t, err)
+		defer sshClient.Close()
+		session, err := sshClient.NewSession()
+		require.NoError(t, err)
+		defer session.Close()
+		err = session.RequestPty("xterm", 24, 80, ssh.TerminalModes{})
+		require.NoError(t, err)
+		err = session.Run("true")
+		require.NoError(t, err)
+		require.Empty(t, client.getRecordings())
+	})
+}
+
 func setupSSHSession(t *testing.T, options agentsdk.Metadata) *ssh.Session {
 	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 	defer cancel()
@@ -1528,7 +2246,7 @@ func (c closeFunc) Close() error {
 	return c()
 }
 
-func setupAgent(t *testing.T, metadata agentsdk.Metadata, ptyTimeout time.Duration) (
+func setupAgent(t *testing.T, metadata agentsdk.Metadata, ptyTimeout time.Duration, opts ...func(*agent.Options)) (
 	*codersdk.WorkspaceAgentConn,
 	*client,
 	<-chan *agentsdk.Stats,
@@ -1552,12 +2270,16 @@ func setupAgent(t *testing.T, metadata agentsdk.Metadata, ptyTimeout time.Durati
 		statsChan:   statsCh,
 		coordinator: coordinator,
 	}
-	closer := agent.New(agent.Options{
+	options := agent.Options{
 		Client:                 c,
 		Filesystem:             fs,
 		Logger:                 slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
 		ReconnectingPTYTimeout: ptyTimeout,
-	})
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	closer := agent.New(options)
 	t.Cleanup(func() {
 		_ = closer.Close()
 	})
@@ -1594,7 +2316,7 @@ func setupAgent(t *testing.T, metadata agentsdk.Metadata, ptyTimeout time.Durati
 	if !agentConn.AwaitReachable(ctx) {
 		t.Fatal("agent not reachable")
 	}
-	return agentConn, c, statsCh, fs, closer
+	return agentConn, c, statsCh, options.Filesystem, closer
 }
 
 var dialTestPayload = []byte("dean-was-here123")
@@ -1641,6 +2363,14 @@ type client struct {
 	lifecycleStates []codersdk.WorkspaceAgentLifecycle
 	startup         agentsdk.PostStartupRequest
 	logs            []agentsdk.StartupLog
+	metadataResults map[string][]agentsdk.PostMetadataRequest
+	recordings      []sessionRecording
+	portShares      chan codersdk.WorkspaceAgentPortShares
+}
+
+type sessionRecording struct {
+	agentsdk.PostSessionRecordingRequest
+	data []byte
 }
 
 func (c *client) Metadata(_ context.Context) (agentsdk.Metadata, error) {
@@ -1741,6 +2471,83 @@ func (c *client) PatchStartupLogs(_ context.Context, logs agentsdk.PatchStartupL
 	return nil
 }
 
+func (c *client) getMetadataResults(key string) []agentsdk.PostMetadataRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]agentsdk.PostMetadataRequest(nil), c.metadataResults[key]...)
+}
+
+func (c *client) PostMetadata(_ context.Context, key string, req agentsdk.PostMetadataRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.metadataResults == nil {
+		c.metadataResults = make(map[string][]agentsdk.PostMetadataRequest)
+	}
+	c.metadataResults[key] = append(c.metadataResults[key], req)
+	return nil
+}
+
+func (c *client) PostSessionRecording(_ context.Context, req agentsdk.PostSessionRecordingRequest) error {
+	data, err := io.ReadAll(req.Recording)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordings = append(c.recordings, sessionRecording{PostSessionRecordingRequest: req, data: data})
+	return nil
+}
+
+// WatchPortShares forwards the shares sent with setPortShares.
+func (c *client) WatchPortShares(ctx context.Context) (<-chan codersdk.WorkspaceAgentPortShares, error) {
+	c.mu.Lock()
+	if c.portShares == nil {
+		c.portShares = make(chan codersdk.WorkspaceAgentPortShares)
+	}
+	portShares := c.portShares
+	c.mu.Unlock()
+
+	sharesChan := make(chan codersdk.WorkspaceAgentPortShares)
+	go func() {
+		defer close(sharesChan)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case shares := <-portShares:
+				select {
+				case <-ctx.Done():
+					return
+				case sharesChan <- shares:
+				}
+			}
+		}
+	}()
+	return sharesChan, nil
+}
+
+func (c *client) setPortShares(ctx context.Context, shares codersdk.WorkspaceAgentPortShares) error {
+	c.mu.Lock()
+	if c.portShares == nil {
+		c.portShares = make(chan codersdk.WorkspaceAgentPortShares)
+	}
+	portShares := c.portShares
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case portShares <- shares:
+		return nil
+	}
+}
+
+func (c *client) getRecordings() []sessionRecording {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.recordings
+}
+
 // tempDirUnixSocket returns a temporary directory that can safely hold unix
 // sockets (probably).
 //
@@ -26,8 +26,9 @@ func (a *agent) apiHandler() http.Handler {
 		cpy[k] = b
 	}
 
-	lp := &listeningPortsHandler{ignorePorts: cpy}
+	lp := &listeningPortsHandler{ignorePorts: cpy, portAllowed: a.portAllowed}
 	r.Get("/api/v0/listening-ports", lp.handler)
+	r.Mount("/api/v0/files", a.filesHandler())
 
 	return r
 }
@@ -37,6 +38,8 @@ type listeningPortsHandler struct {
 	ports       []codersdk.WorkspaceAgentListeningPort
 	mtime       time.Time
 	ignorePorts map[int]string
+	// portAllowed hides ports that may not be dialed.
+	portAllowed func(port uint16) bool
 }
 
 // handler returns a list of listening ports. This is tested by coderd's
@@ -51,7 +54,14 @@ func (lp *listeningPortsHandler) handler(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
+	allowed := make([]codersdk.WorkspaceAgentListeningPort, 0, len(ports))
+	for _, port := range ports {
+		if lp.portAllowed == nil || lp.portAllowed(port.Port) {
+			allowed = append(allowed, port)
+		}
+	}
+
 	httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.WorkspaceAgentListeningPortsResponse{
-		Ports: ports,
+		Ports: allowed,
 	})
 }
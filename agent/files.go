@@ -0,0 +1,358 @@
+package agent
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/spf13/afero"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+)
+
+// filesHandler serves endpoints for reading, writing and listing files in
+// the workspace. Paths are resolved against the agent directory, or the home
+// directory of the user if the agent directory does not exist, and must not
+// escape it through ".." elements or symlinks.
+func (a *agent) filesHandler() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/stat", a.handleStatFile)
+	r.Get("/list", a.handleListFiles)
+	r.Get("/read", a.handleReadFile)
+	r.Put("/write", a.handleWriteFile)
+	return r
+}
+
+func (a *agent) handleStatFile(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	path, ok := a.filePathParam(rw, r)
+	if !ok {
+		return
+	}
+
+	info, err := a.filesystem.Stat(path)
+	if err != nil {
+		writeFileError(rw, r, path, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, convertFileInfo(path, info))
+}
+
+func (a *agent) handleListFiles(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	path, ok := a.filePathParam(rw, r)
+	if !ok {
+		return
+	}
+
+	info, err := a.filesystem.Stat(path)
+	if err != nil {
+		writeFileError(rw, r, path, err)
+		return
+	}
+	if !info.IsDir() {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Path is not a directory.",
+			Detail:  path,
+		})
+		return
+	}
+
+	// ReadDir returns entries sorted by name.
+	infos, err := afero.ReadDir(a.filesystem, path)
+	if err != nil {
+		writeFileError(rw, r, path, err)
+		return
+	}
+	files := make([]codersdk.WorkspaceAgentFileInfo, 0, len(infos))
+	for _, info := range infos {
+		files = append(files, convertFileInfo(filepath.Join(path, info.Name()), info))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.WorkspaceAgentListFilesResponse{
+		Path:  path,
+		Files: files,
+	})
+}
+
+func (a *agent) handleReadFile(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	path, ok := a.filePathParam(rw, r)
+	if !ok {
+		return
+	}
+
+	f, err := a.filesystem.Open(path)
+	if err != nil {
+		writeFileError(rw, r, path, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		writeFileError(rw, r, path, err)
+		return
+	}
+	if info.IsDir() {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Path is a directory.",
+			Detail:  path,
+		})
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/octet-stream")
+	rw.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	rw.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(rw, f)
+}
+
+func (a *agent) handleWriteFile(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	path, ok := a.filePathParam(rw, r)
+	if !ok {
+		return
+	}
+
+	mode := os.FileMode(0o644)
+	if existing, err := a.filesystem.Stat(path); err == nil {
+		if existing.IsDir() {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Path is a directory.",
+				Detail:  path,
+			})
+			return
+		}
+		mode = existing.Mode().Perm()
+	}
+	if rawMode := r.URL.Query().Get("mode"); rawMode != "" {
+		parsed, err := strconv.ParseUint(rawMode, 8, 32)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid file mode.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		mode = os.FileMode(parsed).Perm()
+	}
+
+	dir := filepath.Dir(path)
+	err := a.filesystem.MkdirAll(dir, 0o755)
+	if err != nil {
+		writeFileError(rw, r, dir, err)
+		return
+	}
+
+	// Write to a temporary file in the same directory and rename it, so
+	// readers never observe a partially written file.
+	tmp, err := afero.TempFile(a.filesystem, dir, "."+filepath.Base(path)+".coder-*")
+	if err != nil {
+		writeFileError(rw, r, dir, err)
+		return
+	}
+	_, err = io.Copy(tmp, r.Body)
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = a.filesystem.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = a.filesystem.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = a.filesystem.Remove(tmp.Name())
+		writeFileError(rw, r, path, err)
+		return
+	}
+
+	info, err := a.filesystem.Stat(path)
+	if err != nil {
+		writeFileError(rw, r, path, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, convertFileInfo(path, info))
+}
+
+// errPathOutsideRoot is returned when a path resolves to a location outside
+// of the agent directory.
+var errPathOutsideRoot = xerrors.New("path is outside of the agent directory")
+
+// filePathParam returns the absolute path from the "path" query parameter.
+// An empty path refers to the agent directory.
+func (a *agent) filePathParam(rw http.ResponseWriter, r *http.Request) (string, bool) {
+	path, err := a.resolveFilePath(r.URL.Query().Get("path"))
+	if errors.Is(err, errPathOutsideRoot) {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Path must be within the agent directory.",
+			Detail:  err.Error(),
+		})
+		return "", false
+	}
+	if err != nil {
+		httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to resolve path.",
+			Detail:  err.Error(),
+		})
+		return "", false
+	}
+	return path, true
+}
+
+// resolveFilePath resolves path against the agent directory and returns it
+// with symlinks evaluated. Paths containing ".." elements, and paths that
+// resolve to a location outside of the agent directory, are rejected with
+// errPathOutsideRoot.
+func (a *agent) resolveFilePath(path string) (string, error) {
+	for _, elem := range strings.FieldsFunc(path, isPathSeparator) {
+		if elem == ".." {
+			return "", errPathOutsideRoot
+		}
+	}
+
+	root, err := a.filesRoot()
+	if err != nil {
+		return "", err
+	}
+	root, err = evalSymlinks(a.filesystem, filepath.Clean(root))
+	if err != nil {
+		return "", xerrors.Errorf("resolve agent directory: %w", err)
+	}
+
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := userHomeDir()
+		if err != nil {
+			return "", xerrors.Errorf("get home dir: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path, err = evalSymlinks(a.filesystem, filepath.Clean(path))
+	if err != nil {
+		return "", xerrors.Errorf("resolve path: %w", err)
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errPathOutsideRoot
+	}
+	return path, nil
+}
+
+// evalSymlinks is filepath.EvalSymlinks for an afero.Fs. The path must be
+// absolute and clean. Elements that don't exist yet are kept as they are, so
+// the path of a file that is about to be created can be resolved too.
+// Filesystems without symlink support return the path unchanged.
+func evalSymlinks(fs afero.Fs, path string) (string, error) {
+	lstater, ok := fs.(afero.Lstater)
+	if !ok {
+		return path, nil
+	}
+	reader, ok := fs.(afero.LinkReader)
+	if !ok {
+		return path, nil
+	}
+
+	volume := filepath.VolumeName(path)
+	resolved := volume + string(filepath.Separator)
+	remaining := strings.FieldsFunc(path[len(volume):], isPathSeparator)
+	links := 0
+	for len(remaining) > 0 {
+		elem := remaining[0]
+		remaining = remaining[1:]
+		switch elem {
+		case ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, elem)
+		info, _, err := lstater.LstatIfPossible(next)
+		if errors.Is(err, os.ErrNotExist) {
+			return filepath.Join(append([]string{next}, remaining...)...), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > 255 {
+			return "", xerrors.Errorf("%s: too many links", path)
+		}
+		target, err := reader.ReadlinkIfPossible(next)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			volume = filepath.VolumeName(target)
+			resolved = volume + string(filepath.Separator)
+			target = target[len(volume):]
+		}
+		remaining = append(strings.FieldsFunc(target, isPathSeparator), remaining...)
+	}
+	return resolved, nil
+}
+
+func isPathSeparator(r rune) bool {
+	return r == '/' || r == filepath.Separator
+}
+
+// filesRoot returns the directory relative paths are resolved against.
+func (a *agent) filesRoot() (string, error) {
+	if metadata, ok := a.metadata.Load().(agentsdk.Metadata); ok && metadata.Directory != "" {
+		if info, err := a.filesystem.Stat(metadata.Directory); err == nil && info.IsDir() {
+			return metadata.Directory, nil
+		}
+	}
+	home, err := userHomeDir()
+	if err != nil {
+		return "", xerrors.Errorf("get home dir: %w", err)
+	}
+	return home, nil
+}
+
+func convertFileInfo(path string, info os.FileInfo) codersdk.WorkspaceAgentFileInfo {
+	return codersdk.WorkspaceAgentFileInfo{
+		Name:    info.Name(),
+		Path:    path,
+		Size:    info.Size(),
+		Mode:    uint32(info.Mode()),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+}
+
+func writeFileError(rw http.ResponseWriter, r *http.Request, path string, err error) {
+	status := http.StatusInternalServerError
+	message := "Internal error accessing file."
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		status = http.StatusNotFound
+		message = "File not found."
+	case errors.Is(err, os.ErrPermission):
+		status = http.StatusForbidden
+		message = "Permission denied."
+	}
+	httpapi.Write(r.Context(), rw, status, codersdk.Response{
+		Message: message,
+		Detail:  xerrors.Errorf("%s: %w", path, err).Error(),
+	})
+}
@@ -0,0 +1,171 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+)
+
+const (
+	// metadataLimit is the maximum number of metadata items collected
+	// by an agent.
+	metadataLimit = 128
+	// metadataOutputLimit is the maximum number of bytes of output kept
+	// from a metadata script.
+	metadataOutputLimit = 10 << 10
+	// defaultMetadataTimeout is used when a metadata item does not
+	// specify a timeout.
+	defaultMetadataTimeout = 10 * time.Second
+	// metadataTickInterval is how often the agent checks whether any
+	// metadata item is due to be collected.
+	metadataTickInterval = time.Second
+	// metadataWaitDelay is how long a metadata script's output is read
+	// for after the script is killed, in case a background process it
+	// started still holds stdout.
+	metadataWaitDelay = time.Second
+)
+
+type metadataResult struct {
+	key    string
+	result agentsdk.PostMetadataRequest
+}
+
+// reportMetadataLoop runs the metadata scripts defined on the agent and
+// reports the results to coderd. The set of metadata items is read from
+// the latest metadata, so changes are picked up after a reconnection.
+func (a *agent) reportMetadataLoop(ctx context.Context) {
+	var (
+		ticker  = time.NewTicker(metadataTickInterval)
+		results = make(chan metadataResult, metadataLimit)
+		// lastCollected tracks when each item was last started, so the
+		// interval does not drift with the runtime of the script.
+		lastCollected = make(map[string]time.Time)
+		// running prevents a slow script from being started again
+		// before the previous run has finished.
+		running = make(map[string]struct{})
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case mr := <-results:
+			delete(running, mr.key)
+			err := a.client.PostMetadata(ctx, mr.key, mr.result)
+			if err != nil && ctx.Err() == nil {
+				a.logger.Error(ctx, "report metadata", slog.F("key", mr.key), slog.Error(err))
+			}
+			continue
+		case <-ticker.C:
+		}
+
+		metadata, ok := a.metadata.Load().(agentsdk.Metadata)
+		if !ok {
+			continue
+		}
+		if len(metadata.Metadata) > metadataLimit {
+			a.logger.Error(ctx, "metadata limit exceeded",
+				slog.F("limit", metadataLimit), slog.F("got", len(metadata.Metadata)),
+			)
+			continue
+		}
+
+		for _, md := range metadata.Metadata {
+			if _, ok := running[md.Key]; ok {
+				continue
+			}
+			if last, ok := lastCollected[md.Key]; ok {
+				// An interval of zero means the item is only
+				// collected once.
+				if md.Interval <= 0 || time.Since(last) < time.Duration(md.Interval)*time.Second {
+					continue
+				}
+			}
+
+			md := md
+			running[md.Key] = struct{}{}
+			lastCollected[md.Key] = time.Now()
+			err := a.trackConnGoroutine(func() {
+				result := a.collectMetadata(ctx, md)
+				select {
+				case results <- metadataResult{key: md.Key, result: result}:
+				case <-ctx.Done():
+				}
+			})
+			if err != nil {
+				// The agent is closing.
+				return
+			}
+		}
+	}
+}
+
+// collectMetadata runs the script of a metadata item and returns its
+// trimmed output. Failures are reported in the result rather than returned,
+// so they can be shown to the user.
+func (a *agent) collectMetadata(ctx context.Context, md codersdk.WorkspaceAgentMetadataDescription) agentsdk.PostMetadataRequest {
+	timeout := time.Duration(md.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultMetadataTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd, err := a.createCommand(ctx, md.Script, nil)
+	if err != nil {
+		return agentsdk.PostMetadataRequest{
+			CollectedAt: time.Now(),
+			Error:       fmt.Sprintf("create command: %s", err),
+		}
+	}
+	cmd.Stdout = &limitedWriter{w: &out, n: metadataOutputLimit}
+	cmd.Stderr = cmd.Stdout
+	cmd.WaitDelay = metadataWaitDelay
+	err = cmd.Run()
+
+	result := agentsdk.PostMetadataRequest{
+		CollectedAt: time.Now(),
+		Value:       strings.TrimSpace(out.String()),
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Error = fmt.Sprintf("script timed out after %s", timeout)
+	case err != nil:
+		result.Error = fmt.Sprintf("run script: %s", err)
+	}
+	return result
+}
+
+// limitedWriter discards everything written after the first n bytes.
+// Writes never fail, so commands with a lot of output are not killed by
+// a broken pipe.
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.n <= 0 {
+		return len(p), nil
+	}
+	b := p
+	if len(b) > l.n {
+		b = b[:l.n]
+	}
+	n, err := l.w.Write(b)
+	l.n -= n
+	if err != nil {
+		return n, err
+	}
+	return len(p), nil
+}
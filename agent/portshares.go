@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coder/retry"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/codersdk/agentsdk"
+)
+
+// portAllowed returns whether a port of the workspace may be dialed through
+// the agent. When the template defines port sharing rules, only shared ports
+// and the ports of apps may be dialed.
+func (a *agent) portAllowed(port uint16) bool {
+	shares := a.portShares.Load()
+	if shares == nil || !shares.Restricted {
+		return true
+	}
+	if _, ok := shares.ShareLevel(port); ok {
+		return true
+	}
+	metadata, ok := a.metadata.Load().(agentsdk.Metadata)
+	if !ok {
+		return false
+	}
+	for _, app := range metadata.Apps {
+		if appPort(app.URL) == port {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocalHost returns whether a forwarded host refers to the workspace
+// itself, so port sharing rules apply to it.
+func isLocalHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// forwardTCPCallback rejects connections to ports that may not be dialed.
+// Ports the agent listens on in tailnet are always allowed.
+func (a *agent) forwardTCPCallback(conn net.Conn, listenerExists bool) net.Conn {
+	if listenerExists {
+		return conn
+	}
+	_, portStr, err := net.SplitHostPort(conn.LocalAddr().String())
+	if err == nil {
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err == nil && a.portAllowed(uint16(port)) {
+			return conn
+		}
+	}
+	a.logger.Debug(context.Background(), "rejected connection to port that isn't shared",
+		slog.F("local_addr", conn.LocalAddr().String()),
+		slog.F("remote_addr", conn.RemoteAddr().String()),
+	)
+	_ = conn.Close()
+	return nil
+}
+
+// watchPortShares updates the shared ports when they're changed in coderd
+// until the context is canceled.
+func (a *agent) watchPortShares(ctx context.Context) {
+	for r := retry.New(100*time.Millisecond, 10*time.Second); r.Wait(ctx); {
+		sharesChan, err := a.client.WatchPortShares(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				a.logger.Warn(ctx, "failed to watch port shares", slog.Error(err))
+			}
+			continue
+		}
+		for shares := range sharesChan {
+			shares := shares
+			a.portShares.Store(&shares)
+		}
+	}
+}
+
+// appPort returns the port of an app URL, or 0 if there isn't one.
+func appPort(rawURL string) uint16 {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	portStr := u.Port()
+	if portStr == "" {
+		switch u.Scheme {
+		case "http":
+			return 80
+		case "https":
+			return 443
+		default:
+			return 0
+		}
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return 0
+	}
+	return uint16(port)
+}
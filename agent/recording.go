@@ -0,0 +1,258 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/afero"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/retry"
+)
+
+// sessionRecordingPattern matches the files sessions are recorded to in the
+// temp directory until they're uploaded.
+const sessionRecordingPattern = "coder-session-recording-*.cast"
+
+// sessionRecorder records a PTY session in asciicast v2 format. Output is
+// recorded as is, but only the timing of input is recorded so secrets typed
+// into the session (e.g. passwords at a prompt) aren't stored.
+// See https://docs.asciinema.org/manual/asciicast/v2/
+//
+// A recording stops growing once it reaches the largest size coderd accepts.
+type sessionRecorder struct {
+	fs          afero.Fs
+	sessionType codersdk.WorkspaceSessionType
+	startedAt   time.Time
+
+	mu        sync.Mutex // Protects following.
+	file      afero.File
+	size      int64
+	truncated bool
+	endedAt   time.Time
+	// partial holds the start of a UTF-8 sequence the last output ended in,
+	// so the sequence isn't split across events.
+	partial []byte
+}
+
+func newSessionRecorder(fs afero.Fs, dir string, sessionType codersdk.WorkspaceSessionType, width, height uint16, term string) (*sessionRecorder, error) {
+	file, err := afero.TempFile(fs, dir, sessionRecordingPattern)
+	if err != nil {
+		return nil, xerrors.Errorf("create recording file: %w", err)
+	}
+	r := &sessionRecorder{
+		fs:          fs,
+		sessionType: sessionType,
+		startedAt:   time.Now(),
+		file:        file,
+	}
+	header, err := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": r.startedAt.Unix(),
+		"env": map[string]string{
+			"TERM": term,
+		},
+	})
+	if err != nil {
+		_ = r.remove()
+		return nil, xerrors.Errorf("marshal header: %w", err)
+	}
+	err = r.writeLine(header)
+	if err != nil {
+		_ = r.remove()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write records output of the session. It never fails, so the recorder can
+// be used with io.TeeReader without affecting the session.
+func (r *sessionRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	output := append(r.partial, p...)
+	output, r.partial = splitIncompleteRune(output)
+	if len(output) > 0 {
+		r.event("o", string(output))
+	}
+	return len(p), nil
+}
+
+// Input returns a writer that records when input is sent to the session,
+// but not what was sent.
+func (r *sessionRecorder) Input() io.Writer {
+	return sessionRecorderInput{r}
+}
+
+// Resize records that the terminal of the session was resized.
+func (r *sessionRecorder) Resize(width, height uint16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+// Close ends the recording. The recording can be uploaded afterwards.
+func (r *sessionRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.endedAt.IsZero() {
+		return nil
+	}
+	if len(r.partial) > 0 {
+		r.event("o", string(r.partial))
+		r.partial = nil
+	}
+	r.endedAt = time.Now()
+	return r.file.Close()
+}
+
+// upload sends the recording to coderd. The recording must be closed.
+func (r *sessionRecorder) upload(ctx context.Context, client Client) error {
+	file, err := r.fs.Open(r.file.Name())
+	if err != nil {
+		return xerrors.Errorf("open recording: %w", err)
+	}
+	defer file.Close()
+	return client.PostSessionRecording(ctx, agentsdk.PostSessionRecordingRequest{
+		Type:      r.sessionType,
+		StartedAt: r.startedAt,
+		EndedAt:   r.endedAt,
+		Recording: file,
+	})
+}
+
+func (r *sessionRecorder) remove() error {
+	_ = r.file.Close()
+	return r.fs.Remove(r.file.Name())
+}
+
+// event writes an event to the recording. mu must be held.
+func (r *sessionRecorder) event(code, data string) {
+	if r.truncated || !r.endedAt.IsZero() {
+		return
+	}
+	elapsed := float64(time.Since(r.startedAt).Microseconds()) / float64(time.Second/time.Microsecond)
+	line, err := json.Marshal([]any{elapsed, code, data})
+	if err != nil {
+		return
+	}
+	if r.size+int64(len(line))+1 > agentsdk.MaxSessionRecordingSize {
+		r.truncated = true
+		return
+	}
+	// A failed write leaves a partial line, so stop recording rather than
+	// corrupt the events that follow.
+	if r.writeLine(line) != nil {
+		r.truncated = true
+	}
+}
+
+func (r *sessionRecorder) writeLine(line []byte) error {
+	n, err := r.file.Write(append(line, '\n'))
+	r.size += int64(n)
+	if err != nil {
+		return xerrors.Errorf("write recording: %w", err)
+	}
+	return nil
+}
+
+type sessionRecorderInput struct {
+	recorder *sessionRecorder
+}
+
+func (i sessionRecorderInput) Write(p []byte) (int, error) {
+	i.recorder.mu.Lock()
+	defer i.recorder.mu.Unlock()
+	i.recorder.event("i", "")
+	return len(p), nil
+}
+
+// splitIncompleteRune splits an incomplete UTF-8 sequence off the end of p.
+func splitIncompleteRune(p []byte) (complete, rest []byte) {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(p[i]) {
+			continue
+		}
+		if utf8.FullRune(p[i:]) {
+			break
+		}
+		return p[:i], append([]byte(nil), p[i:]...)
+	}
+	return p, nil
+}
+
+// startSessionRecording starts recording a session if recording is enabled
+// for the agent. It returns nil if it isn't.
+func (a *agent) startSessionRecording(ctx context.Context, logger slog.Logger, sessionType codersdk.WorkspaceSessionType, width, height uint16, term string) *sessionRecorder {
+	metadata, ok := a.metadata.Load().(agentsdk.Metadata)
+	if !ok || !metadata.SessionRecording {
+		return nil
+	}
+	recorder, err := newSessionRecorder(a.filesystem, a.tempDir, sessionType, width, height, term)
+	if err != nil {
+		// Recording is for auditing, so the session goes on without it
+		// rather than fail for the user.
+		logger.Error(ctx, "start session recording", slog.Error(err))
+		return nil
+	}
+	return recorder
+}
+
+// uploadSessionRecording ends a recording and uploads it in the background,
+// retrying until it succeeds or the agent closes. Recordings that couldn't
+// be uploaded before the agent closed are left in the temp directory.
+//
+// The upload isn't tracked as a connection goroutine because sessions can
+// end while the agent is closing, which waits for those to complete.
+func (a *agent) uploadSessionRecording(logger slog.Logger, recorder *sessionRecorder) {
+	if recorder == nil {
+		return
+	}
+	logger = logger.With(slog.F("recording", recorder.file.Name()))
+	err := recorder.Close()
+	if err != nil {
+		logger.Error(context.Background(), "close session recording", slog.Error(err))
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-a.closed:
+				cancel()
+			}
+		}()
+		for retrier := retry.New(time.Second, 30*time.Second); retrier.Wait(ctx); {
+			err := recorder.upload(ctx, a.client)
+			var sdkErr *codersdk.Error
+			if errors.As(err, &sdkErr) && sdkErr.StatusCode() < http.StatusInternalServerError {
+				// Retrying won't make coderd accept the recording.
+				logger.Error(ctx, "upload session recording", slog.Error(err))
+				err = nil
+			}
+			if err == nil {
+				err = recorder.remove()
+				if err != nil {
+					logger.Warn(ctx, "remove session recording", slog.Error(err))
+				}
+				return
+			}
+			logger.Warn(ctx, "upload session recording", slog.Error(err))
+		}
+		logger.Warn(ctx, "agent closed before session recording was uploaded")
+	}()
+}
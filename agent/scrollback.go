@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/armon/circbuf"
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/codersdk"
+)
+
+// reconnectingPTYScrollbackPattern matches the files scrollback is persisted
+// to in the log directory.
+const reconnectingPTYScrollbackPattern = "coder-reconnecting-pty-*.log"
+
+func reconnectingPTYScrollbackPath(logDir string, id uuid.UUID) string {
+	return filepath.Join(logDir, fmt.Sprintf("coder-reconnecting-pty-%s.log", id))
+}
+
+// reconnectingPTYScrollback persists the output of a reconnecting PTY so the
+// scrollback of a session survives agent restarts. The file starts with the
+// offset of the first byte of output it holds, followed by the output.
+//
+// Output is appended as it arrives. Once the file holds twice the output the
+// circular buffer keeps, it's rewritten with only the buffered output.
+type reconnectingPTYScrollback struct {
+	fs      afero.Fs
+	path    string
+	file    afero.File
+	size    int64
+	maxSize int64
+}
+
+// openReconnectingPTYScrollback restores the output persisted for a session
+// into buffer and returns the offset after the restored output. Sessions
+// without persisted output start at offset zero.
+func openReconnectingPTYScrollback(fs afero.Fs, path string, buffer *circbuf.Buffer) (*reconnectingPTYScrollback, int64, error) {
+	var written int64
+	data, err := afero.ReadFile(fs, path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, 0, xerrors.Errorf("read scrollback: %w", err)
+	}
+	if len(data) >= codersdk.ReconnectingPTYOffsetSize {
+		written = int64(binary.LittleEndian.Uint64(data))
+		data = data[codersdk.ReconnectingPTYOffsetSize:]
+		_, _ = buffer.Write(data)
+		written += int64(len(data))
+	}
+
+	scrollback := &reconnectingPTYScrollback{
+		fs:      fs,
+		path:    path,
+		maxSize: 2 * buffer.Size(),
+	}
+	err = scrollback.compact(buffer.Bytes(), written)
+	if err != nil {
+		return nil, 0, err
+	}
+	return scrollback, written, nil
+}
+
+// write persists output that has just been written to the circular buffer.
+// buffered is the content of the buffer after the write, and written the
+// offset after it.
+func (s *reconnectingPTYScrollback) write(output, buffered []byte, written int64) error {
+	if s.size+int64(len(output)) > s.maxSize {
+		return s.compact(buffered, written)
+	}
+	n, err := s.file.Write(output)
+	s.size += int64(n)
+	if err != nil {
+		return xerrors.Errorf("write scrollback: %w", err)
+	}
+	return nil
+}
+
+// compact replaces the file with the buffered output. The output is written
+// to a temporary file first so a crash never leaves a partial file behind.
+func (s *reconnectingPTYScrollback) compact(buffered []byte, written int64) error {
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+
+	data := make([]byte, codersdk.ReconnectingPTYOffsetSize, codersdk.ReconnectingPTYOffsetSize+len(buffered))
+	binary.LittleEndian.PutUint64(data, uint64(written-int64(len(buffered))))
+	data = append(data, buffered...)
+	err := afero.WriteFile(s.fs, s.path+".tmp", data, 0o600)
+	if err != nil {
+		return xerrors.Errorf("write scrollback: %w", err)
+	}
+	err = s.fs.Rename(s.path+".tmp", s.path)
+	if err != nil {
+		return xerrors.Errorf("rename scrollback: %w", err)
+	}
+
+	s.file, err = s.fs.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return xerrors.Errorf("open scrollback: %w", err)
+	}
+	s.size = int64(len(buffered))
+	return nil
+}
+
+// touch marks the scrollback as in use, so it isn't considered stale while
+// a client is connected to an idle session.
+func (s *reconnectingPTYScrollback) touch() {
+	now := time.Now()
+	_ = s.fs.Chtimes(s.path, now, now)
+}
+
+// Close closes the file, leaving the persisted output in place.
+func (s *reconnectingPTYScrollback) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// Remove closes and deletes the file.
+func (s *reconnectingPTYScrollback) Remove() error {
+	_ = s.Close()
+	err := s.fs.Remove(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// removeStaleReconnectingPTYScrollback deletes scrollback persisted for
+// sessions that haven't been used within maxAge. These sessions would have
+// timed out if the agent had kept running.
+func removeStaleReconnectingPTYScrollback(fs afero.Fs, logDir string, maxAge time.Duration) error {
+	paths, err := afero.Glob(fs, filepath.Join(logDir, reconnectingPTYScrollbackPattern))
+	if err != nil {
+		return xerrors.Errorf("glob scrollback: %w", err)
+	}
+	for _, path := range paths {
+		info, err := fs.Stat(path)
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		err = fs.Remove(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return xerrors.Errorf("remove %q: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -35,6 +35,8 @@ func (r *RootCmd) workspaceAgent() *clibase.Cmd {
 		pprofAddress  string
 		noReap        bool
 		sshMaxTimeout time.Duration
+
+		persistReconnectingPTYScrollback bool
 	)
 	cmd := &clibase.Cmd{
 		Use:   "agent",
@@ -206,6 +208,8 @@ func (r *RootCmd) workspaceAgent() *clibase.Cmd {
 				},
 				AgentPorts:    agentPorts,
 				SSHMaxTimeout: sshMaxTimeout,
+
+				PersistReconnectingPTYScrollback: persistReconnectingPTYScrollback,
 			})
 			<-ctx.Done()
 			return closer.Close()
@@ -248,6 +252,12 @@ func (r *RootCmd) workspaceAgent() *clibase.Cmd {
 			Description: "Specify the max timeout for a SSH connection.",
 			Value:       clibase.DurationOf(&sshMaxTimeout),
 		},
+		{
+			Flag:        "persist-reconnecting-pty-scrollback",
+			Env:         "CODER_AGENT_PERSIST_RECONNECTING_PTY_SCROLLBACK",
+			Description: "Write the output of web terminal sessions to the log directory, so it's restored when reconnecting after the agent restarts.",
+			Value:       clibase.BoolOf(&persistReconnectingPTYScrollback),
+		},
 	}
 
 	return cmd
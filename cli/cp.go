@@ -0,0 +1,297 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func (r *RootCmd) cp() *clibase.Cmd {
+	var recursive bool
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Annotations: workspaceCommand,
+		Use:         "cp <source> <destination>",
+		Short:       "Copy files to or from a workspace",
+		Long: "Exactly one of source and destination must be a path in a workspace, " +
+			"in the form <workspace>[.<agent>]:<path>. Workspace paths are resolved " +
+			"against the agent directory and must stay within it, so absolute paths " +
+			"and ~ only work if they are inside the agent directory. Use - to read " +
+			"from stdin or write to stdout.\n\n" + formatExamples(
+			example{
+				Description: "Copy a file to the agent directory of a workspace",
+				Command:     "coder cp ./main.go my-workspace:",
+			},
+			example{
+				Description: "Copy a directory from a workspace",
+				Command:     "coder cp -r my-workspace:project/build ./build",
+			},
+			example{
+				Description: "Print a file in a workspace",
+				Command:     "coder cp my-workspace:project/README.md -",
+			},
+		),
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx, cancel := context.WithCancel(inv.Context())
+			defer cancel()
+
+			src, dst := parseRemotePath(inv.Args[0]), parseRemotePath(inv.Args[1])
+			if src.remote() == dst.remote() {
+				return xerrors.New("exactly one of source and destination must be a workspace path, e.g. my-workspace:file")
+			}
+			remote := src
+			if dst.remote() {
+				remote = dst
+			}
+
+			conn, err := r.dialWorkspaceAgentForFiles(ctx, inv, client, remote.workspace)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			if dst.remote() {
+				return uploadPath(ctx, inv, conn, src.path, dst.path, recursive)
+			}
+			return downloadPath(ctx, inv, conn, src.path, dst.path, recursive)
+		},
+	}
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:          "recursive",
+			FlagShorthand: "r",
+			Description:   "Copy directories recursively.",
+			Value:         clibase.BoolOf(&recursive),
+		},
+	}
+	return cmd
+}
+
+// remotePath is a path that is either local, or in a workspace if workspace
+// is set.
+type remotePath struct {
+	workspace string
+	path      string
+}
+
+func (p remotePath) remote() bool {
+	return p.workspace != ""
+}
+
+// parseRemotePath parses paths in the form <workspace>[.<agent>]:<path>.
+// Anything else, including Windows paths with a volume name, is considered
+// a local path.
+func parseRemotePath(arg string) remotePath {
+	if filepath.VolumeName(arg) != "" {
+		return remotePath{path: arg}
+	}
+	workspace, p, ok := strings.Cut(arg, ":")
+	if !ok || workspace == "" || strings.ContainsAny(workspace, `/\`) {
+		return remotePath{path: arg}
+	}
+	return remotePath{workspace: workspace, path: p}
+}
+
+// dialWorkspaceAgentForFiles waits for the agent of the given workspace to
+// connect and dials it.
+func (r *RootCmd) dialWorkspaceAgentForFiles(ctx context.Context, inv *clibase.Invocation, client *codersdk.Client, workspaceName string) (*codersdk.WorkspaceAgentConn, error) {
+	workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, inv, client, codersdk.Me, workspaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cliui.Agent(ctx, inv.Stderr, cliui.AgentOptions{
+		WorkspaceName: workspace.Name,
+		Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+			return client.WorkspaceAgent(ctx, workspaceAgent.ID)
+		},
+	})
+	if err != nil && !xerrors.Is(err, cliui.AgentStartError) {
+		return nil, xerrors.Errorf("await agent: %w", err)
+	}
+
+	logger, ok := LoggerFromContext(ctx)
+	if !ok {
+		logger = slog.Make(sloghuman.Sink(inv.Stderr))
+	}
+	if r.verbose {
+		logger = logger.Leveled(slog.LevelDebug)
+	}
+	conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, &codersdk.DialWorkspaceAgentOptions{
+		Logger: logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !conn.AwaitReachable(ctx) {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("workspace agent not reachable in time: %v", ctx.Err())
+	}
+	return conn, nil
+}
+
+func uploadPath(ctx context.Context, inv *clibase.Invocation, conn *codersdk.WorkspaceAgentConn, local, remote string, recursive bool) error {
+	if local == "-" {
+		_, err := conn.WriteFile(ctx, remote, 0, inv.Stdin)
+		return err
+	}
+
+	info, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() && !recursive {
+		return xerrors.Errorf("%q is a directory, use --recursive to copy it", local)
+	}
+
+	// Like cp, copy into the destination if it is an existing directory.
+	target := remote
+	remoteInfo, err := conn.StatFile(ctx, remote)
+	switch {
+	case err == nil && remoteInfo.IsDir:
+		target = path.Join(remoteInfo.Path, filepath.Base(local))
+	case err != nil && !isNotFound(err):
+		return err
+	}
+
+	if !info.IsDir() {
+		return uploadFile(ctx, conn, local, target, info.Mode())
+	}
+	return filepath.WalkDir(local, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(local, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return uploadFile(ctx, conn, p, path.Join(target, filepath.ToSlash(rel)), info.Mode())
+	})
+}
+
+func uploadFile(ctx context.Context, conn *codersdk.WorkspaceAgentConn, local, remote string, mode os.FileMode) error {
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = conn.WriteFile(ctx, remote, mode.Perm(), f)
+	if err != nil {
+		return xerrors.Errorf("write %q: %w", remote, err)
+	}
+	return nil
+}
+
+func downloadPath(ctx context.Context, inv *clibase.Invocation, conn *codersdk.WorkspaceAgentConn, remote, local string, recursive bool) error {
+	info, err := conn.StatFile(ctx, remote)
+	if err != nil {
+		return err
+	}
+	if info.IsDir && !recursive {
+		return xerrors.Errorf("%q is a directory, use --recursive to copy it", info.Path)
+	}
+
+	if local == "-" {
+		if info.IsDir {
+			return xerrors.Errorf("cannot write directory %q to stdout", info.Path)
+		}
+		rc, err := conn.ReadFile(ctx, info.Path)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		_, err = io.Copy(inv.Stdout, rc)
+		return err
+	}
+
+	// Like cp, copy into the destination if it is an existing directory.
+	target := local
+	if localInfo, err := os.Stat(local); err == nil && localInfo.IsDir() {
+		target = filepath.Join(local, info.Name)
+	}
+	if !info.IsDir {
+		return downloadFile(ctx, conn, info, target)
+	}
+	return downloadDir(ctx, conn, info.Path, target)
+}
+
+func downloadDir(ctx context.Context, conn *codersdk.WorkspaceAgentConn, remote, local string) error {
+	list, err := conn.ListFiles(ctx, remote)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(local, 0o755)
+	if err != nil {
+		return err
+	}
+	for _, file := range list.Files {
+		target := filepath.Join(local, file.Name)
+		mode := file.FileMode()
+		switch {
+		case file.IsDir:
+			err = downloadDir(ctx, conn, file.Path, target)
+		case mode.IsRegular() || mode&os.ModeSymlink != 0:
+			err = downloadFile(ctx, conn, file, target)
+		default:
+			// Skip devices, sockets and named pipes.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downloadFile(ctx context.Context, conn *codersdk.WorkspaceAgentConn, file codersdk.WorkspaceAgentFileInfo, local string) error {
+	rc, err := conn.ReadFile(ctx, file.Path)
+	if err != nil {
+		return xerrors.Errorf("read %q: %w", file.Path, err)
+	}
+	defer rc.Close()
+
+	mode := file.FileMode().Perm()
+	if mode == 0 {
+		mode = 0o644
+	}
+	f, err := os.OpenFile(local, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, rc)
+	closeErr := f.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func isNotFound(err error) bool {
+	var sdkErr *codersdk.Error
+	return errors.As(err, &sdkErr) && sdkErr.StatusCode() == http.StatusNotFound
+}
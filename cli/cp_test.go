@@ -0,0 +1,148 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestCp(t *testing.T) {
+	t.Parallel()
+
+	// setup starts an agent rooted at the returned directory.
+	setup := func(t *testing.T) (*codersdk.Client, codersdk.Workspace, string) {
+		dir := t.TempDir()
+		client, workspace, agentToken := setupWorkspaceForAgent(t, func(a []*proto.Agent) []*proto.Agent {
+			a[0].Directory = dir
+			return a
+		})
+		agentClient := agentsdk.New(client.URL)
+		agentClient.SetSessionToken(agentToken)
+		agentCloser := agent.New(agent.Options{
+			Client: agentClient,
+			Logger: slogtest.Make(t, nil).Named("agent"),
+		})
+		t.Cleanup(func() {
+			_ = agentCloser.Close()
+		})
+		coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+		return client, workspace, dir
+	}
+
+	t.Run("Upload", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspace, remoteDir := setup(t)
+		localDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(localDir, "src", "nested"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(localDir, "src", "a.txt"), []byte("a"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(localDir, "src", "nested", "b.txt"), []byte("b"), 0o600))
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		inv, root := clitest.New(t, "cp", "-r", filepath.Join(localDir, "src"), workspace.Name+":")
+		clitest.SetupConfig(t, client, root)
+		err := inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filepath.Join(remoteDir, "src", "a.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "a", string(content))
+		content, err = os.ReadFile(filepath.Join(remoteDir, "src", "nested", "b.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "b", string(content))
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspace, remoteDir := setup(t)
+		require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "file.txt"), []byte("hello"), 0o600))
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		localPath := filepath.Join(t.TempDir(), "file.txt")
+		inv, root := clitest.New(t, "cp", workspace.Name+":file.txt", localPath)
+		clitest.SetupConfig(t, client, root)
+		err := inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(localPath)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(content))
+
+		// A directory requires --recursive.
+		inv, root = clitest.New(t, "cp", workspace.Name+":", t.TempDir())
+		clitest.SetupConfig(t, client, root)
+		err = inv.WithContext(ctx).Run()
+		require.ErrorContains(t, err, "is a directory")
+	})
+
+	t.Run("Stdout", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspace, remoteDir := setup(t)
+		require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "file.txt"), []byte("hello"), 0o600))
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		inv, root := clitest.New(t, "cp", workspace.Name+":file.txt", "-")
+		clitest.SetupConfig(t, client, root)
+		var stdout bytes.Buffer
+		inv.Stdout = &stdout
+		err := inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+		require.Equal(t, "hello", stdout.String())
+	})
+
+	t.Run("FilesList", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspace, remoteDir := setup(t)
+		require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "file.txt"), []byte("hello"), 0o600))
+		require.NoError(t, os.Mkdir(filepath.Join(remoteDir, "dir"), 0o755))
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		inv, root := clitest.New(t, "files", "ls", workspace.Name+":")
+		clitest.SetupConfig(t, client, root)
+		var stdout bytes.Buffer
+		inv.Stdout = &stdout
+		err := inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+		require.Contains(t, stdout.String(), "dir/")
+		require.Contains(t, stdout.String(), "file.txt")
+
+		inv, root = clitest.New(t, "files", "ls", workspace.Name+":", "-o", "json")
+		clitest.SetupConfig(t, client, root)
+		stdout.Reset()
+		inv.Stdout = &stdout
+		err = inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+		var files []codersdk.WorkspaceAgentFileInfo
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &files))
+		require.Len(t, files, 2)
+		require.Equal(t, "dir", files[0].Name)
+		require.True(t, files[0].IsDir)
+		require.Equal(t, "file.txt", files[1].Name)
+		require.EqualValues(t, 5, files[1].Size)
+	})
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func (r *RootCmd) files() *clibase.Cmd {
+	cmd := &clibase.Cmd{
+		Annotations: workspaceCommand,
+		Use:         "files",
+		Short:       "Manage files in a workspace",
+		Long: "Workspace paths are given in the form <workspace>[.<agent>]:<path>, and are " +
+			"resolved against the agent directory. Use \"coder cp\" to copy files.\n\n" + formatExamples(
+			example{
+				Description: "List files in the agent directory of a workspace",
+				Command:     "coder files ls my-workspace:",
+			},
+			example{
+				Description: "List files in a directory of a workspace",
+				Command:     "coder files ls my-workspace:.config",
+			},
+		),
+		Aliases: []string{"file"},
+		Handler: func(inv *clibase.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*clibase.Cmd{
+			r.listFiles(),
+		},
+	}
+	return cmd
+}
+
+// fileListRow is the type provided to the OutputFormatter when listing files
+// in a workspace.
+type fileListRow struct {
+	// For JSON format:
+	codersdk.WorkspaceAgentFileInfo `table:"-"`
+
+	// For table format:
+	Mode     string `json:"-" table:"mode"`
+	Size     int64  `json:"-" table:"size"`
+	Modified string `json:"-" table:"modified"`
+	Name     string `json:"-" table:"name,default_sort"`
+}
+
+func (r *RootCmd) listFiles() *clibase.Cmd {
+	formatter := cliui.NewOutputFormatter(
+		cliui.TableFormat([]fileListRow{}, nil),
+		cliui.JSONFormat(),
+	)
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:     "list <workspace>:<path>",
+		Short:   "List files in a directory of a workspace",
+		Aliases: []string{"ls"},
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx := inv.Context()
+			target := parseRemotePath(inv.Args[0])
+			if !target.remote() {
+				return xerrors.Errorf("%q is not a workspace path, e.g. my-workspace:project", inv.Args[0])
+			}
+			conn, err := r.dialWorkspaceAgentForFiles(ctx, inv, client, target.workspace)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			list, err := conn.ListFiles(ctx, target.path)
+			if err != nil {
+				return err
+			}
+			rows := make([]fileListRow, 0, len(list.Files))
+			for _, file := range list.Files {
+				name := file.Name
+				if file.IsDir {
+					name += "/"
+				}
+				rows = append(rows, fileListRow{
+					WorkspaceAgentFileInfo: file,
+					Mode:                   file.FileMode().String(),
+					Size:                   file.Size,
+					Modified:               file.ModTime.Local().Format(time.Stamp),
+					Name:                   name,
+				})
+			}
+
+			out, err := formatter.Format(ctx, rows)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(inv.Stdout, out)
+			return err
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
@@ -12,7 +12,9 @@ import (
 	"syscall"
 
 	"github.com/pion/udp"
+	gossh "golang.org/x/crypto/ssh"
 	"golang.org/x/xerrors"
+	"tailscale.com/net/socks5"
 
 	"github.com/coder/coder/agent"
 	"github.com/coder/coder/cli/clibase"
@@ -22,8 +24,10 @@ import (
 
 func (r *RootCmd) portForward() *clibase.Cmd {
 	var (
-		tcpForwards []string // <port>:<port>
-		udpForwards []string // <port>:<port>
+		tcpForwards  []string // <port>:<port>
+		udpForwards  []string // <port>:<port>
+		unixForwards []string // <path>:<path>
+		socks5       string   // <port> or <ip>:<port>
 	)
 	client := new(codersdk.Client)
 	cmd := &clibase.Cmd{
@@ -47,6 +51,14 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 				Description: "Port forward multiple ports (TCP or UDP) in condensed syntax",
 				Command:     "coder port-forward <workspace> --tcp 8080,9000:3000,9090-9092,10000-10002:10010-10012",
 			},
+			example{
+				Description: "Port forward the Docker socket in the workspace to a local Unix socket",
+				Command:     "coder port-forward <workspace> --unix ./docker.sock:/var/run/docker.sock",
+			},
+			example{
+				Description: "Start a SOCKS5 proxy on port 1080 that connects to any destination from within the workspace",
+				Command:     "coder port-forward <workspace> --socks5 1080",
+			},
 		),
 		Middleware: clibase.Chain(
 			clibase.RequireNArgs(1),
@@ -56,7 +68,7 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 			ctx, cancel := context.WithCancel(inv.Context())
 			defer cancel()
 
-			specs, err := parsePortForwards(tcpForwards, udpForwards)
+			specs, err := parsePortForwards(tcpForwards, udpForwards, unixForwards, socks5)
 			if err != nil {
 				return xerrors.Errorf("parse port-forward specs: %w", err)
 			}
@@ -98,6 +110,9 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 			}
 			defer conn.Close()
 
+			dialer := &workspaceDialer{conn: conn}
+			defer dialer.Close()
+
 			// Start all listeners.
 			var (
 				wg                = new(sync.WaitGroup)
@@ -114,7 +129,7 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 			defer closeAllListeners()
 
 			for i, spec := range specs {
-				l, err := listenAndPortForward(ctx, inv, conn, wg, spec)
+				l, err := listenAndPortForward(ctx, inv, dialer, wg, spec)
 				if err != nil {
 					return err
 				}
@@ -163,20 +178,36 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 			Description: "Forward UDP port(s) from the workspace to the local machine. The UDP connection has TCP-like semantics to support stateful UDP protocols.",
 			Value:       clibase.StringArrayOf(&udpForwards),
 		},
+		{
+			Flag:        "unix",
+			Env:         "CODER_PORT_FORWARD_UNIX",
+			Description: "Forward Unix socket(s) from the workspace to the local machine. Each value is a local and a remote path separated by a colon, or a single path used on both sides.",
+			Value:       clibase.StringArrayOf(&unixForwards),
+		},
+		{
+			Flag:        "socks5",
+			Env:         "CODER_PORT_FORWARD_SOCKS5",
+			Description: "Start a local SOCKS5 proxy on the given port or address. Connections made through the proxy are dialed from within the workspace.",
+			Value:       clibase.StringOf(&socks5),
+		},
 	}
 
 	return cmd
 }
 
-func listenAndPortForward(ctx context.Context, inv *clibase.Invocation, conn *codersdk.WorkspaceAgentConn, wg *sync.WaitGroup, spec portForwardSpec) (net.Listener, error) {
-	_, _ = fmt.Fprintf(inv.Stderr, "Forwarding '%v://%v' locally to '%v://%v' in the workspace\n", spec.listenNetwork, spec.listenAddress, spec.dialNetwork, spec.dialAddress)
+func listenAndPortForward(ctx context.Context, inv *clibase.Invocation, dialer *workspaceDialer, wg *sync.WaitGroup, spec portForwardSpec) (net.Listener, error) {
+	if spec.dialNetwork == "socks5" {
+		_, _ = fmt.Fprintf(inv.Stderr, "Serving SOCKS5 proxy on '%v://%v', dialing destinations from the workspace\n", spec.listenNetwork, spec.listenAddress)
+	} else {
+		_, _ = fmt.Fprintf(inv.Stderr, "Forwarding '%v://%v' locally to '%v://%v' in the workspace\n", spec.listenNetwork, spec.listenAddress, spec.dialNetwork, spec.dialAddress)
+	}
 
 	var (
 		l   net.Listener
 		err error
 	)
 	switch spec.listenNetwork {
-	case "tcp":
+	case "tcp", "unix":
 		l, err = net.Listen(spec.listenNetwork, spec.listenAddress)
 	case "udp":
 		var host, port string
@@ -202,6 +233,25 @@ func listenAndPortForward(ctx context.Context, inv *clibase.Invocation, conn *co
 		return nil, xerrors.Errorf("listen '%v://%v': %w", spec.listenNetwork, spec.listenAddress, err)
 	}
 
+	if spec.dialNetwork == "socks5" {
+		server := &socks5.Server{
+			Logf: func(format string, args ...any) {
+				_, _ = fmt.Fprintf(inv.Stderr, "SOCKS5 proxy: "+format+"\n", args...)
+			},
+			Dialer: dialer.DialContext,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := server.Serve(l)
+			// Silently ignore net.ErrClosed errors.
+			if err != nil && !xerrors.Is(err, net.ErrClosed) {
+				_, _ = fmt.Fprintf(inv.Stderr, "Error serving SOCKS5 proxy on '%v://%v': %v\n", spec.listenNetwork, spec.listenAddress, err)
+			}
+		}()
+		return l, nil
+	}
+
 	wg.Add(1)
 	go func(spec portForwardSpec) {
 		defer wg.Done()
@@ -219,7 +269,7 @@ func listenAndPortForward(ctx context.Context, inv *clibase.Invocation, conn *co
 
 			go func(netConn net.Conn) {
 				defer netConn.Close()
-				remoteConn, err := conn.DialContext(ctx, spec.dialNetwork, spec.dialAddress)
+				remoteConn, err := dialer.DialContext(ctx, spec.dialNetwork, spec.dialAddress)
 				if err != nil {
 					_, _ = fmt.Fprintf(inv.Stderr, "Failed to dial '%v://%v' in workspace: %s\n", spec.dialNetwork, spec.dialAddress, err)
 					return
@@ -234,15 +284,81 @@ func listenAndPortForward(ctx context.Context, inv *clibase.Invocation, conn *co
 	return l, nil
 }
 
+// workspaceDialer dials addresses from within the workspace. TCP and UDP
+// connections to the loopback interface are dialed over the tailnet. Unix
+// sockets and other hosts are reached through the agent's SSH server, which
+// dials on our behalf.
+type workspaceDialer struct {
+	conn *codersdk.WorkspaceAgentConn
+
+	mu        sync.Mutex
+	sshClient *gossh.Client
+}
+
+func (d *workspaceDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	switch network {
+	case "unix":
+	case "tcp":
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, xerrors.Errorf("split %q: %w", addr, err)
+		}
+		if isLoopbackHost(host) {
+			return d.conn.DialContext(ctx, network, addr)
+		}
+	default:
+		return d.conn.DialContext(ctx, network, addr)
+	}
+
+	sshClient, err := d.ssh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sshClient.Dial(network, addr)
+}
+
+// ssh returns the SSH client shared by all connections, establishing it on
+// first use.
+func (d *workspaceDialer) ssh(ctx context.Context) (*gossh.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.sshClient != nil {
+		return d.sshClient, nil
+	}
+	sshClient, err := d.conn.SSHClient(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("connect to workspace ssh: %w", err)
+	}
+	d.sshClient = sshClient
+	return sshClient, nil
+}
+
+func (d *workspaceDialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.sshClient == nil {
+		return nil
+	}
+	return d.sshClient.Close()
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 type portForwardSpec struct {
-	listenNetwork string // tcp, udp
+	listenNetwork string // tcp, udp, unix
 	listenAddress string // <ip>:<port> or path
 
-	dialNetwork string // tcp, udp
-	dialAddress string // <ip>:<port> or path
+	dialNetwork string // tcp, udp, unix, socks5
+	dialAddress string // <ip>:<port> or path, empty for socks5
 }
 
-func parsePortForwards(tcpSpecs, udpSpecs []string) ([]portForwardSpec, error) {
+func parsePortForwards(tcpSpecs, udpSpecs, unixSpecs []string, socks5Spec string) ([]portForwardSpec, error) {
 	specs := []portForwardSpec{}
 
 	for _, specEntry := range tcpSpecs {
@@ -281,6 +397,46 @@ func parsePortForwards(tcpSpecs, udpSpecs []string) ([]portForwardSpec, error) {
 		}
 	}
 
+	for _, spec := range unixSpecs {
+		local, remote, err := parseSrcDestPaths(spec)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse Unix socket port-forward specification %q: %w", spec, err)
+		}
+
+		specs = append(specs, portForwardSpec{
+			listenNetwork: "unix",
+			listenAddress: local,
+			dialNetwork:   "unix",
+			dialAddress:   remote,
+		})
+	}
+
+	if socks5Spec != "" {
+		listenAddress := socks5Spec
+		if !strings.Contains(socks5Spec, ":") {
+			port, err := parsePort(socks5Spec)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to parse SOCKS5 specification %q: %w", socks5Spec, err)
+			}
+			listenAddress = fmt.Sprintf("127.0.0.1:%v", port)
+		} else {
+			host, port, err := net.SplitHostPort(socks5Spec)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to parse SOCKS5 specification %q: %w", socks5Spec, err)
+			}
+			if _, err := parsePort(port); err != nil {
+				return nil, xerrors.Errorf("failed to parse SOCKS5 specification %q: %w", socks5Spec, err)
+			}
+			listenAddress = net.JoinHostPort(host, port)
+		}
+
+		specs = append(specs, portForwardSpec{
+			listenNetwork: "tcp",
+			listenAddress: listenAddress,
+			dialNetwork:   "socks5",
+		})
+	}
+
 	// Check for duplicate entries.
 	locals := map[string]struct{}{}
 	for _, spec := range specs {
@@ -306,6 +462,21 @@ func parsePort(in string) (uint16, error) {
 	return uint16(port), nil
 }
 
+// parseSrcDestPaths parses a Unix socket specification of the form
+// <local path>:<remote path>. A single path is used for both sides. The
+// specification is split on the last colon so local paths on Windows, which
+// may contain a drive letter, are supported.
+func parseSrcDestPaths(in string) (local string, remote string, err error) {
+	local, remote = in, in
+	if i := strings.LastIndex(in, ":"); i > 1 {
+		local, remote = in[:i], in[i+1:]
+	}
+	if strings.TrimSpace(local) == "" || strings.TrimSpace(remote) == "" {
+		return "", "", xerrors.Errorf("invalid Unix socket specification %q", in)
+	}
+	return local, remote, nil
+}
+
 type parsedSrcDestPort struct {
 	local, remote uint16
 }
@@ -13,14 +13,20 @@ func Test_parsePortForwards(t *testing.T) {
 
 	portForwardSpecToString := func(v []portForwardSpec) (out []string) {
 		for _, p := range v {
+			if p.dialNetwork == "socks5" {
+				out = append(out, fmt.Sprintf("socks5:%s", p.listenAddress))
+				continue
+			}
 			require.Equal(t, p.listenNetwork, p.dialNetwork)
 			out = append(out, fmt.Sprintf("%s:%s", strings.Replace(p.listenAddress, "127.0.0.1:", "", 1), strings.Replace(p.dialAddress, "127.0.0.1:", "", 1)))
 		}
 		return out
 	}
 	type args struct {
-		tcpSpecs []string
-		udpSpecs []string
+		tcpSpecs  []string
+		udpSpecs  []string
+		unixSpecs []string
+		socks5    string
 	}
 	tests := []struct {
 		name    string
@@ -58,6 +64,52 @@ func Test_parsePortForwards(t *testing.T) {
 				"8081:8081",
 			},
 		},
+		{
+			name: "Unix sockets",
+			args: args{
+				unixSpecs: []string{
+					"/tmp/local.sock:/var/run/remote.sock",
+					"/tmp/same.sock",
+				},
+			},
+			want: []string{
+				"/tmp/local.sock:/var/run/remote.sock",
+				"/tmp/same.sock:/tmp/same.sock",
+			},
+		},
+		{
+			name: "SOCKS5 port",
+			args: args{
+				socks5: "1080",
+			},
+			want: []string{
+				"socks5:127.0.0.1:1080",
+			},
+		},
+		{
+			name: "SOCKS5 address",
+			args: args{
+				socks5: "0.0.0.0:1080",
+			},
+			want: []string{
+				"socks5:0.0.0.0:1080",
+			},
+		},
+		{
+			name: "SOCKS5 conflicts with TCP",
+			args: args{
+				tcpSpecs: []string{"1080"},
+				socks5:   "1080",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Bad SOCKS5 port",
+			args: args{
+				socks5: "0",
+			},
+			wantErr: true,
+		},
 		{
 			name: "Bad port range",
 			args: args{
@@ -78,7 +130,7 @@ func Test_parsePortForwards(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			got, err := parsePortForwards(tt.args.tcpSpecs, tt.args.udpSpecs)
+			got, err := parsePortForwards(tt.args.tcpSpecs, tt.args.udpSpecs, tt.args.unixSpecs, tt.args.socks5)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("parsePortForwards() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"path/filepath"
+	"runtime"
 	"sync"
 	"testing"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/pion/udp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
 
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
@@ -107,6 +110,28 @@ func TestPortForward(t *testing.T) {
 			},
 		},
 	}
+	if runtime.GOOS != "windows" {
+		cases = append(cases, struct {
+			name        string
+			network     string
+			flag        string
+			setupRemote func(t *testing.T) net.Listener
+			setupLocal  func(t *testing.T) (string, string)
+		}{
+			name:    "Unix",
+			network: "unix",
+			flag:    "--unix=%v:%v",
+			setupRemote: func(t *testing.T) net.Listener {
+				l, err := net.Listen("unix", filepath.Join(t.TempDir(), "remote.sock"))
+				require.NoError(t, err, "create Unix listener")
+				return l
+			},
+			setupLocal: func(t *testing.T) (string, string) {
+				path := filepath.Join(t.TempDir(), "local.sock")
+				return path, path
+			},
+		})
+	}
 
 	// Setup agent once to be shared between test-cases (avoid expensive
 	// non-parallel setup).
@@ -214,6 +239,37 @@ func TestPortForward(t *testing.T) {
 		})
 	}
 
+	//nolint:paralleltest
+	t.Run("SOCKS5", func(t *testing.T) {
+		p := setupTestListener(t, cases[0].setupRemote(t))
+		localAddress, localFlag := cases[0].setupLocal(t)
+
+		inv, root := clitest.New(t, "-v", "port-forward", workspace.Name, "--socks5", localFlag)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t).Attach(inv)
+		inv.Stderr = pty.Output()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		errC := make(chan error)
+		go func() {
+			errC <- inv.WithContext(ctx).Run()
+		}()
+		pty.ExpectMatch("Ready!")
+
+		t.Parallel() // Port is reserved, enable parallel execution.
+
+		dialer, err := proxy.SOCKS5("tcp", localAddress, nil, &net.Dialer{Timeout: testutil.WaitShort})
+		require.NoError(t, err, "create SOCKS5 dialer")
+		c1, err := dialer.Dial("tcp", net.JoinHostPort("127.0.0.1", p))
+		require.NoError(t, err, "open connection through SOCKS5 proxy")
+		defer c1.Close()
+		testDial(t, c1)
+
+		cancel()
+		err = <-errC
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
 	// Test doing TCP and UDP at the same time.
 	//nolint:paralleltest
 	t.Run("All", func(t *testing.T) {
@@ -355,9 +411,11 @@ func setupTestListener(t *testing.T, l net.Listener) string {
 	}()
 
 	addr := l.Addr().String()
-	_, port, err := net.SplitHostPort(addr)
-	require.NoErrorf(t, err, "split non-Unix listen path %q", addr)
-	addr = port
+	if l.Addr().Network() != "unix" {
+		_, port, err := net.SplitHostPort(addr)
+		require.NoErrorf(t, err, "split non-Unix listen path %q", addr)
+		addr = port
+	}
 
 	return addr
 }
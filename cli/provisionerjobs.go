@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func (r *RootCmd) provisioners() *clibase.Cmd {
+	cmd := &clibase.Cmd{
+		Use:     "provisioner",
+		Short:   "Manage provisioners and the jobs they run",
+		Aliases: []string{"provisioners"},
+		Handler: func(inv *clibase.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*clibase.Cmd{
+			r.provisionerJobs(),
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) provisionerJobs() *clibase.Cmd {
+	cmd := &clibase.Cmd{
+		Use:   "jobs",
+		Short: "Inspect and manage the provisioner job queue",
+		Long: "Jobs wait in the queue until a provisioner daemon with matching tags acquires them. " +
+			"Workspace starts are acquired before stops, template dry-runs and template imports.\n" + formatExamples(
+			example{
+				Description: "List the jobs waiting for a provisioner daemon",
+				Command:     "coder provisioner jobs list",
+			},
+			example{
+				Description: "Move a job to the front of the queue",
+				Command:     "coder provisioner jobs bump 1f4b8c2e-7c1a-4d6e-9d2b-3a5f6e7c8d9a",
+			},
+		),
+		Aliases: []string{"job"},
+		Handler: func(inv *clibase.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*clibase.Cmd{
+			r.bumpProvisionerJob(),
+			r.cancelProvisionerJob(),
+			r.listProvisionerJobs(),
+		},
+	}
+	return cmd
+}
+
+// provisionerJobListRow is the type provided to the OutputFormatter.
+type provisionerJobListRow struct {
+	// For JSON format:
+	codersdk.QueuedProvisionerJob `table:"-"`
+
+	// For table format:
+	ID       string `json:"-" table:"id"`
+	Position int    `json:"-" table:"position,default_sort"`
+	Type     string `json:"-" table:"type"`
+	Priority int32  `json:"-" table:"priority"`
+	Tags     string `json:"-" table:"tags"`
+	Workers  int    `json:"-" table:"workers"`
+	Waiting  string `json:"-" table:"waiting"`
+}
+
+func provisionerJobListRowFromJob(job codersdk.QueuedProvisionerJob, now time.Time) provisionerJobListRow {
+	tags := make([]string, 0, len(job.Tags))
+	for key, value := range job.Tags {
+		tags = append(tags, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(tags)
+
+	return provisionerJobListRow{
+		QueuedProvisionerJob: job,
+		ID:                   job.ID.String(),
+		Position:             job.QueuePosition,
+		Type:                 string(job.Type),
+		Priority:             job.Priority,
+		Tags:                 strings.Join(tags, " "),
+		Workers:              len(job.AvailableWorkers),
+		Waiting:              now.Sub(job.CreatedAt).Truncate(time.Second).String(),
+	}
+}
+
+func (r *RootCmd) listProvisionerJobs() *clibase.Cmd {
+	formatter := cliui.NewOutputFormatter(
+		cliui.TableFormat([]provisionerJobListRow{}, []string{"id", "position", "type", "priority", "tags", "workers", "waiting"}),
+		cliui.JSONFormat(),
+	)
+
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the jobs waiting for a provisioner daemon",
+		Long: "Position counts the jobs ahead that can be acquired by the same daemons. " +
+			"Workers is the number of online daemons that can acquire the job, so jobs with none " +
+			"wait until a daemon with matching tags connects.",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(0),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			organization, err := CurrentOrganization(inv, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			jobs, err := client.ProvisionerJobQueue(inv.Context(), organization.ID)
+			if err != nil {
+				return xerrors.Errorf("get provisioner job queue: %w", err)
+			}
+
+			if len(jobs) == 0 {
+				cliui.Infof(
+					inv.Stdout,
+					"No jobs are waiting for a provisioner daemon.\n",
+				)
+				return nil
+			}
+
+			now := time.Now()
+			rows := make([]provisionerJobListRow, len(jobs))
+			for i, job := range jobs {
+				rows[i] = provisionerJobListRowFromJob(job, now)
+			}
+
+			out, err := formatter.Format(inv.Context(), rows)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(inv.Stdout, out)
+			return err
+		},
+	}
+
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+func (r *RootCmd) bumpProvisionerJob() *clibase.Cmd {
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:   "bump <job-id>",
+		Short: "Move a pending job to the front of the queue",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			jobID, err := uuid.Parse(inv.Args[0])
+			if err != nil {
+				return xerrors.Errorf("parse job id: %w", err)
+			}
+			err = client.BumpProvisionerJob(inv.Context(), jobID)
+			if err != nil {
+				return xerrors.Errorf("bump provisioner job: %w", err)
+			}
+
+			cliui.Infof(
+				inv.Stdout,
+				"Job %s has been moved to the front of the queue.\n", jobID,
+			)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) cancelProvisionerJob() *clibase.Cmd {
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:   "cancel <job-id>",
+		Short: "Cancel a pending or running job",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			jobID, err := uuid.Parse(inv.Args[0])
+			if err != nil {
+				return xerrors.Errorf("parse job id: %w", err)
+			}
+			err = client.CancelProvisionerJob(inv.Context(), jobID)
+			if err != nil {
+				return xerrors.Errorf("cancel provisioner job: %w", err)
+			}
+
+			cliui.Infof(
+				inv.Stdout,
+				"Job %s has been canceled.\n", jobID,
+			)
+			return nil
+		},
+	}
+	return cmd
+}
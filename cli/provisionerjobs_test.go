@@ -0,0 +1,76 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestProvisionerJobs(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, nil)
+	user := coderdtest.CreateFirstUser(t, client)
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+
+	// helpful empty response
+	inv, root := clitest.New(t, "provisioner", "jobs", "ls")
+	clitest.SetupConfig(t, client, root)
+	buf := new(bytes.Buffer)
+	inv.Stdout = buf
+	err := inv.WithContext(ctx).Run()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "No jobs are waiting")
+
+	// No provisioner daemons are running, so the jobs stay pending.
+	first := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+	second := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+	inv, root = clitest.New(t, "provisioner", "jobs", "ls")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	inv.Stdout = buf
+	err = inv.WithContext(ctx).Run()
+	require.NoError(t, err)
+	res := buf.String()
+	require.Contains(t, res, "POSITION")
+	require.Contains(t, res, "WORKERS")
+	require.Contains(t, res, first.Job.ID.String())
+	require.Contains(t, res, second.Job.ID.String())
+
+	inv, root = clitest.New(t, "provisioner", "jobs", "bump", second.Job.ID.String())
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	inv.Stdout = buf
+	err = inv.WithContext(ctx).Run()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "front of the queue")
+
+	inv, root = clitest.New(t, "provisioner", "jobs", "cancel", first.Job.ID.String())
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	inv.Stdout = buf
+	err = inv.WithContext(ctx).Run()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "canceled")
+
+	inv, root = clitest.New(t, "provisioner", "jobs", "ls", "--output=json")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	inv.Stdout = buf
+	err = inv.WithContext(ctx).Run()
+	require.NoError(t, err)
+
+	var jobs []codersdk.QueuedProvisionerJob
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &jobs))
+	require.Len(t, jobs, 1)
+	require.Equal(t, second.Job.ID, jobs[0].ID)
+	require.Equal(t, 1, jobs[0].QueuePosition)
+}
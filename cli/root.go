@@ -76,6 +76,7 @@ func (r *RootCmd) Core() []*clibase.Cmd {
 		r.login(),
 		r.logout(),
 		r.portForward(),
+		r.provisioners(),
 		r.publickey(),
 		r.resetPassword(),
 		r.state(),
@@ -86,6 +87,8 @@ func (r *RootCmd) Core() []*clibase.Cmd {
 
 		// Workspace Commands
 		r.configSSH(),
+		r.cp(),
+		r.files(),
 		r.rename(),
 		r.ping(),
 		r.create(),
@@ -100,6 +103,7 @@ func (r *RootCmd) Core() []*clibase.Cmd {
 		r.update(),
 		r.restart(),
 		r.parameters(),
+		r.workspaces(),
 
 		// Hidden
 		r.workspaceAgent(),
@@ -423,6 +427,17 @@ type RootCmd struct {
 // InitClient sets client to a new client.
 // It reads from global configuration files if flags are not set.
 func (r *RootCmd) InitClient(client *codersdk.Client) clibase.MiddlewareFunc {
+	return r.initClientInternal(client, false)
+}
+
+// InitClientMissingTokenOK is like InitClient, but doesn't error if the
+// session token is missing. This is for commands that can authenticate
+// in other ways.
+func (r *RootCmd) InitClientMissingTokenOK(client *codersdk.Client) clibase.MiddlewareFunc {
+	return r.initClientInternal(client, true)
+}
+
+func (r *RootCmd) initClientInternal(client *codersdk.Client, allowTokenMissing bool) clibase.MiddlewareFunc {
 	if client == nil {
 		panic("client is nil")
 	}
@@ -453,9 +468,10 @@ func (r *RootCmd) InitClient(client *codersdk.Client) clibase.MiddlewareFunc {
 				r.token, err = conf.Session().Read()
 				// If the configuration files are absent, the user is logged out
 				if os.IsNotExist(err) {
-					return (errUnauthenticated)
-				}
-				if err != nil {
+					if !allowTokenMissing {
+						return (errUnauthenticated)
+					}
+				} else if err != nil {
 					return err
 				}
 			}
@@ -466,6 +482,10 @@ func (r *RootCmd) InitClient(client *codersdk.Client) clibase.MiddlewareFunc {
 			}
 
 			client.SetSessionToken(r.token)
+			if r.token == "" {
+				// The version and entitlement checks require a session.
+				return next(i)
+			}
 
 			// We send these requests in parallel to minimize latency.
 			var (
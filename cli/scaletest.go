@@ -28,9 +28,15 @@ import (
 	"github.com/coder/coder/scaletest/harness"
 	"github.com/coder/coder/scaletest/reconnectingpty"
 	"github.com/coder/coder/scaletest/workspacebuild"
+	"github.com/coder/coder/scaletest/workspacetraffic"
 )
 
-const scaletestTracerName = "coder_scaletest"
+const (
+	scaletestTracerName = "coder_scaletest"
+	// scaletestPrometheusJob is the job name results are pushed to a
+	// Prometheus Pushgateway under.
+	scaletestPrometheusJob = "coder_scaletest"
+)
 
 func (r *RootCmd) scaletest() *clibase.Cmd {
 	cmd := &clibase.Cmd{
@@ -42,6 +48,7 @@ func (r *RootCmd) scaletest() *clibase.Cmd {
 		Children: []*clibase.Cmd{
 			r.scaletestCleanup(),
 			r.scaletestCreateWorkspaces(),
+			r.scaletestWorkspaceTraffic(),
 		},
 	}
 
@@ -119,6 +126,11 @@ type scaletestStrategyFlags struct {
 	concurrency   int64
 	timeout       time.Duration
 	timeoutPerJob time.Duration
+
+	// Open-loop strategies aren't available for cleanup.
+	arrivalRate     int64
+	rampUp          time.Duration
+	rampInitialRate int64
 }
 
 func (s *scaletestStrategyFlags) attach(opts *clibase.OptionSet) {
@@ -155,11 +167,65 @@ func (s *scaletestStrategyFlags) attach(opts *clibase.OptionSet) {
 			Value:       clibase.DurationOf(&s.timeoutPerJob),
 		},
 	)
+	if s.cleanup {
+		return
+	}
+
+	*opts = append(
+		*opts,
+		clibase.Option{
+			Flag:        "arrival-rate",
+			Env:         "CODER_SCALETEST_ARRIVAL_RATE",
+			Description: "Number of jobs to start per second, regardless of whether earlier jobs have completed. Overrides --concurrency. 0 disables.",
+			Default:     "0",
+			Value:       clibase.Int64Of(&s.arrivalRate),
+		},
+		clibase.Option{
+			Flag:        "ramp-up",
+			Env:         "CODER_SCALETEST_RAMP_UP",
+			Description: "Increase the number of jobs started per second linearly from --ramp-initial-rate to --arrival-rate over this duration. Requires --arrival-rate.",
+			Default:     "0s",
+			Value:       clibase.DurationOf(&s.rampUp),
+		},
+		clibase.Option{
+			Flag:        "ramp-initial-rate",
+			Env:         "CODER_SCALETEST_RAMP_INITIAL_RATE",
+			Description: "Number of jobs to start per second at the beginning of --ramp-up.",
+			Default:     "1",
+			Value:       clibase.Int64Of(&s.rampInitialRate),
+		},
+	)
+}
+
+func (s *scaletestStrategyFlags) validate() error {
+	if s.arrivalRate < 0 {
+		return xerrors.New("--arrival-rate must not be negative")
+	}
+	if s.rampUp < 0 {
+		return xerrors.New("--ramp-up must not be negative")
+	}
+	if s.rampUp > 0 && s.arrivalRate == 0 {
+		return xerrors.New("--ramp-up requires --arrival-rate to be set")
+	}
+	if s.rampInitialRate < 0 {
+		return xerrors.New("--ramp-initial-rate must not be negative")
+	}
+	return nil
 }
 
 func (s *scaletestStrategyFlags) toStrategy() harness.ExecutionStrategy {
 	var strategy harness.ExecutionStrategy
-	if s.concurrency == 1 {
+	if s.arrivalRate > 0 && s.rampUp > 0 {
+		strategy = harness.RampExecutionStrategy{
+			InitialRate: float64(s.rampInitialRate),
+			FinalRate:   float64(s.arrivalRate),
+			Duration:    s.rampUp,
+		}
+	} else if s.arrivalRate > 0 {
+		strategy = harness.ArrivalRateExecutionStrategy{
+			Rate: float64(s.arrivalRate),
+		}
+	} else if s.concurrency == 1 {
 		strategy = harness.LinearExecutionStrategy{}
 	} else if s.concurrency == 0 {
 		strategy = harness.ConcurrentExecutionStrategy{}
@@ -190,8 +256,13 @@ func (s *scaletestStrategyFlags) toContext(ctx context.Context) (context.Context
 type scaleTestOutputFormat string
 
 const (
-	scaleTestOutputFormatText scaleTestOutputFormat = "text"
-	scaleTestOutputFormatJSON scaleTestOutputFormat = "json"
+	scaleTestOutputFormatText  scaleTestOutputFormat = "text"
+	scaleTestOutputFormatJSON  scaleTestOutputFormat = "json"
+	scaleTestOutputFormatJUnit scaleTestOutputFormat = "junit"
+	scaleTestOutputFormatCSV   scaleTestOutputFormat = "csv"
+	// scaleTestOutputFormatPrometheus pushes the results to the Prometheus
+	// Pushgateway at the path of the output spec.
+	scaleTestOutputFormatPrometheus scaleTestOutputFormat = "prometheus"
 	// TODO: html format
 )
 
@@ -202,7 +273,11 @@ type scaleTestOutput struct {
 	path string
 }
 
-func (o *scaleTestOutput) write(res harness.Results, stdout io.Writer) error {
+func (o *scaleTestOutput) write(ctx context.Context, res harness.Results, stdout io.Writer) error {
+	if o.format == scaleTestOutputFormatPrometheus {
+		return res.PushPrometheus(ctx, o.path, scaletestPrometheusJob)
+	}
+
 	var (
 		w = stdout
 		c io.Closer
@@ -223,6 +298,16 @@ func (o *scaleTestOutput) write(res harness.Results, stdout io.Writer) error {
 		if err != nil {
 			return xerrors.Errorf("encode JSON: %w", err)
 		}
+	case scaleTestOutputFormatJUnit:
+		err := res.PrintJUnit(w)
+		if err != nil {
+			return xerrors.Errorf("write JUnit: %w", err)
+		}
+	case scaleTestOutputFormatCSV:
+		err := res.PrintCSV(w)
+		if err != nil {
+			return xerrors.Errorf("write CSV: %w", err)
+		}
 	}
 
 	// Sync the file to disk if it's a file.
@@ -253,7 +338,7 @@ func (s *scaletestOutputFlags) attach(opts *clibase.OptionSet) {
 	*opts = append(*opts, clibase.Option{
 		Flag:        "output",
 		Env:         "CODER_SCALETEST_OUTPUTS",
-		Description: `Output format specs in the format "<format>[:<path>]". Not specifying a path will default to stdout. Available formats: text, json.`,
+		Description: `Output format specs in the format "<format>[:<path>]". Not specifying a path will default to stdout. Available formats: text, json, junit, csv, prometheus. The prometheus format pushes to the Pushgateway URL given as the path, e.g. "prometheus:http://localhost:9091".`,
 		Default:     "text",
 		Value:       clibase.StringArrayOf(&s.outputSpecs),
 	})
@@ -263,8 +348,11 @@ func (s *scaletestOutputFlags) parse() ([]scaleTestOutput, error) {
 	var stdoutFormat scaleTestOutputFormat
 
 	validFormats := map[scaleTestOutputFormat]struct{}{
-		scaleTestOutputFormatText: {},
-		scaleTestOutputFormatJSON: {},
+		scaleTestOutputFormatText:       {},
+		scaleTestOutputFormatJSON:       {},
+		scaleTestOutputFormatJUnit:      {},
+		scaleTestOutputFormatCSV:        {},
+		scaleTestOutputFormatPrometheus: {},
 	}
 
 	var out []scaleTestOutput
@@ -276,6 +364,9 @@ func (s *scaletestOutputFlags) parse() ([]scaleTestOutput, error) {
 		}
 
 		if len(parts) == 1 {
+			if format == scaleTestOutputFormatPrometheus {
+				return nil, xerrors.Errorf("output format %q in output flag %d requires a Pushgateway URL", format, i)
+			}
 			if stdoutFormat != "" {
 				return nil, xerrors.Errorf("multiple output flags specified for stdout")
 			}
@@ -331,6 +422,41 @@ func requireAdmin(ctx context.Context, client *codersdk.Client) (codersdk.User,
 	return me, nil
 }
 
+// getScaletestWorkspaces returns all scaletest workspaces, optionally filtered
+// by template name.
+func getScaletestWorkspaces(ctx context.Context, client *codersdk.Client, template string) ([]codersdk.Workspace, error) {
+	var (
+		pageNumber = 0
+		limit      = 100
+		workspaces []codersdk.Workspace
+	)
+	for {
+		page, err := client.Workspaces(ctx, codersdk.WorkspaceFilter{
+			Name:     "scaletest-",
+			Template: template,
+			Offset:   pageNumber * limit,
+			Limit:    limit,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("fetch scaletest workspaces page %d: %w", pageNumber, err)
+		}
+
+		pageNumber++
+		if len(page.Workspaces) == 0 {
+			break
+		}
+
+		pageWorkspaces := make([]codersdk.Workspace, 0, len(page.Workspaces))
+		for _, w := range page.Workspaces {
+			if isScaleTestWorkspace(w) {
+				pageWorkspaces = append(pageWorkspaces, w)
+			}
+		}
+		workspaces = append(workspaces, pageWorkspaces...)
+	}
+	return workspaces, nil
+}
+
 // userCleanupRunner is a runner that deletes a user in the Run phase.
 type userCleanupRunner struct {
 	client *codersdk.Client
@@ -384,33 +510,9 @@ func (r *RootCmd) scaletestCleanup() *clibase.Cmd {
 			}
 
 			cliui.Infof(inv.Stdout, "Fetching scaletest workspaces...")
-			var (
-				pageNumber = 0
-				limit      = 100
-				workspaces []codersdk.Workspace
-			)
-			for {
-				page, err := client.Workspaces(ctx, codersdk.WorkspaceFilter{
-					Name:   "scaletest-",
-					Offset: pageNumber * limit,
-					Limit:  limit,
-				})
-				if err != nil {
-					return xerrors.Errorf("fetch scaletest workspaces page %d: %w", pageNumber, err)
-				}
-
-				pageNumber++
-				if len(page.Workspaces) == 0 {
-					break
-				}
-
-				pageWorkspaces := make([]codersdk.Workspace, 0, len(page.Workspaces))
-				for _, w := range page.Workspaces {
-					if isScaleTestWorkspace(w) {
-						pageWorkspaces = append(pageWorkspaces, w)
-					}
-				}
-				workspaces = append(workspaces, pageWorkspaces...)
+			workspaces, err := getScaletestWorkspaces(ctx, client, "")
+			if err != nil {
+				return err
 			}
 
 			cliui.Errorf(inv.Stderr, "Found %d scaletest workspaces\n", len(workspaces))
@@ -441,9 +543,11 @@ func (r *RootCmd) scaletestCleanup() *clibase.Cmd {
 			}
 
 			cliui.Infof(inv.Stdout, "Fetching scaletest users...")
-			pageNumber = 0
-			limit = 100
-			var users []codersdk.User
+			var (
+				pageNumber = 0
+				limit      = 100
+				users      []codersdk.User
+			)
 			for {
 				page, err := client.Users(ctx, codersdk.UsersRequest{
 					Search: "scaletest-",
@@ -568,6 +672,10 @@ func (r *RootCmd) scaletestCreateWorkspaces() *clibase.Cmd {
 			if count <= 0 {
 				return xerrors.Errorf("--count is required and must be greater than 0")
 			}
+			err = strategy.validate()
+			if err != nil {
+				return err
+			}
 			outputs, err := output.parse()
 			if err != nil {
 				return xerrors.Errorf("could not parse --output flags")
@@ -782,7 +890,7 @@ func (r *RootCmd) scaletestCreateWorkspaces() *clibase.Cmd {
 
 			res := th.Results()
 			for _, o := range outputs {
-				err = o.write(res, inv.Stdout)
+				err = o.write(ctx, res, inv.Stdout)
 				if err != nil {
 					return xerrors.Errorf("write output %q to %q: %w", o.format, o.path, err)
 				}
@@ -936,6 +1044,224 @@ func (r *RootCmd) scaletestCreateWorkspaces() *clibase.Cmd {
 	return cmd
 }
 
+func (r *RootCmd) scaletestWorkspaceTraffic() *clibase.Cmd {
+	var (
+		template        string
+		duration        time.Duration
+		tickInterval    time.Duration
+		bytesPerTick    int64
+		ssh             bool
+		reconnectingPTY bool
+		apps            []string
+
+		tracingFlags = &scaletestTracingFlags{}
+		output       = &scaletestOutputFlags{}
+	)
+
+	client := new(codersdk.Client)
+
+	cmd := &clibase.Cmd{
+		Use:        "workspace-traffic",
+		Short:      "Generate traffic to scaletest workspaces through coderd",
+		Long:       `Sends steady-state SSH, web terminal and workspace app traffic to every scaletest workspace for the given duration. Workspaces can be created with coder scaletest create-workspaces --no-cleanup. Site owners can only access apps of other users' workspaces if the server runs with --dangerous-allow-path-app-site-owner-access.`,
+		Middleware: r.InitClient(client),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx := inv.Context()
+
+			_, err := requireAdmin(ctx, client)
+			if err != nil {
+				return err
+			}
+
+			client.HTTPClient = &http.Client{
+				Transport: &headerTransport{
+					transport: http.DefaultTransport,
+					header: map[string][]string{
+						codersdk.BypassRatelimitHeader: {"true"},
+					},
+				},
+			}
+
+			if !ssh && !reconnectingPTY && len(apps) == 0 {
+				return xerrors.Errorf("at least one of --ssh, --reconnecting-pty or --app is required")
+			}
+			outputs, err := output.parse()
+			if err != nil {
+				return xerrors.Errorf("could not parse --output flags")
+			}
+
+			workspaces, err := getScaletestWorkspaces(ctx, client, template)
+			if err != nil {
+				return err
+			}
+			if len(workspaces) == 0 {
+				return xerrors.Errorf("no scaletest workspaces exist")
+			}
+
+			tracerProvider, closeTracing, tracingEnabled, err := tracingFlags.provider(ctx)
+			if err != nil {
+				return xerrors.Errorf("create tracer provider: %w", err)
+			}
+			defer func() {
+				// Allow time for traces to flush even if command context is
+				// canceled.
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				_ = closeTracing(ctx)
+			}()
+			tracer := tracerProvider.Tracer(scaletestTracerName)
+
+			// Every workspace sends traffic at the same time for the whole
+			// duration, so the runs must all be concurrent.
+			th := harness.NewTestHarness(harness.ConcurrentExecutionStrategy{}, harness.ConcurrentExecutionStrategy{})
+			runs := 0
+			for i, ws := range workspaces {
+				const name = "workspacetraffic"
+				id := strconv.Itoa(i)
+
+				// Use the first agent, like create-workspaces does.
+				var agent codersdk.WorkspaceAgent
+			resourceLoop:
+				for _, res := range ws.LatestBuild.Resources {
+					for _, a := range res.Agents {
+						agent = a
+						break resourceLoop
+					}
+				}
+				if agent.ID == uuid.Nil {
+					cliui.Warnf(inv.Stderr, "Skipping workspace %s/%s without agents", ws.OwnerName, ws.Name)
+					continue
+				}
+
+				config := workspacetraffic.Config{
+					AgentID:         agent.ID,
+					Duration:        httpapi.Duration(duration),
+					TickInterval:    httpapi.Duration(tickInterval),
+					BytesPerTick:    bytesPerTick,
+					SSH:             ssh,
+					ReconnectingPTY: reconnectingPTY,
+				}
+				for _, slug := range apps {
+					for _, app := range agent.Apps {
+						if app.Slug != slug || app.Subdomain {
+							continue
+						}
+						config.AppPaths = append(config.AppPaths, fmt.Sprintf("/@%s/%s.%s/apps/%s/", ws.OwnerName, ws.Name, agent.Name, app.Slug))
+					}
+				}
+				if !ssh && !reconnectingPTY && len(config.AppPaths) == 0 {
+					cliui.Warnf(inv.Stderr, "Skipping workspace %s/%s without matching path-based apps", ws.OwnerName, ws.Name)
+					continue
+				}
+
+				err = config.Validate()
+				if err != nil {
+					return xerrors.Errorf("validate config: %w", err)
+				}
+
+				var runner harness.Runnable = workspacetraffic.NewRunner(client, config)
+				if tracingEnabled {
+					runner = &runnableTraceWrapper{
+						tracer:   tracer,
+						spanName: fmt.Sprintf("%s/%s", name, id),
+						runner:   runner,
+					}
+				}
+
+				th.AddRun(name, id, runner)
+				runs++
+			}
+			if runs == 0 {
+				return xerrors.Errorf("no scaletest workspaces to send traffic to")
+			}
+
+			_, _ = fmt.Fprintf(inv.Stderr, "Sending traffic to %d workspaces for %s...\n", runs, duration)
+			err = th.Run(ctx)
+			if err != nil {
+				return xerrors.Errorf("run test harness (harness failure, not a test failure): %w", err)
+			}
+
+			res := th.Results()
+			for _, o := range outputs {
+				err = o.write(ctx, res, inv.Stdout)
+				if err != nil {
+					return xerrors.Errorf("write output %q to %q: %w", o.format, o.path, err)
+				}
+			}
+
+			// Upload traces.
+			if tracingEnabled {
+				_, _ = fmt.Fprintln(inv.Stderr, "\nUploading traces...")
+				ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+				defer cancel()
+				err := closeTracing(ctx)
+				if err != nil {
+					_, _ = fmt.Fprintf(inv.Stderr, "\nError uploading traces: %+v\n", err)
+				}
+			}
+
+			if res.TotalFail > 0 {
+				return xerrors.New("load test failed, see above for more details")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:          "template",
+			FlagShorthand: "t",
+			Env:           "CODER_SCALETEST_TEMPLATE",
+			Description:   "Only send traffic to scaletest workspaces using the template with this name.",
+			Value:         clibase.StringOf(&template),
+		},
+		{
+			Flag:        "duration",
+			Env:         "CODER_SCALETEST_DURATION",
+			Default:     "1m",
+			Description: "How long to send traffic to each workspace for.",
+			Value:       clibase.DurationOf(&duration),
+		},
+		{
+			Flag:        "tick-interval",
+			Env:         "CODER_SCALETEST_TICK_INTERVAL",
+			Default:     "100ms",
+			Description: "How often to send traffic. Each tick writes --bytes-per-tick over SSH and the web terminal, and sends a request to each app.",
+			Value:       clibase.DurationOf(&tickInterval),
+		},
+		{
+			Flag:        "bytes-per-tick",
+			Env:         "CODER_SCALETEST_BYTES_PER_TICK",
+			Default:     "1024",
+			Description: "How many bytes to write over SSH and the web terminal on each tick.",
+			Value:       clibase.Int64Of(&bytesPerTick),
+		},
+		{
+			Flag:        "ssh",
+			Env:         "CODER_SCALETEST_SSH",
+			Description: "Echo bytes over an SSH session to each workspace.",
+			Value:       clibase.BoolOf(&ssh),
+		},
+		{
+			Flag:        "reconnecting-pty",
+			Env:         "CODER_SCALETEST_RECONNECTING_PTY",
+			Description: "Type commands into a reconnecting PTY (i.e. web terminal) in each workspace.",
+			Value:       clibase.BoolOf(&reconnectingPTY),
+		},
+		{
+			Flag:        "app",
+			Env:         "CODER_SCALETEST_APPS",
+			Description: "Slugs of workspace apps to send requests to through the path-based app proxy. Can be specified multiple times. Subdomain apps are skipped.",
+			Value:       clibase.StringArrayOf(&apps),
+		},
+	}
+
+	tracingFlags.attach(&cmd.Options)
+	output.attach(&cmd.Options)
+	return cmd
+}
+
 type runnableTraceWrapper struct {
 	tracer   trace.Tracer
 	spanName string
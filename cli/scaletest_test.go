@@ -5,14 +5,22 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/agent"
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/coder/provisioner/echo"
 	"github.com/coder/coder/pty/ptytest"
 	"github.com/coder/coder/scaletest/harness"
 	"github.com/coder/coder/testutil"
@@ -41,6 +49,8 @@ func TestScaleTest(t *testing.T) {
 		tDir := t.TempDir()
 		paramsFile := filepath.Join(tDir, "params.yaml")
 		outputFile := filepath.Join(tDir, "output.json")
+		junitFile := filepath.Join(tDir, "output.xml")
+		csvFile := filepath.Join(tDir, "output.csv")
 
 		f, err := os.Create(paramsFile)
 		require.NoError(t, err)
@@ -74,6 +84,8 @@ param3: 1
 			"--cleanup-job-timeout", "15s",
 			"--output", "text",
 			"--output", "json:"+outputFile,
+			"--output", "junit:"+junitFile,
+			"--output", "csv:"+csvFile,
 		)
 		clitest.SetupConfig(t, client, root)
 		pty := ptytest.New(t)
@@ -109,6 +121,16 @@ param3: 1
 
 		require.EqualValues(t, 2, res.TotalRuns)
 		require.EqualValues(t, 2, res.TotalPass)
+		require.EqualValues(t, 2, res.Phases["user_create"].Count)
+		require.EqualValues(t, 2, res.Phases["workspace_build"].Count)
+
+		junit, err := os.ReadFile(junitFile)
+		require.NoError(t, err)
+		require.Contains(t, string(junit), `<testsuite name="workspacebuild" tests="2" failures="0"`)
+		csv, err := os.ReadFile(csvFile)
+		require.NoError(t, err)
+		require.Contains(t, string(csv), "full_id,test_name,id,phase,started_at,duration_ms,error\n")
+		require.Contains(t, string(csv), ",user_create,")
 
 		// Find the workspaces and users and check that they are what we expect.
 		workspaces, err := client.Workspaces(ctx, codersdk.WorkspaceFilter{
@@ -198,3 +220,103 @@ param3: 1
 		require.Len(t, users.Users, 1)
 	})
 }
+
+func TestScaleTestWorkspaceTraffic(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("The SSH traffic echoes through cat, which isn't available on Windows")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	owner := coderdtest.CreateFirstUser(t, client)
+	agentToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, owner.OrganizationID, &echo.Responses{
+		Parse:          echo.ParseComplete,
+		ProvisionPlan:  echo.ProvisionComplete,
+		ProvisionApply: echo.ProvisionApplyWithAgent(agentToken),
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, owner.OrganizationID, version.ID)
+
+	// The workspace must look like it was created by create-workspaces.
+	_, err := client.CreateUser(ctx, codersdk.CreateUserRequest{
+		OrganizationID: owner.OrganizationID,
+		Username:       "scaletest-traffic-0",
+		Email:          "traffic-0@scaletest.local",
+		Password:       "SomeSecurePassword!",
+	})
+	require.NoError(t, err)
+	userClient := codersdk.New(client.URL)
+	login, err := userClient.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+		Email:    "traffic-0@scaletest.local",
+		Password: "SomeSecurePassword!",
+	})
+	require.NoError(t, err)
+	userClient.SetSessionToken(login.SessionToken)
+	workspace := coderdtest.CreateWorkspace(t, userClient, owner.OrganizationID, template.ID, func(r *codersdk.CreateWorkspaceRequest) {
+		r.Name = "scaletest-traffic-0"
+	})
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}).Named("agent"),
+	})
+	defer func() {
+		_ = agentCloser.Close()
+	}()
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+	inv, root := clitest.New(t, "scaletest", "workspace-traffic",
+		"--template", template.Name,
+		"--duration", "2s",
+		"--ssh",
+		"--reconnecting-pty",
+		"--output", "json:"+outputFile,
+	)
+	clitest.SetupConfig(t, client, root)
+	var stderr strings.Builder
+	inv.Stderr = &stderr
+
+	err = inv.WithContext(ctx).Run()
+	require.NoError(t, err, stderr.String())
+
+	f, err := os.Open(outputFile)
+	require.NoError(t, err)
+	defer f.Close()
+	var res harness.Results
+	err = json.NewDecoder(f).Decode(&res)
+	require.NoError(t, err)
+
+	require.EqualValues(t, 1, res.TotalRuns)
+	require.EqualValues(t, 1, res.TotalPass)
+	require.Greater(t, res.Phases["ssh_echo"].Count, 0)
+	require.Greater(t, res.Phases["pty_type"].Count, 0)
+	require.Greater(t, res.Counters["ssh_bytes_read"], int64(0))
+}
+
+func TestScaleTestCreateWorkspacesArrivalRate(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitMedium)
+	defer cancel()
+
+	client := coderdtest.New(t, nil)
+	_ = coderdtest.CreateFirstUser(t, client)
+
+	inv, root := clitest.New(t, "scaletest", "create-workspaces",
+		"--count", "1",
+		"--template", "doesnotexist",
+		"--ramp-up", "1m",
+	)
+	clitest.SetupConfig(t, client, root)
+
+	err := inv.WithContext(ctx).Run()
+	require.ErrorContains(t, err, "--ramp-up requires --arrival-rate")
+}
@@ -50,6 +50,7 @@ import (
 	"google.golang.org/api/idtoken"
 	"google.golang.org/api/option"
 	"gopkg.in/yaml.v3"
+	"storj.io/drpc"
 	"tailscale.com/tailcfg"
 
 	"cdr.dev/slog"
@@ -72,6 +73,7 @@ import (
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/prometheusmetrics"
+	"github.com/coder/coder/coderd/ratelimit"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/coderd/tracing"
 	"github.com/coder/coder/coderd/updatecheck"
@@ -243,10 +245,23 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 			// was specified.
 			loginRateLimit := 60
 			filesRateLimit := 12
+			workspaceBuildRateLimit := 0
+			agentRateLimit := 0
+			rateLimitPolicies, err := parseRateLimitPolicies(cfg.RateLimit.Policies.Value)
+			if err != nil {
+				return xerrors.Errorf("parse rate limit policies: %w", err)
+			}
+			provisionerPlugins, err := codersdk.ParseProvisionerPlugins(cfg.Provisioner.Plugins.Value())
+			if err != nil {
+				return xerrors.Errorf("parse provisioner plugins: %w", err)
+			}
 			if cfg.RateLimit.DisableAll {
 				cfg.RateLimit.API = -1
 				loginRateLimit = -1
 				filesRateLimit = -1
+				workspaceBuildRateLimit = -1
+				agentRateLimit = -1
+				rateLimitPolicies = nil
 			}
 
 			printLogo(inv)
@@ -631,6 +646,10 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 				APIRateLimit:                int(cfg.RateLimit.API.Value()),
 				LoginRateLimit:              loginRateLimit,
 				FilesRateLimit:              filesRateLimit,
+				WorkspaceBuildRateLimit:     workspaceBuildRateLimit,
+				AgentRateLimit:              agentRateLimit,
+				RateLimitPolicies:           rateLimitPolicies,
+				ProvisionerPlugins:          provisionerPlugins,
 				HTTPClient:                  httpClient,
 				SSHConfig: codersdk.SSHConfigResponse{
 					HostnamePrefix:   cfg.SSHConfig.DeploymentName.String(),
@@ -711,6 +730,13 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 				if slice.Contains(cfg.OIDC.Scopes, "groups") && cfg.OIDC.GroupField == "" {
 					cfg.OIDC.GroupField = "groups"
 				}
+				var groupFilter *regexp.Regexp
+				if cfg.OIDC.GroupRegexFilter != "" {
+					groupFilter, err = regexp.Compile(cfg.OIDC.GroupRegexFilter.String())
+					if err != nil {
+						return xerrors.Errorf("parse oidc group regex filter: %w", err)
+					}
+				}
 				options.OIDCConfig = &coderd.OIDCConfig{
 					OAuth2Config: &oauth2.Config{
 						ClientID:     cfg.OIDC.ClientID.String(),
@@ -730,6 +756,11 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 					AuthURLParams:       cfg.OIDC.AuthURLParams.Value,
 					GroupField:          cfg.OIDC.GroupField.String(),
 					GroupMapping:        cfg.OIDC.GroupMapping.Value,
+					GroupFilter:         groupFilter,
+					CreateMissingGroups: cfg.OIDC.GroupAutoCreate.Value(),
+					UserRoleField:       cfg.OIDC.UserRoleField.String(),
+					UserRoleMapping:     cfg.OIDC.UserRoleMapping.Value,
+					UserRolesDefault:    cfg.OIDC.UserRolesDefault.Value(),
 					SignInText:          cfg.OIDC.SignInText.String(),
 					IconURL:             cfg.OIDC.IconURL.String(),
 					IgnoreEmailVerified: cfg.OIDC.IgnoreEmailVerified.Value(),
@@ -756,6 +787,10 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 				defer options.Pubsub.Close()
 			}
 
+			if cfg.RateLimit.Shared {
+				options.RateLimitStore = ratelimit.NewDatabaseStore(options.Database)
+			}
+
 			var deploymentID string
 			err = options.Database.InTx(func(tx database.Store) error {
 				// This will block until the lock is acquired, and will be
@@ -868,6 +903,18 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 				}
 				defer closeWorkspacesFunc()
 
+				closeAgentsFunc, err := prometheusmetrics.Agents(ctx, options.PrometheusRegistry, options.Database, options.AgentInactiveDisconnectTimeout, 0)
+				if err != nil {
+					return xerrors.Errorf("register agents prometheus metric: %w", err)
+				}
+				defer closeAgentsFunc()
+
+				closeProvisionerJobsFunc, err := prometheusmetrics.ProvisionerJobs(ctx, options.PrometheusRegistry, options.Database, 0)
+				if err != nil {
+					return xerrors.Errorf("register provisioner jobs prometheus metric: %w", err)
+				}
+				defer closeProvisionerJobsFunc()
+
 				//nolint:revive
 				defer serveHandler(ctx, logger, promhttp.InstrumentMetricHandler(
 					options.PrometheusRegistry, promhttp.HandlerFor(options.PrometheusRegistry, promhttp.HandlerOpts{}),
@@ -925,10 +972,23 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 			var provisionerdWaitGroup sync.WaitGroup
 			defer provisionerdWaitGroup.Wait()
 			provisionerdMetrics := provisionerd.NewMetrics(options.PrometheusRegistry)
+			// The Terraform cache outlives this process, so it's shared
+			// with other servers and external provisioner daemons using the
+			// same cache directory.
+			terraformCache, err := terraform.NewCache(ctx, terraform.CacheOptions{
+				Path:       filepath.Join(cfg.CacheDir.String(), "terraform"),
+				MaxSize:    cfg.Provisioner.CacheMaxSize.Value() << 20,
+				MirrorPath: cfg.Provisioner.CacheMirror.String(),
+				Logger:     logger.Named("terraform-cache"),
+				Metrics:    terraform.NewCacheMetrics(options.PrometheusRegistry),
+			})
+			if err != nil {
+				return xerrors.Errorf("create terraform cache: %w", err)
+			}
 			for i := int64(0); i < cfg.Provisioner.Daemons.Value(); i++ {
 				daemonCacheDir := filepath.Join(cacheDir, fmt.Sprintf("provisioner-%d", i))
 				daemon, err := newProvisionerDaemon(
-					ctx, coderAPI, provisionerdMetrics, logger, cfg, daemonCacheDir, errCh, false, &provisionerdWaitGroup,
+					ctx, coderAPI, provisionerdMetrics, logger, cfg, provisionerPlugins, daemonCacheDir, terraformCache, errCh, false, &provisionerdWaitGroup,
 				)
 				if err != nil {
 					return xerrors.Errorf("create provisioner daemon: %w", err)
@@ -940,7 +1000,7 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 			defer shutdownConns()
 
 			// Ensures that old database entries are cleaned up over time!
-			purger := dbpurge.New(ctx, logger, options.Database)
+			purger := dbpurge.New(ctx, logger, options.Database, cfg.Retention, options.PrometheusRegistry)
 			defer purger.Close()
 
 			// Wrap the server in middleware that redirects to the access URL if
@@ -1019,7 +1079,7 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 
 			autobuildPoller := time.NewTicker(cfg.AutobuildPollInterval.Value())
 			defer autobuildPoller.Stop()
-			autobuildExecutor := executor.New(ctx, options.Database, logger, autobuildPoller.C)
+			autobuildExecutor := executor.New(ctx, options.Database, options.Pubsub, coderAPI.TemplateScheduleStore, logger, autobuildPoller.C)
 			autobuildExecutor.Run()
 
 			// Currently there is no way to ask the server to shut
@@ -1229,7 +1289,9 @@ func newProvisionerDaemon(
 	metrics provisionerd.Metrics,
 	logger slog.Logger,
 	cfg *codersdk.DeploymentValues,
+	plugins []codersdk.ProvisionerPlugin,
 	cacheDir string,
+	terraformCache *terraform.Cache,
 	errCh chan error,
 	dev bool,
 	wg *sync.WaitGroup,
@@ -1264,6 +1326,7 @@ func newProvisionerDaemon(
 				Listener: terraformServer,
 			},
 			CachePath: cacheDir,
+			Cache:     terraformCache,
 			Logger:    logger,
 		})
 		if err != nil && !xerrors.Is(err, context.Canceled) {
@@ -1307,6 +1370,13 @@ func newProvisionerDaemon(
 		}()
 		provisioners[string(database.ProvisionerTypeEcho)] = sdkproto.NewDRPCProvisionerClient(echoClient)
 	}
+	pluginProvisioners, err := ExecProvisionerPlugins(ctx, logger, plugins)
+	if err != nil {
+		return nil, err
+	}
+	for provisionerType, client := range pluginProvisioners {
+		provisioners[provisionerType] = client
+	}
 	debounce := time.Second
 	return provisionerd.New(func(ctx context.Context) (proto.DRPCProvisionerDaemonClient, error) {
 		// This debounces calls to listen every second. Read the comment
@@ -1326,6 +1396,30 @@ func newProvisionerDaemon(
 	}), nil
 }
 
+// ExecProvisionerPlugins launches the external provisioner plugins, and
+// returns a client for each by provisioner type. The plugins are killed when
+// the context is canceled.
+func ExecProvisionerPlugins(ctx context.Context, logger slog.Logger, plugins []codersdk.ProvisionerPlugin) (provisionerd.Provisioners, error) {
+	provisioners := provisionerd.Provisioners{}
+	conns := make([]drpc.Conn, 0, len(plugins))
+	for _, plugin := range plugins {
+		pluginLogger := logger.Named(string(plugin.Type))
+		conn, err := provisionersdk.Exec(ctx, plugin.Path, &provisionersdk.ExecOptions{
+			Stderr: slog.Stdlib(ctx, pluginLogger, slog.LevelInfo).Writer(),
+		})
+		if err != nil {
+			for _, conn := range conns {
+				_ = conn.Close()
+			}
+			return nil, xerrors.Errorf("exec provisioner plugin %q: %w", plugin.Type, err)
+		}
+		pluginLogger.Info(ctx, "started provisioner plugin", slog.F("path", plugin.Path))
+		conns = append(conns, conn)
+		provisioners[string(plugin.Type)] = sdkproto.NewDRPCProvisionerClient(conn)
+	}
+	return provisioners, nil
+}
+
 // nolint: revive
 func printLogo(inv *clibase.Invocation) {
 	// Only print the logo in TTYs.
@@ -1673,6 +1767,41 @@ func startBuiltinPostgres(ctx context.Context, cfg config.Root, logger slog.Logg
 	return connectionURL, ep.Stop, nil
 }
 
+// parseRateLimitPolicies converts the rate limit policy overrides of the
+// deployment config.
+func parseRateLimitPolicies(configs []codersdk.RateLimitPolicy) ([]ratelimit.Policy, error) {
+	policies := make([]ratelimit.Policy, 0, len(configs))
+	seen := make(map[string]struct{}, len(configs))
+	for _, cfg := range configs {
+		if !slice.Contains(ratelimit.PolicyNames, cfg.Name) {
+			return nil, xerrors.Errorf("unknown policy %q, must be one of %s", cfg.Name, strings.Join(ratelimit.PolicyNames, ", "))
+		}
+		if _, ok := seen[cfg.Name]; ok {
+			return nil, xerrors.Errorf("policy %q is configured more than once", cfg.Name)
+		}
+		seen[cfg.Name] = struct{}{}
+		if cfg.Window < 0 {
+			return nil, xerrors.Errorf("policy %q: window must not be negative", cfg.Name)
+		}
+		// The other policies are checked before the user is authenticated.
+		if len(cfg.RoleLimits) > 0 && cfg.Name != ratelimit.PolicyFiles && cfg.Name != ratelimit.PolicyWorkspaceBuilds {
+			return nil, xerrors.Errorf("policy %q: role limits only apply to the %q and %q policies", cfg.Name, ratelimit.PolicyFiles, ratelimit.PolicyWorkspaceBuilds)
+		}
+
+		roleLimits := make(map[string]int, len(cfg.RoleLimits))
+		for role, limit := range cfg.RoleLimits {
+			roleLimits[role] = int(limit)
+		}
+		policies = append(policies, ratelimit.Policy{
+			Name:       cfg.Name,
+			Limit:      int(cfg.Limit),
+			Window:     cfg.Window,
+			RoleLimits: roleLimits,
+		})
+	}
+	return policies, nil
+}
+
 func configureHTTPClient(ctx context.Context, clientCertFile, clientKeyFile string, tlsClientCAFile string) (context.Context, *http.Client, error) {
 	if clientCertFile != "" && clientKeyFile != "" {
 		certificates, err := loadCertificates([]string{clientCertFile}, []string{clientKeyFile})
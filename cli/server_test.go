@@ -1263,6 +1263,53 @@ func TestServer(t *testing.T) {
 			cancelFunc()
 			<-serverErr
 		})
+
+		t.Run("Policies", func(t *testing.T) {
+			t.Parallel()
+			ctx, cancelFunc := context.WithCancel(context.Background())
+			defer cancelFunc()
+
+			root, cfg := clitest.New(t,
+				"server",
+				"--in-memory",
+				"--http-address", ":0",
+				"--access-url", "http://example.com",
+				"--rate-limit-policies", `[{"name": "api", "limit": 100, "window": "1h"}]`,
+			)
+			serverErr := make(chan error, 1)
+			go func() {
+				serverErr <- root.WithContext(ctx).Run()
+			}()
+			accessURL := waitAccessURL(t, cfg)
+			client := codersdk.New(accessURL)
+
+			resp, err := client.Request(ctx, http.MethodGet, "/api/v2/buildinfo", nil)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, "100", resp.Header.Get("X-Ratelimit-Limit"))
+			reset, err := strconv.ParseInt(resp.Header.Get("X-Ratelimit-Reset"), 10, 64)
+			require.NoError(t, err)
+			require.Greater(t, time.Unix(reset, 0), time.Now().Add(time.Minute))
+			cancelFunc()
+			<-serverErr
+		})
+
+		t.Run("UnknownPolicy", func(t *testing.T) {
+			t.Parallel()
+			ctx, cancelFunc := context.WithCancel(context.Background())
+			defer cancelFunc()
+
+			root, _ := clitest.New(t,
+				"server",
+				"--in-memory",
+				"--http-address", ":0",
+				"--access-url", "http://example.com",
+				"--rate-limit-policies", `[{"name": "unknown", "limit": 100}]`,
+			)
+			err := root.WithContext(ctx).Run()
+			require.ErrorContains(t, err, `unknown policy "unknown"`)
+		})
 	})
 
 	waitFile := func(t *testing.T, fiName string, dur time.Duration) {
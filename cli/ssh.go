@@ -291,6 +291,9 @@ func getWorkspaceAndAgent(ctx context.Context, inv *clibase.Invocation, client *
 	}
 
 	if workspace.LatestBuild.Transition != codersdk.WorkspaceTransitionStart {
+		if workspace.DeletingAt != nil {
+			return codersdk.Workspace{}, codersdk.WorkspaceAgent{}, xerrors.Errorf("workspace must be in start transition to ssh, and will be deleted for inactivity at %s unless it's started", workspace.DeletingAt.Format(time.RFC822))
+		}
 		return codersdk.Workspace{}, codersdk.WorkspaceAgent{}, xerrors.New("workspace must be in start transition to ssh")
 	}
 	if workspace.LatestBuild.Job.CompletedAt == nil {
@@ -348,7 +351,9 @@ func tryPollWorkspaceAutostop(ctx context.Context, client *codersdk.Client, work
 	return notify.Notify(condition, workspacePollInterval, autostopNotifyCountdown...)
 }
 
-// Notify the user if the workspace is due to shutdown.
+// Notify the user if the workspace is due to shutdown, either because of
+// its deadline or because it has not been used for too long, or if it's due
+// to be deleted.
 func notifyCondition(ctx context.Context, client *codersdk.Client, workspaceID uuid.UUID, lock *flock.Flock) notify.Condition {
 	return func(now time.Time) (deadline time.Time, callback func()) {
 		// Keep trying to regain the lock.
@@ -362,22 +367,13 @@ func notifyCondition(ctx context.Context, client *codersdk.Client, workspaceID u
 			return time.Time{}, nil
 		}
 
-		if ptr.NilOrZero(ws.TTLMillis) {
+		deadline, reason := workspaceNotifyDeadline(ws)
+		if deadline.IsZero() {
 			return time.Time{}, nil
 		}
 
-		deadline = ws.LatestBuild.Deadline.Time
 		callback = func() {
-			ttl := deadline.Sub(now)
-			var title, body string
-			if ttl > time.Minute {
-				title = fmt.Sprintf(`Workspace %s stopping soon`, ws.Name)
-				body = fmt.Sprintf(
-					`Your Coder workspace %s is scheduled to stop in %.0f mins`, ws.Name, ttl.Minutes())
-			} else {
-				title = fmt.Sprintf("Workspace %s stopping!", ws.Name)
-				body = fmt.Sprintf("Your Coder workspace %s is stopping any time now!", ws.Name)
-			}
+			title, body := workspaceNotifyMessage(ws.Name, reason, deadline.Sub(now))
 			// notify user with a native system notification (best effort)
 			_ = beeep.Notify(title, body, "")
 		}
@@ -385,6 +381,57 @@ func notifyCondition(ctx context.Context, client *codersdk.Client, workspaceID u
 	}
 }
 
+type workspaceNotifyReason int
+
+const (
+	workspaceNotifyStop workspaceNotifyReason = iota
+	workspaceNotifyDormant
+	workspaceNotifyDelete
+)
+
+// workspaceNotifyDeadline returns the next time the workspace is stopped or
+// deleted, and why, or the zero time if neither is scheduled.
+func workspaceNotifyDeadline(ws codersdk.Workspace) (time.Time, workspaceNotifyReason) {
+	// Stopped workspaces are only scheduled for deletion.
+	if ws.DeletingAt != nil {
+		return *ws.DeletingAt, workspaceNotifyDelete
+	}
+	var deadline time.Time
+	if !ptr.NilOrZero(ws.TTLMillis) {
+		deadline = ws.LatestBuild.Deadline.Time
+	}
+	// The workspace may be stopped for inactivity before the deadline.
+	if ws.DormantAt != nil && (deadline.IsZero() || ws.DormantAt.Before(deadline)) {
+		return *ws.DormantAt, workspaceNotifyDormant
+	}
+	return deadline, workspaceNotifyStop
+}
+
+// workspaceNotifyMessage returns the title and body of the notification that
+// the workspace is stopped or deleted in ttl.
+func workspaceNotifyMessage(name string, reason workspaceNotifyReason, ttl time.Duration) (title, body string) {
+	if reason == workspaceNotifyDelete {
+		if ttl > time.Minute {
+			return fmt.Sprintf(`Workspace %s deleting soon`, name),
+				fmt.Sprintf(`Your Coder workspace %s will be deleted for inactivity in %.0f mins. Start it to keep it`, name, ttl.Minutes())
+		}
+		return fmt.Sprintf("Workspace %s deleting!", name),
+			fmt.Sprintf("Your Coder workspace %s is being deleted any time now!", name)
+	}
+	if ttl > time.Minute {
+		title = fmt.Sprintf(`Workspace %s stopping soon`, name)
+		body = fmt.Sprintf(
+			`Your Coder workspace %s is scheduled to stop in %.0f mins`, name, ttl.Minutes())
+		if reason == workspaceNotifyDormant {
+			body = fmt.Sprintf(
+				`Your Coder workspace %s will be stopped for inactivity in %.0f mins`, name, ttl.Minutes())
+		}
+		return title, body
+	}
+	return fmt.Sprintf("Workspace %s stopping!", name),
+		fmt.Sprintf("Your Coder workspace %s is stopping any time now!", name)
+}
+
 // Verify if the user workspace is outdated and prepare an actionable message for user.
 func verifyWorkspaceOutdated(client *codersdk.Client, workspace codersdk.Workspace) (string, bool) {
 	if !workspace.Outdated {
@@ -3,10 +3,12 @@ package cli
 import (
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -56,3 +58,50 @@ func TestBuildWorkspaceLink(t *testing.T) {
 
 	assert.Equal(t, workspaceLink.String(), fakeServerURL+"/@"+fakeOwnerName+"/"+fakeWorkspaceName)
 }
+
+func TestWorkspaceNotifyDeadline(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	deadline := now.Add(time.Hour)
+	dormantAt := now.Add(30 * time.Minute)
+	deletingAt := now.Add(24 * time.Hour)
+
+	t.Run("None", func(t *testing.T) {
+		t.Parallel()
+
+		got, _ := workspaceNotifyDeadline(codersdk.Workspace{})
+		assert.True(t, got.IsZero(), "no deadline expected")
+	})
+	t.Run("Stop", func(t *testing.T) {
+		t.Parallel()
+
+		workspace := codersdk.Workspace{TTLMillis: ptr.Ref(time.Hour.Milliseconds())}
+		workspace.LatestBuild.Deadline = codersdk.NewNullTime(deadline, true)
+		got, reason := workspaceNotifyDeadline(workspace)
+		assert.Equal(t, deadline, got)
+		assert.Equal(t, workspaceNotifyStop, reason)
+	})
+	t.Run("Dormant", func(t *testing.T) {
+		t.Parallel()
+
+		workspace := codersdk.Workspace{TTLMillis: ptr.Ref(time.Hour.Milliseconds()), DormantAt: &dormantAt}
+		workspace.LatestBuild.Deadline = codersdk.NewNullTime(deadline, true)
+		got, reason := workspaceNotifyDeadline(workspace)
+		assert.Equal(t, dormantAt, got)
+		assert.Equal(t, workspaceNotifyDormant, reason)
+	})
+	t.Run("Delete", func(t *testing.T) {
+		t.Parallel()
+
+		got, reason := workspaceNotifyDeadline(codersdk.Workspace{DeletingAt: &deletingAt})
+		assert.Equal(t, deletingAt, got)
+		assert.Equal(t, workspaceNotifyDelete, reason)
+
+		title, body := workspaceNotifyMessage(fakeWorkspaceName, reason, 2*time.Hour)
+		assert.Contains(t, title, "deleting soon")
+		assert.Contains(t, body, "will be deleted for inactivity in 120 mins")
+		title, _ = workspaceNotifyMessage(fakeWorkspaceName, reason, 0)
+		assert.Contains(t, title, "deleting!")
+	})
+}
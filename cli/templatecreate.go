@@ -144,6 +144,11 @@ func (r *RootCmd) templateCreate() *clibase.Cmd {
 			Value:       clibase.DurationOf(&defaultTTL),
 		},
 		uploadFlags.option(),
+		{
+			Flag:        "provisioner",
+			Description: "Specify the provisioner to run the template with. Use the name of a provisioner plugin declared on the Coder server to target it.",
+			Value:       clibase.StringOf(&provisioner),
+		},
 		{
 			Flag:        "test.provisioner",
 			Description: "Customize the provisioner backend.",
@@ -20,7 +20,11 @@ func (r *RootCmd) templateEdit() *clibase.Cmd {
 		icon                         string
 		defaultTTL                   time.Duration
 		maxTTL                       time.Duration
+		inactivityTTL                time.Duration
+		deleteTTL                    time.Duration
+		autostopRequirement          string
 		allowUserCancelWorkspaceJobs bool
+		requiredPromotionApprovals   int64
 	)
 	client := new(codersdk.Client)
 
@@ -32,17 +36,17 @@ func (r *RootCmd) templateEdit() *clibase.Cmd {
 		),
 		Short: "Edit the metadata of a template by name.",
 		Handler: func(inv *clibase.Invocation) error {
-			if maxTTL != 0 {
+			if maxTTL != 0 || inactivityTTL != 0 || deleteTTL != 0 || autostopRequirement != "" {
 				entitlements, err := client.Entitlements(inv.Context())
 				var sdkErr *codersdk.Error
 				if xerrors.As(err, &sdkErr) && sdkErr.StatusCode() == http.StatusNotFound {
-					return xerrors.Errorf("your deployment appears to be an AGPL deployment, so you cannot set --max-ttl")
+					return xerrors.Errorf("your deployment appears to be an AGPL deployment, so you cannot set --max-ttl, --inactivity-ttl, --delete-ttl or --autostop-requirement")
 				} else if err != nil {
 					return xerrors.Errorf("get entitlements: %w", err)
 				}
 
 				if !entitlements.Features[codersdk.FeatureAdvancedTemplateScheduling].Enabled {
-					return xerrors.Errorf("your license is not entitled to use advanced template scheduling, so you cannot set --max-ttl")
+					return xerrors.Errorf("your license is not entitled to use advanced template scheduling, so you cannot set --max-ttl, --inactivity-ttl, --delete-ttl or --autostop-requirement")
 				}
 			}
 
@@ -63,8 +67,18 @@ func (r *RootCmd) templateEdit() *clibase.Cmd {
 				Icon:                         icon,
 				DefaultTTLMillis:             defaultTTL.Milliseconds(),
 				MaxTTLMillis:                 maxTTL.Milliseconds(),
+				InactivityTTLMillis:          inactivityTTL.Milliseconds(),
+				DeleteTTLMillis:              deleteTTL.Milliseconds(),
+				AutostopRequirement:          autostopRequirement,
 				AllowUserCancelWorkspaceJobs: allowUserCancelWorkspaceJobs,
 			}
+			if inv.ParsedFlags().Changed("required-promotion-approvals") {
+				if requiredPromotionApprovals < 0 {
+					return xerrors.New("--required-promotion-approvals must not be negative")
+				}
+				approvals := int32(requiredPromotionApprovals)
+				req.RequiredPromotionApprovals = &approvals
+			}
 
 			_, err = client.UpdateTemplateMeta(inv.Context(), template.ID, req)
 			if err != nil {
@@ -106,12 +120,32 @@ func (r *RootCmd) templateEdit() *clibase.Cmd {
 			Description: "Edit the template maximum time before shutdown - workspaces created from this template must shutdown within the given duration after starting. This is an enterprise-only feature.",
 			Value:       clibase.DurationOf(&maxTTL),
 		},
+		{
+			Flag:        "inactivity-ttl",
+			Description: "Edit the template inactivity time before shutdown - running workspaces created from this template are stopped after not being used for the given duration. This is an enterprise-only feature.",
+			Value:       clibase.DurationOf(&inactivityTTL),
+		},
+		{
+			Flag:        "delete-ttl",
+			Description: "Edit the template time before deletion - stopped workspaces created from this template are deleted after not being used for the given duration. This is an enterprise-only feature.",
+			Value:       clibase.DurationOf(&deleteTTL),
+		},
+		{
+			Flag:        "autostop-requirement",
+			Description: "Edit the template autostop requirement - running workspaces created from this template must stop at the next occurrence of this weekly cron schedule, e.g. \"0 2 * * 0\". The schedule must not specify a timezone, as it is evaluated in the timezone of each workspace owner. This is an enterprise-only feature.",
+			Value:       clibase.StringOf(&autostopRequirement),
+		},
 		{
 			Flag:        "allow-user-cancel-workspace-jobs",
 			Description: "Allow users to cancel in-progress workspace jobs.",
 			Default:     "true",
 			Value:       clibase.BoolOf(&allowUserCancelWorkspaceJobs),
 		},
+		{
+			Flag:        "required-promotion-approvals",
+			Description: "Edit the number of approvals a template version needs before it can be promoted to the active version. Set to 0 to allow promoting versions without approvals.",
+			Value:       clibase.Int64Of(&requiredPromotionApprovals),
+		},
 		cliui.SkipPromptOption(),
 	}
 
@@ -1,18 +1,302 @@
 package cli
 
 import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
 	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
 )
 
-func (*RootCmd) templatePlan() *clibase.Cmd {
-	return &clibase.Cmd{
-		Use: "plan <directory>",
+func (r *RootCmd) templatePlan() *clibase.Cmd {
+	var (
+		versionName       string
+		provisioner       string
+		parameterFile     string
+		richParameterFile string
+		variablesFile     string
+		variables         []string
+		provisionerTags   []string
+		workspaceName     string
+		uploadFlags       templateUploadFlags
+	)
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:   "plan [template]",
+		Short: "Plan a new template version from the current directory or as specified by flag, and show the resources it would change",
+		Long: formatExamples(
+			example{
+				Description: "Show the resource changes and cost of the template in the current directory",
+				Command:     "coder templates plan my-template",
+			},
+			example{
+				Description: "Show the changes a build of the template in the current directory would make to an existing workspace",
+				Command:     "coder templates plan my-template --workspace my-workspace",
+			},
+		),
 		Middleware: clibase.Chain(
-			clibase.RequireNArgs(1),
+			clibase.RequireRangeArgs(0, 1),
+			r.InitClient(client),
 		),
-		Short: "Plan a template push from the current directory",
 		Handler: func(inv *clibase.Invocation) error {
+			organization, err := CurrentOrganization(inv, client)
+			if err != nil {
+				return err
+			}
+
+			name, err := uploadFlags.templateName(inv.Args)
+			if err != nil {
+				return err
+			}
+
+			template, err := client.TemplateByName(inv.Context(), organization.ID, name)
+			if err != nil {
+				return err
+			}
+
+			// Plan against the state of an existing workspace, using the
+			// parameters of its last build as defaults.
+			var (
+				workspaceID         uuid.UUID
+				lastBuildParameters []codersdk.WorkspaceBuildParameter
+			)
+			if workspaceName != "" {
+				workspace, err := namedWorkspace(inv.Context(), client, workspaceName)
+				if err != nil {
+					return xerrors.Errorf("get workspace: %w", err)
+				}
+				if workspace.TemplateID != template.ID {
+					return xerrors.Errorf("workspace %q doesn't use template %q", workspaceName, template.Name)
+				}
+				lastBuildParameters, err = client.WorkspaceBuildParameters(inv.Context(), workspace.LatestBuild.ID)
+				if err != nil {
+					return xerrors.Errorf("get workspace build parameters: %w", err)
+				}
+				workspaceID = workspace.ID
+			}
+
+			resp, err := uploadFlags.upload(inv, client)
+			if err != nil {
+				return err
+			}
+
+			tags, err := ParseProvisionerTags(provisionerTags)
+			if err != nil {
+				return err
+			}
+
+			version, parameters, err := createValidTemplateVersion(inv, createValidTemplateVersionArgs{
+				Name:            versionName,
+				Client:          client,
+				Organization:    organization,
+				Provisioner:     database.ProvisionerType(provisioner),
+				FileID:          resp.ID,
+				ParameterFile:   parameterFile,
+				VariablesFile:   variablesFile,
+				Variables:       variables,
+				Template:        &template,
+				ReuseParameters: true,
+				ProvisionerTags: tags,
+			})
+			if err != nil {
+				return err
+			}
+
+			richParameters, err := templatePlanRichParameters(inv, client, version.ID, richParameterFile, lastBuildParameters)
+			if err != nil {
+				return err
+			}
+
+			dryRun, err := client.CreateTemplateVersionDryRun(inv.Context(), version.ID, codersdk.CreateTemplateVersionDryRunRequest{
+				ParameterValues:     parameters,
+				RichParameterValues: richParameters,
+				WorkspaceID:         workspaceID,
+			})
+			if err != nil {
+				return xerrors.Errorf("begin template version dry-run: %w", err)
+			}
+			_, _ = fmt.Fprintln(inv.Stdout, "Planning template version...")
+			err = cliui.ProvisionerJob(inv.Context(), inv.Stdout, cliui.ProvisionerJobOptions{
+				Fetch: func() (codersdk.ProvisionerJob, error) {
+					return client.TemplateVersionDryRun(inv.Context(), version.ID, dryRun.ID)
+				},
+				Cancel: func() error {
+					return client.CancelTemplateVersionDryRun(inv.Context(), version.ID, dryRun.ID)
+				},
+				Logs: func() (<-chan codersdk.ProvisionerJobLog, io.Closer, error) {
+					return client.TemplateVersionDryRunLogsAfter(inv.Context(), version.ID, dryRun.ID, 0)
+				},
+				// Don't show log output for the dry-run unless there's an error.
+				Silent: true,
+			})
+			if err != nil {
+				return xerrors.Errorf("dry-run template version: %w", err)
+			}
+
+			changes, err := client.TemplateVersionDryRunChanges(inv.Context(), version.ID, dryRun.ID)
+			if err != nil {
+				return xerrors.Errorf("get template version dry-run changes: %w", err)
+			}
+
+			_, _ = fmt.Fprintln(inv.Stdout)
+			if len(changes.Changes) == 0 {
+				_, _ = fmt.Fprintln(inv.Stdout, "No changes. The template version doesn't change any resources.")
+			} else {
+				out, err := cliui.DisplayTable(templatePlanChangesToRows(changes.Changes), "", nil)
+				if err != nil {
+					return xerrors.Errorf("render table: %w", err)
+				}
+				_, _ = fmt.Fprintln(inv.Stdout, out)
+				_, _ = fmt.Fprintln(inv.Stdout)
+				_, _ = fmt.Fprintln(inv.Stdout, templatePlanSummary(changes))
+			}
+			if workspaceID == uuid.Nil {
+				_, _ = fmt.Fprintln(inv.Stdout, "\nChanges were planned against an empty workspace. Use --workspace to plan against an existing one.")
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "\nTemplate version %s was created but isn't active.\n", cliui.Styles.Keyword.Render(version.Name))
 			return nil
 		},
 	}
+
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:        "provisioner",
+			Description: "Specify the provisioner to run the template with. Use the name of a provisioner plugin declared on the Coder server to target it.",
+			Value:       clibase.StringOf(&provisioner),
+		},
+		{
+			Flag:          "test.provisioner",
+			FlagShorthand: "p",
+			Description:   "Customize the provisioner backend.",
+			Default:       "terraform",
+			Value:         clibase.StringOf(&provisioner),
+			// This is for testing!
+			Hidden: true,
+		},
+		{
+			Flag:        "parameter-file",
+			Description: "Specify a file path with parameter values.",
+			Value:       clibase.StringOf(&parameterFile),
+		},
+		{
+			Flag:        "rich-parameter-file",
+			Description: "Specify a file path with values for rich parameters defined in the template. Parameters that aren't specified use their default value.",
+			Value:       clibase.StringOf(&richParameterFile),
+		},
+		{
+			Flag:        "variables-file",
+			Description: "Specify a file path with values for Terraform-managed variables.",
+			Value:       clibase.StringOf(&variablesFile),
+		},
+		{
+			Flag:        "variable",
+			Description: "Specify a set of values for Terraform-managed variables.",
+			Value:       clibase.StringArrayOf(&variables),
+		},
+		{
+			Flag:        "provisioner-tag",
+			Description: "Specify a set of tags to target provisioner daemons.",
+			Value:       clibase.StringArrayOf(&provisionerTags),
+		},
+		{
+			Flag:        "name",
+			Description: "Specify a name for the new template version. It will be automatically generated if not provided.",
+			Value:       clibase.StringOf(&versionName),
+		},
+		{
+			Flag:        "workspace",
+			Description: "Specify a workspace of the template to plan the changes against. Its last build parameters are used as defaults. Changes are planned against an empty workspace if not provided.",
+			Value:       clibase.StringOf(&workspaceName),
+		},
+		cliui.SkipPromptOption(),
+		uploadFlags.option(),
+	}
+	return cmd
+}
+
+// templatePlanRichParameters returns values for the rich parameters of the
+// template version. Values are read from the parameter file, then the last
+// build parameters, and parameters without a default value are prompted for.
+func templatePlanRichParameters(inv *clibase.Invocation, client *codersdk.Client, versionID uuid.UUID, richParameterFile string, lastBuildParameters []codersdk.WorkspaceBuildParameter) ([]codersdk.WorkspaceBuildParameter, error) {
+	templateVersionParameters, err := client.TemplateVersionRichParameters(inv.Context(), versionID)
+	if err != nil {
+		return nil, xerrors.Errorf("get template version rich parameters: %w", err)
+	}
+
+	parameterMapFromFile := map[string]string{}
+	if richParameterFile != "" {
+		parameterMapFromFile, err = createParameterMapFromFile(richParameterFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	richParameters := make([]codersdk.WorkspaceBuildParameter, 0)
+RichParameterLoop:
+	for _, templateVersionParameter := range templateVersionParameters {
+		_, inFile := parameterMapFromFile[templateVersionParameter.Name]
+		if !inFile {
+			for _, lastBuildParameter := range lastBuildParameters {
+				if lastBuildParameter.Name == templateVersionParameter.Name {
+					richParameters = append(richParameters, lastBuildParameter)
+					continue RichParameterLoop
+				}
+			}
+		}
+		if !inFile && !templateVersionParameter.Required {
+			continue
+		}
+		parameterValue, err := getWorkspaceBuildParameterValueFromMapOrInput(inv, parameterMapFromFile, templateVersionParameter)
+		if err != nil {
+			return nil, err
+		}
+		richParameters = append(richParameters, *parameterValue)
+	}
+	return richParameters, nil
+}
+
+type templatePlanChangeRow struct {
+	Action         string `table:"action"`
+	Address        string `table:"address,default_sort"`
+	PriorDailyCost int32  `table:"prior daily cost"`
+	DailyCost      int32  `table:"daily cost"`
+}
+
+func templatePlanChangesToRows(changes []codersdk.WorkspaceResourceChange) []templatePlanChangeRow {
+	rows := make([]templatePlanChangeRow, len(changes))
+	for i, change := range changes {
+		rows[i] = templatePlanChangeRow{
+			Action:         string(change.Action),
+			Address:        change.Address,
+			PriorDailyCost: change.PriorDailyCost,
+			DailyCost:      change.DailyCost,
+		}
+	}
+	return rows
+}
+
+// templatePlanSummary returns a Terraform-style summary of the number of
+// changes by action, and the change in daily cost.
+func templatePlanSummary(changes codersdk.TemplateVersionDryRunChanges) string {
+	counts := map[codersdk.WorkspaceResourceChangeAction]int{}
+	for _, change := range changes.Changes {
+		counts[change.Action]++
+	}
+	var summary strings.Builder
+	_, _ = fmt.Fprintf(&summary, "Plan: %d to create, %d to update, %d to replace, %d to delete.",
+		counts[codersdk.WorkspaceResourceChangeActionCreate],
+		counts[codersdk.WorkspaceResourceChangeActionUpdate],
+		counts[codersdk.WorkspaceResourceChangeActionReplace],
+		counts[codersdk.WorkspaceResourceChangeActionDelete],
+	)
+	if changes.DailyCost != 0 || changes.PriorDailyCost != 0 {
+		_, _ = fmt.Fprintf(&summary, "\nDaily cost: %d -> %d (%+d).", changes.PriorDailyCost, changes.DailyCost, changes.DailyCost-changes.PriorDailyCost)
+	}
+	return summary.String()
 }
@@ -0,0 +1,99 @@
+package cli_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestTemplatePlan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Changes", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		activeVersion := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, activeVersion.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, activeVersion.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		source := clitest.CreateTemplateVersionSource(t, &echo.Responses{
+			Parse: echo.ParseComplete,
+			ProvisionApply: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Resources: []*proto.Resource{{
+							Name: "dev",
+							Type: "aws_instance",
+						}},
+						ResourceChanges: []*proto.ResourceChange{{
+							Address:        "aws_instance.dev",
+							Name:           "dev",
+							Type:           "aws_instance",
+							Action:         proto.ResourceChangeAction_REPLACE,
+							DailyCost:      20,
+							PriorDailyCost: 15,
+						}},
+					},
+				},
+			}},
+		})
+		inv, root := clitest.New(t, "templates", "plan", template.Name, "-y", "--directory", source, "--test.provisioner", string(database.ProvisionerTypeEcho), "--workspace", workspace.Name)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t).Attach(inv)
+
+		execDone := make(chan error)
+		go func() {
+			execDone <- inv.Run()
+		}()
+
+		pty.ExpectMatch("aws_instance.dev")
+		pty.ExpectMatch("Plan: 0 to create, 0 to update, 1 to replace, 0 to delete.")
+		pty.ExpectMatch("Daily cost: 15 -> 20 (+5).")
+		require.NoError(t, <-execDone)
+
+		// The planned version must not become active.
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		template, err := client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		require.Equal(t, activeVersion.ID, template.ActiveVersionID)
+	})
+
+	t.Run("NoChanges", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		activeVersion := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, activeVersion.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, activeVersion.ID)
+
+		source := clitest.CreateTemplateVersionSource(t, &echo.Responses{
+			Parse:          echo.ParseComplete,
+			ProvisionApply: echo.ProvisionComplete,
+		})
+		inv, root := clitest.New(t, "templates", "plan", template.Name, "-y", "--directory", source, "--test.provisioner", string(database.ProvisionerTypeEcho))
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t).Attach(inv)
+
+		execDone := make(chan error)
+		go func() {
+			execDone <- inv.Run()
+		}()
+
+		pty.ExpectMatch("No changes.")
+		pty.ExpectMatch("Changes were planned against an empty workspace.")
+		require.NoError(t, <-execDone)
+	})
+}
@@ -160,6 +160,12 @@ func (r *RootCmd) templatePush() *clibase.Cmd {
 				return xerrors.Errorf("job failed: %s", job.Job.Status)
 			}
 
+			// Templates that require approvals can't be updated directly, so
+			// the version is submitted for promotion instead.
+			if template.RequiredPromotionApprovals > 0 {
+				return promoteTemplateVersion(inv, client, template, job.ID)
+			}
+
 			err = client.UpdateActiveTemplateVersion(inv.Context(), template.ID, codersdk.UpdateActiveTemplateVersion{
 				ID: job.ID,
 			})
@@ -173,6 +179,11 @@ func (r *RootCmd) templatePush() *clibase.Cmd {
 	}
 
 	cmd.Options = clibase.OptionSet{
+		{
+			Flag:        "provisioner",
+			Description: "Specify the provisioner to run the template with. Use the name of a provisioner plugin declared on the Coder server to target it.",
+			Value:       clibase.StringOf(&provisioner),
+		},
 		{
 			Flag:          "test.provisioner",
 			FlagShorthand: "p",
@@ -156,6 +156,47 @@ func TestTemplatePush(t *testing.T) {
 		require.Equal(t, "example", templateVersions[1].Name)
 	})
 
+	t.Run("RequiresApproval", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		approvals := int32(1)
+		_, err := client.UpdateTemplateMeta(context.Background(), template.ID, codersdk.UpdateTemplateMeta{
+			RequiredPromotionApprovals: &approvals,
+		})
+		require.NoError(t, err)
+
+		source := clitest.CreateTemplateVersionSource(t, &echo.Responses{
+			Parse:          echo.ParseComplete,
+			ProvisionPlan:  echo.ProvisionComplete,
+			ProvisionApply: echo.ProvisionComplete,
+		})
+		inv, root := clitest.New(t, "templates", "push", template.Name, "--directory", source, "--test.provisioner", string(database.ProvisionerTypeEcho), "--name", "example", "--yes")
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t).Attach(inv)
+
+		execDone := make(chan error)
+		go func() {
+			execDone <- inv.Run()
+		}()
+		pty.ExpectMatch("pending with 0/1 approvals")
+		require.NoError(t, <-execDone)
+
+		// The version is pending promotion instead of being active.
+		template, err = client.Template(context.Background(), template.ID)
+		require.NoError(t, err)
+		require.Equal(t, version.ID, template.ActiveVersionID)
+		promotions, err := client.TemplateVersionPromotions(context.Background(), template.ID)
+		require.NoError(t, err)
+		require.Len(t, promotions, 1)
+		require.Equal(t, "example", promotions[0].TemplateVersionName)
+		require.Equal(t, codersdk.TemplateVersionPromotionStatusPending, promotions[0].Status)
+	})
+
 	// This test modifies the working directory.
 	//nolint:paralleltest
 	t.Run("UseWorkingDir", func(t *testing.T) {
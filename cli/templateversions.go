@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -23,12 +24,22 @@ func (r *RootCmd) templateVersions() *clibase.Cmd {
 				Description: "List versions of a specific template",
 				Command:     "coder templates versions list my-template",
 			},
+			example{
+				Description: "Request the promotion of a version to the active version",
+				Command:     "coder templates versions promote my-template my-version",
+			},
+			example{
+				Description: "Approve the promotion of a version",
+				Command:     "coder templates versions approve my-template my-version",
+			},
 		),
 		Handler: func(inv *clibase.Invocation) error {
 			return inv.Command.HelpHandler(inv)
 		},
 		Children: []*clibase.Cmd{
 			r.templateVersionsList(),
+			r.templateVersionsPromote(),
+			r.templateVersionsApprove(),
 		},
 	}
 
@@ -115,3 +126,147 @@ func templateVersionsToRows(activeVersionID uuid.UUID, templateVersions ...coder
 
 	return rows
 }
+
+func (r *RootCmd) templateVersionsPromote() *clibase.Cmd {
+	client := new(codersdk.Client)
+
+	cmd := &clibase.Cmd{
+		Use: "promote <template> <version>",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Short: "Request the promotion of a version to the active version of the template",
+		Long:  "A dry-run of the version is started, and the version is promoted once the dry-run succeeds and the promotion has the number of approvals the template requires. Running the command again for a pending promotion restarts a failed dry-run.",
+		Handler: func(inv *clibase.Invocation) error {
+			organization, err := CurrentOrganization(inv, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			template, err := client.TemplateByName(inv.Context(), organization.ID, inv.Args[0])
+			if err != nil {
+				return xerrors.Errorf("get template by name: %w", err)
+			}
+			version, err := client.TemplateVersionByName(inv.Context(), template.ID, inv.Args[1])
+			if err != nil {
+				return xerrors.Errorf("get template version by name: %w", err)
+			}
+
+			return promoteTemplateVersion(inv, client, template, version.ID)
+		},
+	}
+
+	return cmd
+}
+
+func (r *RootCmd) templateVersionsApprove() *clibase.Cmd {
+	client := new(codersdk.Client)
+
+	cmd := &clibase.Cmd{
+		Use: "approve <template> <version>",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Short: "Approve the pending promotion of a version",
+		Handler: func(inv *clibase.Invocation) error {
+			organization, err := CurrentOrganization(inv, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			template, err := client.TemplateByName(inv.Context(), organization.ID, inv.Args[0])
+			if err != nil {
+				return xerrors.Errorf("get template by name: %w", err)
+			}
+			version, err := client.TemplateVersionByName(inv.Context(), template.ID, inv.Args[1])
+			if err != nil {
+				return xerrors.Errorf("get template version by name: %w", err)
+			}
+			promotions, err := client.TemplateVersionPromotions(inv.Context(), template.ID)
+			if err != nil {
+				return xerrors.Errorf("get template version promotions: %w", err)
+			}
+
+			for _, promotion := range promotions {
+				if promotion.TemplateVersionID != version.ID || promotion.Status != codersdk.TemplateVersionPromotionStatusPending {
+					continue
+				}
+				promotion, err = client.ApproveTemplateVersionPromotion(inv.Context(), template.ID, promotion.ID)
+				if err != nil {
+					return xerrors.Errorf("approve template version promotion: %w", err)
+				}
+				_, _ = fmt.Fprintln(inv.Stdout, templateVersionPromotionSummary(promotion))
+				return nil
+			}
+			return xerrors.Errorf("version %q of template %q has no pending promotion", version.Name, template.Name)
+		},
+	}
+
+	return cmd
+}
+
+// promoteTemplateVersion requests the promotion of a version and waits for
+// its dry-run to complete, so the version is promoted straight away if the
+// promotion already has enough approvals.
+func promoteTemplateVersion(inv *clibase.Invocation, client *codersdk.Client, template codersdk.Template, versionID uuid.UUID) error {
+	ctx := inv.Context()
+	req := codersdk.CreateTemplateVersionPromotionRequest{
+		TemplateVersionID: versionID,
+	}
+	promotion, err := client.CreateTemplateVersionPromotion(ctx, template.ID, req)
+	if err != nil {
+		return xerrors.Errorf("create template version promotion: %w", err)
+	}
+
+	if promotion.Status == codersdk.TemplateVersionPromotionStatusPending {
+		_, _ = fmt.Fprintf(inv.Stdout, "Waiting for the dry-run of %s...\n", cliui.Styles.Keyword.Render(promotion.TemplateVersionName))
+		job, err := awaitTemplateVersionDryRun(ctx, client, promotion)
+		if err != nil {
+			return err
+		}
+		if job.Status != codersdk.ProvisionerJobSucceeded {
+			return xerrors.Errorf("dry-run %s: %s", job.Status, job.Error)
+		}
+		// Requesting the promotion again promotes the version if it's ready.
+		promotion, err = client.CreateTemplateVersionPromotion(ctx, template.ID, req)
+		if err != nil {
+			return xerrors.Errorf("create template version promotion: %w", err)
+		}
+	}
+
+	_, _ = fmt.Fprintln(inv.Stdout, templateVersionPromotionSummary(promotion))
+	return nil
+}
+
+func awaitTemplateVersionDryRun(ctx context.Context, client *codersdk.Client, promotion codersdk.TemplateVersionPromotion) (codersdk.ProvisionerJob, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	job := promotion.DryRun
+	for job.Status.Active() {
+		select {
+		case <-ctx.Done():
+			return codersdk.ProvisionerJob{}, ctx.Err()
+		case <-ticker.C:
+		}
+		var err error
+		job, err = client.TemplateVersionDryRun(ctx, promotion.TemplateVersionID, promotion.DryRun.ID)
+		if err != nil {
+			return codersdk.ProvisionerJob{}, xerrors.Errorf("get dry-run: %w", err)
+		}
+	}
+	return job, nil
+}
+
+func templateVersionPromotionSummary(promotion codersdk.TemplateVersionPromotion) string {
+	name := cliui.Styles.Keyword.Render(promotion.TemplateVersionName)
+	if promotion.Status == codersdk.TemplateVersionPromotionStatusPromoted {
+		return fmt.Sprintf("Promoted %s to the active version!", name)
+	}
+	if promotion.Status == codersdk.TemplateVersionPromotionStatusSuperseded {
+		return fmt.Sprintf("The promotion of %s was superseded by another version.", name)
+	}
+	if promotion.DryRun.Status != codersdk.ProvisionerJobSucceeded {
+		return fmt.Sprintf("The promotion of %s is waiting for its dry-run to succeed (%d/%d approvals).", name, len(promotion.ApproverIDs), promotion.RequiredApprovals)
+	}
+	return fmt.Sprintf("The promotion of %s is pending with %d/%d approvals.", name, len(promotion.ApproverIDs), promotion.RequiredApprovals)
+}
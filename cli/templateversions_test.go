@@ -7,7 +7,9 @@ import (
 
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
 )
 
 func TestTemplateVersions(t *testing.T) {
@@ -36,4 +38,46 @@ func TestTemplateVersions(t *testing.T) {
 		pty.ExpectMatch(version.CreatedBy.Username)
 		pty.ExpectMatch("Active")
 	})
+
+	t.Run("PromoteAndApprove", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		approver, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleTemplateAdmin())
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		version = coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		inv, root := clitest.New(t, "templates", "edit", template.Name, "--required-promotion-approvals", "1")
+		clitest.SetupConfig(t, client, root)
+		require.NoError(t, inv.WithContext(ctx).Run())
+
+		inv, root = clitest.New(t, "templates", "versions", "promote", template.Name, version.Name)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t).Attach(inv)
+		errC := make(chan error)
+		go func() {
+			errC <- inv.WithContext(ctx).Run()
+		}()
+		pty.ExpectMatch("Waiting for the dry-run")
+		pty.ExpectMatch("pending with 0/1 approvals")
+		require.NoError(t, <-errC)
+
+		inv, root = clitest.New(t, "templates", "versions", "approve", template.Name, version.Name)
+		clitest.SetupConfig(t, approver, root)
+		pty = ptytest.New(t).Attach(inv)
+		go func() {
+			errC <- inv.WithContext(ctx).Run()
+		}()
+		pty.ExpectMatch("Promoted")
+		require.NoError(t, <-errC)
+
+		template, err := client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		require.Equal(t, version.ID, template.ActiveVersionID)
+	})
 }
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func (r *RootCmd) workspaces() *clibase.Cmd {
+	cmd := &clibase.Cmd{
+		Annotations: workspaceCommand,
+		Use:         "workspaces",
+		Short:       "Manage many workspaces at once",
+		Aliases:     []string{"workspace"},
+		Handler: func(inv *clibase.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*clibase.Cmd{
+			r.workspacesBulk(),
+		},
+	}
+	return cmd
+}
+
+type workspaceBulkRow struct {
+	Workspace string `table:"workspace,default_sort"`
+	Status    string `table:"status"`
+	Error     string `table:"error"`
+}
+
+func (r *RootCmd) workspacesBulk() *clibase.Cmd {
+	var (
+		filter    string
+		batchSize int64
+		dryRun    bool
+		client    = new(codersdk.Client)
+	)
+	cmd := &clibase.Cmd{
+		Use:   "bulk <start|stop|update|delete>",
+		Short: "Start, stop, update or delete every workspace matching a filter",
+		Long: "Builds are created on the server in batches, and the next batch is started once every build in the current batch has completed. Workspaces that are already in the requested state are skipped.\n" + formatExamples(
+			example{
+				Description: "Update every outdated workspace of a template, 20 at a time",
+				Command:     `coder workspaces bulk update --filter "template:docker" --batch-size 20`,
+			},
+			example{
+				Description: "List the workspaces that would be stopped",
+				Command:     `coder workspaces bulk stop --filter "owner:alice" --dry-run`,
+			},
+		),
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Options: clibase.OptionSet{
+			{
+				Flag:        "filter",
+				Env:         "CODER_WORKSPACES_BULK_FILTER",
+				Description: `Search query to select workspaces, using the same syntax as "coder list --search".`,
+				Default:     "owner:me",
+				Value:       clibase.StringOf(&filter),
+			},
+			{
+				Flag:        "batch-size",
+				Env:         "CODER_WORKSPACES_BULK_BATCH_SIZE",
+				Description: "Number of workspace builds to run at once.",
+				Default:     "10",
+				Value:       clibase.Int64Of(&batchSize),
+			},
+			{
+				Flag:        "dry-run",
+				Description: "List the workspaces that would be built without building them.",
+				Value:       clibase.BoolOf(&dryRun),
+			},
+			cliui.SkipPromptOption(),
+		},
+		Handler: func(inv *clibase.Invocation) error {
+			ctx := inv.Context()
+			action := codersdk.WorkspaceBulkJobAction(inv.Args[0])
+			switch action {
+			case codersdk.WorkspaceBulkJobActionStart, codersdk.WorkspaceBulkJobActionStop,
+				codersdk.WorkspaceBulkJobActionUpdate, codersdk.WorkspaceBulkJobActionDelete:
+			default:
+				return xerrors.Errorf("invalid action %q, must be one of start, stop, update or delete", action)
+			}
+			if batchSize < 1 {
+				return xerrors.New("--batch-size must be greater than 0")
+			}
+			req := codersdk.CreateWorkspaceBulkJobRequest{
+				Action:    action,
+				Filter:    filter,
+				BatchSize: int(batchSize),
+			}
+
+			// Always plan first, so the user can see what's about to happen.
+			planReq := req
+			planReq.DryRun = true
+			plan, err := client.CreateWorkspaceBulkJob(ctx, planReq)
+			if err != nil {
+				return xerrors.Errorf("plan bulk %s: %w", action, err)
+			}
+			if plan.Progress.Total == 0 {
+				_, _ = fmt.Fprintf(inv.Stdout, "No workspaces match the filter %q.\n", filter)
+				return nil
+			}
+			out, err := cliui.DisplayTable(workspaceBulkRows(plan.Workspaces), "", nil)
+			if err != nil {
+				return xerrors.Errorf("render table: %w", err)
+			}
+			_, _ = fmt.Fprintln(inv.Stdout, out)
+			_, _ = fmt.Fprintf(inv.Stdout, "\n%d workspaces to %s, %d skipped.\n", plan.Progress.Pending, action, plan.Progress.Skipped)
+			if dryRun || plan.Progress.Pending == 0 {
+				return nil
+			}
+
+			_, err = cliui.Prompt(inv, cliui.PromptOptions{
+				Text:      fmt.Sprintf("Confirm %s %d workspaces?", action, plan.Progress.Pending),
+				IsConfirm: true,
+			})
+			if err != nil {
+				return err
+			}
+
+			job, err := client.CreateWorkspaceBulkJob(ctx, req)
+			if err != nil {
+				return xerrors.Errorf("create bulk job: %w", err)
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "\nStarted bulk job %s.\n", cliui.Styles.Keyword.Render(job.ID.String()))
+
+			// Print each workspace as it completes, and the overall progress
+			// when it changes.
+			var (
+				reported     = map[string]bool{}
+				lastProgress codersdk.WorkspaceBulkJobProgress
+				ticker       = time.NewTicker(time.Second)
+			)
+			defer ticker.Stop()
+			for {
+				for _, workspace := range job.Workspaces {
+					name := workspace.OwnerName + "/" + workspace.WorkspaceName
+					if reported[name] || workspace.Status == codersdk.WorkspaceBulkJobStatusSkipped || !workspace.Status.Done() {
+						continue
+					}
+					reported[name] = true
+					if workspace.Status == codersdk.WorkspaceBulkJobStatusFailed {
+						_, _ = fmt.Fprintf(inv.Stdout, "%s %s: %s\n", cliui.Styles.Error.Render("✘"), name, workspace.Error)
+						continue
+					}
+					_, _ = fmt.Fprintf(inv.Stdout, "%s %s\n", cliui.Styles.Keyword.Render("✔"), name)
+				}
+				if job.Progress != lastProgress {
+					lastProgress = job.Progress
+					_, _ = fmt.Fprintln(inv.Stdout, cliui.Styles.Placeholder.Render(workspaceBulkProgress(job.Progress)))
+				}
+				if job.CompletedAt != nil {
+					break
+				}
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-ticker.C:
+				}
+				job, err = client.WorkspaceBulkJob(ctx, job.ID)
+				if err != nil {
+					return xerrors.Errorf("get bulk job: %w", err)
+				}
+			}
+
+			if job.Status == codersdk.WorkspaceBulkJobStatusFailed {
+				return xerrors.Errorf("bulk %s failed: %s", action, job.Error)
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "\nBulk %s completed!\n", action)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func workspaceBulkRows(workspaces []codersdk.WorkspaceBulkJobWorkspace) []workspaceBulkRow {
+	rows := make([]workspaceBulkRow, len(workspaces))
+	for i, workspace := range workspaces {
+		rows[i] = workspaceBulkRow{
+			Workspace: workspace.OwnerName + "/" + workspace.WorkspaceName,
+			Status:    string(workspace.Status),
+			Error:     workspace.Error,
+		}
+	}
+	return rows
+}
+
+func workspaceBulkProgress(progress codersdk.WorkspaceBulkJobProgress) string {
+	var details []string
+	for _, count := range []struct {
+		name  string
+		count int
+	}{
+		{"running", progress.Running},
+		{"succeeded", progress.Succeeded},
+		{"failed", progress.Failed},
+		{"skipped", progress.Skipped},
+	} {
+		if count.count > 0 {
+			details = append(details, fmt.Sprintf("%d %s", count.count, count.name))
+		}
+	}
+	return fmt.Sprintf("%d/%d done (%s)", progress.Done(), progress.Total, strings.Join(details, ", "))
+}
@@ -0,0 +1,105 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspacesBulk(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Stop", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspaces := make([]codersdk.Workspace, 0, 2)
+		for i := 0; i < 2; i++ {
+			workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+			coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+			workspaces = append(workspaces, workspace)
+		}
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		inv, root := clitest.New(t, "workspaces", "bulk", "stop", "--filter", "template:"+template.Name, "--yes")
+		clitest.SetupConfig(t, client, root)
+
+		pty := ptytest.New(t).Attach(inv)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- inv.WithContext(ctx).Run()
+		}()
+		pty.ExpectMatch("2 workspaces to stop, 0 skipped")
+		pty.ExpectMatch("Started bulk job")
+		pty.ExpectMatch("2/2 done (2 succeeded)")
+		pty.ExpectMatch("Bulk stop completed")
+
+		err := <-done
+		require.NoError(t, err, "execute failed")
+
+		for _, workspace := range workspaces {
+			workspace, err := client.Workspace(ctx, workspace.ID)
+			require.NoError(t, err)
+			require.Equal(t, codersdk.WorkspaceTransitionStop, workspace.LatestBuild.Transition)
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		inv, root := clitest.New(t, "workspaces", "bulk", "start", "--dry-run")
+		clitest.SetupConfig(t, client, root)
+
+		pty := ptytest.New(t).Attach(inv)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- inv.WithContext(ctx).Run()
+		}()
+		pty.ExpectMatch(workspace.Name)
+		pty.ExpectMatch("skipped")
+		pty.ExpectMatch("0 workspaces to start, 1 skipped")
+
+		err := <-done
+		require.NoError(t, err, "execute failed")
+
+		workspace, err = client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+	})
+
+	t.Run("InvalidAction", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		inv, root := clitest.New(t, "workspaces", "bulk", "restart")
+		clitest.SetupConfig(t, client, root)
+
+		err := inv.Run()
+		require.ErrorContains(t, err, `invalid action "restart"`)
+	})
+}
@@ -384,6 +384,31 @@ const docTemplate = `{
                 }
             }
         },
+        "/deployment/purge": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "General"
+                ],
+                "summary": "Get deployment purge stats",
+                "operationId": "get-deployment-purge-stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.DeploymentPurgeStats"
+                        }
+                    }
+                }
+            }
+        },
         "/deployment/ssh": {
             "get": {
                 "security": [
@@ -820,6 +845,228 @@ const docTemplate = `{
                 }
             }
         },
+        "/notifications/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Get notification webhooks",
+                "operationId": "get-notification-webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.NotificationWebhook"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Create notification webhook",
+                "operationId": "create-notification-webhook",
+                "parameters": [
+                    {
+                        "description": "Create notification webhook request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.CreateNotificationWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.NotificationWebhook"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/webhooks/{webhook}": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Get notification webhook by ID",
+                "operationId": "get-notification-webhook-by-id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Webhook ID",
+                        "name": "webhook",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.NotificationWebhook"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Delete notification webhook",
+                "operationId": "delete-notification-webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Webhook ID",
+                        "name": "webhook",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Update notification webhook",
+                "operationId": "update-notification-webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Webhook ID",
+                        "name": "webhook",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Patch notification webhook request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.PatchNotificationWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.NotificationWebhook"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/webhooks/{webhook}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Get notification webhook deliveries",
+                "operationId": "get-notification-webhook-deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Webhook ID",
+                        "name": "webhook",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of deliveries returned",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.NotificationWebhookDelivery"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/organizations": {
             "post": {
                 "security": [
@@ -1230,6 +1477,44 @@ const docTemplate = `{
                 }
             }
         },
+        "/organizations/{organization}/provisionerjobs/queue": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Provisioning"
+                ],
+                "summary": "Get provisioner job queue",
+                "operationId": "get-provisioner-job-queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Organization ID",
+                        "name": "organization",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.QueuedProvisionerJob"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/organizations/{organization}/templates": {
             "get": {
                 "security": [
@@ -1698,29 +1983,101 @@ const docTemplate = `{
                 }
             }
         },
-        "/replicas": {
-            "get": {
+        "/provisionerjobs/{provisionerjob}/bump": {
+            "post": {
                 "security": [
                     {
                         "CoderSessionToken": []
                     }
                 ],
+                "description": "Moves a pending job to the front of the queue.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Enterprise"
+                    "Provisioning"
+                ],
+                "summary": "Bump provisioner job",
+                "operationId": "bump-provisioner-job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Provisioner job ID",
+                        "name": "provisionerjob",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get active replicas",
-                "operationId": "get-active-replicas",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/codersdk.Replica"
-                            }
+                            "$ref": "#/definitions/codersdk.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/provisionerjobs/{provisionerjob}/cancel": {
+            "patch": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "description": "Cancels a job regardless of the workspace or template it\nbelongs to. Pending jobs are never acquired, and running jobs\nare canceled by their provisioner daemon.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Provisioning"
+                ],
+                "summary": "Cancel provisioner job",
+                "operationId": "cancel-provisioner-job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Provisioner job ID",
+                        "name": "provisionerjob",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/replicas": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Enterprise"
+                ],
+                "summary": "Get active replicas",
+                "operationId": "get-active-replicas",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.Replica"
+                            }
                         }
                     }
                 }
@@ -2075,6 +2432,140 @@ const docTemplate = `{
                 }
             }
         },
+        "/templates/{template}/promotions": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Get template version promotions",
+                "operationId": "get-template-version-promotions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Template ID",
+                        "name": "template",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.TemplateVersionPromotion"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "description": "Requests that a version becomes the active version of the\ntemplate. A dry-run of the version is started, and the version\nis promoted once the dry-run succeeds and the promotion has the\nnumber of approvals the template requires. If the version\nalready has a pending promotion, a failed dry-run is restarted\nand the version is promoted if it's ready.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Create template version promotion",
+                "operationId": "create-template-version-promotion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Template ID",
+                        "name": "template",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create template version promotion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.CreateTemplateVersionPromotionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.TemplateVersionPromotion"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.TemplateVersionPromotion"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{template}/promotions/{templateversionpromotion}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "description": "Records the approval of the authenticated user, who must be\nallowed to update the template and can't be the requester of\nthe promotion. The version is promoted if the approval meets\nthe requirement of the template and the dry-run has succeeded.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Approve template version promotion",
+                "operationId": "approve-template-version-promotion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Template ID",
+                        "name": "template",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Template version promotion ID",
+                        "name": "templateversionpromotion",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.TemplateVersionPromotion"
+                        }
+                    }
+                }
+            }
+        },
         "/templates/{template}/versions": {
             "get": {
                 "security": [
@@ -2470,6 +2961,49 @@ const docTemplate = `{
                 }
             }
         },
+        "/templateversions/{templateversion}/dry-run/{jobID}/changes": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Get template version dry-run resource changes by job ID",
+                "operationId": "get-template-version-dry-run-resource-changes-by-job-id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Template version ID",
+                        "name": "templateversion",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Job ID",
+                        "name": "jobID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.TemplateVersionDryRunChanges"
+                        }
+                    }
+                }
+            }
+        },
         "/templateversions/{templateversion}/dry-run/{jobID}/logs": {
             "get": {
                 "security": [
@@ -4288,7 +4822,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/workspaceagents/me/report-lifecycle": {
+        "/workspaceagents/me/metadata/{key}": {
             "post": {
                 "security": [
                     {
@@ -4301,17 +4835,25 @@ const docTemplate = `{
                 "tags": [
                     "Agents"
                 ],
-                "summary": "Submit workspace agent lifecycle state",
-                "operationId": "submit-workspace-agent-lifecycle-state",
+                "summary": "Submit workspace agent metadata",
+                "operationId": "submit-workspace-agent-metadata",
                 "parameters": [
                     {
-                        "description": "Workspace agent lifecycle request",
+                        "description": "Workspace agent metadata request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/agentsdk.PostLifecycleRequest"
+                            "$ref": "#/definitions/agentsdk.PostMetadataRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "metadata key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -4324,46 +4866,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/workspaceagents/me/report-stats": {
-            "post": {
+        "/workspaceagents/me/port-shares/watch": {
+            "get": {
                 "security": [
                     {
                         "CoderSessionToken": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "The current port shares are sent when the request is made,\nand again whenever they change.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
                     "Agents"
                 ],
-                "summary": "Submit workspace agent stats",
-                "operationId": "submit-workspace-agent-stats",
-                "parameters": [
-                    {
-                        "description": "Stats request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/agentsdk.Stats"
-                        }
-                    }
-                ],
+                "summary": "Watch workspace agent port shares",
+                "operationId": "watch-workspace-agent-port-shares",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/agentsdk.StatsResponse"
+                            "$ref": "#/definitions/codersdk.Response"
                         }
                     }
                 }
             }
         },
-        "/workspaceagents/me/startup": {
+        "/workspaceagents/me/report-lifecycle": {
             "post": {
                 "security": [
                     {
@@ -4373,17 +4902,159 @@ const docTemplate = `{
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
                     "Agents"
                 ],
-                "summary": "Submit workspace agent startup",
-                "operationId": "submit-workspace-agent-startup",
+                "summary": "Submit workspace agent lifecycle state",
+                "operationId": "submit-workspace-agent-lifecycle-state",
                 "parameters": [
                     {
-                        "description": "Startup request",
+                        "description": "Workspace agent lifecycle request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/agentsdk.PostLifecycleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Success"
+                    }
+                },
+                "x-apidocgen": {
+                    "skip": true
+                }
+            }
+        },
+        "/workspaceagents/me/report-stats": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Submit workspace agent stats",
+                "operationId": "submit-workspace-agent-stats",
+                "parameters": [
+                    {
+                        "description": "Stats request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/agentsdk.Stats"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/agentsdk.StatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaceagents/me/session-recordings": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "description": "The recording is the body of the request, in asciicast v2 format.",
+                "consumes": [
+                    "application/x-asciicast"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Upload workspace agent session recording",
+                "operationId": "upload-workspace-agent-session-recording",
+                "parameters": [
+                    {
+                        "description": "Session recording",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "ssh",
+                            "reconnecting_pty"
+                        ],
+                        "type": "string",
+                        "description": "Session type",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Session start time",
+                        "name": "started_at",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Session end time",
+                        "name": "ended_at",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.WorkspaceSessionRecording"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaceagents/me/startup": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Submit workspace agent startup",
+                "operationId": "submit-workspace-agent-startup",
+                "parameters": [
+                    {
+                        "description": "Startup request",
                         "name": "request",
                         "in": "body",
                         "required": true,
@@ -4578,6 +5249,122 @@ const docTemplate = `{
                 }
             }
         },
+        "/workspaceagents/{workspaceagent}/port-shares": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Get workspace agent port shares",
+                "operationId": "get-workspace-agent-port-shares",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace agent ID",
+                        "name": "workspaceagent",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.WorkspaceAgentPortShares"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Upsert workspace agent port share",
+                "operationId": "upsert-workspace-agent-port-share",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace agent ID",
+                        "name": "workspaceagent",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Port share",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.UpsertWorkspaceAgentPortShareRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.WorkspaceAgentPortShare"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaceagents/{workspaceagent}/port-shares/{port}": {
+            "delete": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Delete workspace agent port share",
+                "operationId": "delete-workspace-agent-port-share",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace agent ID",
+                        "name": "workspaceagent",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Port",
+                        "name": "port",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
         "/workspaceagents/{workspaceagent}/pty": {
             "get": {
                 "security": [
@@ -4663,6 +5450,41 @@ const docTemplate = `{
                 }
             }
         },
+        "/workspaceagents/{workspaceagent}/watch-metadata": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Watch for workspace agent metadata updates",
+                "operationId": "watch-for-workspace-agent-metadata-updates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace agent ID",
+                        "name": "workspaceagent",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.Response"
+                        }
+                    }
+                }
+            }
+        },
         "/workspacebuilds/{workspacebuild}": {
             "get": {
                 "security": [
@@ -4886,15 +5708,135 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.WorkspaceBuild"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "List workspaces",
+                "operationId": "list-workspaces",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by owner username",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by template name",
+                        "name": "template",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter with partial-match by workspace name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "running",
+                            "stopping",
+                            "stopped",
+                            "failed",
+                            "canceling",
+                            "canceled",
+                            "deleted",
+                            "deleting"
+                        ],
+                        "type": "string",
+                        "description": "Filter by workspace status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "connected",
+                            "connecting",
+                            "disconnected",
+                            "timeout"
+                        ],
+                        "type": "string",
+                        "description": "Filter by agent status",
+                        "name": "has_agent",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.WorkspacesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/bulk": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "description": "Creates builds for every workspace matching the filter that\nthe user is allowed to perform the action on. Builds are\ncreated in batches, and the next batch is started once every\nbuild in the current batch has completed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Create workspace bulk job",
+                "operationId": "create-workspace-bulk-job",
+                "parameters": [
+                    {
+                        "description": "Create workspace bulk job request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.CreateWorkspaceBulkJobRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dry run",
                         "schema": {
-                            "$ref": "#/definitions/codersdk.WorkspaceBuild"
+                            "$ref": "#/definitions/codersdk.WorkspaceBulkJob"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.WorkspaceBulkJob"
                         }
                     }
                 }
             }
         },
-        "/workspaces": {
+        "/workspaces/bulk/{workspacebulkjob}": {
             "get": {
                 "security": [
                     {
@@ -4907,62 +5849,23 @@ const docTemplate = `{
                 "tags": [
                     "Workspaces"
                 ],
-                "summary": "List workspaces",
-                "operationId": "list-workspaces",
+                "summary": "Get workspace bulk job",
+                "operationId": "get-workspace-bulk-job",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Filter by owner username",
-                        "name": "owner",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by template name",
-                        "name": "template",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter with partial-match by workspace name",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "pending",
-                            "running",
-                            "stopping",
-                            "stopped",
-                            "failed",
-                            "canceling",
-                            "canceled",
-                            "deleted",
-                            "deleting"
-                        ],
-                        "type": "string",
-                        "description": "Filter by workspace status",
-                        "name": "status",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "connected",
-                            "connecting",
-                            "disconnected",
-                            "timeout"
-                        ],
-                        "type": "string",
-                        "description": "Filter by agent status",
-                        "name": "has_agent",
-                        "in": "query"
+                        "format": "uuid",
+                        "description": "Workspace bulk job ID",
+                        "name": "workspacebulkjob",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/codersdk.WorkspacesResponse"
+                            "$ref": "#/definitions/codersdk.WorkspaceBulkJob"
                         }
                     }
                 }
@@ -5245,6 +6148,82 @@ const docTemplate = `{
                 }
             }
         },
+        "/workspaces/{workspace}/session-recordings": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Get workspace session recordings",
+                "operationId": "get-workspace-session-recordings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace ID",
+                        "name": "workspace",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.WorkspaceSessionRecording"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{workspace}/session-recordings/{recording}": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "description": "The recording is returned in asciicast v2 format.",
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Download workspace session recording",
+                "operationId": "download-workspace-session-recording",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace ID",
+                        "name": "workspace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Recording ID",
+                        "name": "recording",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
         "/workspaces/{workspace}/ttl": {
             "put": {
                 "security": [
@@ -5422,9 +6401,32 @@ const docTemplate = `{
                     "description": "GitAuthConfigs stores the number of Git configurations\nthe Coder deployment has. If this number is \u003e0, we\nset up special configuration in the workspace.",
                     "type": "integer"
                 },
+                "metadata": {
+                    "description": "Metadata describes the metadata items the agent should collect and\nreport with PostMetadata.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.WorkspaceAgentMetadataDescription"
+                    }
+                },
                 "motd_file": {
                     "type": "string"
                 },
+                "port_shares": {
+                    "description": "PortShares are the ports shared on the agent. Changes are received\nwith WatchPortShares.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceAgentPortShares"
+                        }
+                    ]
+                },
+                "reconnecting_pty_buffer_size": {
+                    "description": "ReconnectingPTYBufferSize is the number of bytes of output each\nreconnecting PTY keeps for replay. Zero means the agent default.",
+                    "type": "integer"
+                },
+                "session_recording": {
+                    "description": "SessionRecording makes the agent record PTY sessions and upload them\nwith PostSessionRecording once they end.",
+                    "type": "boolean"
+                },
                 "shutdown_script": {
                     "type": "string"
                 },
@@ -5473,6 +6475,20 @@ const docTemplate = `{
                 }
             }
         },
+        "agentsdk.PostMetadataRequest": {
+            "type": "object",
+            "properties": {
+                "collected_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
         "agentsdk.PostStartupRequest": {
             "type": "object",
             "properties": {
@@ -5675,6 +6691,17 @@ const docTemplate = `{
                 }
             }
         },
+        "clibase.Struct-array_codersdk_RateLimitPolicy": {
+            "type": "object",
+            "properties": {
+                "value": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.RateLimitPolicy"
+                    }
+                }
+            }
+        },
         "clibase.URL": {
             "type": "object",
             "properties": {
@@ -5969,6 +6996,38 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.AuditExportConfig": {
+            "type": "object",
+            "properties": {
+                "file_max_backups": {
+                    "type": "integer"
+                },
+                "file_max_size": {
+                    "type": "integer"
+                },
+                "file_path": {
+                    "type": "string"
+                },
+                "syslog_url": {
+                    "$ref": "#/definitions/clibase.URL"
+                },
+                "webhook_batch_size": {
+                    "type": "integer"
+                },
+                "webhook_buffer_dir": {
+                    "type": "string"
+                },
+                "webhook_flush_interval": {
+                    "type": "integer"
+                },
+                "webhook_secret": {
+                    "type": "string"
+                },
+                "webhook_url": {
+                    "$ref": "#/definitions/clibase.URL"
+                }
+            }
+        },
         "codersdk.AuditLog": {
             "type": "object",
             "properties": {
@@ -6154,12 +7213,16 @@ const docTemplate = `{
             "enum": [
                 "initiator",
                 "autostart",
-                "autostop"
+                "autostop",
+                "dormancy",
+                "autodelete"
             ],
             "x-enum-varnames": [
                 "BuildReasonInitiator",
                 "BuildReasonAutostart",
-                "BuildReasonAutostop"
+                "BuildReasonAutostop",
+                "BuildReasonDormancy",
+                "BuildReasonAutodelete"
             ]
         },
         "codersdk.CreateFirstUserRequest": {
@@ -6211,6 +7274,38 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.CreateNotificationWebhookRequest": {
+            "type": "object",
+            "required": [
+                "events",
+                "name",
+                "url"
+            ],
+            "properties": {
+                "enabled": {
+                    "description": "Enabled defaults to true.",
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.NotificationEventType"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "payload_template": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
         "codersdk.CreateOrganizationRequest": {
             "type": "object",
             "required": [
@@ -6283,6 +7378,10 @@ const docTemplate = `{
                     "description": "DefaultTTLMillis allows optionally specifying the default TTL\nfor all workspaces created from this template.",
                     "type": "integer"
                 },
+                "delete_ttl_ms": {
+                    "description": "DeleteTTLMillis allows optionally specifying how long a stopped\nworkspace may go unused before it is deleted automatically.",
+                    "type": "integer"
+                },
                 "description": {
                     "description": "Description is a description of what the template contains. It must be\nless than 128 bytes.",
                     "type": "string"
@@ -6295,6 +7394,10 @@ const docTemplate = `{
                     "description": "Icon is a relative path or external URL that specifies\nan icon to be displayed in the dashboard.",
                     "type": "string"
                 },
+                "inactivity_ttl_ms": {
+                    "description": "InactivityTTLMillis allows optionally specifying how long a running\nworkspace may go unused before it is stopped automatically.",
+                    "type": "integer"
+                },
                 "max_ttl_ms": {
                     "description": "MaxTTLMillis allows optionally specifying the max lifetime for\nworkspaces created from this template.",
                     "type": "integer"
@@ -6337,11 +7440,35 @@ const docTemplate = `{
                         "$ref": "#/definitions/codersdk.VariableValue"
                     }
                 },
+                "workspace_id": {
+                    "description": "WorkspaceID is the workspace of the template to plan the changes\nagainst. Changes are planned against an empty workspace if it's not set.",
+                    "type": "string",
+                    "format": "uuid"
+                },
                 "workspace_name": {
                     "type": "string"
                 }
             }
         },
+        "codersdk.CreateTemplateVersionPromotionRequest": {
+            "type": "object",
+            "required": [
+                "template_version_id"
+            ],
+            "properties": {
+                "rich_parameter_values": {
+                    "description": "RichParameterValues are used for the dry-run. Parameters that aren't\nset use their default value.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.WorkspaceBuildParameter"
+                    }
+                },
+                "template_version_id": {
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
         "codersdk.CreateTemplateVersionRequest": {
             "type": "object",
             "required": [
@@ -6367,11 +7494,7 @@ const docTemplate = `{
                     }
                 },
                 "provisioner": {
-                    "type": "string",
-                    "enum": [
-                        "terraform",
-                        "echo"
-                    ]
+                    "type": "string"
                 },
                 "storage_method": {
                     "enum": [
@@ -6559,16 +7682,51 @@ const docTemplate = `{
                 },
                 "transition": {
                     "enum": [
-                        "create",
+                        "create",
+                        "start",
+                        "stop",
+                        "delete"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceTransition"
+                        }
+                    ]
+                }
+            }
+        },
+        "codersdk.CreateWorkspaceBulkJobRequest": {
+            "type": "object",
+            "required": [
+                "action"
+            ],
+            "properties": {
+                "action": {
+                    "enum": [
                         "start",
                         "stop",
+                        "update",
                         "delete"
                     ],
                     "allOf": [
                         {
-                            "$ref": "#/definitions/codersdk.WorkspaceTransition"
+                            "$ref": "#/definitions/codersdk.WorkspaceBulkJobAction"
                         }
                     ]
+                },
+                "batch_size": {
+                    "description": "BatchSize is the number of builds to run at once. The next batch is\nstarted once every build in the current batch has completed. Defaults\nto 10.",
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1
+                },
+                "dry_run": {
+                    "description": "DryRun returns the workspaces that would be built without creating the\njob.",
+                    "type": "boolean"
+                },
+                "filter": {
+                    "description": "Filter uses the same syntax as the workspaces search query, e.g.\n\"template:docker outdated:true\".",
+                    "type": "string"
                 }
             }
         },
@@ -6714,6 +7872,22 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.DeploymentPurgeStats": {
+            "type": "object",
+            "properties": {
+                "collected_at": {
+                    "description": "CollectedAt is the time in which stats are collected at.",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.PurgeTableStats"
+                    }
+                }
+            }
+        },
         "codersdk.DeploymentStats": {
             "type": "object",
             "properties": {
@@ -6760,6 +7934,9 @@ const docTemplate = `{
                 "agent_stat_refresh_interval": {
                     "type": "integer"
                 },
+                "audit_export": {
+                    "$ref": "#/definitions/codersdk.AuditExportConfig"
+                },
                 "audit_logging": {
                     "type": "boolean"
                 },
@@ -6857,12 +8034,18 @@ const docTemplate = `{
                 "redirect_to_access_url": {
                     "type": "boolean"
                 },
+                "retention": {
+                    "$ref": "#/definitions/codersdk.RetentionConfig"
+                },
                 "scim_api_key": {
                     "type": "string"
                 },
                 "secure_auth_cookie": {
                     "type": "boolean"
                 },
+                "session_recording": {
+                    "type": "boolean"
+                },
                 "ssh_keygen_algorithm": {
                     "type": "string"
                 },
@@ -7245,6 +8428,103 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.NotificationEventType": {
+            "type": "string",
+            "enum": [
+                "workspace_build_succeeded",
+                "workspace_build_failed",
+                "workspace_autostop_imminent",
+                "workspace_deleted",
+                "template_version_promoted"
+            ],
+            "x-enum-varnames": [
+                "NotificationEventWorkspaceBuildSucceeded",
+                "NotificationEventWorkspaceBuildFailed",
+                "NotificationEventWorkspaceAutostopImminent",
+                "NotificationEventWorkspaceDeleted",
+                "NotificationEventTemplateVersionPromoted"
+            ]
+        },
+        "codersdk.NotificationWebhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.NotificationEventType"
+                    }
+                },
+                "has_secret": {
+                    "description": "HasSecret is true when requests are signed with the\nX-Coder-Signature-256 header. The secret itself is never returned.",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "payload_template": {
+                    "description": "PayloadTemplate is a Go text/template rendered with a\nNotificationEvent. The JSON encoding of the event is sent when it is\nempty.",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "codersdk.NotificationWebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "event_type": {
+                    "$ref": "#/definitions/codersdk.NotificationEventType"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "request_body": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "webhook_id": {
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
         "codersdk.OAuth2Config": {
             "type": "object",
             "properties": {
@@ -7323,9 +8603,15 @@ const docTemplate = `{
                 "email_field": {
                     "type": "string"
                 },
+                "group_auto_create": {
+                    "type": "boolean"
+                },
                 "group_mapping": {
                     "type": "object"
                 },
+                "group_regex_filter": {
+                    "type": "string"
+                },
                 "groups_field": {
                     "type": "string"
                 },
@@ -7347,6 +8633,18 @@ const docTemplate = `{
                 "sign_in_text": {
                     "type": "string"
                 },
+                "user_role_field": {
+                    "type": "string"
+                },
+                "user_role_mapping": {
+                    "type": "object"
+                },
+                "user_roles_default": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "username_field": {
                     "type": "string"
                 }
@@ -7582,6 +8880,33 @@ const docTemplate = `{
                 "ParameterSourceSchemeData"
             ]
         },
+        "codersdk.PatchNotificationWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.NotificationEventType"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "payload_template": {
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "Secret replaces the signing secret. An empty string disables signing.",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
         "codersdk.PatchTemplateVersionRequest": {
             "type": "object",
             "properties": {
@@ -7615,17 +8940,32 @@ const docTemplate = `{
         "codersdk.ProvisionerConfig": {
             "type": "object",
             "properties": {
+                "cache_max_size": {
+                    "type": "integer"
+                },
+                "cache_mirror": {
+                    "type": "string"
+                },
                 "daemon_poll_interval": {
                     "type": "integer"
                 },
                 "daemon_poll_jitter": {
                     "type": "integer"
                 },
+                "daemon_psk": {
+                    "type": "string"
+                },
                 "daemons": {
                     "type": "integer"
                 },
                 "force_cancel_interval": {
                     "type": "integer"
+                },
+                "plugins": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
@@ -7636,10 +8976,19 @@ const docTemplate = `{
                     "type": "string",
                     "format": "date-time"
                 },
+                "current_job_id": {
+                    "description": "CurrentJobID is the job the daemon reported running in its last\nheartbeat.",
+                    "type": "string",
+                    "format": "uuid"
+                },
                 "id": {
                     "type": "string",
                     "format": "uuid"
                 },
+                "last_seen_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -7649,6 +8998,18 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "status": {
+                    "enum": [
+                        "idle",
+                        "busy",
+                        "offline"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.ProvisionerDaemonStatus"
+                        }
+                    ]
+                },
                 "tags": {
                     "type": "object",
                     "additionalProperties": {
@@ -7662,9 +9023,25 @@ const docTemplate = `{
                             "$ref": "#/definitions/sql.NullTime"
                         }
                     ]
+                },
+                "version": {
+                    "type": "string"
                 }
             }
         },
+        "codersdk.ProvisionerDaemonStatus": {
+            "type": "string",
+            "enum": [
+                "idle",
+                "busy",
+                "offline"
+            ],
+            "x-enum-varnames": [
+                "ProvisionerDaemonIdle",
+                "ProvisionerDaemonBusy",
+                "ProvisionerDaemonOffline"
+            ]
+        },
         "codersdk.ProvisionerJob": {
             "type": "object",
             "properties": {
@@ -7787,6 +9164,19 @@ const docTemplate = `{
                 "ProvisionerJobFailed"
             ]
         },
+        "codersdk.ProvisionerJobType": {
+            "type": "string",
+            "enum": [
+                "template_version_import",
+                "workspace_build",
+                "template_version_dry_run"
+            ],
+            "x-enum-varnames": [
+                "ProvisionerJobTypeTemplateVersionImport",
+                "ProvisionerJobTypeWorkspaceBuild",
+                "ProvisionerJobTypeTemplateVersionDryRun"
+            ]
+        },
         "codersdk.ProvisionerLogLevel": {
             "type": "string",
             "enum": [
@@ -7805,6 +9195,27 @@ const docTemplate = `{
                 "ProvisionerStorageMethodFile"
             ]
         },
+        "codersdk.PurgeTableStats": {
+            "type": "object",
+            "properties": {
+                "before": {
+                    "description": "Before is the cutoff time. Rows older than this are purged.",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "retention_ms": {
+                    "description": "RetentionMillis is how long rows are kept before being purged. Zero\nmeans rows are kept forever.",
+                    "type": "integer"
+                },
+                "rows": {
+                    "description": "Rows is the number of rows that would be purged.",
+                    "type": "integer"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
         "codersdk.PutExtendWorkspaceRequest": {
             "type": "object",
             "required": [
@@ -7817,6 +9228,60 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.QueuedProvisionerJob": {
+            "type": "object",
+            "properties": {
+                "available_workers": {
+                    "description": "AvailableWorkers are the online provisioner daemons that can acquire\nthe job. A job without any will wait until a matching daemon connects.",
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "format": "uuid"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "initiator_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "priority": {
+                    "description": "Priority decides the order jobs are acquired in. Jobs with a higher\npriority are acquired first, and jobs with the same priority are\nacquired in the order they were created.",
+                    "type": "integer"
+                },
+                "provisioner": {
+                    "type": "string"
+                },
+                "queue_position": {
+                    "description": "QueuePosition starts at 1 and counts the pending jobs that will be\nacquired before this one by the daemons that can run it.",
+                    "type": "integer"
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "enum": [
+                        "template_version_import",
+                        "workspace_build",
+                        "template_version_dry_run"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.ProvisionerJobType"
+                        }
+                    ]
+                }
+            }
+        },
         "codersdk.RateLimitConfig": {
             "type": "object",
             "properties": {
@@ -7825,6 +9290,36 @@ const docTemplate = `{
                 },
                 "disable_all": {
                     "type": "boolean"
+                },
+                "policies": {
+                    "$ref": "#/definitions/clibase.Struct-array_codersdk_RateLimitPolicy"
+                },
+                "shared": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "codersdk.RateLimitPolicy": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "Limit is the number of requests allowed per window. Zero keeps the\ndefault limit, and a negative limit disables rate limiting.",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name is one of \"api\", \"login\", \"files\", \"workspace_builds\" or\n\"agents\".",
+                    "type": "string"
+                },
+                "role_limits": {
+                    "description": "RoleLimits overrides the limit for users with the given site roles,\ne.g. \"owner\". A zero or negative limit disables rate limiting for the\nrole.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "window": {
+                    "description": "Window is the duration requests are counted over. Zero keeps the\ndefault of a minute.",
+                    "type": "integer"
                 }
             }
         },
@@ -7874,7 +9369,9 @@ const docTemplate = `{
                 "git_ssh_key",
                 "api_key",
                 "group",
-                "license"
+                "license",
+                "workspace_session_recording",
+                "template_version_promotion"
             ],
             "x-enum-varnames": [
                 "ResourceTypeTemplate",
@@ -7885,7 +9382,9 @@ const docTemplate = `{
                 "ResourceTypeGitSSHKey",
                 "ResourceTypeAPIKey",
                 "ResourceTypeGroup",
-                "ResourceTypeLicense"
+                "ResourceTypeLicense",
+                "ResourceTypeWorkspaceSessionRecording",
+                "ResourceTypeTemplateVersionPromotion"
             ]
         },
         "codersdk.Response": {
@@ -7908,6 +9407,23 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.RetentionConfig": {
+            "type": "object",
+            "properties": {
+                "audit_logs": {
+                    "type": "integer"
+                },
+                "provisioner_job_logs": {
+                    "type": "integer"
+                },
+                "purge_batch_size": {
+                    "type": "integer"
+                },
+                "workspace_build_states": {
+                    "type": "integer"
+                }
+            }
+        },
         "codersdk.Role": {
             "type": "object",
             "properties": {
@@ -8065,6 +9581,10 @@ const docTemplate = `{
                 "allow_user_cancel_workspace_jobs": {
                     "type": "boolean"
                 },
+                "autostop_requirement": {
+                    "description": "AutostopRequirement is an enterprise feature. It is a weekly cron\nschedule without a timezone, e.g. \"0 2 * * 0\". Running workspaces must\nbe stopped at the next occurrence of the schedule in the timezone of\nthe workspace owner.",
+                    "type": "string"
+                },
                 "build_time_stats": {
                     "$ref": "#/definitions/codersdk.TemplateBuildTimeStats"
                 },
@@ -8082,6 +9602,9 @@ const docTemplate = `{
                 "default_ttl_ms": {
                     "type": "integer"
                 },
+                "delete_ttl_ms": {
+                    "type": "integer"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -8095,6 +9618,10 @@ const docTemplate = `{
                     "type": "string",
                     "format": "uuid"
                 },
+                "inactivity_ttl_ms": {
+                    "description": "InactivityTTLMillis and DeleteTTLMillis are enterprise features. Their\nvalues are only used if your license is entitled to use the advanced\ntemplate scheduling feature.",
+                    "type": "integer"
+                },
                 "max_ttl_ms": {
                     "description": "MaxTTLMillis is an enterprise feature. It's value is only used if your\nlicense is entitled to use the advanced template scheduling feature.",
                     "type": "integer"
@@ -8112,6 +9639,10 @@ const docTemplate = `{
                         "terraform"
                     ]
                 },
+                "required_promotion_approvals": {
+                    "description": "RequiredPromotionApprovals is the number of approvals a version needs\nbefore it can become the active version. If it's zero, the active\nversion can be updated directly.",
+                    "type": "integer"
+                },
                 "updated_at": {
                     "type": "string",
                     "format": "date-time"
@@ -8284,6 +9815,25 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.TemplateVersionDryRunChanges": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.WorkspaceResourceChange"
+                    }
+                },
+                "daily_cost": {
+                    "description": "DailyCost is the total daily cost of the changed resources after the\nchanges are applied.",
+                    "type": "integer"
+                },
+                "prior_daily_cost": {
+                    "description": "PriorDailyCost is the total daily cost of the changed resources before\nthe changes are applied.",
+                    "type": "integer"
+                }
+            }
+        },
         "codersdk.TemplateVersionGitAuth": {
             "type": "object",
             "properties": {
@@ -8385,6 +9935,81 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.TemplateVersionPromotion": {
+            "type": "object",
+            "properties": {
+                "approver_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "format": "uuid"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "dry_run": {
+                    "$ref": "#/definitions/codersdk.ProvisionerJob"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "promoted_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "requester_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "required_approvals": {
+                    "description": "RequiredApprovals is the current requirement of the template, so it\ncan change while the promotion is pending.",
+                    "type": "integer"
+                },
+                "status": {
+                    "enum": [
+                        "pending",
+                        "promoted",
+                        "superseded"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.TemplateVersionPromotionStatus"
+                        }
+                    ]
+                },
+                "template_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "template_version_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "template_version_name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "codersdk.TemplateVersionPromotionStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "promoted",
+                "superseded"
+            ],
+            "x-enum-varnames": [
+                "TemplateVersionPromotionStatusPending",
+                "TemplateVersionPromotionStatusPromoted",
+                "TemplateVersionPromotionStatusSuperseded"
+            ]
+        },
         "codersdk.TemplateVersionVariable": {
             "type": "object",
             "properties": {
@@ -8577,6 +10202,30 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.UpsertWorkspaceAgentPortShareRequest": {
+            "type": "object",
+            "required": [
+                "port",
+                "share_level"
+            ],
+            "properties": {
+                "port": {
+                    "type": "integer"
+                },
+                "share_level": {
+                    "enum": [
+                        "owner",
+                        "authenticated",
+                        "public"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceAppSharingLevel"
+                        }
+                    ]
+                }
+            }
+        },
         "codersdk.User": {
             "type": "object",
             "required": [
@@ -8693,6 +10342,16 @@ const docTemplate = `{
                     "type": "string",
                     "format": "date-time"
                 },
+                "deleting_at": {
+                    "description": "DeletingAt is when the workspace will be deleted. It is only set for\nstopped workspaces whose template has a delete TTL.",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "dormant_at": {
+                    "description": "DormantAt is when the workspace will be stopped for inactivity. It is\nonly set for running workspaces whose template has an inactivity TTL.",
+                    "type": "string",
+                    "format": "date-time"
+                },
                 "id": {
                     "type": "string",
                     "format": "uuid"
@@ -8810,16 +10469,31 @@ const docTemplate = `{
                     "description": "LoginBeforeReady if true, the agent will delay logins until it is ready (e.g. executing startup script has ended).",
                     "type": "boolean"
                 },
+                "metadata": {
+                    "description": "Metadata is the latest result of each metadata item defined on the\nagent. It is only populated when fetching a single agent, use\nWatchWorkspaceAgentMetadata to follow updates.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.WorkspaceAgentMetadata"
+                    }
+                },
                 "name": {
                     "type": "string"
                 },
                 "operating_system": {
                     "type": "string"
                 },
+                "reconnecting_pty_buffer_size": {
+                    "description": "ReconnectingPTYBufferSize is the number of bytes of output each\nreconnecting PTY keeps for replay. Zero means the agent default.",
+                    "type": "integer"
+                },
                 "resource_id": {
                     "type": "string",
                     "format": "uuid"
                 },
+                "session_recording": {
+                    "description": "SessionRecording is true if the template records the PTY sessions of\nthe agent. The deployment can also record the sessions of every agent.",
+                    "type": "boolean"
+                },
                 "shutdown_script": {
                     "type": "string"
                 },
@@ -8862,55 +10536,177 @@ const docTemplate = `{
                 }
             }
         },
-        "codersdk.WorkspaceAgentLifecycle": {
+        "codersdk.WorkspaceAgentLifecycle": {
+            "type": "string",
+            "enum": [
+                "created",
+                "starting",
+                "start_timeout",
+                "start_error",
+                "ready",
+                "shutting_down",
+                "shutdown_timeout",
+                "shutdown_error",
+                "off"
+            ],
+            "x-enum-varnames": [
+                "WorkspaceAgentLifecycleCreated",
+                "WorkspaceAgentLifecycleStarting",
+                "WorkspaceAgentLifecycleStartTimeout",
+                "WorkspaceAgentLifecycleStartError",
+                "WorkspaceAgentLifecycleReady",
+                "WorkspaceAgentLifecycleShuttingDown",
+                "WorkspaceAgentLifecycleShutdownTimeout",
+                "WorkspaceAgentLifecycleShutdownError",
+                "WorkspaceAgentLifecycleOff"
+            ]
+        },
+        "codersdk.WorkspaceAgentListeningPort": {
+            "type": "object",
+            "properties": {
+                "network": {
+                    "description": "only \"tcp\" at the moment",
+                    "type": "string"
+                },
+                "port": {
+                    "type": "integer"
+                },
+                "process_name": {
+                    "description": "may be empty",
+                    "type": "string"
+                },
+                "share_level": {
+                    "description": "ShareLevel is the sharing level of the port. It's set by coderd, the\nagent leaves it empty.",
+                    "enum": [
+                        "owner",
+                        "authenticated",
+                        "public"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceAppSharingLevel"
+                        }
+                    ]
+                }
+            }
+        },
+        "codersdk.WorkspaceAgentListeningPortsResponse": {
+            "type": "object",
+            "properties": {
+                "ports": {
+                    "description": "If there are no ports in the list, nothing should be displayed in the UI.\nThere must not be a \"no ports available\" message or anything similar, as\nthere will always be no ports displayed on platforms where our port\ndetection logic is unsupported.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.WorkspaceAgentListeningPort"
+                    }
+                }
+            }
+        },
+        "codersdk.WorkspaceAgentMetadata": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "$ref": "#/definitions/codersdk.WorkspaceAgentMetadataDescription"
+                },
+                "result": {
+                    "$ref": "#/definitions/codersdk.WorkspaceAgentMetadataResult"
+                }
+            }
+        },
+        "codersdk.WorkspaceAgentMetadataDescription": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                },
+                "interval": {
+                    "description": "Interval is the number of seconds between collections.",
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "Timeout is the number of seconds the script may run for.",
+                    "type": "integer"
+                }
+            }
+        },
+        "codersdk.WorkspaceAgentMetadataResult": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "description": "Age is the number of seconds since the metadata was collected.\nIt is provided in addition to CollectedAt to protect against clock skew.",
+                    "type": "integer"
+                },
+                "collected_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "codersdk.WorkspaceAgentPortShare": {
+            "type": "object",
+            "properties": {
+                "port": {
+                    "type": "integer"
+                },
+                "share_level": {
+                    "enum": [
+                        "owner",
+                        "authenticated",
+                        "public"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceAppSharingLevel"
+                        }
+                    ]
+                },
+                "source": {
+                    "enum": [
+                        "template",
+                        "owner"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceAgentPortShareSource"
+                        }
+                    ]
+                }
+            }
+        },
+        "codersdk.WorkspaceAgentPortShareSource": {
             "type": "string",
             "enum": [
-                "created",
-                "starting",
-                "start_timeout",
-                "start_error",
-                "ready",
-                "shutting_down",
-                "shutdown_timeout",
-                "shutdown_error",
-                "off"
+                "template",
+                "owner"
             ],
             "x-enum-varnames": [
-                "WorkspaceAgentLifecycleCreated",
-                "WorkspaceAgentLifecycleStarting",
-                "WorkspaceAgentLifecycleStartTimeout",
-                "WorkspaceAgentLifecycleStartError",
-                "WorkspaceAgentLifecycleReady",
-                "WorkspaceAgentLifecycleShuttingDown",
-                "WorkspaceAgentLifecycleShutdownTimeout",
-                "WorkspaceAgentLifecycleShutdownError",
-                "WorkspaceAgentLifecycleOff"
+                "WorkspaceAgentPortShareSourceTemplate",
+                "WorkspaceAgentPortShareSourceOwner"
             ]
         },
-        "codersdk.WorkspaceAgentListeningPort": {
+        "codersdk.WorkspaceAgentPortShares": {
             "type": "object",
             "properties": {
-                "network": {
-                    "description": "only \"tcp\" at the moment",
-                    "type": "string"
-                },
-                "port": {
-                    "type": "integer"
+                "restricted": {
+                    "description": "Restricted is true when the template defines port sharing rules. The\nagent then only allows dialing shared ports and the ports of apps.\nOtherwise any port can be dialed, and ports that aren't shared are\nshared with the owner.",
+                    "type": "boolean"
                 },
-                "process_name": {
-                    "description": "may be empty",
-                    "type": "string"
-                }
-            }
-        },
-        "codersdk.WorkspaceAgentListeningPortsResponse": {
-            "type": "object",
-            "properties": {
-                "ports": {
-                    "description": "If there are no ports in the list, nothing should be displayed in the UI.\nThere must not be a \"no ports available\" message or anything similar, as\nthere will always be no ports displayed on platforms where our port\ndetection logic is unsupported.",
+                "shares": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/codersdk.WorkspaceAgentListeningPort"
+                        "$ref": "#/definitions/codersdk.WorkspaceAgentPortShare"
                     }
                 }
             }
@@ -9071,7 +10867,9 @@ const docTemplate = `{
                     "enum": [
                         "initiator",
                         "autostart",
-                        "autostop"
+                        "autostop",
+                        "dormancy",
+                        "autodelete"
                     ],
                     "allOf": [
                         {
@@ -9154,6 +10952,167 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.WorkspaceBulkJob": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "enum": [
+                        "start",
+                        "stop",
+                        "update",
+                        "delete"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceBulkJobAction"
+                        }
+                    ]
+                },
+                "batch_size": {
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "filter": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID is empty for dry runs.",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "initiator_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "progress": {
+                    "$ref": "#/definitions/codersdk.WorkspaceBulkJobProgress"
+                },
+                "status": {
+                    "enum": [
+                        "pending",
+                        "running",
+                        "succeeded",
+                        "failed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceBulkJobStatus"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "workspaces": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.WorkspaceBulkJobWorkspace"
+                    }
+                }
+            }
+        },
+        "codersdk.WorkspaceBulkJobAction": {
+            "type": "string",
+            "enum": [
+                "start",
+                "stop",
+                "update",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "WorkspaceBulkJobActionStart",
+                "WorkspaceBulkJobActionStop",
+                "WorkspaceBulkJobActionUpdate",
+                "WorkspaceBulkJobActionDelete"
+            ]
+        },
+        "codersdk.WorkspaceBulkJobProgress": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "succeeded": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "codersdk.WorkspaceBulkJobStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "succeeded",
+                "failed",
+                "skipped"
+            ],
+            "x-enum-varnames": [
+                "WorkspaceBulkJobStatusPending",
+                "WorkspaceBulkJobStatusRunning",
+                "WorkspaceBulkJobStatusSucceeded",
+                "WorkspaceBulkJobStatusFailed",
+                "WorkspaceBulkJobStatusSkipped"
+            ]
+        },
+        "codersdk.WorkspaceBulkJobWorkspace": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "owner_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "enum": [
+                        "pending",
+                        "running",
+                        "succeeded",
+                        "failed",
+                        "skipped"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceBulkJobStatus"
+                        }
+                    ]
+                },
+                "workspace_build_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "workspace_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "workspace_name": {
+                    "type": "string"
+                }
+            }
+        },
         "codersdk.WorkspaceConnectionLatencyMS": {
             "type": "object",
             "properties": {
@@ -9261,6 +11220,54 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.WorkspaceResourceChange": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "enum": [
+                        "create",
+                        "update",
+                        "replace",
+                        "delete"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceResourceChangeAction"
+                        }
+                    ]
+                },
+                "address": {
+                    "type": "string"
+                },
+                "daily_cost": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prior_daily_cost": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "codersdk.WorkspaceResourceChangeAction": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "replace",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "WorkspaceResourceChangeActionCreate",
+                "WorkspaceResourceChangeActionUpdate",
+                "WorkspaceResourceChangeActionReplace",
+                "WorkspaceResourceChangeActionDelete"
+            ]
+        },
         "codersdk.WorkspaceResourceMetadata": {
             "type": "object",
             "properties": {
@@ -9275,6 +11282,61 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.WorkspaceSessionRecording": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "ended_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "size": {
+                    "description": "Size is the size of the recording in bytes.",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "type": {
+                    "enum": [
+                        "ssh",
+                        "reconnecting_pty"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceSessionType"
+                        }
+                    ]
+                },
+                "workspace_id": {
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
+        "codersdk.WorkspaceSessionType": {
+            "type": "string",
+            "enum": [
+                "ssh",
+                "reconnecting_pty"
+            ],
+            "x-enum-varnames": [
+                "WorkspaceSessionTypeSSH",
+                "WorkspaceSessionTypeReconnectingPTY"
+            ]
+        },
         "codersdk.WorkspaceStatus": {
             "type": "string",
             "enum": [
@@ -361,6 +361,28 @@ func (api *API) auditLogResourceLink(ctx context.Context, alog database.GetAudit
 		return fmt.Sprintf("/@%s/%s/builds/%s",
 			workspaceOwner.Username, additionalFields.WorkspaceName, additionalFields.BuildNumber)
 
+	case database.ResourceTypeWorkspaceSessionRecording:
+		// Recordings aren't shown in the dashboard, so this links to the
+		// download.
+		if len(additionalFields.WorkspaceName) == 0 || len(additionalFields.WorkspaceOwner) == 0 {
+			return ""
+		}
+		workspaceOwner, getWorkspaceOwnerErr := api.Database.GetUserByEmailOrUsername(ctx, database.GetUserByEmailOrUsernameParams{
+			Username: additionalFields.WorkspaceOwner,
+		})
+		if getWorkspaceOwnerErr != nil {
+			return ""
+		}
+		workspace, getWorkspaceErr := api.Database.GetWorkspaceByOwnerIDAndName(ctx, database.GetWorkspaceByOwnerIDAndNameParams{
+			OwnerID: workspaceOwner.ID,
+			Name:    additionalFields.WorkspaceName,
+		})
+		if getWorkspaceErr != nil {
+			return ""
+		}
+		return fmt.Sprintf("/api/v2/workspaces/%s/session-recordings/%s",
+			workspace.ID, alog.ResourceID)
+
 	default:
 		return ""
 	}
@@ -16,7 +16,9 @@ type Auditable interface {
 		database.GitSSHKey |
 		database.WorkspaceBuild |
 		database.AuditableGroup |
-		database.License
+		database.License |
+		database.WorkspaceSessionRecording |
+		database.TemplateVersionPromotion
 }
 
 // Map is a map of changed fields in an audited resource. It maps field names to
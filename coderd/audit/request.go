@@ -78,6 +78,10 @@ func ResourceTarget[T Auditable](tgt T) string {
 		return ""
 	case database.License:
 		return strconv.Itoa(int(typed.ID))
+	case database.WorkspaceSessionRecording:
+		return typed.ID.String()
+	case database.TemplateVersionPromotion:
+		return typed.ID.String()
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -103,6 +107,10 @@ func ResourceID[T Auditable](tgt T) uuid.UUID {
 		return typed.UserID
 	case database.License:
 		return typed.UUID
+	case database.WorkspaceSessionRecording:
+		return typed.ID
+	case database.TemplateVersionPromotion:
+		return typed.ID
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -128,6 +136,10 @@ func ResourceType[T Auditable](tgt T) database.ResourceType {
 		return database.ResourceTypeApiKey
 	case database.License:
 		return database.ResourceTypeLicense
+	case database.WorkspaceSessionRecording:
+		return database.ResourceTypeWorkspaceSessionRecording
+	case database.TemplateVersionPromotion:
+		return database.ResourceTypeTemplateVersionPromotion
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 	"encoding/json"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,17 +13,24 @@ import (
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/coderd/notifications"
 	"github.com/coder/coder/coderd/provisionerdserver"
 	"github.com/coder/coder/coderd/schedule"
 )
 
-// Executor automatically starts or stops workspaces.
+// autostopImminentBefore is how long before an automatic stop the
+// workspace_autostop_imminent notification is sent.
+const autostopImminentBefore = 30 * time.Minute
+
+// Executor automatically starts, stops or deletes workspaces.
 type Executor struct {
-	ctx     context.Context
-	db      database.Store
-	log     slog.Logger
-	tick    <-chan time.Time
-	statsCh chan<- Stats
+	ctx                   context.Context
+	db                    database.Store
+	ps                    database.Pubsub
+	templateScheduleStore *atomic.Pointer[schedule.TemplateScheduleStore]
+	log                   slog.Logger
+	tick                  <-chan time.Time
+	statsCh               chan<- Stats
 }
 
 // Stats contains information about one run of Executor.
@@ -33,13 +41,15 @@ type Stats struct {
 }
 
 // New returns a new autobuild executor.
-func New(ctx context.Context, db database.Store, log slog.Logger, tick <-chan time.Time) *Executor {
+func New(ctx context.Context, db database.Store, ps database.Pubsub, tss *atomic.Pointer[schedule.TemplateScheduleStore], log slog.Logger, tick <-chan time.Time) *Executor {
 	le := &Executor{
 		//nolint:gocritic // Autostart has a limited set of permissions.
-		ctx:  dbauthz.AsAutostart(ctx),
-		db:   db,
-		tick: tick,
-		log:  log,
+		ctx:                   dbauthz.AsAutostart(ctx),
+		db:                    db,
+		ps:                    ps,
+		templateScheduleStore: tss,
+		tick:                  tick,
+		log:                   log,
 	}
 	return le
 }
@@ -51,7 +61,7 @@ func (e *Executor) WithStatsChannel(ch chan<- Stats) *Executor {
 	return e
 }
 
-// Run will cause executor to start or stop workspaces on every
+// Run will cause executor to start, stop or delete workspaces on every
 // tick from its channel. It will stop when its context is Done, or when
 // its channel is closed.
 func (e *Executor) Run() {
@@ -102,6 +112,9 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	// NOTE: If a workspace build is created with a given TTL and then the user either
 	//       changes or unsets the TTL, the deadline for the workspace build will not
 	//       have changed. This behavior is as expected per #2229.
+	//
+	// Inactivity and delete TTLs are set at the template level and are compared
+	// against the last time the workspace was used.
 	workspaceRows, err := e.db.GetWorkspaces(e.ctx, database.GetWorkspacesParams{
 		Deleted: false,
 	})
@@ -111,9 +124,21 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	}
 	workspaces := database.ConvertWorkspaceRows(workspaceRows)
 
+	templateScheduleStore := *e.templateScheduleStore.Load()
+	templateSchedules := make(map[uuid.UUID]schedule.TemplateScheduleOptions)
 	var eligibleWorkspaceIDs []uuid.UUID
 	for _, ws := range workspaces {
-		if isEligibleForAutoStartStop(ws) {
+		templateSchedule, ok := templateSchedules[ws.TemplateID]
+		if !ok {
+			var err error
+			templateSchedule, err = templateScheduleStore.GetTemplateScheduleOptions(e.ctx, e.db, ws.TemplateID)
+			if err != nil {
+				e.log.Error(e.ctx, "get template schedule options", slog.F("template_id", ws.TemplateID), slog.Error(err))
+				continue
+			}
+			templateSchedules[ws.TemplateID] = templateSchedule
+		}
+		if isEligibleForAutomaticBuild(ws, templateSchedule) {
 			eligibleWorkspaceIDs = append(eligibleWorkspaceIDs, ws.ID)
 		}
 	}
@@ -137,7 +162,12 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					log.Error(e.ctx, "get workspace autostart failed", slog.Error(err))
 					return nil
 				}
-				if !isEligibleForAutoStartStop(ws) {
+				templateSchedule, err := templateScheduleStore.GetTemplateScheduleOptions(e.ctx, db, ws.TemplateID)
+				if err != nil {
+					log.Error(e.ctx, "get template schedule options", slog.Error(err))
+					return nil
+				}
+				if !isEligibleForAutomaticBuild(ws, templateSchedule) {
 					return nil
 				}
 
@@ -154,13 +184,22 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					return nil
 				}
 
-				validTransition, nextTransition, err := getNextTransition(ws, priorHistory, priorJob)
+				validTransition, reason, nextTransition, err := getNextTransition(ws, priorHistory, priorJob, templateSchedule)
 				if err != nil {
 					log.Debug(e.ctx, "skipping workspace", slog.Error(err))
 					return nil
 				}
 
 				if currentTick.Before(nextTransition) {
+					if validTransition == database.WorkspaceTransitionStop && !currentTick.Before(nextTransition.Add(-autostopImminentBefore)) {
+						// The event ID is derived from the build, so it is
+						// only delivered once even though it is published on
+						// every tick.
+						err := notifications.Publish(e.ps, notifications.AutostopImminentEvent(priorHistory, nextTransition))
+						if err != nil {
+							log.Error(e.ctx, "publish autostop imminent notification", slog.Error(err))
+						}
+					}
 					log.Debug(e.ctx, "skipping workspace: too early",
 						slog.F("next_transition_at", nextTransition),
 						slog.F("transition", validTransition),
@@ -169,10 +208,13 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					return nil
 				}
 
-				log.Info(e.ctx, "scheduling workspace transition", slog.F("transition", validTransition))
+				log.Info(e.ctx, "scheduling workspace transition",
+					slog.F("transition", validTransition),
+					slog.F("reason", reason),
+				)
 
 				stats.Transitions[ws.ID] = validTransition
-				if err := build(e.ctx, db, ws, validTransition, priorHistory, priorJob); err != nil {
+				if err := build(e.ctx, db, ws, validTransition, reason, priorHistory, priorJob); err != nil {
 					log.Error(e.ctx, "unable to transition workspace",
 						slog.F("transition", validTransition),
 						slog.Error(err),
@@ -198,48 +240,83 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	return stats
 }
 
-func isEligibleForAutoStartStop(ws database.Workspace) bool {
-	return !ws.Deleted && (ws.AutostartSchedule.String != "" || ws.Ttl.Int64 > 0)
+func isEligibleForAutomaticBuild(ws database.Workspace, templateSchedule schedule.TemplateScheduleOptions) bool {
+	if ws.Deleted {
+		return false
+	}
+	return ws.AutostartSchedule.String != "" || ws.Ttl.Int64 > 0 ||
+		templateSchedule.InactivityTTL > 0 || templateSchedule.DeleteTTL > 0 ||
+		templateSchedule.AutostopRequirement != ""
 }
 
+// getNextTransition returns the next automatic transition of the workspace
+// and when it is due. If several transitions apply, the earliest one wins.
 func getNextTransition(
 	ws database.Workspace,
 	priorHistory database.WorkspaceBuild,
 	priorJob database.ProvisionerJob,
+	templateSchedule schedule.TemplateScheduleOptions,
 ) (
 	validTransition database.WorkspaceTransition,
+	reason database.BuildReason,
 	nextTransition time.Time,
 	err error,
 ) {
 	if !priorJob.CompletedAt.Valid || priorJob.Error.String != "" {
-		return "", time.Time{}, xerrors.Errorf("last workspace build did not complete successfully")
+		return "", "", time.Time{}, xerrors.Errorf("last workspace build did not complete successfully")
 	}
 
 	switch priorHistory.Transition {
 	case database.WorkspaceTransitionStart:
-		if priorHistory.Deadline.IsZero() {
-			return "", time.Time{}, xerrors.Errorf("latest workspace build has zero deadline")
-		}
 		// For stopping, do not truncate. This is inconsistent with autostart, but
 		// it ensures we will not stop too early.
-		return database.WorkspaceTransitionStop, priorHistory.Deadline, nil
-	case database.WorkspaceTransitionStop:
-		sched, err := schedule.Weekly(ws.AutostartSchedule.String)
+		if !priorHistory.Deadline.IsZero() {
+			validTransition, reason, nextTransition = database.WorkspaceTransitionStop, database.BuildReasonAutostop, priorHistory.Deadline
+		}
+		// The required stop is already part of the deadline of builds that
+		// completed after the requirement was set. Calculating it here
+		// enforces it on workspaces that were running at that time.
+		requiredStopAt, err := templateSchedule.RequiredStopAt(priorJob.CompletedAt.Time, schedule.UserLocation(ws.AutostartSchedule.String))
 		if err != nil {
-			return "", time.Time{}, xerrors.Errorf("workspace has invalid autostart schedule: %w", err)
+			return "", "", time.Time{}, xerrors.Errorf("template has invalid autostop requirement: %w", err)
+		}
+		if !requiredStopAt.IsZero() && (nextTransition.IsZero() || requiredStopAt.Before(nextTransition)) {
+			validTransition, reason, nextTransition = database.WorkspaceTransitionStop, database.BuildReasonAutostop, requiredStopAt
+		}
+		dormantAt := templateSchedule.DormantAt(ws.LastUsedAt, priorJob.CompletedAt.Time)
+		if !dormantAt.IsZero() && (nextTransition.IsZero() || dormantAt.Before(nextTransition)) {
+			validTransition, reason, nextTransition = database.WorkspaceTransitionStop, database.BuildReasonDormancy, dormantAt
+		}
+		if nextTransition.IsZero() {
+			return "", "", time.Time{}, xerrors.Errorf("latest workspace build has zero deadline")
+		}
+		return validTransition, reason, nextTransition, nil
+	case database.WorkspaceTransitionStop:
+		if ws.AutostartSchedule.String != "" {
+			sched, err := schedule.Weekly(ws.AutostartSchedule.String)
+			if err != nil {
+				return "", "", time.Time{}, xerrors.Errorf("workspace has invalid autostart schedule: %w", err)
+			}
+			// Round down to the nearest minute, as this is the finest granularity cron supports.
+			// Truncate is probably not necessary here, but doing it anyway to be sure.
+			validTransition, reason, nextTransition = database.WorkspaceTransitionStart, database.BuildReasonAutostart, sched.Next(priorHistory.CreatedAt).Truncate(time.Minute)
+		}
+		deletingAt := templateSchedule.DeletingAt(ws.LastUsedAt, priorJob.CompletedAt.Time)
+		if !deletingAt.IsZero() && (nextTransition.IsZero() || deletingAt.Before(nextTransition)) {
+			validTransition, reason, nextTransition = database.WorkspaceTransitionDelete, database.BuildReasonAutodelete, deletingAt
 		}
-		// Round down to the nearest minute, as this is the finest granularity cron supports.
-		// Truncate is probably not necessary here, but doing it anyway to be sure.
-		nextTransition = sched.Next(priorHistory.CreatedAt).Truncate(time.Minute)
-		return database.WorkspaceTransitionStart, nextTransition, nil
+		if nextTransition.IsZero() {
+			return "", "", time.Time{}, xerrors.Errorf("workspace has no autostart schedule")
+		}
+		return validTransition, reason, nextTransition, nil
 	default:
-		return "", time.Time{}, xerrors.Errorf("last transition not valid for autostart or autostop")
+		return "", "", time.Time{}, xerrors.Errorf("last transition not valid for autostart or autostop")
 	}
 }
 
 // TODO(cian): this function duplicates most of api.postWorkspaceBuilds. Refactor.
 // See: https://github.com/coder/coder/issues/1401
-func build(ctx context.Context, store database.Store, workspace database.Workspace, trans database.WorkspaceTransition, priorHistory database.WorkspaceBuild, priorJob database.ProvisionerJob) error {
+func build(ctx context.Context, store database.Store, workspace database.Workspace, trans database.WorkspaceTransition, buildReason database.BuildReason, priorHistory database.WorkspaceBuild, priorJob database.ProvisionerJob) error {
 	template, err := store.GetTemplateByID(ctx, workspace.TemplateID)
 	if err != nil {
 		return xerrors.Errorf("get workspace template: %w", err)
@@ -259,12 +336,13 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 	provisionerJobID := uuid.New()
 	now := database.Now()
 
-	var buildReason database.BuildReason
+	templateVersionID := template.ActiveVersionID
 	switch trans {
-	case database.WorkspaceTransitionStart:
-		buildReason = database.BuildReasonAutostart
-	case database.WorkspaceTransitionStop:
-		buildReason = database.BuildReasonAutostop
+	case database.WorkspaceTransitionStart, database.WorkspaceTransitionStop:
+	case database.WorkspaceTransitionDelete:
+		// Destroy the workspace with the version that created its resources,
+		// as the active version may no longer be compatible with the state.
+		templateVersionID = priorHistory.TemplateVersionID
 	default:
 		return xerrors.Errorf("Unsupported transition: %q", trans)
 	}
@@ -287,6 +365,7 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 			FileID:         priorJob.FileID,
 			Tags:           priorJob.Tags,
 			Input:          input,
+			Priority:       provisionerdserver.WorkspaceBuildPriority(trans),
 		})
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
@@ -296,7 +375,7 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 			CreatedAt:         now,
 			UpdatedAt:         now,
 			WorkspaceID:       workspace.ID,
-			TemplateVersionID: template.ActiveVersionID,
+			TemplateVersionID: templateVersionID,
 			BuildNumber:       priorBuildNumber + 1,
 			ProvisionerState:  priorHistory.ProvisionerState,
 			InitiatorID:       workspace.OwnerID,
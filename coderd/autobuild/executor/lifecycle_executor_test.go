@@ -2,6 +2,7 @@ package executor_test
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
@@ -13,6 +14,8 @@ import (
 	"github.com/coder/coder/coderd/autobuild/executor"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbtestutil"
+	"github.com/coder/coder/coderd/notifications"
 	"github.com/coder/coder/coderd/schedule"
 	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
@@ -429,6 +432,53 @@ func TestExecutorWorkspaceAutostopBeforeDeadline(t *testing.T) {
 	assert.Len(t, stats.Transitions, 0)
 }
 
+func TestExecutorAutostopImminent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db, ps  = dbtestutil.NewDB(t)
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			Database:                 db,
+			Pubsub:                   ps,
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+		})
+		// Given: we have a user with a workspace
+		workspace = mustProvisionWorkspace(t, client)
+		events    = make(chan notifications.Event, 1)
+	)
+	cancel, err := ps.Subscribe(notifications.EventChannel, func(_ context.Context, message []byte) {
+		var event notifications.Event
+		if !assert.NoError(t, json.Unmarshal(message, &event)) {
+			return
+		}
+		if event.Type == database.NotificationEventTypeWorkspaceAutostopImminent {
+			events <- event
+		}
+	})
+	require.NoError(t, err)
+	defer cancel()
+
+	// When: the autobuild executor ticks shortly before the deadline
+	go func() {
+		tickCh <- workspace.LatestBuild.Deadline.Time.Add(-10 * time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace is not stopped, but a notification is published
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+
+	event := <-events
+	assert.Equal(t, workspace.ID, event.WorkspaceID)
+	assert.Equal(t, workspace.LatestBuild.ID, event.WorkspaceBuildID)
+	assert.WithinDuration(t, workspace.LatestBuild.Deadline.Time, event.Deadline, time.Second)
+}
+
 func TestExecutorWorkspaceAutostopNoWaitChangedMyMind(t *testing.T) {
 	t.Parallel()
 
@@ -605,6 +655,249 @@ func TestExecutorAutostartWithParameters(t *testing.T) {
 	mustWorkspaceParameters(t, client, workspace.LatestBuild.ID)
 }
 
+func TestExecutorInactivityStop(t *testing.T) {
+	t.Parallel()
+
+	var (
+		inactivityTTL = time.Hour
+		tickCh        = make(chan time.Time)
+		statsCh       = make(chan executor.Stats)
+		client        = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			TemplateScheduleStore: templateScheduleStore{
+				InactivityTTL: inactivityTTL,
+			},
+		})
+		// Given: we have a user with a running workspace without autostop
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.TTLMillis = nil
+		})
+	)
+	require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+	require.Zero(t, workspace.LatestBuild.Deadline)
+	require.NotNil(t, workspace.DormantAt)
+	require.Nil(t, workspace.DeletingAt)
+
+	// When: the autobuild executor ticks after the workspace has been unused
+	// for longer than the inactivity TTL
+	go func() {
+		tickCh <- workspace.DormantAt.Add(time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be stopped for inactivity
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Contains(t, stats.Transitions, workspace.ID)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.BuildReasonDormancy, workspace.LatestBuild.Reason)
+}
+
+func TestExecutorInactivityStopTooEarly(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			TemplateScheduleStore: templateScheduleStore{
+				InactivityTTL: time.Hour,
+			},
+		})
+		// Given: we have a user with a running workspace without autostop
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.TTLMillis = nil
+		})
+	)
+	require.NotNil(t, workspace.DormantAt)
+
+	// When: the autobuild executor ticks before the inactivity TTL has passed
+	go func() {
+		tickCh <- workspace.DormantAt.Add(-time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: nothing should happen
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+}
+
+func TestExecutorInactivityBeforeDeadline(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			TemplateScheduleStore: templateScheduleStore{
+				UserSchedulingEnabled: true,
+				InactivityTTL:         time.Hour,
+			},
+		})
+		// Given: we have a user with a workspace that stops after 8 hours
+		workspace = mustProvisionWorkspace(t, client)
+	)
+	require.NotZero(t, workspace.LatestBuild.Deadline)
+	require.NotNil(t, workspace.DormantAt)
+	require.True(t, workspace.DormantAt.Before(workspace.LatestBuild.Deadline.Time))
+
+	// When: the autobuild executor ticks after the inactivity TTL, but before
+	// the deadline
+	go func() {
+		tickCh <- workspace.DormantAt.Add(time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be stopped for inactivity
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.BuildReasonDormancy, workspace.LatestBuild.Reason)
+}
+
+func TestExecutorAutostopRequirement(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			TemplateScheduleStore: templateScheduleStore{
+				UserSchedulingEnabled: true,
+				AutostopRequirement:   "0 2 * * *",
+			},
+		})
+		// Given: we have a user with a running workspace without autostop
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.TTLMillis = nil
+		})
+	)
+	// The required stop is the max deadline of the build, which also caps
+	// the deadline. It is in the timezone of the autostart schedule.
+	loc, err := time.LoadLocation("US/Central")
+	require.NoError(t, err)
+	require.NotZero(t, workspace.LatestBuild.MaxDeadline)
+	require.Equal(t, 2, workspace.LatestBuild.MaxDeadline.Time.In(loc).Hour())
+	require.Equal(t, workspace.LatestBuild.MaxDeadline, workspace.LatestBuild.Deadline)
+
+	// When: the autobuild executor ticks after the required stop
+	go func() {
+		tickCh <- workspace.LatestBuild.MaxDeadline.Time.Add(time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be stopped
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.BuildReasonAutostop, workspace.LatestBuild.Reason)
+}
+
+func TestExecutorAutoDelete(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			TemplateScheduleStore: templateScheduleStore{
+				DeleteTTL: 24 * time.Hour,
+			},
+		})
+		// Given: we have a user with a workspace without autostart
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.AutostartSchedule = nil
+		})
+	)
+	require.Nil(t, workspace.DeletingAt)
+
+	// Given: the workspace is stopped
+	workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.NotNil(t, workspace.DeletingAt)
+	require.Nil(t, workspace.DormantAt)
+
+	// When: the autobuild executor ticks after the delete TTL
+	go func() {
+		tickCh <- workspace.DeletingAt.Add(time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be deleted
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Contains(t, stats.Transitions, workspace.ID)
+	assert.Equal(t, database.WorkspaceTransitionDelete, stats.Transitions[workspace.ID])
+
+	builds, err := client.WorkspaceBuilds(ctx, codersdk.WorkspaceBuildsRequest{WorkspaceID: workspace.ID})
+	require.NoError(t, err)
+	require.NotEmpty(t, builds)
+	assert.Equal(t, codersdk.WorkspaceTransitionDelete, builds[0].Transition)
+	assert.Equal(t, codersdk.BuildReasonAutodelete, builds[0].Reason)
+}
+
+func TestExecutorAutoDeleteAutostartFirst(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sched   = mustSchedule(t, "CRON_TZ=UTC 0 * * * *")
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			TemplateScheduleStore: templateScheduleStore{
+				DeleteTTL: 24 * time.Hour,
+			},
+		})
+		// Given: we have a user with a workspace that autostarts every hour
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.AutostartSchedule = ptr.Ref(sched.String())
+		})
+	)
+	// Given: the workspace is stopped
+	workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+
+	// When: the autobuild executor ticks after the scheduled autostart time
+	go func() {
+		tickCh <- sched.Next(workspace.LatestBuild.CreatedAt)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be started rather than deleted
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStart, stats.Transitions[workspace.ID])
+}
+
 func mustProvisionWorkspace(t *testing.T, client *codersdk.Client, mut ...func(*codersdk.CreateWorkspaceRequest)) codersdk.Workspace {
 	t.Helper()
 	user := coderdtest.CreateFirstUser(t, client)
@@ -659,6 +952,19 @@ func mustWorkspaceParameters(t *testing.T, client *codersdk.Client, workspaceID
 	require.NotEmpty(t, buildParameters)
 }
 
+// templateScheduleStore returns the given options for every template.
+type templateScheduleStore schedule.TemplateScheduleOptions
+
+var _ schedule.TemplateScheduleStore = templateScheduleStore{}
+
+func (s templateScheduleStore) GetTemplateScheduleOptions(_ context.Context, _ database.Store, _ uuid.UUID) (schedule.TemplateScheduleOptions, error) {
+	return schedule.TemplateScheduleOptions(s), nil
+}
+
+func (templateScheduleStore) SetTemplateScheduleOptions(ctx context.Context, db database.Store, template database.Template, opts schedule.TemplateScheduleOptions) (database.Template, error) {
+	return schedule.NewAGPLTemplateScheduleStore().SetTemplateScheduleOptions(ctx, db, template, opts)
+}
+
 func TestMain(m *testing.M) {
 	goleak.VerifyTestMain(m)
 }
@@ -49,7 +49,9 @@ import (
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/metricscache"
+	"github.com/coder/coder/coderd/notifications"
 	"github.com/coder/coder/coderd/provisionerdserver"
+	"github.com/coder/coder/coderd/ratelimit"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/schedule"
 	"github.com/coder/coder/coderd/telemetry"
@@ -119,8 +121,8 @@ type Options struct {
 	DERPServer            *derp.Server
 	DERPMap               *tailcfg.DERPMap
 	SwaggerEndpoint       bool
-	SetUserGroups         func(ctx context.Context, tx database.Store, userID uuid.UUID, groupNames []string) error
-	TemplateScheduleStore schedule.TemplateScheduleStore
+	SetUserGroups         func(ctx context.Context, tx database.Store, userID uuid.UUID, groupNames []string, createMissingGroups bool) ([]GroupChange, error)
+	TemplateScheduleStore *atomic.Pointer[schedule.TemplateScheduleStore]
 	// AppSigningKey denotes the symmetric key to use for signing app tickets.
 	// The key must be 64 bytes long.
 	AppSigningKey []byte
@@ -128,9 +130,20 @@ type Options struct {
 	// APIRateLimit is the minutely throughput rate limit per user or ip.
 	// Setting a rate limit <0 will disable the rate limiter across the entire
 	// app. Some specific routes have their own configurable rate limits.
-	APIRateLimit   int
-	LoginRateLimit int
-	FilesRateLimit int
+	APIRateLimit            int
+	LoginRateLimit          int
+	FilesRateLimit          int
+	WorkspaceBuildRateLimit int
+	AgentRateLimit          int
+	// RateLimitPolicies override the limits, windows and role limits of the
+	// policies above by name.
+	RateLimitPolicies []ratelimit.Policy
+	// ProvisionerPlugins are the external provisioners declared in the
+	// deployment config, parsed from DeploymentValues.Provisioner.Plugins.
+	ProvisionerPlugins []codersdk.ProvisionerPlugin
+	// RateLimitStore keeps the rate limit counters. Defaults to counters
+	// that are local to this replica.
+	RateLimitStore ratelimit.Store
 
 	MetricsCacheRefreshInterval time.Duration
 	AgentStatsRefreshInterval   time.Duration
@@ -207,6 +220,15 @@ func New(options *Options) *API {
 	if options.FilesRateLimit == 0 {
 		options.FilesRateLimit = 12
 	}
+	if options.WorkspaceBuildRateLimit == 0 {
+		options.WorkspaceBuildRateLimit = 60
+	}
+	if options.AgentRateLimit == 0 {
+		options.AgentRateLimit = 512
+	}
+	if options.RateLimitStore == nil {
+		options.RateLimitStore = ratelimit.NewMemoryStore()
+	}
 	if options.PrometheusRegistry == nil {
 		options.PrometheusRegistry = prometheus.NewRegistry()
 	}
@@ -223,15 +245,19 @@ func New(options *Options) *API {
 		options.SSHConfig.HostnamePrefix = "coder."
 	}
 	if options.SetUserGroups == nil {
-		options.SetUserGroups = func(ctx context.Context, _ database.Store, id uuid.UUID, groups []string) error {
+		options.SetUserGroups = func(ctx context.Context, _ database.Store, id uuid.UUID, groups []string, _ bool) ([]GroupChange, error) {
 			options.Logger.Warn(ctx, "attempted to assign OIDC groups without enterprise license",
 				slog.F("id", id), slog.F("groups", groups),
 			)
-			return nil
+			return nil, nil
 		}
 	}
 	if options.TemplateScheduleStore == nil {
-		options.TemplateScheduleStore = schedule.NewAGPLTemplateScheduleStore()
+		options.TemplateScheduleStore = &atomic.Pointer[schedule.TemplateScheduleStore]{}
+	}
+	if options.TemplateScheduleStore.Load() == nil {
+		v := schedule.NewAGPLTemplateScheduleStore()
+		options.TemplateScheduleStore.Store(&v)
 	}
 	if len(options.AppSigningKey) != 64 {
 		panic("coderd: AppSigningKey must be 64 bytes long")
@@ -292,8 +318,13 @@ func New(options *Options) *API {
 		),
 		metricsCache:          metricsCache,
 		Auditor:               atomic.Pointer[audit.Auditor]{},
-		TemplateScheduleStore: atomic.Pointer[schedule.TemplateScheduleStore]{},
+		TemplateScheduleStore: options.TemplateScheduleStore,
+		ProvisionerdMetrics:   provisionerdserver.NewMetrics(options.PrometheusRegistry),
 		Experiments:           experiments,
+		ProvisionerTypes:      []database.ProvisionerType{database.ProvisionerTypeEcho, database.ProvisionerTypeTerraform},
+	}
+	for _, plugin := range options.ProvisionerPlugins {
+		api.ProvisionerTypes = append(api.ProvisionerTypes, database.ProvisionerType(plugin.Type))
 	}
 	if options.UpdateCheckOptions != nil {
 		api.updateChecker = updatecheck.New(
@@ -302,6 +333,19 @@ func New(options *Options) *API {
 			*options.UpdateCheckOptions,
 		)
 	}
+	api.notificationDispatcher, err = notifications.New(
+		options.Logger.Named("notifications"),
+		options.Database,
+		options.Pubsub,
+		notifications.Options{
+			HTTPClient: options.HTTPClient,
+		},
+	)
+	if err != nil {
+		// Notifications are best effort, so don't prevent the API from
+		// starting.
+		options.Logger.Error(context.Background(), "start notification dispatcher, notification webhooks won't be delivered", slog.Error(err))
+	}
 
 	var oidcAuthURLParams map[string]string
 	if options.OIDCConfig != nil {
@@ -309,9 +353,10 @@ func New(options *Options) *API {
 	}
 
 	api.Auditor.Store(&options.Auditor)
-	api.TemplateScheduleStore.Store(&options.TemplateScheduleStore)
 	api.workspaceAgentCache = wsconncache.New(api.dialWorkspaceAgentTailnet, 0)
 	api.TailnetCoordinator.Store(&options.TailnetCoordinator)
+	api.workspaceBulkJobsWaitGroup.Add(1)
+	go api.reapStaleWorkspaceBulkJobs()
 
 	apiKeyMiddleware := httpmw.ExtractAPIKeyMW(httpmw.ExtractAPIKeyConfig{
 		DB:                          options.Database,
@@ -329,9 +374,32 @@ func New(options *Options) *API {
 		Optional:                    false,
 	})
 
-	// API rate limit middleware. The counter is local and not shared between
-	// replicas or instances of this middleware.
-	apiRateLimiter := httpmw.RateLimit(options.APIRateLimit, time.Minute)
+	// Every rate limit policy can be overridden by the admin. The counters
+	// are only shared between replicas if the store is.
+	rateLimitPolicies := map[string]ratelimit.Policy{
+		ratelimit.PolicyAPI:             {Limit: options.APIRateLimit, ByEndpoint: true},
+		ratelimit.PolicyLogin:           {Limit: options.LoginRateLimit, ByEndpoint: true},
+		ratelimit.PolicyFiles:           {Limit: options.FilesRateLimit, ByEndpoint: true},
+		ratelimit.PolicyWorkspaceBuilds: {Limit: options.WorkspaceBuildRateLimit},
+		ratelimit.PolicyAgents:          {Limit: options.AgentRateLimit, ByEndpoint: true},
+	}
+	for name, policy := range rateLimitPolicies {
+		policy.Name = name
+		policy.Window = time.Minute
+		rateLimitPolicies[name] = policy
+	}
+	for _, override := range options.RateLimitPolicies {
+		policy, ok := rateLimitPolicies[override.Name]
+		if !ok {
+			panic(fmt.Sprintf("coderd: unknown rate limit policy %q", override.Name))
+		}
+		rateLimitPolicies[override.Name] = policy.Override(override)
+	}
+	rateLimiter := ratelimit.New(api.Logger.Named("ratelimit"), options.RateLimitStore, options.PrometheusRegistry)
+	rateLimit := func(name string) func(http.Handler) http.Handler {
+		return httpmw.RateLimitPolicy(rateLimiter, rateLimitPolicies[name])
+	}
+	apiRateLimiter := rateLimit(ratelimit.PolicyAPI)
 
 	derpHandler := derphttp.Handler(api.DERPServer)
 	derpHandler, api.derpCloseFunc = tailnet.WithWebsocketSupport(api.DERPServer, derpHandler)
@@ -419,6 +487,7 @@ func New(options *Options) *API {
 			r.Use(apiKeyMiddleware)
 			r.Get("/config", api.deploymentValues)
 			r.Get("/stats", api.deploymentStats)
+			r.Get("/purge", api.deploymentPurgeStats)
 			r.Get("/ssh", api.sshConfig)
 		})
 		r.Route("/experiments", func(r chi.Router) {
@@ -437,7 +506,7 @@ func New(options *Options) *API {
 		r.Route("/files", func(r chi.Router) {
 			r.Use(
 				apiKeyMiddleware,
-				httpmw.RateLimit(options.FilesRateLimit, time.Minute),
+				rateLimit(ratelimit.PolicyFiles),
 			)
 			r.Get("/{fileID}", api.fileByID)
 			r.Post("/", api.postFile)
@@ -452,6 +521,7 @@ func New(options *Options) *API {
 					httpmw.ExtractOrganizationParam(options.Database),
 				)
 				r.Get("/", api.organization)
+				r.Get("/provisionerjobs/queue", api.provisionerJobQueue)
 				r.Post("/templateversions", api.postTemplateVersionsByOrganization)
 				r.Route("/templates", func(r chi.Router) {
 					r.Post("/", api.postTemplateByOrganization)
@@ -473,11 +543,19 @@ func New(options *Options) *API {
 							httpmw.ExtractOrganizationMemberParam(options.Database),
 						)
 						r.Put("/roles", api.putMemberRoles)
-						r.Post("/workspaces", api.postWorkspacesByOrganization)
+						r.With(rateLimit(ratelimit.PolicyWorkspaceBuilds)).Post("/workspaces", api.postWorkspacesByOrganization)
 					})
 				})
 			})
 		})
+		r.Route("/provisionerjobs/{provisionerjob}", func(r chi.Router) {
+			r.Use(
+				apiKeyMiddleware,
+				httpmw.ExtractProvisionerJobParam(options.Database),
+			)
+			r.Post("/bump", api.postBumpProvisionerJob)
+			r.Patch("/cancel", api.patchCancelProvisionerJob)
+		})
 		r.Route("/parameters/{scope}/{id}", func(r chi.Router) {
 			r.Use(apiKeyMiddleware)
 			r.Post("/", api.postParameter)
@@ -500,6 +578,11 @@ func New(options *Options) *API {
 				r.Patch("/", api.patchActiveTemplateVersion)
 				r.Get("/{templateversionname}", api.templateVersionByName)
 			})
+			r.Route("/promotions", func(r chi.Router) {
+				r.Get("/", api.templateVersionPromotions)
+				r.Post("/", api.postTemplateVersionPromotion)
+				r.Post("/{templateversionpromotion}/approve", api.postTemplateVersionPromotionApproval)
+			})
 		})
 		r.Route("/templateversions/{templateversion}", func(r chi.Router) {
 			r.Use(
@@ -520,6 +603,7 @@ func New(options *Options) *API {
 				r.Post("/", api.postTemplateVersionDryRun)
 				r.Get("/{jobID}", api.templateVersionDryRun)
 				r.Get("/{jobID}/resources", api.templateVersionDryRunResources)
+				r.Get("/{jobID}/changes", api.templateVersionDryRunChanges)
 				r.Get("/{jobID}/logs", api.templateVersionDryRunLogs)
 				r.Patch("/{jobID}/cancel", api.patchTemplateVersionDryRunCancel)
 			})
@@ -534,7 +618,7 @@ func New(options *Options) *API {
 				// attacks.
 				//
 				// This value is intentionally increased during tests.
-				r.Use(httpmw.RateLimit(options.LoginRateLimit, time.Minute))
+				r.Use(rateLimit(ratelimit.PolicyLogin))
 				r.Post("/login", api.postLogin)
 				r.Route("/oauth2", func(r chi.Router) {
 					r.Route("/github", func(r chi.Router) {
@@ -608,8 +692,12 @@ func New(options *Options) *API {
 			r.Post("/aws-instance-identity", api.postWorkspaceAuthAWSInstanceIdentity)
 			r.Post("/google-instance-identity", api.postWorkspaceAuthGoogleInstanceIdentity)
 			r.Route("/me", func(r chi.Router) {
-				r.Use(httpmw.ExtractWorkspaceAgent(options.Database))
+				r.Use(
+					httpmw.ExtractWorkspaceAgent(options.Database),
+					rateLimit(ratelimit.PolicyAgents),
+				)
 				r.Get("/metadata", api.workspaceAgentMetadata)
+				r.Post("/metadata/{key}", api.workspaceAgentPostMetadata)
 				r.Post("/startup", api.postWorkspaceAgentStartup)
 				r.Patch("/startup-logs", api.patchWorkspaceAgentStartupLogs)
 				r.Post("/app-health", api.postWorkspaceAppHealth)
@@ -618,6 +706,8 @@ func New(options *Options) *API {
 				r.Get("/coordinate", api.workspaceAgentCoordinate)
 				r.Post("/report-stats", api.workspaceAgentReportStats)
 				r.Post("/report-lifecycle", api.workspaceAgentReportLifecycle)
+				r.Post("/session-recordings", api.postWorkspaceAgentSessionRecording)
+				r.Get("/port-shares/watch", api.watchWorkspaceAgentPortShares)
 			})
 			// No middleware on the PTY endpoint since it uses workspace
 			// application auth and tickets.
@@ -631,6 +721,12 @@ func New(options *Options) *API {
 				r.Get("/", api.workspaceAgent)
 				r.Get("/startup-logs", api.workspaceAgentStartupLogs)
 				r.Get("/listening-ports", api.workspaceAgentListeningPorts)
+				r.Route("/port-shares", func(r chi.Router) {
+					r.Get("/", api.workspaceAgentPortShares)
+					r.Put("/", api.putWorkspaceAgentPortShare)
+					r.Delete("/{port}", api.deleteWorkspaceAgentPortShare)
+				})
+				r.Get("/watch-metadata", api.watchWorkspaceAgentMetadata)
 				r.Get("/connection", api.workspaceAgentConnection)
 				r.Get("/coordinate", api.workspaceAgentClientCoordinate)
 			})
@@ -640,6 +736,10 @@ func New(options *Options) *API {
 				apiKeyMiddleware,
 			)
 			r.Get("/", api.workspaces)
+			r.Route("/bulk", func(r chi.Router) {
+				r.Post("/", api.postWorkspaceBulkJob)
+				r.Get("/{workspacebulkjob}", api.workspaceBulkJob)
+			})
 			r.Route("/{workspace}", func(r chi.Router) {
 				r.Use(
 					httpmw.ExtractWorkspaceParam(options.Database),
@@ -648,7 +748,7 @@ func New(options *Options) *API {
 				r.Patch("/", api.patchWorkspace)
 				r.Route("/builds", func(r chi.Router) {
 					r.Get("/", api.workspaceBuilds)
-					r.Post("/", api.postWorkspaceBuilds)
+					r.With(rateLimit(ratelimit.PolicyWorkspaceBuilds)).Post("/", api.postWorkspaceBuilds)
 				})
 				r.Route("/autostart", func(r chi.Router) {
 					r.Put("/", api.putWorkspaceAutostart)
@@ -658,6 +758,10 @@ func New(options *Options) *API {
 				})
 				r.Get("/watch", api.watchWorkspace)
 				r.Put("/extend", api.putExtendWorkspace)
+				r.Route("/session-recordings", func(r chi.Router) {
+					r.Get("/", api.workspaceSessionRecordings)
+					r.Get("/{recording}", api.workspaceSessionRecording)
+				})
 			})
 		})
 		r.Route("/workspacebuilds/{workspacebuild}", func(r chi.Router) {
@@ -696,6 +800,18 @@ func New(options *Options) *API {
 			r.Use(apiKeyMiddleware)
 			r.Get("/daus", api.deploymentDAUs)
 		})
+		r.Route("/notifications/webhooks", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.notificationWebhooks)
+			r.Post("/", api.postNotificationWebhook)
+			r.Route("/{webhook}", func(r chi.Router) {
+				r.Use(httpmw.ExtractNotificationWebhookParam(options.Database))
+				r.Get("/", api.notificationWebhook)
+				r.Patch("/", api.patchNotificationWebhook)
+				r.Delete("/", api.deleteNotificationWebhook)
+				r.Get("/deliveries", api.notificationWebhookDeliveries)
+			})
+		})
 		r.Route("/debug", func(r chi.Router) {
 			r.Use(
 				apiKeyMiddleware,
@@ -745,7 +861,12 @@ type API struct {
 	WorkspaceClientCoordinateOverride atomic.Pointer[func(rw http.ResponseWriter) bool]
 	TailnetCoordinator                atomic.Pointer[tailnet.Coordinator]
 	QuotaCommitter                    atomic.Pointer[proto.QuotaCommitter]
-	TemplateScheduleStore             atomic.Pointer[schedule.TemplateScheduleStore]
+	// TemplateScheduleStore is shared with the autobuild executor, so
+	// swapping the store (e.g. when a license is added) applies to both.
+	TemplateScheduleStore *atomic.Pointer[schedule.TemplateScheduleStore]
+	// ProvisionerdMetrics are shared by every provisioner daemon served by
+	// this API, including external ones.
+	ProvisionerdMetrics *provisionerdserver.Metrics
 
 	HTTPAuth *HTTPAuthorizer
 
@@ -760,14 +881,25 @@ type API struct {
 	WebsocketWaitGroup sync.WaitGroup
 	derpCloseFunc      func()
 
+	// workspaceBulkJobsWaitGroup tracks running workspace bulk jobs, which
+	// stop creating batches when ctx is canceled, and their reaper.
+	workspaceBulkJobsWaitGroup sync.WaitGroup
+
 	metricsCache          *metricscache.Cache
 	workspaceAgentCache   *wsconncache.Cache
 	updateChecker         *updatecheck.Checker
 	WorkspaceAppsProvider *workspaceapps.Provider
 
+	// notificationDispatcher is nil if it failed to start.
+	notificationDispatcher *notifications.Dispatcher
+
 	// Experiments contains the list of experiments currently enabled.
 	// This is used to gate features that are not yet ready for production.
 	Experiments codersdk.Experiments
+	// ProvisionerTypes contains the provisioner types that template versions
+	// can target: the built-in provisioners, and the external provisioner
+	// plugins declared in the deployment config.
+	ProvisionerTypes []database.ProvisionerType
 }
 
 // Close waits for all WebSocket connections to drain before returning.
@@ -778,11 +910,15 @@ func (api *API) Close() error {
 	api.WebsocketWaitMutex.Lock()
 	api.WebsocketWaitGroup.Wait()
 	api.WebsocketWaitMutex.Unlock()
+	api.workspaceBulkJobsWaitGroup.Wait()
 
 	api.metricsCache.Close()
 	if api.updateChecker != nil {
 		api.updateChecker.Close()
 	}
+	if api.notificationDispatcher != nil {
+		_ = api.notificationDispatcher.Close()
+	}
 	coordinator := api.TailnetCoordinator.Load()
 	if coordinator != nil {
 		_ = (*coordinator).Close()
@@ -827,7 +963,7 @@ func (api *API) CreateInMemoryProvisionerDaemon(ctx context.Context, debounce ti
 		ID:           uuid.New(),
 		CreatedAt:    database.Now(),
 		Name:         name,
-		Provisioners: []database.ProvisionerType{database.ProvisionerTypeEcho, database.ProvisionerTypeTerraform},
+		Provisioners: api.ProvisionerTypes,
 		Tags: dbtype.StringMap{
 			provisionerdserver.TagScope: provisionerdserver.ScopeOrganization,
 		},
@@ -855,7 +991,8 @@ func (api *API) CreateInMemoryProvisionerDaemon(ctx context.Context, debounce ti
 		Tags:                  tags,
 		QuotaCommitter:        &api.QuotaCommitter,
 		Auditor:               &api.Auditor,
-		TemplateScheduleStore: &api.TemplateScheduleStore,
+		TemplateScheduleStore: api.TemplateScheduleStore,
+		Metrics:               api.ProvisionerdMetrics,
 		AcquireJobDebounce:    debounce,
 		Logger:                api.Logger.Named(fmt.Sprintf("provisionerd-%s", daemon.Name)),
 	})
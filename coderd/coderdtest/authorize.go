@@ -431,6 +431,7 @@ func randomRBACType() string {
 		rbac.ResourceLicense.Type,
 		rbac.ResourceDeploymentValues.Type,
 		rbac.ResourceReplicas.Type,
+		rbac.ResourceNotificationWebhook.Type,
 		rbac.ResourceDebugInfo.Type,
 	}
 	return all[must(cryptorand.Intn(len(all)))]
@@ -26,6 +26,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -62,6 +63,7 @@ import (
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/ratelimit"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/schedule"
 	"github.com/coder/coder/coderd/telemetry"
@@ -106,9 +108,12 @@ type Options struct {
 	TemplateScheduleStore schedule.TemplateScheduleStore
 
 	// All rate limits default to -1 (unlimited) in tests if not set.
-	APIRateLimit   int
-	LoginRateLimit int
-	FilesRateLimit int
+	APIRateLimit            int
+	LoginRateLimit          int
+	FilesRateLimit          int
+	WorkspaceBuildRateLimit int
+	AgentRateLimit          int
+	RateLimitPolicies       []ratelimit.Policy
 
 	// IncludeProvisionerDaemon when true means to start an in-memory provisionerD
 	IncludeProvisionerDaemon    bool
@@ -197,6 +202,8 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 	if options.DeploymentValues == nil {
 		options.DeploymentValues = DeploymentValues(t)
 	}
+	provisionerPlugins, err := codersdk.ParseProvisionerPlugins(options.DeploymentValues.Provisioner.Plugins.Value())
+	require.NoError(t, err)
 
 	// If no ratelimits are set, disable all rate limiting for tests.
 	if options.APIRateLimit == 0 {
@@ -208,11 +215,25 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 	if options.FilesRateLimit == 0 {
 		options.FilesRateLimit = -1
 	}
+	if options.WorkspaceBuildRateLimit == 0 {
+		options.WorkspaceBuildRateLimit = -1
+	}
+	if options.AgentRateLimit == 0 {
+		options.AgentRateLimit = -1
+	}
+
+	templateScheduleStore := &atomic.Pointer[schedule.TemplateScheduleStore]{}
+	if options.TemplateScheduleStore == nil {
+		options.TemplateScheduleStore = schedule.NewAGPLTemplateScheduleStore()
+	}
+	templateScheduleStore.Store(&options.TemplateScheduleStore)
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	lifecycleExecutor := executor.New(
 		ctx,
 		options.Database,
+		options.Pubsub,
+		templateScheduleStore,
 		slogtest.Make(t, nil).Named("autobuild.executor").Leveled(slog.LevelDebug),
 		options.AutobuildTicker,
 	).WithStatsChannel(options.AutobuildStats)
@@ -292,23 +313,27 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 			Pubsub:                         options.Pubsub,
 			GitAuthConfigs:                 options.GitAuthConfigs,
 
-			Auditor:               options.Auditor,
-			AWSCertificates:       options.AWSCertificates,
-			AzureCertificates:     options.AzureCertificates,
-			GithubOAuth2Config:    options.GithubOAuth2Config,
-			RealIPConfig:          options.RealIPConfig,
-			OIDCConfig:            options.OIDCConfig,
-			GoogleTokenValidator:  options.GoogleTokenValidator,
-			SSHKeygenAlgorithm:    options.SSHKeygenAlgorithm,
-			DERPServer:            derpServer,
-			APIRateLimit:          options.APIRateLimit,
-			LoginRateLimit:        options.LoginRateLimit,
-			FilesRateLimit:        options.FilesRateLimit,
-			Authorizer:            options.Authorizer,
-			Telemetry:             telemetry.NewNoop(),
-			TemplateScheduleStore: options.TemplateScheduleStore,
-			TLSCertificates:       options.TLSCertificates,
-			TrialGenerator:        options.TrialGenerator,
+			Auditor:                 options.Auditor,
+			AWSCertificates:         options.AWSCertificates,
+			AzureCertificates:       options.AzureCertificates,
+			GithubOAuth2Config:      options.GithubOAuth2Config,
+			RealIPConfig:            options.RealIPConfig,
+			OIDCConfig:              options.OIDCConfig,
+			GoogleTokenValidator:    options.GoogleTokenValidator,
+			SSHKeygenAlgorithm:      options.SSHKeygenAlgorithm,
+			DERPServer:              derpServer,
+			APIRateLimit:            options.APIRateLimit,
+			LoginRateLimit:          options.LoginRateLimit,
+			FilesRateLimit:          options.FilesRateLimit,
+			WorkspaceBuildRateLimit: options.WorkspaceBuildRateLimit,
+			AgentRateLimit:          options.AgentRateLimit,
+			RateLimitPolicies:       options.RateLimitPolicies,
+			ProvisionerPlugins:      provisionerPlugins,
+			Authorizer:              options.Authorizer,
+			Telemetry:               telemetry.NewNoop(),
+			TemplateScheduleStore:   templateScheduleStore,
+			TLSCertificates:         options.TLSCertificates,
+			TrialGenerator:          options.TrialGenerator,
 			DERPMap: &tailcfg.DERPMap{
 				Regions: map[int]*tailcfg.DERPRegion{
 					1: {
@@ -421,7 +446,11 @@ func NewExternalProvisionerDaemon(t *testing.T, client *codersdk.Client, org uui
 	}()
 
 	closer := provisionerd.New(func(ctx context.Context) (provisionerdproto.DRPCProvisionerDaemonClient, error) {
-		return client.ServeProvisionerDaemon(ctx, org, []codersdk.ProvisionerType{codersdk.ProvisionerTypeEcho}, tags)
+		return client.ServeProvisionerDaemon(ctx, codersdk.ServeProvisionerDaemonRequest{
+			Organization: org,
+			Provisioners: []codersdk.ProvisionerType{codersdk.ProvisionerTypeEcho},
+			Tags:         tags,
+		})
 	}, &provisionerd.Options{
 		Filesystem:          fs,
 		Logger:              slogtest.Make(t, nil).Named("provisionerd").Leveled(slog.LevelDebug),
@@ -215,15 +215,47 @@ func (q *querier) UpdateGroupByID(ctx context.Context, arg database.UpdateGroupB
 	return updateWithReturn(q.log, q.auth, fetch, q.db.UpdateGroupByID)(ctx, arg)
 }
 
+func (q *querier) BumpProvisionerJobByID(ctx context.Context, arg database.BumpProvisionerJobByIDParams) error {
+	// Reordering the queue affects every job, so it's restricted to those
+	// who can manage provisioner daemons.
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceProvisionerDaemon); err != nil {
+		return err
+	}
+	return q.db.BumpProvisionerJobByID(ctx, arg)
+}
+
+func (q *querier) GetPendingProvisionerJobs(ctx context.Context) ([]database.ProvisionerJob, error) {
+	// Anyone who can see the provisioner daemons can see the queue of jobs
+	// waiting for them.
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceProvisionerDaemon); err != nil {
+		return nil, err
+	}
+	return q.db.GetPendingProvisionerJobs(ctx)
+}
+
 func (q *querier) UpdateProvisionerJobWithCancelByID(ctx context.Context, arg database.UpdateProvisionerJobWithCancelByIDParams) error {
-	job, err := q.db.GetProvisionerJobByID(ctx, arg.ID)
+	err := q.authorizeProvisionerJobCancel(ctx, arg.ID)
+	if err != nil {
+		// Those who manage provisioner daemons can cancel any job to unblock
+		// the queue.
+		if q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceProvisionerDaemon) != nil {
+			return err
+		}
+	}
+	return q.db.UpdateProvisionerJobWithCancelByID(ctx, arg)
+}
+
+// authorizeProvisionerJobCancel checks whether the actor can cancel the job
+// through the workspace or template version it belongs to.
+func (q *querier) authorizeProvisionerJobCancel(ctx context.Context, jobID uuid.UUID) error {
+	job, err := q.db.GetProvisionerJobByID(ctx, jobID)
 	if err != nil {
 		return err
 	}
 
 	switch job.Type {
 	case database.ProvisionerJobTypeWorkspaceBuild:
-		build, err := q.db.GetWorkspaceBuildByJobID(ctx, arg.ID)
+		build, err := q.db.GetWorkspaceBuildByJobID(ctx, jobID)
 		if err != nil {
 			return err
 		}
@@ -279,7 +311,7 @@ func (q *querier) UpdateProvisionerJobWithCancelByID(ctx context.Context, arg da
 	default:
 		return xerrors.Errorf("unknown job type: %q", job.Type)
 	}
-	return q.db.UpdateProvisionerJobWithCancelByID(ctx, arg)
+	return nil
 }
 
 func (q *querier) GetProvisionerJobByID(ctx context.Context, id uuid.UUID) (database.ProvisionerJob, error) {
@@ -318,6 +350,15 @@ func (q *querier) GetProvisionerLogsAfterID(ctx context.Context, arg database.Ge
 	return q.db.GetProvisionerLogsAfterID(ctx, arg)
 }
 
+func (q *querier) GetProvisionerJobResourceChangesByJobID(ctx context.Context, jobID uuid.UUID) ([]database.ProvisionerJobResourceChange, error) {
+	// Authorized read on job lets the actor also read the resource changes.
+	_, err := q.GetProvisionerJobByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return q.db.GetProvisionerJobResourceChangesByJobID(ctx, jobID)
+}
+
 func (q *querier) GetWorkspaceAgentStartupLogsAfter(ctx context.Context, arg database.GetWorkspaceAgentStartupLogsAfterParams) ([]database.WorkspaceAgentStartupLog, error) {
 	_, err := q.GetWorkspaceAgentByID(ctx, arg.AgentID)
 	if err != nil {
@@ -369,6 +410,43 @@ func (q *querier) DeleteLicense(ctx context.Context, id int32) (int32, error) {
 	return id, nil
 }
 
+func (q *querier) GetNotificationWebhooks(ctx context.Context) ([]database.NotificationWebhook, error) {
+	fetch := func(ctx context.Context, _ interface{}) ([]database.NotificationWebhook, error) {
+		return q.db.GetNotificationWebhooks(ctx)
+	}
+	return fetchWithPostFilter(q.auth, fetch)(ctx, nil)
+}
+
+func (q *querier) GetNotificationWebhookByID(ctx context.Context, id uuid.UUID) (database.NotificationWebhook, error) {
+	return fetch(q.log, q.auth, q.db.GetNotificationWebhookByID)(ctx, id)
+}
+
+func (q *querier) InsertNotificationWebhook(ctx context.Context, arg database.InsertNotificationWebhookParams) (database.NotificationWebhook, error) {
+	return insert(q.log, q.auth, rbac.ResourceNotificationWebhook, q.db.InsertNotificationWebhook)(ctx, arg)
+}
+
+func (q *querier) UpdateNotificationWebhookByID(ctx context.Context, arg database.UpdateNotificationWebhookByIDParams) (database.NotificationWebhook, error) {
+	fetch := func(ctx context.Context, arg database.UpdateNotificationWebhookByIDParams) (database.NotificationWebhook, error) {
+		return q.db.GetNotificationWebhookByID(ctx, arg.ID)
+	}
+	return updateWithReturn(q.log, q.auth, fetch, q.db.UpdateNotificationWebhookByID)(ctx, arg)
+}
+
+func (q *querier) DeleteNotificationWebhookByID(ctx context.Context, id uuid.UUID) error {
+	return deleteQ(q.log, q.auth, q.db.GetNotificationWebhookByID, q.db.DeleteNotificationWebhookByID)(ctx, id)
+}
+
+func (q *querier) GetNotificationWebhookDeliveries(ctx context.Context, arg database.GetNotificationWebhookDeliveriesParams) ([]database.NotificationWebhookDelivery, error) {
+	webhook, err := q.db.GetNotificationWebhookByID(ctx, arg.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.authorizeContext(ctx, rbac.ActionRead, webhook); err != nil {
+		return nil, err
+	}
+	return q.db.GetNotificationWebhookDeliveries(ctx, arg)
+}
+
 func (q *querier) GetDeploymentID(ctx context.Context) (string, error) {
 	// No authz checks
 	return q.db.GetDeploymentID(ctx)
@@ -405,6 +483,10 @@ func (q *querier) GetGroupsByOrganizationID(ctx context.Context, organizationID
 	return fetchWithPostFilter(q.auth, q.db.GetGroupsByOrganizationID)(ctx, organizationID)
 }
 
+func (q *querier) GetGroupsByOrganizationAndUserID(ctx context.Context, arg database.GetGroupsByOrganizationAndUserIDParams) ([]database.Group, error) {
+	return fetchWithPostFilter(q.auth, q.db.GetGroupsByOrganizationAndUserID)(ctx, arg)
+}
+
 func (q *querier) GetOrganizationByID(ctx context.Context, id uuid.UUID) (database.Organization, error) {
 	return fetch(q.log, q.auth, q.db.GetOrganizationByID)(ctx, id)
 }
@@ -994,6 +1076,98 @@ func (q *querier) GetTemplateUserRoles(ctx context.Context, id uuid.UUID) ([]dat
 	return q.db.GetTemplateUserRoles(ctx, id)
 }
 
+// authorizeTemplateVersionPromotion authorizes the action on the template of
+// the promotion. Promotions have no RBAC object of their own, since they can
+// only be read and changed by those who can read and change the template.
+func (q *querier) authorizeTemplateVersionPromotion(ctx context.Context, action rbac.Action, promotionID uuid.UUID) error {
+	promotion, err := q.db.GetTemplateVersionPromotionByID(ctx, promotionID)
+	if err != nil {
+		return err
+	}
+	template, err := q.db.GetTemplateByID(ctx, promotion.TemplateID)
+	if err != nil {
+		return err
+	}
+	return q.authorizeContext(ctx, action, template)
+}
+
+func (q *querier) GetTemplateVersionPromotionByID(ctx context.Context, id uuid.UUID) (database.TemplateVersionPromotion, error) {
+	// An actor can read a promotion if they can read the related template.
+	promotion, err := q.db.GetTemplateVersionPromotionByID(ctx, id)
+	if err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+	if _, err := q.GetTemplateByID(ctx, promotion.TemplateID); err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+	return promotion, nil
+}
+
+func (q *querier) GetPendingTemplateVersionPromotionByTemplateVersionID(ctx context.Context, templateVersionID uuid.UUID) (database.TemplateVersionPromotion, error) {
+	// An actor can read a promotion if they can read the related template.
+	promotion, err := q.db.GetPendingTemplateVersionPromotionByTemplateVersionID(ctx, templateVersionID)
+	if err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+	if _, err := q.GetTemplateByID(ctx, promotion.TemplateID); err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+	return promotion, nil
+}
+
+func (q *querier) GetTemplateVersionPromotionsByTemplateID(ctx context.Context, templateID uuid.UUID) ([]database.TemplateVersionPromotion, error) {
+	// An actor can read promotions if they can read the related template.
+	if _, err := q.GetTemplateByID(ctx, templateID); err != nil {
+		return nil, err
+	}
+	return q.db.GetTemplateVersionPromotionsByTemplateID(ctx, templateID)
+}
+
+func (q *querier) InsertTemplateVersionPromotion(ctx context.Context, arg database.InsertTemplateVersionPromotionParams) (database.TemplateVersionPromotion, error) {
+	// An actor can request a promotion if they can update the template.
+	template, err := q.db.GetTemplateByID(ctx, arg.TemplateID)
+	if err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, template); err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+	return q.db.InsertTemplateVersionPromotion(ctx, arg)
+}
+
+func (q *querier) InsertTemplateVersionPromotionApproval(ctx context.Context, arg database.InsertTemplateVersionPromotionApprovalParams) (database.TemplateVersionPromotion, error) {
+	// An actor can approve a promotion if they can update the template.
+	if err := q.authorizeTemplateVersionPromotion(ctx, rbac.ActionUpdate, arg.ID); err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+	return q.db.InsertTemplateVersionPromotionApproval(ctx, arg)
+}
+
+func (q *querier) UpdateTemplateVersionPromotionByID(ctx context.Context, arg database.UpdateTemplateVersionPromotionByIDParams) (database.TemplateVersionPromotion, error) {
+	if err := q.authorizeTemplateVersionPromotion(ctx, rbac.ActionUpdate, arg.ID); err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+	return q.db.UpdateTemplateVersionPromotionByID(ctx, arg)
+}
+
+func (q *querier) PromoteTemplateVersionPromotion(ctx context.Context, arg database.PromoteTemplateVersionPromotionParams) (database.TemplateVersionPromotion, error) {
+	if err := q.authorizeTemplateVersionPromotion(ctx, rbac.ActionUpdate, arg.ID); err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+	return q.db.PromoteTemplateVersionPromotion(ctx, arg)
+}
+
+func (q *querier) SupersedeTemplateVersionPromotions(ctx context.Context, arg database.SupersedeTemplateVersionPromotionsParams) error {
+	template, err := q.db.GetTemplateByID(ctx, arg.TemplateID)
+	if err != nil {
+		return err
+	}
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, template); err != nil {
+		return err
+	}
+	return q.db.SupersedeTemplateVersionPromotions(ctx, arg)
+}
+
 func (q *querier) DeleteAPIKeysByUserID(ctx context.Context, userID uuid.UUID) error {
 	// TODO: This is not 100% correct because it omits apikey IDs.
 	err := q.authorizeContext(ctx, rbac.ActionDelete,
@@ -1319,6 +1493,32 @@ func (q *querier) UpdateWorkspaceAgentLifecycleStateByID(ctx context.Context, ar
 	return q.db.UpdateWorkspaceAgentLifecycleStateByID(ctx, arg)
 }
 
+func (q *querier) UpdateWorkspaceAgentMetadata(ctx context.Context, arg database.UpdateWorkspaceAgentMetadataParams) error {
+	workspace, err := q.db.GetWorkspaceByAgentID(ctx, arg.WorkspaceAgentID)
+	if err != nil {
+		return err
+	}
+
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, workspace); err != nil {
+		return err
+	}
+
+	return q.db.UpdateWorkspaceAgentMetadata(ctx, arg)
+}
+
+func (q *querier) GetWorkspaceAgentMetadata(ctx context.Context, workspaceAgentID uuid.UUID) ([]database.WorkspaceAgentMetadatum, error) {
+	workspace, err := q.db.GetWorkspaceByAgentID(ctx, workspaceAgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.authorizeContext(ctx, rbac.ActionRead, workspace); err != nil {
+		return nil, err
+	}
+
+	return q.db.GetWorkspaceAgentMetadata(ctx, workspaceAgentID)
+}
+
 func (q *querier) UpdateWorkspaceAgentStartupLogOverflowByID(ctx context.Context, arg database.UpdateWorkspaceAgentStartupLogOverflowByIDParams) error {
 	agent, err := q.db.GetWorkspaceAgentByID(ctx, arg.ID)
 	if err != nil {
@@ -1647,6 +1847,87 @@ func (q *querier) GetWorkspaceByWorkspaceAppID(ctx context.Context, workspaceApp
 	return fetch(q.log, q.auth, q.db.GetWorkspaceByWorkspaceAppID)(ctx, workspaceAppID)
 }
 
+func (q *querier) InsertWorkspaceSessionRecording(ctx context.Context, arg database.InsertWorkspaceSessionRecordingParams) (database.WorkspaceSessionRecording, error) {
+	// Recordings are uploaded by the workspace agent.
+	workspace, err := q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	if err != nil {
+		return database.WorkspaceSessionRecording{}, err
+	}
+	err = q.authorizeContext(ctx, rbac.ActionUpdate, workspace)
+	if err != nil {
+		return database.WorkspaceSessionRecording{}, err
+	}
+	return q.db.InsertWorkspaceSessionRecording(ctx, arg)
+}
+
+// Session recordings are kept for security reviews, so only those who can
+// read the audit log can read them. Workspace owners can't.
+func (q *querier) GetWorkspaceSessionRecordingByID(ctx context.Context, id uuid.UUID) (database.WorkspaceSessionRecording, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return database.WorkspaceSessionRecording{}, err
+	}
+	return q.db.GetWorkspaceSessionRecordingByID(ctx, id)
+}
+
+func (q *querier) GetWorkspaceSessionRecordingsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]database.GetWorkspaceSessionRecordingsByWorkspaceIDRow, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return nil, err
+	}
+	return q.db.GetWorkspaceSessionRecordingsByWorkspaceID(ctx, workspaceID)
+}
+
+func (q *querier) GetWorkspaceAgentPortShareRules(ctx context.Context, workspaceAgentID uuid.UUID) ([]database.WorkspaceAgentPortShareRule, error) {
+	workspace, err := q.db.GetWorkspaceByAgentID(ctx, workspaceAgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.authorizeContext(ctx, rbac.ActionRead, workspace); err != nil {
+		return nil, err
+	}
+
+	return q.db.GetWorkspaceAgentPortShareRules(ctx, workspaceAgentID)
+}
+
+func (q *querier) GetWorkspaceAgentPortShares(ctx context.Context, arg database.GetWorkspaceAgentPortSharesParams) ([]database.WorkspaceAgentPortShare, error) {
+	workspace, err := q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.authorizeContext(ctx, rbac.ActionRead, workspace); err != nil {
+		return nil, err
+	}
+
+	return q.db.GetWorkspaceAgentPortShares(ctx, arg)
+}
+
+func (q *querier) UpsertWorkspaceAgentPortShare(ctx context.Context, arg database.UpsertWorkspaceAgentPortShareParams) (database.WorkspaceAgentPortShare, error) {
+	workspace, err := q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	if err != nil {
+		return database.WorkspaceAgentPortShare{}, err
+	}
+
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, workspace); err != nil {
+		return database.WorkspaceAgentPortShare{}, err
+	}
+
+	return q.db.UpsertWorkspaceAgentPortShare(ctx, arg)
+}
+
+func (q *querier) DeleteWorkspaceAgentPortShare(ctx context.Context, arg database.DeleteWorkspaceAgentPortShareParams) error {
+	workspace, err := q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, workspace); err != nil {
+		return err
+	}
+
+	return q.db.DeleteWorkspaceAgentPortShare(ctx, arg)
+}
+
 func authorizedTemplateVersionFromJob(ctx context.Context, q *querier, job database.ProvisionerJob) (database.TemplateVersion, error) {
 	switch job.Type {
 	case database.ProvisionerJobTypeTemplateVersionDryRun:
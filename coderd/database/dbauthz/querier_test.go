@@ -282,6 +282,14 @@ func (s *MethodTestSuite) TestProvsionerJob() {
 		check.Args(database.UpdateProvisionerJobWithCancelByIDParams{ID: j.ID}).
 			Asserts(v.RBACObject(tpl), []rbac.Action{rbac.ActionRead, rbac.ActionUpdate}).Returns()
 	}))
+	s.Run("BumpProvisionerJobByID", s.Subtest(func(db database.Store, check *expects) {
+		j := dbgen.ProvisionerJob(s.T(), db, database.ProvisionerJob{})
+		check.Args(database.BumpProvisionerJobByIDParams{ID: j.ID}).Asserts(rbac.ResourceProvisionerDaemon, rbac.ActionUpdate).Returns()
+	}))
+	s.Run("GetPendingProvisionerJobs", s.Subtest(func(db database.Store, check *expects) {
+		j := dbgen.ProvisionerJob(s.T(), db, database.ProvisionerJob{})
+		check.Args().Asserts(rbac.ResourceProvisionerDaemon, rbac.ActionRead).Returns(slice.New(j))
+	}))
 	s.Run("GetProvisionerJobsByIDs", s.Subtest(func(db database.Store, check *expects) {
 		a := dbgen.ProvisionerJob(s.T(), db, database.ProvisionerJob{})
 		b := dbgen.ProvisionerJob(s.T(), db, database.ProvisionerJob{})
@@ -297,6 +305,14 @@ func (s *MethodTestSuite) TestProvsionerJob() {
 			JobID: j.ID,
 		}).Asserts(w, rbac.ActionRead).Returns([]database.ProvisionerJobLog{})
 	}))
+	s.Run("GetProvisionerJobResourceChangesByJobID", s.Subtest(func(db database.Store, check *expects) {
+		w := dbgen.Workspace(s.T(), db, database.Workspace{})
+		j := dbgen.ProvisionerJob(s.T(), db, database.ProvisionerJob{
+			Type: database.ProvisionerJobTypeWorkspaceBuild,
+		})
+		_ = dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{JobID: j.ID, WorkspaceID: w.ID})
+		check.Args(j.ID).Asserts(w, rbac.ActionRead).Returns([]database.ProvisionerJobResourceChange{})
+	}))
 }
 
 func (s *MethodTestSuite) TestLicense() {
@@ -347,6 +363,43 @@ func (s *MethodTestSuite) TestLicense() {
 	}))
 }
 
+func (s *MethodTestSuite) TestNotificationWebhook() {
+	s.Run("GetNotificationWebhooks", s.Subtest(func(db database.Store, check *expects) {
+		w := dbgen.NotificationWebhook(s.T(), db, database.NotificationWebhook{})
+		check.Args().Asserts(w, rbac.ActionRead).
+			Returns([]database.NotificationWebhook{w})
+	}))
+	s.Run("GetNotificationWebhookByID", s.Subtest(func(db database.Store, check *expects) {
+		w := dbgen.NotificationWebhook(s.T(), db, database.NotificationWebhook{})
+		check.Args(w.ID).Asserts(w, rbac.ActionRead).Returns(w)
+	}))
+	s.Run("InsertNotificationWebhook", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.InsertNotificationWebhookParams{
+			ID:     uuid.New(),
+			Name:   "test",
+			Events: []database.NotificationEventType{database.NotificationEventTypeWorkspaceBuildFailed},
+		}).Asserts(rbac.ResourceNotificationWebhook, rbac.ActionCreate)
+	}))
+	s.Run("UpdateNotificationWebhookByID", s.Subtest(func(db database.Store, check *expects) {
+		w := dbgen.NotificationWebhook(s.T(), db, database.NotificationWebhook{})
+		check.Args(database.UpdateNotificationWebhookByIDParams{
+			ID:     w.ID,
+			Name:   w.Name,
+			Events: w.Events,
+		}).Asserts(w, rbac.ActionUpdate)
+	}))
+	s.Run("DeleteNotificationWebhookByID", s.Subtest(func(db database.Store, check *expects) {
+		w := dbgen.NotificationWebhook(s.T(), db, database.NotificationWebhook{})
+		check.Args(w.ID).Asserts(w, rbac.ActionDelete)
+	}))
+	s.Run("GetNotificationWebhookDeliveries", s.Subtest(func(db database.Store, check *expects) {
+		w := dbgen.NotificationWebhook(s.T(), db, database.NotificationWebhook{})
+		check.Args(database.GetNotificationWebhookDeliveriesParams{
+			WebhookID: w.ID,
+		}).Asserts(w, rbac.ActionRead)
+	}))
+}
+
 func (s *MethodTestSuite) TestOrganization() {
 	s.Run("GetGroupsByOrganizationID", s.Subtest(func(db database.Store, check *expects) {
 		o := dbgen.Organization(s.T(), db, database.Organization{})
@@ -355,6 +408,17 @@ func (s *MethodTestSuite) TestOrganization() {
 		check.Args(o.ID).Asserts(a, rbac.ActionRead, b, rbac.ActionRead).
 			Returns([]database.Group{a, b})
 	}))
+	s.Run("GetGroupsByOrganizationAndUserID", s.Subtest(func(db database.Store, check *expects) {
+		o := dbgen.Organization(s.T(), db, database.Organization{})
+		u := dbgen.User(s.T(), db, database.User{})
+		a := dbgen.Group(s.T(), db, database.Group{OrganizationID: o.ID})
+		_ = dbgen.Group(s.T(), db, database.Group{OrganizationID: o.ID})
+		_ = dbgen.GroupMember(s.T(), db, database.GroupMember{GroupID: a.ID, UserID: u.ID})
+		check.Args(database.GetGroupsByOrganizationAndUserIDParams{
+			OrganizationID: o.ID,
+			UserID:         u.ID,
+		}).Asserts(a, rbac.ActionRead).Returns([]database.Group{a})
+	}))
 	s.Run("GetOrganizationByID", s.Subtest(func(db database.Store, check *expects) {
 		o := dbgen.Organization(s.T(), db, database.Organization{})
 		check.Args(o.ID).Asserts(o, rbac.ActionRead).Returns(o)
@@ -754,6 +818,73 @@ func (s *MethodTestSuite) TestTemplate() {
 			GitAuthProviders: []string{},
 		}).Asserts(t1, rbac.ActionUpdate).Returns()
 	}))
+	s.Run("GetTemplateVersionPromotionByID", s.Subtest(func(db database.Store, check *expects) {
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		p := dbgen.TemplateVersionPromotion(s.T(), db, database.TemplateVersionPromotion{
+			TemplateID: t1.ID,
+		})
+		check.Args(p.ID).Asserts(t1, rbac.ActionRead).Returns(p)
+	}))
+	s.Run("GetPendingTemplateVersionPromotionByTemplateVersionID", s.Subtest(func(db database.Store, check *expects) {
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		p := dbgen.TemplateVersionPromotion(s.T(), db, database.TemplateVersionPromotion{
+			TemplateID: t1.ID,
+		})
+		check.Args(p.TemplateVersionID).Asserts(t1, rbac.ActionRead).Returns(p)
+	}))
+	s.Run("GetTemplateVersionPromotionsByTemplateID", s.Subtest(func(db database.Store, check *expects) {
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		p := dbgen.TemplateVersionPromotion(s.T(), db, database.TemplateVersionPromotion{
+			TemplateID: t1.ID,
+		})
+		check.Args(t1.ID).Asserts(t1, rbac.ActionRead).Returns(slice.New(p))
+	}))
+	s.Run("InsertTemplateVersionPromotion", s.Subtest(func(db database.Store, check *expects) {
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		check.Args(database.InsertTemplateVersionPromotionParams{
+			ID:                uuid.New(),
+			TemplateID:        t1.ID,
+			TemplateVersionID: uuid.New(),
+			Status:            database.TemplateVersionPromotionStatusPending,
+		}).Asserts(t1, rbac.ActionUpdate)
+	}))
+	s.Run("InsertTemplateVersionPromotionApproval", s.Subtest(func(db database.Store, check *expects) {
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		p := dbgen.TemplateVersionPromotion(s.T(), db, database.TemplateVersionPromotion{
+			TemplateID: t1.ID,
+		})
+		check.Args(database.InsertTemplateVersionPromotionApprovalParams{
+			ID:         p.ID,
+			ApproverID: uuid.New(),
+		}).Asserts(t1, rbac.ActionUpdate)
+	}))
+	s.Run("UpdateTemplateVersionPromotionByID", s.Subtest(func(db database.Store, check *expects) {
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		p := dbgen.TemplateVersionPromotion(s.T(), db, database.TemplateVersionPromotion{
+			TemplateID: t1.ID,
+		})
+		check.Args(database.UpdateTemplateVersionPromotionByIDParams{
+			ID:          p.ID,
+			DryRunJobID: p.DryRunJobID,
+			Status:      database.TemplateVersionPromotionStatusPromoted,
+		}).Asserts(t1, rbac.ActionUpdate)
+	}))
+	s.Run("PromoteTemplateVersionPromotion", s.Subtest(func(db database.Store, check *expects) {
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		p := dbgen.TemplateVersionPromotion(s.T(), db, database.TemplateVersionPromotion{
+			TemplateID: t1.ID,
+		})
+		check.Args(database.PromoteTemplateVersionPromotionParams{
+			ID: p.ID,
+		}).Asserts(t1, rbac.ActionUpdate)
+	}))
+	s.Run("SupersedeTemplateVersionPromotions", s.Subtest(func(db database.Store, check *expects) {
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		check.Args(database.SupersedeTemplateVersionPromotionsParams{
+			TemplateID:  t1.ID,
+			PromotionID: uuid.New(),
+		}).Asserts(t1, rbac.ActionUpdate).Returns()
+	}))
 }
 
 func (s *MethodTestSuite) TestUser() {
@@ -983,6 +1114,31 @@ func (s *MethodTestSuite) TestWorkspace() {
 			LifecycleState: database.WorkspaceAgentLifecycleStateCreated,
 		}).Asserts(ws, rbac.ActionUpdate).Returns()
 	}))
+	s.Run("UpdateWorkspaceAgentMetadata", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{WorkspaceID: ws.ID, JobID: uuid.New()})
+		res := dbgen.WorkspaceResource(s.T(), db, database.WorkspaceResource{JobID: build.JobID})
+		agt := dbgen.WorkspaceAgent(s.T(), db, database.WorkspaceAgent{ResourceID: res.ID})
+		check.Args(database.UpdateWorkspaceAgentMetadataParams{
+			WorkspaceAgentID: agt.ID,
+		}).Asserts(ws, rbac.ActionUpdate).Returns()
+	}))
+	s.Run("GetWorkspaceAgentMetadata", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{WorkspaceID: ws.ID, JobID: uuid.New()})
+		res := dbgen.WorkspaceResource(s.T(), db, database.WorkspaceResource{JobID: build.JobID})
+		agt := dbgen.WorkspaceAgent(s.T(), db, database.WorkspaceAgent{ResourceID: res.ID})
+		_ = db.InsertWorkspaceAgentMetadata(context.Background(), database.InsertWorkspaceAgentMetadataParams{
+			WorkspaceAgentID: agt.ID,
+			DisplayName:      "test",
+			Key:              "test",
+		})
+		check.Args(agt.ID).Asserts(ws, rbac.ActionRead).Returns([]database.WorkspaceAgentMetadatum{{
+			WorkspaceAgentID: agt.ID,
+			DisplayName:      "test",
+			Key:              "test",
+		}})
+	}))
 	s.Run("UpdateWorkspaceAgentStartupLogOverflowByID", s.Subtest(func(db database.Store, check *expects) {
 		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
 		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{WorkspaceID: ws.ID, JobID: uuid.New()})
@@ -1214,6 +1370,70 @@ func (s *MethodTestSuite) TestWorkspace() {
 		app := dbgen.WorkspaceApp(s.T(), db, database.WorkspaceApp{AgentID: agt.ID})
 		check.Args(app.ID).Asserts(ws, rbac.ActionRead).Returns(ws)
 	}))
+	s.Run("InsertWorkspaceSessionRecording", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		check.Args(database.InsertWorkspaceSessionRecordingParams{
+			ID:          uuid.New(),
+			WorkspaceID: ws.ID,
+			SessionType: database.WorkspaceSessionTypeSSH,
+		}).Asserts(ws, rbac.ActionUpdate)
+	}))
+	s.Run("GetWorkspaceSessionRecordingByID", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		recording, err := db.InsertWorkspaceSessionRecording(context.Background(), database.InsertWorkspaceSessionRecordingParams{
+			ID:          uuid.New(),
+			WorkspaceID: ws.ID,
+			SessionType: database.WorkspaceSessionTypeSSH,
+			Data:        []byte{},
+		})
+		require.NoError(s.T(), err)
+		check.Args(recording.ID).Asserts(rbac.ResourceAuditLog, rbac.ActionRead).Returns(recording)
+	}))
+	s.Run("GetWorkspaceSessionRecordingsByWorkspaceID", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		check.Args(ws.ID).Asserts(rbac.ResourceAuditLog, rbac.ActionRead)
+	}))
+	s.Run("GetWorkspaceAgentPortShareRules", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{WorkspaceID: ws.ID, JobID: uuid.New()})
+		res := dbgen.WorkspaceResource(s.T(), db, database.WorkspaceResource{JobID: build.JobID})
+		agt := dbgen.WorkspaceAgent(s.T(), db, database.WorkspaceAgent{ResourceID: res.ID})
+		err := db.InsertWorkspaceAgentPortShareRule(context.Background(), database.InsertWorkspaceAgentPortShareRuleParams{
+			WorkspaceAgentID: agt.ID,
+			Port:             8080,
+			ShareLevel:       database.AppSharingLevelPublic,
+		})
+		require.NoError(s.T(), err)
+		check.Args(agt.ID).Asserts(ws, rbac.ActionRead).Returns([]database.WorkspaceAgentPortShareRule{{
+			WorkspaceAgentID: agt.ID,
+			Port:             8080,
+			ShareLevel:       database.AppSharingLevelPublic,
+		}})
+	}))
+	s.Run("GetWorkspaceAgentPortShares", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		check.Args(database.GetWorkspaceAgentPortSharesParams{
+			WorkspaceID: ws.ID,
+			AgentName:   "dev",
+		}).Asserts(ws, rbac.ActionRead)
+	}))
+	s.Run("UpsertWorkspaceAgentPortShare", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		check.Args(database.UpsertWorkspaceAgentPortShareParams{
+			WorkspaceID: ws.ID,
+			AgentName:   "dev",
+			Port:        8080,
+			ShareLevel:  database.AppSharingLevelAuthenticated,
+		}).Asserts(ws, rbac.ActionUpdate)
+	}))
+	s.Run("DeleteWorkspaceAgentPortShare", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		check.Args(database.DeleteWorkspaceAgentPortShareParams{
+			WorkspaceID: ws.ID,
+			AgentName:   "dev",
+			Port:        8080,
+		}).Asserts(ws, rbac.ActionUpdate).Returns()
+	}))
 }
 
 func (s *MethodTestSuite) TestExtraMethods() {
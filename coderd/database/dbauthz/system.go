@@ -259,6 +259,20 @@ func (q *querier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, createdAt
 	return q.db.GetWorkspaceAgentsCreatedAfter(ctx, createdAt)
 }
 
+func (q *querier) GetWorkspaceAgentsInLatestBuilds(ctx context.Context) ([]database.GetWorkspaceAgentsInLatestBuildsRow, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetWorkspaceAgentsInLatestBuilds(ctx)
+}
+
+func (q *querier) GetPendingProvisionerJobStats(ctx context.Context) ([]database.GetPendingProvisionerJobStatsRow, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetPendingProvisionerJobStats(ctx)
+}
+
 func (q *querier) GetWorkspaceAppsCreatedAfter(ctx context.Context, createdAt time.Time) ([]database.WorkspaceApp, error) {
 	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
 		return nil, err
@@ -294,6 +308,48 @@ func (q *querier) DeleteOldWorkspaceAgentStartupLogs(ctx context.Context) error
 	return q.db.DeleteOldWorkspaceAgentStartupLogs(ctx)
 }
 
+func (q *querier) DeleteOldAuditLogs(ctx context.Context, arg database.DeleteOldAuditLogsParams) (int64, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.DeleteOldAuditLogs(ctx, arg)
+}
+
+func (q *querier) DeleteOldProvisionerJobLogs(ctx context.Context, arg database.DeleteOldProvisionerJobLogsParams) (int64, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.DeleteOldProvisionerJobLogs(ctx, arg)
+}
+
+func (q *querier) DeleteOldWorkspaceBuildStates(ctx context.Context, arg database.DeleteOldWorkspaceBuildStatesParams) (int64, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.DeleteOldWorkspaceBuildStates(ctx, arg)
+}
+
+func (q *querier) GetOldAuditLogCount(ctx context.Context, before time.Time) (int64, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.GetOldAuditLogCount(ctx, before)
+}
+
+func (q *querier) GetOldProvisionerJobLogCount(ctx context.Context, before time.Time) (int64, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.GetOldProvisionerJobLogCount(ctx, before)
+}
+
+func (q *querier) GetOldWorkspaceBuildStateCount(ctx context.Context, before time.Time) (int64, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.GetOldWorkspaceBuildStateCount(ctx, before)
+}
+
 func (q *querier) GetDeploymentWorkspaceAgentStats(ctx context.Context, createdAfter time.Time) (database.GetDeploymentWorkspaceAgentStatsRow, error) {
 	return q.db.GetDeploymentWorkspaceAgentStats(ctx, createdAfter)
 }
@@ -330,6 +386,20 @@ func (q *querier) InsertWorkspaceAgent(ctx context.Context, arg database.InsertW
 	return q.db.InsertWorkspaceAgent(ctx, arg)
 }
 
+func (q *querier) InsertWorkspaceAgentMetadata(ctx context.Context, arg database.InsertWorkspaceAgentMetadataParams) error {
+	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.InsertWorkspaceAgentMetadata(ctx, arg)
+}
+
+func (q *querier) InsertWorkspaceAgentPortShareRule(ctx context.Context, arg database.InsertWorkspaceAgentPortShareRuleParams) error {
+	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.InsertWorkspaceAgentPortShareRule(ctx, arg)
+}
+
 func (q *querier) InsertWorkspaceApp(ctx context.Context, arg database.InsertWorkspaceAppParams) (database.WorkspaceApp, error) {
 	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
 		return database.WorkspaceApp{}, err
@@ -396,6 +466,20 @@ func (q *querier) InsertProvisionerDaemon(ctx context.Context, arg database.Inse
 	return q.db.InsertProvisionerDaemon(ctx, arg)
 }
 
+func (q *querier) UpdateProvisionerDaemonHeartbeat(ctx context.Context, arg database.UpdateProvisionerDaemonHeartbeatParams) error {
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.UpdateProvisionerDaemonHeartbeat(ctx, arg)
+}
+
+func (q *querier) DeleteStaleProvisionerDaemons(ctx context.Context, staleBefore time.Time) (int64, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.DeleteStaleProvisionerDaemons(ctx, staleBefore)
+}
+
 func (q *querier) InsertTemplateVersionParameter(ctx context.Context, arg database.InsertTemplateVersionParameterParams) (database.TemplateVersionParameter, error) {
 	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
 		return database.TemplateVersionParameter{}, err
@@ -410,6 +494,13 @@ func (q *querier) InsertTemplateVersionVariable(ctx context.Context, arg databas
 	return q.db.InsertTemplateVersionVariable(ctx, arg)
 }
 
+func (q *querier) InsertProvisionerJobResourceChange(ctx context.Context, arg database.InsertProvisionerJobResourceChangeParams) (database.ProvisionerJobResourceChange, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
+		return database.ProvisionerJobResourceChange{}, err
+	}
+	return q.db.InsertProvisionerJobResourceChange(ctx, arg)
+}
+
 func (q *querier) InsertWorkspaceResource(ctx context.Context, arg database.InsertWorkspaceResourceParams) (database.WorkspaceResource, error) {
 	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
 		return database.WorkspaceResource{}, err
@@ -423,3 +514,181 @@ func (q *querier) InsertParameterSchema(ctx context.Context, arg database.Insert
 	}
 	return q.db.InsertParameterSchema(ctx, arg)
 }
+
+func (q *querier) GetEnabledNotificationWebhooksByEventType(ctx context.Context, eventType database.NotificationEventType) ([]database.NotificationWebhook, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetEnabledNotificationWebhooksByEventType(ctx, eventType)
+}
+
+func (q *querier) InsertNotificationWebhookDelivery(ctx context.Context, arg database.InsertNotificationWebhookDeliveryParams) (database.NotificationWebhookDelivery, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
+		return database.NotificationWebhookDelivery{}, err
+	}
+	return q.db.InsertNotificationWebhookDelivery(ctx, arg)
+}
+
+func (q *querier) UpdateNotificationWebhookDeliveryByID(ctx context.Context, arg database.UpdateNotificationWebhookDeliveryByIDParams) error {
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.UpdateNotificationWebhookDeliveryByID(ctx, arg)
+}
+
+func (q *querier) DeleteOldNotificationWebhookDeliveries(ctx context.Context) error {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.DeleteOldNotificationWebhookDeliveries(ctx)
+}
+
+func (q *querier) IncrementRateLimitCounter(ctx context.Context, arg database.IncrementRateLimitCounterParams) (int64, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.IncrementRateLimitCounter(ctx, arg)
+}
+
+func (q *querier) DeleteExpiredRateLimitCounters(ctx context.Context, now time.Time) error {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.DeleteExpiredRateLimitCounters(ctx, now)
+}
+
+func (q *querier) UpsertTailnetCoordinator(ctx context.Context, arg database.UpsertTailnetCoordinatorParams) (database.TailnetCoordinator, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return database.TailnetCoordinator{}, err
+	}
+	return q.db.UpsertTailnetCoordinator(ctx, arg)
+}
+
+func (q *querier) DeleteTailnetCoordinator(ctx context.Context, id uuid.UUID) error {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.DeleteTailnetCoordinator(ctx, id)
+}
+
+func (q *querier) DeleteTailnetCoordinatorsHeartbeatBefore(ctx context.Context, heartbeatAt time.Time) error {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.DeleteTailnetCoordinatorsHeartbeatBefore(ctx, heartbeatAt)
+}
+
+func (q *querier) GetTailnetCoordinators(ctx context.Context) ([]database.TailnetCoordinator, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetTailnetCoordinators(ctx)
+}
+
+func (q *querier) UpsertTailnetAgent(ctx context.Context, arg database.UpsertTailnetAgentParams) (database.TailnetAgent, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return database.TailnetAgent{}, err
+	}
+	return q.db.UpsertTailnetAgent(ctx, arg)
+}
+
+func (q *querier) DeleteTailnetAgent(ctx context.Context, arg database.DeleteTailnetAgentParams) error {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.DeleteTailnetAgent(ctx, arg)
+}
+
+func (q *querier) GetTailnetAgents(ctx context.Context, id uuid.UUID) ([]database.TailnetAgent, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetTailnetAgents(ctx, id)
+}
+
+func (q *querier) GetAllTailnetAgents(ctx context.Context) ([]database.TailnetAgent, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetAllTailnetAgents(ctx)
+}
+
+func (q *querier) UpsertTailnetClient(ctx context.Context, arg database.UpsertTailnetClientParams) (database.TailnetClient, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return database.TailnetClient{}, err
+	}
+	return q.db.UpsertTailnetClient(ctx, arg)
+}
+
+func (q *querier) DeleteTailnetClient(ctx context.Context, arg database.DeleteTailnetClientParams) error {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.DeleteTailnetClient(ctx, arg)
+}
+
+func (q *querier) GetTailnetClientsForAgent(ctx context.Context, agentID uuid.UUID) ([]database.TailnetClient, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetTailnetClientsForAgent(ctx, agentID)
+}
+
+func (q *querier) GetAllTailnetClients(ctx context.Context) ([]database.TailnetClient, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetAllTailnetClients(ctx)
+}
+
+// Workspace bulk jobs are run by coderd on behalf of the initiator. The
+// workspaces are authorized when the builds are created, and the API only
+// returns jobs to their initiator.
+func (q *querier) InsertWorkspaceBulkJob(ctx context.Context, arg database.InsertWorkspaceBulkJobParams) (database.WorkspaceBulkJob, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
+		return database.WorkspaceBulkJob{}, err
+	}
+	return q.db.InsertWorkspaceBulkJob(ctx, arg)
+}
+
+func (q *querier) GetWorkspaceBulkJobByID(ctx context.Context, id uuid.UUID) (database.WorkspaceBulkJob, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return database.WorkspaceBulkJob{}, err
+	}
+	return q.db.GetWorkspaceBulkJobByID(ctx, id)
+}
+
+func (q *querier) GetStaleWorkspaceBulkJobs(ctx context.Context, updatedAt time.Time) ([]database.WorkspaceBulkJob, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetStaleWorkspaceBulkJobs(ctx, updatedAt)
+}
+
+func (q *querier) UpdateWorkspaceBulkJobByID(ctx context.Context, arg database.UpdateWorkspaceBulkJobByIDParams) error {
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.UpdateWorkspaceBulkJobByID(ctx, arg)
+}
+
+func (q *querier) InsertWorkspaceBulkJobWorkspace(ctx context.Context, arg database.InsertWorkspaceBulkJobWorkspaceParams) (database.WorkspaceBulkJobWorkspace, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
+		return database.WorkspaceBulkJobWorkspace{}, err
+	}
+	return q.db.InsertWorkspaceBulkJobWorkspace(ctx, arg)
+}
+
+func (q *querier) GetWorkspaceBulkJobWorkspaces(ctx context.Context, bulkJobID uuid.UUID) ([]database.GetWorkspaceBulkJobWorkspacesRow, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetWorkspaceBulkJobWorkspaces(ctx, bulkJobID)
+}
+
+func (q *querier) UpdateWorkspaceBulkJobWorkspace(ctx context.Context, arg database.UpdateWorkspaceBulkJobWorkspaceParams) error {
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.UpdateWorkspaceBulkJobWorkspace(ctx, arg)
+}
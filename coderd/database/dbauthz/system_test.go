@@ -3,6 +3,7 @@ package dbauthz_test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -118,6 +119,13 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 		_ = dbgen.WorkspaceAgent(s.T(), db, database.WorkspaceAgent{CreatedAt: time.Now().Add(-time.Hour)})
 		check.Args(time.Now()).Asserts(rbac.ResourceSystem, rbac.ActionRead)
 	}))
+	s.Run("GetWorkspaceAgentsInLatestBuilds", s.Subtest(func(db database.Store, check *expects) {
+		check.Args().Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
+	s.Run("GetPendingProvisionerJobStats", s.Subtest(func(db database.Store, check *expects) {
+		_ = dbgen.ProvisionerJob(s.T(), db, database.ProvisionerJob{})
+		check.Args().Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
 	s.Run("GetWorkspaceAppsCreatedAfter", s.Subtest(func(db database.Store, check *expects) {
 		_ = dbgen.WorkspaceApp(s.T(), db, database.WorkspaceApp{CreatedAt: time.Now().Add(-time.Hour)})
 		check.Args(time.Now()).Asserts(rbac.ResourceSystem, rbac.ActionRead)
@@ -133,6 +141,66 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 	s.Run("DeleteOldWorkspaceAgentStats", s.Subtest(func(db database.Store, check *expects) {
 		check.Args().Asserts(rbac.ResourceSystem, rbac.ActionDelete)
 	}))
+	s.Run("DeleteOldNotificationWebhookDeliveries", s.Subtest(func(db database.Store, check *expects) {
+		check.Args().Asserts(rbac.ResourceSystem, rbac.ActionDelete)
+	}))
+	s.Run("GetEnabledNotificationWebhooksByEventType", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.NotificationEventTypeWorkspaceBuildFailed).Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
+	s.Run("InsertNotificationWebhookDelivery", s.Subtest(func(db database.Store, check *expects) {
+		w := dbgen.NotificationWebhook(s.T(), db, database.NotificationWebhook{})
+		check.Args(database.InsertNotificationWebhookDeliveryParams{
+			ID:        uuid.New(),
+			WebhookID: w.ID,
+			EventID:   uuid.New(),
+			EventType: database.NotificationEventTypeWorkspaceBuildFailed,
+			Attempt:   1,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionCreate)
+	}))
+	s.Run("UpdateNotificationWebhookDeliveryByID", s.Subtest(func(db database.Store, check *expects) {
+		w := dbgen.NotificationWebhook(s.T(), db, database.NotificationWebhook{})
+		d, err := db.InsertNotificationWebhookDelivery(context.Background(), database.InsertNotificationWebhookDeliveryParams{
+			ID:        uuid.New(),
+			WebhookID: w.ID,
+			EventID:   uuid.New(),
+			EventType: database.NotificationEventTypeWorkspaceBuildFailed,
+			Attempt:   1,
+		})
+		require.NoError(s.T(), err)
+		check.Args(database.UpdateNotificationWebhookDeliveryByIDParams{
+			ID:         d.ID,
+			StatusCode: 200,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionUpdate)
+	}))
+	s.Run("DeleteOldAuditLogs", s.Subtest(func(db database.Store, check *expects) {
+		_ = dbgen.AuditLog(s.T(), db, database.AuditLog{Time: time.Now().Add(-time.Hour)})
+		check.Args(database.DeleteOldAuditLogsParams{
+			Before:     time.Now(),
+			LimitCount: 100,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionDelete).Returns(int64(1))
+	}))
+	s.Run("DeleteOldProvisionerJobLogs", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.DeleteOldProvisionerJobLogsParams{
+			Before:     time.Now(),
+			LimitCount: 100,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionDelete).Returns(int64(0))
+	}))
+	s.Run("DeleteOldWorkspaceBuildStates", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.DeleteOldWorkspaceBuildStatesParams{
+			Before:     time.Now(),
+			LimitCount: 100,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionDelete).Returns(int64(0))
+	}))
+	s.Run("GetOldAuditLogCount", s.Subtest(func(db database.Store, check *expects) {
+		_ = dbgen.AuditLog(s.T(), db, database.AuditLog{Time: time.Now().Add(-time.Hour)})
+		check.Args(time.Now()).Asserts(rbac.ResourceSystem, rbac.ActionRead).Returns(int64(1))
+	}))
+	s.Run("GetOldProvisionerJobLogCount", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(time.Now()).Asserts(rbac.ResourceSystem, rbac.ActionRead).Returns(int64(0))
+	}))
+	s.Run("GetOldWorkspaceBuildStateCount", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(time.Now()).Asserts(rbac.ResourceSystem, rbac.ActionRead).Returns(int64(0))
+	}))
 	s.Run("GetParameterSchemasCreatedAfter", s.Subtest(func(db database.Store, check *expects) {
 		_ = dbgen.ParameterSchema(s.T(), db, database.ParameterSchema{CreatedAt: time.Now().Add(-time.Hour)})
 		check.Args(time.Now()).Asserts(rbac.ResourceSystem, rbac.ActionRead)
@@ -218,6 +286,18 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 			ID: uuid.New(),
 		}).Asserts(rbac.ResourceSystem, rbac.ActionCreate)
 	}))
+	s.Run("InsertWorkspaceAgentMetadata", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.InsertWorkspaceAgentMetadataParams{
+			WorkspaceAgentID: uuid.New(),
+		}).Asserts(rbac.ResourceSystem, rbac.ActionCreate)
+	}))
+	s.Run("InsertWorkspaceAgentPortShareRule", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.InsertWorkspaceAgentPortShareRuleParams{
+			WorkspaceAgentID: uuid.New(),
+			Port:             8080,
+			ShareLevel:       database.AppSharingLevelPublic,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionCreate)
+	}))
 	s.Run("InsertWorkspaceApp", s.Subtest(func(db database.Store, check *expects) {
 		check.Args(database.InsertWorkspaceAppParams{
 			ID:           uuid.New(),
@@ -262,6 +342,19 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 			Type:          database.ProvisionerJobTypeWorkspaceBuild,
 		}).Asserts( /*rbac.ResourceSystem, rbac.ActionCreate*/ )
 	}))
+	s.Run("UpdateProvisionerDaemonHeartbeat", s.Subtest(func(db database.Store, check *expects) {
+		d, err := db.InsertProvisionerDaemon(context.Background(), database.InsertProvisionerDaemonParams{ID: uuid.New()})
+		require.NoError(s.T(), err)
+		check.Args(database.UpdateProvisionerDaemonHeartbeatParams{
+			ID:         d.ID,
+			LastSeenAt: sql.NullTime{Time: time.Now(), Valid: true},
+		}).Asserts(rbac.ResourceSystem, rbac.ActionUpdate)
+	}))
+	s.Run("DeleteStaleProvisionerDaemons", s.Subtest(func(db database.Store, check *expects) {
+		_, err := db.InsertProvisionerDaemon(context.Background(), database.InsertProvisionerDaemonParams{ID: uuid.New()})
+		require.NoError(s.T(), err)
+		check.Args(time.Now().Add(time.Hour)).Asserts(rbac.ResourceSystem, rbac.ActionDelete)
+	}))
 	s.Run("InsertProvisionerJobLogs", s.Subtest(func(db database.Store, check *expects) {
 		// TODO: we need to create a ProvisionerJob resource
 		j := dbgen.ProvisionerJob(s.T(), db, database.ProvisionerJob{})
@@ -288,6 +381,14 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 			Transition: database.WorkspaceTransitionStart,
 		}).Asserts(rbac.ResourceSystem, rbac.ActionCreate)
 	}))
+	s.Run("InsertProvisionerJobResourceChange", s.Subtest(func(db database.Store, check *expects) {
+		j := dbgen.ProvisionerJob(s.T(), db, database.ProvisionerJob{})
+		check.Args(database.InsertProvisionerJobResourceChangeParams{
+			JobID:   j.ID,
+			Address: "null_resource.example",
+			Action:  database.ResourceChangeActionCreate,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionCreate)
+	}))
 	s.Run("InsertParameterSchema", s.Subtest(func(db database.Store, check *expects) {
 		check.Args(database.InsertParameterSchemaParams{
 			ID:                       uuid.New(),
@@ -296,4 +397,125 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 			ValidationTypeSystem:     database.ParameterTypeSystemNone,
 		}).Asserts(rbac.ResourceSystem, rbac.ActionCreate)
 	}))
+	s.Run("IncrementRateLimitCounter", s.Subtest(func(db database.Store, check *expects) {
+		now := database.Now()
+		check.Args(database.IncrementRateLimitCounterParams{
+			Key:         "api:127.0.0.1",
+			WindowStart: now,
+			ExpiresAt:   now.Add(time.Minute),
+		}).Asserts(rbac.ResourceSystem, rbac.ActionUpdate).Returns(int64(1))
+	}))
+	s.Run("DeleteExpiredRateLimitCounters", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.Now()).Asserts(rbac.ResourceSystem, rbac.ActionDelete)
+	}))
+	s.Run("UpsertTailnetCoordinator", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.UpsertTailnetCoordinatorParams{
+			ID:          uuid.New(),
+			HeartbeatAt: database.Now(),
+		}).Asserts(rbac.ResourceSystem, rbac.ActionUpdate)
+	}))
+	s.Run("DeleteTailnetCoordinator", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(uuid.New()).Asserts(rbac.ResourceSystem, rbac.ActionDelete)
+	}))
+	s.Run("DeleteTailnetCoordinatorsHeartbeatBefore", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.Now()).Asserts(rbac.ResourceSystem, rbac.ActionDelete)
+	}))
+	s.Run("GetTailnetCoordinators", s.Subtest(func(db database.Store, check *expects) {
+		check.Args().Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
+	s.Run("UpsertTailnetAgent", s.Subtest(func(db database.Store, check *expects) {
+		coordinator, err := db.UpsertTailnetCoordinator(context.Background(), database.UpsertTailnetCoordinatorParams{ID: uuid.New()})
+		require.NoError(s.T(), err)
+		check.Args(database.UpsertTailnetAgentParams{
+			ID:            uuid.New(),
+			CoordinatorID: coordinator.ID,
+			Node:          json.RawMessage("{}"),
+		}).Asserts(rbac.ResourceSystem, rbac.ActionUpdate)
+	}))
+	s.Run("DeleteTailnetAgent", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.DeleteTailnetAgentParams{
+			ID:            uuid.New(),
+			CoordinatorID: uuid.New(),
+		}).Asserts(rbac.ResourceSystem, rbac.ActionDelete)
+	}))
+	s.Run("GetTailnetAgents", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(uuid.New()).Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
+	s.Run("GetAllTailnetAgents", s.Subtest(func(db database.Store, check *expects) {
+		check.Args().Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
+	s.Run("UpsertTailnetClient", s.Subtest(func(db database.Store, check *expects) {
+		coordinator, err := db.UpsertTailnetCoordinator(context.Background(), database.UpsertTailnetCoordinatorParams{ID: uuid.New()})
+		require.NoError(s.T(), err)
+		check.Args(database.UpsertTailnetClientParams{
+			ID:            uuid.New(),
+			CoordinatorID: coordinator.ID,
+			AgentID:       uuid.New(),
+			Node:          json.RawMessage("{}"),
+		}).Asserts(rbac.ResourceSystem, rbac.ActionUpdate)
+	}))
+	s.Run("DeleteTailnetClient", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.DeleteTailnetClientParams{
+			ID:            uuid.New(),
+			CoordinatorID: uuid.New(),
+		}).Asserts(rbac.ResourceSystem, rbac.ActionDelete)
+	}))
+	s.Run("GetTailnetClientsForAgent", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(uuid.New()).Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
+	s.Run("GetAllTailnetClients", s.Subtest(func(db database.Store, check *expects) {
+		check.Args().Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
+	s.Run("InsertWorkspaceBulkJob", s.Subtest(func(db database.Store, check *expects) {
+		u := dbgen.User(s.T(), db, database.User{})
+		check.Args(database.InsertWorkspaceBulkJobParams{
+			ID:          uuid.New(),
+			InitiatorID: u.ID,
+			Action:      database.WorkspaceBulkJobActionStop,
+			BatchSize:   10,
+			Status:      database.WorkspaceBulkJobStatusRunning,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionCreate)
+	}))
+	s.Run("GetWorkspaceBulkJobByID", s.Subtest(func(db database.Store, check *expects) {
+		job := dbgen.WorkspaceBulkJob(s.T(), db, database.WorkspaceBulkJob{})
+		check.Args(job.ID).Asserts(rbac.ResourceSystem, rbac.ActionRead).Returns(job)
+	}))
+	s.Run("GetStaleWorkspaceBulkJobs", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(time.Now()).Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
+	s.Run("UpdateWorkspaceBulkJobByID", s.Subtest(func(db database.Store, check *expects) {
+		job := dbgen.WorkspaceBulkJob(s.T(), db, database.WorkspaceBulkJob{})
+		check.Args(database.UpdateWorkspaceBulkJobByIDParams{
+			ID:     job.ID,
+			Status: database.WorkspaceBulkJobStatusSucceeded,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionUpdate)
+	}))
+	s.Run("InsertWorkspaceBulkJobWorkspace", s.Subtest(func(db database.Store, check *expects) {
+		job := dbgen.WorkspaceBulkJob(s.T(), db, database.WorkspaceBulkJob{})
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		check.Args(database.InsertWorkspaceBulkJobWorkspaceParams{
+			BulkJobID:   job.ID,
+			WorkspaceID: ws.ID,
+			Status:      database.WorkspaceBulkJobStatusPending,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionCreate)
+	}))
+	s.Run("GetWorkspaceBulkJobWorkspaces", s.Subtest(func(db database.Store, check *expects) {
+		job := dbgen.WorkspaceBulkJob(s.T(), db, database.WorkspaceBulkJob{})
+		check.Args(job.ID).Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
+	s.Run("UpdateWorkspaceBulkJobWorkspace", s.Subtest(func(db database.Store, check *expects) {
+		job := dbgen.WorkspaceBulkJob(s.T(), db, database.WorkspaceBulkJob{})
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		_, err := db.InsertWorkspaceBulkJobWorkspace(context.Background(), database.InsertWorkspaceBulkJobWorkspaceParams{
+			BulkJobID:   job.ID,
+			WorkspaceID: ws.ID,
+			Status:      database.WorkspaceBulkJobStatusPending,
+		})
+		require.NoError(s.T(), err)
+		check.Args(database.UpdateWorkspaceBulkJobWorkspaceParams{
+			BulkJobID:   job.ID,
+			WorkspaceID: ws.ID,
+			Status:      database.WorkspaceBulkJobStatusSkipped,
+		}).Asserts(rbac.ResourceSystem, rbac.ActionUpdate)
+	}))
 }
@@ -34,6 +34,11 @@ var errDuplicateKey = &pq.Error{
 	Message: "duplicate key value violates unique constraint",
 }
 
+var errForeignKeyConstraint = &pq.Error{
+	Code:    "23503",
+	Message: "insert or update on table violates foreign key constraint",
+}
+
 // New returns an in-memory fake of the database.
 func New() database.Store {
 	return &fakeQuerier{
@@ -105,32 +110,46 @@ type data struct {
 	userLinks           []database.UserLink
 
 	// New tables
-	workspaceAgentStats       []database.WorkspaceAgentStat
-	auditLogs                 []database.AuditLog
-	files                     []database.File
-	gitAuthLinks              []database.GitAuthLink
-	gitSSHKey                 []database.GitSSHKey
-	groupMembers              []database.GroupMember
-	groups                    []database.Group
-	licenses                  []database.License
-	parameterSchemas          []database.ParameterSchema
-	parameterValues           []database.ParameterValue
-	provisionerDaemons        []database.ProvisionerDaemon
-	provisionerJobLogs        []database.ProvisionerJobLog
-	provisionerJobs           []database.ProvisionerJob
-	replicas                  []database.Replica
-	templateVersions          []database.TemplateVersion
-	templateVersionParameters []database.TemplateVersionParameter
-	templateVersionVariables  []database.TemplateVersionVariable
-	templates                 []database.Template
-	workspaceAgents           []database.WorkspaceAgent
-	workspaceAgentLogs        []database.WorkspaceAgentStartupLog
-	workspaceApps             []database.WorkspaceApp
-	workspaceBuilds           []database.WorkspaceBuild
-	workspaceBuildParameters  []database.WorkspaceBuildParameter
-	workspaceResourceMetadata []database.WorkspaceResourceMetadatum
-	workspaceResources        []database.WorkspaceResource
-	workspaces                []database.Workspace
+	workspaceAgentStats           []database.WorkspaceAgentStat
+	auditLogs                     []database.AuditLog
+	files                         []database.File
+	gitAuthLinks                  []database.GitAuthLink
+	gitSSHKey                     []database.GitSSHKey
+	groupMembers                  []database.GroupMember
+	groups                        []database.Group
+	licenses                      []database.License
+	notificationWebhooks          []database.NotificationWebhook
+	notificationDeliveries        []database.NotificationWebhookDelivery
+	parameterSchemas              []database.ParameterSchema
+	parameterValues               []database.ParameterValue
+	provisionerDaemons            []database.ProvisionerDaemon
+	provisionerJobLogs            []database.ProvisionerJobLog
+	provisionerJobResourceChanges []database.ProvisionerJobResourceChange
+	provisionerJobs               []database.ProvisionerJob
+	rateLimitCounters             []database.RateLimitCounter
+	replicas                      []database.Replica
+	tailnetAgents                 []database.TailnetAgent
+	tailnetClients                []database.TailnetClient
+	tailnetCoordinators           []database.TailnetCoordinator
+	templateVersions              []database.TemplateVersion
+	templateVersionPromotions     []database.TemplateVersionPromotion
+	templateVersionParameters     []database.TemplateVersionParameter
+	templateVersionVariables      []database.TemplateVersionVariable
+	templates                     []database.Template
+	workspaceAgents               []database.WorkspaceAgent
+	workspaceAgentLogs            []database.WorkspaceAgentStartupLog
+	workspaceAgentMetadata        []database.WorkspaceAgentMetadatum
+	workspaceAgentPortShares      []database.WorkspaceAgentPortShare
+	workspaceAgentPortRules       []database.WorkspaceAgentPortShareRule
+	workspaceApps                 []database.WorkspaceApp
+	workspaceBuilds               []database.WorkspaceBuild
+	workspaceBuildParameters      []database.WorkspaceBuildParameter
+	workspaceBulkJobs             []database.WorkspaceBulkJob
+	workspaceBulkJobWorkspaces    []database.WorkspaceBulkJobWorkspace
+	workspaceResourceMetadata     []database.WorkspaceResourceMetadatum
+	workspaceResources            []database.WorkspaceResource
+	workspaceSessionRecordings    []database.WorkspaceSessionRecording
+	workspaces                    []database.Workspace
 
 	// Locks is a map of lock names. Any keys within the map are currently
 	// locked.
@@ -262,10 +281,7 @@ func (q *fakeQuerier) AcquireProvisionerJob(_ context.Context, arg database.Acqu
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
-	for index, provisionerJob := range q.provisionerJobs {
-		if provisionerJob.StartedAt.Valid {
-			continue
-		}
+	for _, provisionerJob := range q.getPendingProvisionerJobsNoLock() {
 		found := false
 		for _, provisionerType := range arg.Types {
 			if provisionerJob.Provisioner != provisionerType {
@@ -300,20 +316,181 @@ func (q *fakeQuerier) AcquireProvisionerJob(_ context.Context, arg database.Acqu
 		if missing {
 			continue
 		}
-		provisionerJob.StartedAt = arg.StartedAt
-		provisionerJob.UpdatedAt = arg.StartedAt.Time
-		provisionerJob.WorkerID = arg.WorkerID
-		q.provisionerJobs[index] = provisionerJob
-		return provisionerJob, nil
+		for index, job := range q.provisionerJobs {
+			if job.ID != provisionerJob.ID {
+				continue
+			}
+			provisionerJob.StartedAt = arg.StartedAt
+			provisionerJob.UpdatedAt = arg.StartedAt.Time
+			provisionerJob.WorkerID = arg.WorkerID
+			q.provisionerJobs[index] = provisionerJob
+			return provisionerJob, nil
+		}
 	}
 	return database.ProvisionerJob{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) BumpProvisionerJobByID(_ context.Context, arg database.BumpProvisionerJobByIDParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	pending := q.getPendingProvisionerJobsNoLock()
+	if len(pending) == 0 {
+		return nil
+	}
+	for index, job := range q.provisionerJobs {
+		if job.ID != arg.ID || job.StartedAt.Valid || job.CanceledAt.Valid {
+			continue
+		}
+		job.Priority = pending[0].Priority + 1
+		job.UpdatedAt = arg.UpdatedAt
+		q.provisionerJobs[index] = job
+	}
+	return nil
+}
+
 func (*fakeQuerier) DeleteOldWorkspaceAgentStats(_ context.Context) error {
 	// no-op
 	return nil
 }
 
+func (q *fakeQuerier) DeleteOldAuditLogs(_ context.Context, arg database.DeleteOldAuditLogsParams) (int64, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return 0, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	// Audit logs are kept sorted by time, so the oldest are first.
+	var deleted int64
+	logs := make([]database.AuditLog, 0, len(q.auditLogs))
+	for _, alog := range q.auditLogs {
+		if alog.Time.Before(arg.Before) && deleted < int64(arg.LimitCount) {
+			deleted++
+			continue
+		}
+		logs = append(logs, alog)
+	}
+	q.auditLogs = logs
+	return deleted, nil
+}
+
+func (q *fakeQuerier) GetOldAuditLogCount(_ context.Context, before time.Time) (int64, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var count int64
+	for _, alog := range q.auditLogs {
+		if alog.Time.Before(before) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *fakeQuerier) DeleteOldProvisionerJobLogs(_ context.Context, arg database.DeleteOldProvisionerJobLogsParams) (int64, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return 0, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	completed := q.getProvisionerJobsCompletedBeforeNoLock(arg.Before)
+	var deleted int64
+	logs := make([]database.ProvisionerJobLog, 0, len(q.provisionerJobLogs))
+	for _, jobLog := range q.provisionerJobLogs {
+		if _, ok := completed[jobLog.JobID]; ok && deleted < int64(arg.LimitCount) {
+			deleted++
+			continue
+		}
+		logs = append(logs, jobLog)
+	}
+	q.provisionerJobLogs = logs
+	return deleted, nil
+}
+
+func (q *fakeQuerier) GetOldProvisionerJobLogCount(_ context.Context, before time.Time) (int64, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	completed := q.getProvisionerJobsCompletedBeforeNoLock(before)
+	var count int64
+	for _, jobLog := range q.provisionerJobLogs {
+		if _, ok := completed[jobLog.JobID]; ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *fakeQuerier) getProvisionerJobsCompletedBeforeNoLock(before time.Time) map[uuid.UUID]struct{} {
+	completed := make(map[uuid.UUID]struct{})
+	for _, job := range q.provisionerJobs {
+		if job.CompletedAt.Valid && job.CompletedAt.Time.Before(before) {
+			completed[job.ID] = struct{}{}
+		}
+	}
+	return completed
+}
+
+func (q *fakeQuerier) DeleteOldWorkspaceBuildStates(_ context.Context, arg database.DeleteOldWorkspaceBuildStatesParams) (int64, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return 0, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var updated int64
+	for _, index := range q.getOldWorkspaceBuildStatesNoLock(arg.Before) {
+		if updated >= int64(arg.LimitCount) {
+			break
+		}
+		q.workspaceBuilds[index].ProvisionerState = nil
+		updated++
+	}
+	return updated, nil
+}
+
+func (q *fakeQuerier) GetOldWorkspaceBuildStateCount(_ context.Context, before time.Time) (int64, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	return int64(len(q.getOldWorkspaceBuildStatesNoLock(before))), nil
+}
+
+// getOldWorkspaceBuildStatesNoLock returns the indexes of superseded workspace
+// builds created before the given time that still have provisioner state,
+// ordered from oldest to newest.
+func (q *fakeQuerier) getOldWorkspaceBuildStatesNoLock(before time.Time) []int {
+	latest := make(map[uuid.UUID]int32)
+	for _, build := range q.workspaceBuilds {
+		if build.BuildNumber > latest[build.WorkspaceID] {
+			latest[build.WorkspaceID] = build.BuildNumber
+		}
+	}
+	indexes := make([]int, 0)
+	for i, build := range q.workspaceBuilds {
+		if !build.CreatedAt.Before(before) || build.ProvisionerState == nil {
+			continue
+		}
+		if build.BuildNumber >= latest[build.WorkspaceID] {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return q.workspaceBuilds[indexes[i]].CreatedAt.Before(q.workspaceBuilds[indexes[j]].CreatedAt)
+	})
+	return indexes
+}
+
 func (q *fakeQuerier) GetWorkspaceAgentsInLatestBuildByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]database.WorkspaceAgent, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1375,10 +1552,14 @@ func convertToWorkspaceRows(workspaces []database.Workspace, count int64) []data
 	return rows
 }
 
-func (q *fakeQuerier) GetWorkspaceByID(_ context.Context, id uuid.UUID) (database.Workspace, error) {
+func (q *fakeQuerier) GetWorkspaceByID(ctx context.Context, id uuid.UUID) (database.Workspace, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
 
+	return q.getWorkspaceByIDNoLock(ctx, id)
+}
+
+func (q *fakeQuerier) getWorkspaceByIDNoLock(_ context.Context, id uuid.UUID) (database.Workspace, error) {
 	for _, workspace := range q.workspaces {
 		if workspace.ID == id {
 			return workspace, nil
@@ -1750,6 +1931,10 @@ func (q *fakeQuerier) GetOrganizationByID(_ context.Context, id uuid.UUID) (data
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
 
+	return q.getOrganizationByIDNoLock(id)
+}
+
+func (q *fakeQuerier) getOrganizationByIDNoLock(id uuid.UUID) (database.Organization, error) {
 	for _, organization := range q.organizations {
 		if organization.ID == id {
 			return organization, nil
@@ -1882,6 +2067,7 @@ func (q *fakeQuerier) UpdateTemplateMetaByID(_ context.Context, arg database.Upd
 		tpl.DisplayName = arg.DisplayName
 		tpl.Description = arg.Description
 		tpl.Icon = arg.Icon
+		tpl.RequiredPromotionApprovals = arg.RequiredPromotionApprovals
 		q.templates[idx] = tpl
 		return tpl.DeepCopy(), nil
 	}
@@ -1904,6 +2090,9 @@ func (q *fakeQuerier) UpdateTemplateScheduleByID(_ context.Context, arg database
 		tpl.UpdatedAt = database.Now()
 		tpl.DefaultTTL = arg.DefaultTTL
 		tpl.MaxTTL = arg.MaxTTL
+		tpl.InactivityTTL = arg.InactivityTTL
+		tpl.DeleteTTL = arg.DeleteTTL
+		tpl.AutostopRequirement = arg.AutostopRequirement
 		q.templates[idx] = tpl
 		return tpl.DeepCopy(), nil
 	}
@@ -2525,6 +2714,60 @@ func (q *fakeQuerier) GetWorkspaceAgentsCreatedAfter(_ context.Context, after ti
 	return workspaceAgents, nil
 }
 
+func (q *fakeQuerier) GetWorkspaceAgentsInLatestBuilds(ctx context.Context) ([]database.GetWorkspaceAgentsInLatestBuildsRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := make([]database.GetWorkspaceAgentsInLatestBuildsRow, 0)
+	for _, workspace := range q.workspaces {
+		if workspace.Deleted {
+			continue
+		}
+		build, err := q.getLatestWorkspaceBuildByWorkspaceIDNoLock(ctx, workspace.ID)
+		if err != nil {
+			continue
+		}
+		owner, err := q.getUserByIDNoLock(workspace.OwnerID)
+		if err != nil {
+			continue
+		}
+		template, err := q.getTemplateByIDNoLock(ctx, workspace.TemplateID)
+		if err != nil {
+			continue
+		}
+		organization, err := q.getOrganizationByIDNoLock(workspace.OrganizationID)
+		if err != nil {
+			continue
+		}
+
+		resourceIDs := make(map[uuid.UUID]struct{})
+		for _, resource := range q.workspaceResources {
+			if resource.JobID == build.JobID {
+				resourceIDs[resource.ID] = struct{}{}
+			}
+		}
+		for _, agent := range q.workspaceAgents {
+			if _, ok := resourceIDs[agent.ResourceID]; !ok {
+				continue
+			}
+			rows = append(rows, database.GetWorkspaceAgentsInLatestBuildsRow{
+				ID:                       agent.ID,
+				Name:                     agent.Name,
+				CreatedAt:                agent.CreatedAt,
+				FirstConnectedAt:         agent.FirstConnectedAt,
+				LastConnectedAt:          agent.LastConnectedAt,
+				DisconnectedAt:           agent.DisconnectedAt,
+				ConnectionTimeoutSeconds: agent.ConnectionTimeoutSeconds,
+				WorkspaceName:            workspace.Name,
+				Username:                 owner.Username,
+				TemplateName:             template.Name,
+				OrganizationName:         organization.Name,
+			})
+		}
+	}
+	return rows, nil
+}
+
 func (q *fakeQuerier) GetWorkspaceAppByAgentIDAndSlug(_ context.Context, arg database.GetWorkspaceAppByAgentIDAndSlugParams) (database.WorkspaceApp, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return database.WorkspaceApp{}, err
@@ -2656,6 +2899,23 @@ func (q *fakeQuerier) GetWorkspaceResourceMetadataByResourceIDs(_ context.Contex
 	return metadata, nil
 }
 
+func (q *fakeQuerier) GetProvisionerJobResourceChangesByJobID(_ context.Context, jobID uuid.UUID) ([]database.ProvisionerJobResourceChange, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	changes := make([]database.ProvisionerJobResourceChange, 0)
+	for _, change := range q.provisionerJobResourceChanges {
+		if change.JobID != jobID {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	slices.SortFunc(changes, func(a, b database.ProvisionerJobResourceChange) bool {
+		return a.Address < b.Address
+	})
+	return changes, nil
+}
+
 func (q *fakeQuerier) GetProvisionerJobsByIDs(_ context.Context, ids []uuid.UUID) ([]database.ProvisionerJob, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -2676,6 +2936,70 @@ func (q *fakeQuerier) GetProvisionerJobsByIDs(_ context.Context, ids []uuid.UUID
 	return jobs, nil
 }
 
+func (q *fakeQuerier) GetPendingProvisionerJobs(_ context.Context) ([]database.ProvisionerJob, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	return q.getPendingProvisionerJobsNoLock(), nil
+}
+
+// getPendingProvisionerJobsNoLock returns the jobs waiting for a provisioner
+// daemon in the order they will be acquired.
+func (q *fakeQuerier) getPendingProvisionerJobsNoLock() []database.ProvisionerJob {
+	jobs := make([]database.ProvisionerJob, 0)
+	for _, job := range q.provisionerJobs {
+		if job.StartedAt.Valid || job.CanceledAt.Valid {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].Priority != jobs[j].Priority {
+			return jobs[i].Priority > jobs[j].Priority
+		}
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+func (q *fakeQuerier) GetPendingProvisionerJobStats(_ context.Context) ([]database.GetPendingProvisionerJobStatsRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := make([]database.GetPendingProvisionerJobStatsRow, 0)
+	for _, job := range q.provisionerJobs {
+		if job.StartedAt.Valid || job.CanceledAt.Valid {
+			continue
+		}
+		organization, err := q.getOrganizationByIDNoLock(job.OrganizationID)
+		if err != nil {
+			continue
+		}
+		found := false
+		for i, row := range rows {
+			if row.Provisioner != job.Provisioner || row.OrganizationName != organization.Name || !maps.Equal(row.Tags, job.Tags) {
+				continue
+			}
+			rows[i].Count++
+			if job.CreatedAt.Before(row.OldestCreatedAt) {
+				rows[i].OldestCreatedAt = job.CreatedAt
+			}
+			found = true
+			break
+		}
+		if !found {
+			rows = append(rows, database.GetPendingProvisionerJobStatsRow{
+				Provisioner:      job.Provisioner,
+				Tags:             job.Tags,
+				OrganizationName: organization.Name,
+				Count:            1,
+				OldestCreatedAt:  job.CreatedAt,
+			})
+		}
+	}
+	return rows, nil
+}
+
 func (q *fakeQuerier) GetProvisionerJobsCreatedAfter(_ context.Context, after time.Time) ([]database.ProvisionerJob, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -2959,6 +3283,34 @@ func (q *fakeQuerier) InsertProvisionerJobLogs(_ context.Context, arg database.I
 	return logs, nil
 }
 
+func (q *fakeQuerier) InsertProvisionerJobResourceChange(_ context.Context, arg database.InsertProvisionerJobResourceChangeParams) (database.ProvisionerJobResourceChange, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.ProvisionerJobResourceChange{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, change := range q.provisionerJobResourceChanges {
+		if change.JobID == arg.JobID && change.Address == arg.Address {
+			return database.ProvisionerJobResourceChange{}, errDuplicateKey
+		}
+	}
+
+	//nolint:gosimple
+	change := database.ProvisionerJobResourceChange{
+		JobID:          arg.JobID,
+		Address:        arg.Address,
+		Type:           arg.Type,
+		Name:           arg.Name,
+		Action:         arg.Action,
+		DailyCost:      arg.DailyCost,
+		PriorDailyCost: arg.PriorDailyCost,
+	}
+	q.provisionerJobResourceChanges = append(q.provisionerJobResourceChanges, change)
+	return change, nil
+}
+
 func (q *fakeQuerier) InsertParameterSchema(_ context.Context, arg database.InsertParameterSchemaParams) (database.ParameterSchema, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return database.ParameterSchema{}, err
@@ -3010,6 +3362,48 @@ func (q *fakeQuerier) InsertProvisionerDaemon(_ context.Context, arg database.In
 	return daemon, nil
 }
 
+func (q *fakeQuerier) UpdateProvisionerDaemonHeartbeat(_ context.Context, arg database.UpdateProvisionerDaemonHeartbeatParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, daemon := range q.provisionerDaemons {
+		if daemon.ID != arg.ID {
+			continue
+		}
+		daemon.LastSeenAt = arg.LastSeenAt
+		daemon.Version = arg.Version
+		daemon.CurrentJobID = arg.CurrentJobID
+		q.provisionerDaemons[i] = daemon
+		return nil
+	}
+	return nil
+}
+
+func (q *fakeQuerier) DeleteStaleProvisionerDaemons(_ context.Context, staleBefore time.Time) (int64, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var deleted int64
+	daemons := make([]database.ProvisionerDaemon, 0, len(q.provisionerDaemons))
+	for _, daemon := range q.provisionerDaemons {
+		lastSeen := daemon.CreatedAt
+		if daemon.LastSeenAt.Valid {
+			lastSeen = daemon.LastSeenAt.Time
+		}
+		if lastSeen.Before(staleBefore) {
+			deleted++
+			continue
+		}
+		daemons = append(daemons, daemon)
+	}
+	q.provisionerDaemons = daemons
+	return deleted, nil
+}
+
 func (q *fakeQuerier) InsertProvisionerJob(_ context.Context, arg database.InsertProvisionerJobParams) (database.ProvisionerJob, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return database.ProvisionerJob{}, err
@@ -3030,6 +3424,7 @@ func (q *fakeQuerier) InsertProvisionerJob(_ context.Context, arg database.Inser
 		Type:           arg.Type,
 		Input:          arg.Input,
 		Tags:           arg.Tags,
+		Priority:       arg.Priority,
 	}
 	q.provisionerJobs = append(q.provisionerJobs, job)
 	return job, nil
@@ -3044,25 +3439,27 @@ func (q *fakeQuerier) InsertWorkspaceAgent(_ context.Context, arg database.Inser
 	defer q.mutex.Unlock()
 
 	agent := database.WorkspaceAgent{
-		ID:                       arg.ID,
-		CreatedAt:                arg.CreatedAt,
-		UpdatedAt:                arg.UpdatedAt,
-		ResourceID:               arg.ResourceID,
-		AuthToken:                arg.AuthToken,
-		AuthInstanceID:           arg.AuthInstanceID,
-		EnvironmentVariables:     arg.EnvironmentVariables,
-		Name:                     arg.Name,
-		Architecture:             arg.Architecture,
-		OperatingSystem:          arg.OperatingSystem,
-		Directory:                arg.Directory,
-		StartupScript:            arg.StartupScript,
-		InstanceMetadata:         arg.InstanceMetadata,
-		ResourceMetadata:         arg.ResourceMetadata,
-		ConnectionTimeoutSeconds: arg.ConnectionTimeoutSeconds,
-		TroubleshootingURL:       arg.TroubleshootingURL,
-		MOTDFile:                 arg.MOTDFile,
-		LifecycleState:           database.WorkspaceAgentLifecycleStateCreated,
-		ShutdownScript:           arg.ShutdownScript,
+		ID:                        arg.ID,
+		CreatedAt:                 arg.CreatedAt,
+		UpdatedAt:                 arg.UpdatedAt,
+		ResourceID:                arg.ResourceID,
+		AuthToken:                 arg.AuthToken,
+		AuthInstanceID:            arg.AuthInstanceID,
+		EnvironmentVariables:      arg.EnvironmentVariables,
+		Name:                      arg.Name,
+		Architecture:              arg.Architecture,
+		OperatingSystem:           arg.OperatingSystem,
+		Directory:                 arg.Directory,
+		StartupScript:             arg.StartupScript,
+		InstanceMetadata:          arg.InstanceMetadata,
+		ResourceMetadata:          arg.ResourceMetadata,
+		ConnectionTimeoutSeconds:  arg.ConnectionTimeoutSeconds,
+		TroubleshootingURL:        arg.TroubleshootingURL,
+		MOTDFile:                  arg.MOTDFile,
+		LifecycleState:            database.WorkspaceAgentLifecycleStateCreated,
+		ShutdownScript:            arg.ShutdownScript,
+		ReconnectingPTYBufferSize: arg.ReconnectingPTYBufferSize,
+		SessionRecording:          arg.SessionRecording,
 	}
 
 	q.workspaceAgents = append(q.workspaceAgents, agent)
@@ -3869,6 +4266,10 @@ func (q *fakeQuerier) GetWorkspaceAgentStats(_ context.Context, createdAfter tim
 	statByAgent := map[uuid.UUID]database.GetWorkspaceAgentStatsRow{}
 	for _, agentStat := range latestAgentStats {
 		stat := statByAgent[agentStat.AgentID]
+		stat.AgentID = agentStat.AgentID
+		stat.UserID = agentStat.UserID
+		stat.WorkspaceID = agentStat.WorkspaceID
+		stat.TemplateID = agentStat.TemplateID
 		stat.SessionCountVSCode += agentStat.SessionCountVSCode
 		stat.SessionCountJetBrains += agentStat.SessionCountJetBrains
 		stat.SessionCountReconnectingPTY += agentStat.SessionCountReconnectingPTY
@@ -4461,45 +4862,258 @@ func (q *fakeQuerier) DeleteLicense(_ context.Context, id int32) (int32, error)
 	return 0, sql.ErrNoRows
 }
 
-func (*fakeQuerier) DeleteOldWorkspaceAgentStartupLogs(_ context.Context) error {
-	// noop
-	return nil
+func (q *fakeQuerier) GetNotificationWebhooks(_ context.Context) ([]database.NotificationWebhook, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	webhooks := append([]database.NotificationWebhook{}, q.notificationWebhooks...)
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].Name < webhooks[j].Name })
+	return webhooks, nil
 }
 
-func (q *fakeQuerier) GetUserLinkByLinkedID(_ context.Context, id string) (database.UserLink, error) {
+func (q *fakeQuerier) GetNotificationWebhookByID(_ context.Context, id uuid.UUID) (database.NotificationWebhook, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
 
-	for _, link := range q.userLinks {
-		if link.LinkedID == id {
-			return link, nil
+	for _, webhook := range q.notificationWebhooks {
+		if webhook.ID == id {
+			return webhook, nil
 		}
 	}
-	return database.UserLink{}, sql.ErrNoRows
+	return database.NotificationWebhook{}, sql.ErrNoRows
 }
 
-func (q *fakeQuerier) GetUserLinkByUserIDLoginType(_ context.Context, params database.GetUserLinkByUserIDLoginTypeParams) (database.UserLink, error) {
-	if err := validateDatabaseType(params); err != nil {
-		return database.UserLink{}, err
-	}
-
+func (q *fakeQuerier) GetEnabledNotificationWebhooksByEventType(_ context.Context, eventType database.NotificationEventType) ([]database.NotificationWebhook, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
 
-	for _, link := range q.userLinks {
-		if link.UserID == params.UserID && link.LoginType == params.LoginType {
-			return link, nil
+	var webhooks []database.NotificationWebhook
+	for _, webhook := range q.notificationWebhooks {
+		if !webhook.Enabled {
+			continue
+		}
+		for _, event := range webhook.Events {
+			if event == eventType {
+				webhooks = append(webhooks, webhook)
+				break
+			}
 		}
 	}
-	return database.UserLink{}, sql.ErrNoRows
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].Name < webhooks[j].Name })
+	return webhooks, nil
 }
 
-func (q *fakeQuerier) InsertUserLink(_ context.Context, args database.InsertUserLinkParams) (database.UserLink, error) {
-	q.mutex.RLock()
-	defer q.mutex.RUnlock()
+func (q *fakeQuerier) InsertNotificationWebhook(_ context.Context, arg database.InsertNotificationWebhookParams) (database.NotificationWebhook, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.NotificationWebhook{}, err
+	}
 
-	//nolint:gosimple
-	link := database.UserLink{
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, webhook := range q.notificationWebhooks {
+		if webhook.Name == arg.Name {
+			return database.NotificationWebhook{}, errDuplicateKey
+		}
+	}
+
+	//nolint:gosimple
+	webhook := database.NotificationWebhook{
+		ID:              arg.ID,
+		CreatedAt:       arg.CreatedAt,
+		UpdatedAt:       arg.UpdatedAt,
+		Name:            arg.Name,
+		Url:             arg.Url,
+		Events:          arg.Events,
+		PayloadTemplate: arg.PayloadTemplate,
+		Secret:          arg.Secret,
+		Enabled:         arg.Enabled,
+	}
+	q.notificationWebhooks = append(q.notificationWebhooks, webhook)
+	return webhook, nil
+}
+
+func (q *fakeQuerier) UpdateNotificationWebhookByID(_ context.Context, arg database.UpdateNotificationWebhookByIDParams) (database.NotificationWebhook, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.NotificationWebhook{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, webhook := range q.notificationWebhooks {
+		if webhook.ID != arg.ID && webhook.Name == arg.Name {
+			return database.NotificationWebhook{}, errDuplicateKey
+		}
+	}
+	for i, webhook := range q.notificationWebhooks {
+		if webhook.ID != arg.ID {
+			continue
+		}
+		webhook.UpdatedAt = arg.UpdatedAt
+		webhook.Name = arg.Name
+		webhook.Url = arg.Url
+		webhook.Events = arg.Events
+		webhook.PayloadTemplate = arg.PayloadTemplate
+		webhook.Secret = arg.Secret
+		webhook.Enabled = arg.Enabled
+		q.notificationWebhooks[i] = webhook
+		return webhook, nil
+	}
+	return database.NotificationWebhook{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteNotificationWebhookByID(_ context.Context, id uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, webhook := range q.notificationWebhooks {
+		if webhook.ID != id {
+			continue
+		}
+		q.notificationWebhooks = append(q.notificationWebhooks[:i], q.notificationWebhooks[i+1:]...)
+
+		deliveries := q.notificationDeliveries[:0]
+		for _, delivery := range q.notificationDeliveries {
+			if delivery.WebhookID != id {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		q.notificationDeliveries = deliveries
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertNotificationWebhookDelivery(_ context.Context, arg database.InsertNotificationWebhookDeliveryParams) (database.NotificationWebhookDelivery, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.NotificationWebhookDelivery{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, delivery := range q.notificationDeliveries {
+		if delivery.WebhookID == arg.WebhookID && delivery.EventID == arg.EventID && delivery.Attempt == arg.Attempt {
+			// ON CONFLICT DO NOTHING returns no rows.
+			return database.NotificationWebhookDelivery{}, sql.ErrNoRows
+		}
+	}
+
+	delivery := database.NotificationWebhookDelivery{
+		ID:          arg.ID,
+		WebhookID:   arg.WebhookID,
+		EventID:     arg.EventID,
+		EventType:   arg.EventType,
+		Attempt:     arg.Attempt,
+		CreatedAt:   arg.CreatedAt,
+		RequestBody: arg.RequestBody,
+	}
+	q.notificationDeliveries = append(q.notificationDeliveries, delivery)
+	return delivery, nil
+}
+
+func (q *fakeQuerier) UpdateNotificationWebhookDeliveryByID(_ context.Context, arg database.UpdateNotificationWebhookDeliveryByIDParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, delivery := range q.notificationDeliveries {
+		if delivery.ID != arg.ID {
+			continue
+		}
+		delivery.CompletedAt = arg.CompletedAt
+		delivery.StatusCode = arg.StatusCode
+		delivery.Error = arg.Error
+		q.notificationDeliveries[i] = delivery
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetNotificationWebhookDeliveries(_ context.Context, arg database.GetNotificationWebhookDeliveriesParams) ([]database.NotificationWebhookDelivery, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var deliveries []database.NotificationWebhookDelivery
+	for _, delivery := range q.notificationDeliveries {
+		if delivery.WebhookID == arg.WebhookID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].Attempt > deliveries[j].Attempt
+		}
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if arg.LimitOpt > 0 && len(deliveries) > int(arg.LimitOpt) {
+		deliveries = deliveries[:arg.LimitOpt]
+	}
+	return deliveries, nil
+}
+
+func (q *fakeQuerier) DeleteOldNotificationWebhookDeliveries(_ context.Context) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	before := database.Now().Add(-30 * 24 * time.Hour)
+	deliveries := q.notificationDeliveries[:0]
+	for _, delivery := range q.notificationDeliveries {
+		if !delivery.CreatedAt.Before(before) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	q.notificationDeliveries = deliveries
+	return nil
+}
+
+func (*fakeQuerier) DeleteOldWorkspaceAgentStartupLogs(_ context.Context) error {
+	// noop
+	return nil
+}
+
+func (q *fakeQuerier) GetUserLinkByLinkedID(_ context.Context, id string) (database.UserLink, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, link := range q.userLinks {
+		if link.LinkedID == id {
+			return link, nil
+		}
+	}
+	return database.UserLink{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetUserLinkByUserIDLoginType(_ context.Context, params database.GetUserLinkByUserIDLoginTypeParams) (database.UserLink, error) {
+	if err := validateDatabaseType(params); err != nil {
+		return database.UserLink{}, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, link := range q.userLinks {
+		if link.UserID == params.UserID && link.LoginType == params.LoginType {
+			return link, nil
+		}
+	}
+	return database.UserLink{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertUserLink(_ context.Context, args database.InsertUserLinkParams) (database.UserLink, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	//nolint:gosimple
+	link := database.UserLink{
 		UserID:            args.UserID,
 		LoginType:         args.LoginType,
 		LinkedID:          args.LinkedID,
@@ -4663,6 +5277,30 @@ func (q *fakeQuerier) GetGroupsByOrganizationID(_ context.Context, organizationI
 	return groups, nil
 }
 
+func (q *fakeQuerier) GetGroupsByOrganizationAndUserID(_ context.Context, arg database.GetGroupsByOrganizationAndUserIDParams) ([]database.Group, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var groupIDs []uuid.UUID
+	for _, member := range q.groupMembers {
+		if member.UserID == arg.UserID {
+			groupIDs = append(groupIDs, member.GroupID)
+		}
+	}
+	var groups []database.Group
+	for _, group := range q.groups {
+		if group.OrganizationID == arg.OrganizationID && slices.Contains(groupIDs, group.ID) {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups, nil
+}
+
 func (q *fakeQuerier) DeleteGroupByID(_ context.Context, id uuid.UUID) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -4877,6 +5515,61 @@ func (q *fakeQuerier) UpdateWorkspaceAgentLifecycleStateByID(_ context.Context,
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) InsertWorkspaceAgentMetadata(_ context.Context, arg database.InsertWorkspaceAgentMetadataParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	metadatum := database.WorkspaceAgentMetadatum{
+		WorkspaceAgentID: arg.WorkspaceAgentID,
+		Script:           arg.Script,
+		DisplayName:      arg.DisplayName,
+		Key:              arg.Key,
+		Timeout:          arg.Timeout,
+		Interval:         arg.Interval,
+	}
+
+	q.workspaceAgentMetadata = append(q.workspaceAgentMetadata, metadatum)
+	return nil
+}
+
+func (q *fakeQuerier) UpdateWorkspaceAgentMetadata(_ context.Context, arg database.UpdateWorkspaceAgentMetadataParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, m := range q.workspaceAgentMetadata {
+		if m.WorkspaceAgentID == arg.WorkspaceAgentID && m.Key == arg.Key {
+			m.Value = arg.Value
+			m.Error = arg.Error
+			m.CollectedAt = arg.CollectedAt
+			q.workspaceAgentMetadata[i] = m
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentMetadata(_ context.Context, workspaceAgentID uuid.UUID) ([]database.WorkspaceAgentMetadatum, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	metadata := make([]database.WorkspaceAgentMetadatum, 0)
+	for _, m := range q.workspaceAgentMetadata {
+		if m.WorkspaceAgentID == workspaceAgentID {
+			metadata = append(metadata, m)
+		}
+	}
+	return metadata, nil
+}
+
 func (q *fakeQuerier) UpdateWorkspaceAgentStartupLogOverflowByID(_ context.Context, arg database.UpdateWorkspaceAgentStartupLogOverflowByIDParams) error {
 	if err := validateDatabaseType(arg); err != nil {
 		return err
@@ -4893,3 +5586,765 @@ func (q *fakeQuerier) UpdateWorkspaceAgentStartupLogOverflowByID(_ context.Conte
 	}
 	return sql.ErrNoRows
 }
+
+func (q *fakeQuerier) IncrementRateLimitCounter(_ context.Context, arg database.IncrementRateLimitCounterParams) (int64, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return 0, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, counter := range q.rateLimitCounters {
+		if counter.Key != arg.Key {
+			continue
+		}
+		if counter.WindowStart.Before(arg.WindowStart) {
+			counter.WindowStart = arg.WindowStart
+			counter.Count = 0
+		}
+		if counter.ExpiresAt.Before(arg.ExpiresAt) {
+			counter.ExpiresAt = arg.ExpiresAt
+		}
+		counter.Count++
+		q.rateLimitCounters[i] = counter
+		return counter.Count, nil
+	}
+	q.rateLimitCounters = append(q.rateLimitCounters, database.RateLimitCounter{
+		Key:         arg.Key,
+		WindowStart: arg.WindowStart,
+		ExpiresAt:   arg.ExpiresAt,
+		Count:       1,
+	})
+	return 1, nil
+}
+
+func (q *fakeQuerier) DeleteExpiredRateLimitCounters(_ context.Context, now time.Time) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	counters := q.rateLimitCounters[:0]
+	for _, counter := range q.rateLimitCounters {
+		if !counter.ExpiresAt.Before(now) {
+			counters = append(counters, counter)
+		}
+	}
+	q.rateLimitCounters = counters
+	return nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceSessionRecording(_ context.Context, arg database.InsertWorkspaceSessionRecordingParams) (database.WorkspaceSessionRecording, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.WorkspaceSessionRecording{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple
+	recording := database.WorkspaceSessionRecording{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		WorkspaceID: arg.WorkspaceID,
+		AgentID:     arg.AgentID,
+		SessionType: arg.SessionType,
+		StartedAt:   arg.StartedAt,
+		EndedAt:     arg.EndedAt,
+		Data:        arg.Data,
+	}
+	q.workspaceSessionRecordings = append(q.workspaceSessionRecordings, recording)
+	return recording, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceSessionRecordingByID(_ context.Context, id uuid.UUID) (database.WorkspaceSessionRecording, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, recording := range q.workspaceSessionRecordings {
+		if recording.ID == id {
+			return recording, nil
+		}
+	}
+	return database.WorkspaceSessionRecording{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceSessionRecordingsByWorkspaceID(_ context.Context, workspaceID uuid.UUID) ([]database.GetWorkspaceSessionRecordingsByWorkspaceIDRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	recordings := make([]database.GetWorkspaceSessionRecordingsByWorkspaceIDRow, 0)
+	for _, recording := range q.workspaceSessionRecordings {
+		if recording.WorkspaceID != workspaceID {
+			continue
+		}
+		recordings = append(recordings, database.GetWorkspaceSessionRecordingsByWorkspaceIDRow{
+			ID:          recording.ID,
+			CreatedAt:   recording.CreatedAt,
+			WorkspaceID: recording.WorkspaceID,
+			AgentID:     recording.AgentID,
+			SessionType: recording.SessionType,
+			StartedAt:   recording.StartedAt,
+			EndedAt:     recording.EndedAt,
+			Size:        int64(len(recording.Data)),
+		})
+	}
+	slices.SortFunc(recordings, func(a, b database.GetWorkspaceSessionRecordingsByWorkspaceIDRow) bool {
+		return a.StartedAt.After(b.StartedAt)
+	})
+	return recordings, nil
+}
+
+func (q *fakeQuerier) InsertTemplateVersionPromotion(_ context.Context, arg database.InsertTemplateVersionPromotionParams) (database.TemplateVersionPromotion, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, promotion := range q.templateVersionPromotions {
+		if promotion.TemplateVersionID == arg.TemplateVersionID && promotion.Status == database.TemplateVersionPromotionStatusPending {
+			return database.TemplateVersionPromotion{}, errDuplicateKey
+		}
+	}
+
+	promotion := database.TemplateVersionPromotion{
+		ID:                arg.ID,
+		CreatedAt:         arg.CreatedAt,
+		UpdatedAt:         arg.UpdatedAt,
+		TemplateID:        arg.TemplateID,
+		TemplateVersionID: arg.TemplateVersionID,
+		RequesterID:       arg.RequesterID,
+		DryRunJobID:       arg.DryRunJobID,
+		ApproverIDs:       []uuid.UUID{},
+		Status:            arg.Status,
+	}
+	q.templateVersionPromotions = append(q.templateVersionPromotions, promotion)
+	return promotion, nil
+}
+
+func (q *fakeQuerier) GetTemplateVersionPromotionByID(_ context.Context, id uuid.UUID) (database.TemplateVersionPromotion, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, promotion := range q.templateVersionPromotions {
+		if promotion.ID == id {
+			return promotion, nil
+		}
+	}
+	return database.TemplateVersionPromotion{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetPendingTemplateVersionPromotionByTemplateVersionID(_ context.Context, templateVersionID uuid.UUID) (database.TemplateVersionPromotion, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, promotion := range q.templateVersionPromotions {
+		if promotion.TemplateVersionID == templateVersionID && promotion.Status == database.TemplateVersionPromotionStatusPending {
+			return promotion, nil
+		}
+	}
+	return database.TemplateVersionPromotion{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetTemplateVersionPromotionsByTemplateID(_ context.Context, templateID uuid.UUID) ([]database.TemplateVersionPromotion, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	promotions := make([]database.TemplateVersionPromotion, 0)
+	for _, promotion := range q.templateVersionPromotions {
+		if promotion.TemplateID == templateID {
+			promotions = append(promotions, promotion)
+		}
+	}
+	slices.SortFunc(promotions, func(a, b database.TemplateVersionPromotion) bool {
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+	return promotions, nil
+}
+
+func (q *fakeQuerier) UpdateTemplateVersionPromotionByID(_ context.Context, arg database.UpdateTemplateVersionPromotionByIDParams) (database.TemplateVersionPromotion, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, promotion := range q.templateVersionPromotions {
+		if promotion.ID != arg.ID {
+			continue
+		}
+		promotion.UpdatedAt = arg.UpdatedAt
+		promotion.DryRunJobID = arg.DryRunJobID
+		promotion.Status = arg.Status
+		promotion.PromotedAt = arg.PromotedAt
+		q.templateVersionPromotions[i] = promotion
+		return promotion, nil
+	}
+	return database.TemplateVersionPromotion{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) PromoteTemplateVersionPromotion(_ context.Context, arg database.PromoteTemplateVersionPromotionParams) (database.TemplateVersionPromotion, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, promotion := range q.templateVersionPromotions {
+		if promotion.ID != arg.ID || promotion.Status != database.TemplateVersionPromotionStatusPending {
+			continue
+		}
+		promotion.Status = database.TemplateVersionPromotionStatusPromoted
+		promotion.UpdatedAt = arg.UpdatedAt
+		promotion.PromotedAt = arg.PromotedAt
+		q.templateVersionPromotions[i] = promotion
+		return promotion, nil
+	}
+	return database.TemplateVersionPromotion{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertTemplateVersionPromotionApproval(_ context.Context, arg database.InsertTemplateVersionPromotionApprovalParams) (database.TemplateVersionPromotion, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, promotion := range q.templateVersionPromotions {
+		if promotion.ID != arg.ID {
+			continue
+		}
+		promotion.UpdatedAt = arg.UpdatedAt
+		if !slices.Contains(promotion.ApproverIDs, arg.ApproverID) {
+			// Copy so promotions returned earlier aren't changed.
+			promotion.ApproverIDs = append(slices.Clone(promotion.ApproverIDs), arg.ApproverID)
+		}
+		q.templateVersionPromotions[i] = promotion
+		return promotion, nil
+	}
+	return database.TemplateVersionPromotion{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) SupersedeTemplateVersionPromotions(_ context.Context, arg database.SupersedeTemplateVersionPromotionsParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, promotion := range q.templateVersionPromotions {
+		if promotion.TemplateID != arg.TemplateID ||
+			promotion.Status != database.TemplateVersionPromotionStatusPending ||
+			promotion.ID == arg.PromotionID {
+			continue
+		}
+		promotion.Status = database.TemplateVersionPromotionStatusSuperseded
+		promotion.UpdatedAt = arg.UpdatedAt
+		q.templateVersionPromotions[i] = promotion
+	}
+	return nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceBulkJob(_ context.Context, arg database.InsertWorkspaceBulkJobParams) (database.WorkspaceBulkJob, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.WorkspaceBulkJob{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	job := database.WorkspaceBulkJob{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		InitiatorID: arg.InitiatorID,
+		Action:      arg.Action,
+		Filter:      arg.Filter,
+		BatchSize:   arg.BatchSize,
+		Status:      arg.Status,
+	}
+	q.workspaceBulkJobs = append(q.workspaceBulkJobs, job)
+	return job, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceBulkJobByID(_ context.Context, id uuid.UUID) (database.WorkspaceBulkJob, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, job := range q.workspaceBulkJobs {
+		if job.ID == id {
+			return job, nil
+		}
+	}
+	return database.WorkspaceBulkJob{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetStaleWorkspaceBulkJobs(_ context.Context, updatedAt time.Time) ([]database.WorkspaceBulkJob, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	jobs := make([]database.WorkspaceBulkJob, 0)
+	for _, job := range q.workspaceBulkJobs {
+		if !job.CompletedAt.Valid && job.UpdatedAt.Before(updatedAt) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (q *fakeQuerier) UpdateWorkspaceBulkJobByID(_ context.Context, arg database.UpdateWorkspaceBulkJobByIDParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, job := range q.workspaceBulkJobs {
+		if job.ID != arg.ID {
+			continue
+		}
+		job.UpdatedAt = arg.UpdatedAt
+		job.CompletedAt = arg.CompletedAt
+		job.Status = arg.Status
+		job.Error = arg.Error
+		q.workspaceBulkJobs[i] = job
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertWorkspaceBulkJobWorkspace(_ context.Context, arg database.InsertWorkspaceBulkJobWorkspaceParams) (database.WorkspaceBulkJobWorkspace, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.WorkspaceBulkJobWorkspace{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, workspace := range q.workspaceBulkJobWorkspaces {
+		if workspace.BulkJobID == arg.BulkJobID && workspace.WorkspaceID == arg.WorkspaceID {
+			return database.WorkspaceBulkJobWorkspace{}, errDuplicateKey
+		}
+	}
+	workspace := database.WorkspaceBulkJobWorkspace{
+		BulkJobID:   arg.BulkJobID,
+		WorkspaceID: arg.WorkspaceID,
+		UpdatedAt:   arg.UpdatedAt,
+		Status:      arg.Status,
+	}
+	q.workspaceBulkJobWorkspaces = append(q.workspaceBulkJobWorkspaces, workspace)
+	return workspace, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceBulkJobWorkspaces(_ context.Context, bulkJobID uuid.UUID) ([]database.GetWorkspaceBulkJobWorkspacesRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := make([]database.GetWorkspaceBulkJobWorkspacesRow, 0)
+	for _, bulkWorkspace := range q.workspaceBulkJobWorkspaces {
+		if bulkWorkspace.BulkJobID != bulkJobID {
+			continue
+		}
+		row := database.GetWorkspaceBulkJobWorkspacesRow{
+			BulkJobID:        bulkWorkspace.BulkJobID,
+			WorkspaceID:      bulkWorkspace.WorkspaceID,
+			WorkspaceBuildID: bulkWorkspace.WorkspaceBuildID,
+			UpdatedAt:        bulkWorkspace.UpdatedAt,
+			Status:           bulkWorkspace.Status,
+			Error:            bulkWorkspace.Error,
+		}
+		workspace, err := q.getWorkspaceByIDNoLock(context.Background(), bulkWorkspace.WorkspaceID)
+		if err != nil {
+			continue
+		}
+		row.WorkspaceName = workspace.Name
+		owner, err := q.getUserByIDNoLock(workspace.OwnerID)
+		if err != nil {
+			continue
+		}
+		row.OwnerUsername = owner.Username
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b database.GetWorkspaceBulkJobWorkspacesRow) bool {
+		if a.OwnerUsername != b.OwnerUsername {
+			return a.OwnerUsername < b.OwnerUsername
+		}
+		return a.WorkspaceName < b.WorkspaceName
+	})
+	return rows, nil
+}
+
+func (q *fakeQuerier) UpdateWorkspaceBulkJobWorkspace(_ context.Context, arg database.UpdateWorkspaceBulkJobWorkspaceParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, workspace := range q.workspaceBulkJobWorkspaces {
+		if workspace.BulkJobID != arg.BulkJobID || workspace.WorkspaceID != arg.WorkspaceID {
+			continue
+		}
+		workspace.UpdatedAt = arg.UpdatedAt
+		workspace.WorkspaceBuildID = arg.WorkspaceBuildID
+		workspace.Status = arg.Status
+		workspace.Error = arg.Error
+		q.workspaceBulkJobWorkspaces[i] = workspace
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) UpsertTailnetCoordinator(_ context.Context, arg database.UpsertTailnetCoordinatorParams) (database.TailnetCoordinator, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.TailnetCoordinator{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, coordinator := range q.tailnetCoordinators {
+		if coordinator.ID == arg.ID {
+			q.tailnetCoordinators[i].HeartbeatAt = arg.HeartbeatAt
+			return q.tailnetCoordinators[i], nil
+		}
+	}
+	coordinator := database.TailnetCoordinator{
+		ID:          arg.ID,
+		HeartbeatAt: arg.HeartbeatAt,
+	}
+	q.tailnetCoordinators = append(q.tailnetCoordinators, coordinator)
+	return coordinator, nil
+}
+
+func (q *fakeQuerier) DeleteTailnetCoordinator(_ context.Context, id uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.deleteTailnetCoordinators(func(coordinator database.TailnetCoordinator) bool {
+		return coordinator.ID == id
+	})
+	return nil
+}
+
+func (q *fakeQuerier) DeleteTailnetCoordinatorsHeartbeatBefore(_ context.Context, heartbeatAt time.Time) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.deleteTailnetCoordinators(func(coordinator database.TailnetCoordinator) bool {
+		return coordinator.HeartbeatAt.Before(heartbeatAt)
+	})
+	return nil
+}
+
+// deleteTailnetCoordinators deletes the coordinators that match, and cascades
+// to their agents and clients like the foreign keys do. mutex must be held.
+func (q *fakeQuerier) deleteTailnetCoordinators(match func(database.TailnetCoordinator) bool) {
+	deleted := map[uuid.UUID]struct{}{}
+	coordinators := make([]database.TailnetCoordinator, 0, len(q.tailnetCoordinators))
+	for _, coordinator := range q.tailnetCoordinators {
+		if match(coordinator) {
+			deleted[coordinator.ID] = struct{}{}
+			continue
+		}
+		coordinators = append(coordinators, coordinator)
+	}
+	q.tailnetCoordinators = coordinators
+
+	agents := make([]database.TailnetAgent, 0, len(q.tailnetAgents))
+	for _, agent := range q.tailnetAgents {
+		if _, ok := deleted[agent.CoordinatorID]; !ok {
+			agents = append(agents, agent)
+		}
+	}
+	q.tailnetAgents = agents
+
+	clients := make([]database.TailnetClient, 0, len(q.tailnetClients))
+	for _, client := range q.tailnetClients {
+		if _, ok := deleted[client.CoordinatorID]; !ok {
+			clients = append(clients, client)
+		}
+	}
+	q.tailnetClients = clients
+}
+
+func (q *fakeQuerier) GetTailnetCoordinators(_ context.Context) ([]database.TailnetCoordinator, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	coordinators := slices.Clone(q.tailnetCoordinators)
+	slices.SortFunc(coordinators, func(a, b database.TailnetCoordinator) bool {
+		return a.HeartbeatAt.After(b.HeartbeatAt)
+	})
+	return coordinators, nil
+}
+
+func (q *fakeQuerier) UpsertTailnetAgent(_ context.Context, arg database.UpsertTailnetAgentParams) (database.TailnetAgent, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.TailnetAgent{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if !q.tailnetCoordinatorExists(arg.CoordinatorID) {
+		return database.TailnetAgent{}, errForeignKeyConstraint
+	}
+	agent := database.TailnetAgent{
+		ID:            arg.ID,
+		CoordinatorID: arg.CoordinatorID,
+		UpdatedAt:     arg.UpdatedAt,
+		Node:          arg.Node,
+	}
+	for i, existing := range q.tailnetAgents {
+		if existing.ID == arg.ID && existing.CoordinatorID == arg.CoordinatorID {
+			q.tailnetAgents[i] = agent
+			return agent, nil
+		}
+	}
+	q.tailnetAgents = append(q.tailnetAgents, agent)
+	return agent, nil
+}
+
+func (q *fakeQuerier) DeleteTailnetAgent(_ context.Context, arg database.DeleteTailnetAgentParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, agent := range q.tailnetAgents {
+		if agent.ID == arg.ID && agent.CoordinatorID == arg.CoordinatorID {
+			q.tailnetAgents = append(q.tailnetAgents[:i], q.tailnetAgents[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *fakeQuerier) GetTailnetAgents(_ context.Context, id uuid.UUID) ([]database.TailnetAgent, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	agents := make([]database.TailnetAgent, 0)
+	for _, agent := range q.tailnetAgents {
+		if agent.ID == id {
+			agents = append(agents, agent)
+		}
+	}
+	slices.SortFunc(agents, func(a, b database.TailnetAgent) bool {
+		return a.UpdatedAt.After(b.UpdatedAt)
+	})
+	return agents, nil
+}
+
+func (q *fakeQuerier) GetAllTailnetAgents(_ context.Context) ([]database.TailnetAgent, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	agents := slices.Clone(q.tailnetAgents)
+	slices.SortFunc(agents, func(a, b database.TailnetAgent) bool {
+		if a.ID != b.ID {
+			return a.ID.String() < b.ID.String()
+		}
+		return a.UpdatedAt.After(b.UpdatedAt)
+	})
+	return agents, nil
+}
+
+func (q *fakeQuerier) UpsertTailnetClient(_ context.Context, arg database.UpsertTailnetClientParams) (database.TailnetClient, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.TailnetClient{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if !q.tailnetCoordinatorExists(arg.CoordinatorID) {
+		return database.TailnetClient{}, errForeignKeyConstraint
+	}
+	client := database.TailnetClient{
+		ID:            arg.ID,
+		CoordinatorID: arg.CoordinatorID,
+		AgentID:       arg.AgentID,
+		UpdatedAt:     arg.UpdatedAt,
+		Node:          arg.Node,
+	}
+	for i, existing := range q.tailnetClients {
+		if existing.ID == arg.ID && existing.CoordinatorID == arg.CoordinatorID {
+			q.tailnetClients[i] = client
+			return client, nil
+		}
+	}
+	q.tailnetClients = append(q.tailnetClients, client)
+	return client, nil
+}
+
+func (q *fakeQuerier) DeleteTailnetClient(_ context.Context, arg database.DeleteTailnetClientParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, client := range q.tailnetClients {
+		if client.ID == arg.ID && client.CoordinatorID == arg.CoordinatorID {
+			q.tailnetClients = append(q.tailnetClients[:i], q.tailnetClients[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *fakeQuerier) GetTailnetClientsForAgent(_ context.Context, agentID uuid.UUID) ([]database.TailnetClient, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	clients := make([]database.TailnetClient, 0)
+	for _, client := range q.tailnetClients {
+		if client.AgentID == agentID {
+			clients = append(clients, client)
+		}
+	}
+	slices.SortFunc(clients, func(a, b database.TailnetClient) bool {
+		return a.UpdatedAt.After(b.UpdatedAt)
+	})
+	return clients, nil
+}
+
+func (q *fakeQuerier) GetAllTailnetClients(_ context.Context) ([]database.TailnetClient, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	clients := slices.Clone(q.tailnetClients)
+	slices.SortFunc(clients, func(a, b database.TailnetClient) bool {
+		if a.AgentID != b.AgentID {
+			return a.AgentID.String() < b.AgentID.String()
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	return clients, nil
+}
+
+// tailnetCoordinatorExists is used in place of the foreign key constraints
+// of tailnet agents and clients. mutex must be held.
+func (q *fakeQuerier) tailnetCoordinatorExists(id uuid.UUID) bool {
+	for _, coordinator := range q.tailnetCoordinators {
+		if coordinator.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *fakeQuerier) InsertWorkspaceAgentPortShareRule(_ context.Context, arg database.InsertWorkspaceAgentPortShareRuleParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, rule := range q.workspaceAgentPortRules {
+		if rule.WorkspaceAgentID == arg.WorkspaceAgentID && rule.Port == arg.Port {
+			return errDuplicateKey
+		}
+	}
+	//nolint:gosimple
+	q.workspaceAgentPortRules = append(q.workspaceAgentPortRules, database.WorkspaceAgentPortShareRule{
+		WorkspaceAgentID: arg.WorkspaceAgentID,
+		Port:             arg.Port,
+		ShareLevel:       arg.ShareLevel,
+	})
+	return nil
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentPortShareRules(_ context.Context, workspaceAgentID uuid.UUID) ([]database.WorkspaceAgentPortShareRule, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rules := make([]database.WorkspaceAgentPortShareRule, 0)
+	for _, rule := range q.workspaceAgentPortRules {
+		if rule.WorkspaceAgentID == workspaceAgentID {
+			rules = append(rules, rule)
+		}
+	}
+	slices.SortFunc(rules, func(a, b database.WorkspaceAgentPortShareRule) bool {
+		return a.Port < b.Port
+	})
+	return rules, nil
+}
+
+func (q *fakeQuerier) UpsertWorkspaceAgentPortShare(_ context.Context, arg database.UpsertWorkspaceAgentPortShareParams) (database.WorkspaceAgentPortShare, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.WorkspaceAgentPortShare{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == arg.WorkspaceID && share.AgentName == arg.AgentName && share.Port == arg.Port {
+			share.ShareLevel = arg.ShareLevel
+			share.UpdatedAt = arg.UpdatedAt
+			q.workspaceAgentPortShares[i] = share
+			return share, nil
+		}
+	}
+	share := database.WorkspaceAgentPortShare{
+		WorkspaceID: arg.WorkspaceID,
+		AgentName:   arg.AgentName,
+		Port:        arg.Port,
+		ShareLevel:  arg.ShareLevel,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+	}
+	q.workspaceAgentPortShares = append(q.workspaceAgentPortShares, share)
+	return share, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentPortShares(_ context.Context, arg database.GetWorkspaceAgentPortSharesParams) ([]database.WorkspaceAgentPortShare, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	shares := make([]database.WorkspaceAgentPortShare, 0)
+	for _, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == arg.WorkspaceID && share.AgentName == arg.AgentName {
+			shares = append(shares, share)
+		}
+	}
+	slices.SortFunc(shares, func(a, b database.WorkspaceAgentPortShare) bool {
+		return a.Port < b.Port
+	})
+	return shares, nil
+}
+
+func (q *fakeQuerier) DeleteWorkspaceAgentPortShare(_ context.Context, arg database.DeleteWorkspaceAgentPortShareParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == arg.WorkspaceID && share.AgentName == arg.AgentName && share.Port == arg.Port {
+			q.workspaceAgentPortShares = append(q.workspaceAgentPortShares[:i], q.workspaceAgentPortShares[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
@@ -129,6 +129,45 @@ func TestUserOrder(t *testing.T) {
 	}
 }
 
+// TestAcquireProvisionerJobOrder ensures that the fake database acquires jobs
+// by priority, skips canceled jobs, and otherwise acquires them in the order
+// they were created.
+func TestAcquireProvisionerJobOrder(t *testing.T) {
+	t.Parallel()
+
+	db := dbfake.New()
+	now := database.Now()
+	canceled := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{
+		CreatedAt: now.Add(-time.Hour),
+		Priority:  100,
+	})
+	err := db.UpdateProvisionerJobWithCancelByID(context.Background(), database.UpdateProvisionerJobWithCancelByIDParams{
+		ID:          canceled.ID,
+		CanceledAt:  sql.NullTime{Time: now, Valid: true},
+		CompletedAt: sql.NullTime{Time: now, Valid: true},
+	})
+	require.NoError(t, err)
+	oldest := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{CreatedAt: now.Add(-time.Minute)})
+	newest := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{CreatedAt: now})
+	important := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{CreatedAt: now, Priority: 1})
+
+	for _, expected := range []database.ProvisionerJob{important, oldest, newest} {
+		job, err := db.AcquireProvisionerJob(context.Background(), database.AcquireProvisionerJobParams{
+			StartedAt: sql.NullTime{Time: now, Valid: true},
+			Types:     []database.ProvisionerType{database.ProvisionerTypeEcho},
+			Tags:      []byte("{}"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, expected.ID, job.ID)
+	}
+	_, err = db.AcquireProvisionerJob(context.Background(), database.AcquireProvisionerJobParams{
+		StartedAt: sql.NullTime{Time: now, Valid: true},
+		Types:     []database.ProvisionerType{database.ProvisionerTypeEcho},
+		Tags:      []byte("{}"),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 func methods(rt reflect.Type) map[string]bool {
 	methods := make(map[string]bool)
 	for i := 0; i < rt.NumMethod(); i++ {
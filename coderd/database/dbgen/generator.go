@@ -172,6 +172,21 @@ func WorkspaceBuild(t testing.TB, db database.Store, orig database.WorkspaceBuil
 	return build
 }
 
+func WorkspaceBulkJob(t testing.TB, db database.Store, orig database.WorkspaceBulkJob) database.WorkspaceBulkJob {
+	job, err := db.InsertWorkspaceBulkJob(context.Background(), database.InsertWorkspaceBulkJobParams{
+		ID:          takeFirst(orig.ID, uuid.New()),
+		CreatedAt:   takeFirst(orig.CreatedAt, database.Now()),
+		UpdatedAt:   takeFirst(orig.UpdatedAt, database.Now()),
+		InitiatorID: takeFirst(orig.InitiatorID, uuid.New()),
+		Action:      takeFirst(orig.Action, database.WorkspaceBulkJobActionStart),
+		Filter:      takeFirst(orig.Filter, ""),
+		BatchSize:   takeFirst(orig.BatchSize, 10),
+		Status:      takeFirst(orig.Status, database.WorkspaceBulkJobStatusRunning),
+	})
+	require.NoError(t, err, "insert workspace bulk job")
+	return job
+}
+
 func User(t testing.TB, db database.Store, orig database.User) database.User {
 	user, err := db.InsertUser(context.Background(), database.InsertUserParams{
 		ID:             takeFirst(orig.ID, uuid.New()),
@@ -262,6 +277,7 @@ func ProvisionerJob(t testing.TB, db database.Store, orig database.ProvisionerJo
 		Type:           takeFirst(orig.Type, database.ProvisionerJobTypeWorkspaceBuild),
 		Input:          takeFirstSlice(orig.Input, []byte("{}")),
 		Tags:           orig.Tags,
+		Priority:       orig.Priority,
 	})
 	require.NoError(t, err, "insert job")
 	return job
@@ -384,6 +400,21 @@ func TemplateVersion(t testing.TB, db database.Store, orig database.TemplateVers
 	return version
 }
 
+func TemplateVersionPromotion(t testing.TB, db database.Store, orig database.TemplateVersionPromotion) database.TemplateVersionPromotion {
+	promotion, err := db.InsertTemplateVersionPromotion(context.Background(), database.InsertTemplateVersionPromotionParams{
+		ID:                takeFirst(orig.ID, uuid.New()),
+		CreatedAt:         takeFirst(orig.CreatedAt, database.Now()),
+		UpdatedAt:         takeFirst(orig.UpdatedAt, database.Now()),
+		TemplateID:        takeFirst(orig.TemplateID, uuid.New()),
+		TemplateVersionID: takeFirst(orig.TemplateVersionID, uuid.New()),
+		RequesterID:       takeFirst(orig.RequesterID, uuid.New()),
+		DryRunJobID:       takeFirst(orig.DryRunJobID, uuid.New()),
+		Status:            takeFirst(orig.Status, database.TemplateVersionPromotionStatusPending),
+	})
+	require.NoError(t, err, "insert template version promotion")
+	return promotion
+}
+
 func TemplateVersionVariable(t testing.TB, db database.Store, orig database.TemplateVersionVariable) database.TemplateVersionVariable {
 	version, err := db.InsertTemplateVersionVariable(context.Background(), database.InsertTemplateVersionVariableParams{
 		TemplateVersionID: takeFirst(orig.TemplateVersionID, uuid.New()),
@@ -465,3 +496,21 @@ func WorkspaceAgentStat(t testing.TB, db database.Store, orig database.Workspace
 	require.NoError(t, err, "insert workspace agent stat")
 	return scheme
 }
+
+func NotificationWebhook(t testing.TB, db database.Store, orig database.NotificationWebhook) database.NotificationWebhook {
+	webhook, err := db.InsertNotificationWebhook(context.Background(), database.InsertNotificationWebhookParams{
+		ID:              takeFirst(orig.ID, uuid.New()),
+		CreatedAt:       takeFirst(orig.CreatedAt, database.Now()),
+		UpdatedAt:       takeFirst(orig.UpdatedAt, database.Now()),
+		Name:            takeFirst(orig.Name, namesgenerator.GetRandomName(1)),
+		Url:             takeFirst(orig.Url, "https://webhook.example.com"),
+		Events:          takeFirstSlice(orig.Events, database.AllNotificationEventTypeValues()),
+		PayloadTemplate: orig.PayloadTemplate,
+		Secret:          orig.Secret,
+		// Disabled webhooks are rarely useful in tests, so webhooks are
+		// always created enabled.
+		Enabled: true,
+	})
+	require.NoError(t, err, "insert notification webhook")
+	return webhook
+}
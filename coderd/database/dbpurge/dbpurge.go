@@ -6,22 +6,44 @@ import (
 	"io"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/codersdk"
+)
+
+const (
+	delay = 24 * time.Hour
+	// defaultBatchSize is used when no batch size is configured.
+	defaultBatchSize = 1000
+)
+
+// Tables with a configurable retention policy.
+const (
+	TableAuditLogs          = "audit_logs"
+	TableProvisionerJobLogs = "provisioner_job_logs"
+	TableWorkspaceBuilds    = "workspace_builds"
 )
 
 // New creates a new periodically purging database instance.
 // It is the caller's responsibility to call Close on the returned instance.
 //
 // This is for cleaning up old, unused resources from the database that take up space.
-func New(ctx context.Context, logger slog.Logger, db database.Store) io.Closer {
+// Tables with a retention policy are purged in batches of at most
+// retention.PurgeBatchSize rows so that large purges don't lock tables for long.
+func New(ctx context.Context, logger slog.Logger, db database.Store, retention codersdk.RetentionConfig, registerer prometheus.Registerer) io.Closer {
 	closed := make(chan struct{})
-	ctx, cancelFunc := context.WithCancel(ctx)
+	//nolint:gocritic // The purger needs to delete rows of any table.
+	ctx, cancelFunc := context.WithCancel(dbauthz.AsSystemRestricted(ctx))
+	p := newPurger(logger, db, retention, registerer)
 	go func() {
 		defer close(closed)
-		ticker := time.NewTicker(24 * time.Hour)
+		ticker := time.NewTicker(delay)
 		defer ticker.Stop()
 		for {
 			select {
@@ -30,14 +52,7 @@ func New(ctx context.Context, logger slog.Logger, db database.Store) io.Closer {
 			case <-ticker.C:
 			}
 
-			var eg errgroup.Group
-			eg.Go(func() error {
-				return db.DeleteOldWorkspaceAgentStartupLogs(ctx)
-			})
-			eg.Go(func() error {
-				return db.DeleteOldWorkspaceAgentStats(ctx)
-			})
-			err := eg.Wait()
+			err := p.purge(ctx)
 			if err != nil {
 				if errors.Is(err, context.Canceled) {
 					return
@@ -62,3 +77,136 @@ func (i *instance) Close() error {
 	<-i.closed
 	return nil
 }
+
+type purger struct {
+	logger     slog.Logger
+	db         database.Store
+	retention  codersdk.RetentionConfig
+	rowsPurged *prometheus.CounterVec
+}
+
+func newPurger(logger slog.Logger, db database.Store, retention codersdk.RetentionConfig, registerer prometheus.Registerer) *purger {
+	return &purger{
+		logger:    logger,
+		db:        db,
+		retention: retention,
+		rowsPurged: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "dbpurge",
+			Name:      "rows_purged_total",
+			Help:      "The number of rows purged from the database by table.",
+		}, []string{"table"}),
+	}
+}
+
+func (p *purger) purge(ctx context.Context) error {
+	now := database.Now()
+	batchSize := int32(p.retention.PurgeBatchSize.Value())
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return p.db.DeleteOldWorkspaceAgentStartupLogs(ctx)
+	})
+	eg.Go(func() error {
+		return p.db.DeleteOldWorkspaceAgentStats(ctx)
+	})
+	eg.Go(func() error {
+		return p.db.DeleteOldNotificationWebhookDeliveries(ctx)
+	})
+	eg.Go(func() error {
+		return p.db.DeleteExpiredRateLimitCounters(ctx, now)
+	})
+	eg.Go(func() error {
+		return p.purgeTable(ctx, TableAuditLogs, p.retention.AuditLogs.Value(), now, batchSize, func(before time.Time, limit int32) (int64, error) {
+			return p.db.DeleteOldAuditLogs(ctx, database.DeleteOldAuditLogsParams{
+				Before:     before,
+				LimitCount: limit,
+			})
+		})
+	})
+	eg.Go(func() error {
+		return p.purgeTable(ctx, TableProvisionerJobLogs, p.retention.ProvisionerJobLogs.Value(), now, batchSize, func(before time.Time, limit int32) (int64, error) {
+			return p.db.DeleteOldProvisionerJobLogs(ctx, database.DeleteOldProvisionerJobLogsParams{
+				Before:     before,
+				LimitCount: limit,
+			})
+		})
+	})
+	eg.Go(func() error {
+		return p.purgeTable(ctx, TableWorkspaceBuilds, p.retention.WorkspaceBuildStates.Value(), now, batchSize, func(before time.Time, limit int32) (int64, error) {
+			return p.db.DeleteOldWorkspaceBuildStates(ctx, database.DeleteOldWorkspaceBuildStatesParams{
+				Before:     before,
+				LimitCount: limit,
+			})
+		})
+	})
+	return eg.Wait()
+}
+
+// purgeTable repeatedly calls purgeBatch until fewer than batchSize rows are
+// purged. A retention of zero disables purging.
+func (p *purger) purgeTable(ctx context.Context, table string, retention time.Duration, now time.Time, batchSize int32, purgeBatch func(before time.Time, limit int32) (int64, error)) error {
+	if retention <= 0 {
+		return nil
+	}
+	before := now.Add(-retention)
+
+	var total int64
+	for {
+		purged, err := purgeBatch(before, batchSize)
+		if err != nil {
+			return xerrors.Errorf("purge %s: %w", table, err)
+		}
+		total += purged
+		p.rowsPurged.WithLabelValues(table).Add(float64(purged))
+		if purged < int64(batchSize) {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	if total > 0 {
+		p.logger.Info(ctx, "purged old database entries",
+			slog.F("table", table),
+			slog.F("before", before),
+			slog.F("rows", total),
+		)
+	}
+	return nil
+}
+
+// Stats returns the number of rows the next purge would remove from each
+// table with a configurable retention policy, if it ran at the given time.
+func Stats(ctx context.Context, db database.Store, retention codersdk.RetentionConfig, now time.Time) ([]codersdk.PurgeTableStats, error) {
+	tables := []struct {
+		name      string
+		retention time.Duration
+		count     func(ctx context.Context, before time.Time) (int64, error)
+	}{
+		{TableAuditLogs, retention.AuditLogs.Value(), db.GetOldAuditLogCount},
+		{TableProvisionerJobLogs, retention.ProvisionerJobLogs.Value(), db.GetOldProvisionerJobLogCount},
+		{TableWorkspaceBuilds, retention.WorkspaceBuildStates.Value(), db.GetOldWorkspaceBuildStateCount},
+	}
+
+	stats := make([]codersdk.PurgeTableStats, 0, len(tables))
+	for _, table := range tables {
+		stat := codersdk.PurgeTableStats{
+			Table:           table.name,
+			RetentionMillis: table.retention.Milliseconds(),
+		}
+		if table.retention > 0 {
+			stat.Before = now.Add(-table.retention)
+			rows, err := table.count(ctx, stat.Before)
+			if err != nil {
+				return nil, xerrors.Errorf("count %s: %w", table.name, err)
+			}
+			stat.Rows = rows
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
@@ -0,0 +1,110 @@
+package dbpurge
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbfake"
+	"github.com/coder/coder/coderd/database/dbgen"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestPurgeRetention(t *testing.T) {
+	t.Parallel()
+
+	db := dbfake.New()
+	now := database.Now()
+	old := now.Add(-48 * time.Hour)
+
+	for i := 0; i < 3; i++ {
+		_ = dbgen.AuditLog(t, db, database.AuditLog{Time: old})
+	}
+	recent := dbgen.AuditLog(t, db, database.AuditLog{Time: now})
+
+	oldJob := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{})
+	err := db.UpdateProvisionerJobWithCompleteByID(context.Background(), database.UpdateProvisionerJobWithCompleteByIDParams{
+		ID:          oldJob.ID,
+		UpdatedAt:   old,
+		CompletedAt: sql.NullTime{Time: old, Valid: true},
+	})
+	require.NoError(t, err)
+	runningJob := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{})
+	for _, jobID := range []uuid.UUID{oldJob.ID, runningJob.ID} {
+		_, err := db.InsertProvisionerJobLogs(context.Background(), database.InsertProvisionerJobLogsParams{
+			JobID:     jobID,
+			CreatedAt: []time.Time{old},
+			Source:    []database.LogSource{database.LogSourceProvisioner},
+			Level:     []database.LogLevel{database.LogLevelInfo},
+			Stage:     []string{"stage"},
+			Output:    []string{"output"},
+		})
+		require.NoError(t, err)
+	}
+
+	workspace := dbgen.Workspace(t, db, database.Workspace{})
+	superseded := dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+		WorkspaceID:      workspace.ID,
+		BuildNumber:      1,
+		CreatedAt:        old,
+		ProvisionerState: []byte("state"),
+	})
+	latest := dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+		WorkspaceID:      workspace.ID,
+		BuildNumber:      2,
+		CreatedAt:        old,
+		ProvisionerState: []byte("state"),
+	})
+
+	retention := codersdk.RetentionConfig{
+		AuditLogs:            clibase.Duration(24 * time.Hour),
+		ProvisionerJobLogs:   clibase.Duration(24 * time.Hour),
+		WorkspaceBuildStates: clibase.Duration(24 * time.Hour),
+		// A small batch size ensures purging happens over multiple batches.
+		PurgeBatchSize: 2,
+	}
+
+	stats, err := Stats(context.Background(), db, retention, now)
+	require.NoError(t, err)
+	require.Equal(t, []codersdk.PurgeTableStats{
+		{Table: TableAuditLogs, RetentionMillis: retention.AuditLogs.Value().Milliseconds(), Before: now.Add(-24 * time.Hour), Rows: 3},
+		{Table: TableProvisionerJobLogs, RetentionMillis: retention.ProvisionerJobLogs.Value().Milliseconds(), Before: now.Add(-24 * time.Hour), Rows: 1},
+		{Table: TableWorkspaceBuilds, RetentionMillis: retention.WorkspaceBuildStates.Value().Milliseconds(), Before: now.Add(-24 * time.Hour), Rows: 1},
+	}, stats)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+	defer cancel()
+	err = newPurger(slogtest.Make(t, nil), db, retention, prometheus.NewRegistry()).purge(ctx)
+	require.NoError(t, err)
+
+	stats, err = Stats(context.Background(), db, retention, now)
+	require.NoError(t, err)
+	for _, stat := range stats {
+		require.Zero(t, stat.Rows, stat.Table)
+	}
+
+	logs, err := db.GetAuditLogsOffset(context.Background(), database.GetAuditLogsOffsetParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, recent.ID, logs[0].ID)
+
+	jobLogs, err := db.GetProvisionerLogsAfterID(context.Background(), database.GetProvisionerLogsAfterIDParams{JobID: runningJob.ID})
+	require.NoError(t, err)
+	require.Len(t, jobLogs, 1)
+
+	build, err := db.GetWorkspaceBuildByID(context.Background(), superseded.ID)
+	require.NoError(t, err)
+	require.Empty(t, build.ProvisionerState)
+	build, err = db.GetWorkspaceBuildByID(context.Background(), latest.ID)
+	require.NoError(t, err)
+	require.Equal(t, []byte("state"), build.ProvisionerState)
+}
@@ -3,14 +3,21 @@ package dbpurge_test
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/goleak"
 
 	"github.com/stretchr/testify/require"
 
 	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/dbfake"
+	"github.com/coder/coder/coderd/database/dbgen"
 	"github.com/coder/coder/coderd/database/dbpurge"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
 )
 
 func TestMain(m *testing.M) {
@@ -20,7 +27,28 @@ func TestMain(m *testing.M) {
 // Ensures no goroutines leak.
 func TestPurge(t *testing.T) {
 	t.Parallel()
-	purger := dbpurge.New(context.Background(), slogtest.Make(t, nil), dbfake.New())
+	purger := dbpurge.New(context.Background(), slogtest.Make(t, nil), dbfake.New(), codersdk.RetentionConfig{}, prometheus.NewRegistry())
 	err := purger.Close()
 	require.NoError(t, err)
 }
+
+// Ensures old rows aren't purged as soon as the purger starts, since every
+// replica starts one.
+func TestPurgeNotAtStartup(t *testing.T) {
+	t.Parallel()
+
+	db := dbfake.New()
+	_ = dbgen.AuditLog(t, db, database.AuditLog{Time: database.Now().Add(-48 * time.Hour)})
+	retention := codersdk.RetentionConfig{
+		AuditLogs: clibase.Duration(24 * time.Hour),
+	}
+
+	purger := dbpurge.New(context.Background(), slogtest.Make(t, nil), db, retention, prometheus.NewRegistry())
+	time.Sleep(testutil.IntervalMedium)
+	err := purger.Close()
+	require.NoError(t, err)
+
+	logs, err := db.GetAuditLogsOffset(context.Background(), database.GetAuditLogsOffsetParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+}
@@ -43,3 +43,26 @@ func (t *TemplateACL) Scan(src interface{}) error {
 func (t TemplateACL) Value() (driver.Value, error) {
 	return json.Marshal(t)
 }
+
+// ProvisionerType is the name of a provisioner. Besides the built-in
+// provisioners, any external provisioner plugin declared in the deployment
+// config is a valid type.
+type ProvisionerType string
+
+const (
+	ProvisionerTypeEcho      ProvisionerType = "echo"
+	ProvisionerTypeTerraform ProvisionerType = "terraform"
+)
+
+// Scan is required to scan provisioner type arrays with pq.Array.
+func (p *ProvisionerType) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		*p = ProvisionerType(v)
+	case []byte:
+		*p = ProvisionerType(v)
+	default:
+		return xerrors.Errorf("unexpected type %T", src)
+	}
+	return nil
+}
@@ -24,7 +24,9 @@ CREATE TYPE audit_action AS ENUM (
 CREATE TYPE build_reason AS ENUM (
     'initiator',
     'autostart',
-    'autostop'
+    'autostop',
+    'dormancy',
+    'autodelete'
 );
 
 CREATE TYPE log_level AS ENUM (
@@ -47,6 +49,14 @@ CREATE TYPE login_type AS ENUM (
     'token'
 );
 
+CREATE TYPE notification_event_type AS ENUM (
+    'workspace_build_succeeded',
+    'workspace_build_failed',
+    'workspace_autostop_imminent',
+    'workspace_deleted',
+    'template_version_promoted'
+);
+
 CREATE TYPE parameter_destination_scheme AS ENUM (
     'none',
     'environment_variable',
@@ -79,9 +89,15 @@ CREATE TYPE provisioner_storage_method AS ENUM (
     'file'
 );
 
-CREATE TYPE provisioner_type AS ENUM (
-    'echo',
-    'terraform'
+CREATE DOMAIN provisioner_type AS text;
+
+COMMENT ON DOMAIN provisioner_type IS 'The name of a built-in provisioner, or of an external provisioner plugin declared in the deployment config.';
+
+CREATE TYPE resource_change_action AS ENUM (
+    'create',
+    'update',
+    'replace',
+    'delete'
 );
 
 CREATE TYPE resource_type AS ENUM (
@@ -94,7 +110,15 @@ CREATE TYPE resource_type AS ENUM (
     'api_key',
     'group',
     'workspace_build',
-    'license'
+    'license',
+    'workspace_session_recording',
+    'template_version_promotion'
+);
+
+CREATE TYPE template_version_promotion_status AS ENUM (
+    'pending',
+    'promoted',
+    'superseded'
 );
 
 CREATE TYPE user_status AS ENUM (
@@ -121,6 +145,26 @@ CREATE TYPE workspace_app_health AS ENUM (
     'unhealthy'
 );
 
+CREATE TYPE workspace_bulk_job_action AS ENUM (
+    'start',
+    'stop',
+    'update',
+    'delete'
+);
+
+CREATE TYPE workspace_bulk_job_status AS ENUM (
+    'pending',
+    'running',
+    'succeeded',
+    'failed',
+    'skipped'
+);
+
+CREATE TYPE workspace_session_type AS ENUM (
+    'ssh',
+    'reconnecting_pty'
+);
+
 CREATE TYPE workspace_transition AS ENUM (
     'start',
     'stop',
@@ -222,6 +266,41 @@ CREATE SEQUENCE licenses_id_seq
 
 ALTER SEQUENCE licenses_id_seq OWNED BY licenses.id;
 
+CREATE TABLE notification_webhook_deliveries (
+    id uuid NOT NULL,
+    webhook_id uuid NOT NULL,
+    event_id uuid NOT NULL,
+    event_type notification_event_type NOT NULL,
+    attempt integer NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    completed_at timestamp with time zone,
+    request_body text NOT NULL,
+    status_code integer DEFAULT 0 NOT NULL,
+    error text DEFAULT ''::text NOT NULL
+);
+
+COMMENT ON COLUMN notification_webhook_deliveries.attempt IS 'Starts at 1. Every replica receives every event, so the unique constraint ensures each attempt is made once.';
+
+COMMENT ON COLUMN notification_webhook_deliveries.status_code IS 'The HTTP status code of the response, or 0 if no response was received.';
+
+CREATE TABLE notification_webhooks (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    name text NOT NULL,
+    url text NOT NULL,
+    events notification_event_type[] NOT NULL,
+    payload_template text DEFAULT ''::text NOT NULL,
+    secret text DEFAULT ''::text NOT NULL,
+    enabled boolean DEFAULT true NOT NULL
+);
+
+COMMENT ON COLUMN notification_webhooks.events IS 'The event types that are sent to the webhook.';
+
+COMMENT ON COLUMN notification_webhooks.payload_template IS 'A Go text/template used to render the request body. The JSON encoded event is sent if empty.';
+
+COMMENT ON COLUMN notification_webhooks.secret IS 'Used to sign request bodies with HMAC-SHA256 if set.';
+
 CREATE TABLE organization_members (
     user_id uuid NOT NULL,
     organization_id uuid NOT NULL,
@@ -277,9 +356,16 @@ CREATE TABLE provisioner_daemons (
     name character varying(64) NOT NULL,
     provisioners provisioner_type[] NOT NULL,
     replica_id uuid,
-    tags jsonb DEFAULT '{}'::jsonb NOT NULL
+    tags jsonb DEFAULT '{}'::jsonb NOT NULL,
+    last_seen_at timestamp with time zone,
+    version text DEFAULT ''::text NOT NULL,
+    current_job_id uuid
 );
 
+COMMENT ON COLUMN provisioner_daemons.last_seen_at IS 'The last time the daemon sent a heartbeat. Daemons that stop sending heartbeats are reaped.';
+
+COMMENT ON COLUMN provisioner_daemons.current_job_id IS 'The job the daemon reported running in its last heartbeat.';
+
 CREATE TABLE provisioner_job_logs (
     job_id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -299,6 +385,18 @@ CREATE SEQUENCE provisioner_job_logs_id_seq
 
 ALTER SEQUENCE provisioner_job_logs_id_seq OWNED BY provisioner_job_logs.id;
 
+CREATE TABLE provisioner_job_resource_changes (
+    job_id uuid NOT NULL,
+    address text NOT NULL,
+    type text NOT NULL,
+    name text NOT NULL,
+    action resource_change_action NOT NULL,
+    daily_cost integer DEFAULT 0 NOT NULL,
+    prior_daily_cost integer DEFAULT 0 NOT NULL
+);
+
+COMMENT ON TABLE provisioner_job_resource_changes IS 'Changes to resources planned by a provisioner job, with the daily cost of each resource before and after the change.';
+
 CREATE TABLE provisioner_jobs (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -316,7 +414,17 @@ CREATE TABLE provisioner_jobs (
     worker_id uuid,
     file_id uuid NOT NULL,
     tags jsonb DEFAULT '{"scope": "organization"}'::jsonb NOT NULL,
-    error_code text
+    error_code text,
+    priority integer DEFAULT 0 NOT NULL
+);
+
+COMMENT ON COLUMN provisioner_jobs.priority IS 'Pending jobs with a higher priority are acquired first. Jobs with the same priority are acquired in the order they were created.';
+
+CREATE UNLOGGED TABLE rate_limit_counters (
+    key text NOT NULL,
+    window_start timestamp with time zone NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    count bigint DEFAULT 0 NOT NULL
 );
 
 CREATE TABLE replicas (
@@ -338,6 +446,49 @@ CREATE TABLE site_configs (
     value character varying(8192) NOT NULL
 );
 
+CREATE TABLE tailnet_agents (
+    id uuid NOT NULL,
+    coordinator_id uuid NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    node jsonb NOT NULL
+);
+
+COMMENT ON TABLE tailnet_agents IS 'The latest node of each agent connected to a coordinator.';
+
+CREATE TABLE tailnet_clients (
+    id uuid NOT NULL,
+    coordinator_id uuid NOT NULL,
+    agent_id uuid NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    node jsonb NOT NULL
+);
+
+COMMENT ON TABLE tailnet_clients IS 'The latest node of each client connected to a coordinator, and the agent it connects to.';
+
+CREATE TABLE tailnet_coordinators (
+    id uuid NOT NULL,
+    heartbeat_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE tailnet_coordinators IS 'The high availability tailnet coordinators of each replica. Coordinators that stop heartbeating are deleted along with their connections.';
+
+CREATE TABLE template_version_promotions (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    template_id uuid NOT NULL,
+    template_version_id uuid NOT NULL,
+    requester_id uuid NOT NULL,
+    dry_run_job_id uuid NOT NULL,
+    approver_ids uuid[] DEFAULT '{}'::uuid[] NOT NULL,
+    status template_version_promotion_status NOT NULL,
+    promoted_at timestamp with time zone
+);
+
+COMMENT ON TABLE template_version_promotions IS 'Requests to make a template version active, which are completed once the dry-run succeeds and enough users approve.';
+
+COMMENT ON COLUMN template_version_promotions.status IS 'Pending promotions are superseded when another version of the template is promoted.';
+
 CREATE TABLE template_version_parameters (
     template_version_id uuid NOT NULL,
     name text NOT NULL,
@@ -442,7 +593,11 @@ CREATE TABLE templates (
     group_acl jsonb DEFAULT '{}'::jsonb NOT NULL,
     display_name character varying(64) DEFAULT ''::character varying NOT NULL,
     allow_user_cancel_workspace_jobs boolean DEFAULT true NOT NULL,
-    max_ttl bigint DEFAULT '0'::bigint NOT NULL
+    max_ttl bigint DEFAULT '0'::bigint NOT NULL,
+    inactivity_ttl bigint DEFAULT '0'::bigint NOT NULL,
+    delete_ttl bigint DEFAULT '0'::bigint NOT NULL,
+    autostop_requirement text DEFAULT ''::text NOT NULL,
+    required_promotion_approvals integer DEFAULT 0 NOT NULL
 );
 
 COMMENT ON COLUMN templates.default_ttl IS 'The default duration for auto-stop for workspaces created from this template.';
@@ -451,6 +606,14 @@ COMMENT ON COLUMN templates.display_name IS 'Display name is a custom, human-fri
 
 COMMENT ON COLUMN templates.allow_user_cancel_workspace_jobs IS 'Allow users to cancel in-progress workspace jobs.';
 
+COMMENT ON COLUMN templates.inactivity_ttl IS 'The duration a running workspace may go unused before it is stopped automatically.';
+
+COMMENT ON COLUMN templates.delete_ttl IS 'The duration a workspace may stay stopped and unused before it is deleted automatically.';
+
+COMMENT ON COLUMN templates.autostop_requirement IS 'A weekly cron schedule without a timezone. Running workspaces must be stopped at the next occurrence of the schedule in the owner''s timezone.';
+
+COMMENT ON COLUMN templates.required_promotion_approvals IS 'The number of approvals a template version needs before it can become the active version. Zero disables the promotion workflow.';
+
 CREATE TABLE user_links (
     user_id uuid NOT NULL,
     login_type login_type NOT NULL,
@@ -475,6 +638,37 @@ CREATE TABLE users (
     last_seen_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
 );
 
+CREATE UNLOGGED TABLE workspace_agent_metadata (
+    workspace_agent_id uuid NOT NULL,
+    display_name character varying(127) NOT NULL,
+    key character varying(127) NOT NULL,
+    script character varying(65535) NOT NULL,
+    value character varying(65535) DEFAULT ''::character varying NOT NULL,
+    error character varying(65535) DEFAULT ''::character varying NOT NULL,
+    timeout bigint NOT NULL,
+    "interval" bigint NOT NULL,
+    collected_at timestamp with time zone DEFAULT '0001-01-01 00:00:00+00'::timestamp with time zone NOT NULL
+);
+
+CREATE TABLE workspace_agent_port_share_rules (
+    workspace_agent_id uuid NOT NULL,
+    port integer NOT NULL,
+    share_level app_sharing_level NOT NULL
+);
+
+COMMENT ON TABLE workspace_agent_port_share_rules IS 'Port sharing rules defined by the template of a workspace agent.';
+
+CREATE TABLE workspace_agent_port_shares (
+    workspace_id uuid NOT NULL,
+    agent_name text NOT NULL,
+    port integer NOT NULL,
+    share_level app_sharing_level NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_agent_port_shares IS 'Ports shared by workspace owners. Shares are keyed by agent name so they persist across workspace builds.';
+
 CREATE TABLE workspace_agent_startup_logs (
     agent_id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -542,6 +736,8 @@ CREATE TABLE workspace_agents (
     shutdown_script_timeout_seconds integer DEFAULT 0 NOT NULL,
     startup_logs_length integer DEFAULT 0 NOT NULL,
     startup_logs_overflowed boolean DEFAULT false NOT NULL,
+    reconnecting_pty_buffer_size integer DEFAULT 0 NOT NULL,
+    session_recording boolean DEFAULT false NOT NULL,
     CONSTRAINT max_startup_logs_length CHECK ((startup_logs_length <= 1048576))
 );
 
@@ -569,6 +765,10 @@ COMMENT ON COLUMN workspace_agents.startup_logs_length IS 'Total length of start
 
 COMMENT ON COLUMN workspace_agents.startup_logs_overflowed IS 'Whether the startup logs overflowed in length';
 
+COMMENT ON COLUMN workspace_agents.reconnecting_pty_buffer_size IS 'The number of bytes of output each reconnecting PTY keeps for replay, 0 means the agent default.';
+
+COMMENT ON COLUMN workspace_agents.session_recording IS 'Whether the agent records SSH and web terminal sessions.';
+
 CREATE TABLE workspace_apps (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -614,6 +814,32 @@ CREATE TABLE workspace_builds (
     max_deadline timestamp with time zone DEFAULT '0001-01-01 00:00:00+00'::timestamp with time zone NOT NULL
 );
 
+CREATE TABLE workspace_bulk_job_workspaces (
+    bulk_job_id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    workspace_build_id uuid,
+    updated_at timestamp with time zone NOT NULL,
+    status workspace_bulk_job_status NOT NULL,
+    error text DEFAULT ''::text NOT NULL
+);
+
+COMMENT ON COLUMN workspace_bulk_job_workspaces.status IS 'Workspaces are skipped if they are already in the state the action would put them in.';
+
+CREATE TABLE workspace_bulk_jobs (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    completed_at timestamp with time zone,
+    initiator_id uuid NOT NULL,
+    action workspace_bulk_job_action NOT NULL,
+    filter text NOT NULL,
+    batch_size integer NOT NULL,
+    status workspace_bulk_job_status NOT NULL,
+    error text DEFAULT ''::text NOT NULL
+);
+
+COMMENT ON TABLE workspace_bulk_jobs IS 'Builds of many workspaces matching a search filter, created in batches.';
+
 CREATE TABLE workspace_resource_metadata (
     workspace_resource_id uuid NOT NULL,
     key character varying(1024) NOT NULL,
@@ -644,6 +870,19 @@ CREATE TABLE workspace_resources (
     daily_cost integer DEFAULT 0 NOT NULL
 );
 
+CREATE TABLE workspace_session_recordings (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    workspace_id uuid NOT NULL,
+    agent_id uuid NOT NULL,
+    session_type workspace_session_type NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    ended_at timestamp with time zone NOT NULL,
+    data bytea NOT NULL
+);
+
+COMMENT ON COLUMN workspace_session_recordings.data IS 'The session in asciicast v2 format.';
+
 CREATE TABLE workspaces (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -702,6 +941,18 @@ ALTER TABLE ONLY licenses
 ALTER TABLE ONLY licenses
     ADD CONSTRAINT licenses_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY notification_webhook_deliveries
+    ADD CONSTRAINT notification_webhook_deliveries_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY notification_webhook_deliveries
+    ADD CONSTRAINT notification_webhook_deliveries_webhook_id_event_id_attempt_key UNIQUE (webhook_id, event_id, attempt);
+
+ALTER TABLE ONLY notification_webhooks
+    ADD CONSTRAINT notification_webhooks_name_key UNIQUE (name);
+
+ALTER TABLE ONLY notification_webhooks
+    ADD CONSTRAINT notification_webhooks_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY organization_members
     ADD CONSTRAINT organization_members_pkey PRIMARY KEY (organization_id, user_id);
 
@@ -729,15 +980,33 @@ ALTER TABLE ONLY provisioner_daemons
 ALTER TABLE ONLY provisioner_job_logs
     ADD CONSTRAINT provisioner_job_logs_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY provisioner_job_resource_changes
+    ADD CONSTRAINT provisioner_job_resource_changes_pkey PRIMARY KEY (job_id, address);
+
 ALTER TABLE ONLY provisioner_jobs
     ADD CONSTRAINT provisioner_jobs_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY rate_limit_counters
+    ADD CONSTRAINT rate_limit_counters_pkey PRIMARY KEY (key);
+
 ALTER TABLE ONLY site_configs
     ADD CONSTRAINT site_configs_key_key UNIQUE (key);
 
+ALTER TABLE ONLY tailnet_agents
+    ADD CONSTRAINT tailnet_agents_pkey PRIMARY KEY (id, coordinator_id);
+
+ALTER TABLE ONLY tailnet_clients
+    ADD CONSTRAINT tailnet_clients_pkey PRIMARY KEY (id, coordinator_id);
+
+ALTER TABLE ONLY tailnet_coordinators
+    ADD CONSTRAINT tailnet_coordinators_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY template_version_parameters
     ADD CONSTRAINT template_version_parameters_template_version_id_name_key UNIQUE (template_version_id, name);
 
+ALTER TABLE ONLY template_version_promotions
+    ADD CONSTRAINT template_version_promotions_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY template_version_variables
     ADD CONSTRAINT template_version_variables_template_version_id_name_key UNIQUE (template_version_id, name);
 
@@ -756,6 +1025,15 @@ ALTER TABLE ONLY user_links
 ALTER TABLE ONLY users
     ADD CONSTRAINT users_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_agent_metadata
+    ADD CONSTRAINT workspace_agent_metadata_pkey PRIMARY KEY (workspace_agent_id, key);
+
+ALTER TABLE ONLY workspace_agent_port_share_rules
+    ADD CONSTRAINT workspace_agent_port_share_rules_pkey PRIMARY KEY (workspace_agent_id, port);
+
+ALTER TABLE ONLY workspace_agent_port_shares
+    ADD CONSTRAINT workspace_agent_port_shares_pkey PRIMARY KEY (workspace_id, agent_name, port);
+
 ALTER TABLE ONLY workspace_agent_startup_logs
     ADD CONSTRAINT workspace_agent_startup_logs_pkey PRIMARY KEY (id);
 
@@ -780,6 +1058,12 @@ ALTER TABLE ONLY workspace_builds
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_workspace_id_build_number_key UNIQUE (workspace_id, build_number);
 
+ALTER TABLE ONLY workspace_bulk_job_workspaces
+    ADD CONSTRAINT workspace_bulk_job_workspaces_pkey PRIMARY KEY (bulk_job_id, workspace_id);
+
+ALTER TABLE ONLY workspace_bulk_jobs
+    ADD CONSTRAINT workspace_bulk_jobs_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspace_resource_metadata
     ADD CONSTRAINT workspace_resource_metadata_name UNIQUE (workspace_resource_id, key);
 
@@ -789,6 +1073,9 @@ ALTER TABLE ONLY workspace_resource_metadata
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_pkey PRIMARY KEY (id);
 
@@ -820,10 +1107,24 @@ CREATE UNIQUE INDEX idx_users_email ON users USING btree (email) WHERE (deleted
 
 CREATE UNIQUE INDEX idx_users_username ON users USING btree (username) WHERE (deleted = false);
 
+CREATE INDEX notification_webhook_deliveries_webhook_id_created_at_idx ON notification_webhook_deliveries USING btree (webhook_id, created_at DESC);
+
 CREATE INDEX provisioner_job_logs_id_job_id_idx ON provisioner_job_logs USING btree (job_id, id);
 
 CREATE INDEX provisioner_jobs_started_at_idx ON provisioner_jobs USING btree (started_at) WHERE (started_at IS NULL);
 
+CREATE INDEX rate_limit_counters_expires_at_idx ON rate_limit_counters USING btree (expires_at);
+
+CREATE INDEX tailnet_agents_coordinator_id_idx ON tailnet_agents USING btree (coordinator_id);
+
+CREATE INDEX tailnet_clients_agent_id_idx ON tailnet_clients USING btree (agent_id);
+
+CREATE INDEX tailnet_clients_coordinator_id_idx ON tailnet_clients USING btree (coordinator_id);
+
+CREATE UNIQUE INDEX template_version_promotions_pending_idx ON template_version_promotions USING btree (template_version_id) WHERE (status = 'pending'::template_version_promotion_status);
+
+CREATE INDEX template_version_promotions_template_id_idx ON template_version_promotions USING btree (template_id, created_at DESC);
+
 CREATE UNIQUE INDEX templates_organization_id_name_idx ON templates USING btree (organization_id, lower((name)::text)) WHERE (deleted = false);
 
 CREATE UNIQUE INDEX users_email_lower_idx ON users USING btree (lower(email)) WHERE (deleted = false);
@@ -838,6 +1139,8 @@ CREATE INDEX workspace_agents_resource_id_idx ON workspace_agents USING btree (r
 
 CREATE INDEX workspace_resources_job_id_idx ON workspace_resources USING btree (job_id);
 
+CREATE INDEX workspace_session_recordings_workspace_id_idx ON workspace_session_recordings USING btree (workspace_id, started_at DESC);
+
 CREATE UNIQUE INDEX workspaces_owner_id_lower_idx ON workspaces USING btree (owner_id, lower((name)::text)) WHERE (deleted = false);
 
 ALTER TABLE ONLY api_keys
@@ -855,6 +1158,9 @@ ALTER TABLE ONLY group_members
 ALTER TABLE ONLY groups
     ADD CONSTRAINT groups_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY notification_webhook_deliveries
+    ADD CONSTRAINT notification_webhook_deliveries_webhook_id_fkey FOREIGN KEY (webhook_id) REFERENCES notification_webhooks(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY organization_members
     ADD CONSTRAINT organization_members_organization_id_uuid_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
@@ -867,12 +1173,33 @@ ALTER TABLE ONLY parameter_schemas
 ALTER TABLE ONLY provisioner_job_logs
     ADD CONSTRAINT provisioner_job_logs_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY provisioner_job_resource_changes
+    ADD CONSTRAINT provisioner_job_resource_changes_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY provisioner_jobs
     ADD CONSTRAINT provisioner_jobs_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY tailnet_agents
+    ADD CONSTRAINT tailnet_agents_coordinator_id_fkey FOREIGN KEY (coordinator_id) REFERENCES tailnet_coordinators(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY tailnet_clients
+    ADD CONSTRAINT tailnet_clients_coordinator_id_fkey FOREIGN KEY (coordinator_id) REFERENCES tailnet_coordinators(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY template_version_parameters
     ADD CONSTRAINT template_version_parameters_template_version_id_fkey FOREIGN KEY (template_version_id) REFERENCES template_versions(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY template_version_promotions
+    ADD CONSTRAINT template_version_promotions_dry_run_job_id_fkey FOREIGN KEY (dry_run_job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY template_version_promotions
+    ADD CONSTRAINT template_version_promotions_requester_id_fkey FOREIGN KEY (requester_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY template_version_promotions
+    ADD CONSTRAINT template_version_promotions_template_id_fkey FOREIGN KEY (template_id) REFERENCES templates(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY template_version_promotions
+    ADD CONSTRAINT template_version_promotions_template_version_id_fkey FOREIGN KEY (template_version_id) REFERENCES template_versions(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY template_version_variables
     ADD CONSTRAINT template_version_variables_template_version_id_fkey FOREIGN KEY (template_version_id) REFERENCES template_versions(id) ON DELETE CASCADE;
 
@@ -894,6 +1221,15 @@ ALTER TABLE ONLY templates
 ALTER TABLE ONLY user_links
     ADD CONSTRAINT user_links_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_agent_metadata
+    ADD CONSTRAINT workspace_agent_metadata_workspace_agent_id_fkey FOREIGN KEY (workspace_agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_agent_port_share_rules
+    ADD CONSTRAINT workspace_agent_port_share_rules_workspace_agent_id_fkey FOREIGN KEY (workspace_agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_agent_port_shares
+    ADD CONSTRAINT workspace_agent_port_shares_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_agent_startup_logs
     ADD CONSTRAINT workspace_agent_startup_logs_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
 
@@ -915,12 +1251,30 @@ ALTER TABLE ONLY workspace_builds
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_bulk_job_workspaces
+    ADD CONSTRAINT workspace_bulk_job_workspaces_bulk_job_id_fkey FOREIGN KEY (bulk_job_id) REFERENCES workspace_bulk_jobs(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_bulk_job_workspaces
+    ADD CONSTRAINT workspace_bulk_job_workspaces_workspace_build_id_fkey FOREIGN KEY (workspace_build_id) REFERENCES workspace_builds(id) ON DELETE SET NULL;
+
+ALTER TABLE ONLY workspace_bulk_job_workspaces
+    ADD CONSTRAINT workspace_bulk_job_workspaces_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_bulk_jobs
+    ADD CONSTRAINT workspace_bulk_jobs_initiator_id_fkey FOREIGN KEY (initiator_id) REFERENCES users(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_resource_metadata
     ADD CONSTRAINT workspace_resource_metadata_workspace_resource_id_fkey FOREIGN KEY (workspace_resource_id) REFERENCES workspace_resources(id) ON DELETE CASCADE;
 
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT;
 
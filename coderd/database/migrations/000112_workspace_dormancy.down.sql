@@ -0,0 +1,6 @@
+ALTER TABLE "templates" DROP COLUMN "delete_ttl";
+ALTER TABLE "templates" DROP COLUMN "inactivity_ttl";
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS". Convert builds using the new values to the closest existing reason.
+UPDATE workspace_builds SET reason = 'autostop' WHERE reason IN ('dormancy', 'autodelete');
@@ -0,0 +1,8 @@
+ALTER TABLE "templates" ADD COLUMN "inactivity_ttl" bigint DEFAULT '0'::bigint NOT NULL;
+ALTER TABLE "templates" ADD COLUMN "delete_ttl" bigint DEFAULT '0'::bigint NOT NULL;
+
+COMMENT ON COLUMN templates.inactivity_ttl IS 'The duration a running workspace may go unused before it is stopped automatically.';
+COMMENT ON COLUMN templates.delete_ttl IS 'The duration a workspace may stay stopped and unused before it is deleted automatically.';
+
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'dormancy';
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'autodelete';
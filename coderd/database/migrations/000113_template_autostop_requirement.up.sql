@@ -0,0 +1,3 @@
+ALTER TABLE "templates" ADD COLUMN "autostop_requirement" text DEFAULT ''::text NOT NULL;
+
+COMMENT ON COLUMN templates.autostop_requirement IS 'A weekly cron schedule without a timezone. Running workspaces must be stopped at the next occurrence of the schedule in the owner''s timezone.';
@@ -0,0 +1,3 @@
+DROP TABLE notification_webhook_deliveries;
+DROP TABLE notification_webhooks;
+DROP TYPE notification_event_type;
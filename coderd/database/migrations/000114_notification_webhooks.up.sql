@@ -0,0 +1,45 @@
+CREATE TYPE notification_event_type AS ENUM (
+	'workspace_build_succeeded',
+	'workspace_build_failed',
+	'workspace_autostop_imminent',
+	'workspace_deleted',
+	'template_version_promoted'
+);
+
+CREATE TABLE notification_webhooks (
+	id uuid NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	name text NOT NULL,
+	url text NOT NULL,
+	events notification_event_type[] NOT NULL,
+	payload_template text DEFAULT ''::text NOT NULL,
+	secret text DEFAULT ''::text NOT NULL,
+	enabled boolean DEFAULT true NOT NULL,
+	PRIMARY KEY (id),
+	CONSTRAINT notification_webhooks_name_key UNIQUE (name)
+);
+
+COMMENT ON COLUMN notification_webhooks.events IS 'The event types that are sent to the webhook.';
+COMMENT ON COLUMN notification_webhooks.payload_template IS 'A Go text/template used to render the request body. The JSON encoded event is sent if empty.';
+COMMENT ON COLUMN notification_webhooks.secret IS 'Used to sign request bodies with HMAC-SHA256 if set.';
+
+CREATE TABLE notification_webhook_deliveries (
+	id uuid NOT NULL,
+	webhook_id uuid NOT NULL REFERENCES notification_webhooks (id) ON DELETE CASCADE,
+	event_id uuid NOT NULL,
+	event_type notification_event_type NOT NULL,
+	attempt integer NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	completed_at timestamp with time zone,
+	request_body text NOT NULL,
+	status_code integer DEFAULT 0 NOT NULL,
+	error text DEFAULT ''::text NOT NULL,
+	PRIMARY KEY (id),
+	CONSTRAINT notification_webhook_deliveries_webhook_id_event_id_attempt_key UNIQUE (webhook_id, event_id, attempt)
+);
+
+COMMENT ON COLUMN notification_webhook_deliveries.attempt IS 'Starts at 1. Every replica receives every event, so the unique constraint ensures each attempt is made once.';
+COMMENT ON COLUMN notification_webhook_deliveries.status_code IS 'The HTTP status code of the response, or 0 if no response was received.';
+
+CREATE INDEX notification_webhook_deliveries_webhook_id_created_at_idx ON notification_webhook_deliveries USING btree (webhook_id, created_at DESC);
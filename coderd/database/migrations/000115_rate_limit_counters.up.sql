@@ -0,0 +1,13 @@
+-- Counters of the fixed windows used by rate limit policies when they are
+-- shared between replicas. Each key only keeps its current window. Losing
+-- the counters on a crash only resets the current windows, so the table
+-- doesn't need to be logged.
+CREATE UNLOGGED TABLE rate_limit_counters (
+	key text NOT NULL,
+	window_start timestamp with time zone NOT NULL,
+	expires_at timestamp with time zone NOT NULL,
+	count bigint DEFAULT 0 NOT NULL,
+	PRIMARY KEY (key)
+);
+
+CREATE INDEX rate_limit_counters_expires_at_idx ON rate_limit_counters USING btree (expires_at);
@@ -0,0 +1,4 @@
+ALTER TABLE provisioner_daemons
+	DROP COLUMN last_seen_at,
+	DROP COLUMN version,
+	DROP COLUMN current_job_id;
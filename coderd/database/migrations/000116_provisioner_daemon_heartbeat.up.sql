@@ -0,0 +1,8 @@
+ALTER TABLE provisioner_daemons
+	ADD COLUMN last_seen_at timestamp with time zone,
+	ADD COLUMN version text DEFAULT ''::text NOT NULL,
+	ADD COLUMN current_job_id uuid;
+
+COMMENT ON COLUMN provisioner_daemons.last_seen_at IS 'The last time the daemon sent a heartbeat. Daemons that stop sending heartbeats are reaped.';
+
+COMMENT ON COLUMN provisioner_daemons.current_job_id IS 'The job the daemon reported running in its last heartbeat.';
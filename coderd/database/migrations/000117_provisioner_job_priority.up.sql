@@ -0,0 +1,4 @@
+ALTER TABLE provisioner_jobs
+	ADD COLUMN priority integer DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN provisioner_jobs.priority IS 'Pending jobs with a higher priority are acquired first. Jobs with the same priority are acquired in the order they were created.';
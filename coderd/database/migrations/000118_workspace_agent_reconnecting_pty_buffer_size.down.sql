@@ -0,0 +1 @@
+ALTER TABLE workspace_agents DROP COLUMN reconnecting_pty_buffer_size;
@@ -0,0 +1,3 @@
+ALTER TABLE workspace_agents ADD COLUMN reconnecting_pty_buffer_size integer NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN workspace_agents.reconnecting_pty_buffer_size IS 'The number of bytes of output each reconnecting PTY keeps for replay, 0 means the agent default.';
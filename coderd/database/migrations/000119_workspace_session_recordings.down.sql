@@ -0,0 +1,7 @@
+DROP TABLE workspace_session_recordings;
+DROP TYPE workspace_session_type;
+
+ALTER TABLE workspace_agents DROP COLUMN session_recording;
+
+-- It's not possible to drop enum values from enum types, so the resource
+-- type is left in place.
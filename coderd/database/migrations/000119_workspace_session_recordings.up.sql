@@ -0,0 +1,26 @@
+ALTER TABLE workspace_agents ADD COLUMN session_recording boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN workspace_agents.session_recording IS 'Whether the agent records SSH and web terminal sessions.';
+
+CREATE TYPE workspace_session_type AS ENUM (
+	'ssh',
+	'reconnecting_pty'
+);
+
+CREATE TABLE workspace_session_recordings (
+	id uuid NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+	session_type workspace_session_type NOT NULL,
+	started_at timestamp with time zone NOT NULL,
+	ended_at timestamp with time zone NOT NULL,
+	data bytea NOT NULL,
+	PRIMARY KEY (id)
+);
+
+COMMENT ON COLUMN workspace_session_recordings.data IS 'The session in asciicast v2 format.';
+
+CREATE INDEX workspace_session_recordings_workspace_id_idx ON workspace_session_recordings USING btree (workspace_id, started_at DESC);
+
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'workspace_session_recording';
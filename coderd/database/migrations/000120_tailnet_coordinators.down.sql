@@ -0,0 +1,5 @@
+DROP TABLE tailnet_clients;
+
+DROP TABLE tailnet_agents;
+
+DROP TABLE tailnet_coordinators;
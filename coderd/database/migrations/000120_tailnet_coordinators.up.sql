@@ -0,0 +1,34 @@
+CREATE TABLE tailnet_coordinators (
+	id uuid NOT NULL,
+	heartbeat_at timestamp with time zone NOT NULL,
+	PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE tailnet_coordinators IS 'The high availability tailnet coordinators of each replica. Coordinators that stop heartbeating are deleted along with their connections.';
+
+CREATE TABLE tailnet_agents (
+	id uuid NOT NULL,
+	coordinator_id uuid NOT NULL REFERENCES tailnet_coordinators (id) ON DELETE CASCADE,
+	updated_at timestamp with time zone NOT NULL,
+	node jsonb NOT NULL,
+	PRIMARY KEY (id, coordinator_id)
+);
+
+COMMENT ON TABLE tailnet_agents IS 'The latest node of each agent connected to a coordinator.';
+
+CREATE INDEX tailnet_agents_coordinator_id_idx ON tailnet_agents USING btree (coordinator_id);
+
+CREATE TABLE tailnet_clients (
+	id uuid NOT NULL,
+	coordinator_id uuid NOT NULL REFERENCES tailnet_coordinators (id) ON DELETE CASCADE,
+	agent_id uuid NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	node jsonb NOT NULL,
+	PRIMARY KEY (id, coordinator_id)
+);
+
+COMMENT ON TABLE tailnet_clients IS 'The latest node of each client connected to a coordinator, and the agent it connects to.';
+
+CREATE INDEX tailnet_clients_agent_id_idx ON tailnet_clients USING btree (agent_id);
+
+CREATE INDEX tailnet_clients_coordinator_id_idx ON tailnet_clients USING btree (coordinator_id);
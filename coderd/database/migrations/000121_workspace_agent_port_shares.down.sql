@@ -0,0 +1,2 @@
+DROP TABLE workspace_agent_port_shares;
+DROP TABLE workspace_agent_port_share_rules;
@@ -0,0 +1,20 @@
+CREATE TABLE workspace_agent_port_share_rules (
+	workspace_agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+	port integer NOT NULL,
+	share_level app_sharing_level NOT NULL,
+	PRIMARY KEY (workspace_agent_id, port)
+);
+
+COMMENT ON TABLE workspace_agent_port_share_rules IS 'Port sharing rules defined by the template of a workspace agent.';
+
+CREATE TABLE workspace_agent_port_shares (
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	agent_name text NOT NULL,
+	port integer NOT NULL,
+	share_level app_sharing_level NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	PRIMARY KEY (workspace_id, agent_name, port)
+);
+
+COMMENT ON TABLE workspace_agent_port_shares IS 'Ports shared by workspace owners. Shares are keyed by agent name so they persist across workspace builds.';
@@ -0,0 +1,2 @@
+DROP TABLE provisioner_job_resource_changes;
+DROP TYPE resource_change_action;
@@ -0,0 +1,19 @@
+CREATE TYPE resource_change_action AS ENUM (
+	'create',
+	'update',
+	'replace',
+	'delete'
+);
+
+CREATE TABLE provisioner_job_resource_changes (
+	job_id uuid NOT NULL REFERENCES provisioner_jobs (id) ON DELETE CASCADE,
+	address text NOT NULL,
+	type text NOT NULL,
+	name text NOT NULL,
+	action resource_change_action NOT NULL,
+	daily_cost integer NOT NULL DEFAULT 0,
+	prior_daily_cost integer NOT NULL DEFAULT 0,
+	PRIMARY KEY (job_id, address)
+);
+
+COMMENT ON TABLE provisioner_job_resource_changes IS 'Changes to resources planned by a provisioner job, with the daily cost of each resource before and after the change.';
@@ -0,0 +1,14 @@
+ALTER DOMAIN provisioner_type RENAME TO new_provisioner_type;
+
+CREATE TYPE provisioner_type AS ENUM (
+	'echo',
+	'terraform'
+);
+
+-- This fails if any rows target a provisioner plugin, since they can't be
+-- represented by the enum.
+ALTER TABLE provisioner_daemons ALTER COLUMN provisioners TYPE provisioner_type[] USING (provisioners::text[]::provisioner_type[]);
+ALTER TABLE provisioner_jobs ALTER COLUMN provisioner TYPE provisioner_type USING (provisioner::text::provisioner_type);
+ALTER TABLE templates ALTER COLUMN provisioner TYPE provisioner_type USING (provisioner::text::provisioner_type);
+
+DROP DOMAIN new_provisioner_type;
@@ -0,0 +1,14 @@
+-- Provisioner types are no longer a fixed set, since external provisioner
+-- plugins can be declared in the deployment config. The enum is replaced
+-- with a domain over text, and types are validated by coderd instead.
+ALTER TYPE provisioner_type RENAME TO old_provisioner_type;
+
+CREATE DOMAIN provisioner_type AS text;
+
+COMMENT ON DOMAIN provisioner_type IS 'The name of a built-in provisioner, or of an external provisioner plugin declared in the deployment config.';
+
+ALTER TABLE provisioner_daemons ALTER COLUMN provisioners TYPE provisioner_type[] USING (provisioners::text[]::provisioner_type[]);
+ALTER TABLE provisioner_jobs ALTER COLUMN provisioner TYPE provisioner_type USING (provisioner::text::provisioner_type);
+ALTER TABLE templates ALTER COLUMN provisioner TYPE provisioner_type USING (provisioner::text::provisioner_type);
+
+DROP TYPE old_provisioner_type;
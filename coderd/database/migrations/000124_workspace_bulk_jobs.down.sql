@@ -0,0 +1,4 @@
+DROP TABLE workspace_bulk_job_workspaces;
+DROP TABLE workspace_bulk_jobs;
+DROP TYPE workspace_bulk_job_status;
+DROP TYPE workspace_bulk_job_action;
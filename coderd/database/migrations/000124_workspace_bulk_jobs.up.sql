@@ -0,0 +1,42 @@
+CREATE TYPE workspace_bulk_job_action AS ENUM (
+	'start',
+	'stop',
+	'update',
+	'delete'
+);
+
+CREATE TYPE workspace_bulk_job_status AS ENUM (
+	'pending',
+	'running',
+	'succeeded',
+	'failed',
+	'skipped'
+);
+
+CREATE TABLE workspace_bulk_jobs (
+	id uuid NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	completed_at timestamp with time zone,
+	initiator_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	action workspace_bulk_job_action NOT NULL,
+	filter text NOT NULL,
+	batch_size integer NOT NULL,
+	status workspace_bulk_job_status NOT NULL,
+	error text NOT NULL DEFAULT '',
+	PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE workspace_bulk_jobs IS 'Builds of many workspaces matching a search filter, created in batches.';
+
+CREATE TABLE workspace_bulk_job_workspaces (
+	bulk_job_id uuid NOT NULL REFERENCES workspace_bulk_jobs (id) ON DELETE CASCADE,
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	workspace_build_id uuid REFERENCES workspace_builds (id) ON DELETE SET NULL,
+	updated_at timestamp with time zone NOT NULL,
+	status workspace_bulk_job_status NOT NULL,
+	error text NOT NULL DEFAULT '',
+	PRIMARY KEY (bulk_job_id, workspace_id)
+);
+
+COMMENT ON COLUMN workspace_bulk_job_workspaces.status IS 'Workspaces are skipped if they are already in the state the action would put them in.';
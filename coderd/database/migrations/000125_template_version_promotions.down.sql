@@ -0,0 +1,7 @@
+DROP TABLE template_version_promotions;
+DROP TYPE template_version_promotion_status;
+
+ALTER TABLE templates DROP COLUMN required_promotion_approvals;
+
+-- It's not possible to drop enum values from enum types, so the resource
+-- type is left in place.
@@ -0,0 +1,33 @@
+ALTER TABLE templates ADD COLUMN required_promotion_approvals integer NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN templates.required_promotion_approvals IS 'The number of approvals a template version needs before it can become the active version. Zero disables the promotion workflow.';
+
+CREATE TYPE template_version_promotion_status AS ENUM (
+	'pending',
+	'promoted',
+	'superseded'
+);
+
+CREATE TABLE template_version_promotions (
+	id uuid NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	template_id uuid NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+	template_version_id uuid NOT NULL REFERENCES template_versions (id) ON DELETE CASCADE,
+	requester_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	dry_run_job_id uuid NOT NULL REFERENCES provisioner_jobs (id) ON DELETE CASCADE,
+	approver_ids uuid[] NOT NULL DEFAULT '{}',
+	status template_version_promotion_status NOT NULL,
+	promoted_at timestamp with time zone,
+	PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE template_version_promotions IS 'Requests to make a template version active, which are completed once the dry-run succeeds and enough users approve.';
+
+COMMENT ON COLUMN template_version_promotions.status IS 'Pending promotions are superseded when another version of the template is promoted.';
+
+CREATE UNIQUE INDEX template_version_promotions_pending_idx ON template_version_promotions USING btree (template_version_id) WHERE (status = 'pending'::template_version_promotion_status);
+
+CREATE INDEX template_version_promotions_template_id_idx ON template_version_promotions USING btree (template_id, created_at DESC);
+
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'template_version_promotion';
@@ -0,0 +1,15 @@
+INSERT INTO workspace_agent_metadata (
+	workspace_agent_id,
+	display_name,
+	key,
+	script,
+	timeout,
+	interval
+) VALUES (
+	'45e89705-e09d-4850-bcec-f9a937f5d78d',
+	'CPU Usage',
+	'cpu',
+	'top -bn1 | grep Cpu',
+	1,
+	5
+);
@@ -0,0 +1,45 @@
+INSERT INTO notification_webhooks (
+	id,
+	created_at,
+	updated_at,
+	name,
+	url,
+	events,
+	payload_template,
+	secret,
+	enabled
+) VALUES (
+	'f0c1a8e4-7a6f-4b1e-9d3a-2c5b8e7f6a10',
+	'2023-05-10 10:00:00+00',
+	'2023-05-10 10:00:00+00',
+	'builds',
+	'https://example.com/hooks/coder',
+	'{workspace_build_succeeded,workspace_build_failed}',
+	'',
+	'',
+	true
+);
+
+INSERT INTO notification_webhook_deliveries (
+	id,
+	webhook_id,
+	event_id,
+	event_type,
+	attempt,
+	created_at,
+	completed_at,
+	request_body,
+	status_code,
+	error
+) VALUES (
+	'3b2d6f51-9c0e-4e8a-a7d4-6f1e2b3c4d50',
+	'f0c1a8e4-7a6f-4b1e-9d3a-2c5b8e7f6a10',
+	'8e4c2a10-5b7d-4f3e-9a1c-0d2e4f6a8b90',
+	'workspace_build_succeeded',
+	1,
+	'2023-05-10 10:01:00+00',
+	'2023-05-10 10:01:01+00',
+	'{}',
+	200,
+	''
+);
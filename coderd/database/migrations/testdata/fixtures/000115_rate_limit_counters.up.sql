@@ -0,0 +1,11 @@
+INSERT INTO rate_limit_counters (
+	key,
+	window_start,
+	expires_at,
+	count
+) VALUES (
+	'user:30095c71-380b-457a-8995-97b8ee6e5307',
+	'2023-05-10 10:00:00+00',
+	'2023-05-10 10:01:00+00',
+	3
+);
@@ -0,0 +1,19 @@
+INSERT INTO workspace_session_recordings (
+	id,
+	created_at,
+	workspace_id,
+	agent_id,
+	session_type,
+	started_at,
+	ended_at,
+	data
+) VALUES (
+	'c9a7e5d3-1b2f-4a6c-8e0d-3f5a7b9c1d20',
+	'2023-05-10 10:05:00+00',
+	'3a9a1feb-e89d-457c-9d53-ac751b198ebe',
+	'5f8e48e4-1304-45bd-b91a-ab12c8bfc20f',
+	'ssh',
+	'2023-05-10 10:00:00+00',
+	'2023-05-10 10:05:00+00',
+	'\x7b2276657273696f6e223a327d0a'
+);
@@ -0,0 +1,33 @@
+INSERT INTO tailnet_coordinators (
+	id,
+	heartbeat_at
+) VALUES (
+	'a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c50',
+	'2023-05-10 10:00:00+00'
+);
+
+INSERT INTO tailnet_agents (
+	id,
+	coordinator_id,
+	updated_at,
+	node
+) VALUES (
+	'5f8e48e4-1304-45bd-b91a-ab12c8bfc20f',
+	'a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c50',
+	'2023-05-10 10:00:00+00',
+	'{"id": 1, "preferred_derp": 999}'
+);
+
+INSERT INTO tailnet_clients (
+	id,
+	coordinator_id,
+	agent_id,
+	updated_at,
+	node
+) VALUES (
+	'd4e5f6a7-b8c9-4d0e-9f1a-2b3c4d5e6f70',
+	'a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c50',
+	'5f8e48e4-1304-45bd-b91a-ab12c8bfc20f',
+	'2023-05-10 10:00:00+00',
+	'{"id": 2, "preferred_derp": 999}'
+);
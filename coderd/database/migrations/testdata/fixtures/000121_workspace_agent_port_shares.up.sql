@@ -0,0 +1,25 @@
+INSERT INTO workspace_agent_port_share_rules (
+	workspace_agent_id,
+	port,
+	share_level
+) VALUES (
+	'5f8e48e4-1304-45bd-b91a-ab12c8bfc20f',
+	8080,
+	'authenticated'
+);
+
+INSERT INTO workspace_agent_port_shares (
+	workspace_id,
+	agent_name,
+	port,
+	share_level,
+	created_at,
+	updated_at
+) VALUES (
+	'3a9a1feb-e89d-457c-9d53-ac751b198ebe',
+	'main',
+	8080,
+	'authenticated',
+	'2023-05-10 10:00:00+00',
+	'2023-05-10 10:00:00+00'
+);
@@ -0,0 +1,17 @@
+INSERT INTO provisioner_job_resource_changes (
+	job_id,
+	address,
+	type,
+	name,
+	action,
+	daily_cost,
+	prior_daily_cost
+) VALUES (
+	'3013ee6d-3c8f-4dcf-8271-01fd1e88aba6',
+	'docker_container.workspace[0]',
+	'docker_container',
+	'workspace',
+	'create',
+	10,
+	0
+);
@@ -0,0 +1,39 @@
+INSERT INTO workspace_bulk_jobs (
+	id,
+	created_at,
+	updated_at,
+	completed_at,
+	initiator_id,
+	action,
+	filter,
+	batch_size,
+	status,
+	error
+) VALUES (
+	'b7c8d9e0-f1a2-4b3c-8d4e-5f6a7b8c9d10',
+	'2023-05-10 10:00:00+00',
+	'2023-05-10 10:02:00+00',
+	'2023-05-10 10:02:00+00',
+	'30095c71-380b-457a-8995-97b8ee6e5307',
+	'stop',
+	'owner:admin',
+	10,
+	'succeeded',
+	''
+);
+
+INSERT INTO workspace_bulk_job_workspaces (
+	bulk_job_id,
+	workspace_id,
+	workspace_build_id,
+	updated_at,
+	status,
+	error
+) VALUES (
+	'b7c8d9e0-f1a2-4b3c-8d4e-5f6a7b8c9d10',
+	'3a9a1feb-e89d-457c-9d53-ac751b198ebe',
+	'ea36844d-8eb6-41a2-a237-e9a8ae3f99ea',
+	'2023-05-10 10:02:00+00',
+	'succeeded',
+	''
+);
@@ -0,0 +1,23 @@
+INSERT INTO template_version_promotions (
+	id,
+	created_at,
+	updated_at,
+	template_id,
+	template_version_id,
+	requester_id,
+	dry_run_job_id,
+	approver_ids,
+	status,
+	promoted_at
+) VALUES (
+	'e1f2a3b4-c5d6-4e7f-8a9b-0c1d2e3f4a50',
+	'2023-05-10 10:00:00+00',
+	'2023-05-10 10:03:00+00',
+	'4cc1f466-f326-477e-8762-9d0c6781fc56',
+	'920baba5-4c64-4686-8b7d-d1bef5683eae',
+	'30095c71-380b-457a-8995-97b8ee6e5307',
+	'3013ee6d-3c8f-4dcf-8271-01fd1e88aba6',
+	'{0ed9befc-4911-4ccf-a8e2-559bf72daa94}',
+	'promoted',
+	'2023-05-10 10:03:00+00'
+);
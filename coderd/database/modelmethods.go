@@ -220,6 +220,10 @@ func (l License) RBACObject() rbac.Object {
 	return rbac.ResourceLicense.WithIDString(strconv.FormatInt(int64(l.ID), 10))
 }
 
+func (w NotificationWebhook) RBACObject() rbac.Object {
+	return rbac.ResourceNotificationWebhook.WithID(w.ID)
+}
+
 type WorkspaceAgentConnectionStatus struct {
 	Status           WorkspaceAgentStatus `json:"status"`
 	FirstConnectedAt *time.Time           `json:"first_connected_at"`
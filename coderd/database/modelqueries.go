@@ -78,6 +78,10 @@ func (q *sqlQuerier) GetAuthorizedTemplates(ctx context.Context, arg GetTemplate
 			&i.DisplayName,
 			&i.AllowUserCancelWorkspaceJobs,
 			&i.MaxTTL,
+			&i.InactivityTTL,
+			&i.DeleteTTL,
+			&i.AutostopRequirement,
+			&i.RequiredPromotionApprovals,
 		); err != nil {
 			return nil, err
 		}
@@ -212,9 +212,11 @@ func AllAuditActionValues() []AuditAction {
 type BuildReason string
 
 const (
-	BuildReasonInitiator BuildReason = "initiator"
-	BuildReasonAutostart BuildReason = "autostart"
-	BuildReasonAutostop  BuildReason = "autostop"
+	BuildReasonInitiator  BuildReason = "initiator"
+	BuildReasonAutostart  BuildReason = "autostart"
+	BuildReasonAutostop   BuildReason = "autostop"
+	BuildReasonDormancy   BuildReason = "dormancy"
+	BuildReasonAutodelete BuildReason = "autodelete"
 )
 
 func (e *BuildReason) Scan(src interface{}) error {
@@ -256,7 +258,9 @@ func (e BuildReason) Valid() bool {
 	switch e {
 	case BuildReasonInitiator,
 		BuildReasonAutostart,
-		BuildReasonAutostop:
+		BuildReasonAutostop,
+		BuildReasonDormancy,
+		BuildReasonAutodelete:
 		return true
 	}
 	return false
@@ -267,6 +271,8 @@ func AllBuildReasonValues() []BuildReason {
 		BuildReasonInitiator,
 		BuildReasonAutostart,
 		BuildReasonAutostop,
+		BuildReasonDormancy,
+		BuildReasonAutodelete,
 	}
 }
 
@@ -459,6 +465,73 @@ func AllLoginTypeValues() []LoginType {
 	}
 }
 
+type NotificationEventType string
+
+const (
+	NotificationEventTypeWorkspaceBuildSucceeded   NotificationEventType = "workspace_build_succeeded"
+	NotificationEventTypeWorkspaceBuildFailed      NotificationEventType = "workspace_build_failed"
+	NotificationEventTypeWorkspaceAutostopImminent NotificationEventType = "workspace_autostop_imminent"
+	NotificationEventTypeWorkspaceDeleted          NotificationEventType = "workspace_deleted"
+	NotificationEventTypeTemplateVersionPromoted   NotificationEventType = "template_version_promoted"
+)
+
+func (e *NotificationEventType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotificationEventType(s)
+	case string:
+		*e = NotificationEventType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotificationEventType: %T", src)
+	}
+	return nil
+}
+
+type NullNotificationEventType struct {
+	NotificationEventType NotificationEventType `json:"notification_event_type"`
+	Valid                 bool                  `json:"valid"` // Valid is true if NotificationEventType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotificationEventType) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotificationEventType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotificationEventType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotificationEventType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotificationEventType), nil
+}
+
+func (e NotificationEventType) Valid() bool {
+	switch e {
+	case NotificationEventTypeWorkspaceBuildSucceeded,
+		NotificationEventTypeWorkspaceBuildFailed,
+		NotificationEventTypeWorkspaceAutostopImminent,
+		NotificationEventTypeWorkspaceDeleted,
+		NotificationEventTypeTemplateVersionPromoted:
+		return true
+	}
+	return false
+}
+
+func AllNotificationEventTypeValues() []NotificationEventType {
+	return []NotificationEventType{
+		NotificationEventTypeWorkspaceBuildSucceeded,
+		NotificationEventTypeWorkspaceBuildFailed,
+		NotificationEventTypeWorkspaceAutostopImminent,
+		NotificationEventTypeWorkspaceDeleted,
+		NotificationEventTypeTemplateVersionPromoted,
+	}
+}
+
 type ParameterDestinationScheme string
 
 const (
@@ -813,77 +886,85 @@ func AllProvisionerStorageMethodValues() []ProvisionerStorageMethod {
 	}
 }
 
-type ProvisionerType string
+type ResourceChangeAction string
 
 const (
-	ProvisionerTypeEcho      ProvisionerType = "echo"
-	ProvisionerTypeTerraform ProvisionerType = "terraform"
+	ResourceChangeActionCreate  ResourceChangeAction = "create"
+	ResourceChangeActionUpdate  ResourceChangeAction = "update"
+	ResourceChangeActionReplace ResourceChangeAction = "replace"
+	ResourceChangeActionDelete  ResourceChangeAction = "delete"
 )
 
-func (e *ProvisionerType) Scan(src interface{}) error {
+func (e *ResourceChangeAction) Scan(src interface{}) error {
 	switch s := src.(type) {
 	case []byte:
-		*e = ProvisionerType(s)
+		*e = ResourceChangeAction(s)
 	case string:
-		*e = ProvisionerType(s)
+		*e = ResourceChangeAction(s)
 	default:
-		return fmt.Errorf("unsupported scan type for ProvisionerType: %T", src)
+		return fmt.Errorf("unsupported scan type for ResourceChangeAction: %T", src)
 	}
 	return nil
 }
 
-type NullProvisionerType struct {
-	ProvisionerType ProvisionerType
-	Valid           bool // Valid is true if ProvisionerType is not NULL
+type NullResourceChangeAction struct {
+	ResourceChangeAction ResourceChangeAction `json:"resource_change_action"`
+	Valid                bool                 `json:"valid"` // Valid is true if ResourceChangeAction is not NULL
 }
 
 // Scan implements the Scanner interface.
-func (ns *NullProvisionerType) Scan(value interface{}) error {
+func (ns *NullResourceChangeAction) Scan(value interface{}) error {
 	if value == nil {
-		ns.ProvisionerType, ns.Valid = "", false
+		ns.ResourceChangeAction, ns.Valid = "", false
 		return nil
 	}
 	ns.Valid = true
-	return ns.ProvisionerType.Scan(value)
+	return ns.ResourceChangeAction.Scan(value)
 }
 
 // Value implements the driver Valuer interface.
-func (ns NullProvisionerType) Value() (driver.Value, error) {
+func (ns NullResourceChangeAction) Value() (driver.Value, error) {
 	if !ns.Valid {
 		return nil, nil
 	}
-	return string(ns.ProvisionerType), nil
+	return string(ns.ResourceChangeAction), nil
 }
 
-func (e ProvisionerType) Valid() bool {
+func (e ResourceChangeAction) Valid() bool {
 	switch e {
-	case ProvisionerTypeEcho,
-		ProvisionerTypeTerraform:
+	case ResourceChangeActionCreate,
+		ResourceChangeActionUpdate,
+		ResourceChangeActionReplace,
+		ResourceChangeActionDelete:
 		return true
 	}
 	return false
 }
 
-func AllProvisionerTypeValues() []ProvisionerType {
-	return []ProvisionerType{
-		ProvisionerTypeEcho,
-		ProvisionerTypeTerraform,
+func AllResourceChangeActionValues() []ResourceChangeAction {
+	return []ResourceChangeAction{
+		ResourceChangeActionCreate,
+		ResourceChangeActionUpdate,
+		ResourceChangeActionReplace,
+		ResourceChangeActionDelete,
 	}
 }
 
 type ResourceType string
 
 const (
-	ResourceTypeOrganization    ResourceType = "organization"
-	ResourceTypeTemplate        ResourceType = "template"
-	ResourceTypeTemplateVersion ResourceType = "template_version"
-	ResourceTypeUser            ResourceType = "user"
-	ResourceTypeWorkspace       ResourceType = "workspace"
-	ResourceTypeGitSshKey       ResourceType = "git_ssh_key"
-	ResourceTypeApiKey          ResourceType = "api_key"
-	ResourceTypeGroup           ResourceType = "group"
-	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
-	ResourceTypeLicense         ResourceType = "license"
+	ResourceTypeOrganization              ResourceType = "organization"
+	ResourceTypeTemplate                  ResourceType = "template"
+	ResourceTypeTemplateVersion           ResourceType = "template_version"
+	ResourceTypeUser                      ResourceType = "user"
+	ResourceTypeWorkspace                 ResourceType = "workspace"
+	ResourceTypeGitSshKey                 ResourceType = "git_ssh_key"
+	ResourceTypeApiKey                    ResourceType = "api_key"
+	ResourceTypeGroup                     ResourceType = "group"
+	ResourceTypeWorkspaceBuild            ResourceType = "workspace_build"
+	ResourceTypeLicense                   ResourceType = "license"
+	ResourceTypeWorkspaceSessionRecording ResourceType = "workspace_session_recording"
+	ResourceTypeTemplateVersionPromotion  ResourceType = "template_version_promotion"
 )
 
 func (e *ResourceType) Scan(src interface{}) error {
@@ -932,7 +1013,9 @@ func (e ResourceType) Valid() bool {
 		ResourceTypeApiKey,
 		ResourceTypeGroup,
 		ResourceTypeWorkspaceBuild,
-		ResourceTypeLicense:
+		ResourceTypeLicense,
+		ResourceTypeWorkspaceSessionRecording,
+		ResourceTypeTemplateVersionPromotion:
 		return true
 	}
 	return false
@@ -950,6 +1033,69 @@ func AllResourceTypeValues() []ResourceType {
 		ResourceTypeGroup,
 		ResourceTypeWorkspaceBuild,
 		ResourceTypeLicense,
+		ResourceTypeWorkspaceSessionRecording,
+		ResourceTypeTemplateVersionPromotion,
+	}
+}
+
+type TemplateVersionPromotionStatus string
+
+const (
+	TemplateVersionPromotionStatusPending    TemplateVersionPromotionStatus = "pending"
+	TemplateVersionPromotionStatusPromoted   TemplateVersionPromotionStatus = "promoted"
+	TemplateVersionPromotionStatusSuperseded TemplateVersionPromotionStatus = "superseded"
+)
+
+func (e *TemplateVersionPromotionStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TemplateVersionPromotionStatus(s)
+	case string:
+		*e = TemplateVersionPromotionStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TemplateVersionPromotionStatus: %T", src)
+	}
+	return nil
+}
+
+type NullTemplateVersionPromotionStatus struct {
+	TemplateVersionPromotionStatus TemplateVersionPromotionStatus `json:"template_version_promotion_status"`
+	Valid                          bool                           `json:"valid"` // Valid is true if TemplateVersionPromotionStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTemplateVersionPromotionStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.TemplateVersionPromotionStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TemplateVersionPromotionStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTemplateVersionPromotionStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TemplateVersionPromotionStatus), nil
+}
+
+func (e TemplateVersionPromotionStatus) Valid() bool {
+	switch e {
+	case TemplateVersionPromotionStatusPending,
+		TemplateVersionPromotionStatusPromoted,
+		TemplateVersionPromotionStatusSuperseded:
+		return true
+	}
+	return false
+}
+
+func AllTemplateVersionPromotionStatusValues() []TemplateVersionPromotionStatus {
+	return []TemplateVersionPromotionStatus{
+		TemplateVersionPromotionStatusPending,
+		TemplateVersionPromotionStatusPromoted,
+		TemplateVersionPromotionStatusSuperseded,
 	}
 }
 
@@ -1154,6 +1300,195 @@ func AllWorkspaceAppHealthValues() []WorkspaceAppHealth {
 	}
 }
 
+type WorkspaceBulkJobAction string
+
+const (
+	WorkspaceBulkJobActionStart  WorkspaceBulkJobAction = "start"
+	WorkspaceBulkJobActionStop   WorkspaceBulkJobAction = "stop"
+	WorkspaceBulkJobActionUpdate WorkspaceBulkJobAction = "update"
+	WorkspaceBulkJobActionDelete WorkspaceBulkJobAction = "delete"
+)
+
+func (e *WorkspaceBulkJobAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceBulkJobAction(s)
+	case string:
+		*e = WorkspaceBulkJobAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceBulkJobAction: %T", src)
+	}
+	return nil
+}
+
+type NullWorkspaceBulkJobAction struct {
+	WorkspaceBulkJobAction WorkspaceBulkJobAction `json:"workspace_bulk_job_action"`
+	Valid                  bool                   `json:"valid"` // Valid is true if WorkspaceBulkJobAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWorkspaceBulkJobAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.WorkspaceBulkJobAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WorkspaceBulkJobAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWorkspaceBulkJobAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WorkspaceBulkJobAction), nil
+}
+
+func (e WorkspaceBulkJobAction) Valid() bool {
+	switch e {
+	case WorkspaceBulkJobActionStart,
+		WorkspaceBulkJobActionStop,
+		WorkspaceBulkJobActionUpdate,
+		WorkspaceBulkJobActionDelete:
+		return true
+	}
+	return false
+}
+
+func AllWorkspaceBulkJobActionValues() []WorkspaceBulkJobAction {
+	return []WorkspaceBulkJobAction{
+		WorkspaceBulkJobActionStart,
+		WorkspaceBulkJobActionStop,
+		WorkspaceBulkJobActionUpdate,
+		WorkspaceBulkJobActionDelete,
+	}
+}
+
+type WorkspaceBulkJobStatus string
+
+const (
+	WorkspaceBulkJobStatusPending   WorkspaceBulkJobStatus = "pending"
+	WorkspaceBulkJobStatusRunning   WorkspaceBulkJobStatus = "running"
+	WorkspaceBulkJobStatusSucceeded WorkspaceBulkJobStatus = "succeeded"
+	WorkspaceBulkJobStatusFailed    WorkspaceBulkJobStatus = "failed"
+	WorkspaceBulkJobStatusSkipped   WorkspaceBulkJobStatus = "skipped"
+)
+
+func (e *WorkspaceBulkJobStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceBulkJobStatus(s)
+	case string:
+		*e = WorkspaceBulkJobStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceBulkJobStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWorkspaceBulkJobStatus struct {
+	WorkspaceBulkJobStatus WorkspaceBulkJobStatus `json:"workspace_bulk_job_status"`
+	Valid                  bool                   `json:"valid"` // Valid is true if WorkspaceBulkJobStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWorkspaceBulkJobStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WorkspaceBulkJobStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WorkspaceBulkJobStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWorkspaceBulkJobStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WorkspaceBulkJobStatus), nil
+}
+
+func (e WorkspaceBulkJobStatus) Valid() bool {
+	switch e {
+	case WorkspaceBulkJobStatusPending,
+		WorkspaceBulkJobStatusRunning,
+		WorkspaceBulkJobStatusSucceeded,
+		WorkspaceBulkJobStatusFailed,
+		WorkspaceBulkJobStatusSkipped:
+		return true
+	}
+	return false
+}
+
+func AllWorkspaceBulkJobStatusValues() []WorkspaceBulkJobStatus {
+	return []WorkspaceBulkJobStatus{
+		WorkspaceBulkJobStatusPending,
+		WorkspaceBulkJobStatusRunning,
+		WorkspaceBulkJobStatusSucceeded,
+		WorkspaceBulkJobStatusFailed,
+		WorkspaceBulkJobStatusSkipped,
+	}
+}
+
+type WorkspaceSessionType string
+
+const (
+	WorkspaceSessionTypeSSH             WorkspaceSessionType = "ssh"
+	WorkspaceSessionTypeReconnectingPTY WorkspaceSessionType = "reconnecting_pty"
+)
+
+func (e *WorkspaceSessionType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceSessionType(s)
+	case string:
+		*e = WorkspaceSessionType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceSessionType: %T", src)
+	}
+	return nil
+}
+
+type NullWorkspaceSessionType struct {
+	WorkspaceSessionType WorkspaceSessionType `json:"workspace_session_type"`
+	Valid                bool                 `json:"valid"` // Valid is true if WorkspaceSessionType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWorkspaceSessionType) Scan(value interface{}) error {
+	if value == nil {
+		ns.WorkspaceSessionType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WorkspaceSessionType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWorkspaceSessionType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WorkspaceSessionType), nil
+}
+
+func (e WorkspaceSessionType) Valid() bool {
+	switch e {
+	case WorkspaceSessionTypeSSH,
+		WorkspaceSessionTypeReconnectingPTY:
+		return true
+	}
+	return false
+}
+
+func AllWorkspaceSessionTypeValues() []WorkspaceSessionType {
+	return []WorkspaceSessionType{
+		WorkspaceSessionTypeSSH,
+		WorkspaceSessionTypeReconnectingPTY,
+	}
+}
+
 type WorkspaceTransition string
 
 const (
@@ -1298,6 +1633,36 @@ type License struct {
 	UUID uuid.UUID `db:"uuid" json:"uuid"`
 }
 
+type NotificationWebhook struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	Name      string    `db:"name" json:"name"`
+	Url       string    `db:"url" json:"url"`
+	// The event types that are sent to the webhook.
+	Events []NotificationEventType `db:"events" json:"events"`
+	// A Go text/template used to render the request body. The JSON encoded event is sent if empty.
+	PayloadTemplate string `db:"payload_template" json:"payload_template"`
+	// Used to sign request bodies with HMAC-SHA256 if set.
+	Secret  string `db:"secret" json:"secret"`
+	Enabled bool   `db:"enabled" json:"enabled"`
+}
+
+type NotificationWebhookDelivery struct {
+	ID        uuid.UUID             `db:"id" json:"id"`
+	WebhookID uuid.UUID             `db:"webhook_id" json:"webhook_id"`
+	EventID   uuid.UUID             `db:"event_id" json:"event_id"`
+	EventType NotificationEventType `db:"event_type" json:"event_type"`
+	// Starts at 1. Every replica receives every event, so the unique constraint ensures each attempt is made once.
+	Attempt     int32        `db:"attempt" json:"attempt"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+	CompletedAt sql.NullTime `db:"completed_at" json:"completed_at"`
+	RequestBody string       `db:"request_body" json:"request_body"`
+	// The HTTP status code of the response, or 0 if no response was received.
+	StatusCode int32  `db:"status_code" json:"status_code"`
+	Error      string `db:"error" json:"error"`
+}
+
 type Organization struct {
 	ID          uuid.UUID `db:"id" json:"id"`
 	Name        string    `db:"name" json:"name"`
@@ -1354,6 +1719,11 @@ type ProvisionerDaemon struct {
 	Provisioners []ProvisionerType `db:"provisioners" json:"provisioners"`
 	ReplicaID    uuid.NullUUID     `db:"replica_id" json:"replica_id"`
 	Tags         dbtype.StringMap  `db:"tags" json:"tags"`
+	// The last time the daemon sent a heartbeat. Daemons that stop sending heartbeats are reaped.
+	LastSeenAt sql.NullTime `db:"last_seen_at" json:"last_seen_at"`
+	Version    string       `db:"version" json:"version"`
+	// The job the daemon reported running in its last heartbeat.
+	CurrentJobID uuid.NullUUID `db:"current_job_id" json:"current_job_id"`
 }
 
 type ProvisionerJob struct {
@@ -1374,6 +1744,8 @@ type ProvisionerJob struct {
 	FileID         uuid.UUID                `db:"file_id" json:"file_id"`
 	Tags           dbtype.StringMap         `db:"tags" json:"tags"`
 	ErrorCode      sql.NullString           `db:"error_code" json:"error_code"`
+	// Pending jobs with a higher priority are acquired first. Jobs with the same priority are acquired in the order they were created.
+	Priority int32 `db:"priority" json:"priority"`
 }
 
 type ProvisionerJobLog struct {
@@ -1386,6 +1758,24 @@ type ProvisionerJobLog struct {
 	ID        int64     `db:"id" json:"id"`
 }
 
+// Changes to resources planned by a provisioner job, with the daily cost of each resource before and after the change.
+type ProvisionerJobResourceChange struct {
+	JobID          uuid.UUID            `db:"job_id" json:"job_id"`
+	Address        string               `db:"address" json:"address"`
+	Type           string               `db:"type" json:"type"`
+	Name           string               `db:"name" json:"name"`
+	Action         ResourceChangeAction `db:"action" json:"action"`
+	DailyCost      int32                `db:"daily_cost" json:"daily_cost"`
+	PriorDailyCost int32                `db:"prior_daily_cost" json:"prior_daily_cost"`
+}
+
+type RateLimitCounter struct {
+	Key         string    `db:"key" json:"key"`
+	WindowStart time.Time `db:"window_start" json:"window_start"`
+	ExpiresAt   time.Time `db:"expires_at" json:"expires_at"`
+	Count       int64     `db:"count" json:"count"`
+}
+
 type Replica struct {
 	ID              uuid.UUID    `db:"id" json:"id"`
 	CreatedAt       time.Time    `db:"created_at" json:"created_at"`
@@ -1405,6 +1795,29 @@ type SiteConfig struct {
 	Value string `db:"value" json:"value"`
 }
 
+// The latest node of each agent connected to a coordinator.
+type TailnetAgent struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	CoordinatorID uuid.UUID       `db:"coordinator_id" json:"coordinator_id"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+	Node          json.RawMessage `db:"node" json:"node"`
+}
+
+// The latest node of each client connected to a coordinator, and the agent it connects to.
+type TailnetClient struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	CoordinatorID uuid.UUID       `db:"coordinator_id" json:"coordinator_id"`
+	AgentID       uuid.UUID       `db:"agent_id" json:"agent_id"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+	Node          json.RawMessage `db:"node" json:"node"`
+}
+
+// The high availability tailnet coordinators of each replica. Coordinators that stop heartbeating are deleted along with their connections.
+type TailnetCoordinator struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	HeartbeatAt time.Time `db:"heartbeat_at" json:"heartbeat_at"`
+}
+
 type Template struct {
 	ID              uuid.UUID       `db:"id" json:"id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
@@ -1426,6 +1839,14 @@ type Template struct {
 	// Allow users to cancel in-progress workspace jobs.
 	AllowUserCancelWorkspaceJobs bool  `db:"allow_user_cancel_workspace_jobs" json:"allow_user_cancel_workspace_jobs"`
 	MaxTTL                       int64 `db:"max_ttl" json:"max_ttl"`
+	// The duration a running workspace may go unused before it is stopped automatically.
+	InactivityTTL int64 `db:"inactivity_ttl" json:"inactivity_ttl"`
+	// The duration a workspace may stay stopped and unused before it is deleted automatically.
+	DeleteTTL int64 `db:"delete_ttl" json:"delete_ttl"`
+	// A weekly cron schedule without a timezone. Running workspaces must be stopped at the next occurrence of the schedule in the owner's timezone.
+	AutostopRequirement string `db:"autostop_requirement" json:"autostop_requirement"`
+	// The number of approvals a template version needs before it can become the active version. Zero disables the promotion workflow.
+	RequiredPromotionApprovals int32 `db:"required_promotion_approvals" json:"required_promotion_approvals"`
 }
 
 type TemplateVersion struct {
@@ -1474,6 +1895,21 @@ type TemplateVersionParameter struct {
 	LegacyVariableName string `db:"legacy_variable_name" json:"legacy_variable_name"`
 }
 
+// Requests to make a template version active, which are completed once the dry-run succeeds and enough users approve.
+type TemplateVersionPromotion struct {
+	ID                uuid.UUID   `db:"id" json:"id"`
+	CreatedAt         time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time   `db:"updated_at" json:"updated_at"`
+	TemplateID        uuid.UUID   `db:"template_id" json:"template_id"`
+	TemplateVersionID uuid.UUID   `db:"template_version_id" json:"template_version_id"`
+	RequesterID       uuid.UUID   `db:"requester_id" json:"requester_id"`
+	DryRunJobID       uuid.UUID   `db:"dry_run_job_id" json:"dry_run_job_id"`
+	ApproverIDs       []uuid.UUID `db:"approver_ids" json:"approver_ids"`
+	// Pending promotions are superseded when another version of the template is promoted.
+	Status     TemplateVersionPromotionStatus `db:"status" json:"status"`
+	PromotedAt sql.NullTime                   `db:"promoted_at" json:"promoted_at"`
+}
+
 type TemplateVersionVariable struct {
 	TemplateVersionID uuid.UUID `db:"template_version_id" json:"template_version_id"`
 	// Variable name
@@ -1573,6 +2009,39 @@ type WorkspaceAgent struct {
 	StartupLogsLength int32 `db:"startup_logs_length" json:"startup_logs_length"`
 	// Whether the startup logs overflowed in length
 	StartupLogsOverflowed bool `db:"startup_logs_overflowed" json:"startup_logs_overflowed"`
+	// The number of bytes of output each reconnecting PTY keeps for replay, 0 means the agent default.
+	ReconnectingPTYBufferSize int32 `db:"reconnecting_pty_buffer_size" json:"reconnecting_pty_buffer_size"`
+	// Whether the agent records SSH and web terminal sessions.
+	SessionRecording bool `db:"session_recording" json:"session_recording"`
+}
+
+type WorkspaceAgentMetadatum struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	DisplayName      string    `db:"display_name" json:"display_name"`
+	Key              string    `db:"key" json:"key"`
+	Script           string    `db:"script" json:"script"`
+	Value            string    `db:"value" json:"value"`
+	Error            string    `db:"error" json:"error"`
+	Timeout          int64     `db:"timeout" json:"timeout"`
+	Interval         int64     `db:"interval" json:"interval"`
+	CollectedAt      time.Time `db:"collected_at" json:"collected_at"`
+}
+
+// Ports shared by workspace owners. Shares are keyed by agent name so they persist across workspace builds.
+type WorkspaceAgentPortShare struct {
+	WorkspaceID uuid.UUID       `db:"workspace_id" json:"workspace_id"`
+	AgentName   string          `db:"agent_name" json:"agent_name"`
+	Port        int32           `db:"port" json:"port"`
+	ShareLevel  AppSharingLevel `db:"share_level" json:"share_level"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// Port sharing rules defined by the template of a workspace agent.
+type WorkspaceAgentPortShareRule struct {
+	WorkspaceAgentID uuid.UUID       `db:"workspace_agent_id" json:"workspace_agent_id"`
+	Port             int32           `db:"port" json:"port"`
+	ShareLevel       AppSharingLevel `db:"share_level" json:"share_level"`
 }
 
 type WorkspaceAgentStartupLog struct {
@@ -1645,6 +2114,30 @@ type WorkspaceBuildParameter struct {
 	Value string `db:"value" json:"value"`
 }
 
+// Builds of many workspaces matching a search filter, created in batches.
+type WorkspaceBulkJob struct {
+	ID          uuid.UUID              `db:"id" json:"id"`
+	CreatedAt   time.Time              `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time              `db:"updated_at" json:"updated_at"`
+	CompletedAt sql.NullTime           `db:"completed_at" json:"completed_at"`
+	InitiatorID uuid.UUID              `db:"initiator_id" json:"initiator_id"`
+	Action      WorkspaceBulkJobAction `db:"action" json:"action"`
+	Filter      string                 `db:"filter" json:"filter"`
+	BatchSize   int32                  `db:"batch_size" json:"batch_size"`
+	Status      WorkspaceBulkJobStatus `db:"status" json:"status"`
+	Error       string                 `db:"error" json:"error"`
+}
+
+type WorkspaceBulkJobWorkspace struct {
+	BulkJobID        uuid.UUID     `db:"bulk_job_id" json:"bulk_job_id"`
+	WorkspaceID      uuid.UUID     `db:"workspace_id" json:"workspace_id"`
+	WorkspaceBuildID uuid.NullUUID `db:"workspace_build_id" json:"workspace_build_id"`
+	UpdatedAt        time.Time     `db:"updated_at" json:"updated_at"`
+	// Workspaces are skipped if they are already in the state the action would put them in.
+	Status WorkspaceBulkJobStatus `db:"status" json:"status"`
+	Error  string                 `db:"error" json:"error"`
+}
+
 type WorkspaceResource struct {
 	ID           uuid.UUID           `db:"id" json:"id"`
 	CreatedAt    time.Time           `db:"created_at" json:"created_at"`
@@ -1665,3 +2158,15 @@ type WorkspaceResourceMetadatum struct {
 	Sensitive           bool           `db:"sensitive" json:"sensitive"`
 	ID                  int64          `db:"id" json:"id"`
 }
+
+type WorkspaceSessionRecording struct {
+	ID          uuid.UUID            `db:"id" json:"id"`
+	CreatedAt   time.Time            `db:"created_at" json:"created_at"`
+	WorkspaceID uuid.UUID            `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID            `db:"agent_id" json:"agent_id"`
+	SessionType WorkspaceSessionType `db:"session_type" json:"session_type"`
+	StartedAt   time.Time            `db:"started_at" json:"started_at"`
+	EndedAt     time.Time            `db:"ended_at" json:"ended_at"`
+	// The session in asciicast v2 format.
+	Data []byte `db:"data" json:"data"`
+}
@@ -338,6 +338,39 @@ func (q *sqlQuerier) UpdateAPIKeyByID(ctx context.Context, arg UpdateAPIKeyByIDP
 	return err
 }
 
+const deleteOldAuditLogs = `-- name: DeleteOldAuditLogs :execrows
+DELETE FROM
+	audit_logs
+WHERE
+	id IN (
+		SELECT
+			id
+		FROM
+			audit_logs
+		WHERE
+			"time" < $1 :: timestamptz
+		ORDER BY
+			"time" ASC
+		LIMIT
+			$2 :: integer
+	)
+`
+
+type DeleteOldAuditLogsParams struct {
+	Before     time.Time `db:"before" json:"before"`
+	LimitCount int32     `db:"limit_count" json:"limit_count"`
+}
+
+// Audit logs are deleted in batches so that large purges don't hold locks on
+// the table for long.
+func (q *sqlQuerier) DeleteOldAuditLogs(ctx context.Context, arg DeleteOldAuditLogsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldAuditLogs, arg.Before, arg.LimitCount)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getAuditLogsOffset = `-- name: GetAuditLogsOffset :many
 SELECT
     audit_logs.id, audit_logs.time, audit_logs.user_id, audit_logs.organization_id, audit_logs.ip, audit_logs.user_agent, audit_logs.resource_type, audit_logs.resource_id, audit_logs.resource_target, audit_logs.action, audit_logs.diff, audit_logs.status_code, audit_logs.additional_fields, audit_logs.request_id, audit_logs.resource_icon,
@@ -537,6 +570,22 @@ func (q *sqlQuerier) GetAuditLogsOffset(ctx context.Context, arg GetAuditLogsOff
 	return items, nil
 }
 
+const getOldAuditLogCount = `-- name: GetOldAuditLogCount :one
+SELECT
+	COUNT(*)
+FROM
+	audit_logs
+WHERE
+	"time" < $1 :: timestamptz
+`
+
+func (q *sqlQuerier) GetOldAuditLogCount(ctx context.Context, before time.Time) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getOldAuditLogCount, before)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const insertAuditLog = `-- name: InsertAuditLog :one
 INSERT INTO
 	audit_logs (
@@ -1213,6 +1262,55 @@ func (q *sqlQuerier) GetGroupByOrgAndName(ctx context.Context, arg GetGroupByOrg
 	return i, err
 }
 
+const getGroupsByOrganizationAndUserID = `-- name: GetGroupsByOrganizationAndUserID :many
+SELECT
+	groups.id, groups.name, groups.organization_id, groups.avatar_url, groups.quota_allowance
+FROM
+	groups
+JOIN
+	group_members
+ON
+	group_members.group_id = groups.id
+WHERE
+	groups.organization_id = $1
+AND
+	group_members.user_id = $2
+`
+
+type GetGroupsByOrganizationAndUserIDParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+}
+
+func (q *sqlQuerier) GetGroupsByOrganizationAndUserID(ctx context.Context, arg GetGroupsByOrganizationAndUserIDParams) ([]Group, error) {
+	rows, err := q.db.QueryContext(ctx, getGroupsByOrganizationAndUserID, arg.OrganizationID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Group
+	for rows.Next() {
+		var i Group
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.OrganizationID,
+			&i.AvatarURL,
+			&i.QuotaAllowance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getGroupsByOrganizationID = `-- name: GetGroupsByOrganizationID :many
 SELECT
 	id, name, organization_id, avatar_url, quota_allowance
@@ -1532,32 +1630,59 @@ func (q *sqlQuerier) TryAcquireLock(ctx context.Context, pgTryAdvisoryXactLock i
 	return pg_try_advisory_xact_lock, err
 }
 
-const getOrganizationIDsByMemberIDs = `-- name: GetOrganizationIDsByMemberIDs :many
-SELECT
-    user_id, array_agg(organization_id) :: uuid [ ] AS "organization_IDs"
-FROM
-    organization_members
+const deleteNotificationWebhookByID = `-- name: DeleteNotificationWebhookByID :exec
+DELETE FROM
+	notification_webhooks
 WHERE
-    user_id = ANY($1 :: uuid [ ])
-GROUP BY
-    user_id
+	id = $1
 `
 
-type GetOrganizationIDsByMemberIDsRow struct {
-	UserID          uuid.UUID   `db:"user_id" json:"user_id"`
-	OrganizationIDs []uuid.UUID `db:"organization_IDs" json:"organization_IDs"`
+func (q *sqlQuerier) DeleteNotificationWebhookByID(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteNotificationWebhookByID, id)
+	return err
 }
 
-func (q *sqlQuerier) GetOrganizationIDsByMemberIDs(ctx context.Context, ids []uuid.UUID) ([]GetOrganizationIDsByMemberIDsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getOrganizationIDsByMemberIDs, pq.Array(ids))
+const deleteOldNotificationWebhookDeliveries = `-- name: DeleteOldNotificationWebhookDeliveries :exec
+DELETE FROM notification_webhook_deliveries WHERE created_at < NOW() - INTERVAL '30 days'
+`
+
+func (q *sqlQuerier) DeleteOldNotificationWebhookDeliveries(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteOldNotificationWebhookDeliveries)
+	return err
+}
+
+const getEnabledNotificationWebhooksByEventType = `-- name: GetEnabledNotificationWebhooksByEventType :many
+SELECT
+	id, created_at, updated_at, name, url, events, payload_template, secret, enabled
+FROM
+	notification_webhooks
+WHERE
+	enabled = true
+	AND $1 :: notification_event_type = ANY(events)
+ORDER BY
+	name ASC
+`
+
+func (q *sqlQuerier) GetEnabledNotificationWebhooksByEventType(ctx context.Context, eventType NotificationEventType) ([]NotificationWebhook, error) {
+	rows, err := q.db.QueryContext(ctx, getEnabledNotificationWebhooksByEventType, eventType)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetOrganizationIDsByMemberIDsRow
+	var items []NotificationWebhook
 	for rows.Next() {
-		var i GetOrganizationIDsByMemberIDsRow
-		if err := rows.Scan(&i.UserID, pq.Array(&i.OrganizationIDs)); err != nil {
+		var i NotificationWebhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			pq.Array(&i.Events),
+			&i.PayloadTemplate,
+			&i.Secret,
+			&i.Enabled,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -1571,60 +1696,115 @@ func (q *sqlQuerier) GetOrganizationIDsByMemberIDs(ctx context.Context, ids []uu
 	return items, nil
 }
 
-const getOrganizationMemberByUserID = `-- name: GetOrganizationMemberByUserID :one
+const getNotificationWebhookByID = `-- name: GetNotificationWebhookByID :one
 SELECT
-	user_id, organization_id, created_at, updated_at, roles
+	id, created_at, updated_at, name, url, events, payload_template, secret, enabled
 FROM
-	organization_members
+	notification_webhooks
 WHERE
-	organization_id = $1
-	AND user_id = $2
+	id = $1
 LIMIT
 	1
 `
 
-type GetOrganizationMemberByUserIDParams struct {
-	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
-	UserID         uuid.UUID `db:"user_id" json:"user_id"`
-}
-
-func (q *sqlQuerier) GetOrganizationMemberByUserID(ctx context.Context, arg GetOrganizationMemberByUserIDParams) (OrganizationMember, error) {
-	row := q.db.QueryRowContext(ctx, getOrganizationMemberByUserID, arg.OrganizationID, arg.UserID)
-	var i OrganizationMember
+func (q *sqlQuerier) GetNotificationWebhookByID(ctx context.Context, id uuid.UUID) (NotificationWebhook, error) {
+	row := q.db.QueryRowContext(ctx, getNotificationWebhookByID, id)
+	var i NotificationWebhook
 	err := row.Scan(
-		&i.UserID,
-		&i.OrganizationID,
+		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		pq.Array(&i.Roles),
+		&i.Name,
+		&i.Url,
+		pq.Array(&i.Events),
+		&i.PayloadTemplate,
+		&i.Secret,
+		&i.Enabled,
 	)
 	return i, err
 }
 
-const getOrganizationMembershipsByUserID = `-- name: GetOrganizationMembershipsByUserID :many
+const getNotificationWebhookDeliveries = `-- name: GetNotificationWebhookDeliveries :many
 SELECT
-	user_id, organization_id, created_at, updated_at, roles
+	id, webhook_id, event_id, event_type, attempt, created_at, completed_at, request_body, status_code, error
 FROM
-	organization_members
+	notification_webhook_deliveries
 WHERE
-  user_id = $1
+	webhook_id = $1
+ORDER BY
+	created_at DESC, attempt DESC
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF($2 :: int, 0)
 `
 
-func (q *sqlQuerier) GetOrganizationMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]OrganizationMember, error) {
-	rows, err := q.db.QueryContext(ctx, getOrganizationMembershipsByUserID, userID)
+type GetNotificationWebhookDeliveriesParams struct {
+	WebhookID uuid.UUID `db:"webhook_id" json:"webhook_id"`
+	LimitOpt  int32     `db:"limit_opt" json:"limit_opt"`
+}
+
+func (q *sqlQuerier) GetNotificationWebhookDeliveries(ctx context.Context, arg GetNotificationWebhookDeliveriesParams) ([]NotificationWebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, getNotificationWebhookDeliveries, arg.WebhookID, arg.LimitOpt)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []OrganizationMember
+	var items []NotificationWebhookDelivery
 	for rows.Next() {
-		var i OrganizationMember
+		var i NotificationWebhookDelivery
 		if err := rows.Scan(
-			&i.UserID,
-			&i.OrganizationID,
+			&i.ID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.EventType,
+			&i.Attempt,
+			&i.CreatedAt,
+			&i.CompletedAt,
+			&i.RequestBody,
+			&i.StatusCode,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationWebhooks = `-- name: GetNotificationWebhooks :many
+SELECT
+	id, created_at, updated_at, name, url, events, payload_template, secret, enabled
+FROM
+	notification_webhooks
+ORDER BY
+	name ASC
+`
+
+func (q *sqlQuerier) GetNotificationWebhooks(ctx context.Context) ([]NotificationWebhook, error) {
+	rows, err := q.db.QueryContext(ctx, getNotificationWebhooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationWebhook
+	for rows.Next() {
+		var i NotificationWebhook
+		if err := rows.Scan(
+			&i.ID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
-			pq.Array(&i.Roles),
+			&i.Name,
+			&i.Url,
+			pq.Array(&i.Events),
+			&i.PayloadTemplate,
+			&i.Secret,
+			&i.Enabled,
 		); err != nil {
 			return nil, err
 		}
@@ -1639,89 +1819,388 @@ func (q *sqlQuerier) GetOrganizationMembershipsByUserID(ctx context.Context, use
 	return items, nil
 }
 
-const insertOrganizationMember = `-- name: InsertOrganizationMember :one
+const insertNotificationWebhook = `-- name: InsertNotificationWebhook :one
 INSERT INTO
-	organization_members (
-		organization_id,
-		user_id,
+	notification_webhooks (
+		id,
 		created_at,
 		updated_at,
-		roles
+		name,
+		url,
+		events,
+		payload_template,
+		secret,
+		enabled
 	)
 VALUES
-	($1, $2, $3, $4, $5) RETURNING user_id, organization_id, created_at, updated_at, roles
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at, name, url, events, payload_template, secret, enabled
 `
 
-type InsertOrganizationMemberParams struct {
-	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
-	UserID         uuid.UUID `db:"user_id" json:"user_id"`
-	CreatedAt      time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
-	Roles          []string  `db:"roles" json:"roles"`
+type InsertNotificationWebhookParams struct {
+	ID              uuid.UUID               `db:"id" json:"id"`
+	CreatedAt       time.Time               `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time               `db:"updated_at" json:"updated_at"`
+	Name            string                  `db:"name" json:"name"`
+	Url             string                  `db:"url" json:"url"`
+	Events          []NotificationEventType `db:"events" json:"events"`
+	PayloadTemplate string                  `db:"payload_template" json:"payload_template"`
+	Secret          string                  `db:"secret" json:"secret"`
+	Enabled         bool                    `db:"enabled" json:"enabled"`
 }
 
-func (q *sqlQuerier) InsertOrganizationMember(ctx context.Context, arg InsertOrganizationMemberParams) (OrganizationMember, error) {
-	row := q.db.QueryRowContext(ctx, insertOrganizationMember,
-		arg.OrganizationID,
-		arg.UserID,
+func (q *sqlQuerier) InsertNotificationWebhook(ctx context.Context, arg InsertNotificationWebhookParams) (NotificationWebhook, error) {
+	row := q.db.QueryRowContext(ctx, insertNotificationWebhook,
+		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
-		pq.Array(arg.Roles),
+		arg.Name,
+		arg.Url,
+		pq.Array(arg.Events),
+		arg.PayloadTemplate,
+		arg.Secret,
+		arg.Enabled,
 	)
-	var i OrganizationMember
+	var i NotificationWebhook
 	err := row.Scan(
-		&i.UserID,
-		&i.OrganizationID,
+		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		pq.Array(&i.Roles),
+		&i.Name,
+		&i.Url,
+		pq.Array(&i.Events),
+		&i.PayloadTemplate,
+		&i.Secret,
+		&i.Enabled,
 	)
 	return i, err
 }
 
-const updateMemberRoles = `-- name: UpdateMemberRoles :one
-UPDATE
-	organization_members
-SET
-	-- Remove all duplicates from the roles.
-	roles = ARRAY(SELECT DISTINCT UNNEST($1 :: text[]))
-WHERE
-	user_id = $2
-	AND organization_id = $3
-RETURNING user_id, organization_id, created_at, updated_at, roles
+const insertNotificationWebhookDelivery = `-- name: InsertNotificationWebhookDelivery :one
+INSERT INTO
+	notification_webhook_deliveries (
+		id,
+		webhook_id,
+		event_id,
+		event_type,
+		attempt,
+		created_at,
+		request_body
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (webhook_id, event_id, attempt) DO NOTHING
+RETURNING id, webhook_id, event_id, event_type, attempt, created_at, completed_at, request_body, status_code, error
 `
 
-type UpdateMemberRolesParams struct {
-	GrantedRoles []string  `db:"granted_roles" json:"granted_roles"`
-	UserID       uuid.UUID `db:"user_id" json:"user_id"`
-	OrgID        uuid.UUID `db:"org_id" json:"org_id"`
+type InsertNotificationWebhookDeliveryParams struct {
+	ID          uuid.UUID             `db:"id" json:"id"`
+	WebhookID   uuid.UUID             `db:"webhook_id" json:"webhook_id"`
+	EventID     uuid.UUID             `db:"event_id" json:"event_id"`
+	EventType   NotificationEventType `db:"event_type" json:"event_type"`
+	Attempt     int32                 `db:"attempt" json:"attempt"`
+	CreatedAt   time.Time             `db:"created_at" json:"created_at"`
+	RequestBody string                `db:"request_body" json:"request_body"`
 }
 
-func (q *sqlQuerier) UpdateMemberRoles(ctx context.Context, arg UpdateMemberRolesParams) (OrganizationMember, error) {
-	row := q.db.QueryRowContext(ctx, updateMemberRoles, pq.Array(arg.GrantedRoles), arg.UserID, arg.OrgID)
-	var i OrganizationMember
+// Returns no rows if the attempt was already made by another replica.
+func (q *sqlQuerier) InsertNotificationWebhookDelivery(ctx context.Context, arg InsertNotificationWebhookDeliveryParams) (NotificationWebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, insertNotificationWebhookDelivery,
+		arg.ID,
+		arg.WebhookID,
+		arg.EventID,
+		arg.EventType,
+		arg.Attempt,
+		arg.CreatedAt,
+		arg.RequestBody,
+	)
+	var i NotificationWebhookDelivery
 	err := row.Scan(
-		&i.UserID,
-		&i.OrganizationID,
+		&i.ID,
+		&i.WebhookID,
+		&i.EventID,
+		&i.EventType,
+		&i.Attempt,
 		&i.CreatedAt,
-		&i.UpdatedAt,
-		pq.Array(&i.Roles),
+		&i.CompletedAt,
+		&i.RequestBody,
+		&i.StatusCode,
+		&i.Error,
 	)
 	return i, err
 }
 
-const getOrganizationByID = `-- name: GetOrganizationByID :one
-SELECT
-	id, name, description, created_at, updated_at
-FROM
-	organizations
+const updateNotificationWebhookByID = `-- name: UpdateNotificationWebhookByID :one
+UPDATE
+	notification_webhooks
+SET
+	updated_at = $2,
+	name = $3,
+	url = $4,
+	events = $5,
+	payload_template = $6,
+	secret = $7,
+	enabled = $8
 WHERE
 	id = $1
+RETURNING
+	id, created_at, updated_at, name, url, events, payload_template, secret, enabled
 `
 
-func (q *sqlQuerier) GetOrganizationByID(ctx context.Context, id uuid.UUID) (Organization, error) {
-	row := q.db.QueryRowContext(ctx, getOrganizationByID, id)
-	var i Organization
+type UpdateNotificationWebhookByIDParams struct {
+	ID              uuid.UUID               `db:"id" json:"id"`
+	UpdatedAt       time.Time               `db:"updated_at" json:"updated_at"`
+	Name            string                  `db:"name" json:"name"`
+	Url             string                  `db:"url" json:"url"`
+	Events          []NotificationEventType `db:"events" json:"events"`
+	PayloadTemplate string                  `db:"payload_template" json:"payload_template"`
+	Secret          string                  `db:"secret" json:"secret"`
+	Enabled         bool                    `db:"enabled" json:"enabled"`
+}
+
+func (q *sqlQuerier) UpdateNotificationWebhookByID(ctx context.Context, arg UpdateNotificationWebhookByIDParams) (NotificationWebhook, error) {
+	row := q.db.QueryRowContext(ctx, updateNotificationWebhookByID,
+		arg.ID,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.Url,
+		pq.Array(arg.Events),
+		arg.PayloadTemplate,
+		arg.Secret,
+		arg.Enabled,
+	)
+	var i NotificationWebhook
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		pq.Array(&i.Events),
+		&i.PayloadTemplate,
+		&i.Secret,
+		&i.Enabled,
+	)
+	return i, err
+}
+
+const updateNotificationWebhookDeliveryByID = `-- name: UpdateNotificationWebhookDeliveryByID :exec
+UPDATE
+	notification_webhook_deliveries
+SET
+	completed_at = $2,
+	status_code = $3,
+	error = $4
+WHERE
+	id = $1
+`
+
+type UpdateNotificationWebhookDeliveryByIDParams struct {
+	ID          uuid.UUID    `db:"id" json:"id"`
+	CompletedAt sql.NullTime `db:"completed_at" json:"completed_at"`
+	StatusCode  int32        `db:"status_code" json:"status_code"`
+	Error       string       `db:"error" json:"error"`
+}
+
+func (q *sqlQuerier) UpdateNotificationWebhookDeliveryByID(ctx context.Context, arg UpdateNotificationWebhookDeliveryByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateNotificationWebhookDeliveryByID,
+		arg.ID,
+		arg.CompletedAt,
+		arg.StatusCode,
+		arg.Error,
+	)
+	return err
+}
+
+const getOrganizationIDsByMemberIDs = `-- name: GetOrganizationIDsByMemberIDs :many
+SELECT
+    user_id, array_agg(organization_id) :: uuid [ ] AS "organization_IDs"
+FROM
+    organization_members
+WHERE
+    user_id = ANY($1 :: uuid [ ])
+GROUP BY
+    user_id
+`
+
+type GetOrganizationIDsByMemberIDsRow struct {
+	UserID          uuid.UUID   `db:"user_id" json:"user_id"`
+	OrganizationIDs []uuid.UUID `db:"organization_IDs" json:"organization_IDs"`
+}
+
+func (q *sqlQuerier) GetOrganizationIDsByMemberIDs(ctx context.Context, ids []uuid.UUID) ([]GetOrganizationIDsByMemberIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getOrganizationIDsByMemberIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOrganizationIDsByMemberIDsRow
+	for rows.Next() {
+		var i GetOrganizationIDsByMemberIDsRow
+		if err := rows.Scan(&i.UserID, pq.Array(&i.OrganizationIDs)); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrganizationMemberByUserID = `-- name: GetOrganizationMemberByUserID :one
+SELECT
+	user_id, organization_id, created_at, updated_at, roles
+FROM
+	organization_members
+WHERE
+	organization_id = $1
+	AND user_id = $2
+LIMIT
+	1
+`
+
+type GetOrganizationMemberByUserIDParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+}
+
+func (q *sqlQuerier) GetOrganizationMemberByUserID(ctx context.Context, arg GetOrganizationMemberByUserIDParams) (OrganizationMember, error) {
+	row := q.db.QueryRowContext(ctx, getOrganizationMemberByUserID, arg.OrganizationID, arg.UserID)
+	var i OrganizationMember
+	err := row.Scan(
+		&i.UserID,
+		&i.OrganizationID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		pq.Array(&i.Roles),
+	)
+	return i, err
+}
+
+const getOrganizationMembershipsByUserID = `-- name: GetOrganizationMembershipsByUserID :many
+SELECT
+	user_id, organization_id, created_at, updated_at, roles
+FROM
+	organization_members
+WHERE
+  user_id = $1
+`
+
+func (q *sqlQuerier) GetOrganizationMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]OrganizationMember, error) {
+	rows, err := q.db.QueryContext(ctx, getOrganizationMembershipsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrganizationMember
+	for rows.Next() {
+		var i OrganizationMember
+		if err := rows.Scan(
+			&i.UserID,
+			&i.OrganizationID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			pq.Array(&i.Roles),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertOrganizationMember = `-- name: InsertOrganizationMember :one
+INSERT INTO
+	organization_members (
+		organization_id,
+		user_id,
+		created_at,
+		updated_at,
+		roles
+	)
+VALUES
+	($1, $2, $3, $4, $5) RETURNING user_id, organization_id, created_at, updated_at, roles
+`
+
+type InsertOrganizationMemberParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+	Roles          []string  `db:"roles" json:"roles"`
+}
+
+func (q *sqlQuerier) InsertOrganizationMember(ctx context.Context, arg InsertOrganizationMemberParams) (OrganizationMember, error) {
+	row := q.db.QueryRowContext(ctx, insertOrganizationMember,
+		arg.OrganizationID,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		pq.Array(arg.Roles),
+	)
+	var i OrganizationMember
+	err := row.Scan(
+		&i.UserID,
+		&i.OrganizationID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		pq.Array(&i.Roles),
+	)
+	return i, err
+}
+
+const updateMemberRoles = `-- name: UpdateMemberRoles :one
+UPDATE
+	organization_members
+SET
+	-- Remove all duplicates from the roles.
+	roles = ARRAY(SELECT DISTINCT UNNEST($1 :: text[]))
+WHERE
+	user_id = $2
+	AND organization_id = $3
+RETURNING user_id, organization_id, created_at, updated_at, roles
+`
+
+type UpdateMemberRolesParams struct {
+	GrantedRoles []string  `db:"granted_roles" json:"granted_roles"`
+	UserID       uuid.UUID `db:"user_id" json:"user_id"`
+	OrgID        uuid.UUID `db:"org_id" json:"org_id"`
+}
+
+func (q *sqlQuerier) UpdateMemberRoles(ctx context.Context, arg UpdateMemberRolesParams) (OrganizationMember, error) {
+	row := q.db.QueryRowContext(ctx, updateMemberRoles, pq.Array(arg.GrantedRoles), arg.UserID, arg.OrgID)
+	var i OrganizationMember
+	err := row.Scan(
+		&i.UserID,
+		&i.OrganizationID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		pq.Array(&i.Roles),
+	)
+	return i, err
+}
+
+const getOrganizationByID = `-- name: GetOrganizationByID :one
+SELECT
+	id, name, description, created_at, updated_at
+FROM
+	organizations
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetOrganizationByID(ctx context.Context, id uuid.UUID) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, getOrganizationByID, id)
+	var i Organization
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
@@ -2275,9 +2754,27 @@ func (q *sqlQuerier) ParameterValues(ctx context.Context, arg ParameterValuesPar
 	return items, nil
 }
 
+const deleteStaleProvisionerDaemons = `-- name: DeleteStaleProvisionerDaemons :execrows
+DELETE FROM
+	provisioner_daemons
+WHERE
+	COALESCE(last_seen_at, created_at) < $1 :: timestamptz
+`
+
+// Deletes daemons that haven't sent a heartbeat since the given time.
+// Daemons that have never sent a heartbeat are considered last seen when
+// they were created.
+func (q *sqlQuerier) DeleteStaleProvisionerDaemons(ctx context.Context, staleBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteStaleProvisionerDaemons, staleBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getProvisionerDaemons = `-- name: GetProvisionerDaemons :many
 SELECT
-	id, created_at, updated_at, name, provisioners, replica_id, tags
+	id, created_at, updated_at, name, provisioners, replica_id, tags, last_seen_at, version, current_job_id
 FROM
 	provisioner_daemons
 `
@@ -2299,6 +2796,9 @@ func (q *sqlQuerier) GetProvisionerDaemons(ctx context.Context) ([]ProvisionerDa
 			pq.Array(&i.Provisioners),
 			&i.ReplicaID,
 			&i.Tags,
+			&i.LastSeenAt,
+			&i.Version,
+			&i.CurrentJobID,
 		); err != nil {
 			return nil, err
 		}
@@ -2323,7 +2823,7 @@ INSERT INTO
 		tags
 	)
 VALUES
-	($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at, name, provisioners, replica_id, tags
+	($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at, name, provisioners, replica_id, tags, last_seen_at, version, current_job_id
 `
 
 type InsertProvisionerDaemonParams struct {
@@ -2351,35 +2851,120 @@ func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProv
 		pq.Array(&i.Provisioners),
 		&i.ReplicaID,
 		&i.Tags,
+		&i.LastSeenAt,
+		&i.Version,
+		&i.CurrentJobID,
 	)
 	return i, err
 }
 
-const getProvisionerLogsAfterID = `-- name: GetProvisionerLogsAfterID :many
-SELECT
-	job_id, created_at, source, level, stage, output, id
-FROM
-	provisioner_job_logs
+const updateProvisionerDaemonHeartbeat = `-- name: UpdateProvisionerDaemonHeartbeat :exec
+UPDATE
+	provisioner_daemons
+SET
+	last_seen_at = $1,
+	version = $2,
+	current_job_id = $3
 WHERE
-	job_id = $1
-	AND (
-		id > $2
-	) ORDER BY id ASC
+	id = $4
 `
 
-type GetProvisionerLogsAfterIDParams struct {
-	JobID        uuid.UUID `db:"job_id" json:"job_id"`
-	CreatedAfter int64     `db:"created_after" json:"created_after"`
+type UpdateProvisionerDaemonHeartbeatParams struct {
+	LastSeenAt   sql.NullTime  `db:"last_seen_at" json:"last_seen_at"`
+	Version      string        `db:"version" json:"version"`
+	CurrentJobID uuid.NullUUID `db:"current_job_id" json:"current_job_id"`
+	ID           uuid.UUID     `db:"id" json:"id"`
 }
 
-func (q *sqlQuerier) GetProvisionerLogsAfterID(ctx context.Context, arg GetProvisionerLogsAfterIDParams) ([]ProvisionerJobLog, error) {
-	rows, err := q.db.QueryContext(ctx, getProvisionerLogsAfterID, arg.JobID, arg.CreatedAfter)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ProvisionerJobLog
-	for rows.Next() {
+func (q *sqlQuerier) UpdateProvisionerDaemonHeartbeat(ctx context.Context, arg UpdateProvisionerDaemonHeartbeatParams) error {
+	_, err := q.db.ExecContext(ctx, updateProvisionerDaemonHeartbeat,
+		arg.LastSeenAt,
+		arg.Version,
+		arg.CurrentJobID,
+		arg.ID,
+	)
+	return err
+}
+
+const deleteOldProvisionerJobLogs = `-- name: DeleteOldProvisionerJobLogs :execrows
+DELETE FROM
+	provisioner_job_logs
+WHERE
+	id IN (
+		SELECT
+			provisioner_job_logs.id
+		FROM
+			provisioner_job_logs
+		JOIN
+			provisioner_jobs ON provisioner_jobs.id = provisioner_job_logs.job_id
+		WHERE
+			provisioner_jobs.completed_at < $1 :: timestamptz
+		ORDER BY
+			provisioner_job_logs.id ASC
+		LIMIT
+			$2 :: integer
+	)
+`
+
+type DeleteOldProvisionerJobLogsParams struct {
+	Before     time.Time `db:"before" json:"before"`
+	LimitCount int32     `db:"limit_count" json:"limit_count"`
+}
+
+// Logs are only purged for jobs that have completed, so logs of running jobs
+// are never removed. Logs are deleted in batches so that large purges don't
+// hold locks on the table for long.
+func (q *sqlQuerier) DeleteOldProvisionerJobLogs(ctx context.Context, arg DeleteOldProvisionerJobLogsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldProvisionerJobLogs, arg.Before, arg.LimitCount)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getOldProvisionerJobLogCount = `-- name: GetOldProvisionerJobLogCount :one
+SELECT
+	COUNT(*)
+FROM
+	provisioner_job_logs
+JOIN
+	provisioner_jobs ON provisioner_jobs.id = provisioner_job_logs.job_id
+WHERE
+	provisioner_jobs.completed_at < $1 :: timestamptz
+`
+
+func (q *sqlQuerier) GetOldProvisionerJobLogCount(ctx context.Context, before time.Time) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getOldProvisionerJobLogCount, before)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getProvisionerLogsAfterID = `-- name: GetProvisionerLogsAfterID :many
+SELECT
+	job_id, created_at, source, level, stage, output, id
+FROM
+	provisioner_job_logs
+WHERE
+	job_id = $1
+	AND (
+		id > $2
+	) ORDER BY id ASC
+`
+
+type GetProvisionerLogsAfterIDParams struct {
+	JobID        uuid.UUID `db:"job_id" json:"job_id"`
+	CreatedAfter int64     `db:"created_after" json:"created_after"`
+}
+
+func (q *sqlQuerier) GetProvisionerLogsAfterID(ctx context.Context, arg GetProvisionerLogsAfterIDParams) ([]ProvisionerJobLog, error) {
+	rows, err := q.db.QueryContext(ctx, getProvisionerLogsAfterID, arg.JobID, arg.CreatedAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProvisionerJobLog
+	for rows.Next() {
 		var i ProvisionerJobLog
 		if err := rows.Scan(
 			&i.JobID,
@@ -2462,6 +3047,88 @@ func (q *sqlQuerier) InsertProvisionerJobLogs(ctx context.Context, arg InsertPro
 	return items, nil
 }
 
+const getProvisionerJobResourceChangesByJobID = `-- name: GetProvisionerJobResourceChangesByJobID :many
+SELECT
+	job_id, address, type, name, action, daily_cost, prior_daily_cost
+FROM
+	provisioner_job_resource_changes
+WHERE
+	job_id = $1
+ORDER BY
+	address ASC
+`
+
+func (q *sqlQuerier) GetProvisionerJobResourceChangesByJobID(ctx context.Context, jobID uuid.UUID) ([]ProvisionerJobResourceChange, error) {
+	rows, err := q.db.QueryContext(ctx, getProvisionerJobResourceChangesByJobID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProvisionerJobResourceChange
+	for rows.Next() {
+		var i ProvisionerJobResourceChange
+		if err := rows.Scan(
+			&i.JobID,
+			&i.Address,
+			&i.Type,
+			&i.Name,
+			&i.Action,
+			&i.DailyCost,
+			&i.PriorDailyCost,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertProvisionerJobResourceChange = `-- name: InsertProvisionerJobResourceChange :one
+INSERT INTO
+	provisioner_job_resource_changes (job_id, address, type, name, action, daily_cost, prior_daily_cost)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7) RETURNING job_id, address, type, name, action, daily_cost, prior_daily_cost
+`
+
+type InsertProvisionerJobResourceChangeParams struct {
+	JobID          uuid.UUID            `db:"job_id" json:"job_id"`
+	Address        string               `db:"address" json:"address"`
+	Type           string               `db:"type" json:"type"`
+	Name           string               `db:"name" json:"name"`
+	Action         ResourceChangeAction `db:"action" json:"action"`
+	DailyCost      int32                `db:"daily_cost" json:"daily_cost"`
+	PriorDailyCost int32                `db:"prior_daily_cost" json:"prior_daily_cost"`
+}
+
+func (q *sqlQuerier) InsertProvisionerJobResourceChange(ctx context.Context, arg InsertProvisionerJobResourceChangeParams) (ProvisionerJobResourceChange, error) {
+	row := q.db.QueryRowContext(ctx, insertProvisionerJobResourceChange,
+		arg.JobID,
+		arg.Address,
+		arg.Type,
+		arg.Name,
+		arg.Action,
+		arg.DailyCost,
+		arg.PriorDailyCost,
+	)
+	var i ProvisionerJobResourceChange
+	err := row.Scan(
+		&i.JobID,
+		&i.Address,
+		&i.Type,
+		&i.Name,
+		&i.Action,
+		&i.DailyCost,
+		&i.PriorDailyCost,
+	)
+	return i, err
+}
+
 const acquireProvisionerJob = `-- name: AcquireProvisionerJob :one
 UPDATE
 	provisioner_jobs
@@ -2477,17 +3144,19 @@ WHERE
 			provisioner_jobs AS nested
 		WHERE
 			nested.started_at IS NULL
+			AND nested.canceled_at IS NULL
 			-- Ensure the caller has the correct provisioner.
 			AND nested.provisioner = ANY($3 :: provisioner_type [ ])
 			-- Ensure the caller satisfies all job tags.
 			AND nested.tags <@ $4 :: jsonb
 		ORDER BY
+			nested.priority DESC,
 			nested.created_at
 		FOR UPDATE
 		SKIP LOCKED
 		LIMIT
 			1
-	) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code
+	) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, priority
 `
 
 type AcquireProvisionerJobParams struct {
@@ -2498,7 +3167,8 @@ type AcquireProvisionerJobParams struct {
 }
 
 // Acquires the lock for a single job that isn't started, completed,
-// canceled, and that matches an array of provisioner types.
+// canceled, and that matches an array of provisioner types. Jobs with
+// a higher priority are acquired first.
 //
 // SKIP LOCKED is used to jump over locked rows. This prevents
 // multiple provisioners from acquiring the same jobs. See:
@@ -2529,13 +3199,162 @@ func (q *sqlQuerier) AcquireProvisionerJob(ctx context.Context, arg AcquireProvi
 		&i.FileID,
 		&i.Tags,
 		&i.ErrorCode,
+		&i.Priority,
 	)
 	return i, err
 }
 
+const bumpProvisionerJobByID = `-- name: BumpProvisionerJobByID :exec
+UPDATE
+	provisioner_jobs
+SET
+	updated_at = $1,
+	priority = (
+		SELECT
+			MAX(pending.priority) + 1
+		FROM
+			provisioner_jobs AS pending
+		WHERE
+			pending.started_at IS NULL
+			AND pending.canceled_at IS NULL
+	)
+WHERE
+	provisioner_jobs.id = $2
+	AND provisioner_jobs.started_at IS NULL
+	AND provisioner_jobs.canceled_at IS NULL
+`
+
+type BumpProvisionerJobByIDParams struct {
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ID        uuid.UUID `db:"id" json:"id"`
+}
+
+// Moves a pending job to the front of the queue by giving it a higher
+// priority than every other pending job.
+func (q *sqlQuerier) BumpProvisionerJobByID(ctx context.Context, arg BumpProvisionerJobByIDParams) error {
+	_, err := q.db.ExecContext(ctx, bumpProvisionerJobByID, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const getPendingProvisionerJobStats = `-- name: GetPendingProvisionerJobStats :many
+SELECT
+	provisioner_jobs.provisioner,
+	provisioner_jobs.tags,
+	organizations.name AS organization_name,
+	COUNT(*) AS count,
+	MIN(provisioner_jobs.created_at)::timestamptz AS oldest_created_at
+FROM
+	provisioner_jobs
+JOIN
+	organizations ON organizations.id = provisioner_jobs.organization_id
+WHERE
+	provisioner_jobs.started_at IS NULL
+	AND provisioner_jobs.canceled_at IS NULL
+GROUP BY
+	provisioner_jobs.provisioner,
+	provisioner_jobs.tags,
+	organizations.name
+`
+
+type GetPendingProvisionerJobStatsRow struct {
+	Provisioner      ProvisionerType  `db:"provisioner" json:"provisioner"`
+	Tags             dbtype.StringMap `db:"tags" json:"tags"`
+	OrganizationName string           `db:"organization_name" json:"organization_name"`
+	Count            int64            `db:"count" json:"count"`
+	OldestCreatedAt  time.Time        `db:"oldest_created_at" json:"oldest_created_at"`
+}
+
+// Returns the number of jobs waiting for a provisioner daemon and when the
+// oldest of them was created, grouped by the daemons that can acquire them.
+func (q *sqlQuerier) GetPendingProvisionerJobStats(ctx context.Context) ([]GetPendingProvisionerJobStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingProvisionerJobStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPendingProvisionerJobStatsRow
+	for rows.Next() {
+		var i GetPendingProvisionerJobStatsRow
+		if err := rows.Scan(
+			&i.Provisioner,
+			&i.Tags,
+			&i.OrganizationName,
+			&i.Count,
+			&i.OldestCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPendingProvisionerJobs = `-- name: GetPendingProvisionerJobs :many
+SELECT
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, priority
+FROM
+	provisioner_jobs
+WHERE
+	started_at IS NULL
+	AND canceled_at IS NULL
+ORDER BY
+	priority DESC,
+	created_at
+`
+
+// Returns the jobs waiting for a provisioner daemon in the order they will
+// be acquired.
+func (q *sqlQuerier) GetPendingProvisionerJobs(ctx context.Context) ([]ProvisionerJob, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingProvisionerJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProvisionerJob
+	for rows.Next() {
+		var i ProvisionerJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.StartedAt,
+			&i.CanceledAt,
+			&i.CompletedAt,
+			&i.Error,
+			&i.OrganizationID,
+			&i.InitiatorID,
+			&i.Provisioner,
+			&i.StorageMethod,
+			&i.Type,
+			&i.Input,
+			&i.WorkerID,
+			&i.FileID,
+			&i.Tags,
+			&i.ErrorCode,
+			&i.Priority,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProvisionerJobByID = `-- name: GetProvisionerJobByID :one
 SELECT
-	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, priority
 FROM
 	provisioner_jobs
 WHERE
@@ -2563,13 +3382,14 @@ func (q *sqlQuerier) GetProvisionerJobByID(ctx context.Context, id uuid.UUID) (P
 		&i.FileID,
 		&i.Tags,
 		&i.ErrorCode,
+		&i.Priority,
 	)
 	return i, err
 }
 
 const getProvisionerJobsByIDs = `-- name: GetProvisionerJobsByIDs :many
 SELECT
-	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, priority
 FROM
 	provisioner_jobs
 WHERE
@@ -2603,6 +3423,7 @@ func (q *sqlQuerier) GetProvisionerJobsByIDs(ctx context.Context, ids []uuid.UUI
 			&i.FileID,
 			&i.Tags,
 			&i.ErrorCode,
+			&i.Priority,
 		); err != nil {
 			return nil, err
 		}
@@ -2618,7 +3439,7 @@ func (q *sqlQuerier) GetProvisionerJobsByIDs(ctx context.Context, ids []uuid.UUI
 }
 
 const getProvisionerJobsCreatedAfter = `-- name: GetProvisionerJobsCreatedAfter :many
-SELECT id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code FROM provisioner_jobs WHERE created_at > $1
+SELECT id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, priority FROM provisioner_jobs WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetProvisionerJobsCreatedAfter(ctx context.Context, createdAt time.Time) ([]ProvisionerJob, error) {
@@ -2648,6 +3469,7 @@ func (q *sqlQuerier) GetProvisionerJobsCreatedAfter(ctx context.Context, created
 			&i.FileID,
 			&i.Tags,
 			&i.ErrorCode,
+			&i.Priority,
 		); err != nil {
 			return nil, err
 		}
@@ -2675,10 +3497,11 @@ INSERT INTO
 		file_id,
 		"type",
 		"input",
-		tags
+		tags,
+		priority
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, priority
 `
 
 type InsertProvisionerJobParams struct {
@@ -2693,6 +3516,7 @@ type InsertProvisionerJobParams struct {
 	Type           ProvisionerJobType       `db:"type" json:"type"`
 	Input          json.RawMessage          `db:"input" json:"input"`
 	Tags           dbtype.StringMap         `db:"tags" json:"tags"`
+	Priority       int32                    `db:"priority" json:"priority"`
 }
 
 func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisionerJobParams) (ProvisionerJob, error) {
@@ -2708,6 +3532,7 @@ func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisi
 		arg.Type,
 		arg.Input,
 		arg.Tags,
+		arg.Priority,
 	)
 	var i ProvisionerJob
 	err := row.Scan(
@@ -2728,6 +3553,7 @@ func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisi
 		&i.FileID,
 		&i.Tags,
 		&i.ErrorCode,
+		&i.Priority,
 	)
 	return i, err
 }
@@ -2850,6 +3676,49 @@ func (q *sqlQuerier) GetQuotaConsumedForUser(ctx context.Context, ownerID uuid.U
 	return column_1, err
 }
 
+const deleteExpiredRateLimitCounters = `-- name: DeleteExpiredRateLimitCounters :exec
+DELETE FROM rate_limit_counters WHERE expires_at < $1 :: timestamptz
+`
+
+func (q *sqlQuerier) DeleteExpiredRateLimitCounters(ctx context.Context, now time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredRateLimitCounters, now)
+	return err
+}
+
+const incrementRateLimitCounter = `-- name: IncrementRateLimitCounter :one
+INSERT INTO
+	rate_limit_counters (key, window_start, expires_at, count)
+VALUES
+	($1, $2, $3, 1)
+ON CONFLICT
+	(key)
+DO UPDATE SET
+	count = CASE
+		WHEN rate_limit_counters.window_start >= EXCLUDED.window_start THEN rate_limit_counters.count + 1
+		ELSE 1
+	END,
+	window_start = GREATEST(rate_limit_counters.window_start, EXCLUDED.window_start),
+	expires_at = GREATEST(rate_limit_counters.expires_at, EXCLUDED.expires_at)
+RETURNING
+	count
+`
+
+type IncrementRateLimitCounterParams struct {
+	Key         string    `db:"key" json:"key"`
+	WindowStart time.Time `db:"window_start" json:"window_start"`
+	ExpiresAt   time.Time `db:"expires_at" json:"expires_at"`
+}
+
+// Increments the counter of the current window of a key. The counter is
+// reset when a new window starts. Requests for an older window, e.g. from a
+// replica with a skewed clock, are counted against the current window.
+func (q *sqlQuerier) IncrementRateLimitCounter(ctx context.Context, arg IncrementRateLimitCounterParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, incrementRateLimitCounter, arg.Key, arg.WindowStart, arg.ExpiresAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const deleteReplicasUpdatedBefore = `-- name: DeleteReplicasUpdatedBefore :exec
 DELETE FROM replicas WHERE updated_at < $1
 `
@@ -3133,32 +4002,337 @@ func (q *sqlQuerier) UpsertServiceBanner(ctx context.Context, value string) erro
 	return err
 }
 
-const getTemplateAverageBuildTime = `-- name: GetTemplateAverageBuildTime :one
-WITH build_times AS (
-SELECT
-	EXTRACT(EPOCH FROM (pj.completed_at - pj.started_at))::FLOAT AS exec_time_sec,
-	workspace_builds.transition
-FROM
-	workspace_builds
-JOIN template_versions ON
-	workspace_builds.template_version_id = template_versions.id
-JOIN provisioner_jobs pj ON
-	workspace_builds.job_id = pj.id
-WHERE
-	template_versions.template_id = $1 AND
-		(pj.completed_at IS NOT NULL) AND (pj.started_at IS NOT NULL) AND
-		(pj.started_at > $2) AND
-		(pj.canceled_at IS NULL) AND
-		((pj.error IS NULL) OR (pj.error = ''))
-ORDER BY
-	workspace_builds.created_at DESC
-)
-SELECT
-	-- Postgres offers no clear way to DRY this short of a function or other
-	-- complexities.
-	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'start')), -1)::FLOAT AS start_50,
-	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'stop')), -1)::FLOAT AS stop_50,
-	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'delete')), -1)::FLOAT AS delete_50,
+const deleteTailnetAgent = `-- name: DeleteTailnetAgent :exec
+DELETE FROM tailnet_agents WHERE id = $1 AND coordinator_id = $2
+`
+
+type DeleteTailnetAgentParams struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	CoordinatorID uuid.UUID `db:"coordinator_id" json:"coordinator_id"`
+}
+
+func (q *sqlQuerier) DeleteTailnetAgent(ctx context.Context, arg DeleteTailnetAgentParams) error {
+	_, err := q.db.ExecContext(ctx, deleteTailnetAgent, arg.ID, arg.CoordinatorID)
+	return err
+}
+
+const deleteTailnetClient = `-- name: DeleteTailnetClient :exec
+DELETE FROM tailnet_clients WHERE id = $1 AND coordinator_id = $2
+`
+
+type DeleteTailnetClientParams struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	CoordinatorID uuid.UUID `db:"coordinator_id" json:"coordinator_id"`
+}
+
+func (q *sqlQuerier) DeleteTailnetClient(ctx context.Context, arg DeleteTailnetClientParams) error {
+	_, err := q.db.ExecContext(ctx, deleteTailnetClient, arg.ID, arg.CoordinatorID)
+	return err
+}
+
+const deleteTailnetCoordinator = `-- name: DeleteTailnetCoordinator :exec
+DELETE FROM tailnet_coordinators WHERE id = $1
+`
+
+func (q *sqlQuerier) DeleteTailnetCoordinator(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteTailnetCoordinator, id)
+	return err
+}
+
+const deleteTailnetCoordinatorsHeartbeatBefore = `-- name: DeleteTailnetCoordinatorsHeartbeatBefore :exec
+DELETE FROM tailnet_coordinators WHERE heartbeat_at < $1
+`
+
+// Deleting a coordinator deletes the agents and clients connected to it.
+func (q *sqlQuerier) DeleteTailnetCoordinatorsHeartbeatBefore(ctx context.Context, heartbeatAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteTailnetCoordinatorsHeartbeatBefore, heartbeatAt)
+	return err
+}
+
+const getAllTailnetAgents = `-- name: GetAllTailnetAgents :many
+SELECT id, coordinator_id, updated_at, node FROM tailnet_agents ORDER BY id, updated_at DESC
+`
+
+func (q *sqlQuerier) GetAllTailnetAgents(ctx context.Context) ([]TailnetAgent, error) {
+	rows, err := q.db.QueryContext(ctx, getAllTailnetAgents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TailnetAgent
+	for rows.Next() {
+		var i TailnetAgent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CoordinatorID,
+			&i.UpdatedAt,
+			&i.Node,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllTailnetClients = `-- name: GetAllTailnetClients :many
+SELECT id, coordinator_id, agent_id, updated_at, node FROM tailnet_clients ORDER BY agent_id, id
+`
+
+func (q *sqlQuerier) GetAllTailnetClients(ctx context.Context) ([]TailnetClient, error) {
+	rows, err := q.db.QueryContext(ctx, getAllTailnetClients)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TailnetClient
+	for rows.Next() {
+		var i TailnetClient
+		if err := rows.Scan(
+			&i.ID,
+			&i.CoordinatorID,
+			&i.AgentID,
+			&i.UpdatedAt,
+			&i.Node,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTailnetAgents = `-- name: GetTailnetAgents :many
+SELECT id, coordinator_id, updated_at, node FROM tailnet_agents WHERE id = $1 ORDER BY updated_at DESC
+`
+
+// An agent may be connected to more than one coordinator while it reconnects,
+// so the most recent node comes first.
+func (q *sqlQuerier) GetTailnetAgents(ctx context.Context, id uuid.UUID) ([]TailnetAgent, error) {
+	rows, err := q.db.QueryContext(ctx, getTailnetAgents, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TailnetAgent
+	for rows.Next() {
+		var i TailnetAgent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CoordinatorID,
+			&i.UpdatedAt,
+			&i.Node,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTailnetClientsForAgent = `-- name: GetTailnetClientsForAgent :many
+SELECT id, coordinator_id, agent_id, updated_at, node FROM tailnet_clients WHERE agent_id = $1 ORDER BY updated_at DESC
+`
+
+func (q *sqlQuerier) GetTailnetClientsForAgent(ctx context.Context, agentID uuid.UUID) ([]TailnetClient, error) {
+	rows, err := q.db.QueryContext(ctx, getTailnetClientsForAgent, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TailnetClient
+	for rows.Next() {
+		var i TailnetClient
+		if err := rows.Scan(
+			&i.ID,
+			&i.CoordinatorID,
+			&i.AgentID,
+			&i.UpdatedAt,
+			&i.Node,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTailnetCoordinators = `-- name: GetTailnetCoordinators :many
+SELECT id, heartbeat_at FROM tailnet_coordinators ORDER BY heartbeat_at DESC
+`
+
+func (q *sqlQuerier) GetTailnetCoordinators(ctx context.Context) ([]TailnetCoordinator, error) {
+	rows, err := q.db.QueryContext(ctx, getTailnetCoordinators)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TailnetCoordinator
+	for rows.Next() {
+		var i TailnetCoordinator
+		if err := rows.Scan(&i.ID, &i.HeartbeatAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertTailnetAgent = `-- name: UpsertTailnetAgent :one
+INSERT INTO
+	tailnet_agents (id, coordinator_id, updated_at, node)
+VALUES
+	($1, $2, $3, $4)
+ON CONFLICT (id, coordinator_id)
+DO UPDATE SET
+	updated_at = $3,
+	node = $4
+RETURNING id, coordinator_id, updated_at, node
+`
+
+type UpsertTailnetAgentParams struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	CoordinatorID uuid.UUID       `db:"coordinator_id" json:"coordinator_id"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+	Node          json.RawMessage `db:"node" json:"node"`
+}
+
+func (q *sqlQuerier) UpsertTailnetAgent(ctx context.Context, arg UpsertTailnetAgentParams) (TailnetAgent, error) {
+	row := q.db.QueryRowContext(ctx, upsertTailnetAgent,
+		arg.ID,
+		arg.CoordinatorID,
+		arg.UpdatedAt,
+		arg.Node,
+	)
+	var i TailnetAgent
+	err := row.Scan(
+		&i.ID,
+		&i.CoordinatorID,
+		&i.UpdatedAt,
+		&i.Node,
+	)
+	return i, err
+}
+
+const upsertTailnetClient = `-- name: UpsertTailnetClient :one
+INSERT INTO
+	tailnet_clients (id, coordinator_id, agent_id, updated_at, node)
+VALUES
+	($1, $2, $3, $4, $5)
+ON CONFLICT (id, coordinator_id)
+DO UPDATE SET
+	agent_id = $3,
+	updated_at = $4,
+	node = $5
+RETURNING id, coordinator_id, agent_id, updated_at, node
+`
+
+type UpsertTailnetClientParams struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	CoordinatorID uuid.UUID       `db:"coordinator_id" json:"coordinator_id"`
+	AgentID       uuid.UUID       `db:"agent_id" json:"agent_id"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+	Node          json.RawMessage `db:"node" json:"node"`
+}
+
+func (q *sqlQuerier) UpsertTailnetClient(ctx context.Context, arg UpsertTailnetClientParams) (TailnetClient, error) {
+	row := q.db.QueryRowContext(ctx, upsertTailnetClient,
+		arg.ID,
+		arg.CoordinatorID,
+		arg.AgentID,
+		arg.UpdatedAt,
+		arg.Node,
+	)
+	var i TailnetClient
+	err := row.Scan(
+		&i.ID,
+		&i.CoordinatorID,
+		&i.AgentID,
+		&i.UpdatedAt,
+		&i.Node,
+	)
+	return i, err
+}
+
+const upsertTailnetCoordinator = `-- name: UpsertTailnetCoordinator :one
+INSERT INTO
+	tailnet_coordinators (id, heartbeat_at)
+VALUES
+	($1, $2)
+ON CONFLICT (id)
+DO UPDATE SET
+	heartbeat_at = $2
+RETURNING id, heartbeat_at
+`
+
+type UpsertTailnetCoordinatorParams struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	HeartbeatAt time.Time `db:"heartbeat_at" json:"heartbeat_at"`
+}
+
+func (q *sqlQuerier) UpsertTailnetCoordinator(ctx context.Context, arg UpsertTailnetCoordinatorParams) (TailnetCoordinator, error) {
+	row := q.db.QueryRowContext(ctx, upsertTailnetCoordinator, arg.ID, arg.HeartbeatAt)
+	var i TailnetCoordinator
+	err := row.Scan(&i.ID, &i.HeartbeatAt)
+	return i, err
+}
+
+const getTemplateAverageBuildTime = `-- name: GetTemplateAverageBuildTime :one
+WITH build_times AS (
+SELECT
+	EXTRACT(EPOCH FROM (pj.completed_at - pj.started_at))::FLOAT AS exec_time_sec,
+	workspace_builds.transition
+FROM
+	workspace_builds
+JOIN template_versions ON
+	workspace_builds.template_version_id = template_versions.id
+JOIN provisioner_jobs pj ON
+	workspace_builds.job_id = pj.id
+WHERE
+	template_versions.template_id = $1 AND
+		(pj.completed_at IS NOT NULL) AND (pj.started_at IS NOT NULL) AND
+		(pj.started_at > $2) AND
+		(pj.canceled_at IS NULL) AND
+		((pj.error IS NULL) OR (pj.error = ''))
+ORDER BY
+	workspace_builds.created_at DESC
+)
+SELECT
+	-- Postgres offers no clear way to DRY this short of a function or other
+	-- complexities.
+	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'start')), -1)::FLOAT AS start_50,
+	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'stop')), -1)::FLOAT AS stop_50,
+	coalesce((PERCENTILE_DISC(0.5) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'delete')), -1)::FLOAT AS delete_50,
 	coalesce((PERCENTILE_DISC(0.95) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'start')), -1)::FLOAT AS start_95,
 	coalesce((PERCENTILE_DISC(0.95) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'stop')), -1)::FLOAT AS stop_95,
 	coalesce((PERCENTILE_DISC(0.95) WITHIN GROUP(ORDER BY exec_time_sec) FILTER (WHERE transition = 'delete')), -1)::FLOAT AS delete_95
@@ -3195,7 +4369,7 @@ func (q *sqlQuerier) GetTemplateAverageBuildTime(ctx context.Context, arg GetTem
 
 const getTemplateByID = `-- name: GetTemplateByID :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, delete_ttl, autostop_requirement, required_promotion_approvals
 FROM
 	templates
 WHERE
@@ -3225,13 +4399,17 @@ func (q *sqlQuerier) GetTemplateByID(ctx context.Context, id uuid.UUID) (Templat
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.DeleteTTL,
+		&i.AutostopRequirement,
+		&i.RequiredPromotionApprovals,
 	)
 	return i, err
 }
 
 const getTemplateByOrganizationAndName = `-- name: GetTemplateByOrganizationAndName :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, delete_ttl, autostop_requirement, required_promotion_approvals
 FROM
 	templates
 WHERE
@@ -3269,12 +4447,16 @@ func (q *sqlQuerier) GetTemplateByOrganizationAndName(ctx context.Context, arg G
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.DeleteTTL,
+		&i.AutostopRequirement,
+		&i.RequiredPromotionApprovals,
 	)
 	return i, err
 }
 
 const getTemplates = `-- name: GetTemplates :many
-SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl FROM templates
+SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, delete_ttl, autostop_requirement, required_promotion_approvals FROM templates
 ORDER BY (name, id) ASC
 `
 
@@ -3305,6 +4487,10 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 			&i.DisplayName,
 			&i.AllowUserCancelWorkspaceJobs,
 			&i.MaxTTL,
+			&i.InactivityTTL,
+			&i.DeleteTTL,
+			&i.AutostopRequirement,
+			&i.RequiredPromotionApprovals,
 		); err != nil {
 			return nil, err
 		}
@@ -3321,7 +4507,7 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 
 const getTemplatesWithFilter = `-- name: GetTemplatesWithFilter :many
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, delete_ttl, autostop_requirement, required_promotion_approvals
 FROM
 	templates
 WHERE
@@ -3389,6 +4575,10 @@ func (q *sqlQuerier) GetTemplatesWithFilter(ctx context.Context, arg GetTemplate
 			&i.DisplayName,
 			&i.AllowUserCancelWorkspaceJobs,
 			&i.MaxTTL,
+			&i.InactivityTTL,
+			&i.DeleteTTL,
+			&i.AutostopRequirement,
+			&i.RequiredPromotionApprovals,
 		); err != nil {
 			return nil, err
 		}
@@ -3422,7 +4612,7 @@ INSERT INTO
 		allow_user_cancel_workspace_jobs
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, delete_ttl, autostop_requirement, required_promotion_approvals
 `
 
 type InsertTemplateParams struct {
@@ -3478,6 +4668,10 @@ func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParam
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.DeleteTTL,
+		&i.AutostopRequirement,
+		&i.RequiredPromotionApprovals,
 	)
 	return i, err
 }
@@ -3491,7 +4685,7 @@ SET
 WHERE
 	id = $3
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, delete_ttl, autostop_requirement, required_promotion_approvals
 `
 
 type UpdateTemplateACLByIDParams struct {
@@ -3521,6 +4715,10 @@ func (q *sqlQuerier) UpdateTemplateACLByID(ctx context.Context, arg UpdateTempla
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.DeleteTTL,
+		&i.AutostopRequirement,
+		&i.RequiredPromotionApprovals,
 	)
 	return i, err
 }
@@ -3576,11 +4774,12 @@ SET
 	name = $4,
 	icon = $5,
 	display_name = $6,
-	allow_user_cancel_workspace_jobs = $7
+	allow_user_cancel_workspace_jobs = $7,
+	required_promotion_approvals = $8
 WHERE
 	id = $1
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, delete_ttl, autostop_requirement, required_promotion_approvals
 `
 
 type UpdateTemplateMetaByIDParams struct {
@@ -3591,6 +4790,7 @@ type UpdateTemplateMetaByIDParams struct {
 	Icon                         string    `db:"icon" json:"icon"`
 	DisplayName                  string    `db:"display_name" json:"display_name"`
 	AllowUserCancelWorkspaceJobs bool      `db:"allow_user_cancel_workspace_jobs" json:"allow_user_cancel_workspace_jobs"`
+	RequiredPromotionApprovals   int32     `db:"required_promotion_approvals" json:"required_promotion_approvals"`
 }
 
 func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTemplateMetaByIDParams) (Template, error) {
@@ -3602,6 +4802,7 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		arg.Icon,
 		arg.DisplayName,
 		arg.AllowUserCancelWorkspaceJobs,
+		arg.RequiredPromotionApprovals,
 	)
 	var i Template
 	err := row.Scan(
@@ -3622,6 +4823,10 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.DeleteTTL,
+		&i.AutostopRequirement,
+		&i.RequiredPromotionApprovals,
 	)
 	return i, err
 }
@@ -3632,18 +4837,24 @@ UPDATE
 SET
 	updated_at = $2,
 	default_ttl = $3,
-	max_ttl = $4
+	max_ttl = $4,
+	inactivity_ttl = $5,
+	delete_ttl = $6,
+	autostop_requirement = $7
 WHERE
 	id = $1
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, delete_ttl, autostop_requirement, required_promotion_approvals
 `
 
 type UpdateTemplateScheduleByIDParams struct {
-	ID         uuid.UUID `db:"id" json:"id"`
-	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
-	DefaultTTL int64     `db:"default_ttl" json:"default_ttl"`
-	MaxTTL     int64     `db:"max_ttl" json:"max_ttl"`
+	ID                  uuid.UUID `db:"id" json:"id"`
+	UpdatedAt           time.Time `db:"updated_at" json:"updated_at"`
+	DefaultTTL          int64     `db:"default_ttl" json:"default_ttl"`
+	MaxTTL              int64     `db:"max_ttl" json:"max_ttl"`
+	InactivityTTL       int64     `db:"inactivity_ttl" json:"inactivity_ttl"`
+	DeleteTTL           int64     `db:"delete_ttl" json:"delete_ttl"`
+	AutostopRequirement string    `db:"autostop_requirement" json:"autostop_requirement"`
 }
 
 func (q *sqlQuerier) UpdateTemplateScheduleByID(ctx context.Context, arg UpdateTemplateScheduleByIDParams) (Template, error) {
@@ -3652,6 +4863,9 @@ func (q *sqlQuerier) UpdateTemplateScheduleByID(ctx context.Context, arg UpdateT
 		arg.UpdatedAt,
 		arg.DefaultTTL,
 		arg.MaxTTL,
+		arg.InactivityTTL,
+		arg.DeleteTTL,
+		arg.AutostopRequirement,
 	)
 	var i Template
 	err := row.Scan(
@@ -3672,6 +4886,10 @@ func (q *sqlQuerier) UpdateTemplateScheduleByID(ctx context.Context, arg UpdateT
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.DeleteTTL,
+		&i.AutostopRequirement,
+		&i.RequiredPromotionApprovals,
 	)
 	return i, err
 }
@@ -3815,59 +5033,367 @@ func (q *sqlQuerier) InsertTemplateVersionParameter(ctx context.Context, arg Ins
 	return i, err
 }
 
-const getPreviousTemplateVersion = `-- name: GetPreviousTemplateVersion :one
+const getPendingTemplateVersionPromotionByTemplateVersionID = `-- name: GetPendingTemplateVersionPromotionByTemplateVersionID :one
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+	id, created_at, updated_at, template_id, template_version_id, requester_id, dry_run_job_id, approver_ids, status, promoted_at
 FROM
-	template_versions
+	template_version_promotions
 WHERE
-	created_at < (
-		SELECT created_at
-		FROM template_versions AS tv
-		WHERE tv.organization_id = $1 AND tv.name = $2 AND tv.template_id = $3
-	)
-	AND organization_id = $1
-	AND template_id = $3
-ORDER BY created_at DESC
-LIMIT 1
+	template_version_id = $1
+	AND status = 'pending'
+LIMIT
+	1
 `
 
-type GetPreviousTemplateVersionParams struct {
-	OrganizationID uuid.UUID     `db:"organization_id" json:"organization_id"`
-	Name           string        `db:"name" json:"name"`
-	TemplateID     uuid.NullUUID `db:"template_id" json:"template_id"`
-}
-
-func (q *sqlQuerier) GetPreviousTemplateVersion(ctx context.Context, arg GetPreviousTemplateVersionParams) (TemplateVersion, error) {
-	row := q.db.QueryRowContext(ctx, getPreviousTemplateVersion, arg.OrganizationID, arg.Name, arg.TemplateID)
-	var i TemplateVersion
+func (q *sqlQuerier) GetPendingTemplateVersionPromotionByTemplateVersionID(ctx context.Context, templateVersionID uuid.UUID) (TemplateVersionPromotion, error) {
+	row := q.db.QueryRowContext(ctx, getPendingTemplateVersionPromotionByTemplateVersionID, templateVersionID)
+	var i TemplateVersionPromotion
 	err := row.Scan(
 		&i.ID,
-		&i.TemplateID,
-		&i.OrganizationID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.Name,
-		&i.Readme,
-		&i.JobID,
-		&i.CreatedBy,
-		pq.Array(&i.GitAuthProviders),
+		&i.TemplateID,
+		&i.TemplateVersionID,
+		&i.RequesterID,
+		&i.DryRunJobID,
+		pq.Array(&i.ApproverIDs),
+		&i.Status,
+		&i.PromotedAt,
 	)
 	return i, err
 }
 
-const getTemplateVersionByID = `-- name: GetTemplateVersionByID :one
+const getTemplateVersionPromotionByID = `-- name: GetTemplateVersionPromotionByID :one
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+	id, created_at, updated_at, template_id, template_version_id, requester_id, dry_run_job_id, approver_ids, status, promoted_at
 FROM
-	template_versions
+	template_version_promotions
 WHERE
 	id = $1
+LIMIT
+	1
 `
 
-func (q *sqlQuerier) GetTemplateVersionByID(ctx context.Context, id uuid.UUID) (TemplateVersion, error) {
-	row := q.db.QueryRowContext(ctx, getTemplateVersionByID, id)
-	var i TemplateVersion
+func (q *sqlQuerier) GetTemplateVersionPromotionByID(ctx context.Context, id uuid.UUID) (TemplateVersionPromotion, error) {
+	row := q.db.QueryRowContext(ctx, getTemplateVersionPromotionByID, id)
+	var i TemplateVersionPromotion
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TemplateID,
+		&i.TemplateVersionID,
+		&i.RequesterID,
+		&i.DryRunJobID,
+		pq.Array(&i.ApproverIDs),
+		&i.Status,
+		&i.PromotedAt,
+	)
+	return i, err
+}
+
+const getTemplateVersionPromotionsByTemplateID = `-- name: GetTemplateVersionPromotionsByTemplateID :many
+SELECT
+	id, created_at, updated_at, template_id, template_version_id, requester_id, dry_run_job_id, approver_ids, status, promoted_at
+FROM
+	template_version_promotions
+WHERE
+	template_id = $1
+ORDER BY
+	created_at DESC
+`
+
+func (q *sqlQuerier) GetTemplateVersionPromotionsByTemplateID(ctx context.Context, templateID uuid.UUID) ([]TemplateVersionPromotion, error) {
+	rows, err := q.db.QueryContext(ctx, getTemplateVersionPromotionsByTemplateID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TemplateVersionPromotion
+	for rows.Next() {
+		var i TemplateVersionPromotion
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TemplateID,
+			&i.TemplateVersionID,
+			&i.RequesterID,
+			&i.DryRunJobID,
+			pq.Array(&i.ApproverIDs),
+			&i.Status,
+			&i.PromotedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertTemplateVersionPromotion = `-- name: InsertTemplateVersionPromotion :one
+INSERT INTO
+	template_version_promotions (
+		id,
+		created_at,
+		updated_at,
+		template_id,
+		template_version_id,
+		requester_id,
+		dry_run_job_id,
+		status
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at, updated_at, template_id, template_version_id, requester_id, dry_run_job_id, approver_ids, status, promoted_at
+`
+
+type InsertTemplateVersionPromotionParams struct {
+	ID                uuid.UUID                      `db:"id" json:"id"`
+	CreatedAt         time.Time                      `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time                      `db:"updated_at" json:"updated_at"`
+	TemplateID        uuid.UUID                      `db:"template_id" json:"template_id"`
+	TemplateVersionID uuid.UUID                      `db:"template_version_id" json:"template_version_id"`
+	RequesterID       uuid.UUID                      `db:"requester_id" json:"requester_id"`
+	DryRunJobID       uuid.UUID                      `db:"dry_run_job_id" json:"dry_run_job_id"`
+	Status            TemplateVersionPromotionStatus `db:"status" json:"status"`
+}
+
+func (q *sqlQuerier) InsertTemplateVersionPromotion(ctx context.Context, arg InsertTemplateVersionPromotionParams) (TemplateVersionPromotion, error) {
+	row := q.db.QueryRowContext(ctx, insertTemplateVersionPromotion,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.TemplateID,
+		arg.TemplateVersionID,
+		arg.RequesterID,
+		arg.DryRunJobID,
+		arg.Status,
+	)
+	var i TemplateVersionPromotion
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TemplateID,
+		&i.TemplateVersionID,
+		&i.RequesterID,
+		&i.DryRunJobID,
+		pq.Array(&i.ApproverIDs),
+		&i.Status,
+		&i.PromotedAt,
+	)
+	return i, err
+}
+
+const insertTemplateVersionPromotionApproval = `-- name: InsertTemplateVersionPromotionApproval :one
+UPDATE
+	template_version_promotions
+SET
+	updated_at = $1,
+	approver_ids = CASE
+		WHEN $2 :: uuid = ANY(approver_ids) THEN approver_ids
+		ELSE array_append(approver_ids, $2 :: uuid)
+	END
+WHERE
+	id = $3
+RETURNING
+	id, created_at, updated_at, template_id, template_version_id, requester_id, dry_run_job_id, approver_ids, status, promoted_at
+`
+
+type InsertTemplateVersionPromotionApprovalParams struct {
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	ApproverID uuid.UUID `db:"approver_id" json:"approver_id"`
+	ID         uuid.UUID `db:"id" json:"id"`
+}
+
+// Approvals are appended rather than overwritten, so concurrent approvals
+// aren't lost. Approving twice has no effect.
+func (q *sqlQuerier) InsertTemplateVersionPromotionApproval(ctx context.Context, arg InsertTemplateVersionPromotionApprovalParams) (TemplateVersionPromotion, error) {
+	row := q.db.QueryRowContext(ctx, insertTemplateVersionPromotionApproval, arg.UpdatedAt, arg.ApproverID, arg.ID)
+	var i TemplateVersionPromotion
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TemplateID,
+		&i.TemplateVersionID,
+		&i.RequesterID,
+		&i.DryRunJobID,
+		pq.Array(&i.ApproverIDs),
+		&i.Status,
+		&i.PromotedAt,
+	)
+	return i, err
+}
+
+const promoteTemplateVersionPromotion = `-- name: PromoteTemplateVersionPromotion :one
+UPDATE
+	template_version_promotions
+SET
+	status = 'promoted',
+	updated_at = $1,
+	promoted_at = $2
+WHERE
+	id = $3
+	AND status = 'pending'
+RETURNING
+	id, created_at, updated_at, template_id, template_version_id, requester_id, dry_run_job_id, approver_ids, status, promoted_at
+`
+
+type PromoteTemplateVersionPromotionParams struct {
+	UpdatedAt  time.Time    `db:"updated_at" json:"updated_at"`
+	PromotedAt sql.NullTime `db:"promoted_at" json:"promoted_at"`
+	ID         uuid.UUID    `db:"id" json:"id"`
+}
+
+// The status is only changed while the promotion is pending, so concurrent
+// approvals and dry-run completions promote the version once.
+func (q *sqlQuerier) PromoteTemplateVersionPromotion(ctx context.Context, arg PromoteTemplateVersionPromotionParams) (TemplateVersionPromotion, error) {
+	row := q.db.QueryRowContext(ctx, promoteTemplateVersionPromotion, arg.UpdatedAt, arg.PromotedAt, arg.ID)
+	var i TemplateVersionPromotion
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TemplateID,
+		&i.TemplateVersionID,
+		&i.RequesterID,
+		&i.DryRunJobID,
+		pq.Array(&i.ApproverIDs),
+		&i.Status,
+		&i.PromotedAt,
+	)
+	return i, err
+}
+
+const supersedeTemplateVersionPromotions = `-- name: SupersedeTemplateVersionPromotions :exec
+UPDATE
+	template_version_promotions
+SET
+	status = 'superseded',
+	updated_at = $1
+WHERE
+	template_id = $2
+	AND status = 'pending'
+	AND id != $3
+`
+
+type SupersedeTemplateVersionPromotionsParams struct {
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	TemplateID  uuid.UUID `db:"template_id" json:"template_id"`
+	PromotionID uuid.UUID `db:"promotion_id" json:"promotion_id"`
+}
+
+func (q *sqlQuerier) SupersedeTemplateVersionPromotions(ctx context.Context, arg SupersedeTemplateVersionPromotionsParams) error {
+	_, err := q.db.ExecContext(ctx, supersedeTemplateVersionPromotions, arg.UpdatedAt, arg.TemplateID, arg.PromotionID)
+	return err
+}
+
+const updateTemplateVersionPromotionByID = `-- name: UpdateTemplateVersionPromotionByID :one
+UPDATE
+	template_version_promotions
+SET
+	updated_at = $2,
+	dry_run_job_id = $3,
+	status = $4,
+	promoted_at = $5
+WHERE
+	id = $1
+RETURNING
+	id, created_at, updated_at, template_id, template_version_id, requester_id, dry_run_job_id, approver_ids, status, promoted_at
+`
+
+type UpdateTemplateVersionPromotionByIDParams struct {
+	ID          uuid.UUID                      `db:"id" json:"id"`
+	UpdatedAt   time.Time                      `db:"updated_at" json:"updated_at"`
+	DryRunJobID uuid.UUID                      `db:"dry_run_job_id" json:"dry_run_job_id"`
+	Status      TemplateVersionPromotionStatus `db:"status" json:"status"`
+	PromotedAt  sql.NullTime                   `db:"promoted_at" json:"promoted_at"`
+}
+
+func (q *sqlQuerier) UpdateTemplateVersionPromotionByID(ctx context.Context, arg UpdateTemplateVersionPromotionByIDParams) (TemplateVersionPromotion, error) {
+	row := q.db.QueryRowContext(ctx, updateTemplateVersionPromotionByID,
+		arg.ID,
+		arg.UpdatedAt,
+		arg.DryRunJobID,
+		arg.Status,
+		arg.PromotedAt,
+	)
+	var i TemplateVersionPromotion
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TemplateID,
+		&i.TemplateVersionID,
+		&i.RequesterID,
+		&i.DryRunJobID,
+		pq.Array(&i.ApproverIDs),
+		&i.Status,
+		&i.PromotedAt,
+	)
+	return i, err
+}
+
+const getPreviousTemplateVersion = `-- name: GetPreviousTemplateVersion :one
+SELECT
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+FROM
+	template_versions
+WHERE
+	created_at < (
+		SELECT created_at
+		FROM template_versions AS tv
+		WHERE tv.organization_id = $1 AND tv.name = $2 AND tv.template_id = $3
+	)
+	AND organization_id = $1
+	AND template_id = $3
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetPreviousTemplateVersionParams struct {
+	OrganizationID uuid.UUID     `db:"organization_id" json:"organization_id"`
+	Name           string        `db:"name" json:"name"`
+	TemplateID     uuid.NullUUID `db:"template_id" json:"template_id"`
+}
+
+func (q *sqlQuerier) GetPreviousTemplateVersion(ctx context.Context, arg GetPreviousTemplateVersionParams) (TemplateVersion, error) {
+	row := q.db.QueryRowContext(ctx, getPreviousTemplateVersion, arg.OrganizationID, arg.Name, arg.TemplateID)
+	var i TemplateVersion
+	err := row.Scan(
+		&i.ID,
+		&i.TemplateID,
+		&i.OrganizationID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Readme,
+		&i.JobID,
+		&i.CreatedBy,
+		pq.Array(&i.GitAuthProviders),
+	)
+	return i, err
+}
+
+const getTemplateVersionByID = `-- name: GetTemplateVersionByID :one
+SELECT
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+FROM
+	template_versions
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetTemplateVersionByID(ctx context.Context, id uuid.UUID) (TemplateVersion, error) {
+	row := q.db.QueryRowContext(ctx, getTemplateVersionByID, id)
+	var i TemplateVersion
 	err := row.Scan(
 		&i.ID,
 		&i.TemplateID,
@@ -5139,71 +6665,245 @@ func (q *sqlQuerier) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusP
 	return i, err
 }
 
-const deleteOldWorkspaceAgentStartupLogs = `-- name: DeleteOldWorkspaceAgentStartupLogs :exec
-DELETE FROM workspace_agent_startup_logs WHERE agent_id IN
-	(SELECT id FROM workspace_agents WHERE last_connected_at IS NOT NULL
-		AND last_connected_at < NOW() - INTERVAL '7 day')
+const deleteWorkspaceAgentPortShare = `-- name: DeleteWorkspaceAgentPortShare :exec
+DELETE FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+	AND agent_name = $2
+	AND port = $3
 `
 
-// If an agent hasn't connected in the last 7 days, we purge it's logs.
-// Logs can take up a lot of space, so it's important we clean up frequently.
-func (q *sqlQuerier) DeleteOldWorkspaceAgentStartupLogs(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, deleteOldWorkspaceAgentStartupLogs)
+type DeleteWorkspaceAgentPortShareParams struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	AgentName   string    `db:"agent_name" json:"agent_name"`
+	Port        int32     `db:"port" json:"port"`
+}
+
+func (q *sqlQuerier) DeleteWorkspaceAgentPortShare(ctx context.Context, arg DeleteWorkspaceAgentPortShareParams) error {
+	_, err := q.db.ExecContext(ctx, deleteWorkspaceAgentPortShare, arg.WorkspaceID, arg.AgentName, arg.Port)
 	return err
 }
 
-const getWorkspaceAgentByAuthToken = `-- name: GetWorkspaceAgentByAuthToken :one
+const getWorkspaceAgentPortShareRules = `-- name: GetWorkspaceAgentPortShareRules :many
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
+	workspace_agent_id, port, share_level
 FROM
-	workspace_agents
+	workspace_agent_port_share_rules
 WHERE
-	auth_token = $1
+	workspace_agent_id = $1
 ORDER BY
-	created_at DESC
+	port ASC
 `
 
-func (q *sqlQuerier) GetWorkspaceAgentByAuthToken(ctx context.Context, authToken uuid.UUID) (WorkspaceAgent, error) {
-	row := q.db.QueryRowContext(ctx, getWorkspaceAgentByAuthToken, authToken)
-	var i WorkspaceAgent
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Name,
-		&i.FirstConnectedAt,
-		&i.LastConnectedAt,
-		&i.DisconnectedAt,
-		&i.ResourceID,
-		&i.AuthToken,
-		&i.AuthInstanceID,
-		&i.Architecture,
-		&i.EnvironmentVariables,
-		&i.OperatingSystem,
-		&i.StartupScript,
-		&i.InstanceMetadata,
-		&i.ResourceMetadata,
-		&i.Directory,
-		&i.Version,
-		&i.LastConnectedReplicaID,
-		&i.ConnectionTimeoutSeconds,
-		&i.TroubleshootingURL,
-		&i.MOTDFile,
-		&i.LifecycleState,
-		&i.LoginBeforeReady,
+func (q *sqlQuerier) GetWorkspaceAgentPortShareRules(ctx context.Context, workspaceAgentID uuid.UUID) ([]WorkspaceAgentPortShareRule, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentPortShareRules, workspaceAgentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentPortShareRule
+	for rows.Next() {
+		var i WorkspaceAgentPortShareRule
+		if err := rows.Scan(&i.WorkspaceAgentID, &i.Port, &i.ShareLevel); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkspaceAgentPortShares = `-- name: GetWorkspaceAgentPortShares :many
+SELECT
+	workspace_id, agent_name, port, share_level, created_at, updated_at
+FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+	AND agent_name = $2
+ORDER BY
+	port ASC
+`
+
+type GetWorkspaceAgentPortSharesParams struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	AgentName   string    `db:"agent_name" json:"agent_name"`
+}
+
+func (q *sqlQuerier) GetWorkspaceAgentPortShares(ctx context.Context, arg GetWorkspaceAgentPortSharesParams) ([]WorkspaceAgentPortShare, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentPortShares, arg.WorkspaceID, arg.AgentName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentPortShare
+	for rows.Next() {
+		var i WorkspaceAgentPortShare
+		if err := rows.Scan(
+			&i.WorkspaceID,
+			&i.AgentName,
+			&i.Port,
+			&i.ShareLevel,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceAgentPortShareRule = `-- name: InsertWorkspaceAgentPortShareRule :exec
+INSERT INTO
+	workspace_agent_port_share_rules (
+		workspace_agent_id,
+		port,
+		share_level
+	)
+VALUES
+	($1, $2, $3)
+`
+
+type InsertWorkspaceAgentPortShareRuleParams struct {
+	WorkspaceAgentID uuid.UUID       `db:"workspace_agent_id" json:"workspace_agent_id"`
+	Port             int32           `db:"port" json:"port"`
+	ShareLevel       AppSharingLevel `db:"share_level" json:"share_level"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceAgentPortShareRule(ctx context.Context, arg InsertWorkspaceAgentPortShareRuleParams) error {
+	_, err := q.db.ExecContext(ctx, insertWorkspaceAgentPortShareRule, arg.WorkspaceAgentID, arg.Port, arg.ShareLevel)
+	return err
+}
+
+const upsertWorkspaceAgentPortShare = `-- name: UpsertWorkspaceAgentPortShare :one
+INSERT INTO
+	workspace_agent_port_shares (
+		workspace_id,
+		agent_name,
+		port,
+		share_level,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6)
+ON CONFLICT (workspace_id, agent_name, port)
+DO UPDATE SET
+	share_level = $4,
+	updated_at = $6
+RETURNING workspace_id, agent_name, port, share_level, created_at, updated_at
+`
+
+type UpsertWorkspaceAgentPortShareParams struct {
+	WorkspaceID uuid.UUID       `db:"workspace_id" json:"workspace_id"`
+	AgentName   string          `db:"agent_name" json:"agent_name"`
+	Port        int32           `db:"port" json:"port"`
+	ShareLevel  AppSharingLevel `db:"share_level" json:"share_level"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpsertWorkspaceAgentPortShare(ctx context.Context, arg UpsertWorkspaceAgentPortShareParams) (WorkspaceAgentPortShare, error) {
+	row := q.db.QueryRowContext(ctx, upsertWorkspaceAgentPortShare,
+		arg.WorkspaceID,
+		arg.AgentName,
+		arg.Port,
+		arg.ShareLevel,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i WorkspaceAgentPortShare
+	err := row.Scan(
+		&i.WorkspaceID,
+		&i.AgentName,
+		&i.Port,
+		&i.ShareLevel,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteOldWorkspaceAgentStartupLogs = `-- name: DeleteOldWorkspaceAgentStartupLogs :exec
+DELETE FROM workspace_agent_startup_logs WHERE agent_id IN
+	(SELECT id FROM workspace_agents WHERE last_connected_at IS NOT NULL
+		AND last_connected_at < NOW() - INTERVAL '7 day')
+`
+
+// If an agent hasn't connected in the last 7 days, we purge it's logs.
+// Logs can take up a lot of space, so it's important we clean up frequently.
+func (q *sqlQuerier) DeleteOldWorkspaceAgentStartupLogs(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteOldWorkspaceAgentStartupLogs)
+	return err
+}
+
+const getWorkspaceAgentByAuthToken = `-- name: GetWorkspaceAgentByAuthToken :one
+SELECT
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, reconnecting_pty_buffer_size, session_recording
+FROM
+	workspace_agents
+WHERE
+	auth_token = $1
+ORDER BY
+	created_at DESC
+`
+
+func (q *sqlQuerier) GetWorkspaceAgentByAuthToken(ctx context.Context, authToken uuid.UUID) (WorkspaceAgent, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceAgentByAuthToken, authToken)
+	var i WorkspaceAgent
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.FirstConnectedAt,
+		&i.LastConnectedAt,
+		&i.DisconnectedAt,
+		&i.ResourceID,
+		&i.AuthToken,
+		&i.AuthInstanceID,
+		&i.Architecture,
+		&i.EnvironmentVariables,
+		&i.OperatingSystem,
+		&i.StartupScript,
+		&i.InstanceMetadata,
+		&i.ResourceMetadata,
+		&i.Directory,
+		&i.Version,
+		&i.LastConnectedReplicaID,
+		&i.ConnectionTimeoutSeconds,
+		&i.TroubleshootingURL,
+		&i.MOTDFile,
+		&i.LifecycleState,
+		&i.LoginBeforeReady,
 		&i.StartupScriptTimeoutSeconds,
 		&i.ExpandedDirectory,
 		&i.ShutdownScript,
 		&i.ShutdownScriptTimeoutSeconds,
 		&i.StartupLogsLength,
 		&i.StartupLogsOverflowed,
+		&i.ReconnectingPTYBufferSize,
+		&i.SessionRecording,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByID = `-- name: GetWorkspaceAgentByID :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, reconnecting_pty_buffer_size, session_recording
 FROM
 	workspace_agents
 WHERE
@@ -5244,13 +6944,15 @@ func (q *sqlQuerier) GetWorkspaceAgentByID(ctx context.Context, id uuid.UUID) (W
 		&i.ShutdownScriptTimeoutSeconds,
 		&i.StartupLogsLength,
 		&i.StartupLogsOverflowed,
+		&i.ReconnectingPTYBufferSize,
+		&i.SessionRecording,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByInstanceID = `-- name: GetWorkspaceAgentByInstanceID :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, reconnecting_pty_buffer_size, session_recording
 FROM
 	workspace_agents
 WHERE
@@ -5293,10 +6995,54 @@ func (q *sqlQuerier) GetWorkspaceAgentByInstanceID(ctx context.Context, authInst
 		&i.ShutdownScriptTimeoutSeconds,
 		&i.StartupLogsLength,
 		&i.StartupLogsOverflowed,
+		&i.ReconnectingPTYBufferSize,
+		&i.SessionRecording,
 	)
 	return i, err
 }
 
+const getWorkspaceAgentMetadata = `-- name: GetWorkspaceAgentMetadata :many
+SELECT
+	workspace_agent_id, display_name, key, script, value, error, timeout, interval, collected_at
+FROM
+	workspace_agent_metadata
+WHERE
+	workspace_agent_id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceAgentMetadata(ctx context.Context, workspaceAgentID uuid.UUID) ([]WorkspaceAgentMetadatum, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentMetadata, workspaceAgentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentMetadatum
+	for rows.Next() {
+		var i WorkspaceAgentMetadatum
+		if err := rows.Scan(
+			&i.WorkspaceAgentID,
+			&i.DisplayName,
+			&i.Key,
+			&i.Script,
+			&i.Value,
+			&i.Error,
+			&i.Timeout,
+			&i.Interval,
+			&i.CollectedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWorkspaceAgentStartupLogsAfter = `-- name: GetWorkspaceAgentStartupLogsAfter :many
 SELECT
 	agent_id, created_at, output, id
@@ -5344,7 +7090,7 @@ func (q *sqlQuerier) GetWorkspaceAgentStartupLogsAfter(ctx context.Context, arg
 
 const getWorkspaceAgentsByResourceIDs = `-- name: GetWorkspaceAgentsByResourceIDs :many
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, reconnecting_pty_buffer_size, session_recording
 FROM
 	workspace_agents
 WHERE
@@ -5391,6 +7137,8 @@ func (q *sqlQuerier) GetWorkspaceAgentsByResourceIDs(ctx context.Context, ids []
 			&i.ShutdownScriptTimeoutSeconds,
 			&i.StartupLogsLength,
 			&i.StartupLogsOverflowed,
+			&i.ReconnectingPTYBufferSize,
+			&i.SessionRecording,
 		); err != nil {
 			return nil, err
 		}
@@ -5406,7 +7154,7 @@ func (q *sqlQuerier) GetWorkspaceAgentsByResourceIDs(ctx context.Context, ids []
 }
 
 const getWorkspaceAgentsCreatedAfter = `-- name: GetWorkspaceAgentsCreatedAfter :many
-SELECT id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed FROM workspace_agents WHERE created_at > $1
+SELECT id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, reconnecting_pty_buffer_size, session_recording FROM workspace_agents WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceAgent, error) {
@@ -5449,6 +7197,8 @@ func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, created
 			&i.ShutdownScriptTimeoutSeconds,
 			&i.StartupLogsLength,
 			&i.StartupLogsOverflowed,
+			&i.ReconnectingPTYBufferSize,
+			&i.SessionRecording,
 		); err != nil {
 			return nil, err
 		}
@@ -5465,7 +7215,7 @@ func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, created
 
 const getWorkspaceAgentsInLatestBuildByWorkspaceID = `-- name: GetWorkspaceAgentsInLatestBuildByWorkspaceID :many
 SELECT
-	workspace_agents.id, workspace_agents.created_at, workspace_agents.updated_at, workspace_agents.name, workspace_agents.first_connected_at, workspace_agents.last_connected_at, workspace_agents.disconnected_at, workspace_agents.resource_id, workspace_agents.auth_token, workspace_agents.auth_instance_id, workspace_agents.architecture, workspace_agents.environment_variables, workspace_agents.operating_system, workspace_agents.startup_script, workspace_agents.instance_metadata, workspace_agents.resource_metadata, workspace_agents.directory, workspace_agents.version, workspace_agents.last_connected_replica_id, workspace_agents.connection_timeout_seconds, workspace_agents.troubleshooting_url, workspace_agents.motd_file, workspace_agents.lifecycle_state, workspace_agents.login_before_ready, workspace_agents.startup_script_timeout_seconds, workspace_agents.expanded_directory, workspace_agents.shutdown_script, workspace_agents.shutdown_script_timeout_seconds, workspace_agents.startup_logs_length, workspace_agents.startup_logs_overflowed
+	workspace_agents.id, workspace_agents.created_at, workspace_agents.updated_at, workspace_agents.name, workspace_agents.first_connected_at, workspace_agents.last_connected_at, workspace_agents.disconnected_at, workspace_agents.resource_id, workspace_agents.auth_token, workspace_agents.auth_instance_id, workspace_agents.architecture, workspace_agents.environment_variables, workspace_agents.operating_system, workspace_agents.startup_script, workspace_agents.instance_metadata, workspace_agents.resource_metadata, workspace_agents.directory, workspace_agents.version, workspace_agents.last_connected_replica_id, workspace_agents.connection_timeout_seconds, workspace_agents.troubleshooting_url, workspace_agents.motd_file, workspace_agents.lifecycle_state, workspace_agents.login_before_ready, workspace_agents.startup_script_timeout_seconds, workspace_agents.expanded_directory, workspace_agents.shutdown_script, workspace_agents.shutdown_script_timeout_seconds, workspace_agents.startup_logs_length, workspace_agents.startup_logs_overflowed, workspace_agents.reconnecting_pty_buffer_size, workspace_agents.session_recording
 FROM
 	workspace_agents
 JOIN
@@ -5524,6 +7274,98 @@ func (q *sqlQuerier) GetWorkspaceAgentsInLatestBuildByWorkspaceID(ctx context.Co
 			&i.ShutdownScriptTimeoutSeconds,
 			&i.StartupLogsLength,
 			&i.StartupLogsOverflowed,
+			&i.ReconnectingPTYBufferSize,
+			&i.SessionRecording,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkspaceAgentsInLatestBuilds = `-- name: GetWorkspaceAgentsInLatestBuilds :many
+SELECT
+	workspace_agents.id,
+	workspace_agents.name,
+	workspace_agents.created_at,
+	workspace_agents.first_connected_at,
+	workspace_agents.last_connected_at,
+	workspace_agents.disconnected_at,
+	workspace_agents.connection_timeout_seconds,
+	workspaces.name AS workspace_name,
+	users.username,
+	templates.name AS template_name,
+	organizations.name AS organization_name
+FROM
+	workspace_agents
+JOIN
+	workspace_resources ON workspace_resources.id = workspace_agents.resource_id
+JOIN
+	workspace_builds ON workspace_builds.job_id = workspace_resources.job_id
+JOIN
+	workspaces ON workspaces.id = workspace_builds.workspace_id
+JOIN
+	users ON users.id = workspaces.owner_id
+JOIN
+	templates ON templates.id = workspaces.template_id
+JOIN
+	organizations ON organizations.id = workspaces.organization_id
+WHERE
+	workspaces.deleted = false
+	AND workspace_builds.build_number = (
+		SELECT
+			MAX(build_number)
+		FROM
+			workspace_builds AS latest_builds
+		WHERE
+			latest_builds.workspace_id = workspaces.id
+	)
+`
+
+type GetWorkspaceAgentsInLatestBuildsRow struct {
+	ID                       uuid.UUID    `db:"id" json:"id"`
+	Name                     string       `db:"name" json:"name"`
+	CreatedAt                time.Time    `db:"created_at" json:"created_at"`
+	FirstConnectedAt         sql.NullTime `db:"first_connected_at" json:"first_connected_at"`
+	LastConnectedAt          sql.NullTime `db:"last_connected_at" json:"last_connected_at"`
+	DisconnectedAt           sql.NullTime `db:"disconnected_at" json:"disconnected_at"`
+	ConnectionTimeoutSeconds int32        `db:"connection_timeout_seconds" json:"connection_timeout_seconds"`
+	WorkspaceName            string       `db:"workspace_name" json:"workspace_name"`
+	Username                 string       `db:"username" json:"username"`
+	TemplateName             string       `db:"template_name" json:"template_name"`
+	OrganizationName         string       `db:"organization_name" json:"organization_name"`
+}
+
+// Returns the agents of the latest build of every workspace, along with the
+// names used to label agent metrics.
+func (q *sqlQuerier) GetWorkspaceAgentsInLatestBuilds(ctx context.Context) ([]GetWorkspaceAgentsInLatestBuildsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentsInLatestBuilds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWorkspaceAgentsInLatestBuildsRow
+	for rows.Next() {
+		var i GetWorkspaceAgentsInLatestBuildsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.FirstConnectedAt,
+			&i.LastConnectedAt,
+			&i.DisconnectedAt,
+			&i.ConnectionTimeoutSeconds,
+			&i.WorkspaceName,
+			&i.Username,
+			&i.TemplateName,
+			&i.OrganizationName,
 		); err != nil {
 			return nil, err
 		}
@@ -5561,10 +7403,12 @@ INSERT INTO
 		login_before_ready,
 		startup_script_timeout_seconds,
 		shutdown_script,
-		shutdown_script_timeout_seconds
+		shutdown_script_timeout_seconds,
+		reconnecting_pty_buffer_size,
+		session_recording
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21) RETURNING id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23) RETURNING id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, reconnecting_pty_buffer_size, session_recording
 `
 
 type InsertWorkspaceAgentParams struct {
@@ -5589,6 +7433,8 @@ type InsertWorkspaceAgentParams struct {
 	StartupScriptTimeoutSeconds  int32                 `db:"startup_script_timeout_seconds" json:"startup_script_timeout_seconds"`
 	ShutdownScript               sql.NullString        `db:"shutdown_script" json:"shutdown_script"`
 	ShutdownScriptTimeoutSeconds int32                 `db:"shutdown_script_timeout_seconds" json:"shutdown_script_timeout_seconds"`
+	ReconnectingPTYBufferSize    int32                 `db:"reconnecting_pty_buffer_size" json:"reconnecting_pty_buffer_size"`
+	SessionRecording             bool                  `db:"session_recording" json:"session_recording"`
 }
 
 func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspaceAgentParams) (WorkspaceAgent, error) {
@@ -5614,6 +7460,8 @@ func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspa
 		arg.StartupScriptTimeoutSeconds,
 		arg.ShutdownScript,
 		arg.ShutdownScriptTimeoutSeconds,
+		arg.ReconnectingPTYBufferSize,
+		arg.SessionRecording,
 	)
 	var i WorkspaceAgent
 	err := row.Scan(
@@ -5647,10 +7495,47 @@ func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspa
 		&i.ShutdownScriptTimeoutSeconds,
 		&i.StartupLogsLength,
 		&i.StartupLogsOverflowed,
+		&i.ReconnectingPTYBufferSize,
+		&i.SessionRecording,
 	)
 	return i, err
 }
 
+const insertWorkspaceAgentMetadata = `-- name: InsertWorkspaceAgentMetadata :exec
+INSERT INTO
+	workspace_agent_metadata (
+		workspace_agent_id,
+		display_name,
+		key,
+		script,
+		timeout,
+		interval
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6)
+`
+
+type InsertWorkspaceAgentMetadataParams struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	DisplayName      string    `db:"display_name" json:"display_name"`
+	Key              string    `db:"key" json:"key"`
+	Script           string    `db:"script" json:"script"`
+	Timeout          int64     `db:"timeout" json:"timeout"`
+	Interval         int64     `db:"interval" json:"interval"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceAgentMetadata(ctx context.Context, arg InsertWorkspaceAgentMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, insertWorkspaceAgentMetadata,
+		arg.WorkspaceAgentID,
+		arg.DisplayName,
+		arg.Key,
+		arg.Script,
+		arg.Timeout,
+		arg.Interval,
+	)
+	return err
+}
+
 const insertWorkspaceAgentStartupLogs = `-- name: InsertWorkspaceAgentStartupLogs :many
 WITH new_length AS (
 	UPDATE workspace_agents SET
@@ -5758,6 +7643,37 @@ func (q *sqlQuerier) UpdateWorkspaceAgentLifecycleStateByID(ctx context.Context,
 	return err
 }
 
+const updateWorkspaceAgentMetadata = `-- name: UpdateWorkspaceAgentMetadata :exec
+UPDATE
+	workspace_agent_metadata
+SET
+	value = $3,
+	error = $4,
+	collected_at = $5
+WHERE
+	workspace_agent_id = $1
+	AND key = $2
+`
+
+type UpdateWorkspaceAgentMetadataParams struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	Key              string    `db:"key" json:"key"`
+	Value            string    `db:"value" json:"value"`
+	Error            string    `db:"error" json:"error"`
+	CollectedAt      time.Time `db:"collected_at" json:"collected_at"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentMetadata(ctx context.Context, arg UpdateWorkspaceAgentMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentMetadata,
+		arg.WorkspaceAgentID,
+		arg.Key,
+		arg.Value,
+		arg.Error,
+		arg.CollectedAt,
+	)
+	return err
+}
+
 const updateWorkspaceAgentStartupByID = `-- name: UpdateWorkspaceAgentStartupByID :exec
 UPDATE
 	workspace_agents
@@ -6429,25 +8345,70 @@ func (q *sqlQuerier) InsertWorkspaceBuildParameters(ctx context.Context, arg Ins
 	return err
 }
 
-const getLatestWorkspaceBuildByWorkspaceID = `-- name: GetLatestWorkspaceBuildByWorkspaceID :one
-SELECT
-	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline
-FROM
+const deleteOldWorkspaceBuildStates = `-- name: DeleteOldWorkspaceBuildStates :execrows
+UPDATE
 	workspace_builds
+SET
+	provisioner_state = NULL
 WHERE
-	workspace_id = $1
-ORDER BY
-    build_number desc
-LIMIT
-	1
-`
-
-func (q *sqlQuerier) GetLatestWorkspaceBuildByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (WorkspaceBuild, error) {
-	row := q.db.QueryRowContext(ctx, getLatestWorkspaceBuildByWorkspaceID, workspaceID)
-	var i WorkspaceBuild
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
+	id IN (
+		SELECT
+			wb.id
+		FROM
+			workspace_builds wb
+		WHERE
+			wb.created_at < $1 :: timestamptz
+			AND wb.provisioner_state IS NOT NULL
+			AND wb.build_number < (
+				SELECT
+					MAX(build_number)
+				FROM
+					workspace_builds
+				WHERE
+					workspace_builds.workspace_id = wb.workspace_id
+			)
+		ORDER BY
+			wb.created_at ASC
+		LIMIT
+			$2 :: integer
+	)
+`
+
+type DeleteOldWorkspaceBuildStatesParams struct {
+	Before     time.Time `db:"before" json:"before"`
+	LimitCount int32     `db:"limit_count" json:"limit_count"`
+}
+
+// The provisioner state of a build is only needed while it's the latest build
+// of its workspace, so the state of superseded builds can be cleared. Builds
+// are updated in batches so that large purges don't hold locks for long.
+func (q *sqlQuerier) DeleteOldWorkspaceBuildStates(ctx context.Context, arg DeleteOldWorkspaceBuildStatesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldWorkspaceBuildStates, arg.Before, arg.LimitCount)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getLatestWorkspaceBuildByWorkspaceID = `-- name: GetLatestWorkspaceBuildByWorkspaceID :one
+SELECT
+	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline
+FROM
+	workspace_builds
+WHERE
+	workspace_id = $1
+ORDER BY
+    build_number desc
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetLatestWorkspaceBuildByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (WorkspaceBuild, error) {
+	row := q.db.QueryRowContext(ctx, getLatestWorkspaceBuildByWorkspaceID, workspaceID)
+	var i WorkspaceBuild
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.WorkspaceID,
 		&i.TemplateVersionID,
@@ -6572,6 +8533,31 @@ func (q *sqlQuerier) GetLatestWorkspaceBuildsByWorkspaceIDs(ctx context.Context,
 	return items, nil
 }
 
+const getOldWorkspaceBuildStateCount = `-- name: GetOldWorkspaceBuildStateCount :one
+SELECT
+	COUNT(*)
+FROM
+	workspace_builds wb
+WHERE
+	wb.created_at < $1 :: timestamptz
+	AND wb.provisioner_state IS NOT NULL
+	AND wb.build_number < (
+		SELECT
+			MAX(build_number)
+		FROM
+			workspace_builds
+		WHERE
+			workspace_builds.workspace_id = wb.workspace_id
+	)
+`
+
+func (q *sqlQuerier) GetOldWorkspaceBuildStateCount(ctx context.Context, before time.Time) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getOldWorkspaceBuildStateCount, before)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getWorkspaceBuildByID = `-- name: GetWorkspaceBuildByID :one
 SELECT
 	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline
@@ -6765,30 +8751,323 @@ const getWorkspaceBuildsCreatedAfter = `-- name: GetWorkspaceBuildsCreatedAfter
 SELECT id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline FROM workspace_builds WHERE created_at > $1
 `
 
-func (q *sqlQuerier) GetWorkspaceBuildsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceBuild, error) {
-	rows, err := q.db.QueryContext(ctx, getWorkspaceBuildsCreatedAfter, createdAt)
+func (q *sqlQuerier) GetWorkspaceBuildsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceBuild, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceBuildsCreatedAfter, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceBuild
+	for rows.Next() {
+		var i WorkspaceBuild
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.WorkspaceID,
+			&i.TemplateVersionID,
+			&i.BuildNumber,
+			&i.Transition,
+			&i.InitiatorID,
+			&i.ProvisionerState,
+			&i.JobID,
+			&i.Deadline,
+			&i.Reason,
+			&i.DailyCost,
+			&i.MaxDeadline,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceBuild = `-- name: InsertWorkspaceBuild :one
+INSERT INTO
+	workspace_builds (
+		id,
+		created_at,
+		updated_at,
+		workspace_id,
+		template_version_id,
+		"build_number",
+		transition,
+		initiator_id,
+		job_id,
+		provisioner_state,
+		deadline,
+		max_deadline,
+		reason
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline
+`
+
+type InsertWorkspaceBuildParams struct {
+	ID                uuid.UUID           `db:"id" json:"id"`
+	CreatedAt         time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time           `db:"updated_at" json:"updated_at"`
+	WorkspaceID       uuid.UUID           `db:"workspace_id" json:"workspace_id"`
+	TemplateVersionID uuid.UUID           `db:"template_version_id" json:"template_version_id"`
+	BuildNumber       int32               `db:"build_number" json:"build_number"`
+	Transition        WorkspaceTransition `db:"transition" json:"transition"`
+	InitiatorID       uuid.UUID           `db:"initiator_id" json:"initiator_id"`
+	JobID             uuid.UUID           `db:"job_id" json:"job_id"`
+	ProvisionerState  []byte              `db:"provisioner_state" json:"provisioner_state"`
+	Deadline          time.Time           `db:"deadline" json:"deadline"`
+	MaxDeadline       time.Time           `db:"max_deadline" json:"max_deadline"`
+	Reason            BuildReason         `db:"reason" json:"reason"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceBuild(ctx context.Context, arg InsertWorkspaceBuildParams) (WorkspaceBuild, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceBuild,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.WorkspaceID,
+		arg.TemplateVersionID,
+		arg.BuildNumber,
+		arg.Transition,
+		arg.InitiatorID,
+		arg.JobID,
+		arg.ProvisionerState,
+		arg.Deadline,
+		arg.MaxDeadline,
+		arg.Reason,
+	)
+	var i WorkspaceBuild
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.TemplateVersionID,
+		&i.BuildNumber,
+		&i.Transition,
+		&i.InitiatorID,
+		&i.ProvisionerState,
+		&i.JobID,
+		&i.Deadline,
+		&i.Reason,
+		&i.DailyCost,
+		&i.MaxDeadline,
+	)
+	return i, err
+}
+
+const updateWorkspaceBuildByID = `-- name: UpdateWorkspaceBuildByID :one
+UPDATE
+	workspace_builds
+SET
+	updated_at = $2,
+	provisioner_state = $3,
+	deadline = $4,
+	max_deadline = $5
+WHERE
+	id = $1 RETURNING id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline
+`
+
+type UpdateWorkspaceBuildByIDParams struct {
+	ID               uuid.UUID `db:"id" json:"id"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+	ProvisionerState []byte    `db:"provisioner_state" json:"provisioner_state"`
+	Deadline         time.Time `db:"deadline" json:"deadline"`
+	MaxDeadline      time.Time `db:"max_deadline" json:"max_deadline"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceBuildByID(ctx context.Context, arg UpdateWorkspaceBuildByIDParams) (WorkspaceBuild, error) {
+	row := q.db.QueryRowContext(ctx, updateWorkspaceBuildByID,
+		arg.ID,
+		arg.UpdatedAt,
+		arg.ProvisionerState,
+		arg.Deadline,
+		arg.MaxDeadline,
+	)
+	var i WorkspaceBuild
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.TemplateVersionID,
+		&i.BuildNumber,
+		&i.Transition,
+		&i.InitiatorID,
+		&i.ProvisionerState,
+		&i.JobID,
+		&i.Deadline,
+		&i.Reason,
+		&i.DailyCost,
+		&i.MaxDeadline,
+	)
+	return i, err
+}
+
+const updateWorkspaceBuildCostByID = `-- name: UpdateWorkspaceBuildCostByID :one
+UPDATE
+	workspace_builds
+SET
+	daily_cost = $2
+WHERE
+	id = $1 RETURNING id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline
+`
+
+type UpdateWorkspaceBuildCostByIDParams struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	DailyCost int32     `db:"daily_cost" json:"daily_cost"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceBuildCostByID(ctx context.Context, arg UpdateWorkspaceBuildCostByIDParams) (WorkspaceBuild, error) {
+	row := q.db.QueryRowContext(ctx, updateWorkspaceBuildCostByID, arg.ID, arg.DailyCost)
+	var i WorkspaceBuild
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.TemplateVersionID,
+		&i.BuildNumber,
+		&i.Transition,
+		&i.InitiatorID,
+		&i.ProvisionerState,
+		&i.JobID,
+		&i.Deadline,
+		&i.Reason,
+		&i.DailyCost,
+		&i.MaxDeadline,
+	)
+	return i, err
+}
+
+const getStaleWorkspaceBulkJobs = `-- name: GetStaleWorkspaceBulkJobs :many
+SELECT
+	id, created_at, updated_at, completed_at, initiator_id, action, filter, batch_size, status, error
+FROM
+	workspace_bulk_jobs
+WHERE
+	completed_at IS NULL
+	AND updated_at < $1
+`
+
+// Returns the bulk jobs that are still running but haven't been updated since
+// the given time, because the replica that ran them went away.
+func (q *sqlQuerier) GetStaleWorkspaceBulkJobs(ctx context.Context, updatedAt time.Time) ([]WorkspaceBulkJob, error) {
+	rows, err := q.db.QueryContext(ctx, getStaleWorkspaceBulkJobs, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceBulkJob
+	for rows.Next() {
+		var i WorkspaceBulkJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompletedAt,
+			&i.InitiatorID,
+			&i.Action,
+			&i.Filter,
+			&i.BatchSize,
+			&i.Status,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkspaceBulkJobByID = `-- name: GetWorkspaceBulkJobByID :one
+SELECT
+	id, created_at, updated_at, completed_at, initiator_id, action, filter, batch_size, status, error
+FROM
+	workspace_bulk_jobs
+WHERE
+	id = $1
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetWorkspaceBulkJobByID(ctx context.Context, id uuid.UUID) (WorkspaceBulkJob, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceBulkJobByID, id)
+	var i WorkspaceBulkJob
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+		&i.InitiatorID,
+		&i.Action,
+		&i.Filter,
+		&i.BatchSize,
+		&i.Status,
+		&i.Error,
+	)
+	return i, err
+}
+
+const getWorkspaceBulkJobWorkspaces = `-- name: GetWorkspaceBulkJobWorkspaces :many
+SELECT
+	workspace_bulk_job_workspaces.bulk_job_id, workspace_bulk_job_workspaces.workspace_id, workspace_bulk_job_workspaces.workspace_build_id, workspace_bulk_job_workspaces.updated_at, workspace_bulk_job_workspaces.status, workspace_bulk_job_workspaces.error,
+	workspaces.name AS workspace_name,
+	users.username AS owner_username
+FROM
+	workspace_bulk_job_workspaces
+INNER JOIN
+	workspaces ON workspaces.id = workspace_bulk_job_workspaces.workspace_id
+INNER JOIN
+	users ON users.id = workspaces.owner_id
+WHERE
+	workspace_bulk_job_workspaces.bulk_job_id = $1
+ORDER BY
+	users.username ASC,
+	workspaces.name ASC
+`
+
+type GetWorkspaceBulkJobWorkspacesRow struct {
+	BulkJobID        uuid.UUID              `db:"bulk_job_id" json:"bulk_job_id"`
+	WorkspaceID      uuid.UUID              `db:"workspace_id" json:"workspace_id"`
+	WorkspaceBuildID uuid.NullUUID          `db:"workspace_build_id" json:"workspace_build_id"`
+	UpdatedAt        time.Time              `db:"updated_at" json:"updated_at"`
+	Status           WorkspaceBulkJobStatus `db:"status" json:"status"`
+	Error            string                 `db:"error" json:"error"`
+	WorkspaceName    string                 `db:"workspace_name" json:"workspace_name"`
+	OwnerUsername    string                 `db:"owner_username" json:"owner_username"`
+}
+
+func (q *sqlQuerier) GetWorkspaceBulkJobWorkspaces(ctx context.Context, bulkJobID uuid.UUID) ([]GetWorkspaceBulkJobWorkspacesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceBulkJobWorkspaces, bulkJobID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []WorkspaceBuild
+	var items []GetWorkspaceBulkJobWorkspacesRow
 	for rows.Next() {
-		var i WorkspaceBuild
+		var i GetWorkspaceBulkJobWorkspacesRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.BulkJobID,
 			&i.WorkspaceID,
-			&i.TemplateVersionID,
-			&i.BuildNumber,
-			&i.Transition,
-			&i.InitiatorID,
-			&i.ProvisionerState,
-			&i.JobID,
-			&i.Deadline,
-			&i.Reason,
-			&i.DailyCost,
-			&i.MaxDeadline,
+			&i.WorkspaceBuildID,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.Error,
+			&i.WorkspaceName,
+			&i.OwnerUsername,
 		); err != nil {
 			return nil, err
 		}
@@ -6803,161 +9082,161 @@ func (q *sqlQuerier) GetWorkspaceBuildsCreatedAfter(ctx context.Context, created
 	return items, nil
 }
 
-const insertWorkspaceBuild = `-- name: InsertWorkspaceBuild :one
+const insertWorkspaceBulkJob = `-- name: InsertWorkspaceBulkJob :one
 INSERT INTO
-	workspace_builds (
+	workspace_bulk_jobs (
 		id,
 		created_at,
 		updated_at,
-		workspace_id,
-		template_version_id,
-		"build_number",
-		transition,
 		initiator_id,
-		job_id,
-		provisioner_state,
-		deadline,
-		max_deadline,
-		reason
+		action,
+		filter,
+		batch_size,
+		status
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at, updated_at, completed_at, initiator_id, action, filter, batch_size, status, error
 `
 
-type InsertWorkspaceBuildParams struct {
-	ID                uuid.UUID           `db:"id" json:"id"`
-	CreatedAt         time.Time           `db:"created_at" json:"created_at"`
-	UpdatedAt         time.Time           `db:"updated_at" json:"updated_at"`
-	WorkspaceID       uuid.UUID           `db:"workspace_id" json:"workspace_id"`
-	TemplateVersionID uuid.UUID           `db:"template_version_id" json:"template_version_id"`
-	BuildNumber       int32               `db:"build_number" json:"build_number"`
-	Transition        WorkspaceTransition `db:"transition" json:"transition"`
-	InitiatorID       uuid.UUID           `db:"initiator_id" json:"initiator_id"`
-	JobID             uuid.UUID           `db:"job_id" json:"job_id"`
-	ProvisionerState  []byte              `db:"provisioner_state" json:"provisioner_state"`
-	Deadline          time.Time           `db:"deadline" json:"deadline"`
-	MaxDeadline       time.Time           `db:"max_deadline" json:"max_deadline"`
-	Reason            BuildReason         `db:"reason" json:"reason"`
+type InsertWorkspaceBulkJobParams struct {
+	ID          uuid.UUID              `db:"id" json:"id"`
+	CreatedAt   time.Time              `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time              `db:"updated_at" json:"updated_at"`
+	InitiatorID uuid.UUID              `db:"initiator_id" json:"initiator_id"`
+	Action      WorkspaceBulkJobAction `db:"action" json:"action"`
+	Filter      string                 `db:"filter" json:"filter"`
+	BatchSize   int32                  `db:"batch_size" json:"batch_size"`
+	Status      WorkspaceBulkJobStatus `db:"status" json:"status"`
 }
 
-func (q *sqlQuerier) InsertWorkspaceBuild(ctx context.Context, arg InsertWorkspaceBuildParams) (WorkspaceBuild, error) {
-	row := q.db.QueryRowContext(ctx, insertWorkspaceBuild,
+func (q *sqlQuerier) InsertWorkspaceBulkJob(ctx context.Context, arg InsertWorkspaceBulkJobParams) (WorkspaceBulkJob, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceBulkJob,
 		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
-		arg.WorkspaceID,
-		arg.TemplateVersionID,
-		arg.BuildNumber,
-		arg.Transition,
 		arg.InitiatorID,
-		arg.JobID,
-		arg.ProvisionerState,
-		arg.Deadline,
-		arg.MaxDeadline,
-		arg.Reason,
+		arg.Action,
+		arg.Filter,
+		arg.BatchSize,
+		arg.Status,
 	)
-	var i WorkspaceBuild
+	var i WorkspaceBulkJob
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.WorkspaceID,
-		&i.TemplateVersionID,
-		&i.BuildNumber,
-		&i.Transition,
+		&i.CompletedAt,
 		&i.InitiatorID,
-		&i.ProvisionerState,
-		&i.JobID,
-		&i.Deadline,
-		&i.Reason,
-		&i.DailyCost,
-		&i.MaxDeadline,
+		&i.Action,
+		&i.Filter,
+		&i.BatchSize,
+		&i.Status,
+		&i.Error,
 	)
 	return i, err
 }
 
-const updateWorkspaceBuildByID = `-- name: UpdateWorkspaceBuildByID :one
+const insertWorkspaceBulkJobWorkspace = `-- name: InsertWorkspaceBulkJobWorkspace :one
+INSERT INTO
+	workspace_bulk_job_workspaces (
+		bulk_job_id,
+		workspace_id,
+		updated_at,
+		status
+	)
+VALUES
+	($1, $2, $3, $4) RETURNING bulk_job_id, workspace_id, workspace_build_id, updated_at, status, error
+`
+
+type InsertWorkspaceBulkJobWorkspaceParams struct {
+	BulkJobID   uuid.UUID              `db:"bulk_job_id" json:"bulk_job_id"`
+	WorkspaceID uuid.UUID              `db:"workspace_id" json:"workspace_id"`
+	UpdatedAt   time.Time              `db:"updated_at" json:"updated_at"`
+	Status      WorkspaceBulkJobStatus `db:"status" json:"status"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceBulkJobWorkspace(ctx context.Context, arg InsertWorkspaceBulkJobWorkspaceParams) (WorkspaceBulkJobWorkspace, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceBulkJobWorkspace,
+		arg.BulkJobID,
+		arg.WorkspaceID,
+		arg.UpdatedAt,
+		arg.Status,
+	)
+	var i WorkspaceBulkJobWorkspace
+	err := row.Scan(
+		&i.BulkJobID,
+		&i.WorkspaceID,
+		&i.WorkspaceBuildID,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.Error,
+	)
+	return i, err
+}
+
+const updateWorkspaceBulkJobByID = `-- name: UpdateWorkspaceBulkJobByID :exec
 UPDATE
-	workspace_builds
+	workspace_bulk_jobs
 SET
 	updated_at = $2,
-	provisioner_state = $3,
-	deadline = $4,
-	max_deadline = $5
+	completed_at = $3,
+	status = $4,
+	error = $5
 WHERE
-	id = $1 RETURNING id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline
+	id = $1
 `
 
-type UpdateWorkspaceBuildByIDParams struct {
-	ID               uuid.UUID `db:"id" json:"id"`
-	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
-	ProvisionerState []byte    `db:"provisioner_state" json:"provisioner_state"`
-	Deadline         time.Time `db:"deadline" json:"deadline"`
-	MaxDeadline      time.Time `db:"max_deadline" json:"max_deadline"`
+type UpdateWorkspaceBulkJobByIDParams struct {
+	ID          uuid.UUID              `db:"id" json:"id"`
+	UpdatedAt   time.Time              `db:"updated_at" json:"updated_at"`
+	CompletedAt sql.NullTime           `db:"completed_at" json:"completed_at"`
+	Status      WorkspaceBulkJobStatus `db:"status" json:"status"`
+	Error       string                 `db:"error" json:"error"`
 }
 
-func (q *sqlQuerier) UpdateWorkspaceBuildByID(ctx context.Context, arg UpdateWorkspaceBuildByIDParams) (WorkspaceBuild, error) {
-	row := q.db.QueryRowContext(ctx, updateWorkspaceBuildByID,
+func (q *sqlQuerier) UpdateWorkspaceBulkJobByID(ctx context.Context, arg UpdateWorkspaceBulkJobByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceBulkJobByID,
 		arg.ID,
 		arg.UpdatedAt,
-		arg.ProvisionerState,
-		arg.Deadline,
-		arg.MaxDeadline,
-	)
-	var i WorkspaceBuild
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.WorkspaceID,
-		&i.TemplateVersionID,
-		&i.BuildNumber,
-		&i.Transition,
-		&i.InitiatorID,
-		&i.ProvisionerState,
-		&i.JobID,
-		&i.Deadline,
-		&i.Reason,
-		&i.DailyCost,
-		&i.MaxDeadline,
+		arg.CompletedAt,
+		arg.Status,
+		arg.Error,
 	)
-	return i, err
+	return err
 }
 
-const updateWorkspaceBuildCostByID = `-- name: UpdateWorkspaceBuildCostByID :one
+const updateWorkspaceBulkJobWorkspace = `-- name: UpdateWorkspaceBulkJobWorkspace :exec
 UPDATE
-	workspace_builds
+	workspace_bulk_job_workspaces
 SET
-	daily_cost = $2
+	updated_at = $3,
+	workspace_build_id = $4,
+	status = $5,
+	error = $6
 WHERE
-	id = $1 RETURNING id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline
+	bulk_job_id = $1
+	AND workspace_id = $2
 `
 
-type UpdateWorkspaceBuildCostByIDParams struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	DailyCost int32     `db:"daily_cost" json:"daily_cost"`
+type UpdateWorkspaceBulkJobWorkspaceParams struct {
+	BulkJobID        uuid.UUID              `db:"bulk_job_id" json:"bulk_job_id"`
+	WorkspaceID      uuid.UUID              `db:"workspace_id" json:"workspace_id"`
+	UpdatedAt        time.Time              `db:"updated_at" json:"updated_at"`
+	WorkspaceBuildID uuid.NullUUID          `db:"workspace_build_id" json:"workspace_build_id"`
+	Status           WorkspaceBulkJobStatus `db:"status" json:"status"`
+	Error            string                 `db:"error" json:"error"`
 }
 
-func (q *sqlQuerier) UpdateWorkspaceBuildCostByID(ctx context.Context, arg UpdateWorkspaceBuildCostByIDParams) (WorkspaceBuild, error) {
-	row := q.db.QueryRowContext(ctx, updateWorkspaceBuildCostByID, arg.ID, arg.DailyCost)
-	var i WorkspaceBuild
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.WorkspaceID,
-		&i.TemplateVersionID,
-		&i.BuildNumber,
-		&i.Transition,
-		&i.InitiatorID,
-		&i.ProvisionerState,
-		&i.JobID,
-		&i.Deadline,
-		&i.Reason,
-		&i.DailyCost,
-		&i.MaxDeadline,
+func (q *sqlQuerier) UpdateWorkspaceBulkJobWorkspace(ctx context.Context, arg UpdateWorkspaceBulkJobWorkspaceParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceBulkJobWorkspace,
+		arg.BulkJobID,
+		arg.WorkspaceID,
+		arg.UpdatedAt,
+		arg.WorkspaceBuildID,
+		arg.Status,
+		arg.Error,
 	)
-	return i, err
+	return err
 }
 
 const getWorkspaceResourceByID = `-- name: GetWorkspaceResourceByID :one
@@ -7985,3 +10264,144 @@ func (q *sqlQuerier) UpdateWorkspaceTTLToBeWithinTemplateMax(ctx context.Context
 	_, err := q.db.ExecContext(ctx, updateWorkspaceTTLToBeWithinTemplateMax, arg.TemplateMaxTTL, arg.TemplateID)
 	return err
 }
+
+const getWorkspaceSessionRecordingByID = `-- name: GetWorkspaceSessionRecordingByID :one
+SELECT
+	id, created_at, workspace_id, agent_id, session_type, started_at, ended_at, data
+FROM
+	workspace_session_recordings
+WHERE
+	id = $1
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetWorkspaceSessionRecordingByID(ctx context.Context, id uuid.UUID) (WorkspaceSessionRecording, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceSessionRecordingByID, id)
+	var i WorkspaceSessionRecording
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.SessionType,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.Data,
+	)
+	return i, err
+}
+
+const getWorkspaceSessionRecordingsByWorkspaceID = `-- name: GetWorkspaceSessionRecordingsByWorkspaceID :many
+SELECT
+	id,
+	created_at,
+	workspace_id,
+	agent_id,
+	session_type,
+	started_at,
+	ended_at,
+	octet_length("data")::bigint AS size
+FROM
+	workspace_session_recordings
+WHERE
+	workspace_id = $1
+ORDER BY
+	started_at DESC
+`
+
+type GetWorkspaceSessionRecordingsByWorkspaceIDRow struct {
+	ID          uuid.UUID            `db:"id" json:"id"`
+	CreatedAt   time.Time            `db:"created_at" json:"created_at"`
+	WorkspaceID uuid.UUID            `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID            `db:"agent_id" json:"agent_id"`
+	SessionType WorkspaceSessionType `db:"session_type" json:"session_type"`
+	StartedAt   time.Time            `db:"started_at" json:"started_at"`
+	EndedAt     time.Time            `db:"ended_at" json:"ended_at"`
+	Size        int64                `db:"size" json:"size"`
+}
+
+// The recordings themselves are left out, they can be large.
+func (q *sqlQuerier) GetWorkspaceSessionRecordingsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]GetWorkspaceSessionRecordingsByWorkspaceIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceSessionRecordingsByWorkspaceID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWorkspaceSessionRecordingsByWorkspaceIDRow
+	for rows.Next() {
+		var i GetWorkspaceSessionRecordingsByWorkspaceIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+			&i.AgentID,
+			&i.SessionType,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceSessionRecording = `-- name: InsertWorkspaceSessionRecording :one
+INSERT INTO
+	workspace_session_recordings (
+		id,
+		created_at,
+		workspace_id,
+		agent_id,
+		session_type,
+		started_at,
+		ended_at,
+		"data"
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at, workspace_id, agent_id, session_type, started_at, ended_at, data
+`
+
+type InsertWorkspaceSessionRecordingParams struct {
+	ID          uuid.UUID            `db:"id" json:"id"`
+	CreatedAt   time.Time            `db:"created_at" json:"created_at"`
+	WorkspaceID uuid.UUID            `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID            `db:"agent_id" json:"agent_id"`
+	SessionType WorkspaceSessionType `db:"session_type" json:"session_type"`
+	StartedAt   time.Time            `db:"started_at" json:"started_at"`
+	EndedAt     time.Time            `db:"ended_at" json:"ended_at"`
+	Data        []byte               `db:"data" json:"data"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceSessionRecording(ctx context.Context, arg InsertWorkspaceSessionRecordingParams) (WorkspaceSessionRecording, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceSessionRecording,
+		arg.ID,
+		arg.CreatedAt,
+		arg.WorkspaceID,
+		arg.AgentID,
+		arg.SessionType,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.Data,
+	)
+	var i WorkspaceSessionRecording
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.SessionType,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.Data,
+	)
+	return i, err
+}
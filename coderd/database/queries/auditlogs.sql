@@ -120,3 +120,30 @@ INSERT INTO
     )
 VALUES
 	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING *;
+
+-- Audit logs are deleted in batches so that large purges don't hold locks on
+-- the table for long.
+-- name: DeleteOldAuditLogs :execrows
+DELETE FROM
+	audit_logs
+WHERE
+	id IN (
+		SELECT
+			id
+		FROM
+			audit_logs
+		WHERE
+			"time" < @before :: timestamptz
+		ORDER BY
+			"time" ASC
+		LIMIT
+			@limit_count :: integer
+	);
+
+-- name: GetOldAuditLogCount :one
+SELECT
+	COUNT(*)
+FROM
+	audit_logs
+WHERE
+	"time" < @before :: timestamptz;
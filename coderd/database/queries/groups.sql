@@ -30,6 +30,20 @@ WHERE
 AND
 	id != $1;
 
+-- name: GetGroupsByOrganizationAndUserID :many
+SELECT
+	groups.*
+FROM
+	groups
+JOIN
+	group_members
+ON
+	group_members.group_id = groups.id
+WHERE
+	groups.organization_id = @organization_id
+AND
+	group_members.user_id = @user_id;
+
 -- name: InsertGroup :one
 INSERT INTO groups (
 	id,
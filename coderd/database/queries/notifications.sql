@@ -0,0 +1,109 @@
+-- name: GetNotificationWebhooks :many
+SELECT
+	*
+FROM
+	notification_webhooks
+ORDER BY
+	name ASC;
+
+-- name: GetNotificationWebhookByID :one
+SELECT
+	*
+FROM
+	notification_webhooks
+WHERE
+	id = $1
+LIMIT
+	1;
+
+-- name: GetEnabledNotificationWebhooksByEventType :many
+SELECT
+	*
+FROM
+	notification_webhooks
+WHERE
+	enabled = true
+	AND @event_type :: notification_event_type = ANY(events)
+ORDER BY
+	name ASC;
+
+-- name: InsertNotificationWebhook :one
+INSERT INTO
+	notification_webhooks (
+		id,
+		created_at,
+		updated_at,
+		name,
+		url,
+		events,
+		payload_template,
+		secret,
+		enabled
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *;
+
+-- name: UpdateNotificationWebhookByID :one
+UPDATE
+	notification_webhooks
+SET
+	updated_at = $2,
+	name = $3,
+	url = $4,
+	events = $5,
+	payload_template = $6,
+	secret = $7,
+	enabled = $8
+WHERE
+	id = $1
+RETURNING
+	*;
+
+-- name: DeleteNotificationWebhookByID :exec
+DELETE FROM
+	notification_webhooks
+WHERE
+	id = $1;
+
+-- name: InsertNotificationWebhookDelivery :one
+-- Returns no rows if the attempt was already made by another replica.
+INSERT INTO
+	notification_webhook_deliveries (
+		id,
+		webhook_id,
+		event_id,
+		event_type,
+		attempt,
+		created_at,
+		request_body
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (webhook_id, event_id, attempt) DO NOTHING
+RETURNING *;
+
+-- name: UpdateNotificationWebhookDeliveryByID :exec
+UPDATE
+	notification_webhook_deliveries
+SET
+	completed_at = $2,
+	status_code = $3,
+	error = $4
+WHERE
+	id = $1;
+
+-- name: GetNotificationWebhookDeliveries :many
+SELECT
+	*
+FROM
+	notification_webhook_deliveries
+WHERE
+	webhook_id = @webhook_id
+ORDER BY
+	created_at DESC, attempt DESC
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF(@limit_opt :: int, 0);
+
+-- name: DeleteOldNotificationWebhookDeliveries :exec
+DELETE FROM notification_webhook_deliveries WHERE created_at < NOW() - INTERVAL '30 days';
@@ -15,3 +15,22 @@ INSERT INTO
 	)
 VALUES
 	($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: UpdateProvisionerDaemonHeartbeat :exec
+UPDATE
+	provisioner_daemons
+SET
+	last_seen_at = @last_seen_at,
+	version = @version,
+	current_job_id = @current_job_id
+WHERE
+	id = @id;
+
+-- name: DeleteStaleProvisionerDaemons :execrows
+-- Deletes daemons that haven't sent a heartbeat since the given time.
+-- Daemons that have never sent a heartbeat are considered last seen when
+-- they were created.
+DELETE FROM
+	provisioner_daemons
+WHERE
+	COALESCE(last_seen_at, created_at) < @stale_before :: timestamptz;
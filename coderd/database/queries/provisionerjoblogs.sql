@@ -19,3 +19,35 @@ SELECT
 	unnest(@level :: log_level [ ]) AS LEVEL,
 	unnest(@stage :: VARCHAR(128) [ ]) AS stage,
 	unnest(@output :: VARCHAR(1024) [ ]) AS output RETURNING *;
+
+-- Logs are only purged for jobs that have completed, so logs of running jobs
+-- are never removed. Logs are deleted in batches so that large purges don't
+-- hold locks on the table for long.
+-- name: DeleteOldProvisionerJobLogs :execrows
+DELETE FROM
+	provisioner_job_logs
+WHERE
+	id IN (
+		SELECT
+			provisioner_job_logs.id
+		FROM
+			provisioner_job_logs
+		JOIN
+			provisioner_jobs ON provisioner_jobs.id = provisioner_job_logs.job_id
+		WHERE
+			provisioner_jobs.completed_at < @before :: timestamptz
+		ORDER BY
+			provisioner_job_logs.id ASC
+		LIMIT
+			@limit_count :: integer
+	);
+
+-- name: GetOldProvisionerJobLogCount :one
+SELECT
+	COUNT(*)
+FROM
+	provisioner_job_logs
+JOIN
+	provisioner_jobs ON provisioner_jobs.id = provisioner_job_logs.job_id
+WHERE
+	provisioner_jobs.completed_at < @before :: timestamptz;
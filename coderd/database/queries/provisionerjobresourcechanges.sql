@@ -0,0 +1,15 @@
+-- name: InsertProvisionerJobResourceChange :one
+INSERT INTO
+	provisioner_job_resource_changes (job_id, address, type, name, action, daily_cost, prior_daily_cost)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: GetProvisionerJobResourceChangesByJobID :many
+SELECT
+	*
+FROM
+	provisioner_job_resource_changes
+WHERE
+	job_id = $1
+ORDER BY
+	address ASC;
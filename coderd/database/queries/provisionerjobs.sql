@@ -1,5 +1,6 @@
 -- Acquires the lock for a single job that isn't started, completed,
--- canceled, and that matches an array of provisioner types.
+-- canceled, and that matches an array of provisioner types. Jobs with
+-- a higher priority are acquired first.
 --
 -- SKIP LOCKED is used to jump over locked rows. This prevents
 -- multiple provisioners from acquiring the same jobs. See:
@@ -19,11 +20,13 @@ WHERE
 			provisioner_jobs AS nested
 		WHERE
 			nested.started_at IS NULL
+			AND nested.canceled_at IS NULL
 			-- Ensure the caller has the correct provisioner.
 			AND nested.provisioner = ANY(@types :: provisioner_type [ ])
 			-- Ensure the caller satisfies all job tags.
 			AND nested.tags <@ @tags :: jsonb
 		ORDER BY
+			nested.priority DESC,
 			nested.created_at
 		FOR UPDATE
 		SKIP LOCKED
@@ -50,6 +53,41 @@ WHERE
 -- name: GetProvisionerJobsCreatedAfter :many
 SELECT * FROM provisioner_jobs WHERE created_at > $1;
 
+-- name: GetPendingProvisionerJobs :many
+-- Returns the jobs waiting for a provisioner daemon in the order they will
+-- be acquired.
+SELECT
+	*
+FROM
+	provisioner_jobs
+WHERE
+	started_at IS NULL
+	AND canceled_at IS NULL
+ORDER BY
+	priority DESC,
+	created_at;
+
+-- name: GetPendingProvisionerJobStats :many
+-- Returns the number of jobs waiting for a provisioner daemon and when the
+-- oldest of them was created, grouped by the daemons that can acquire them.
+SELECT
+	provisioner_jobs.provisioner,
+	provisioner_jobs.tags,
+	organizations.name AS organization_name,
+	COUNT(*) AS count,
+	MIN(provisioner_jobs.created_at)::timestamptz AS oldest_created_at
+FROM
+	provisioner_jobs
+JOIN
+	organizations ON organizations.id = provisioner_jobs.organization_id
+WHERE
+	provisioner_jobs.started_at IS NULL
+	AND provisioner_jobs.canceled_at IS NULL
+GROUP BY
+	provisioner_jobs.provisioner,
+	provisioner_jobs.tags,
+	organizations.name;
+
 -- name: InsertProvisionerJob :one
 INSERT INTO
 	provisioner_jobs (
@@ -63,10 +101,11 @@ INSERT INTO
 		file_id,
 		"type",
 		"input",
-		tags
+		tags,
+		priority
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING *;
 
 -- name: UpdateProvisionerJobByID :exec
 UPDATE
@@ -76,6 +115,27 @@ SET
 WHERE
 	id = $1;
 
+-- name: BumpProvisionerJobByID :exec
+-- Moves a pending job to the front of the queue by giving it a higher
+-- priority than every other pending job.
+UPDATE
+	provisioner_jobs
+SET
+	updated_at = @updated_at,
+	priority = (
+		SELECT
+			MAX(pending.priority) + 1
+		FROM
+			provisioner_jobs AS pending
+		WHERE
+			pending.started_at IS NULL
+			AND pending.canceled_at IS NULL
+	)
+WHERE
+	provisioner_jobs.id = @id
+	AND provisioner_jobs.started_at IS NULL
+	AND provisioner_jobs.canceled_at IS NULL;
+
 -- name: UpdateProvisionerJobWithCancelByID :exec
 UPDATE
 	provisioner_jobs
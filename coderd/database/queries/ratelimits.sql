@@ -0,0 +1,22 @@
+-- name: IncrementRateLimitCounter :one
+-- Increments the counter of the current window of a key. The counter is
+-- reset when a new window starts. Requests for an older window, e.g. from a
+-- replica with a skewed clock, are counted against the current window.
+INSERT INTO
+	rate_limit_counters (key, window_start, expires_at, count)
+VALUES
+	($1, $2, $3, 1)
+ON CONFLICT
+	(key)
+DO UPDATE SET
+	count = CASE
+		WHEN rate_limit_counters.window_start >= EXCLUDED.window_start THEN rate_limit_counters.count + 1
+		ELSE 1
+	END,
+	window_start = GREATEST(rate_limit_counters.window_start, EXCLUDED.window_start),
+	expires_at = GREATEST(rate_limit_counters.expires_at, EXCLUDED.expires_at)
+RETURNING
+	count;
+
+-- name: DeleteExpiredRateLimitCounters :exec
+DELETE FROM rate_limit_counters WHERE expires_at < @now :: timestamptz;
@@ -0,0 +1,62 @@
+-- name: UpsertTailnetCoordinator :one
+INSERT INTO
+	tailnet_coordinators (id, heartbeat_at)
+VALUES
+	($1, $2)
+ON CONFLICT (id)
+DO UPDATE SET
+	heartbeat_at = $2
+RETURNING *;
+
+-- name: DeleteTailnetCoordinator :exec
+DELETE FROM tailnet_coordinators WHERE id = $1;
+
+-- name: DeleteTailnetCoordinatorsHeartbeatBefore :exec
+-- Deleting a coordinator deletes the agents and clients connected to it.
+DELETE FROM tailnet_coordinators WHERE heartbeat_at < $1;
+
+-- name: GetTailnetCoordinators :many
+SELECT * FROM tailnet_coordinators ORDER BY heartbeat_at DESC;
+
+-- name: UpsertTailnetAgent :one
+INSERT INTO
+	tailnet_agents (id, coordinator_id, updated_at, node)
+VALUES
+	($1, $2, $3, $4)
+ON CONFLICT (id, coordinator_id)
+DO UPDATE SET
+	updated_at = $3,
+	node = $4
+RETURNING *;
+
+-- name: DeleteTailnetAgent :exec
+DELETE FROM tailnet_agents WHERE id = $1 AND coordinator_id = $2;
+
+-- name: GetTailnetAgents :many
+-- An agent may be connected to more than one coordinator while it reconnects,
+-- so the most recent node comes first.
+SELECT * FROM tailnet_agents WHERE id = $1 ORDER BY updated_at DESC;
+
+-- name: GetAllTailnetAgents :many
+SELECT * FROM tailnet_agents ORDER BY id, updated_at DESC;
+
+-- name: UpsertTailnetClient :one
+INSERT INTO
+	tailnet_clients (id, coordinator_id, agent_id, updated_at, node)
+VALUES
+	($1, $2, $3, $4, $5)
+ON CONFLICT (id, coordinator_id)
+DO UPDATE SET
+	agent_id = $3,
+	updated_at = $4,
+	node = $5
+RETURNING *;
+
+-- name: DeleteTailnetClient :exec
+DELETE FROM tailnet_clients WHERE id = $1 AND coordinator_id = $2;
+
+-- name: GetTailnetClientsForAgent :many
+SELECT * FROM tailnet_clients WHERE agent_id = $1 ORDER BY updated_at DESC;
+
+-- name: GetAllTailnetClients :many
+SELECT * FROM tailnet_clients ORDER BY agent_id, id;
@@ -104,7 +104,8 @@ SET
 	name = $4,
 	icon = $5,
 	display_name = $6,
-	allow_user_cancel_workspace_jobs = $7
+	allow_user_cancel_workspace_jobs = $7,
+	required_promotion_approvals = $8
 WHERE
 	id = $1
 RETURNING
@@ -116,7 +117,10 @@ UPDATE
 SET
 	updated_at = $2,
 	default_ttl = $3,
-	max_ttl = $4
+	max_ttl = $4,
+	inactivity_ttl = $5,
+	delete_ttl = $6,
+	autostop_requirement = $7
 WHERE
 	id = $1
 RETURNING
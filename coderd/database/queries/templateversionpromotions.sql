@@ -0,0 +1,100 @@
+-- name: InsertTemplateVersionPromotion :one
+INSERT INTO
+	template_version_promotions (
+		id,
+		created_at,
+		updated_at,
+		template_id,
+		template_version_id,
+		requester_id,
+		dry_run_job_id,
+		status
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: GetTemplateVersionPromotionByID :one
+SELECT
+	*
+FROM
+	template_version_promotions
+WHERE
+	id = $1
+LIMIT
+	1;
+
+-- name: GetPendingTemplateVersionPromotionByTemplateVersionID :one
+SELECT
+	*
+FROM
+	template_version_promotions
+WHERE
+	template_version_id = $1
+	AND status = 'pending'
+LIMIT
+	1;
+
+-- name: GetTemplateVersionPromotionsByTemplateID :many
+SELECT
+	*
+FROM
+	template_version_promotions
+WHERE
+	template_id = $1
+ORDER BY
+	created_at DESC;
+
+-- name: UpdateTemplateVersionPromotionByID :one
+UPDATE
+	template_version_promotions
+SET
+	updated_at = $2,
+	dry_run_job_id = $3,
+	status = $4,
+	promoted_at = $5
+WHERE
+	id = $1
+RETURNING
+	*;
+
+-- The status is only changed while the promotion is pending, so concurrent
+-- approvals and dry-run completions promote the version once.
+-- name: PromoteTemplateVersionPromotion :one
+UPDATE
+	template_version_promotions
+SET
+	status = 'promoted',
+	updated_at = @updated_at,
+	promoted_at = @promoted_at
+WHERE
+	id = @id
+	AND status = 'pending'
+RETURNING
+	*;
+
+-- Approvals are appended rather than overwritten, so concurrent approvals
+-- aren't lost. Approving twice has no effect.
+-- name: InsertTemplateVersionPromotionApproval :one
+UPDATE
+	template_version_promotions
+SET
+	updated_at = @updated_at,
+	approver_ids = CASE
+		WHEN @approver_id :: uuid = ANY(approver_ids) THEN approver_ids
+		ELSE array_append(approver_ids, @approver_id :: uuid)
+	END
+WHERE
+	id = @id
+RETURNING
+	*;
+
+-- name: SupersedeTemplateVersionPromotions :exec
+UPDATE
+	template_version_promotions
+SET
+	status = 'superseded',
+	updated_at = @updated_at
+WHERE
+	template_id = @template_id
+	AND status = 'pending'
+	AND id != @promotion_id;
@@ -0,0 +1,56 @@
+-- name: InsertWorkspaceAgentPortShareRule :exec
+INSERT INTO
+	workspace_agent_port_share_rules (
+		workspace_agent_id,
+		port,
+		share_level
+	)
+VALUES
+	($1, $2, $3);
+
+-- name: GetWorkspaceAgentPortShareRules :many
+SELECT
+	*
+FROM
+	workspace_agent_port_share_rules
+WHERE
+	workspace_agent_id = $1
+ORDER BY
+	port ASC;
+
+-- name: UpsertWorkspaceAgentPortShare :one
+INSERT INTO
+	workspace_agent_port_shares (
+		workspace_id,
+		agent_name,
+		port,
+		share_level,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6)
+ON CONFLICT (workspace_id, agent_name, port)
+DO UPDATE SET
+	share_level = $4,
+	updated_at = $6
+RETURNING *;
+
+-- name: GetWorkspaceAgentPortShares :many
+SELECT
+	*
+FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+	AND agent_name = $2
+ORDER BY
+	port ASC;
+
+-- name: DeleteWorkspaceAgentPortShare :exec
+DELETE FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+	AND agent_name = $2
+	AND port = $3;
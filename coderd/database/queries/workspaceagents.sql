@@ -60,10 +60,12 @@ INSERT INTO
 		login_before_ready,
 		startup_script_timeout_seconds,
 		shutdown_script,
-		shutdown_script_timeout_seconds
+		shutdown_script_timeout_seconds,
+		reconnecting_pty_buffer_size,
+		session_recording
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23) RETURNING *;
 
 -- name: UpdateWorkspaceAgentConnectionByID :exec
 UPDATE
@@ -152,3 +154,75 @@ WHERE
     	WHERE
 			wb.workspace_id = @workspace_id :: uuid
 	);
+
+-- name: InsertWorkspaceAgentMetadata :exec
+INSERT INTO
+	workspace_agent_metadata (
+		workspace_agent_id,
+		display_name,
+		key,
+		script,
+		timeout,
+		interval
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6);
+
+-- name: UpdateWorkspaceAgentMetadata :exec
+UPDATE
+	workspace_agent_metadata
+SET
+	value = $3,
+	error = $4,
+	collected_at = $5
+WHERE
+	workspace_agent_id = $1
+	AND key = $2;
+
+-- name: GetWorkspaceAgentMetadata :many
+SELECT
+	*
+FROM
+	workspace_agent_metadata
+WHERE
+	workspace_agent_id = $1;
+
+-- name: GetWorkspaceAgentsInLatestBuilds :many
+-- Returns the agents of the latest build of every workspace, along with the
+-- names used to label agent metrics.
+SELECT
+	workspace_agents.id,
+	workspace_agents.name,
+	workspace_agents.created_at,
+	workspace_agents.first_connected_at,
+	workspace_agents.last_connected_at,
+	workspace_agents.disconnected_at,
+	workspace_agents.connection_timeout_seconds,
+	workspaces.name AS workspace_name,
+	users.username,
+	templates.name AS template_name,
+	organizations.name AS organization_name
+FROM
+	workspace_agents
+JOIN
+	workspace_resources ON workspace_resources.id = workspace_agents.resource_id
+JOIN
+	workspace_builds ON workspace_builds.job_id = workspace_resources.job_id
+JOIN
+	workspaces ON workspaces.id = workspace_builds.workspace_id
+JOIN
+	users ON users.id = workspaces.owner_id
+JOIN
+	templates ON templates.id = workspaces.template_id
+JOIN
+	organizations ON organizations.id = workspaces.organization_id
+WHERE
+	workspaces.deleted = false
+	AND workspace_builds.build_number = (
+		SELECT
+			MAX(build_number)
+		FROM
+			workspace_builds AS latest_builds
+		WHERE
+			latest_builds.workspace_id = workspaces.id
+	);
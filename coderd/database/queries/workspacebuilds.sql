@@ -144,3 +144,51 @@ SET
 WHERE
 	id = $1 RETURNING *;
 
+
+-- The provisioner state of a build is only needed while it's the latest build
+-- of its workspace, so the state of superseded builds can be cleared. Builds
+-- are updated in batches so that large purges don't hold locks for long.
+-- name: DeleteOldWorkspaceBuildStates :execrows
+UPDATE
+	workspace_builds
+SET
+	provisioner_state = NULL
+WHERE
+	id IN (
+		SELECT
+			wb.id
+		FROM
+			workspace_builds wb
+		WHERE
+			wb.created_at < @before :: timestamptz
+			AND wb.provisioner_state IS NOT NULL
+			AND wb.build_number < (
+				SELECT
+					MAX(build_number)
+				FROM
+					workspace_builds
+				WHERE
+					workspace_builds.workspace_id = wb.workspace_id
+			)
+		ORDER BY
+			wb.created_at ASC
+		LIMIT
+			@limit_count :: integer
+	);
+
+-- name: GetOldWorkspaceBuildStateCount :one
+SELECT
+	COUNT(*)
+FROM
+	workspace_builds wb
+WHERE
+	wb.created_at < @before :: timestamptz
+	AND wb.provisioner_state IS NOT NULL
+	AND wb.build_number < (
+		SELECT
+			MAX(build_number)
+		FROM
+			workspace_builds
+		WHERE
+			workspace_builds.workspace_id = wb.workspace_id
+	);
@@ -0,0 +1,86 @@
+-- name: InsertWorkspaceBulkJob :one
+INSERT INTO
+	workspace_bulk_jobs (
+		id,
+		created_at,
+		updated_at,
+		initiator_id,
+		action,
+		filter,
+		batch_size,
+		status
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: GetWorkspaceBulkJobByID :one
+SELECT
+	*
+FROM
+	workspace_bulk_jobs
+WHERE
+	id = $1
+LIMIT
+	1;
+
+-- name: GetStaleWorkspaceBulkJobs :many
+-- Returns the bulk jobs that are still running but haven't been updated since
+-- the given time, because the replica that ran them went away.
+SELECT
+	*
+FROM
+	workspace_bulk_jobs
+WHERE
+	completed_at IS NULL
+	AND updated_at < $1;
+
+-- name: UpdateWorkspaceBulkJobByID :exec
+UPDATE
+	workspace_bulk_jobs
+SET
+	updated_at = $2,
+	completed_at = $3,
+	status = $4,
+	error = $5
+WHERE
+	id = $1;
+
+-- name: InsertWorkspaceBulkJobWorkspace :one
+INSERT INTO
+	workspace_bulk_job_workspaces (
+		bulk_job_id,
+		workspace_id,
+		updated_at,
+		status
+	)
+VALUES
+	($1, $2, $3, $4) RETURNING *;
+
+-- name: GetWorkspaceBulkJobWorkspaces :many
+SELECT
+	workspace_bulk_job_workspaces.*,
+	workspaces.name AS workspace_name,
+	users.username AS owner_username
+FROM
+	workspace_bulk_job_workspaces
+INNER JOIN
+	workspaces ON workspaces.id = workspace_bulk_job_workspaces.workspace_id
+INNER JOIN
+	users ON users.id = workspaces.owner_id
+WHERE
+	workspace_bulk_job_workspaces.bulk_job_id = $1
+ORDER BY
+	users.username ASC,
+	workspaces.name ASC;
+
+-- name: UpdateWorkspaceBulkJobWorkspace :exec
+UPDATE
+	workspace_bulk_job_workspaces
+SET
+	updated_at = $3,
+	workspace_build_id = $4,
+	status = $5,
+	error = $6
+WHERE
+	bulk_job_id = $1
+	AND workspace_id = $2;
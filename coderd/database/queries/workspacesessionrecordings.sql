@@ -0,0 +1,42 @@
+-- name: InsertWorkspaceSessionRecording :one
+INSERT INTO
+	workspace_session_recordings (
+		id,
+		created_at,
+		workspace_id,
+		agent_id,
+		session_type,
+		started_at,
+		ended_at,
+		"data"
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: GetWorkspaceSessionRecordingByID :one
+SELECT
+	*
+FROM
+	workspace_session_recordings
+WHERE
+	id = $1
+LIMIT
+	1;
+
+-- name: GetWorkspaceSessionRecordingsByWorkspaceID :many
+-- The recordings themselves are left out, they can be large.
+SELECT
+	id,
+	created_at,
+	workspace_id,
+	agent_id,
+	session_type,
+	started_at,
+	ended_at,
+	octet_length("data")::bigint AS size
+FROM
+	workspace_session_recordings
+WHERE
+	workspace_id = $1
+ORDER BY
+	started_at DESC;
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/coder/coder/buildinfo"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbpurge"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
@@ -61,6 +63,36 @@ func (api *API) deploymentStats(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(r.Context(), rw, http.StatusOK, stats)
 }
 
+// @Summary Get deployment purge stats
+// @ID get-deployment-purge-stats
+// @Security CoderSessionToken
+// @Produce json
+// @Tags General
+// @Success 200 {object} codersdk.DeploymentPurgeStats
+// @Router /deployment/purge [get]
+func (api *API) deploymentPurgeStats(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceDeploymentStats) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	now := database.Now()
+	tables, err := dbpurge.Stats(ctx, api.Database, api.DeploymentValues.Retention, now)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching purge stats.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.DeploymentPurgeStats{
+		CollectedAt: now,
+		Tables:      tables,
+	})
+}
+
 // @Summary Build info
 // @ID build-info
 // @Produce json
@@ -3,10 +3,13 @@ package coderd_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/coder/coder/cli/clibase"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database/dbpurge"
 	"github.com/coder/coder/testutil"
 )
 
@@ -54,3 +57,27 @@ func TestDeploymentStats(t *testing.T) {
 	_, err := client.DeploymentStats(ctx)
 	require.NoError(t, err)
 }
+
+func TestDeploymentPurgeStats(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	cfg := coderdtest.DeploymentValues(t)
+	cfg.Retention.AuditLogs = clibase.Duration(24 * time.Hour)
+	client := coderdtest.New(t, &coderdtest.Options{
+		DeploymentValues: cfg,
+	})
+	_ = coderdtest.CreateFirstUser(t, client)
+	stats, err := client.DeploymentPurgeStats(ctx)
+	require.NoError(t, err)
+	require.Len(t, stats.Tables, 3)
+	for _, table := range stats.Tables {
+		if table.Table == dbpurge.TableAuditLogs {
+			require.Equal(t, (24 * time.Hour).Milliseconds(), table.RetentionMillis)
+			require.False(t, table.Before.IsZero())
+			continue
+		}
+		require.Zero(t, table.RetentionMillis)
+		require.Zero(t, table.Rows)
+	}
+}
@@ -0,0 +1,55 @@
+package httpmw
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+type notificationWebhookParamContextKey struct{}
+
+// NotificationWebhookParam returns the webhook extracted via the
+// ExtractNotificationWebhookParam middleware.
+func NotificationWebhookParam(r *http.Request) database.NotificationWebhook {
+	webhook, ok := r.Context().Value(notificationWebhookParamContextKey{}).(database.NotificationWebhook)
+	if !ok {
+		panic("developer error: notification webhook param middleware not provided")
+	}
+	return webhook
+}
+
+// ExtractNotificationWebhookParam grabs a notification webhook from the
+// "webhook" URL parameter.
+func ExtractNotificationWebhookParam(db database.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			webhookID, parsed := parseUUID(rw, r, "webhook")
+			if !parsed {
+				return
+			}
+
+			webhook, err := db.GetNotificationWebhookByID(ctx, webhookID)
+			if errors.Is(err, sql.ErrNoRows) {
+				httpapi.ResourceNotFound(rw)
+				return
+			}
+			if err != nil {
+				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+					Message: "Internal error fetching notification webhook.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+
+			ctx = context.WithValue(ctx, notificationWebhookParamContextKey{}, webhook)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
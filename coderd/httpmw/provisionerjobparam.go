@@ -0,0 +1,55 @@
+package httpmw
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+type provisionerJobParamContextKey struct{}
+
+// ProvisionerJobParam returns the provisioner job extracted via the
+// ExtractProvisionerJobParam middleware.
+func ProvisionerJobParam(r *http.Request) database.ProvisionerJob {
+	job, ok := r.Context().Value(provisionerJobParamContextKey{}).(database.ProvisionerJob)
+	if !ok {
+		panic("developer error: provisioner job param middleware not provided")
+	}
+	return job
+}
+
+// ExtractProvisionerJobParam grabs a provisioner job from the
+// "provisionerjob" URL parameter.
+func ExtractProvisionerJobParam(db database.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			jobID, parsed := parseUUID(rw, r, "provisionerjob")
+			if !parsed {
+				return
+			}
+
+			job, err := db.GetProvisionerJobByID(ctx, jobID)
+			if errors.Is(err, sql.ErrNoRows) {
+				httpapi.ResourceNotFound(rw)
+				return
+			}
+			if err != nil {
+				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+					Message: "Internal error fetching provisioner job.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+
+			ctx = context.WithValue(ctx, provisionerJobParamContextKey{}, job)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
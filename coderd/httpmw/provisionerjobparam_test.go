@@ -0,0 +1,79 @@
+package httpmw_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbfake"
+	"github.com/coder/coder/coderd/database/dbgen"
+	"github.com/coder/coder/coderd/httpmw"
+)
+
+func TestProvisionerJobParam(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			db  = dbfake.New()
+			job = dbgen.ProvisionerJob(t, db, database.ProvisionerJob{})
+			r   = httptest.NewRequest("GET", "/", nil)
+			w   = httptest.NewRecorder()
+		)
+
+		router := chi.NewRouter()
+		router.Use(httpmw.ExtractProvisionerJobParam(db))
+		router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			found := httpmw.ProvisionerJobParam(r)
+			require.Equal(t, job, found)
+			w.WriteHeader(http.StatusOK)
+		})
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("provisionerjob", job.ID.String())
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		router.ServeHTTP(w, r)
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			db  = dbfake.New()
+			job = dbgen.ProvisionerJob(t, db, database.ProvisionerJob{})
+			r   = httptest.NewRequest("GET", "/", nil)
+			w   = httptest.NewRecorder()
+		)
+
+		router := chi.NewRouter()
+		router.Use(httpmw.ExtractProvisionerJobParam(db))
+		router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			found := httpmw.ProvisionerJobParam(r)
+			require.Equal(t, job, found)
+			w.WriteHeader(http.StatusOK)
+		})
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("provisionerjob", uuid.NewString())
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		router.ServeHTTP(w, r)
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -2,71 +2,140 @@ package httpmw
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/httprate"
 	"golang.org/x/xerrors"
 
-	"github.com/coder/coder/coderd/database"
+	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/ratelimit"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
-	"github.com/coder/coder/cryptorand"
 )
 
 // RateLimit returns a handler that limits requests per-minute based
-// on IP, endpoint, and user ID (if available).
+// on IP, endpoint, and user ID (if available). The counters are local to
+// the returned handler.
 func RateLimit(count int, window time.Duration) func(http.Handler) http.Handler {
+	return RateLimitPolicy(
+		ratelimit.New(slog.Make(), ratelimit.NewMemoryStore(), nil),
+		ratelimit.Policy{
+			Name:       "default",
+			Limit:      count,
+			Window:     window,
+			ByEndpoint: true,
+		},
+	)
+}
+
+// RateLimitPolicy returns a handler that limits requests according to the
+// policy. Requests are counted per user if an API key was extracted, per
+// workspace agent if an agent token was, and per IP address otherwise.
+//
+// Owners can bypass the limit by setting the codersdk.BypassRatelimitHeader
+// header, e.g. for load tests and automation.
+func RateLimitPolicy(limiter *ratelimit.Limiter, policy ratelimit.Policy) func(http.Handler) http.Handler {
 	// -1 is no rate limit
-	if count <= 0 {
+	if policy.Limit <= 0 {
 		return func(handler http.Handler) http.Handler {
 			return handler
 		}
 	}
 
-	return httprate.Limit(
-		count,
-		window,
-		httprate.WithKeyFuncs(func(r *http.Request) (string, error) {
-			// Prioritize by user, but fallback to IP.
-			apiKey, ok := r.Context().Value(apiKeyContextKey{}).(database.APIKey)
-			if !ok {
-				return httprate.KeyByIP(r)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			key, roles, bypass, err := rateLimitActor(r)
+			if err != nil {
+				httpapi.Write(ctx, rw, http.StatusPreconditionRequired, codersdk.Response{
+					Message: err.Error(),
+				})
+				return
+			}
+			if bypass {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			if policy.ByEndpoint {
+				key += ":" + rateLimitEndpoint(r)
 			}
 
-			if ok, _ := strconv.ParseBool(r.Header.Get(codersdk.BypassRatelimitHeader)); !ok {
-				// No bypass attempt, just ratelimit.
-				return apiKey.UserID.String(), nil
+			result := limiter.Allow(ctx, policy, key, roles)
+			if result.Limit > 0 {
+				rw.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+				rw.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+				rw.Header().Set("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
 			}
+			if !result.Allowed {
+				retryAfter := math.Ceil(time.Until(result.Reset).Seconds())
+				rw.Header().Set("Retry-After", strconv.Itoa(int(math.Max(retryAfter, 1))))
+				httpapi.Write(ctx, rw, http.StatusTooManyRequests, codersdk.Response{
+					Message: fmt.Sprintf("You've been rate limited for sending more than %v requests in %v.", result.Limit, result.Window),
+				})
+				return
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// rateLimitEndpoint returns the route pattern of the request, so requests to
+// a route with different URL parameters share a counter. Otherwise every
+// workspace or template ID would add a counter, which is a row per window
+// with shared counters. Requests that don't match a route share a counter.
+func rateLimitEndpoint(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return r.URL.Path
+	}
+	// The middleware runs before the route is matched, so the pattern is
+	// resolved from the root router.
+	tctx := chi.NewRouteContext()
+	if !rctx.Routes.Match(tctx, r.Method, r.URL.Path) {
+		return "unmatched"
+	}
+	return tctx.RoutePattern()
+}
 
-			// Allow Owner to bypass rate limiting for load tests
-			// and automation.
-			auth := UserAuthorization(r)
+// rateLimitActor returns the key requests are counted by and the site roles
+// of the actor. Users may only request a bypass if they are an owner.
+func rateLimitActor(r *http.Request) (key string, roles []string, bypass bool, err error) {
+	// Prioritize by user, but fallback to the agent and IP.
+	apiKey, ok := APIKeyOptional(r)
+	if !ok {
+		if agent, ok := WorkspaceAgentOptional(r); ok {
+			return "agent:" + agent.ID.String(), nil, false, nil
+		}
+		ip, err := httprate.KeyByIP(r)
+		if err != nil {
+			return "", nil, false, err
+		}
+		return "ip:" + ip, nil, false, nil
+	}
 
-			// We avoid using rbac.Authorizer since rego is CPU-intensive
-			// and undermines the DoS-prevention goal of the rate limiter.
-			for _, role := range auth.Actor.SafeRoleNames() {
-				if role == rbac.RoleOwner() {
-					// HACK: use a random key each time to
-					// de facto disable rate limiting. The
-					// `httprate` package has no
-					// support for selectively changing the limit
-					// for particular keys.
-					return cryptorand.String(16)
-				}
-			}
+	key = "user:" + apiKey.UserID.String()
+	if auth, ok := UserAuthorizationOptional(r); ok {
+		roles = auth.Actor.SafeRoleNames()
+	}
+	if ok, _ := strconv.ParseBool(r.Header.Get(codersdk.BypassRatelimitHeader)); !ok {
+		// No bypass attempt, just ratelimit.
+		return key, roles, false, nil
+	}
 
-			return apiKey.UserID.String(), xerrors.Errorf(
-				"%q provided but user is not %v",
-				codersdk.BypassRatelimitHeader, rbac.RoleOwner(),
-			)
-		}, httprate.KeyByEndpoint),
-		httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
-			httpapi.Write(r.Context(), w, http.StatusTooManyRequests, codersdk.Response{
-				Message: fmt.Sprintf("You've been rate limited for sending more than %v requests in %v.", count, window),
-			})
-		}),
+	// We avoid using rbac.Authorizer since rego is CPU-intensive
+	// and undermines the DoS-prevention goal of the rate limiter.
+	for _, role := range roles {
+		if role == rbac.RoleOwner() {
+			return key, roles, true, nil
+		}
+	}
+	return key, roles, false, xerrors.Errorf(
+		"%q provided but user is not %v",
+		codersdk.BypassRatelimitHeader, rbac.RoleOwner(),
 	)
 }
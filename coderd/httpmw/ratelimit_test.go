@@ -1,21 +1,27 @@
 package httpmw_test
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"cdr.dev/slog/sloggers/slogtest"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/dbfake"
 	"github.com/coder/coder/coderd/database/dbgen"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/ratelimit"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 )
@@ -145,4 +151,165 @@ func TestRateLimit(t *testing.T) {
 			require.False(t, resp.StatusCode == http.StatusTooManyRequests)
 		}
 	})
+
+	t.Run("Headers", func(t *testing.T) {
+		t.Parallel()
+		rtr := chi.NewRouter()
+		rtr.Use(httpmw.RateLimit(2, time.Hour))
+		rtr.Get("/", func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+		})
+
+		for i, remaining := range []string{"1", "0", "0"} {
+			req := httptest.NewRequest("GET", "/", nil)
+			rec := httptest.NewRecorder()
+			rtr.ServeHTTP(rec, req)
+			resp := rec.Result()
+			_ = resp.Body.Close()
+			require.Equal(t, "2", resp.Header.Get("X-RateLimit-Limit"))
+			require.Equal(t, remaining, resp.Header.Get("X-RateLimit-Remaining"))
+			reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+			require.NoError(t, err)
+			require.Greater(t, reset, time.Now().Unix())
+			if i < 2 {
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+				require.Empty(t, resp.Header.Get("Retry-After"))
+				continue
+			}
+			require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+			retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+			require.NoError(t, err)
+			require.Positive(t, retryAfter)
+			require.LessOrEqual(t, retryAfter, int(time.Hour.Seconds()))
+		}
+	})
+
+	t.Run("RoleLimit", func(t *testing.T) {
+		t.Parallel()
+
+		db := dbfake.New()
+		u := dbgen.User(t, db, database.User{
+			RBACRoles: []string{rbac.RoleTemplateAdmin()},
+		})
+		_, key := dbgen.APIKey(t, db, database.APIKey{UserID: u.ID})
+
+		rtr := chi.NewRouter()
+		rtr.Use(httpmw.ExtractAPIKeyMW(httpmw.ExtractAPIKeyConfig{
+			DB:       db,
+			Optional: false,
+		}))
+		rtr.Use(httpmw.RateLimitPolicy(
+			ratelimit.New(slogtest.Make(t, nil), ratelimit.NewMemoryStore(), nil),
+			ratelimit.Policy{
+				Name:       ratelimit.PolicyWorkspaceBuilds,
+				Limit:      1,
+				Window:     time.Hour,
+				RoleLimits: map[string]int{rbac.RoleTemplateAdmin(): 3},
+			},
+		))
+		rtr.Get("/", func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+		})
+
+		for i := 0; i < 5; i++ {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(codersdk.SessionTokenHeader, key)
+			rec := httptest.NewRecorder()
+			req.RemoteAddr = randRemoteAddr()
+			rtr.ServeHTTP(rec, req)
+			resp := rec.Result()
+			_ = resp.Body.Close()
+			require.Equal(t, "3", resp.Header.Get("X-RateLimit-Limit"))
+			require.Equal(t, i >= 3, resp.StatusCode == http.StatusTooManyRequests)
+		}
+	})
+
+	t.Run("ByRoutePattern", func(t *testing.T) {
+		t.Parallel()
+
+		store := &keyStore{Store: ratelimit.NewMemoryStore(), keys: map[string]struct{}{}}
+		rtr := chi.NewRouter()
+		rtr.Route("/api/v2", func(r chi.Router) {
+			r.Use(httpmw.RateLimitPolicy(
+				ratelimit.New(slogtest.Make(t, nil), store, nil),
+				ratelimit.Policy{
+					Name:       ratelimit.PolicyAPI,
+					Limit:      3,
+					Window:     time.Hour,
+					ByEndpoint: true,
+				},
+			))
+			r.Get("/workspaces/{workspace}", func(rw http.ResponseWriter, r *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			})
+		})
+
+		// Requests to the same route with different IDs share a counter, so
+		// the number of counters doesn't grow with the number of IDs.
+		for i := 0; i < 5; i++ {
+			req := httptest.NewRequest("GET", "/api/v2/workspaces/"+uuid.NewString(), nil)
+			rec := httptest.NewRecorder()
+			rtr.ServeHTTP(rec, req)
+			resp := rec.Result()
+			_ = resp.Body.Close()
+			require.Equal(t, i >= 3, resp.StatusCode == http.StatusTooManyRequests)
+		}
+		for i := 0; i < 5; i++ {
+			req := httptest.NewRequest("GET", "/api/v2/unknown/"+uuid.NewString(), nil)
+			rec := httptest.NewRecorder()
+			rtr.ServeHTTP(rec, req)
+			resp := rec.Result()
+			_ = resp.Body.Close()
+		}
+		require.Len(t, store.keys, 2)
+	})
+
+	t.Run("WorkspaceAgent", func(t *testing.T) {
+		t.Parallel()
+
+		db := dbfake.New()
+		newAgent := func() database.WorkspaceAgent {
+			user := dbgen.User(t, db, database.User{})
+			workspace := dbgen.Workspace(t, db, database.Workspace{OwnerID: user.ID})
+			job := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{})
+			resource := dbgen.WorkspaceResource(t, db, database.WorkspaceResource{JobID: job.ID})
+			_ = dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{WorkspaceID: workspace.ID, JobID: job.ID})
+			return dbgen.WorkspaceAgent(t, db, database.WorkspaceAgent{ResourceID: resource.ID})
+		}
+		first, second := newAgent(), newAgent()
+
+		rtr := chi.NewRouter()
+		rtr.Use(
+			httpmw.ExtractWorkspaceAgent(db),
+			httpmw.RateLimit(1, time.Hour),
+		)
+		rtr.Get("/", func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+		})
+
+		// Agents behind the same IP address are counted separately.
+		for i, agent := range []database.WorkspaceAgent{first, second, first} {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(codersdk.SessionTokenHeader, agent.AuthToken.String())
+			rec := httptest.NewRecorder()
+			rtr.ServeHTTP(rec, req)
+			resp := rec.Result()
+			_ = resp.Body.Close()
+			require.Equal(t, i == 2, resp.StatusCode == http.StatusTooManyRequests)
+		}
+	})
+}
+
+// keyStore records the keys of the counters it increments.
+type keyStore struct {
+	ratelimit.Store
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+func (s *keyStore) Increment(ctx context.Context, key string, windowStart, expiresAt time.Time) (int64, error) {
+	s.mu.Lock()
+	s.keys[key] = struct{}{}
+	s.mu.Unlock()
+	return s.Store.Increment(ctx, key, windowStart, expiresAt)
 }
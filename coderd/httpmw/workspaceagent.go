@@ -18,9 +18,16 @@ import (
 
 type workspaceAgentContextKey struct{}
 
+// WorkspaceAgentOptional may return the workspace agent from the ExtractAgent
+// handler.
+func WorkspaceAgentOptional(r *http.Request) (database.WorkspaceAgent, bool) {
+	agent, ok := r.Context().Value(workspaceAgentContextKey{}).(database.WorkspaceAgent)
+	return agent, ok
+}
+
 // WorkspaceAgent returns the workspace agent from the ExtractAgent handler.
 func WorkspaceAgent(r *http.Request) database.WorkspaceAgent {
-	user, ok := r.Context().Value(workspaceAgentContextKey{}).(database.WorkspaceAgent)
+	user, ok := WorkspaceAgentOptional(r)
 	if !ok {
 		panic("developer error: agent middleware not provided")
 	}
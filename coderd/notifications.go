@@ -0,0 +1,373 @@
+package coderd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/notifications"
+	"github.com/coder/coder/codersdk"
+)
+
+// defaultNotificationWebhookDeliveriesLimit is the number of delivery
+// attempts returned when no limit is given.
+const defaultNotificationWebhookDeliveriesLimit = 100
+
+// @Summary Get notification webhooks
+// @ID get-notification-webhooks
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Notifications
+// @Success 200 {array} codersdk.NotificationWebhook
+// @Router /notifications/webhooks [get]
+func (api *API) notificationWebhooks(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhooks, err := api.Database.GetNotificationWebhooks(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching notification webhooks.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	resp := make([]codersdk.NotificationWebhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		resp = append(resp, convertNotificationWebhook(webhook))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp)
+}
+
+// @Summary Get notification webhook by ID
+// @ID get-notification-webhook-by-id
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Notifications
+// @Param webhook path string true "Webhook ID" format(uuid)
+// @Success 200 {object} codersdk.NotificationWebhook
+// @Router /notifications/webhooks/{webhook} [get]
+func (*API) notificationWebhook(rw http.ResponseWriter, r *http.Request) {
+	httpapi.Write(r.Context(), rw, http.StatusOK, convertNotificationWebhook(httpmw.NotificationWebhookParam(r)))
+}
+
+// @Summary Create notification webhook
+// @ID create-notification-webhook
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Notifications
+// @Param request body codersdk.CreateNotificationWebhookRequest true "Create notification webhook request"
+// @Success 201 {object} codersdk.NotificationWebhook
+// @Router /notifications/webhooks [post]
+func (api *API) postNotificationWebhook(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req codersdk.CreateNotificationWebhookRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	validErrs := validateNotificationWebhook(req.URL, req.Events, req.PayloadTemplate)
+	if len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid notification webhook.",
+			Validations: validErrs,
+		})
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	now := database.Now()
+	webhook, err := api.Database.InsertNotificationWebhook(ctx, database.InsertNotificationWebhookParams{
+		ID:              uuid.New(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Name:            req.Name,
+		Url:             req.URL,
+		Events:          convertNotificationEventTypes(req.Events),
+		PayloadTemplate: req.PayloadTemplate,
+		Secret:          req.Secret,
+		Enabled:         enabled,
+	})
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("Notification webhook with name %q already exists.", req.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating notification webhook.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertNotificationWebhook(webhook))
+}
+
+// @Summary Update notification webhook
+// @ID update-notification-webhook
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Notifications
+// @Param webhook path string true "Webhook ID" format(uuid)
+// @Param request body codersdk.PatchNotificationWebhookRequest true "Patch notification webhook request"
+// @Success 200 {object} codersdk.NotificationWebhook
+// @Router /notifications/webhooks/{webhook} [patch]
+func (api *API) patchNotificationWebhook(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx     = r.Context()
+		webhook = httpmw.NotificationWebhookParam(r)
+	)
+
+	var req codersdk.PatchNotificationWebhookRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	params := database.UpdateNotificationWebhookByIDParams{
+		ID:              webhook.ID,
+		UpdatedAt:       database.Now(),
+		Name:            webhook.Name,
+		Url:             webhook.Url,
+		Events:          webhook.Events,
+		PayloadTemplate: webhook.PayloadTemplate,
+		Secret:          webhook.Secret,
+		Enabled:         webhook.Enabled,
+	}
+	if req.Name != nil {
+		params.Name = *req.Name
+	}
+	if req.URL != nil {
+		params.Url = *req.URL
+	}
+	if req.Events != nil {
+		params.Events = convertNotificationEventTypes(req.Events)
+	}
+	if req.PayloadTemplate != nil {
+		params.PayloadTemplate = *req.PayloadTemplate
+	}
+	if req.Secret != nil {
+		params.Secret = *req.Secret
+	}
+	if req.Enabled != nil {
+		params.Enabled = *req.Enabled
+	}
+
+	var validErrs []codersdk.ValidationError
+	if params.Name == "" {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "name", Detail: "Name is required."})
+	}
+	events := make([]codersdk.NotificationEventType, 0, len(params.Events))
+	for _, event := range params.Events {
+		events = append(events, codersdk.NotificationEventType(event))
+	}
+	validErrs = append(validErrs, validateNotificationWebhook(params.Url, events, params.PayloadTemplate)...)
+	if len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid notification webhook.",
+			Validations: validErrs,
+		})
+		return
+	}
+
+	updated, err := api.Database.UpdateNotificationWebhookByID(ctx, params)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("Notification webhook with name %q already exists.", params.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating notification webhook.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertNotificationWebhook(updated))
+}
+
+// @Summary Delete notification webhook
+// @ID delete-notification-webhook
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Notifications
+// @Param webhook path string true "Webhook ID" format(uuid)
+// @Success 200 {object} codersdk.Response
+// @Router /notifications/webhooks/{webhook} [delete]
+func (api *API) deleteNotificationWebhook(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx     = r.Context()
+		webhook = httpmw.NotificationWebhookParam(r)
+	)
+
+	err := api.Database.DeleteNotificationWebhookByID(ctx, webhook.ID)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting notification webhook.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: "Notification webhook has been deleted!",
+	})
+}
+
+// @Summary Get notification webhook deliveries
+// @ID get-notification-webhook-deliveries
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Notifications
+// @Param webhook path string true "Webhook ID" format(uuid)
+// @Param limit query int false "Maximum number of deliveries returned"
+// @Success 200 {array} codersdk.NotificationWebhookDelivery
+// @Router /notifications/webhooks/{webhook}/deliveries [get]
+func (api *API) notificationWebhookDeliveries(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx     = r.Context()
+		webhook = httpmw.NotificationWebhookParam(r)
+		limit   = defaultNotificationWebhookDeliveriesLimit
+	)
+
+	if s := r.URL.Query().Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit <= 0 {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Query param \"limit\" must be a positive integer.",
+				Validations: []codersdk.ValidationError{
+					{Field: "limit", Detail: "Must be a positive integer."},
+				},
+			})
+			return
+		}
+	}
+
+	deliveries, err := api.Database.GetNotificationWebhookDeliveries(ctx, database.GetNotificationWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		LimitOpt:  int32(limit),
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching notification webhook deliveries.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	resp := make([]codersdk.NotificationWebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		resp = append(resp, convertNotificationWebhookDelivery(delivery))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp)
+}
+
+func validateNotificationWebhook(rawURL string, events []codersdk.NotificationEventType, payloadTemplate string) []codersdk.ValidationError {
+	var validErrs []codersdk.ValidationError
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		validErrs = append(validErrs, codersdk.ValidationError{
+			Field:  "url",
+			Detail: "Must be an absolute http or https URL.",
+		})
+	}
+	if len(events) == 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{
+			Field:  "events",
+			Detail: "At least one event is required.",
+		})
+	}
+	for _, event := range events {
+		if !slices.Contains(codersdk.NotificationEventTypes, event) {
+			validErrs = append(validErrs, codersdk.ValidationError{
+				Field:  "events",
+				Detail: fmt.Sprintf("Unknown event %q.", event),
+			})
+		}
+	}
+	_, err = notifications.ParsePayloadTemplate(payloadTemplate)
+	if err != nil {
+		validErrs = append(validErrs, codersdk.ValidationError{
+			Field:  "payload_template",
+			Detail: err.Error(),
+		})
+	}
+	return validErrs
+}
+
+func convertNotificationEventTypes(events []codersdk.NotificationEventType) []database.NotificationEventType {
+	converted := make([]database.NotificationEventType, 0, len(events))
+	for _, event := range events {
+		converted = append(converted, database.NotificationEventType(event))
+	}
+	return converted
+}
+
+func convertNotificationWebhook(webhook database.NotificationWebhook) codersdk.NotificationWebhook {
+	events := make([]codersdk.NotificationEventType, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, codersdk.NotificationEventType(event))
+	}
+	return codersdk.NotificationWebhook{
+		ID:              webhook.ID,
+		CreatedAt:       webhook.CreatedAt,
+		UpdatedAt:       webhook.UpdatedAt,
+		Name:            webhook.Name,
+		URL:             webhook.Url,
+		Events:          events,
+		PayloadTemplate: webhook.PayloadTemplate,
+		HasSecret:       webhook.Secret != "",
+		Enabled:         webhook.Enabled,
+	}
+}
+
+func convertNotificationWebhookDelivery(delivery database.NotificationWebhookDelivery) codersdk.NotificationWebhookDelivery {
+	converted := codersdk.NotificationWebhookDelivery{
+		ID:          delivery.ID,
+		WebhookID:   delivery.WebhookID,
+		EventID:     delivery.EventID,
+		EventType:   codersdk.NotificationEventType(delivery.EventType),
+		Attempt:     delivery.Attempt,
+		CreatedAt:   delivery.CreatedAt,
+		RequestBody: delivery.RequestBody,
+		StatusCode:  delivery.StatusCode,
+		Error:       delivery.Error,
+	}
+	if delivery.CompletedAt.Valid {
+		completedAt := delivery.CompletedAt.Time
+		converted.CompletedAt = &completedAt
+	}
+	return converted
+}
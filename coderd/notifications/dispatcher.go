@@ -0,0 +1,372 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	coderdwebhook "github.com/coder/coder/coderd/webhook"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/retry"
+)
+
+const (
+	// EventTypeHeader contains the type of the delivered event.
+	EventTypeHeader = "X-Coder-Event"
+	// EventIDHeader contains the ID of the delivered event. It is the same
+	// for every attempt, so receivers can use it to discard duplicates.
+	EventIDHeader = "X-Coder-Event-ID"
+
+	defaultMaxAttempts   = 5
+	defaultRetryInterval = time.Second
+	maxRetryInterval     = 5 * time.Minute
+	// maxErrorLength is the maximum length of the error stored for a
+	// delivery attempt.
+	maxErrorLength = 1024
+)
+
+type Options struct {
+	// HTTPClient defaults to a client with a 30s timeout. Clients without a
+	// timeout are copied with one, so an unresponsive webhook can't hold a
+	// delivery forever.
+	HTTPClient *http.Client
+	// MaxAttempts is the number of times delivery of an event to a
+	// webhook is attempted. Defaults to 5.
+	MaxAttempts int
+	// RetryInterval is the delay before the first retry. It doubles with
+	// every attempt. Defaults to 1s.
+	RetryInterval time.Duration
+}
+
+// Dispatcher delivers events published to EventChannel to the enabled
+// webhooks subscribed to them.
+type Dispatcher struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	logger      slog.Logger
+	db          database.Store
+	opts        Options
+	unsubscribe func()
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// New starts a dispatcher. It must be closed to stop pending deliveries.
+func New(logger slog.Logger, db database.Store, ps database.Pubsub, opts Options) (*Dispatcher, error) {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{}
+	}
+	if opts.HTTPClient.Timeout == 0 {
+		client := *opts.HTTPClient
+		client.Timeout = 30 * time.Second
+		opts.HTTPClient = &client
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = defaultRetryInterval
+	}
+
+	//nolint:gocritic // The dispatcher reads webhooks and the resources
+	// referenced by events on behalf of the deployment.
+	ctx, cancel := context.WithCancel(dbauthz.AsSystemRestricted(context.Background()))
+	d := &Dispatcher{
+		ctx:    ctx,
+		cancel: cancel,
+		logger: logger,
+		db:     db,
+		opts:   opts,
+	}
+	unsubscribe, err := ps.Subscribe(EventChannel, d.handleMessage)
+	if err != nil {
+		cancel()
+		return nil, xerrors.Errorf("subscribe to notification events: %w", err)
+	}
+	d.unsubscribe = unsubscribe
+	return d, nil
+}
+
+// Close stops listening for events and aborts pending deliveries.
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	d.unsubscribe()
+	d.cancel()
+	d.wg.Wait()
+	return nil
+}
+
+// goTracked runs fn in a goroutine that Close waits for. It returns false
+// if the dispatcher is closed.
+func (d *Dispatcher) goTracked(fn func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		fn()
+	}()
+	return true
+}
+
+func (d *Dispatcher) handleMessage(_ context.Context, message []byte) {
+	var event Event
+	err := json.Unmarshal(message, &event)
+	if err != nil {
+		d.logger.Error(d.ctx, "unmarshal notification event", slog.Error(err))
+		return
+	}
+	d.goTracked(func() {
+		d.dispatch(d.ctx, event)
+	})
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, event Event) {
+	logger := d.logger.With(slog.F("event_id", event.ID), slog.F("event_type", event.Type))
+
+	webhooks, err := d.db.GetEnabledNotificationWebhooksByEventType(ctx, event.Type)
+	if err != nil {
+		logger.Error(ctx, "get notification webhooks", slog.Error(err))
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := d.eventPayload(ctx, event)
+	if err != nil {
+		logger.Error(ctx, "load notification event payload", slog.Error(err))
+		return
+	}
+
+	for _, webhook := range webhooks {
+		webhook := webhook
+		d.goTracked(func() {
+			d.deliver(ctx, logger.With(slog.F("webhook_id", webhook.ID)), webhook, payload)
+		})
+	}
+}
+
+// deliver sends the event to the webhook, retrying with a backoff. Every
+// attempt is recorded in the delivery history. If another replica has
+// already made an attempt, delivery is left to that replica.
+func (d *Dispatcher) deliver(ctx context.Context, logger slog.Logger, webhook database.NotificationWebhook, payload codersdk.NotificationEvent) {
+	body, renderErr := RenderPayload(webhook.PayloadTemplate, payload)
+
+	var attempt int32
+	for r := retry.New(d.opts.RetryInterval, maxRetryInterval); r.Wait(ctx); {
+		attempt++
+		delivery, err := d.db.InsertNotificationWebhookDelivery(ctx, database.InsertNotificationWebhookDeliveryParams{
+			ID:          uuid.New(),
+			WebhookID:   webhook.ID,
+			EventID:     payload.ID,
+			EventType:   database.NotificationEventType(payload.Type),
+			Attempt:     attempt,
+			CreatedAt:   database.Now(),
+			RequestBody: string(body),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			logger.Error(ctx, "insert notification webhook delivery", slog.Error(err))
+			return
+		}
+
+		var statusCode int
+		err = renderErr
+		if err == nil {
+			statusCode, err = post(ctx, d.opts.HTTPClient, webhook, payload, body)
+		}
+		update := database.UpdateNotificationWebhookDeliveryByIDParams{
+			ID:          delivery.ID,
+			CompletedAt: sql.NullTime{Time: database.Now(), Valid: true},
+			StatusCode:  int32(statusCode),
+		}
+		if err != nil {
+			update.Error = truncate(err.Error(), maxErrorLength)
+		}
+		updateErr := d.db.UpdateNotificationWebhookDeliveryByID(ctx, update)
+		if updateErr != nil {
+			logger.Error(ctx, "update notification webhook delivery", slog.Error(updateErr))
+		}
+
+		if err == nil {
+			return
+		}
+		// A template that fails to render and client errors won't
+		// succeed when retried.
+		if renderErr != nil || coderdwebhook.IsPermanentStatus(statusCode) || int(attempt) >= d.opts.MaxAttempts {
+			logger.Warn(ctx, "notification webhook delivery failed",
+				slog.F("attempt", attempt), slog.Error(err))
+			return
+		}
+		logger.Debug(ctx, "notification webhook delivery failed, retrying",
+			slog.F("attempt", attempt), slog.Error(err))
+	}
+}
+
+func post(ctx context.Context, client *http.Client, webhook database.NotificationWebhook, event codersdk.NotificationEvent, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, xerrors.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventTypeHeader, string(event.Type))
+	req.Header.Set(EventIDHeader, event.ID.String())
+	if webhook.Secret != "" {
+		req.Header.Set(coderdwebhook.SignatureHeader, coderdwebhook.Signature(webhook.Secret, body))
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<20))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, xerrors.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// ParsePayloadTemplate parses a webhook payload template. An empty template
+// is valid and sends the JSON encoded event.
+func ParsePayloadTemplate(text string) (*template.Template, error) {
+	return template.New("payload").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Option("missingkey=zero").Parse(text)
+}
+
+// RenderPayload renders the request body sent to a webhook for an event.
+func RenderPayload(text string, event codersdk.NotificationEvent) ([]byte, error) {
+	if text == "" {
+		return json.Marshal(event)
+	}
+	tmpl, err := ParsePayloadTemplate(text)
+	if err != nil {
+		return nil, xerrors.Errorf("parse payload template: %w", err)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, event)
+	if err != nil {
+		return nil, xerrors.Errorf("render payload template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// eventPayload loads the resources referenced by an event.
+func (d *Dispatcher) eventPayload(ctx context.Context, event Event) (codersdk.NotificationEvent, error) {
+	payload := codersdk.NotificationEvent{
+		ID:        event.ID,
+		Type:      codersdk.NotificationEventType(event.Type),
+		CreatedAt: event.CreatedAt,
+	}
+	if !event.Deadline.IsZero() {
+		deadline := event.Deadline
+		payload.Deadline = &deadline
+	}
+
+	templateID := event.TemplateID
+	if event.WorkspaceID != uuid.Nil {
+		workspace, err := d.db.GetWorkspaceByID(ctx, event.WorkspaceID)
+		if err != nil {
+			return payload, xerrors.Errorf("get workspace: %w", err)
+		}
+		owner, err := d.db.GetUserByID(ctx, workspace.OwnerID)
+		if err != nil {
+			return payload, xerrors.Errorf("get workspace owner: %w", err)
+		}
+		payload.Workspace = &codersdk.NotificationEventWorkspace{
+			ID:        workspace.ID,
+			Name:      workspace.Name,
+			OwnerID:   owner.ID,
+			OwnerName: owner.Username,
+		}
+		templateID = workspace.TemplateID
+	}
+	if event.WorkspaceBuildID != uuid.Nil {
+		build, err := d.db.GetWorkspaceBuildByID(ctx, event.WorkspaceBuildID)
+		if err != nil {
+			return payload, xerrors.Errorf("get workspace build: %w", err)
+		}
+		job, err := d.db.GetProvisionerJobByID(ctx, build.JobID)
+		if err != nil {
+			return payload, xerrors.Errorf("get provisioner job: %w", err)
+		}
+		payload.Build = &codersdk.NotificationEventWorkspaceBuild{
+			ID:          build.ID,
+			BuildNumber: build.BuildNumber,
+			Transition:  codersdk.WorkspaceTransition(build.Transition),
+			Reason:      codersdk.BuildReason(build.Reason),
+			Status:      jobStatus(job),
+			Error:       job.Error.String,
+		}
+	}
+	if templateID != uuid.Nil {
+		tpl, err := d.db.GetTemplateByID(ctx, templateID)
+		if err != nil {
+			return payload, xerrors.Errorf("get template: %w", err)
+		}
+		payload.Template = &codersdk.NotificationEventTemplate{
+			ID:   tpl.ID,
+			Name: tpl.Name,
+		}
+	}
+	if event.TemplateVersionID != uuid.Nil {
+		version, err := d.db.GetTemplateVersionByID(ctx, event.TemplateVersionID)
+		if err != nil {
+			return payload, xerrors.Errorf("get template version: %w", err)
+		}
+		payload.TemplateVersion = &codersdk.NotificationEventTemplateVersion{
+			ID:   version.ID,
+			Name: version.Name,
+		}
+	}
+	return payload, nil
+}
+
+// jobStatus returns the status of a completed provisioner job.
+func jobStatus(job database.ProvisionerJob) codersdk.ProvisionerJobStatus {
+	switch {
+	case job.CanceledAt.Valid:
+		return codersdk.ProvisionerJobCanceled
+	case job.Error.Valid && job.Error.String != "":
+		return codersdk.ProvisionerJobFailed
+	case job.CompletedAt.Valid:
+		return codersdk.ProvisionerJobSucceeded
+	default:
+		return codersdk.ProvisionerJobRunning
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return fmt.Sprintf("%s...", s[:n])
+}
@@ -0,0 +1,393 @@
+package notifications_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbfake"
+	"github.com/coder/coder/coderd/database/dbgen"
+	"github.com/coder/coder/coderd/notifications"
+	coderdwebhook "github.com/coder/coder/coderd/webhook"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestDispatcher(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Deliver", func(t *testing.T) {
+		t.Parallel()
+
+		db, ps, build := setupBuild(t)
+		requests := make(chan *http.Request, 1)
+		bodies := make(chan []byte, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			requests <- r
+			bodies <- body
+		}))
+		t.Cleanup(srv.Close)
+
+		webhook := dbgen.NotificationWebhook(t, db, database.NotificationWebhook{
+			Url:    srv.URL,
+			Events: []database.NotificationEventType{database.NotificationEventTypeWorkspaceBuildSucceeded},
+			Secret: "secret",
+		})
+		newDispatcher(t, db, ps, notifications.Options{})
+
+		err := notifications.Publish(ps, notifications.WorkspaceBuildEvent(database.NotificationEventTypeWorkspaceBuildSucceeded, build))
+		require.NoError(t, err)
+
+		r := recv(t, requests)
+		body := <-bodies
+		require.Equal(t, coderdwebhook.Signature("secret", body), r.Header.Get(coderdwebhook.SignatureHeader))
+		require.Equal(t, string(database.NotificationEventTypeWorkspaceBuildSucceeded), r.Header.Get(notifications.EventTypeHeader))
+
+		var event codersdk.NotificationEvent
+		require.NoError(t, json.Unmarshal(body, &event))
+		require.Equal(t, codersdk.NotificationEventWorkspaceBuildSucceeded, event.Type)
+		require.Equal(t, r.Header.Get(notifications.EventIDHeader), event.ID.String())
+		require.NotNil(t, event.Workspace)
+		require.Equal(t, build.WorkspaceID, event.Workspace.ID)
+		require.NotNil(t, event.Build)
+		require.Equal(t, build.ID, event.Build.ID)
+		require.Equal(t, codersdk.ProvisionerJobSucceeded, event.Build.Status)
+		require.NotNil(t, event.Template)
+		require.NotNil(t, event.TemplateVersion)
+
+		deliveries := awaitDeliveries(t, db, webhook.ID, 1)
+		require.EqualValues(t, http.StatusOK, deliveries[0].StatusCode)
+		require.Empty(t, deliveries[0].Error)
+		require.Equal(t, string(body), deliveries[0].RequestBody)
+	})
+
+	t.Run("HTTPClient", func(t *testing.T) {
+		t.Parallel()
+
+		db, ps, build := setupBuild(t)
+		// The webhook isn't reachable without the configured client, e.g.
+		// because it's behind a proxy.
+		requests := make(chan *http.Request, 1)
+		client := &http.Client{
+			Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				requests <- r
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("")),
+					Request:    r,
+				}, nil
+			}),
+		}
+		webhook := dbgen.NotificationWebhook(t, db, database.NotificationWebhook{
+			Url:    "http://webhook.invalid",
+			Events: []database.NotificationEventType{database.NotificationEventTypeWorkspaceBuildSucceeded},
+		})
+		newDispatcher(t, db, ps, notifications.Options{HTTPClient: client})
+
+		err := notifications.Publish(ps, notifications.WorkspaceBuildEvent(database.NotificationEventTypeWorkspaceBuildSucceeded, build))
+		require.NoError(t, err)
+
+		r := recv(t, requests)
+		require.Equal(t, "webhook.invalid", r.URL.Host)
+		deliveries := awaitDeliveries(t, db, webhook.ID, 1)
+		require.EqualValues(t, http.StatusOK, deliveries[0].StatusCode)
+		require.Empty(t, deliveries[0].Error)
+	})
+
+	t.Run("PayloadTemplate", func(t *testing.T) {
+		t.Parallel()
+
+		db, ps, build := setupBuild(t)
+		bodies := make(chan []byte, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies <- body
+		}))
+		t.Cleanup(srv.Close)
+
+		dbgen.NotificationWebhook(t, db, database.NotificationWebhook{
+			Url:             srv.URL,
+			Events:          []database.NotificationEventType{database.NotificationEventTypeWorkspaceBuildFailed},
+			PayloadTemplate: `{"text": {{ printf "%s failed" .Workspace.Name | json }}}`,
+		})
+		newDispatcher(t, db, ps, notifications.Options{})
+
+		err := notifications.Publish(ps, notifications.WorkspaceBuildEvent(database.NotificationEventTypeWorkspaceBuildFailed, build))
+		require.NoError(t, err)
+
+		workspace, err := db.GetWorkspaceByID(context.Background(), build.WorkspaceID)
+		require.NoError(t, err)
+		body := recv(t, bodies)
+		require.JSONEq(t, `{"text": "`+workspace.Name+` failed"}`, string(body))
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		t.Parallel()
+
+		db, ps, build := setupBuild(t)
+		var calls atomic.Int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(srv.Close)
+
+		webhook := dbgen.NotificationWebhook(t, db, database.NotificationWebhook{
+			Url:    srv.URL,
+			Events: []database.NotificationEventType{database.NotificationEventTypeWorkspaceDeleted},
+		})
+		newDispatcher(t, db, ps, notifications.Options{RetryInterval: time.Millisecond})
+
+		err := notifications.Publish(ps, notifications.WorkspaceBuildEvent(database.NotificationEventTypeWorkspaceDeleted, build))
+		require.NoError(t, err)
+
+		deliveries := awaitDeliveries(t, db, webhook.ID, 2)
+		// Deliveries are returned newest first.
+		require.EqualValues(t, 2, deliveries[0].Attempt)
+		require.EqualValues(t, http.StatusNoContent, deliveries[0].StatusCode)
+		require.EqualValues(t, 1, deliveries[1].Attempt)
+		require.EqualValues(t, http.StatusBadGateway, deliveries[1].StatusCode)
+		require.NotEmpty(t, deliveries[1].Error)
+		require.Equal(t, deliveries[0].EventID, deliveries[1].EventID)
+	})
+
+	t.Run("PermanentFailure", func(t *testing.T) {
+		t.Parallel()
+
+		db, ps, build := setupBuild(t)
+		var calls atomic.Int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		t.Cleanup(srv.Close)
+
+		webhook := dbgen.NotificationWebhook(t, db, database.NotificationWebhook{
+			Url:    srv.URL,
+			Events: []database.NotificationEventType{database.NotificationEventTypeWorkspaceBuildSucceeded},
+		})
+		dispatcher := newDispatcher(t, db, ps, notifications.Options{RetryInterval: time.Millisecond})
+
+		err := notifications.Publish(ps, notifications.WorkspaceBuildEvent(database.NotificationEventTypeWorkspaceBuildSucceeded, build))
+		require.NoError(t, err)
+
+		deliveries := awaitDeliveries(t, db, webhook.ID, 1)
+		require.EqualValues(t, http.StatusNotFound, deliveries[0].StatusCode)
+		// Closing waits for pending deliveries, so no retry is in flight.
+		require.NoError(t, dispatcher.Close())
+		require.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("SingleReplica", func(t *testing.T) {
+		t.Parallel()
+
+		db, ps, build := setupBuild(t)
+		var calls atomic.Int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+		}))
+		t.Cleanup(srv.Close)
+
+		webhook := dbgen.NotificationWebhook(t, db, database.NotificationWebhook{
+			Url:    srv.URL,
+			Events: []database.NotificationEventType{database.NotificationEventTypeWorkspaceAutostopImminent},
+		})
+		first := newDispatcher(t, db, ps, notifications.Options{})
+		second := newDispatcher(t, db, ps, notifications.Options{})
+
+		// The event is published on every tick of the executor.
+		event := notifications.AutostopImminentEvent(build, time.Now().Add(time.Hour))
+		for i := 0; i < 3; i++ {
+			require.NoError(t, notifications.Publish(ps, event))
+		}
+
+		awaitDeliveries(t, db, webhook.ID, 1)
+		require.NoError(t, first.Close())
+		require.NoError(t, second.Close())
+		require.EqualValues(t, 1, calls.Load())
+		deliveries, err := db.GetNotificationWebhookDeliveries(context.Background(), database.GetNotificationWebhookDeliveriesParams{
+			WebhookID: webhook.ID,
+		})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		db, ps, build := setupBuild(t)
+		var calls atomic.Int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+		}))
+		t.Cleanup(srv.Close)
+
+		disabled := dbgen.NotificationWebhook(t, db, database.NotificationWebhook{
+			Url:    srv.URL,
+			Events: []database.NotificationEventType{database.NotificationEventTypeWorkspaceBuildSucceeded},
+		})
+		_, err := db.UpdateNotificationWebhookByID(context.Background(), database.UpdateNotificationWebhookByIDParams{
+			ID:      disabled.ID,
+			Name:    disabled.Name,
+			Url:     disabled.Url,
+			Events:  disabled.Events,
+			Enabled: false,
+		})
+		require.NoError(t, err)
+		// Not subscribed to the event.
+		dbgen.NotificationWebhook(t, db, database.NotificationWebhook{
+			Url:    srv.URL,
+			Events: []database.NotificationEventType{database.NotificationEventTypeWorkspaceBuildFailed},
+		})
+		enabled := dbgen.NotificationWebhook(t, db, database.NotificationWebhook{
+			Url:    srv.URL,
+			Events: []database.NotificationEventType{database.NotificationEventTypeWorkspaceBuildSucceeded},
+		})
+		dispatcher := newDispatcher(t, db, ps, notifications.Options{})
+
+		err = notifications.Publish(ps, notifications.WorkspaceBuildEvent(database.NotificationEventTypeWorkspaceBuildSucceeded, build))
+		require.NoError(t, err)
+
+		awaitDeliveries(t, db, enabled.ID, 1)
+		require.NoError(t, dispatcher.Close())
+		require.EqualValues(t, 1, calls.Load())
+	})
+}
+
+func TestRenderPayload(t *testing.T) {
+	t.Parallel()
+
+	event := codersdk.NotificationEvent{
+		ID:   uuid.New(),
+		Type: codersdk.NotificationEventTemplateVersionPromoted,
+		Template: &codersdk.NotificationEventTemplate{
+			Name: "docker",
+		},
+	}
+
+	body, err := notifications.RenderPayload("", event)
+	require.NoError(t, err)
+	var decoded codersdk.NotificationEvent
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	require.Equal(t, event.ID, decoded.ID)
+
+	body, err = notifications.RenderPayload("{{ .Type }}: {{ .Template.Name }}", event)
+	require.NoError(t, err)
+	require.Equal(t, "template_version_promoted: docker", string(body))
+
+	_, err = notifications.RenderPayload("{{ .Type ", event)
+	require.Error(t, err)
+
+	// Referencing a field of a missing resource fails to render.
+	_, err = notifications.RenderPayload("{{ .Workspace.Name }}", event)
+	require.Error(t, err)
+}
+
+func recv[T any](t *testing.T, c <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-c:
+		return v
+	case <-time.After(testutil.WaitShort):
+		t.Fatal("timed out waiting for webhook request")
+	}
+	var zero T
+	return zero
+}
+
+func newDispatcher(t *testing.T, db database.Store, ps database.Pubsub, opts notifications.Options) *notifications.Dispatcher {
+	t.Helper()
+	dispatcher, err := notifications.New(slogtest.Make(t, nil), db, ps, opts)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = dispatcher.Close()
+	})
+	return dispatcher
+}
+
+// setupBuild creates a workspace with a completed build.
+func setupBuild(t *testing.T) (database.Store, database.Pubsub, database.WorkspaceBuild) {
+	t.Helper()
+	db := dbfake.New()
+	ps := database.NewPubsubInMemory()
+
+	user := dbgen.User(t, db, database.User{})
+	org := dbgen.Organization(t, db, database.Organization{})
+	tpl := dbgen.Template(t, db, database.Template{
+		OrganizationID: org.ID,
+		CreatedBy:      user.ID,
+	})
+	version := dbgen.TemplateVersion(t, db, database.TemplateVersion{
+		OrganizationID: org.ID,
+		TemplateID:     uuid.NullUUID{UUID: tpl.ID, Valid: true},
+		CreatedBy:      user.ID,
+	})
+	workspace := dbgen.Workspace(t, db, database.Workspace{
+		OwnerID:        user.ID,
+		OrganizationID: org.ID,
+		TemplateID:     tpl.ID,
+	})
+	job := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{
+		OrganizationID: org.ID,
+		InitiatorID:    user.ID,
+		Type:           database.ProvisionerJobTypeWorkspaceBuild,
+	})
+	err := db.UpdateProvisionerJobWithCompleteByID(context.Background(), database.UpdateProvisionerJobWithCompleteByIDParams{
+		ID:          job.ID,
+		UpdatedAt:   database.Now(),
+		CompletedAt: sql.NullTime{Time: database.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+	build := dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+		WorkspaceID:       workspace.ID,
+		TemplateVersionID: version.ID,
+		JobID:             job.ID,
+	})
+	return db, ps, build
+}
+
+// awaitDeliveries waits until the webhook has n completed delivery attempts.
+func awaitDeliveries(t *testing.T, db database.Store, webhookID uuid.UUID, n int) []database.NotificationWebhookDelivery {
+	t.Helper()
+	var deliveries []database.NotificationWebhookDelivery
+	require.Eventually(t, func() bool {
+		var err error
+		deliveries, err = db.GetNotificationWebhookDeliveries(context.Background(), database.GetNotificationWebhookDeliveriesParams{
+			WebhookID: webhookID,
+		})
+		if !assert.NoError(t, err) || len(deliveries) < n {
+			return false
+		}
+		for _, delivery := range deliveries {
+			if !delivery.CompletedAt.Valid {
+				return false
+			}
+		}
+		return true
+	}, testutil.WaitShort, testutil.IntervalFast)
+	require.Len(t, deliveries, n)
+	return deliveries
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,80 @@
+// Package notifications delivers workspace and template lifecycle events to
+// the notification webhooks configured by deployment admins.
+//
+// Events are published to a deployment wide pubsub channel. Every replica
+// runs a Dispatcher, and the delivery history table ensures each attempt is
+// only made by a single replica.
+package notifications
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+// EventChannel is the pubsub channel events are published to.
+const EventChannel = "notifications"
+
+// Event is published to EventChannel. Only IDs are included, the dispatcher
+// loads the rest of the payload from the database.
+type Event struct {
+	// ID identifies the event across replicas. Publishers that may publish
+	// the same event more than once must use a stable ID.
+	ID                uuid.UUID                      `json:"id"`
+	Type              database.NotificationEventType `json:"type"`
+	CreatedAt         time.Time                      `json:"created_at"`
+	WorkspaceID       uuid.UUID                      `json:"workspace_id,omitempty"`
+	WorkspaceBuildID  uuid.UUID                      `json:"workspace_build_id,omitempty"`
+	TemplateID        uuid.UUID                      `json:"template_id,omitempty"`
+	TemplateVersionID uuid.UUID                      `json:"template_version_id,omitempty"`
+	Deadline          time.Time                      `json:"deadline,omitempty"`
+}
+
+// Publish sends an event to the dispatchers of all replicas. An ID and
+// creation time are assigned if unset.
+func Publish(ps database.Pubsub, event Event) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = database.Now()
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return xerrors.Errorf("marshal event: %w", err)
+	}
+	err = ps.Publish(EventChannel, data)
+	if err != nil {
+		return xerrors.Errorf("publish event: %w", err)
+	}
+	return nil
+}
+
+// WorkspaceBuildEvent returns the event published when a workspace build
+// completes.
+func WorkspaceBuildEvent(eventType database.NotificationEventType, build database.WorkspaceBuild) Event {
+	return Event{
+		Type:              eventType,
+		WorkspaceID:       build.WorkspaceID,
+		WorkspaceBuildID:  build.ID,
+		TemplateVersionID: build.TemplateVersionID,
+	}
+}
+
+// AutostopImminentEvent returns the event published when a workspace is
+// about to be stopped automatically. The ID is derived from the build, so
+// the event is delivered once no matter how often it is published.
+func AutostopImminentEvent(build database.WorkspaceBuild, deadline time.Time) Event {
+	return Event{
+		ID:                uuid.NewSHA1(build.ID, []byte(database.NotificationEventTypeWorkspaceAutostopImminent)),
+		Type:              database.NotificationEventTypeWorkspaceAutostopImminent,
+		WorkspaceID:       build.WorkspaceID,
+		WorkspaceBuildID:  build.ID,
+		TemplateVersionID: build.TemplateVersionID,
+		Deadline:          deadline,
+	}
+}
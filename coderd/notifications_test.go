@@ -0,0 +1,230 @@
+package coderd_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/util/ptr"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestNotificationWebhooks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SecretNeverReturned", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		const secret = "super-secret-signing-key"
+		webhook, err := client.CreateNotificationWebhook(ctx, codersdk.CreateNotificationWebhookRequest{
+			Name:   "slack",
+			URL:    "https://hooks.example.com/slack",
+			Events: []codersdk.NotificationEventType{codersdk.NotificationEventWorkspaceBuildFailed},
+			Secret: secret,
+		})
+		require.NoError(t, err)
+
+		for _, req := range []struct {
+			method string
+			path   string
+			body   interface{}
+		}{
+			{method: http.MethodGet, path: "/api/v2/notifications/webhooks"},
+			{method: http.MethodGet, path: "/api/v2/notifications/webhooks/" + webhook.ID.String()},
+			{method: http.MethodPatch, path: "/api/v2/notifications/webhooks/" + webhook.ID.String(), body: codersdk.PatchNotificationWebhookRequest{
+				Secret: ptr.Ref(secret),
+			}},
+		} {
+			res, err := client.Request(ctx, req.method, req.path, req.body)
+			require.NoError(t, err)
+			body, err := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+			require.NotContains(t, string(body), secret, "%s %s", req.method, req.path)
+			require.Contains(t, string(body), `"has_secret": true`, "%s %s", req.method, req.path)
+		}
+	})
+
+	t.Run("CRUD", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		webhook, err := client.CreateNotificationWebhook(ctx, codersdk.CreateNotificationWebhookRequest{
+			Name:   "slack",
+			URL:    "https://hooks.example.com/slack",
+			Events: []codersdk.NotificationEventType{codersdk.NotificationEventWorkspaceBuildFailed},
+			Secret: "secret",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "slack", webhook.Name)
+		require.True(t, webhook.Enabled)
+		require.True(t, webhook.HasSecret)
+
+		_, err = client.CreateNotificationWebhook(ctx, codersdk.CreateNotificationWebhookRequest{
+			Name:   "slack",
+			URL:    "https://hooks.example.com/other",
+			Events: []codersdk.NotificationEventType{codersdk.NotificationEventWorkspaceDeleted},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+
+		updated, err := client.PatchNotificationWebhook(ctx, webhook.ID, codersdk.PatchNotificationWebhookRequest{
+			Events:  []codersdk.NotificationEventType{codersdk.NotificationEventWorkspaceDeleted},
+			Secret:  ptr.Ref(""),
+			Enabled: ptr.Ref(false),
+		})
+		require.NoError(t, err)
+		require.Equal(t, webhook.URL, updated.URL)
+		require.Equal(t, []codersdk.NotificationEventType{codersdk.NotificationEventWorkspaceDeleted}, updated.Events)
+		require.False(t, updated.HasSecret)
+		require.False(t, updated.Enabled)
+
+		got, err := client.NotificationWebhook(ctx, webhook.ID)
+		require.NoError(t, err)
+		require.Equal(t, updated, got)
+
+		webhooks, err := client.NotificationWebhooks(ctx)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+
+		err = client.DeleteNotificationWebhook(ctx, webhook.ID)
+		require.NoError(t, err)
+		_, err = client.NotificationWebhook(ctx, webhook.ID)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateNotificationWebhook(ctx, codersdk.CreateNotificationWebhookRequest{
+			Name:            "invalid",
+			URL:             "ftp://hooks.example.com",
+			Events:          []codersdk.NotificationEventType{"unknown"},
+			PayloadTemplate: "{{ .Type ",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		fields := make([]string, 0, len(apiErr.Validations))
+		for _, validation := range apiErr.Validations {
+			fields = append(fields, validation.Field)
+		}
+		require.ElementsMatch(t, []string{"url", "events", "payload_template"}, fields)
+	})
+
+	t.Run("Member", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		member, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		webhook, err := client.CreateNotificationWebhook(ctx, codersdk.CreateNotificationWebhookRequest{
+			Name:   "admin",
+			URL:    "https://hooks.example.com",
+			Events: []codersdk.NotificationEventType{codersdk.NotificationEventWorkspaceDeleted},
+		})
+		require.NoError(t, err)
+
+		_, err = member.CreateNotificationWebhook(ctx, codersdk.CreateNotificationWebhookRequest{
+			Name:   "member",
+			URL:    "https://hooks.example.com",
+			Events: []codersdk.NotificationEventType{codersdk.NotificationEventWorkspaceDeleted},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+		webhooks, err := member.NotificationWebhooks(ctx)
+		require.NoError(t, err)
+		require.Empty(t, webhooks)
+
+		_, err = member.NotificationWebhook(ctx, webhook.ID)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("DeliverBuildSucceeded", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		events := make(chan codersdk.NotificationEvent, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			var event codersdk.NotificationEvent
+			if err := json.Unmarshal(body, &event); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			select {
+			case events <- event:
+			default:
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		webhook, err := client.CreateNotificationWebhook(ctx, codersdk.CreateNotificationWebhookRequest{
+			Name:   "builds",
+			URL:    srv.URL,
+			Events: []codersdk.NotificationEventType{codersdk.NotificationEventWorkspaceBuildSucceeded},
+		})
+		require.NoError(t, err)
+
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		var event codersdk.NotificationEvent
+		select {
+		case event = <-events:
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for webhook")
+		}
+		require.Equal(t, codersdk.NotificationEventWorkspaceBuildSucceeded, event.Type)
+		require.NotNil(t, event.Workspace)
+		require.Equal(t, workspace.Name, event.Workspace.Name)
+		require.NotNil(t, event.Build)
+		require.Equal(t, workspace.LatestBuild.ID, event.Build.ID)
+
+		require.Eventually(t, func() bool {
+			deliveries, err := client.NotificationWebhookDeliveries(ctx, webhook.ID, 0)
+			return err == nil && len(deliveries) == 1 && deliveries[0].StatusCode == http.StatusOK
+		}, testutil.WaitLong, testutil.IntervalFast)
+	})
+}
@@ -2,10 +2,13 @@ package prometheusmetrics
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/exp/maps"
 
 	"github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/database"
@@ -106,3 +109,194 @@ func Workspaces(ctx context.Context, registerer prometheus.Registerer, db databa
 	}()
 	return cancelFunc, nil
 }
+
+// Agents tracks the connection status of the agents in the latest build of
+// every workspace, and the network usage and latency reported by each agent
+// within the last interval. An inactiveTimeout of zero uses the coderd default.
+func Agents(ctx context.Context, registerer prometheus.Registerer, db database.Store, inactiveTimeout, duration time.Duration) (context.CancelFunc, error) {
+	if duration == 0 {
+		duration = 5 * time.Minute
+	}
+	if inactiveTimeout == 0 {
+		inactiveTimeout = 6 * time.Second
+	}
+
+	agentLabels := []string{"agent_name", "username", "workspace_name", "template_name", "organization_name"}
+	connectionsGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coderd",
+		Subsystem: "agents",
+		Name:      "connections",
+		Help:      "The number of workspace agents with a connection status.",
+	}, []string{"status", "template_name", "organization_name"})
+	err := registerer.Register(connectionsGauge)
+	if err != nil {
+		return nil, err
+	}
+	rxBytesGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coderd",
+		Subsystem: "agentstats",
+		Name:      "rx_bytes",
+		Help:      "The number of bytes received by the agent within the last interval.",
+	}, agentLabels)
+	err = registerer.Register(rxBytesGauge)
+	if err != nil {
+		return nil, err
+	}
+	txBytesGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coderd",
+		Subsystem: "agentstats",
+		Name:      "tx_bytes",
+		Help:      "The number of bytes sent by the agent within the last interval.",
+	}, agentLabels)
+	err = registerer.Register(txBytesGauge)
+	if err != nil {
+		return nil, err
+	}
+	latencyGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coderd",
+		Subsystem: "agentstats",
+		Name:      "connection_median_latency_seconds",
+		Help:      "The median latency of connections to the agent within the last interval.",
+	}, agentLabels)
+	err = registerer.Register(latencyGauge)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	ticker := time.NewTicker(duration)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			agents, err := db.GetWorkspaceAgentsInLatestBuilds(ctx)
+			if err != nil {
+				continue
+			}
+			stats, err := db.GetWorkspaceAgentStats(ctx, database.Now().Add(-duration))
+			if err != nil {
+				continue
+			}
+
+			connectionsGauge.Reset()
+			agentsByID := make(map[uuid.UUID]database.GetWorkspaceAgentsInLatestBuildsRow, len(agents))
+			for _, agent := range agents {
+				agentsByID[agent.ID] = agent
+				status := database.WorkspaceAgent{
+					CreatedAt:                agent.CreatedAt,
+					FirstConnectedAt:         agent.FirstConnectedAt,
+					LastConnectedAt:          agent.LastConnectedAt,
+					DisconnectedAt:           agent.DisconnectedAt,
+					ConnectionTimeoutSeconds: agent.ConnectionTimeoutSeconds,
+				}.Status(inactiveTimeout)
+				connectionsGauge.WithLabelValues(string(status.Status), agent.TemplateName, agent.OrganizationName).Add(1)
+			}
+
+			rxBytesGauge.Reset()
+			txBytesGauge.Reset()
+			latencyGauge.Reset()
+			for _, stat := range stats {
+				agent, ok := agentsByID[stat.AgentID]
+				if !ok {
+					continue
+				}
+				labels := []string{agent.Name, agent.Username, agent.WorkspaceName, agent.TemplateName, agent.OrganizationName}
+				rxBytesGauge.WithLabelValues(labels...).Set(float64(stat.WorkspaceRxBytes))
+				txBytesGauge.WithLabelValues(labels...).Set(float64(stat.WorkspaceTxBytes))
+				latencyGauge.WithLabelValues(labels...).Set(stat.WorkspaceConnectionLatency50 / 1000)
+			}
+		}
+	}()
+	return cancelFunc, nil
+}
+
+// ProvisionerJobs tracks the number of provisioner jobs waiting for a
+// provisioner daemon and how long the oldest of them has been waiting.
+func ProvisionerJobs(ctx context.Context, registerer prometheus.Registerer, db database.Store, duration time.Duration) (context.CancelFunc, error) {
+	if duration == 0 {
+		duration = 5 * time.Minute
+	}
+
+	labels := []string{"provisioner", "tags", "organization_name"}
+	pendingGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coderd",
+		Subsystem: "provisioner_jobs",
+		Name:      "pending",
+		Help:      "The number of provisioner jobs waiting for a provisioner daemon.",
+	}, labels)
+	err := registerer.Register(pendingGauge)
+	if err != nil {
+		return nil, err
+	}
+	waitGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coderd",
+		Subsystem: "provisioner_jobs",
+		Name:      "pending_wait_seconds",
+		Help:      "The time the oldest pending provisioner job has been waiting for a provisioner daemon.",
+	}, labels)
+	err = registerer.Register(waitGauge)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	ticker := time.NewTicker(duration)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			rows, err := db.GetPendingProvisionerJobStats(ctx)
+			if err != nil {
+				continue
+			}
+
+			// Different tags may format the same way, e.g. if a value contains
+			// a comma, so merge the rows by their labels first.
+			type queue struct {
+				count  int64
+				oldest time.Time
+			}
+			queues := make(map[[3]string]queue, len(rows))
+			for _, row := range rows {
+				key := [3]string{string(row.Provisioner), formatTags(row.Tags), row.OrganizationName}
+				q, ok := queues[key]
+				q.count += row.Count
+				if !ok || row.OldestCreatedAt.Before(q.oldest) {
+					q.oldest = row.OldestCreatedAt
+				}
+				queues[key] = q
+			}
+
+			pendingGauge.Reset()
+			waitGauge.Reset()
+			now := database.Now()
+			for key, q := range queues {
+				pendingGauge.WithLabelValues(key[:]...).Set(float64(q.count))
+				waitGauge.WithLabelValues(key[:]...).Set(now.Sub(q.oldest).Seconds())
+			}
+		}
+	}()
+	return cancelFunc, nil
+}
+
+// formatTags returns the provisioner tags as comma-separated key=value pairs
+// sorted by key.
+func formatTags(tags map[string]string) string {
+	keys := maps.Keys(tags)
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+tags[key])
+	}
+	return strings.Join(pairs, ",")
+}
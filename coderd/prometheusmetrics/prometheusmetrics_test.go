@@ -239,3 +239,151 @@ func TestWorkspaces(t *testing.T) {
 		})
 	}
 }
+
+func TestAgents(t *testing.T) {
+	t.Parallel()
+
+	db := dbfake.New()
+	org := dbgen.Organization(t, db, database.Organization{})
+	user := dbgen.User(t, db, database.User{})
+	template := dbgen.Template(t, db, database.Template{OrganizationID: org.ID})
+	workspace := dbgen.Workspace(t, db, database.Workspace{
+		OwnerID:        user.ID,
+		OrganizationID: org.ID,
+		TemplateID:     template.ID,
+	})
+	job := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{OrganizationID: org.ID})
+	_ = dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+		WorkspaceID: workspace.ID,
+		JobID:       job.ID,
+	})
+	resource := dbgen.WorkspaceResource(t, db, database.WorkspaceResource{JobID: job.ID})
+	connected := dbgen.WorkspaceAgent(t, db, database.WorkspaceAgent{ResourceID: resource.ID})
+	err := db.UpdateWorkspaceAgentConnectionByID(context.Background(), database.UpdateWorkspaceAgentConnectionByIDParams{
+		ID:               connected.ID,
+		FirstConnectedAt: sql.NullTime{Time: database.Now(), Valid: true},
+		// Far enough in the future for the agent to stay connected
+		// for the duration of the test.
+		LastConnectedAt: sql.NullTime{Time: database.Now().Add(time.Hour), Valid: true},
+		UpdatedAt:       database.Now(),
+	})
+	require.NoError(t, err)
+	_ = dbgen.WorkspaceAgent(t, db, database.WorkspaceAgent{ResourceID: resource.ID})
+	_ = dbgen.WorkspaceAgentStat(t, db, database.WorkspaceAgentStat{
+		// Stats are only reported for the last interval, so keep this
+		// within it for the duration of the test.
+		CreatedAt:                 database.Now().Add(time.Hour),
+		AgentID:                   connected.ID,
+		WorkspaceID:               workspace.ID,
+		TemplateID:                template.ID,
+		UserID:                    user.ID,
+		RxBytes:                   1024,
+		TxBytes:                   2048,
+		ConnectionMedianLatencyMS: 50,
+	})
+	// Agents of other builds are ignored.
+	_ = dbgen.WorkspaceAgent(t, db, database.WorkspaceAgent{})
+
+	registry := prometheus.NewRegistry()
+	cancel, err := prometheusmetrics.Agents(context.Background(), registry, db, 0, time.Millisecond)
+	require.NoError(t, err)
+	t.Cleanup(cancel)
+
+	require.Eventually(t, func() bool {
+		metrics, err := registry.Gather()
+		assert.NoError(t, err)
+		connections := map[string]float64{}
+		var rxBytes, txBytes, latency float64
+		for _, family := range metrics {
+			for _, metric := range family.GetMetric() {
+				labels := map[string]string{}
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				assert.Equal(t, template.Name, labels["template_name"])
+				assert.Equal(t, org.Name, labels["organization_name"])
+				switch family.GetName() {
+				case "coderd_agents_connections":
+					connections[labels["status"]] = metric.GetGauge().GetValue()
+				case "coderd_agentstats_rx_bytes":
+					assert.Equal(t, connected.Name, labels["agent_name"])
+					assert.Equal(t, user.Username, labels["username"])
+					assert.Equal(t, workspace.Name, labels["workspace_name"])
+					rxBytes = metric.GetGauge().GetValue()
+				case "coderd_agentstats_tx_bytes":
+					txBytes = metric.GetGauge().GetValue()
+				case "coderd_agentstats_connection_median_latency_seconds":
+					latency = metric.GetGauge().GetValue()
+				}
+			}
+		}
+		return connections[string(database.WorkspaceAgentStatusConnected)] == 1 &&
+			connections[string(database.WorkspaceAgentStatusConnecting)] == 1 &&
+			rxBytes == 1024 && txBytes == 2048 && latency == 0.05
+	}, testutil.WaitShort, testutil.IntervalFast)
+}
+
+func TestProvisionerJobs(t *testing.T) {
+	t.Parallel()
+
+	db := dbfake.New()
+	org := dbgen.Organization(t, db, database.Organization{})
+	tags := map[string]string{"scope": "organization", "environment": "on-prem"}
+	_ = dbgen.ProvisionerJob(t, db, database.ProvisionerJob{
+		OrganizationID: org.ID,
+		Provisioner:    database.ProvisionerTypeTerraform,
+		Tags:           tags,
+		CreatedAt:      database.Now().Add(-time.Hour),
+	})
+	_ = dbgen.ProvisionerJob(t, db, database.ProvisionerJob{
+		OrganizationID: org.ID,
+		Provisioner:    database.ProvisionerTypeTerraform,
+		Tags:           tags,
+	})
+	// Jobs with other tags are counted separately.
+	_ = dbgen.ProvisionerJob(t, db, database.ProvisionerJob{
+		OrganizationID: org.ID,
+		Provisioner:    database.ProvisionerTypeTerraform,
+		Tags:           map[string]string{"scope": "organization"},
+	})
+	// Started jobs aren't pending.
+	_ = dbgen.ProvisionerJob(t, db, database.ProvisionerJob{OrganizationID: org.ID})
+	_, err := db.AcquireProvisionerJob(context.Background(), database.AcquireProvisionerJobParams{
+		StartedAt: sql.NullTime{Time: database.Now(), Valid: true},
+		Types:     []database.ProvisionerType{database.ProvisionerTypeEcho},
+	})
+	require.NoError(t, err)
+
+	registry := prometheus.NewRegistry()
+	cancel, err := prometheusmetrics.ProvisionerJobs(context.Background(), registry, db, time.Millisecond)
+	require.NoError(t, err)
+	t.Cleanup(cancel)
+
+	require.Eventually(t, func() bool {
+		metrics, err := registry.Gather()
+		assert.NoError(t, err)
+		pending := map[string]float64{}
+		wait := map[string]float64{}
+		for _, family := range metrics {
+			for _, metric := range family.GetMetric() {
+				labels := map[string]string{}
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				assert.Equal(t, org.Name, labels["organization_name"])
+				assert.Equal(t, string(database.ProvisionerTypeTerraform), labels["provisioner"])
+				switch family.GetName() {
+				case "coderd_provisioner_jobs_pending":
+					pending[labels["tags"]] = metric.GetGauge().GetValue()
+				case "coderd_provisioner_jobs_pending_wait_seconds":
+					wait[labels["tags"]] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+		return len(pending) == 2 &&
+			pending["environment=on-prem,scope=organization"] == 2 &&
+			pending["scope=organization"] == 1 &&
+			wait["environment=on-prem,scope=organization"] >= time.Hour.Seconds() &&
+			wait["scope=organization"] < time.Hour.Seconds()
+	}, testutil.WaitShort, testutil.IntervalFast)
+}
@@ -0,0 +1,85 @@
+package provisionerdserver
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/codersdk"
+)
+
+// Metrics are recorded by provisioner daemon servers as jobs complete. They
+// are shared by all daemons connected to a single coderd.
+type Metrics struct {
+	WorkspaceBuildDuration *prometheus.HistogramVec
+}
+
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	auto := promauto.With(reg)
+
+	return &Metrics{
+		WorkspaceBuildDuration: auto.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "coderd",
+			Subsystem: "workspace_builds",
+			Name:      "duration_seconds",
+			Help:      "The time workspace builds took from being acquired by a provisioner daemon to completion.",
+			Buckets: []float64{
+				1, // 1s
+				10,
+				30,
+				60, // 1min
+				60 * 5,
+				60 * 10,
+				60 * 30, // 30min
+				60 * 60, // 1hr
+			},
+		}, []string{"template_name", "organization_name", "transition", "status"}),
+	}
+}
+
+// observeWorkspaceBuild records the duration of a completed workspace build
+// job. Each job is completed by exactly one server, so builds are observed
+// once regardless of how many replicas are running.
+func (server *Server) observeWorkspaceBuild(ctx context.Context, job database.ProvisionerJob, build database.WorkspaceBuild) {
+	if server.Metrics == nil || !job.StartedAt.Valid || !job.CompletedAt.Valid {
+		return
+	}
+
+	workspace, err := server.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		server.Logger.Warn(ctx, "get workspace for build metrics", slog.F("workspace_build_id", build.ID), slog.Error(err))
+		return
+	}
+	template, err := server.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		server.Logger.Warn(ctx, "get template for build metrics", slog.F("workspace_build_id", build.ID), slog.Error(err))
+		return
+	}
+	// Provisioner daemons can't read organizations.
+	//nolint:gocritic // Only the organization name is used as a label.
+	organization, err := server.Database.GetOrganizationByID(dbauthz.AsSystemRestricted(ctx), workspace.OrganizationID)
+	if err != nil {
+		server.Logger.Warn(ctx, "get organization for build metrics", slog.F("workspace_build_id", build.ID), slog.Error(err))
+		return
+	}
+
+	status := codersdk.ProvisionerJobSucceeded
+	switch {
+	case job.CanceledAt.Valid:
+		status = codersdk.ProvisionerJobCanceled
+	case job.Error.Valid:
+		status = codersdk.ProvisionerJobFailed
+	}
+
+	server.Metrics.WorkspaceBuildDuration.WithLabelValues(
+		template.Name,
+		organization.Name,
+		string(build.Transition),
+		string(status),
+	).Observe(job.CompletedAt.Time.Sub(job.StartedAt.Time).Seconds())
+}
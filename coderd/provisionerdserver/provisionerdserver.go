@@ -30,6 +30,7 @@ import (
 	"github.com/coder/coder/coderd/database/dbauthz"
 	"github.com/coder/coder/coderd/gitauth"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/notifications"
 	"github.com/coder/coder/coderd/parameter"
 	"github.com/coder/coder/coderd/schedule"
 	"github.com/coder/coder/coderd/telemetry"
@@ -45,6 +46,10 @@ var (
 	lastAcquireMutex sync.RWMutex
 )
 
+// DaemonOfflineAfter is how long a daemon can go without sending a heartbeat
+// before it's considered offline.
+const DaemonOfflineAfter = time.Minute
+
 type Server struct {
 	AccessURL             *url.URL
 	ID                    uuid.UUID
@@ -58,6 +63,8 @@ type Server struct {
 	QuotaCommitter        *atomic.Pointer[proto.QuotaCommitter]
 	Auditor               *atomic.Pointer[audit.Auditor]
 	TemplateScheduleStore *atomic.Pointer[schedule.TemplateScheduleStore]
+	// Metrics is optional; nothing is recorded when it's nil.
+	Metrics *Metrics
 
 	AcquireJobDebounce time.Duration
 	OIDCConfig         httpmw.OAuth2Config
@@ -296,7 +303,7 @@ func (server *Server) AcquireJob(ctx context.Context, _ *proto.Empty) (*proto.Ac
 		parameters, err := parameter.Compute(ctx, server.Database, parameter.ComputeScope{
 			TemplateImportJobID:       templateVersion.JobID,
 			TemplateID:                templateVersion.TemplateID,
-			WorkspaceID:               uuid.NullUUID{},
+			WorkspaceID:               input.WorkspaceID,
 			AdditionalParameterValues: input.ParameterValues,
 		}, nil)
 		if err != nil {
@@ -309,15 +316,43 @@ func (server *Server) AcquireJob(ctx context.Context, _ *proto.Empty) (*proto.Ac
 			return nil, failJob(fmt.Sprintf("convert computed parameters to protobuf: %s", err))
 		}
 
+		metadata := &sdkproto.Provision_Metadata{
+			CoderUrl:      server.AccessURL.String(),
+			WorkspaceName: input.WorkspaceName,
+		}
+		// Plan against the state of the workspace's latest build so the
+		// changes reflect what a build of this version would do to it.
+		var state []byte
+		if input.WorkspaceID.Valid {
+			workspace, err := server.Database.GetWorkspaceByID(ctx, input.WorkspaceID.UUID)
+			if err != nil {
+				return nil, failJob(fmt.Sprintf("get workspace: %s", err))
+			}
+			workspaceBuild, err := server.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+			if err != nil {
+				return nil, failJob(fmt.Sprintf("get latest workspace build: %s", err))
+			}
+			owner, err := server.Database.GetUserByID(ctx, workspace.OwnerID)
+			if err != nil {
+				return nil, failJob(fmt.Sprintf("get owner: %s", err))
+			}
+			state = workspaceBuild.ProvisionerState
+			if metadata.WorkspaceName == "" {
+				metadata.WorkspaceName = workspace.Name
+			}
+			metadata.WorkspaceId = workspace.ID.String()
+			metadata.WorkspaceOwner = owner.Username
+			metadata.WorkspaceOwnerEmail = owner.Email
+			metadata.WorkspaceOwnerId = owner.ID.String()
+		}
+
 		protoJob.Type = &proto.AcquiredJob_TemplateDryRun_{
 			TemplateDryRun: &proto.AcquiredJob_TemplateDryRun{
 				ParameterValues:     protoParameters,
 				RichParameterValues: convertRichParameterValues(input.RichParameterValues),
 				VariableValues:      asVariableValues(templateVariables),
-				Metadata: &sdkproto.Provision_Metadata{
-					CoderUrl:      server.AccessURL.String(),
-					WorkspaceName: input.WorkspaceName,
-				},
+				Metadata:            metadata,
+				State:               state,
 			},
 		}
 	case database.ProvisionerJobTypeTemplateVersionImport:
@@ -441,6 +476,31 @@ func (server *Server) CommitQuota(ctx context.Context, request *proto.CommitQuot
 	return (*q).CommitQuota(ctx, request)
 }
 
+// Heartbeat marks the daemon as alive, and records the version and current job
+// it reported.
+func (server *Server) Heartbeat(ctx context.Context, request *proto.HeartbeatRequest) (*proto.Empty, error) {
+	//nolint:gocritic // Provisionerd has specific authz rules.
+	ctx = dbauthz.AsProvisionerd(ctx)
+	var jobID uuid.NullUUID
+	if request.JobId != "" {
+		parsedID, err := uuid.Parse(request.JobId)
+		if err != nil {
+			return nil, xerrors.Errorf("parse job id: %w", err)
+		}
+		jobID = uuid.NullUUID{UUID: parsedID, Valid: true}
+	}
+	err := server.Database.UpdateProvisionerDaemonHeartbeat(ctx, database.UpdateProvisionerDaemonHeartbeatParams{
+		ID:           server.ID,
+		LastSeenAt:   sql.NullTime{Time: database.Now(), Valid: true},
+		Version:      request.Version,
+		CurrentJobID: jobID,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("update provisioner daemon heartbeat: %w", err)
+	}
+	return &proto.Empty{}, nil
+}
+
 func (server *Server) UpdateJob(ctx context.Context, request *proto.UpdateJobRequest) (*proto.UpdateJobResponse, error) {
 	//nolint:gocritic // Provisionerd has specific authz rules.
 	ctx = dbauthz.AsProvisionerd(ctx)
@@ -764,6 +824,13 @@ func (server *Server) FailJob(ctx context.Context, failJob *proto.FailedJob) (*p
 		if err != nil {
 			server.Logger.Error(ctx, "audit log - get build", slog.Error(err))
 		} else {
+			server.observeWorkspaceBuild(ctx, job, build)
+
+			err = notifications.Publish(server.Pubsub, notifications.WorkspaceBuildEvent(database.NotificationEventTypeWorkspaceBuildFailed, build))
+			if err != nil {
+				server.Logger.Error(ctx, "publish build failed notification", slog.F("workspace_build_id", build.ID), slog.Error(err))
+			}
+
 			auditAction := auditActionFromTransition(build.Transition)
 			workspace, err := server.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
 			if err != nil {
@@ -999,14 +1066,29 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 					deadline = maxDeadline
 				}
 			}
+			// The autostop requirement is enforced through the max deadline,
+			// so it can't be extended by activity or by the user.
+			requiredStopAt, err := templateSchedule.RequiredStopAt(now, schedule.UserLocation(workspace.AutostartSchedule.String))
+			if err != nil {
+				return xerrors.Errorf("calculate autostop requirement: %w", err)
+			}
+			if !requiredStopAt.IsZero() {
+				if maxDeadline.IsZero() || requiredStopAt.Before(maxDeadline) {
+					maxDeadline = requiredStopAt
+				}
+				if deadline.IsZero() || maxDeadline.Before(deadline) {
+					deadline = maxDeadline
+				}
+			}
 
+			job.CompletedAt = sql.NullTime{
+				Time:  database.Now(),
+				Valid: true,
+			}
 			err = db.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
-				ID:        jobID,
-				UpdatedAt: database.Now(),
-				CompletedAt: sql.NullTime{
-					Time:  database.Now(),
-					Valid: true,
-				},
+				ID:          jobID,
+				UpdatedAt:   database.Now(),
+				CompletedAt: job.CompletedAt,
 			})
 			if err != nil {
 				return xerrors.Errorf("update provisioner job: %w", err)
@@ -1091,6 +1173,7 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 		if err != nil {
 			return nil, xerrors.Errorf("complete job: %w", err)
 		}
+		server.observeWorkspaceBuild(ctx, job, workspaceBuild)
 
 		// audit the outcome of the workspace build
 		if getWorkspaceError == nil {
@@ -1136,6 +1219,21 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 		if err != nil {
 			return nil, xerrors.Errorf("update workspace: %w", err)
 		}
+
+		events := []database.NotificationEventType{database.NotificationEventTypeWorkspaceBuildSucceeded}
+		if workspaceBuild.Transition == database.WorkspaceTransitionDelete {
+			events = append(events, database.NotificationEventTypeWorkspaceDeleted)
+		}
+		for _, event := range events {
+			err = notifications.Publish(server.Pubsub, notifications.WorkspaceBuildEvent(event, workspaceBuild))
+			if err != nil {
+				server.Logger.Error(ctx, "publish workspace build notification",
+					slog.F("workspace_build_id", workspaceBuild.ID),
+					slog.F("event", event),
+					slog.Error(err),
+				)
+			}
+		}
 	case *proto.CompletedJob_TemplateDryRun_:
 		for _, resource := range jobType.TemplateDryRun.Resources {
 			server.Logger.Info(ctx, "inserting template dry-run job resource",
@@ -1148,6 +1246,24 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 				return nil, xerrors.Errorf("insert resource: %w", err)
 			}
 		}
+		for _, change := range jobType.TemplateDryRun.ResourceChanges {
+			action, err := convertResourceChangeAction(change.Action)
+			if err != nil {
+				return nil, xerrors.Errorf("convert resource change action: %w", err)
+			}
+			_, err = server.Database.InsertProvisionerJobResourceChange(ctx, database.InsertProvisionerJobResourceChangeParams{
+				JobID:          jobID,
+				Address:        change.Address,
+				Type:           change.Type,
+				Name:           change.Name,
+				Action:         action,
+				DailyCost:      change.DailyCost,
+				PriorDailyCost: change.PriorDailyCost,
+			})
+			if err != nil {
+				return nil, xerrors.Errorf("insert resource change %q: %w", change.Address, err)
+			}
+		}
 
 		err = server.Database.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
 			ID:        jobID,
@@ -1165,6 +1281,12 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 			return nil, xerrors.Errorf("complete job: %w", err)
 		}
 
+		err = server.promoteAfterDryRun(ctx, job)
+		if err != nil {
+			server.Logger.Error(ctx, "promote template version after dry-run",
+				slog.F("job_id", jobID), slog.Error(err))
+		}
+
 	default:
 		if completed.Type == nil {
 			return nil, xerrors.Errorf("type payload must be provided")
@@ -1271,12 +1393,56 @@ func InsertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.
 				Valid:  prAgent.ShutdownScript != "",
 			},
 			ShutdownScriptTimeoutSeconds: prAgent.GetShutdownScriptTimeoutSeconds(),
+			ReconnectingPTYBufferSize:    prAgent.GetReconnectingPtyBufferSize(),
+			SessionRecording:             prAgent.GetSessionRecording(),
 		})
 		if err != nil {
 			return xerrors.Errorf("insert agent: %w", err)
 		}
 		snapshot.WorkspaceAgents = append(snapshot.WorkspaceAgents, telemetry.ConvertWorkspaceAgent(dbAgent))
 
+		for _, md := range prAgent.Metadata {
+			err := db.InsertWorkspaceAgentMetadata(ctx, database.InsertWorkspaceAgentMetadataParams{
+				WorkspaceAgentID: agentID,
+				DisplayName:      md.DisplayName,
+				Script:           md.Script,
+				Key:              md.Key,
+				Timeout:          md.Timeout,
+				Interval:         md.Interval,
+			})
+			if err != nil {
+				return xerrors.Errorf("insert agent metadata: %w", err)
+			}
+		}
+
+		portShares := make(map[int32]struct{})
+		for _, share := range prAgent.PortShares {
+			if share.Port < 1 || share.Port > 65535 {
+				return xerrors.Errorf("port share port %d must be between 1 and 65535", share.Port)
+			}
+			if _, exists := portShares[share.Port]; exists {
+				return xerrors.Errorf("duplicate port share, must be unique per agent: %d", share.Port)
+			}
+			portShares[share.Port] = struct{}{}
+
+			shareLevel := database.AppSharingLevelOwner
+			switch share.ShareLevel {
+			case sdkproto.AppSharingLevel_AUTHENTICATED:
+				shareLevel = database.AppSharingLevelAuthenticated
+			case sdkproto.AppSharingLevel_PUBLIC:
+				shareLevel = database.AppSharingLevelPublic
+			}
+
+			err := db.InsertWorkspaceAgentPortShareRule(ctx, database.InsertWorkspaceAgentPortShareRuleParams{
+				WorkspaceAgentID: agentID,
+				Port:             share.Port,
+				ShareLevel:       shareLevel,
+			})
+			if err != nil {
+				return xerrors.Errorf("insert agent port share: %w", err)
+			}
+		}
+
 		for _, app := range prAgent.Apps {
 			slug := app.Slug
 			if slug == "" {
@@ -1451,6 +1617,21 @@ func convertLogLevel(logLevel sdkproto.LogLevel) (database.LogLevel, error) {
 	}
 }
 
+func convertResourceChangeAction(action sdkproto.ResourceChangeAction) (database.ResourceChangeAction, error) {
+	switch action {
+	case sdkproto.ResourceChangeAction_CREATE:
+		return database.ResourceChangeActionCreate, nil
+	case sdkproto.ResourceChangeAction_UPDATE:
+		return database.ResourceChangeActionUpdate, nil
+	case sdkproto.ResourceChangeAction_REPLACE:
+		return database.ResourceChangeActionReplace, nil
+	case sdkproto.ResourceChangeAction_DELETE:
+		return database.ResourceChangeActionDelete, nil
+	default:
+		return database.ResourceChangeAction(""), xerrors.Errorf("unknown resource change action: %d", action)
+	}
+}
+
 func convertLogSource(logSource proto.LogSource) (database.LogSource, error) {
 	switch logSource {
 	case proto.LogSource_PROVISIONER_DAEMON:
@@ -1560,6 +1741,9 @@ type TemplateVersionDryRunJob struct {
 	WorkspaceName       string                             `json:"workspace_name"`
 	ParameterValues     []database.ParameterValue          `json:"parameter_values"`
 	RichParameterValues []database.WorkspaceBuildParameter `json:"rich_parameter_values"`
+	// WorkspaceID is the workspace whose state changes are planned against.
+	// Changes are planned against an empty workspace if it's not set.
+	WorkspaceID uuid.NullUUID `json:"workspace_id"`
 }
 
 // ProvisionerJobLogsNotifyMessage is the payload published on
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2"
 
@@ -291,6 +292,69 @@ func TestAcquireJob(t *testing.T) {
 		require.NoError(t, err)
 		require.JSONEq(t, string(want), string(got))
 	})
+	t.Run("TemplateVersionDryRunWorkspace", func(t *testing.T) {
+		t.Parallel()
+		srv := setup(t, false)
+		ctx := context.Background()
+
+		user := dbgen.User(t, srv.Database, database.User{})
+		template := dbgen.Template(t, srv.Database, database.Template{})
+		version := dbgen.TemplateVersion(t, srv.Database, database.TemplateVersion{
+			TemplateID: uuid.NullUUID{
+				UUID:  template.ID,
+				Valid: true,
+			},
+		})
+		workspace := dbgen.Workspace(t, srv.Database, database.Workspace{
+			TemplateID: template.ID,
+			OwnerID:    user.ID,
+		})
+		_ = dbgen.WorkspaceBuild(t, srv.Database, database.WorkspaceBuild{
+			WorkspaceID:      workspace.ID,
+			ProvisionerState: []byte("state"),
+		})
+		file := dbgen.File(t, srv.Database, database.File{CreatedBy: user.ID})
+		_ = dbgen.ProvisionerJob(t, srv.Database, database.ProvisionerJob{
+			InitiatorID:   user.ID,
+			Provisioner:   database.ProvisionerTypeEcho,
+			StorageMethod: database.ProvisionerStorageMethodFile,
+			FileID:        file.ID,
+			Type:          database.ProvisionerJobTypeTemplateVersionDryRun,
+			Input: must(json.Marshal(provisionerdserver.TemplateVersionDryRunJob{
+				TemplateVersionID: version.ID,
+				ParameterValues:   []database.ParameterValue{},
+				WorkspaceID: uuid.NullUUID{
+					UUID:  workspace.ID,
+					Valid: true,
+				},
+			})),
+		})
+
+		job, err := srv.AcquireJob(ctx, nil)
+		require.NoError(t, err)
+
+		got, err := json.Marshal(job.Type)
+		require.NoError(t, err)
+
+		// Changes are planned against the state of the workspace's latest
+		// build.
+		want, err := json.Marshal(&proto.AcquiredJob_TemplateDryRun_{
+			TemplateDryRun: &proto.AcquiredJob_TemplateDryRun{
+				ParameterValues: []*sdkproto.ParameterValue{},
+				State:           []byte("state"),
+				Metadata: &sdkproto.Provision_Metadata{
+					CoderUrl:            srv.AccessURL.String(),
+					WorkspaceName:       workspace.Name,
+					WorkspaceId:         workspace.ID.String(),
+					WorkspaceOwner:      user.Username,
+					WorkspaceOwnerEmail: user.Email,
+					WorkspaceOwnerId:    user.ID.String(),
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.JSONEq(t, string(want), string(got))
+	})
 	t.Run("TemplateVersionImport", func(t *testing.T) {
 		t.Parallel()
 		srv := setup(t, false)
@@ -1044,6 +1108,9 @@ func TestCompleteJob(t *testing.T) {
 			Provisioner:   database.ProvisionerTypeEcho,
 			Type:          database.ProvisionerJobTypeTemplateVersionDryRun,
 			StorageMethod: database.ProvisionerStorageMethodFile,
+			Input: must(json.Marshal(provisionerdserver.TemplateVersionDryRunJob{
+				TemplateVersionID: uuid.New(),
+			})),
 		})
 		require.NoError(t, err)
 		_, err = srv.Database.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -1063,10 +1130,154 @@ func TestCompleteJob(t *testing.T) {
 						Name: "something",
 						Type: "aws_instance",
 					}},
+					ResourceChanges: []*sdkproto.ResourceChange{{
+						Address:   "aws_instance.something",
+						Name:      "something",
+						Type:      "aws_instance",
+						Action:    sdkproto.ResourceChangeAction_CREATE,
+						DailyCost: 10,
+					}},
 				},
 			},
 		})
 		require.NoError(t, err)
+
+		changes, err := srv.Database.GetProvisionerJobResourceChangesByJobID(ctx, job.ID)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, "aws_instance.something", changes[0].Address)
+		require.Equal(t, database.ResourceChangeActionCreate, changes[0].Action)
+		require.EqualValues(t, 10, changes[0].DailyCost)
+	})
+}
+
+func TestWorkspaceBuildMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	srv := setup(t, false)
+	registry := prometheus.NewRegistry()
+	srv.Metrics = provisionerdserver.NewMetrics(registry)
+
+	org := dbgen.Organization(t, srv.Database, database.Organization{})
+	user := dbgen.User(t, srv.Database, database.User{})
+	template := dbgen.Template(t, srv.Database, database.Template{OrganizationID: org.ID})
+	workspace := dbgen.Workspace(t, srv.Database, database.Workspace{
+		OrganizationID: org.ID,
+		TemplateID:     template.ID,
+		OwnerID:        user.ID,
+	})
+	acquireBuild := func(transition database.WorkspaceTransition, buildNumber int32) database.ProvisionerJob {
+		build := dbgen.WorkspaceBuild(t, srv.Database, database.WorkspaceBuild{
+			WorkspaceID: workspace.ID,
+			BuildNumber: buildNumber,
+			Transition:  transition,
+			Reason:      database.BuildReasonInitiator,
+		})
+		job := dbgen.ProvisionerJob(t, srv.Database, database.ProvisionerJob{
+			ID:             build.JobID,
+			OrganizationID: org.ID,
+			InitiatorID:    user.ID,
+			Provisioner:    database.ProvisionerTypeEcho,
+			Type:           database.ProvisionerJobTypeWorkspaceBuild,
+			Input: must(json.Marshal(provisionerdserver.WorkspaceProvisionJob{
+				WorkspaceBuildID: build.ID,
+			})),
+		})
+		_, err := srv.Database.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
+			StartedAt: sql.NullTime{Time: database.Now().Add(-time.Minute), Valid: true},
+			WorkerID:  uuid.NullUUID{UUID: srv.ID, Valid: true},
+			Types:     []database.ProvisionerType{database.ProvisionerTypeEcho},
+		})
+		require.NoError(t, err)
+		return job
+	}
+
+	job := acquireBuild(database.WorkspaceTransitionStart, 1)
+	_, err := srv.CompleteJob(ctx, &proto.CompletedJob{
+		JobId: job.ID.String(),
+		Type: &proto.CompletedJob_WorkspaceBuild_{
+			WorkspaceBuild: &proto.CompletedJob_WorkspaceBuild{},
+		},
+	})
+	require.NoError(t, err)
+
+	job = acquireBuild(database.WorkspaceTransitionStop, 2)
+	_, err = srv.FailJob(ctx, &proto.FailedJob{
+		JobId: job.ID.String(),
+		Error: "failed",
+		Type: &proto.FailedJob_WorkspaceBuild_{
+			WorkspaceBuild: &proto.FailedJob_WorkspaceBuild{},
+		},
+	})
+	require.NoError(t, err)
+
+	metrics, err := registry.Gather()
+	require.NoError(t, err)
+	counts := map[string]uint64{}
+	for _, family := range metrics {
+		require.Equal(t, "coderd_workspace_builds_duration_seconds", family.GetName())
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			require.Equal(t, template.Name, labels["template_name"])
+			require.Equal(t, org.Name, labels["organization_name"])
+			counts[labels["transition"]+":"+labels["status"]] += metric.GetHistogram().GetSampleCount()
+			require.GreaterOrEqual(t, metric.GetHistogram().GetSampleSum(), time.Minute.Seconds())
+		}
+	}
+	require.Equal(t, map[string]uint64{
+		"start:" + string(codersdk.ProvisionerJobSucceeded): 1,
+		"stop:" + string(codersdk.ProvisionerJobFailed):     1,
+	}, counts)
+}
+
+func TestHeartbeat(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	t.Run("InvalidJobID", func(t *testing.T) {
+		t.Parallel()
+		srv := setup(t, false)
+		_, err := srv.Heartbeat(ctx, &proto.HeartbeatRequest{
+			JobId: "hello",
+		})
+		require.ErrorContains(t, err, "invalid UUID")
+	})
+	t.Run("Updates", func(t *testing.T) {
+		t.Parallel()
+		srv := setup(t, false)
+		_, err := srv.Database.InsertProvisionerDaemon(ctx, database.InsertProvisionerDaemonParams{
+			ID:        srv.ID,
+			CreatedAt: database.Now(),
+			Name:      "test",
+		})
+		require.NoError(t, err)
+
+		jobID := uuid.New()
+		_, err = srv.Heartbeat(ctx, &proto.HeartbeatRequest{
+			Version: "v1.2.3",
+			JobId:   jobID.String(),
+		})
+		require.NoError(t, err)
+		daemons, err := srv.Database.GetProvisionerDaemons(ctx)
+		require.NoError(t, err)
+		require.Len(t, daemons, 1)
+		require.True(t, daemons[0].LastSeenAt.Valid)
+		require.Equal(t, "v1.2.3", daemons[0].Version)
+		require.Equal(t, uuid.NullUUID{UUID: jobID, Valid: true}, daemons[0].CurrentJobID)
+
+		// An idle daemon clears its current job.
+		_, err = srv.Heartbeat(ctx, &proto.HeartbeatRequest{
+			Version: "v1.2.3",
+		})
+		require.NoError(t, err)
+		daemons, err = srv.Database.GetProvisionerDaemons(ctx)
+		require.NoError(t, err)
+		require.False(t, daemons[0].CurrentJobID.Valid)
 	})
 }
 
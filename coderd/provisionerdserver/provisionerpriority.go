@@ -0,0 +1,22 @@
+package provisionerdserver
+
+import "github.com/coder/coder/coderd/database"
+
+// Priorities of provisioner jobs. Pending jobs with a higher priority are
+// acquired first, so users waiting on their workspace aren't stuck behind
+// template imports.
+const (
+	PriorityTemplateVersionImport int32 = 0
+	PriorityTemplateVersionDryRun int32 = 10
+	PriorityWorkspaceStop         int32 = 20
+	PriorityWorkspaceStart        int32 = 30
+)
+
+// WorkspaceBuildPriority returns the priority of a workspace build job for
+// the given transition.
+func WorkspaceBuildPriority(transition database.WorkspaceTransition) int32 {
+	if transition == database.WorkspaceTransitionStart {
+		return PriorityWorkspaceStart
+	}
+	return PriorityWorkspaceStop
+}
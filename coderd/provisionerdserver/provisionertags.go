@@ -31,3 +31,15 @@ func MutateTags(userID uuid.UUID, tags map[string]string) map[string]string {
 	}
 	return tags
 }
+
+// TagsMatch returns whether a daemon with the given tags can acquire a job
+// with the given tags. Every job tag must be set to the same value on the
+// daemon.
+func TagsMatch(daemonTags, jobTags map[string]string) bool {
+	for key, value := range jobTags {
+		if daemonTags[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package provisionerdserver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/notifications"
+	"github.com/coder/coder/codersdk"
+)
+
+// PromoteTemplateVersionIfReady makes the version of a pending promotion the
+// active version of the template once the dry-run has succeeded and the
+// promotion has enough approvals. Other pending promotions of the template are
+// superseded.
+func PromoteTemplateVersionIfReady(ctx context.Context, logger slog.Logger, db database.Store, ps database.Pubsub, template database.Template, promotion database.TemplateVersionPromotion) (database.TemplateVersionPromotion, error) {
+	if promotion.Status != database.TemplateVersionPromotionStatusPending ||
+		int32(len(promotion.ApproverIDs)) < template.RequiredPromotionApprovals {
+		return promotion, nil
+	}
+	dryRunJob, err := db.GetProvisionerJobByID(ctx, promotion.DryRunJobID)
+	if err != nil {
+		return database.TemplateVersionPromotion{}, xerrors.Errorf("get dry-run job: %w", err)
+	}
+	if !dryRunJob.CompletedAt.Valid || dryRunJob.CanceledAt.Valid || dryRunJob.Error.Valid {
+		return promotion, nil
+	}
+
+	var (
+		promoted  database.TemplateVersionPromotion
+		completed bool
+	)
+	err = db.InTx(func(tx database.Store) error {
+		now := database.Now()
+		// The promotion is only updated while it's pending, so a concurrent
+		// approval or dry-run completion that promoted it first wins.
+		var err error
+		promoted, err = tx.PromoteTemplateVersionPromotion(ctx, database.PromoteTemplateVersionPromotionParams{
+			ID:         promotion.ID,
+			UpdatedAt:  now,
+			PromotedAt: sql.NullTime{Time: now, Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			promoted, err = tx.GetTemplateVersionPromotionByID(ctx, promotion.ID)
+			if err != nil {
+				return xerrors.Errorf("get promotion: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("promote promotion: %w", err)
+		}
+		err = tx.UpdateTemplateActiveVersionByID(ctx, database.UpdateTemplateActiveVersionByIDParams{
+			ID:              template.ID,
+			ActiveVersionID: promotion.TemplateVersionID,
+			UpdatedAt:       now,
+		})
+		if err != nil {
+			return xerrors.Errorf("update active version: %w", err)
+		}
+		err = tx.SupersedeTemplateVersionPromotions(ctx, database.SupersedeTemplateVersionPromotionsParams{
+			UpdatedAt:   now,
+			TemplateID:  template.ID,
+			PromotionID: promotion.ID,
+		})
+		if err != nil {
+			return xerrors.Errorf("supersede promotions: %w", err)
+		}
+		completed = true
+		return nil
+	}, nil)
+	if err != nil {
+		return database.TemplateVersionPromotion{}, err
+	}
+	if !completed {
+		return promoted, nil
+	}
+
+	PublishTemplateVersionPromoted(ctx, logger, ps, template.ID, promotion.TemplateVersionID)
+	return promoted, nil
+}
+
+// PublishTemplateVersionPromoted notifies watchers of the template and
+// notification webhooks that the active version changed.
+func PublishTemplateVersionPromoted(ctx context.Context, logger slog.Logger, ps database.Pubsub, templateID, templateVersionID uuid.UUID) {
+	err := ps.Publish(codersdk.TemplateNotifyChannel(templateID), []byte{})
+	if err != nil {
+		logger.Warn(ctx, "failed to publish template update",
+			slog.F("template_id", templateID), slog.Error(err))
+	}
+	err = notifications.Publish(ps, notifications.Event{
+		Type:              database.NotificationEventTypeTemplateVersionPromoted,
+		TemplateID:        templateID,
+		TemplateVersionID: templateVersionID,
+	})
+	if err != nil {
+		logger.Warn(ctx, "failed to publish template version promoted notification",
+			slog.F("template_id", templateID), slog.Error(err))
+	}
+}
+
+// promoteAfterDryRun completes the pending promotion waiting on the given
+// dry-run job, if there is one. This covers promotions whose approvals
+// arrived before the dry-run finished.
+func (server *Server) promoteAfterDryRun(ctx context.Context, job database.ProvisionerJob) error {
+	var input TemplateVersionDryRunJob
+	err := json.Unmarshal(job.Input, &input)
+	if err != nil {
+		return xerrors.Errorf("unmarshal job input: %w", err)
+	}
+	promotion, err := server.Database.GetPendingTemplateVersionPromotionByTemplateVersionID(ctx, input.TemplateVersionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("get pending promotion: %w", err)
+	}
+	if promotion.DryRunJobID != job.ID {
+		return nil
+	}
+	template, err := server.Database.GetTemplateByID(ctx, promotion.TemplateID)
+	if err != nil {
+		return xerrors.Errorf("get template: %w", err)
+	}
+	_, err = PromoteTemplateVersionIfReady(ctx, server.Logger, server.Database, server.Pubsub, template, promotion)
+	return err
+}
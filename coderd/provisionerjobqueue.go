@@ -0,0 +1,235 @@
+package coderd
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/exp/maps"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/provisionerdserver"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/util/slice"
+	"github.com/coder/coder/codersdk"
+)
+
+// @Summary Get provisioner job queue
+// @ID get-provisioner-job-queue
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Provisioning
+// @Param organization path string true "Organization ID" format(uuid)
+// @Success 200 {array} codersdk.QueuedProvisionerJob
+// @Router /organizations/{organization}/provisionerjobs/queue [get]
+func (api *API) provisionerJobQueue(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx          = r.Context()
+		organization = httpmw.OrganizationParam(r)
+	)
+
+	jobs, err := api.Database.GetPendingProvisionerJobs(ctx)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching pending provisioner jobs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	daemons, err := api.Database.GetProvisionerDaemons(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner daemons.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Daemons aren't scoped to an organization, so positions are computed
+	// across the whole queue before the jobs of other organizations are
+	// left out.
+	queue := make([]codersdk.QueuedProvisionerJob, 0)
+	for i, job := range convertQueuedProvisionerJobs(jobs, daemons, database.Now()) {
+		if jobs[i].OrganizationID != organization.ID {
+			continue
+		}
+		queue = append(queue, job)
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, queue)
+}
+
+// @Summary Bump provisioner job
+// @Description Moves a pending job to the front of the queue.
+// @ID bump-provisioner-job
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Provisioning
+// @Param provisionerjob path string true "Provisioner job ID" format(uuid)
+// @Success 200 {object} codersdk.Response
+// @Router /provisionerjobs/{provisionerjob}/bump [post]
+func (api *API) postBumpProvisionerJob(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx = r.Context()
+		job = httpmw.ProvisionerJobParam(r)
+	)
+
+	if job.StartedAt.Valid || job.CanceledAt.Valid {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Only pending jobs can be bumped.",
+		})
+		return
+	}
+
+	err := api.Database.BumpProvisionerJobByID(ctx, database.BumpProvisionerJobByIDParams{
+		ID:        job.ID,
+		UpdatedAt: database.Now(),
+	})
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error bumping provisioner job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: "Job has been moved to the front of the queue.",
+	})
+}
+
+// @Summary Cancel provisioner job
+// @Description Cancels a job regardless of the workspace or template it
+// @Description belongs to. Pending jobs are never acquired, and running jobs
+// @Description are canceled by their provisioner daemon.
+// @ID cancel-provisioner-job
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Provisioning
+// @Param provisionerjob path string true "Provisioner job ID" format(uuid)
+// @Success 200 {object} codersdk.Response
+// @Router /provisionerjobs/{provisionerjob}/cancel [patch]
+func (api *API) patchCancelProvisionerJob(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx = r.Context()
+		job = httpmw.ProvisionerJobParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceProvisionerDaemon) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if job.CompletedAt.Valid {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Job has already completed!",
+		})
+		return
+	}
+	if job.CanceledAt.Valid {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Job has already been marked as canceled!",
+		})
+		return
+	}
+
+	err := api.Database.UpdateProvisionerJobWithCancelByID(ctx, database.UpdateProvisionerJobWithCancelByIDParams{
+		ID: job.ID,
+		CanceledAt: sql.NullTime{
+			Time:  database.Now(),
+			Valid: true,
+		},
+		CompletedAt: sql.NullTime{
+			Time: database.Now(),
+			// If the job is running, don't mark it completed!
+			Valid: !job.WorkerID.Valid,
+		},
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating provisioner job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if job.Type == database.ProvisionerJobTypeWorkspaceBuild {
+		build, err := api.Database.GetWorkspaceBuildByJobID(ctx, job.ID)
+		if err == nil {
+			api.publishWorkspaceUpdate(ctx, build.WorkspaceID)
+		}
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: "Job has been marked as canceled...",
+	})
+}
+
+// convertQueuedProvisionerJobs converts pending jobs, in the order they will
+// be acquired, into their places in the queue. A job only waits for jobs
+// ahead of it that could be acquired by the same daemons. Jobs that no online
+// daemon can acquire wait for the jobs ahead of them with the same
+// provisioner and tags.
+func convertQueuedProvisionerJobs(jobs []database.ProvisionerJob, daemons []database.ProvisionerDaemon, now time.Time) []codersdk.QueuedProvisionerJob {
+	online := make([]database.ProvisionerDaemon, 0, len(daemons))
+	for _, daemon := range daemons {
+		lastSeen := daemon.CreatedAt
+		if daemon.LastSeenAt.Valid {
+			lastSeen = daemon.LastSeenAt.Time
+		}
+		if now.Sub(lastSeen) > provisionerdserver.DaemonOfflineAfter {
+			continue
+		}
+		online = append(online, daemon)
+	}
+
+	workers := make([][]uuid.UUID, len(jobs))
+	for i, job := range jobs {
+		workers[i] = make([]uuid.UUID, 0)
+		for _, daemon := range online {
+			if slice.Contains(daemon.Provisioners, job.Provisioner) && provisionerdserver.TagsMatch(daemon.Tags, job.Tags) {
+				workers[i] = append(workers[i], daemon.ID)
+			}
+		}
+	}
+
+	queue := make([]codersdk.QueuedProvisionerJob, 0, len(jobs))
+	for i, job := range jobs {
+		position := 1
+		for j := 0; j < i; j++ {
+			if len(workers[i]) == 0 {
+				if jobs[j].Provisioner == job.Provisioner && maps.Equal(jobs[j].Tags, job.Tags) {
+					position++
+				}
+				continue
+			}
+			if slice.Overlap(workers[i], workers[j]) {
+				position++
+			}
+		}
+		queue = append(queue, codersdk.QueuedProvisionerJob{
+			ID:               job.ID,
+			CreatedAt:        job.CreatedAt,
+			Type:             codersdk.ProvisionerJobType(job.Type),
+			Provisioner:      codersdk.ProvisionerType(job.Provisioner),
+			InitiatorID:      job.InitiatorID,
+			Tags:             job.Tags,
+			Priority:         job.Priority,
+			QueuePosition:    position,
+			AvailableWorkers: workers[i],
+		})
+	}
+	return queue
+}
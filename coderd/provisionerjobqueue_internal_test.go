@@ -0,0 +1,69 @@
+package coderd
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+func TestConvertQueuedProvisionerJobs(t *testing.T) {
+	t.Parallel()
+
+	now := database.Now()
+	job := func(tags map[string]string) database.ProvisionerJob {
+		return database.ProvisionerJob{
+			ID:          uuid.New(),
+			Provisioner: database.ProvisionerTypeTerraform,
+			Tags:        tags,
+		}
+	}
+	daemon := func(tags map[string]string, lastSeen time.Time) database.ProvisionerDaemon {
+		return database.ProvisionerDaemon{
+			ID:           uuid.New(),
+			CreatedAt:    lastSeen,
+			Provisioners: []database.ProvisionerType{database.ProvisionerTypeTerraform},
+			Tags:         tags,
+			LastSeenAt:   sql.NullTime{Time: lastSeen, Valid: true},
+		}
+	}
+
+	orgTags := map[string]string{"scope": "organization"}
+	gpuTags := map[string]string{"scope": "organization", "gpu": "true"}
+	armTags := map[string]string{"scope": "organization", "arch": "arm64"}
+
+	orgDaemon := daemon(orgTags, now)
+	gpuDaemon := daemon(gpuTags, now)
+	offlineDaemon := daemon(armTags, now.Add(-time.Hour))
+
+	jobs := []database.ProvisionerJob{
+		job(gpuTags),
+		job(orgTags),
+		job(armTags),
+		job(orgTags),
+		job(armTags),
+	}
+	queue := convertQueuedProvisionerJobs(jobs, []database.ProvisionerDaemon{orgDaemon, gpuDaemon, offlineDaemon}, now)
+	require.Len(t, queue, len(jobs))
+
+	// Only the GPU daemon can run the first job.
+	require.Equal(t, jobs[0].ID, queue[0].ID)
+	require.Equal(t, 1, queue[0].QueuePosition)
+	require.ElementsMatch(t, []uuid.UUID{gpuDaemon.ID}, queue[0].AvailableWorkers)
+
+	// Both daemons can run jobs without extra tags, so they wait for the GPU
+	// job too.
+	require.Equal(t, 2, queue[1].QueuePosition)
+	require.ElementsMatch(t, []uuid.UUID{orgDaemon.ID, gpuDaemon.ID}, queue[1].AvailableWorkers)
+	require.Equal(t, 3, queue[3].QueuePosition)
+
+	// The only daemon that can run ARM jobs is offline, so they wait behind
+	// each other.
+	require.Empty(t, queue[2].AvailableWorkers)
+	require.Equal(t, 1, queue[2].QueuePosition)
+	require.Equal(t, 2, queue[4].QueuePosition)
+}
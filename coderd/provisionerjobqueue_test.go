@@ -0,0 +1,106 @@
+package coderd_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestProvisionerJobQueue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Pending", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		first := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		second := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		queue, err := client.ProvisionerJobQueue(ctx, user.OrganizationID)
+		require.NoError(t, err)
+		require.Len(t, queue, 2)
+		require.Equal(t, first.Job.ID, queue[0].ID)
+		require.Equal(t, 1, queue[0].QueuePosition)
+		require.Equal(t, second.Job.ID, queue[1].ID)
+		require.Equal(t, 2, queue[1].QueuePosition)
+		require.Equal(t, codersdk.ProvisionerJobTypeTemplateVersionImport, queue[1].Type)
+		// No provisioner daemons are running.
+		require.Empty(t, queue[1].AvailableWorkers)
+	})
+
+	t.Run("Bump", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		first := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		second := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		err := client.BumpProvisionerJob(ctx, second.Job.ID)
+		require.NoError(t, err)
+
+		queue, err := client.ProvisionerJobQueue(ctx, user.OrganizationID)
+		require.NoError(t, err)
+		require.Len(t, queue, 2)
+		require.Equal(t, second.Job.ID, queue[0].ID)
+		require.Equal(t, 1, queue[0].QueuePosition)
+		require.Greater(t, queue[0].Priority, queue[1].Priority)
+		require.Equal(t, first.Job.ID, queue[1].ID)
+		require.Equal(t, 2, queue[1].QueuePosition)
+	})
+
+	t.Run("BumpForbidden", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		templateAdmin, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleTemplateAdmin())
+		version := coderdtest.CreateTemplateVersion(t, templateAdmin, user.OrganizationID, nil)
+		member, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		// Members can see the queue, but can't change it.
+		queue, err := member.ProvisionerJobQueue(ctx, user.OrganizationID)
+		require.NoError(t, err)
+		require.Len(t, queue, 1)
+
+		err = member.BumpProvisionerJob(ctx, version.Job.ID)
+		require.Error(t, err)
+
+		err = templateAdmin.BumpProvisionerJob(ctx, version.Job.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		first := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		second := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		err := client.CancelProvisionerJob(ctx, first.Job.ID)
+		require.NoError(t, err)
+
+		queue, err := client.ProvisionerJobQueue(ctx, user.OrganizationID)
+		require.NoError(t, err)
+		require.Len(t, queue, 1)
+		require.Equal(t, second.Job.ID, queue[0].ID)
+		require.Equal(t, 1, queue[0].QueuePosition)
+
+		version, err := client.TemplateVersion(ctx, first.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.ProvisionerJobCanceled, version.Job.Status)
+
+		err = client.CancelProvisionerJob(ctx, first.Job.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
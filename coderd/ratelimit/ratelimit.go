@@ -0,0 +1,193 @@
+// Package ratelimit implements the rate limit policies applied to groups of
+// API routes.
+//
+// Requests are counted in fixed windows. The counters are kept in a Store,
+// which is either local to a replica or shared between all replicas through
+// the database.
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+)
+
+// Names of the policies applied by coderd.
+const (
+	PolicyAPI             = "api"
+	PolicyLogin           = "login"
+	PolicyFiles           = "files"
+	PolicyWorkspaceBuilds = "workspace_builds"
+	PolicyAgents          = "agents"
+)
+
+// PolicyNames lists the policies applied by coderd.
+var PolicyNames = []string{
+	PolicyAPI,
+	PolicyLogin,
+	PolicyFiles,
+	PolicyWorkspaceBuilds,
+	PolicyAgents,
+}
+
+// Policy is the rate limit of a group of routes.
+type Policy struct {
+	// Name identifies the policy in counter keys and metrics.
+	Name string
+	// Limit is the number of requests allowed per Window. Zero or negative
+	// values disable the limit.
+	Limit int
+	// Window defaults to a minute.
+	Window time.Duration
+	// ByEndpoint counts requests to each endpoint separately.
+	ByEndpoint bool
+	// RoleLimits overrides Limit for actors with the given site roles. If an
+	// actor has multiple roles, the highest limit applies. A zero or negative
+	// limit disables rate limiting for the role.
+	RoleLimits map[string]int
+}
+
+// LimitFor returns the limit of an actor with the given roles. Zero means
+// the actor isn't rate limited.
+func (p Policy) LimitFor(roles []string) int {
+	if p.Limit <= 0 {
+		return 0
+	}
+	limit := p.Limit
+	for _, role := range roles {
+		roleLimit, ok := p.RoleLimits[role]
+		if !ok {
+			continue
+		}
+		if roleLimit <= 0 {
+			return 0
+		}
+		if roleLimit > limit {
+			limit = roleLimit
+		}
+	}
+	return limit
+}
+
+// Override returns the policy with the limit, window and role limits of
+// override applied. A zero limit or window keeps the value of p.
+func (p Policy) Override(override Policy) Policy {
+	if override.Limit != 0 {
+		p.Limit = override.Limit
+	}
+	if override.Window != 0 {
+		p.Window = override.Window
+	}
+	if len(override.RoleLimits) > 0 {
+		roleLimits := make(map[string]int, len(p.RoleLimits)+len(override.RoleLimits))
+		for role, limit := range p.RoleLimits {
+			roleLimits[role] = limit
+		}
+		for role, limit := range override.RoleLimits {
+			roleLimits[role] = limit
+		}
+		p.RoleLimits = roleLimits
+	}
+	return p
+}
+
+func (p Policy) window() time.Duration {
+	if p.Window <= 0 {
+		return time.Minute
+	}
+	return p.Window
+}
+
+// Store keeps the request counters of the current windows.
+type Store interface {
+	// Increment increments the counter of key for the window starting at
+	// windowStart and returns the new count. The counter may be discarded
+	// after expiresAt.
+	Increment(ctx context.Context, key string, windowStart, expiresAt time.Time) (int64, error)
+}
+
+// Result is the outcome of a rate limit check.
+type Result struct {
+	// Allowed is false if the request exceeds the limit.
+	Allowed bool
+	// Limit is zero if the request isn't rate limited.
+	Limit     int
+	Remaining int
+	Window    time.Duration
+	// Reset is when the current window ends.
+	Reset time.Time
+}
+
+// Limiter checks requests against policies.
+type Limiter struct {
+	logger   slog.Logger
+	store    Store
+	now      func() time.Time
+	rejected *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+}
+
+// New returns a limiter that keeps its counters in store. Metrics are not
+// registered if registerer is nil.
+func New(logger slog.Logger, store Store, registerer prometheus.Registerer) *Limiter {
+	factory := promauto.With(registerer)
+	return &Limiter{
+		logger: logger,
+		store:  store,
+		now:    database.Now,
+		rejected: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "api",
+			Name:      "rate_limited_requests_total",
+			Help:      "The total number of API requests rejected by a rate limit policy.",
+		}, []string{"policy"}),
+		errors: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "api",
+			Name:      "rate_limit_errors_total",
+			Help:      "The total number of API requests allowed because their rate limit could not be checked.",
+		}, []string{"policy"}),
+	}
+}
+
+// Allow counts a request of the actor identified by key against the policy.
+// Requests are allowed when the counter can't be incremented, so an
+// unavailable store doesn't take the API down with it.
+func (l *Limiter) Allow(ctx context.Context, policy Policy, key string, roles []string) Result {
+	limit := policy.LimitFor(roles)
+	if limit == 0 {
+		return Result{Allowed: true}
+	}
+
+	window := policy.window()
+	now := l.now()
+	windowStart := now.Truncate(window)
+	reset := windowStart.Add(window)
+	count, err := l.store.Increment(ctx, policy.Name+":"+key, windowStart, reset)
+	if err != nil {
+		l.errors.WithLabelValues(policy.Name).Inc()
+		l.logger.Warn(ctx, "increment rate limit counter",
+			slog.F("policy", policy.Name),
+			slog.Error(err),
+		)
+		return Result{Allowed: true}
+	}
+
+	result := Result{
+		Allowed: count <= int64(limit),
+		Limit:   limit,
+		Window:  window,
+		Reset:   reset,
+	}
+	if result.Allowed {
+		result.Remaining = limit - int(count)
+	} else {
+		l.rejected.WithLabelValues(policy.Name).Inc()
+	}
+	return result
+}
@@ -0,0 +1,214 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/database/dbfake"
+	"github.com/coder/coder/coderd/ratelimit"
+	"github.com/coder/coder/coderd/rbac"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("LimitFor", func(t *testing.T) {
+		t.Parallel()
+
+		policy := ratelimit.Policy{
+			Limit: 10,
+			RoleLimits: map[string]int{
+				rbac.RoleTemplateAdmin(): 100,
+				rbac.RoleUserAdmin():     50,
+				rbac.RoleOwner():         -1,
+			},
+		}
+		require.Equal(t, 10, policy.LimitFor(nil))
+		require.Equal(t, 10, policy.LimitFor([]string{rbac.RoleMember()}))
+		require.Equal(t, 100, policy.LimitFor([]string{rbac.RoleUserAdmin(), rbac.RoleTemplateAdmin()}))
+		require.Equal(t, 0, policy.LimitFor([]string{rbac.RoleTemplateAdmin(), rbac.RoleOwner()}))
+
+		// Role limits don't enable a disabled policy.
+		policy.Limit = -1
+		require.Equal(t, 0, policy.LimitFor([]string{rbac.RoleTemplateAdmin()}))
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		t.Parallel()
+
+		policy := ratelimit.Policy{
+			Name:       ratelimit.PolicyAPI,
+			Limit:      512,
+			Window:     time.Minute,
+			ByEndpoint: true,
+			RoleLimits: map[string]int{rbac.RoleOwner(): -1},
+		}
+		overridden := policy.Override(ratelimit.Policy{
+			Name:       ratelimit.PolicyAPI,
+			Window:     time.Hour,
+			RoleLimits: map[string]int{rbac.RoleTemplateAdmin(): 1024},
+		})
+		require.Equal(t, ratelimit.Policy{
+			Name:       ratelimit.PolicyAPI,
+			Limit:      512,
+			Window:     time.Hour,
+			ByEndpoint: true,
+			RoleLimits: map[string]int{
+				rbac.RoleOwner():         -1,
+				rbac.RoleTemplateAdmin(): 1024,
+			},
+		}, overridden)
+		// The original policy is not modified.
+		require.Len(t, policy.RoleLimits, 1)
+	})
+}
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Limit", func(t *testing.T) {
+		t.Parallel()
+
+		registry := prometheus.NewRegistry()
+		limiter := ratelimit.New(slogtest.Make(t, nil), ratelimit.NewMemoryStore(), registry)
+		policy := ratelimit.Policy{
+			Name:   ratelimit.PolicyLogin,
+			Limit:  2,
+			Window: time.Hour,
+		}
+
+		ctx := context.Background()
+		result := limiter.Allow(ctx, policy, "alice", nil)
+		require.True(t, result.Allowed)
+		require.Equal(t, 2, result.Limit)
+		require.Equal(t, 1, result.Remaining)
+		require.Equal(t, time.Hour, result.Window)
+		require.True(t, result.Reset.After(time.Now()))
+
+		result = limiter.Allow(ctx, policy, "alice", nil)
+		require.True(t, result.Allowed)
+		require.Equal(t, 0, result.Remaining)
+
+		result = limiter.Allow(ctx, policy, "alice", nil)
+		require.False(t, result.Allowed)
+		require.Equal(t, 0, result.Remaining)
+
+		// Other actors have their own counter.
+		result = limiter.Allow(ctx, policy, "bob", nil)
+		require.True(t, result.Allowed)
+
+		// So do other policies.
+		policy.Name = ratelimit.PolicyFiles
+		result = limiter.Allow(ctx, policy, "alice", nil)
+		require.True(t, result.Allowed)
+
+		require.Equal(t, 1, rejectedRequests(t, registry, ratelimit.PolicyLogin))
+	})
+
+	t.Run("RoleLimit", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := ratelimit.New(slogtest.Make(t, nil), ratelimit.NewMemoryStore(), nil)
+		policy := ratelimit.Policy{
+			Name:       ratelimit.PolicyWorkspaceBuilds,
+			Limit:      1,
+			Window:     time.Hour,
+			RoleLimits: map[string]int{rbac.RoleOwner(): -1},
+		}
+
+		ctx := context.Background()
+		for i := 0; i < 5; i++ {
+			result := limiter.Allow(ctx, policy, "owner", []string{rbac.RoleOwner()})
+			require.True(t, result.Allowed)
+			require.Zero(t, result.Limit)
+		}
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := ratelimit.New(slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}), errorStore{}, nil)
+		result := limiter.Allow(context.Background(), ratelimit.Policy{
+			Name:  ratelimit.PolicyAPI,
+			Limit: 1,
+		}, "alice", nil)
+		require.True(t, result.Allowed)
+	})
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	stores := map[string]func() ratelimit.Store{
+		"Memory": func() ratelimit.Store {
+			return ratelimit.NewMemoryStore()
+		},
+		"Database": func() ratelimit.Store {
+			return ratelimit.NewDatabaseStore(dbfake.New())
+		},
+	}
+	for name, newStore := range stores {
+		newStore := newStore
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newStore()
+			ctx := context.Background()
+			start := time.Now().Truncate(time.Minute)
+			end := start.Add(time.Minute)
+
+			for i := int64(1); i <= 3; i++ {
+				count, err := store.Increment(ctx, "api:alice", start, end)
+				require.NoError(t, err)
+				require.Equal(t, i, count)
+			}
+
+			// A new window resets the counter.
+			count, err := store.Increment(ctx, "api:alice", end, end.Add(time.Minute))
+			require.NoError(t, err)
+			require.EqualValues(t, 1, count)
+
+			// Late requests for the previous window count against the
+			// current one.
+			count, err = store.Increment(ctx, "api:alice", start, end)
+			require.NoError(t, err)
+			require.EqualValues(t, 2, count)
+
+			count, err = store.Increment(ctx, "api:bob", start, end)
+			require.NoError(t, err)
+			require.EqualValues(t, 1, count)
+		})
+	}
+}
+
+type errorStore struct{}
+
+func (errorStore) Increment(context.Context, string, time.Time, time.Time) (int64, error) {
+	return 0, xerrors.New("database unavailable")
+}
+
+func rejectedRequests(t *testing.T, registry *prometheus.Registry, policy string) int {
+	t.Helper()
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "coderd_api_rate_limited_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "policy" && label.GetValue() == policy {
+					return int(metric.GetCounter().GetValue())
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+)
+
+// sweepInterval is how often expired counters are removed from a
+// MemoryStore.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps counters in memory. Counters are not shared between
+// replicas, so each replica enforces the limits separately.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+}
+
+type memoryCounter struct {
+	windowStart time.Time
+	expiresAt   time.Time
+	count       int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*memoryCounter),
+	}
+}
+
+func (s *MemoryStore) Increment(_ context.Context, key string, windowStart, expiresAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if windowStart.Sub(s.lastSweep) >= sweepInterval {
+		for k, counter := range s.counters {
+			if counter.expiresAt.Before(windowStart) {
+				delete(s.counters, k)
+			}
+		}
+		s.lastSweep = windowStart
+	}
+
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &memoryCounter{}
+		s.counters[key] = counter
+	}
+	if counter.windowStart.Before(windowStart) {
+		counter.windowStart = windowStart
+		counter.count = 0
+	}
+	if counter.expiresAt.Before(expiresAt) {
+		counter.expiresAt = expiresAt
+	}
+	counter.count++
+	return counter.count, nil
+}
+
+// DatabaseStore keeps counters in the database, so limits are enforced
+// across all replicas. Every checked request costs a database round trip.
+type DatabaseStore struct {
+	db database.Store
+}
+
+func NewDatabaseStore(db database.Store) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+func (s *DatabaseStore) Increment(ctx context.Context, key string, windowStart, expiresAt time.Time) (int64, error) {
+	//nolint:gocritic // Rate limits are checked before the actor is authorized.
+	count, err := s.db.IncrementRateLimitCounter(dbauthz.AsSystemRestricted(ctx), database.IncrementRateLimitCounterParams{
+		Key:         key,
+		WindowStart: windowStart,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return 0, xerrors.Errorf("increment rate limit counter: %w", err)
+	}
+	return count, nil
+}
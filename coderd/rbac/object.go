@@ -155,6 +155,15 @@ var (
 		Type: "replicas",
 	}
 
+	// ResourceNotificationWebhook is a deployment-wide webhook that receives
+	// workspace and template events.
+	// 	create/delete = add or remove a webhook
+	// 	read = view webhooks and their delivery history
+	// 	update = edit a webhook
+	ResourceNotificationWebhook = Object{
+		Type: "notification_webhook",
+	}
+
 	// ResourceDebugInfo controls access to the debug routes `/api/v2/debug/*`.
 	ResourceDebugInfo = Object{
 		Type: "debug_info",
@@ -0,0 +1,41 @@
+package schedule
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// autostopRequirementMinRuntime is the minimum time a workspace may run
+// before the autostop requirement applies. Without it, a workspace started
+// shortly before the required stop window would be stopped right away.
+const autostopRequirementMinRuntime = time.Hour
+
+// UserLocation returns the timezone of a workspace owner, which is taken from
+// the workspace's autostart schedule since the dashboard sets it to the
+// timezone of the user's browser. If the workspace does not have an autostart
+// schedule, UTC is used.
+func UserLocation(autostartSchedule string) *time.Location {
+	if autostartSchedule == "" {
+		return time.UTC
+	}
+	sched, err := Weekly(autostartSchedule)
+	if err != nil {
+		return time.UTC
+	}
+	return sched.Location()
+}
+
+// RequiredStopAt returns the time at which a workspace started at startedAt
+// must be stopped to satisfy the template's autostop requirement, or the zero
+// time if the template does not have one. The requirement is evaluated in loc.
+func (o TemplateScheduleOptions) RequiredStopAt(startedAt time.Time, loc *time.Location) (time.Time, error) {
+	if o.AutostopRequirement == "" {
+		return time.Time{}, nil
+	}
+	sched, err := WeeklyWithoutLocation(o.AutostopRequirement)
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("parse autostop requirement: %w", err)
+	}
+	return sched.In(loc).Next(startedAt.Add(autostopRequirementMinRuntime)), nil
+}
@@ -0,0 +1,83 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/schedule"
+)
+
+func TestTemplateScheduleOptions_RequiredStopAt(t *testing.T) {
+	t.Parallel()
+
+	// 2023-04-03 is a Monday.
+	monday := time.Date(2023, 4, 3, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		requirement   string
+		startedAt     time.Time
+		location      *time.Location
+		expected      time.Time
+		expectedError string
+	}{
+		{
+			name:      "Disabled",
+			startedAt: monday,
+			location:  time.UTC,
+		},
+		{
+			name:        "UTC",
+			requirement: "0 2 * * 0",
+			startedAt:   monday,
+			location:    time.UTC,
+			expected:    time.Date(2023, 4, 9, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "UserLocation",
+			requirement: "0 2 * * 0",
+			startedAt:   monday,
+			location:    mustLocation(t, "US/Central"),
+			expected:    time.Date(2023, 4, 9, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "StartedJustBefore",
+			requirement: "0 2 * * 0",
+			startedAt:   time.Date(2023, 4, 9, 1, 30, 0, 0, time.UTC),
+			location:    time.UTC,
+			expected:    time.Date(2023, 4, 16, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "WithTimezone",
+			requirement:   "CRON_TZ=UTC 0 2 * * 0",
+			startedAt:     monday,
+			location:      time.UTC,
+			expectedError: "must not specify a timezone",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			opts := schedule.TemplateScheduleOptions{AutostopRequirement: tc.requirement}
+			requiredStopAt, err := opts.RequiredStopAt(tc.startedAt, tc.location)
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tc.expected.Equal(requiredStopAt), "expected %s, got %s", tc.expected, requiredStopAt)
+		})
+	}
+}
+
+func TestUserLocation(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, time.UTC, schedule.UserLocation(""))
+	require.Equal(t, time.UTC, schedule.UserLocation("not a schedule"))
+	require.Equal(t, mustLocation(t, "US/Central"), schedule.UserLocation("CRON_TZ=US/Central 30 9 * * 1-5"))
+}
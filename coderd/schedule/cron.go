@@ -76,6 +76,17 @@ func Weekly(raw string) (*Schedule, error) {
 	return cronSched, nil
 }
 
+// WeeklyWithoutLocation parses a Schedule from a weekly spec that must not
+// specify a timezone. It is used for schedules that are defined once but
+// evaluated in the timezone of each user, such as a template's autostop
+// requirement. The returned schedule is in UTC until moved with In.
+func WeeklyWithoutLocation(raw string) (*Schedule, error) {
+	if strings.HasPrefix(raw, "CRON_TZ=") {
+		return nil, xerrors.Errorf("schedule must not specify a timezone")
+	}
+	return Weekly(raw)
+}
+
 // Schedule represents a cron schedule.
 // It's essentially a wrapper for robfig/cron/v3 that has additional
 // convenience methods.
@@ -101,6 +112,16 @@ func (s Schedule) Location() *time.Location {
 	return s.sched.Location
 }
 
+// In returns a copy of the schedule that is evaluated in loc.
+func (s Schedule) In(loc *time.Location) *Schedule {
+	sched := *s.sched
+	sched.Location = loc
+	return &Schedule{
+		sched:   &sched,
+		cronStr: s.cronStr,
+	}
+}
+
 // Cron returns the cron spec for the schedule with the leading CRON_TZ
 // stripped, if present.
 func (s Schedule) Cron() string {
@@ -0,0 +1,37 @@
+package schedule
+
+import (
+	"time"
+)
+
+// DormantAt returns the time at which a running workspace will be stopped for
+// inactivity, or the zero time if the template does not have an inactivity
+// TTL.
+//
+// The completion of the latest build counts as activity, so a workspace that
+// has not been used in a long time is not stopped right after it is started.
+func (o TemplateScheduleOptions) DormantAt(lastUsedAt, buildCompletedAt time.Time) time.Time {
+	if o.InactivityTTL <= 0 {
+		return time.Time{}
+	}
+	return lastActivity(lastUsedAt, buildCompletedAt).Add(o.InactivityTTL)
+}
+
+// DeletingAt returns the time at which a stopped workspace will be deleted,
+// or the zero time if the template does not have a delete TTL.
+//
+// The completion of the latest build counts as activity, so the workspace is
+// kept for at least the delete TTL after it was stopped.
+func (o TemplateScheduleOptions) DeletingAt(lastUsedAt, buildCompletedAt time.Time) time.Time {
+	if o.DeleteTTL <= 0 {
+		return time.Time{}
+	}
+	return lastActivity(lastUsedAt, buildCompletedAt).Add(o.DeleteTTL)
+}
+
+func lastActivity(lastUsedAt, buildCompletedAt time.Time) time.Time {
+	if buildCompletedAt.After(lastUsedAt) {
+		return buildCompletedAt
+	}
+	return lastUsedAt
+}
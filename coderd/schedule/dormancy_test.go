@@ -0,0 +1,74 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/schedule"
+)
+
+func TestTemplateScheduleOptions_Dormancy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		lastUsedAt  = time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+		completedAt = lastUsedAt.Add(time.Hour)
+	)
+
+	testCases := []struct {
+		name               string
+		opts               schedule.TemplateScheduleOptions
+		lastUsedAt         time.Time
+		buildCompletedAt   time.Time
+		expectedDormantAt  time.Time
+		expectedDeletingAt time.Time
+	}{
+		{
+			name:             "Disabled",
+			opts:             schedule.TemplateScheduleOptions{},
+			lastUsedAt:       lastUsedAt,
+			buildCompletedAt: completedAt,
+		},
+		{
+			name: "LastUsed",
+			opts: schedule.TemplateScheduleOptions{
+				InactivityTTL: 24 * time.Hour,
+				DeleteTTL:     7 * 24 * time.Hour,
+			},
+			lastUsedAt:         completedAt.Add(time.Hour),
+			buildCompletedAt:   completedAt,
+			expectedDormantAt:  completedAt.Add(25 * time.Hour),
+			expectedDeletingAt: completedAt.Add(7*24*time.Hour + time.Hour),
+		},
+		{
+			name: "BuildCompleted",
+			opts: schedule.TemplateScheduleOptions{
+				InactivityTTL: 24 * time.Hour,
+				DeleteTTL:     7 * 24 * time.Hour,
+			},
+			lastUsedAt:         lastUsedAt,
+			buildCompletedAt:   completedAt,
+			expectedDormantAt:  completedAt.Add(24 * time.Hour),
+			expectedDeletingAt: completedAt.Add(7 * 24 * time.Hour),
+		},
+		{
+			name: "NeverUsed",
+			opts: schedule.TemplateScheduleOptions{
+				InactivityTTL: time.Hour,
+			},
+			buildCompletedAt:  completedAt,
+			expectedDormantAt: completedAt.Add(time.Hour),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expectedDormantAt, tc.opts.DormantAt(tc.lastUsedAt, tc.buildCompletedAt))
+			require.Equal(t, tc.expectedDeletingAt, tc.opts.DeletingAt(tc.lastUsedAt, tc.buildCompletedAt))
+		})
+	}
+}
@@ -17,6 +17,17 @@ type TemplateScheduleOptions struct {
 	//
 	// If set, users cannot disable automatic workspace shutdown.
 	MaxTTL time.Duration `json:"max_ttl"`
+	// If InactivityTTL is set, running workspaces that have not been used for
+	// this long will be stopped automatically.
+	InactivityTTL time.Duration `json:"inactivity_ttl"`
+	// If DeleteTTL is set, stopped workspaces that have not been used for this
+	// long since they were stopped will be deleted automatically.
+	DeleteTTL time.Duration `json:"delete_ttl"`
+	// If AutostopRequirement is set, running workspaces must be stopped at the
+	// next occurrence of this weekly schedule after they are started. The
+	// schedule does not include a timezone and is evaluated in the timezone of
+	// the workspace owner.
+	AutostopRequirement string `json:"autostop_requirement"`
 }
 
 // TemplateScheduleStore provides an interface for retrieving template
@@ -43,9 +54,12 @@ func (*agplTemplateScheduleStore) GetTemplateScheduleOptions(ctx context.Context
 	return TemplateScheduleOptions{
 		UserSchedulingEnabled: true,
 		DefaultTTL:            time.Duration(tpl.DefaultTTL),
-		// Disregard the value in the database, since MaxTTL is an enterprise
-		// feature.
-		MaxTTL: 0,
+		// Disregard the values in the database, since MaxTTL, InactivityTTL,
+		// DeleteTTL and AutostopRequirement are enterprise features.
+		MaxTTL:              0,
+		InactivityTTL:       0,
+		DeleteTTL:           0,
+		AutostopRequirement: "",
 	}, nil
 }
 
@@ -54,8 +68,11 @@ func (*agplTemplateScheduleStore) SetTemplateScheduleOptions(ctx context.Context
 		ID:         tpl.ID,
 		UpdatedAt:  database.Now(),
 		DefaultTTL: int64(opts.DefaultTTL),
-		// Don't allow changing it, but keep the value in the DB (to avoid
-		// clearing settings if the license has an issue).
-		MaxTTL: tpl.MaxTTL,
+		// Don't allow changing these, but keep the values in the DB (to
+		// avoid clearing settings if the license has an issue).
+		MaxTTL:              tpl.MaxTTL,
+		InactivityTTL:       tpl.InactivityTTL,
+		DeleteTTL:           tpl.DeleteTTL,
+		AutostopRequirement: tpl.AutostopRequirement,
 	})
 }
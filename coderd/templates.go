@@ -199,8 +199,10 @@ func (api *API) postTemplateByOrganization(rw http.ResponseWriter, r *http.Reque
 	}
 
 	var (
-		defaultTTL time.Duration
-		maxTTL     time.Duration
+		defaultTTL    time.Duration
+		maxTTL        time.Duration
+		inactivityTTL time.Duration
+		deleteTTL     time.Duration
 	)
 	if createTemplate.DefaultTTLMillis != nil {
 		defaultTTL = time.Duration(*createTemplate.DefaultTTLMillis) * time.Millisecond
@@ -208,6 +210,12 @@ func (api *API) postTemplateByOrganization(rw http.ResponseWriter, r *http.Reque
 	if createTemplate.MaxTTLMillis != nil {
 		maxTTL = time.Duration(*createTemplate.MaxTTLMillis) * time.Millisecond
 	}
+	if createTemplate.InactivityTTLMillis != nil {
+		inactivityTTL = time.Duration(*createTemplate.InactivityTTLMillis) * time.Millisecond
+	}
+	if createTemplate.DeleteTTLMillis != nil {
+		deleteTTL = time.Duration(*createTemplate.DeleteTTLMillis) * time.Millisecond
+	}
 
 	var validErrs []codersdk.ValidationError
 	if defaultTTL < 0 {
@@ -219,6 +227,12 @@ func (api *API) postTemplateByOrganization(rw http.ResponseWriter, r *http.Reque
 	if maxTTL != 0 && defaultTTL > maxTTL {
 		validErrs = append(validErrs, codersdk.ValidationError{Field: "default_ttl_ms", Detail: "Must be less than or equal to max_ttl_ms if max_ttl_ms is set."})
 	}
+	if inactivityTTL < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "inactivity_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	if deleteTTL < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "delete_ttl_ms", Detail: "Must be a positive integer."})
+	}
 	if len(validErrs) > 0 {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 			Message:     "Invalid create template request.",
@@ -262,6 +276,8 @@ func (api *API) postTemplateByOrganization(rw http.ResponseWriter, r *http.Reque
 			UserSchedulingEnabled: true,
 			DefaultTTL:            defaultTTL,
 			MaxTTL:                maxTTL,
+			InactivityTTL:         inactivityTTL,
+			DeleteTTL:             deleteTTL,
 		})
 		if err != nil {
 			return xerrors.Errorf("set template schedule options: %s", err)
@@ -463,6 +479,21 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 	if req.MaxTTLMillis != 0 && req.DefaultTTLMillis > req.MaxTTLMillis {
 		validErrs = append(validErrs, codersdk.ValidationError{Field: "default_ttl_ms", Detail: "Must be less than or equal to max_ttl_ms if max_ttl_ms is set."})
 	}
+	if req.InactivityTTLMillis < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "inactivity_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	if req.DeleteTTLMillis < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "delete_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	if req.AutostopRequirement != "" {
+		_, err := schedule.WeeklyWithoutLocation(req.AutostopRequirement)
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "autostop_requirement", Detail: err.Error()})
+		}
+	}
+	if req.RequiredPromotionApprovals != nil && *req.RequiredPromotionApprovals < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "required_promotion_approvals", Detail: "Must be a positive integer."})
+	}
 
 	if len(validErrs) > 0 {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
@@ -472,6 +503,18 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Template admins could otherwise skip approvals by lowering the
+	// requirement and updating the active version directly, so lowering
+	// it requires a deployment-level permission they don't have.
+	if req.RequiredPromotionApprovals != nil &&
+		*req.RequiredPromotionApprovals < template.RequiredPromotionApprovals &&
+		!api.Authorize(r, rbac.ActionUpdate, rbac.ResourceDeploymentValues) {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "You don't have permission to lower the required promotion approvals.",
+		})
+		return
+	}
+
 	var updated database.Template
 	err := api.Database.InTx(func(tx database.Store) error {
 		if req.Name == template.Name &&
@@ -480,7 +523,11 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			req.Icon == template.Icon &&
 			req.AllowUserCancelWorkspaceJobs == template.AllowUserCancelWorkspaceJobs &&
 			req.DefaultTTLMillis == time.Duration(template.DefaultTTL).Milliseconds() &&
-			req.MaxTTLMillis == time.Duration(template.MaxTTL).Milliseconds() {
+			req.MaxTTLMillis == time.Duration(template.MaxTTL).Milliseconds() &&
+			req.InactivityTTLMillis == time.Duration(template.InactivityTTL).Milliseconds() &&
+			req.DeleteTTLMillis == time.Duration(template.DeleteTTL).Milliseconds() &&
+			req.AutostopRequirement == template.AutostopRequirement &&
+			(req.RequiredPromotionApprovals == nil || *req.RequiredPromotionApprovals == template.RequiredPromotionApprovals) {
 			return nil
 		}
 
@@ -492,6 +539,7 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 		desc := req.Description
 		icon := req.Icon
 		allowUserCancelWorkspaceJobs := req.AllowUserCancelWorkspaceJobs
+		requiredPromotionApprovals := template.RequiredPromotionApprovals
 
 		if name == "" {
 			name = template.Name
@@ -499,6 +547,9 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 		if desc == "" {
 			desc = template.Description
 		}
+		if req.RequiredPromotionApprovals != nil {
+			requiredPromotionApprovals = *req.RequiredPromotionApprovals
+		}
 
 		var err error
 		updated, err = tx.UpdateTemplateMetaByID(ctx, database.UpdateTemplateMetaByIDParams{
@@ -509,6 +560,7 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			Description:                  desc,
 			Icon:                         icon,
 			AllowUserCancelWorkspaceJobs: allowUserCancelWorkspaceJobs,
+			RequiredPromotionApprovals:   requiredPromotionApprovals,
 		})
 		if err != nil {
 			return xerrors.Errorf("update template metadata: %w", err)
@@ -516,11 +568,20 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 
 		defaultTTL := time.Duration(req.DefaultTTLMillis) * time.Millisecond
 		maxTTL := time.Duration(req.MaxTTLMillis) * time.Millisecond
-		if defaultTTL != time.Duration(template.DefaultTTL) || maxTTL != time.Duration(template.MaxTTL) {
+		inactivityTTL := time.Duration(req.InactivityTTLMillis) * time.Millisecond
+		deleteTTL := time.Duration(req.DeleteTTLMillis) * time.Millisecond
+		if defaultTTL != time.Duration(template.DefaultTTL) ||
+			maxTTL != time.Duration(template.MaxTTL) ||
+			inactivityTTL != time.Duration(template.InactivityTTL) ||
+			deleteTTL != time.Duration(template.DeleteTTL) ||
+			req.AutostopRequirement != template.AutostopRequirement {
 			updated, err = (*api.TemplateScheduleStore.Load()).SetTemplateScheduleOptions(ctx, tx, updated, schedule.TemplateScheduleOptions{
 				UserSchedulingEnabled: true,
 				DefaultTTL:            defaultTTL,
 				MaxTTL:                maxTTL,
+				InactivityTTL:         inactivityTTL,
+				DeleteTTL:             deleteTTL,
+				AutostopRequirement:   req.AutostopRequirement,
 			})
 			if err != nil {
 				return xerrors.Errorf("set template schedule options: %w", err)
@@ -659,8 +720,12 @@ func (api *API) convertTemplate(
 		Icon:                         template.Icon,
 		DefaultTTLMillis:             time.Duration(template.DefaultTTL).Milliseconds(),
 		MaxTTLMillis:                 time.Duration(template.MaxTTL).Milliseconds(),
+		InactivityTTLMillis:          time.Duration(template.InactivityTTL).Milliseconds(),
+		DeleteTTLMillis:              time.Duration(template.DeleteTTL).Milliseconds(),
+		AutostopRequirement:          template.AutostopRequirement,
 		CreatedByID:                  template.CreatedBy,
 		CreatedByName:                createdByName,
 		AllowUserCancelWorkspaceJobs: template.AllowUserCancelWorkspaceJobs,
+		RequiredPromotionApprovals:   template.RequiredPromotionApprovals,
 	}
 }
@@ -543,6 +543,78 @@ func TestPatchTemplateMeta(t *testing.T) {
 		})
 	})
 
+	t.Run("InactivityTTLTooLow", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			InactivityTTLMillis: -1,
+			DeleteTTLMillis:     -1,
+		})
+		var sdkErr *codersdk.Error
+		require.ErrorAs(t, err, &sdkErr)
+		require.Len(t, sdkErr.Validations, 2)
+		require.Equal(t, "inactivity_ttl_ms", sdkErr.Validations[0].Field)
+		require.Equal(t, "delete_ttl_ms", sdkErr.Validations[1].Field)
+	})
+
+	t.Run("DormancyTTLsIgnoredUnlicensed", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		got, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			Name:                         template.Name,
+			DisplayName:                  template.DisplayName,
+			Description:                  template.Description,
+			Icon:                         template.Icon,
+			DefaultTTLMillis:             template.DefaultTTLMillis,
+			InactivityTTLMillis:          time.Hour.Milliseconds(),
+			DeleteTTLMillis:              time.Hour.Milliseconds(),
+			AllowUserCancelWorkspaceJobs: template.AllowUserCancelWorkspaceJobs,
+		})
+		require.NoError(t, err)
+		require.Zero(t, got.InactivityTTLMillis)
+		require.Zero(t, got.DeleteTTLMillis)
+	})
+
+	t.Run("AutostopRequirementIgnoredUnlicensed", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		got, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			Name:                         template.Name,
+			DisplayName:                  template.DisplayName,
+			Description:                  template.Description,
+			Icon:                         template.Icon,
+			DefaultTTLMillis:             template.DefaultTTLMillis,
+			AutostopRequirement:          "0 2 * * 0",
+			AllowUserCancelWorkspaceJobs: template.AllowUserCancelWorkspaceJobs,
+		})
+		require.NoError(t, err)
+		require.Empty(t, got.AutostopRequirement)
+	})
+
 	t.Run("NotModified", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,413 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/provisionerdserver"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// @Summary Get template version promotions
+// @ID get-template-version-promotions
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Templates
+// @Param template path string true "Template ID" format(uuid)
+// @Success 200 {array} codersdk.TemplateVersionPromotion
+// @Router /templates/{template}/promotions [get]
+func (api *API) templateVersionPromotions(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx      = r.Context()
+		template = httpmw.TemplateParam(r)
+	)
+
+	promotions, err := api.Database.GetTemplateVersionPromotionsByTemplateID(ctx, template.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version promotions.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	apiPromotions := make([]codersdk.TemplateVersionPromotion, 0, len(promotions))
+	for _, promotion := range promotions {
+		apiPromotion, err := api.convertTemplateVersionPromotion(ctx, template, promotion)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error converting template version promotion.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		apiPromotions = append(apiPromotions, apiPromotion)
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, apiPromotions)
+}
+
+// @Summary Create template version promotion
+// @Description Requests that a version becomes the active version of the
+// @Description template. A dry-run of the version is started, and the version
+// @Description is promoted once the dry-run succeeds and the promotion has the
+// @Description number of approvals the template requires. If the version
+// @Description already has a pending promotion, a failed dry-run is restarted
+// @Description and the version is promoted if it's ready.
+// @ID create-template-version-promotion
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Templates
+// @Param template path string true "Template ID" format(uuid)
+// @Param request body codersdk.CreateTemplateVersionPromotionRequest true "Create template version promotion request"
+// @Success 200 {object} codersdk.TemplateVersionPromotion
+// @Success 201 {object} codersdk.TemplateVersionPromotion
+// @Router /templates/{template}/promotions [post]
+func (api *API) postTemplateVersionPromotion(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx         = r.Context()
+		apiKey      = httpmw.APIKey(r)
+		template    = httpmw.TemplateParam(r)
+		auditor     = *api.Auditor.Load()
+		auditParams = &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		}
+		aReq, commitAudit = audit.InitRequest[database.TemplateVersionPromotion](rw, auditParams)
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionUpdate, template) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	var req codersdk.CreateTemplateVersionPromotionRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	version, err := api.Database.GetTemplateVersionByID(ctx, req.TemplateVersionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+			Message: "Template version not found.",
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if version.TemplateID.UUID != template.ID {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "The provided template version doesn't belong to the specified template.",
+		})
+		return
+	}
+	if version.ID == template.ActiveVersionID {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "The provided template version is already active.",
+		})
+		return
+	}
+	importJob, err := api.Database.GetProvisionerJobByID(ctx, version.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if convertProvisionerJob(importJob).Status != codersdk.ProvisionerJobSucceeded {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Only versions that imported successfully can be promoted.",
+		})
+		return
+	}
+
+	richParameterValues := make([]database.WorkspaceBuildParameter, len(req.RichParameterValues))
+	for i, v := range req.RichParameterValues {
+		richParameterValues[i] = database.WorkspaceBuildParameter{
+			WorkspaceBuildID: uuid.Nil,
+			Name:             v.Name,
+			Value:            v.Value,
+		}
+	}
+	dryRun := provisionerdserver.TemplateVersionDryRunJob{
+		TemplateVersionID:   version.ID,
+		RichParameterValues: richParameterValues,
+	}
+
+	status := http.StatusCreated
+	promotion, err := api.Database.GetPendingTemplateVersionPromotionByTemplateVersionID(ctx, version.ID)
+	switch {
+	case err == nil:
+		// Requesting the promotion of a version again updates its pending
+		// promotion.
+		status = http.StatusOK
+		auditParams.Action = database.AuditActionWrite
+		aReq.Old = promotion
+
+		dryRunJob, err := api.Database.GetProvisionerJobByID(ctx, promotion.DryRunJobID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching dry-run job.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		switch convertProvisionerJob(dryRunJob).Status {
+		case codersdk.ProvisionerJobFailed, codersdk.ProvisionerJobCanceled:
+			err = api.Database.InTx(func(tx database.Store) error {
+				dryRunJob, err := insertTemplateVersionDryRunJob(ctx, tx, importJob, apiKey.UserID, dryRun)
+				if err != nil {
+					return err
+				}
+				promotion, err = tx.UpdateTemplateVersionPromotionByID(ctx, database.UpdateTemplateVersionPromotionByIDParams{
+					ID:          promotion.ID,
+					UpdatedAt:   database.Now(),
+					DryRunJobID: dryRunJob.ID,
+					Status:      promotion.Status,
+					PromotedAt:  promotion.PromotedAt,
+				})
+				if err != nil {
+					return xerrors.Errorf("update promotion: %w", err)
+				}
+				return nil
+			}, nil)
+			if err != nil {
+				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+					Message: "Internal error restarting dry-run.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		err = api.Database.InTx(func(tx database.Store) error {
+			dryRunJob, err := insertTemplateVersionDryRunJob(ctx, tx, importJob, apiKey.UserID, dryRun)
+			if err != nil {
+				return err
+			}
+			promotion, err = tx.InsertTemplateVersionPromotion(ctx, database.InsertTemplateVersionPromotionParams{
+				ID:                uuid.New(),
+				CreatedAt:         database.Now(),
+				UpdatedAt:         database.Now(),
+				TemplateID:        template.ID,
+				TemplateVersionID: version.ID,
+				RequesterID:       apiKey.UserID,
+				DryRunJobID:       dryRunJob.ID,
+				Status:            database.TemplateVersionPromotionStatusPending,
+			})
+			if err != nil {
+				return xerrors.Errorf("insert promotion: %w", err)
+			}
+			return nil
+		}, nil)
+		if database.IsUniqueViolation(err, database.UniqueTemplateVersionPromotionsPendingIndex) {
+			httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+				Message: "The template version already has a pending promotion.",
+			})
+			return
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error creating template version promotion.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	default:
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version promotion.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	promotion, err = api.promoteTemplateVersionIfReady(ctx, template, promotion)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error promoting template version.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = promotion
+
+	apiPromotion, err := api.convertTemplateVersionPromotion(ctx, template, promotion)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting template version promotion.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, status, apiPromotion)
+}
+
+// @Summary Approve template version promotion
+// @Description Records the approval of the authenticated user, who must be
+// @Description allowed to update the template and can't be the requester of
+// @Description the promotion. The version is promoted if the approval meets
+// @Description the requirement of the template and the dry-run has succeeded.
+// @ID approve-template-version-promotion
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Templates
+// @Param template path string true "Template ID" format(uuid)
+// @Param templateversionpromotion path string true "Template version promotion ID" format(uuid)
+// @Success 200 {object} codersdk.TemplateVersionPromotion
+// @Router /templates/{template}/promotions/{templateversionpromotion}/approve [post]
+func (api *API) postTemplateVersionPromotionApproval(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		apiKey            = httpmw.APIKey(r)
+		template          = httpmw.TemplateParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.TemplateVersionPromotion](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+
+	promotionID, err := uuid.Parse(chi.URLParam(r, "templateversionpromotion"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Template version promotion id must be a valid UUID.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	promotion, err := api.Database.GetTemplateVersionPromotionByID(ctx, promotionID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && promotion.TemplateID != template.ID) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version promotion.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.Old = promotion
+
+	if !api.Authorize(r, rbac.ActionUpdate, template) {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "Only users who can update the template can approve its promotions.",
+		})
+		return
+	}
+	if promotion.Status != database.TemplateVersionPromotionStatusPending {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Only pending promotions can be approved, this promotion is %s.", promotion.Status),
+		})
+		return
+	}
+	if promotion.RequesterID == apiKey.UserID {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "You can't approve a promotion you requested.",
+		})
+		return
+	}
+	if slices.Contains(promotion.ApproverIDs, apiKey.UserID) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "You already approved this promotion.",
+		})
+		return
+	}
+
+	promotion, err = api.Database.InsertTemplateVersionPromotionApproval(ctx, database.InsertTemplateVersionPromotionApprovalParams{
+		ID:         promotion.ID,
+		UpdatedAt:  database.Now(),
+		ApproverID: apiKey.UserID,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error approving template version promotion.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	promotion, err = api.promoteTemplateVersionIfReady(ctx, template, promotion)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error promoting template version.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = promotion
+
+	apiPromotion, err := api.convertTemplateVersionPromotion(ctx, template, promotion)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting template version promotion.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, apiPromotion)
+}
+
+// promoteTemplateVersionIfReady promotes the version once the promotion is
+// approved and its dry-run succeeded. Promotions approved before the dry-run
+// finished are completed by provisionerdserver when the job completes.
+func (api *API) promoteTemplateVersionIfReady(ctx context.Context, template database.Template, promotion database.TemplateVersionPromotion) (database.TemplateVersionPromotion, error) {
+	return provisionerdserver.PromoteTemplateVersionIfReady(ctx, api.Logger, api.Database, api.Pubsub, template, promotion)
+}
+
+func (api *API) convertTemplateVersionPromotion(ctx context.Context, template database.Template, promotion database.TemplateVersionPromotion) (codersdk.TemplateVersionPromotion, error) {
+	version, err := api.Database.GetTemplateVersionByID(ctx, promotion.TemplateVersionID)
+	if err != nil {
+		return codersdk.TemplateVersionPromotion{}, xerrors.Errorf("get template version: %w", err)
+	}
+	dryRunJob, err := api.Database.GetProvisionerJobByID(ctx, promotion.DryRunJobID)
+	if err != nil {
+		return codersdk.TemplateVersionPromotion{}, xerrors.Errorf("get dry-run job: %w", err)
+	}
+
+	apiPromotion := codersdk.TemplateVersionPromotion{
+		ID:                  promotion.ID,
+		CreatedAt:           promotion.CreatedAt,
+		UpdatedAt:           promotion.UpdatedAt,
+		TemplateID:          promotion.TemplateID,
+		TemplateVersionID:   promotion.TemplateVersionID,
+		TemplateVersionName: version.Name,
+		RequesterID:         promotion.RequesterID,
+		Status:              codersdk.TemplateVersionPromotionStatus(promotion.Status),
+		RequiredApprovals:   template.RequiredPromotionApprovals,
+		ApproverIDs:         promotion.ApproverIDs,
+		DryRun:              convertProvisionerJob(dryRunJob),
+	}
+	if promotion.PromotedAt.Valid {
+		apiPromotion.PromotedAt = &promotion.PromotedAt.Time
+	}
+	if apiPromotion.ApproverIDs == nil {
+		apiPromotion.ApproverIDs = []uuid.UUID{}
+	}
+	return apiPromotion, nil
+}
@@ -0,0 +1,306 @@
+package coderd_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbfake"
+	"github.com/coder/coder/coderd/database/dbgen"
+	"github.com/coder/coder/coderd/provisionerdserver"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestTemplateVersionPromotions(t *testing.T) {
+	t.Parallel()
+
+	// setup creates a template that requires one approval to promote a
+	// version, and a second version that isn't active.
+	setup := func(t *testing.T) (*codersdk.Client, codersdk.Template, codersdk.TemplateVersion) {
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		approvals := int32(1)
+		template, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			RequiredPromotionApprovals: &approvals,
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 1, template.RequiredPromotionApprovals)
+
+		version = coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		return client, template, version
+	}
+
+	awaitDryRun := func(t *testing.T, client *codersdk.Client, promotion codersdk.TemplateVersionPromotion) {
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		require.Eventually(t, func() bool {
+			job, err := client.TemplateVersionDryRun(ctx, promotion.TemplateVersionID, promotion.DryRun.ID)
+			return err == nil && job.Status == codersdk.ProvisionerJobSucceeded
+		}, testutil.WaitLong, testutil.IntervalFast)
+	}
+
+	t.Run("PatchBlocked", func(t *testing.T) {
+		t.Parallel()
+		client, template, version := setup(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := client.UpdateActiveTemplateVersion(ctx, template.ID, codersdk.UpdateActiveTemplateVersion{
+			ID: version.ID,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("Approve", func(t *testing.T) {
+		t.Parallel()
+		client, template, version := setup(t)
+		approver, _ := coderdtest.CreateAnotherUser(t, client, template.OrganizationID, rbac.RoleTemplateAdmin())
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		promotion, err := client.CreateTemplateVersionPromotion(ctx, template.ID, codersdk.CreateTemplateVersionPromotionRequest{
+			TemplateVersionID: version.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, codersdk.TemplateVersionPromotionStatusPending, promotion.Status)
+		require.EqualValues(t, 1, promotion.RequiredApprovals)
+		require.Empty(t, promotion.ApproverIDs)
+		awaitDryRun(t, client, promotion)
+
+		// The dry-run succeeded, but the promotion still needs an approval.
+		promotion, err = client.CreateTemplateVersionPromotion(ctx, template.ID, codersdk.CreateTemplateVersionPromotionRequest{
+			TemplateVersionID: version.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, codersdk.TemplateVersionPromotionStatusPending, promotion.Status)
+
+		// The requester can't approve their own promotion.
+		_, err = client.ApproveTemplateVersionPromotion(ctx, template.ID, promotion.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+		promotion, err = approver.ApproveTemplateVersionPromotion(ctx, template.ID, promotion.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.TemplateVersionPromotionStatusPromoted, promotion.Status)
+		require.NotNil(t, promotion.PromotedAt)
+		require.Len(t, promotion.ApproverIDs, 1)
+
+		template, err = client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		require.Equal(t, version.ID, template.ActiveVersionID)
+
+		// Promoted promotions can't be approved again.
+		_, err = approver.ApproveTemplateVersionPromotion(ctx, template.ID, promotion.ID)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("ApproveBeforeDryRun", func(t *testing.T) {
+		t.Parallel()
+		client, template, version := setup(t)
+		approver, _ := coderdtest.CreateAnotherUser(t, client, template.OrganizationID, rbac.RoleTemplateAdmin())
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		promotion, err := client.CreateTemplateVersionPromotion(ctx, template.ID, codersdk.CreateTemplateVersionPromotionRequest{
+			TemplateVersionID: version.ID,
+		})
+		require.NoError(t, err)
+		_, err = approver.ApproveTemplateVersionPromotion(ctx, template.ID, promotion.ID)
+		require.NoError(t, err)
+
+		// The promotion completes when the dry-run succeeds, without it being
+		// requested or approved again.
+		require.Eventually(t, func() bool {
+			template, err := client.Template(ctx, template.ID)
+			return err == nil && template.ActiveVersionID == version.ID
+		}, testutil.WaitLong, testutil.IntervalFast)
+
+		promotions, err := client.TemplateVersionPromotions(ctx, template.ID)
+		require.NoError(t, err)
+		require.Len(t, promotions, 1)
+		require.Equal(t, codersdk.TemplateVersionPromotionStatusPromoted, promotions[0].Status)
+		require.NotNil(t, promotions[0].PromotedAt)
+	})
+
+	t.Run("Supersede", func(t *testing.T) {
+		t.Parallel()
+		client, template, version := setup(t)
+		approver, _ := coderdtest.CreateAnotherUser(t, client, template.OrganizationID, rbac.RoleTemplateAdmin())
+		other := coderdtest.UpdateTemplateVersion(t, client, template.OrganizationID, nil, template.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, other.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		superseded, err := client.CreateTemplateVersionPromotion(ctx, template.ID, codersdk.CreateTemplateVersionPromotionRequest{
+			TemplateVersionID: other.ID,
+		})
+		require.NoError(t, err)
+		promotion, err := client.CreateTemplateVersionPromotion(ctx, template.ID, codersdk.CreateTemplateVersionPromotionRequest{
+			TemplateVersionID: version.ID,
+		})
+		require.NoError(t, err)
+		awaitDryRun(t, client, promotion)
+		_, err = approver.ApproveTemplateVersionPromotion(ctx, template.ID, promotion.ID)
+		require.NoError(t, err)
+
+		promotions, err := client.TemplateVersionPromotions(ctx, template.ID)
+		require.NoError(t, err)
+		require.Len(t, promotions, 2)
+		for _, p := range promotions {
+			switch p.ID {
+			case promotion.ID:
+				require.Equal(t, codersdk.TemplateVersionPromotionStatusPromoted, p.Status)
+			case superseded.ID:
+				require.Equal(t, codersdk.TemplateVersionPromotionStatusSuperseded, p.Status)
+				require.Equal(t, other.Name, p.TemplateVersionName)
+			}
+		}
+	})
+
+	t.Run("LowerRequirement", func(t *testing.T) {
+		t.Parallel()
+		client, template, version := setup(t)
+		templateAdmin, _ := coderdtest.CreateAnotherUser(t, client, template.OrganizationID, rbac.RoleTemplateAdmin())
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Template admins can't skip approvals by removing the requirement.
+		approvals := int32(0)
+		_, err := templateAdmin.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			RequiredPromotionApprovals: &approvals,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+		err = templateAdmin.UpdateActiveTemplateVersion(ctx, template.ID, codersdk.UpdateActiveTemplateVersion{
+			ID: version.ID,
+		})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+		// Raising the requirement only needs template permissions.
+		approvals = 2
+		updated, err := templateAdmin.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			RequiredPromotionApprovals: &approvals,
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, updated.RequiredPromotionApprovals)
+
+		// Owners can lower it.
+		approvals = 0
+		updated, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			RequiredPromotionApprovals: &approvals,
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 0, updated.RequiredPromotionApprovals)
+	})
+
+	t.Run("PromoteOnce", func(t *testing.T) {
+		t.Parallel()
+		db := dbfake.New()
+		ps := database.NewPubsubInMemory()
+		logger := slogtest.Make(t, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		dryRunJob := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{
+			Type: database.ProvisionerJobTypeTemplateVersionDryRun,
+		})
+		err := db.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
+			ID:          dryRunJob.ID,
+			UpdatedAt:   database.Now(),
+			CompletedAt: sql.NullTime{Time: database.Now(), Valid: true},
+		})
+		require.NoError(t, err)
+		template := dbgen.Template(t, db, database.Template{})
+		promotion := dbgen.TemplateVersionPromotion(t, db, database.TemplateVersionPromotion{
+			TemplateID:  template.ID,
+			DryRunJobID: dryRunJob.ID,
+		})
+
+		promoted, err := provisionerdserver.PromoteTemplateVersionIfReady(ctx, logger, db, ps, template, promotion)
+		require.NoError(t, err)
+		require.Equal(t, database.TemplateVersionPromotionStatusPromoted, promoted.Status)
+		activeVersionID := uuid.New()
+		err = db.UpdateTemplateActiveVersionByID(ctx, database.UpdateTemplateActiveVersionByIDParams{
+			ID:              template.ID,
+			ActiveVersionID: activeVersionID,
+			UpdatedAt:       database.Now(),
+		})
+		require.NoError(t, err)
+
+		// An approval racing the dry-run completing still sees the promotion
+		// pending, but the version was already promoted.
+		promoted, err = provisionerdserver.PromoteTemplateVersionIfReady(ctx, logger, db, ps, template, promotion)
+		require.NoError(t, err)
+		require.Equal(t, database.TemplateVersionPromotionStatusPromoted, promoted.Status)
+		template, err = db.GetTemplateByID(ctx, template.ID)
+		require.NoError(t, err)
+		require.Equal(t, activeVersionID, template.ActiveVersionID)
+	})
+
+	t.Run("MemberCannotApprove", func(t *testing.T) {
+		t.Parallel()
+		client, template, version := setup(t)
+		member, _ := coderdtest.CreateAnotherUser(t, client, template.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		promotion, err := client.CreateTemplateVersionPromotion(ctx, template.ID, codersdk.CreateTemplateVersionPromotionRequest{
+			TemplateVersionID: version.ID,
+		})
+		require.NoError(t, err)
+		_, err = member.ApproveTemplateVersionPromotion(ctx, template.ID, promotion.ID)
+		require.Error(t, err)
+	})
+
+	t.Run("DoesNotBelong", func(t *testing.T) {
+		t.Parallel()
+		client, template, _ := setup(t)
+		version := coderdtest.CreateTemplateVersion(t, client, template.OrganizationID, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersionPromotion(ctx, template.ID, codersdk.CreateTemplateVersionPromotionRequest{
+			TemplateVersionID: version.ID,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+		_, err = client.ApproveTemplateVersionPromotion(ctx, template.ID, uuid.New())
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
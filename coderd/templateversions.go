@@ -25,6 +25,7 @@ import (
 	"github.com/coder/coder/coderd/parameter"
 	"github.com/coder/coder/coderd/provisionerdserver"
 	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/util/slice"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/examples"
 	sdkproto "github.com/coder/coder/provisionersdk/proto"
@@ -535,6 +536,45 @@ func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Planning against a workspace requires reading its state, which is
+	// limited to users who can update the template.
+	var workspaceID uuid.NullUUID
+	if req.WorkspaceID != uuid.Nil {
+		workspace, err := api.Database.GetWorkspaceByID(ctx, req.WorkspaceID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !api.Authorize(r, rbac.ActionRead, workspace)) {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Workspace %q not found.", req.WorkspaceID),
+			})
+			return
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching workspace.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if !templateVersion.TemplateID.Valid || workspace.TemplateID != templateVersion.TemplateID.UUID {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Workspace must belong to the template of the template version.",
+			})
+			return
+		}
+		template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching template.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if !api.Authorize(r, rbac.ActionUpdate, template) {
+			httpapi.Forbidden(rw)
+			return
+		}
+		workspaceID = uuid.NullUUID{UUID: workspace.ID, Valid: true}
+	}
+
 	// Convert parameters from request to parameters for the job
 	parameterValues := make([]database.ParameterValue, len(req.ParameterValues))
 	for i, v := range req.ParameterValues {
@@ -558,47 +598,50 @@ func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	// Marshal template version dry-run job with the parameters from the
-	// request.
-	input, err := json.Marshal(provisionerdserver.TemplateVersionDryRunJob{
+	provisionerJob, err := insertTemplateVersionDryRunJob(ctx, api.Database, job, apiKey.UserID, provisionerdserver.TemplateVersionDryRunJob{
 		TemplateVersionID:   templateVersion.ID,
 		WorkspaceName:       req.WorkspaceName,
 		ParameterValues:     parameterValues,
 		RichParameterValues: richParameterValues,
+		WorkspaceID:         workspaceID,
 	})
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error unmarshalling provisioner job.",
+			Message: "Internal error inserting provisioner job.",
 			Detail:  err.Error(),
 		})
 		return
 	}
 
-	// Create a dry-run job
-	jobID := uuid.New()
-	provisionerJob, err := api.Database.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
-		ID:             jobID,
+	httpapi.Write(ctx, rw, http.StatusCreated, convertProvisionerJob(provisionerJob))
+}
+
+// insertTemplateVersionDryRunJob queues a dry-run of a template version using
+// the files and tags of its import job.
+func insertTemplateVersionDryRunJob(ctx context.Context, db database.Store, importJob database.ProvisionerJob, initiatorID uuid.UUID, dryRun provisionerdserver.TemplateVersionDryRunJob) (database.ProvisionerJob, error) {
+	input, err := json.Marshal(dryRun)
+	if err != nil {
+		return database.ProvisionerJob{}, xerrors.Errorf("marshal job input: %w", err)
+	}
+	job, err := db.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
+		ID:             uuid.New(),
 		CreatedAt:      database.Now(),
 		UpdatedAt:      database.Now(),
-		OrganizationID: templateVersion.OrganizationID,
-		InitiatorID:    apiKey.UserID,
-		Provisioner:    job.Provisioner,
-		StorageMethod:  job.StorageMethod,
-		FileID:         job.FileID,
+		OrganizationID: importJob.OrganizationID,
+		InitiatorID:    initiatorID,
+		Provisioner:    importJob.Provisioner,
+		StorageMethod:  importJob.StorageMethod,
+		FileID:         importJob.FileID,
 		Type:           database.ProvisionerJobTypeTemplateVersionDryRun,
 		Input:          input,
 		// Copy tags from the previous run.
-		Tags: job.Tags,
+		Tags:     importJob.Tags,
+		Priority: provisionerdserver.PriorityTemplateVersionDryRun,
 	})
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error inserting provisioner job.",
-			Detail:  err.Error(),
-		})
-		return
+		return database.ProvisionerJob{}, xerrors.Errorf("insert provisioner job: %w", err)
 	}
-
-	httpapi.Write(ctx, rw, http.StatusCreated, convertProvisionerJob(provisionerJob))
+	return job, nil
 }
 
 // @Summary Get template version dry-run by job ID
@@ -638,6 +681,40 @@ func (api *API) templateVersionDryRunResources(rw http.ResponseWriter, r *http.R
 	api.provisionerJobResources(rw, r, job)
 }
 
+// @Summary Get template version dry-run resource changes by job ID
+// @ID get-template-version-dry-run-resource-changes-by-job-id
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Templates
+// @Param templateversion path string true "Template version ID" format(uuid)
+// @Param jobID path string true "Job ID" format(uuid)
+// @Success 200 {object} codersdk.TemplateVersionDryRunChanges
+// @Router /templateversions/{templateversion}/dry-run/{jobID}/changes [get]
+func (api *API) templateVersionDryRunChanges(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	job, ok := api.fetchTemplateVersionDryRunJob(rw, r)
+	if !ok {
+		return
+	}
+	if !job.CompletedAt.Valid {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Job hasn't completed!",
+		})
+		return
+	}
+
+	changes, err := api.Database.GetProvisionerJobResourceChangesByJobID(ctx, job.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching job resource changes.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertTemplateVersionDryRunChanges(changes))
+}
+
 // @Summary Get template version dry-run logs by job ID
 // @ID get-template-version-dry-run-logs-by-job-id
 // @Security CoderSessionToken
@@ -780,6 +857,25 @@ func (api *API) fetchTemplateVersionDryRunJob(rw http.ResponseWriter, r *http.Re
 	return job, true
 }
 
+func convertTemplateVersionDryRunChanges(changes []database.ProvisionerJobResourceChange) codersdk.TemplateVersionDryRunChanges {
+	summary := codersdk.TemplateVersionDryRunChanges{
+		Changes: make([]codersdk.WorkspaceResourceChange, 0, len(changes)),
+	}
+	for _, change := range changes {
+		summary.Changes = append(summary.Changes, codersdk.WorkspaceResourceChange{
+			Address:        change.Address,
+			Type:           change.Type,
+			Name:           change.Name,
+			Action:         codersdk.WorkspaceResourceChangeAction(change.Action),
+			DailyCost:      change.DailyCost,
+			PriorDailyCost: change.PriorDailyCost,
+		})
+		summary.DailyCost += change.DailyCost
+		summary.PriorDailyCost += change.PriorDailyCost
+	}
+	return summary
+}
+
 // @Summary List template versions by template ID
 // @ID list-template-versions-by-template-id
 // @Security CoderSessionToken
@@ -1157,6 +1253,13 @@ func (api *API) patchActiveTemplateVersion(rw http.ResponseWriter, r *http.Reque
 		})
 		return
 	}
+	if template.RequiredPromotionApprovals > 0 {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: fmt.Sprintf("Versions of this template need %d approvals before they can be promoted.", template.RequiredPromotionApprovals),
+			Detail:  "Request a promotion of the version instead.",
+		})
+		return
+	}
 
 	err = api.Database.InTx(func(store database.Store) error {
 		err = store.UpdateTemplateActiveVersionByID(ctx, database.UpdateTemplateActiveVersionByIDParams{
@@ -1180,7 +1283,7 @@ func (api *API) patchActiveTemplateVersion(rw http.ResponseWriter, r *http.Reque
 	newTemplate.ActiveVersionID = req.ID
 	aReq.New = newTemplate
 
-	api.publishTemplateUpdate(ctx, template.ID)
+	api.publishTemplateVersionPromoted(ctx, template.ID, req.ID)
 
 	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
 		Message: "Updated the active template version!",
@@ -1220,6 +1323,17 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 		return
 	}
 
+	if !slice.Contains(api.ProvisionerTypes, database.ProvisionerType(req.Provisioner)) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Unknown provisioner type %q.", req.Provisioner),
+			Validations: []codersdk.ValidationError{{
+				Field:  "provisioner",
+				Detail: "Must be a built-in provisioner, or a provisioner plugin declared in the deployment config.",
+			}},
+		})
+		return
+	}
+
 	if req.TemplateID != uuid.Nil {
 		_, err := api.Database.GetTemplateByID(ctx, req.TemplateID)
 		if errors.Is(err, sql.ErrNoRows) {
@@ -1421,6 +1535,7 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 			Type:           database.ProvisionerJobTypeTemplateVersionImport,
 			Input:          jobInput,
 			Tags:           tags,
+			Priority:       provisionerdserver.PriorityTemplateVersionImport,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
@@ -1640,12 +1755,12 @@ func convertTemplateVersionVariable(variable database.TemplateVersionVariable) c
 	return templateVariable
 }
 
-func watchTemplateChannel(id uuid.UUID) string {
-	return fmt.Sprintf("template:%s", id)
+func (api *API) publishTemplateVersionPromoted(ctx context.Context, templateID, templateVersionID uuid.UUID) {
+	provisionerdserver.PublishTemplateVersionPromoted(ctx, api.Logger, api.Pubsub, templateID, templateVersionID)
 }
 
 func (api *API) publishTemplateUpdate(ctx context.Context, templateID uuid.UUID) {
-	err := api.Pubsub.Publish(watchTemplateChannel(templateID), []byte{})
+	err := api.Pubsub.Publish(codersdk.TemplateNotifyChannel(templateID), []byte{})
 	if err != nil {
 		api.Logger.Warn(ctx, "failed to publish template update",
 			slog.F("template_id", templateID), slog.Error(err))
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/coder/coder/cli/clibase"
 	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
@@ -102,6 +103,48 @@ func TestPostTemplateVersionsByOrganization(t *testing.T) {
 		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
 	})
 
+	t.Run("UnknownProvisioner", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			FileID:        uuid.New(),
+			Provisioner:   "vm",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Len(t, apiErr.Validations, 1)
+		require.Equal(t, "provisioner", apiErr.Validations[0].Field)
+	})
+
+	t.Run("ProvisionerPlugin", func(t *testing.T) {
+		t.Parallel()
+		dv := coderdtest.DeploymentValues(t)
+		dv.Provisioner.Plugins = clibase.StringArray{"vm=/usr/local/bin/vm-provisioner"}
+		client := coderdtest.New(t, &coderdtest.Options{DeploymentValues: dv})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		file, err := client.Upload(ctx, codersdk.ContentTypeTar, bytes.NewReader(make([]byte, 1024)))
+		require.NoError(t, err)
+		version, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			FileID:        file.ID,
+			Provisioner:   "vm",
+		})
+		require.NoError(t, err)
+		// No daemon serves the plugin, so the job stays pending.
+		require.Equal(t, codersdk.ProvisionerJobPending, version.Job.Status)
+	})
+
 	t.Run("WithParameters", func(t *testing.T) {
 		t.Parallel()
 		auditor := audit.NewMock()
@@ -797,6 +840,63 @@ func TestTemplateVersionDryRun(t *testing.T) {
 		require.Equal(t, resource.Type, resources[0].Type)
 	})
 
+	t.Run("Changes", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+			Parse: echo.ParseComplete,
+			ProvisionApply: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Resources: []*proto.Resource{{
+							Name: "cool-resource",
+							Type: "cool_resource_type",
+						}},
+						ResourceChanges: []*proto.ResourceChange{{
+							Address:        "cool_resource_type.cool-resource",
+							Name:           "cool-resource",
+							Type:           "cool_resource_type",
+							Action:         proto.ResourceChangeAction_REPLACE,
+							DailyCost:      20,
+							PriorDailyCost: 15,
+						}, {
+							Address:        "cool_resource_type.old-resource",
+							Name:           "old-resource",
+							Type:           "cool_resource_type",
+							Action:         proto.ResourceChangeAction_DELETE,
+							PriorDailyCost: 10,
+						}},
+					},
+				},
+			}},
+		})
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		job, err := client.CreateTemplateVersionDryRun(ctx, version.ID, codersdk.CreateTemplateVersionDryRunRequest{})
+		require.NoError(t, err)
+
+		// Changes aren't available until the job completes.
+		require.Eventually(t, func() bool {
+			job, err := client.TemplateVersionDryRun(ctx, version.ID, job.ID)
+			return assert.NoError(t, err) && job.Status == codersdk.ProvisionerJobSucceeded
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		changes, err := client.TemplateVersionDryRunChanges(ctx, version.ID, job.ID)
+		require.NoError(t, err)
+		require.Len(t, changes.Changes, 2)
+		require.Equal(t, "cool_resource_type.cool-resource", changes.Changes[0].Address)
+		require.Equal(t, codersdk.WorkspaceResourceChangeActionReplace, changes.Changes[0].Action)
+		require.Equal(t, "cool_resource_type.old-resource", changes.Changes[1].Address)
+		require.Equal(t, codersdk.WorkspaceResourceChangeActionDelete, changes.Changes[1].Action)
+		require.EqualValues(t, 20, changes.DailyCost)
+		require.EqualValues(t, 25, changes.PriorDailyCost)
+	})
+
 	t.Run("ImportNotFinished", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
@@ -822,6 +922,47 @@ func TestTemplateVersionDryRun(t *testing.T) {
 		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
 	})
 
+	t.Run("Workspace", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+		otherVersion := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, otherVersion.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// The workspace must belong to the template of the version.
+		_, err := client.CreateTemplateVersionDryRun(ctx, otherVersion.ID, codersdk.CreateTemplateVersionDryRunRequest{
+			WorkspaceID: workspace.ID,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+		// Reading the workspace state requires updating the template.
+		member, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err = member.CreateTemplateVersionDryRun(ctx, version.ID, codersdk.CreateTemplateVersionDryRunRequest{
+			WorkspaceID: workspace.ID,
+		})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+		job, err := client.CreateTemplateVersionDryRun(ctx, version.ID, codersdk.CreateTemplateVersionDryRunRequest{
+			WorkspaceID: workspace.ID,
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			job, err := client.TemplateVersionDryRun(ctx, version.ID, job.ID)
+			return assert.NoError(t, err) && job.Status == codersdk.ProvisionerJobSucceeded
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+
 	t.Run("Cancel", func(t *testing.T) {
 		t.Parallel()
 
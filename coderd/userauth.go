@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/mail"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/userpassword"
+	"github.com/coder/coder/coderd/util/slice"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -425,7 +427,7 @@ func (api *API) userOAuth2Github(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cookie, key, err := api.oauthLogin(r, oauthLoginParams{
+	params := &oauthLoginParams{
 		User:         user,
 		Link:         link,
 		State:        state,
@@ -435,7 +437,9 @@ func (api *API) userOAuth2Github(rw http.ResponseWriter, r *http.Request) {
 		Email:        verifiedEmail.GetEmail(),
 		Username:     ghUser.GetLogin(),
 		AvatarURL:    ghUser.GetAvatarURL(),
-	})
+	}
+	defer params.CommitAuditLogs()
+	cookie, key, err := api.oauthLogin(rw, r, params)
 	var httpErr httpError
 	if xerrors.As(err, &httpErr) {
 		httpapi.Write(ctx, rw, httpErr.code, codersdk.Response{
@@ -491,6 +495,25 @@ type OIDCConfig struct {
 	// to groups within Coder.
 	// map[oidcGroupName]coderGroupName
 	GroupMapping map[string]string
+	// GroupFilter is matched against the groups returned by the OIDC provider
+	// before they are mapped. Groups that don't match are ignored. If nil, all
+	// groups are used.
+	GroupFilter *regexp.Regexp
+	// CreateMissingGroups creates the groups returned by the OIDC provider
+	// that don't exist in Coder, instead of ignoring them.
+	CreateMissingGroups bool
+	// UserRoleField selects the claim field to be used as the user's site
+	// roles. If the role field is the empty string, then site roles are never
+	// synced from the OIDC provider and can be managed in Coder instead.
+	UserRoleField string
+	// UserRoleMapping controls how roles returned by the OIDC provider get
+	// mapped to site roles within Coder. Roles that aren't mapped are used
+	// as-is.
+	// map[oidcRoleName][]coderRoleName
+	UserRoleMapping map[string][]string
+	// UserRolesDefault are the site roles given to every user when site
+	// roles are synced from the OIDC provider.
+	UserRolesDefault []string
 	// SignInText is the text to display on the OIDC login button
 	SignInText string
 	// IconURL points to the URL of an icon to display on the OIDC login button
@@ -662,6 +685,10 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 						return
 					}
 
+					if api.OIDCConfig.GroupFilter != nil && !api.OIDCConfig.GroupFilter.MatchString(group) {
+						continue
+					}
+
 					if mappedGroup, ok := api.OIDCConfig.GroupMapping[group]; ok {
 						group = mappedGroup
 					}
@@ -676,6 +703,50 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var usingRoles bool
+	var roles []string
+	// If the UserRoleField is the empty string, then site roles from OIDC are
+	// not used. This is so we can support manual role assignment.
+	if api.OIDCConfig.UserRoleField != "" {
+		usingRoles = true
+		roles = append(roles, api.OIDCConfig.UserRolesDefault...)
+
+		var rolesInterface []interface{}
+		switch rolesRaw := claims[api.OIDCConfig.UserRoleField].(type) {
+		case nil:
+		case string:
+			// Some providers return a single role as a string.
+			rolesInterface = []interface{}{rolesRaw}
+		case []interface{}:
+			rolesInterface = rolesRaw
+		default:
+			api.Logger.Debug(ctx, "roles field was an unknown type",
+				slog.F("type", fmt.Sprintf("%T", rolesRaw)),
+			)
+		}
+		api.Logger.Debug(ctx, "roles returned in oidc claims",
+			slog.F("len", len(rolesInterface)),
+			slog.F("roles", rolesInterface),
+		)
+
+		for _, roleInterface := range rolesInterface {
+			role, ok := roleInterface.(string)
+			if !ok {
+				httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+					Message: fmt.Sprintf("Invalid role type. Expected string, got: %T", roleInterface),
+				})
+				return
+			}
+
+			if mappedRoles, ok := api.OIDCConfig.UserRoleMapping[role]; ok {
+				roles = append(roles, mappedRoles...)
+				continue
+			}
+
+			roles = append(roles, role)
+		}
+	}
+
 	// The username is a required property in Coder. We make a best-effort
 	// attempt at using what the claims provide, but if that fails we will
 	// generate a random username.
@@ -721,19 +792,24 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cookie, key, err := api.oauthLogin(r, oauthLoginParams{
-		User:         user,
-		Link:         link,
-		State:        state,
-		LinkedID:     oidcLinkedID(idToken),
-		LoginType:    database.LoginTypeOIDC,
-		AllowSignups: api.OIDCConfig.AllowSignups,
-		Email:        email,
-		Username:     username,
-		AvatarURL:    picture,
-		UsingGroups:  usingGroups,
-		Groups:       groups,
-	})
+	params := &oauthLoginParams{
+		User:                user,
+		Link:                link,
+		State:               state,
+		LinkedID:            oidcLinkedID(idToken),
+		LoginType:           database.LoginTypeOIDC,
+		AllowSignups:        api.OIDCConfig.AllowSignups,
+		Email:               email,
+		Username:            username,
+		AvatarURL:           picture,
+		UsingGroups:         usingGroups,
+		Groups:              groups,
+		CreateMissingGroups: api.OIDCConfig.CreateMissingGroups,
+		UsingRoles:          usingRoles,
+		Roles:               roles,
+	}
+	defer params.CommitAuditLogs()
+	cookie, key, err := api.oauthLogin(rw, r, params)
 	var httpErr httpError
 	if xerrors.As(err, &httpErr) {
 		httpapi.Write(ctx, rw, httpErr.code, codersdk.Response{
@@ -776,8 +852,32 @@ type oauthLoginParams struct {
 	AvatarURL    string
 	// Is UsingGroups is true, then the user will be assigned
 	// to the Groups provided.
-	UsingGroups bool
-	Groups      []string
+	UsingGroups         bool
+	Groups              []string
+	CreateMissingGroups bool
+	// If UsingRoles is true, then the user will be assigned
+	// the site Roles provided.
+	UsingRoles bool
+	Roles      []string
+
+	// commits are the audit logs of the changes made to the user's roles
+	// and groups by the login.
+	commits []func()
+}
+
+// CommitAuditLogs commits the audit logs of the changes made to the user's
+// roles and groups by the login. It does nothing if the login failed.
+func (p *oauthLoginParams) CommitAuditLogs() {
+	for _, commit := range p.commits {
+		commit()
+	}
+}
+
+// GroupChange is a change made to a group when syncing the groups of a user
+// with an auth provider. Old is the zero value if the group was created.
+type GroupChange struct {
+	Old database.AuditableGroup
+	New database.AuditableGroup
 }
 
 type httpError struct {
@@ -794,10 +894,15 @@ func (e httpError) Error() string {
 	return e.msg
 }
 
-func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cookie, database.APIKey, error) {
+func (api *API) oauthLogin(rw http.ResponseWriter, r *http.Request, params *oauthLoginParams) (*http.Cookie, database.APIKey, error) {
 	var (
 		ctx  = r.Context()
 		user database.User
+		// The changes made by syncing the user's roles and groups are only
+		// audited once the transaction is committed.
+		oldRolesUser database.User
+		newRolesUser database.User
+		groupChanges []GroupChange
 	)
 
 	err := api.Database.InTx(func(tx database.Store) error {
@@ -805,6 +910,9 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 			link database.UserLink
 			err  error
 		)
+		oldRolesUser = database.User{}
+		newRolesUser = database.User{}
+		groupChanges = nil
 
 		user = params.User
 		link = params.Link
@@ -919,12 +1027,36 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 		// Ensure groups are correct.
 		if params.UsingGroups {
 			//nolint:gocritic
-			err := api.Options.SetUserGroups(dbauthz.AsSystemRestricted(ctx), tx, user.ID, params.Groups)
+			groupChanges, err = api.Options.SetUserGroups(dbauthz.AsSystemRestricted(ctx), tx, user.ID, params.Groups, params.CreateMissingGroups)
 			if err != nil {
 				return xerrors.Errorf("set user groups: %w", err)
 			}
 		}
 
+		// Ensure site roles are correct.
+		if params.UsingRoles {
+			roles, ignored := siteRoles(params.Roles)
+			if len(ignored) > 0 {
+				api.Logger.Debug(ctx, "ignored roles that aren't site roles",
+					slog.F("user_id", user.ID),
+					slog.F("roles", ignored),
+				)
+			}
+			added, removed := rbac.ChangeRoleSet(user.RBACRoles, roles)
+			if len(added) > 0 || len(removed) > 0 {
+				oldRolesUser = user
+				//nolint:gocritic
+				user, err = tx.UpdateUserRoles(dbauthz.AsSystemRestricted(ctx), database.UpdateUserRolesParams{
+					GrantedRoles: roles,
+					ID:           user.ID,
+				})
+				if err != nil {
+					return xerrors.Errorf("update user roles: %w", err)
+				}
+				newRolesUser = user
+			}
+		}
+
 		needsUpdate := false
 		if user.AvatarURL.String != params.AvatarURL {
 			user.AvatarURL = sql.NullString{
@@ -971,6 +1103,36 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 		return nil, database.APIKey{}, xerrors.Errorf("in tx: %w", err)
 	}
 
+	auditor := *api.Auditor.Load()
+	if newRolesUser.ID != uuid.Nil {
+		aReq, commitAudit := audit.InitRequest[database.User](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+		aReq.Old = oldRolesUser
+		aReq.New = newRolesUser
+		aReq.UserID = user.ID
+		params.commits = append(params.commits, commitAudit)
+	}
+	for _, change := range groupChanges {
+		action := database.AuditActionWrite
+		if change.Old.ID == uuid.Nil {
+			action = database.AuditActionCreate
+		}
+		aReq, commitAudit := audit.InitRequest[database.AuditableGroup](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  action,
+		})
+		aReq.Old = change.Old
+		aReq.New = change.New
+		aReq.UserID = user.ID
+		params.commits = append(params.commits, commitAudit)
+	}
+
 	//nolint:gocritic
 	cookie, key, err := api.createAPIKey(dbauthz.AsSystemRestricted(ctx), createAPIKeyParams{
 		UserID:     user.ID,
@@ -984,6 +1146,25 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 	return cookie, *key, nil
 }
 
+// siteRoles returns the unique site roles in roles, and the roles that were
+// ignored because they aren't site roles. The member role is ignored since
+// every user has it.
+func siteRoles(roles []string) (siteRoles []string, ignored []string) {
+	siteRoles = make([]string, 0, len(roles))
+	for _, role := range slice.Unique(roles) {
+		if _, ok := rbac.IsOrgRole(role); ok || role == rbac.RoleMember() {
+			ignored = append(ignored, role)
+			continue
+		}
+		if _, err := rbac.RoleByName(role); err != nil {
+			ignored = append(ignored, role)
+			continue
+		}
+		siteRoles = append(siteRoles, role)
+	}
+	return siteRoles, ignored
+}
+
 // githubLinkedID returns the unique ID for a GitHub user.
 func githubLinkedID(u *github.User) string {
 	return strconv.FormatInt(u.GetID(), 10)
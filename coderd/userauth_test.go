@@ -22,6 +22,7 @@ import (
 	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -739,6 +740,72 @@ func TestUserOIDC(t *testing.T) {
 		require.Equal(t, database.AuditActionLogin, auditor.AuditLogs()[numLogs-1].Action)
 	})
 
+	t.Run("Roles", func(t *testing.T) {
+		t.Parallel()
+		auditor := audit.NewMock()
+		conf := coderdtest.NewOIDCConfig(t, "")
+
+		config := conf.OIDCConfig(t, nil, func(cfg *coderd.OIDCConfig) {
+			cfg.UserRoleField = "roles"
+			cfg.UserRoleMapping = map[string][]string{
+				"admins": {rbac.RoleTemplateAdmin(), rbac.RoleUserAdmin()},
+			}
+			cfg.UserRolesDefault = []string{"auditor"}
+		})
+		config.AllowSignups = true
+
+		client := coderdtest.New(t, &coderdtest.Options{
+			Auditor:    auditor,
+			OIDCConfig: config,
+		})
+		ctx := testutil.Context(t, testutil.WaitLong)
+		roleNames := func(user codersdk.User) []string {
+			names := make([]string, 0, len(user.Roles))
+			for _, role := range user.Roles {
+				names = append(names, role.Name)
+			}
+			return names
+		}
+
+		resp := oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "kyle@kwc.io",
+			// Unknown and organization roles are ignored.
+			"roles": []string{"admins", "unknown", rbac.RoleMember(), rbac.RoleOrgAdmin(uuid.New())},
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		client.SetSessionToken(authCookieValue(resp.Cookies()))
+		user, err := client.User(ctx, "me")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"auditor", rbac.RoleTemplateAdmin(), rbac.RoleUserAdmin()}, roleNames(user))
+
+		// The role change is audited along with the login.
+		logs := auditor.AuditLogs()
+		require.Len(t, logs, 2)
+		require.Equal(t, database.AuditActionWrite, logs[0].Action)
+		require.Equal(t, database.ResourceTypeUser, logs[0].ResourceType)
+		require.Equal(t, user.ID, logs[0].ResourceID)
+		require.Equal(t, database.AuditActionLogin, logs[1].Action)
+
+		// The provider is the source of truth, so roles are removed too.
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "kyle@kwc.io",
+			"roles": rbac.RoleTemplateAdmin(),
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		user, err = client.User(ctx, "me")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"auditor", rbac.RoleTemplateAdmin()}, roleNames(user))
+		require.Len(t, auditor.AuditLogs(), 4)
+
+		// Nothing is audited if the roles didn't change.
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "kyle@kwc.io",
+			"roles": []string{rbac.RoleTemplateAdmin()},
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		require.Len(t, auditor.AuditLogs(), 5)
+	})
+
 	t.Run("Disabled", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, nil)
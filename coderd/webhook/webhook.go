@@ -0,0 +1,31 @@
+// Package webhook contains helpers shared by the webhooks that Coder sends,
+// such as notification and audit log webhooks.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// SignatureHeader contains the hex encoded HMAC-SHA256 of the request body
+// prefixed with "sha256=", if the webhook has a secret.
+const SignatureHeader = "X-Coder-Signature-256"
+
+// Signature returns the value of SignatureHeader for the given body.
+func Signature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsPermanentStatus returns true for client errors that won't succeed when
+// retried.
+func IsPermanentStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return statusCode >= 400 && statusCode < 500
+}
@@ -0,0 +1,28 @@
+package webhook_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/webhook"
+)
+
+func TestSignature(t *testing.T) {
+	t.Parallel()
+
+	// echo -n '{}' | openssl dgst -sha256 -hmac secret
+	require.Equal(t, "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13", webhook.Signature("secret", []byte("{}")))
+}
+
+func TestIsPermanentStatus(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, webhook.IsPermanentStatus(http.StatusBadRequest))
+	require.True(t, webhook.IsPermanentStatus(http.StatusNotFound))
+	require.False(t, webhook.IsPermanentStatus(http.StatusRequestTimeout))
+	require.False(t, webhook.IsPermanentStatus(http.StatusTooManyRequests))
+	require.False(t, webhook.IsPermanentStatus(http.StatusInternalServerError))
+	require.False(t, webhook.IsPermanentStatus(http.StatusOK))
+}
@@ -0,0 +1,243 @@
+package coderd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/tracing"
+	"github.com/coder/coder/coderd/workspaceapps"
+	"github.com/coder/coder/codersdk"
+)
+
+// @Summary Get workspace agent port shares
+// @ID get-workspace-agent-port-shares
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Success 200 {object} codersdk.WorkspaceAgentPortShares
+// @Router /workspaceagents/{workspaceagent}/port-shares [get]
+func (api *API) workspaceAgentPortShares(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgentParam(r)
+		workspace      = httpmw.WorkspaceParam(r)
+	)
+
+	shares, err := workspaceapps.PortShares(ctx, api.Database, workspace.ID, workspaceAgent)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching port shares.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, shares)
+}
+
+// @Summary Upsert workspace agent port share
+// @ID upsert-workspace-agent-port-share
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param request body codersdk.UpsertWorkspaceAgentPortShareRequest true "Port share"
+// @Success 200 {object} codersdk.WorkspaceAgentPortShare
+// @Router /workspaceagents/{workspaceagent}/port-shares [put]
+func (api *API) putWorkspaceAgentPortShare(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgentParam(r)
+		workspace      = httpmw.WorkspaceParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	var req codersdk.UpsertWorkspaceAgentPortShareRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if req.Port < codersdk.WorkspaceAgentMinimumListeningPort {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Ports below %d are used by the agent and can't be shared.", codersdk.WorkspaceAgentMinimumListeningPort),
+		})
+		return
+	}
+
+	share, err := api.Database.UpsertWorkspaceAgentPortShare(ctx, database.UpsertWorkspaceAgentPortShareParams{
+		WorkspaceID: workspace.ID,
+		AgentName:   workspaceAgent.Name,
+		Port:        int32(req.Port),
+		ShareLevel:  database.AppSharingLevel(req.ShareLevel),
+		CreatedAt:   database.Now(),
+		UpdatedAt:   database.Now(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error sharing port.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	api.publishWorkspaceAgentPortShares(ctx, workspaceAgent)
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.WorkspaceAgentPortShare{
+		Port:       uint16(share.Port),
+		ShareLevel: codersdk.WorkspaceAppSharingLevel(share.ShareLevel),
+		Source:     codersdk.WorkspaceAgentPortShareSourceOwner,
+	})
+}
+
+// @Summary Delete workspace agent port share
+// @ID delete-workspace-agent-port-share
+// @Security CoderSessionToken
+// @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param port path int true "Port"
+// @Success 204
+// @Router /workspaceagents/{workspaceagent}/port-shares/{port} [delete]
+func (api *API) deleteWorkspaceAgentPortShare(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgentParam(r)
+		workspace      = httpmw.WorkspaceParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	port, err := strconv.ParseUint(chi.URLParam(r, "port"), 10, 16)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid port.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	err = api.Database.DeleteWorkspaceAgentPortShare(ctx, database.DeleteWorkspaceAgentPortShareParams{
+		WorkspaceID: workspace.ID,
+		AgentName:   workspaceAgent.Name,
+		Port:        int32(port),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting port share.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	api.publishWorkspaceAgentPortShares(ctx, workspaceAgent)
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// publishWorkspaceAgentPortShares tells a connected agent that its shared
+// ports changed. Agents that aren't connected get the shares with their
+// metadata when they connect.
+func (api *API) publishWorkspaceAgentPortShares(ctx context.Context, workspaceAgent database.WorkspaceAgent) {
+	err := api.Pubsub.Publish(watchWorkspaceAgentPortSharesChannel(workspaceAgent.ID), []byte{})
+	if err != nil {
+		api.Logger.Warn(ctx, "failed to publish workspace agent port shares",
+			slog.F("workspace_agent_id", workspaceAgent.ID), slog.Error(err))
+	}
+}
+
+// @Summary Watch workspace agent port shares
+// @Description The current port shares are sent when the request is made,
+// @Description and again whenever they change.
+// @ID watch-workspace-agent-port-shares
+// @Security CoderSessionToken
+// @Produce text/event-stream
+// @Tags Agents
+// @Success 200 {object} codersdk.Response
+// @Router /workspaceagents/me/port-shares/watch [get]
+func (api *API) watchWorkspaceAgentPortShares(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	workspace, err := api.Database.GetWorkspaceByAgentID(ctx, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	sendEvent, senderClosed, err := httpapi.ServerSentEventSender(rw, r)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error setting up server-sent events.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Prevent handler from returning until the sender is closed.
+	defer func() {
+		<-senderClosed
+	}()
+
+	// Ignore all trace spans after this, they're not too useful.
+	ctx = trace.ContextWithSpan(ctx, tracing.NoopSpan)
+
+	sendUpdate := func(_ context.Context, _ []byte) {
+		shares, err := workspaceapps.PortShares(ctx, api.Database, workspace.ID, workspaceAgent)
+		if err != nil {
+			_ = sendEvent(ctx, codersdk.ServerSentEvent{
+				Type: codersdk.ServerSentEventTypeError,
+				Data: codersdk.Response{
+					Message: "Internal error fetching port shares.",
+					Detail:  err.Error(),
+				},
+			})
+			return
+		}
+		_ = sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeData,
+			Data: shares,
+		})
+	}
+
+	cancelSubscribe, err := api.Pubsub.Subscribe(watchWorkspaceAgentPortSharesChannel(workspaceAgent.ID), sendUpdate)
+	if err != nil {
+		_ = sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeError,
+			Data: codersdk.Response{
+				Message: "Internal error subscribing to port share events.",
+				Detail:  err.Error(),
+			},
+		})
+		return
+	}
+	defer cancelSubscribe()
+
+	// Shares may have changed between fetching the metadata and watching.
+	sendUpdate(ctx, nil)
+
+	select {
+	case <-ctx.Done():
+	case <-senderClosed:
+	}
+}
+
+func watchWorkspaceAgentPortSharesChannel(id uuid.UUID) string {
+	return fmt.Sprintf("workspace_agent_port_shares:%s", id)
+}
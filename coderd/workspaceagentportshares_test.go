@@ -0,0 +1,175 @@
+package coderd_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceAgentPortShares(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	memberClient, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							PortShares: []*proto.Agent_PortShare{{
+								Port:       8080,
+								ShareLevel: proto.AppSharingLevel_PUBLIC,
+							}},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+	agentID := resources[0].Agents[0].ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// The template restricts the ports that can be dialed.
+	shares, err := client.WorkspaceAgentPortShares(ctx, agentID)
+	require.NoError(t, err)
+	require.True(t, shares.Restricted)
+	require.Equal(t, []codersdk.WorkspaceAgentPortShare{{
+		Port:       8080,
+		ShareLevel: codersdk.WorkspaceAppSharingLevelPublic,
+		Source:     codersdk.WorkspaceAgentPortShareSourceTemplate,
+	}}, shares.Shares)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = c.Write([]byte("hello"))
+			_ = c.Close()
+		}
+	}()
+	port := uint16(l.Addr().(*net.TCPAddr).Port)
+
+	conn, err := client.DialWorkspaceAgent(ctx, agentID, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.True(t, conn.AwaitReachable(ctx))
+	dial := func() error {
+		c, err := conn.DialContext(ctx, "tcp", l.Addr().String())
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		_, err = c.Read(make([]byte, 5))
+		return err
+	}
+	require.Error(t, dial())
+
+	// Sharing a port makes the agent allow dialing it once coderd tells it
+	// about the change.
+	share, err := client.UpsertWorkspaceAgentPortShare(ctx, agentID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+		Port:       port,
+		ShareLevel: codersdk.WorkspaceAppSharingLevelAuthenticated,
+	})
+	require.NoError(t, err)
+	require.Equal(t, codersdk.WorkspaceAgentPortShareSourceOwner, share.Source)
+	require.Eventually(t, func() bool {
+		return dial() == nil
+	}, testutil.WaitShort, testutil.IntervalFast)
+
+	// Owners can override the template.
+	_, err = client.UpsertWorkspaceAgentPortShare(ctx, agentID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+		Port:       8080,
+		ShareLevel: codersdk.WorkspaceAppSharingLevelOwner,
+	})
+	require.NoError(t, err)
+	shares, err = client.WorkspaceAgentPortShares(ctx, agentID)
+	require.NoError(t, err)
+	require.Equal(t, []codersdk.WorkspaceAgentPortShare{{
+		Port:       8080,
+		ShareLevel: codersdk.WorkspaceAppSharingLevelOwner,
+		Source:     codersdk.WorkspaceAgentPortShareSourceOwner,
+	}, {
+		Port:       port,
+		ShareLevel: codersdk.WorkspaceAppSharingLevelAuthenticated,
+		Source:     codersdk.WorkspaceAgentPortShareSourceOwner,
+	}}, shares.Shares)
+
+	// Deleting a share falls back to the template.
+	err = client.DeleteWorkspaceAgentPortShare(ctx, agentID, 8080)
+	require.NoError(t, err)
+	err = client.DeleteWorkspaceAgentPortShare(ctx, agentID, port)
+	require.NoError(t, err)
+	shares, err = client.WorkspaceAgentPortShares(ctx, agentID)
+	require.NoError(t, err)
+	require.Len(t, shares.Shares, 1)
+	require.Equal(t, codersdk.WorkspaceAgentPortShareSourceTemplate, shares.Shares[0].Source)
+	require.Eventually(t, func() bool {
+		return dial() != nil
+	}, testutil.WaitShort, testutil.IntervalFast)
+
+	// Ports used by the agent can't be shared.
+	_, err = client.UpsertWorkspaceAgentPortShare(ctx, agentID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+		Port:       1,
+		ShareLevel: codersdk.WorkspaceAppSharingLevelPublic,
+	})
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+	// Only users that can update the workspace can share ports.
+	_, err = memberClient.UpsertWorkspaceAgentPortShare(ctx, agentID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+		Port:       port,
+		ShareLevel: codersdk.WorkspaceAppSharingLevelPublic,
+	})
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
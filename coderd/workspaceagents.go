@@ -12,6 +12,7 @@ import (
 	"net/netip"
 	"net/url"
 	"runtime/pprof"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -72,6 +73,15 @@ func (api *API) workspaceAgent(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	dbMetadata, err := api.Database.GetWorkspaceAgentMetadata(ctx, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agent metadata.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	apiAgent.Metadata = convertWorkspaceAgentMetadata(dbMetadata)
 
 	httpapi.Write(ctx, rw, http.StatusOK, apiAgent)
 }
@@ -137,6 +147,23 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
+	dbMetadata, err := api.Database.GetWorkspaceAgentMetadata(ctx, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agent metadata.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	portShares, err := workspaceapps.PortShares(ctx, api.Database, workspace.ID, workspaceAgent)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agent port shares.",
+			Detail:  err.Error(),
+		})
+		return
+	}
 
 	vscodeProxyURI := strings.ReplaceAll(api.AppHostname, "*",
 		fmt.Sprintf("%s://{{port}}--%s--%s--%s",
@@ -150,17 +177,21 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 	}
 
 	httpapi.Write(ctx, rw, http.StatusOK, agentsdk.Metadata{
-		Apps:                  convertApps(dbApps),
-		DERPMap:               api.DERPMap,
-		GitAuthConfigs:        len(api.GitAuthConfigs),
-		EnvironmentVariables:  apiAgent.EnvironmentVariables,
-		StartupScript:         apiAgent.StartupScript,
-		Directory:             apiAgent.Directory,
-		VSCodePortProxyURI:    vscodeProxyURI,
-		MOTDFile:              workspaceAgent.MOTDFile,
-		StartupScriptTimeout:  time.Duration(apiAgent.StartupScriptTimeoutSeconds) * time.Second,
-		ShutdownScript:        apiAgent.ShutdownScript,
-		ShutdownScriptTimeout: time.Duration(apiAgent.ShutdownScriptTimeoutSeconds) * time.Second,
+		Apps:                      convertApps(dbApps),
+		DERPMap:                   api.DERPMap,
+		GitAuthConfigs:            len(api.GitAuthConfigs),
+		EnvironmentVariables:      apiAgent.EnvironmentVariables,
+		StartupScript:             apiAgent.StartupScript,
+		Directory:                 apiAgent.Directory,
+		VSCodePortProxyURI:        vscodeProxyURI,
+		MOTDFile:                  workspaceAgent.MOTDFile,
+		StartupScriptTimeout:      time.Duration(apiAgent.StartupScriptTimeoutSeconds) * time.Second,
+		ShutdownScript:            apiAgent.ShutdownScript,
+		ShutdownScriptTimeout:     time.Duration(apiAgent.ShutdownScriptTimeoutSeconds) * time.Second,
+		Metadata:                  convertWorkspaceAgentMetadataDescriptions(dbMetadata),
+		ReconnectingPTYBufferSize: apiAgent.ReconnectingPTYBufferSize,
+		SessionRecording:          apiAgent.SessionRecording || api.DeploymentValues.SessionRecording.Value(),
+		PortShares:                portShares,
 	})
 }
 
@@ -577,6 +608,20 @@ func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		width = 80
 	}
+	var ptyOpts []codersdk.ReconnectingPTYOption
+	if r.URL.Query().Has("offset") {
+		offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+		if err != nil || offset < 0 {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Query param 'offset' must be a non-negative integer.",
+				Validations: []codersdk.ValidationError{
+					{Field: "offset", Detail: "invalid offset"},
+				},
+			})
+			return
+		}
+		ptyOpts = append(ptyOpts, codersdk.ReconnectingPTYWithOffset(offset))
+	}
 
 	conn, err := websocket.Accept(rw, r, &websocket.AcceptOptions{
 		CompressionMode: websocket.CompressionDisabled,
@@ -600,7 +645,7 @@ func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer release()
-	ptNetConn, err := agentConn.ReconnectingPTY(ctx, reconnect, uint16(height), uint16(width), r.URL.Query().Get("command"))
+	ptNetConn, err := agentConn.ReconnectingPTY(ctx, reconnect, uint16(height), uint16(width), r.URL.Query().Get("command"), ptyOpts...)
 	if err != nil {
 		_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("dial: %s", err))
 		return
@@ -710,6 +755,18 @@ func (api *API) workspaceAgentListeningPorts(rw http.ResponseWriter, r *http.Req
 		filteredPorts = append(filteredPorts, port)
 	}
 
+	shares, err := workspaceapps.PortShares(ctx, api.Database, httpmw.WorkspaceParam(r).ID, workspaceAgent)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching port shares.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	for i, port := range filteredPorts {
+		filteredPorts[i].ShareLevel, _ = shares.ShareLevel(port.Port)
+	}
+
 	portsResponse.Ports = filteredPorts
 	httpapi.Write(ctx, rw, http.StatusOK, portsResponse)
 }
@@ -1169,6 +1226,8 @@ func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordin
 		StartupScriptTimeoutSeconds:  dbAgent.StartupScriptTimeoutSeconds,
 		ShutdownScript:               dbAgent.ShutdownScript.String,
 		ShutdownScriptTimeoutSeconds: dbAgent.ShutdownScriptTimeoutSeconds,
+		ReconnectingPTYBufferSize:    dbAgent.ReconnectingPTYBufferSize,
+		SessionRecording:             dbAgent.SessionRecording,
 	}
 	node := coordinator.Node(dbAgent.ID)
 	if node != nil {
@@ -1353,6 +1412,198 @@ func (api *API) workspaceAgentReportLifecycle(rw http.ResponseWriter, r *http.Re
 	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
 }
 
+// maxWorkspaceAgentMetadataLength is the maximum length of a metadata value
+// or error, as enforced by the database.
+const maxWorkspaceAgentMetadataLength = 65535
+
+// @Summary Submit workspace agent metadata
+// @ID submit-workspace-agent-metadata
+// @Security CoderSessionToken
+// @Accept json
+// @Tags Agents
+// @Param request body agentsdk.PostMetadataRequest true "Workspace agent metadata request"
+// @Param key path string true "metadata key" format(string)
+// @Success 204 "Success"
+// @Router /workspaceagents/me/metadata/{key} [post]
+// @x-apidocgen {"skip": true}
+func (api *API) workspaceAgentPostMetadata(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req agentsdk.PostMetadataRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+	key := chi.URLParam(r, "key")
+
+	err := api.Database.UpdateWorkspaceAgentMetadata(ctx, database.UpdateWorkspaceAgentMetadataParams{
+		WorkspaceAgentID: workspaceAgent.ID,
+		Key:              key,
+		Value:            truncateWorkspaceAgentMetadata(req.Value),
+		Error:            truncateWorkspaceAgentMetadata(req.Error),
+		CollectedAt:      req.CollectedAt,
+	})
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	api.Logger.Debug(ctx, "accepted workspace agent metadata report",
+		slog.F("agent", workspaceAgent.ID),
+		slog.F("key", key),
+		slog.F("collected_at", req.CollectedAt),
+	)
+
+	err = api.Pubsub.Publish(watchWorkspaceAgentMetadataChannel(workspaceAgent.ID), []byte(key))
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// @Summary Watch for workspace agent metadata updates
+// @ID watch-for-workspace-agent-metadata-updates
+// @Security CoderSessionToken
+// @Produce text/event-stream
+// @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Success 200 {object} codersdk.Response
+// @Router /workspaceagents/{workspaceagent}/watch-metadata [get]
+func (api *API) watchWorkspaceAgentMetadata(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgentParam(r)
+	)
+
+	sendEvent, senderClosed, err := httpapi.ServerSentEventSender(rw, r)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error setting up server-sent events.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Prevent handler from returning until the sender is closed.
+	defer func() {
+		<-senderClosed
+	}()
+
+	// Ignore all trace spans after this, they're not too useful.
+	ctx = trace.ContextWithSpan(ctx, tracing.NoopSpan)
+
+	// Updates are coalesced, so a burst of reports results in a single
+	// fetch of the metadata.
+	update := make(chan struct{}, 1)
+	cancelSub, err := api.Pubsub.Subscribe(watchWorkspaceAgentMetadataChannel(workspaceAgent.ID), func(_ context.Context, _ []byte) {
+		select {
+		case update <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		_ = sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeError,
+			Data: codersdk.Response{
+				Message: "Internal error subscribing to workspace agent metadata.",
+				Detail:  err.Error(),
+			},
+		})
+		return
+	}
+	defer cancelSub()
+
+	sendMetadata := func() bool {
+		md, err := api.Database.GetWorkspaceAgentMetadata(ctx, workspaceAgent.ID)
+		if err != nil {
+			_ = sendEvent(ctx, codersdk.ServerSentEvent{
+				Type: codersdk.ServerSentEventTypeError,
+				Data: codersdk.Response{
+					Message: "Internal error fetching workspace agent metadata.",
+					Detail:  err.Error(),
+				},
+			})
+			return false
+		}
+		err = sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeData,
+			Data: convertWorkspaceAgentMetadata(md),
+		})
+		return err == nil
+	}
+
+	// An initial ping signals to the request that the server is now ready
+	// and the client can begin servicing a channel with data.
+	_ = sendEvent(ctx, codersdk.ServerSentEvent{
+		Type: codersdk.ServerSentEventTypePing,
+	})
+	if !sendMetadata() {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-senderClosed:
+			return
+		case <-update:
+			if !sendMetadata() {
+				return
+			}
+		}
+	}
+}
+
+func convertWorkspaceAgentMetadata(db []database.WorkspaceAgentMetadatum) []codersdk.WorkspaceAgentMetadata {
+	result := make([]codersdk.WorkspaceAgentMetadata, 0, len(db))
+	for _, datum := range db {
+		result = append(result, codersdk.WorkspaceAgentMetadata{
+			Result: codersdk.WorkspaceAgentMetadataResult{
+				Value:       datum.Value,
+				Error:       datum.Error,
+				CollectedAt: datum.CollectedAt,
+				Age:         int64(time.Since(datum.CollectedAt).Seconds()),
+			},
+			Description: codersdk.WorkspaceAgentMetadataDescription{
+				DisplayName: datum.DisplayName,
+				Key:         datum.Key,
+				Script:      datum.Script,
+				Interval:    datum.Interval,
+				Timeout:     datum.Timeout,
+			},
+		})
+	}
+	// Sort by key so the order is stable between updates.
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Description.Key < result[j].Description.Key
+	})
+	return result
+}
+
+func convertWorkspaceAgentMetadataDescriptions(db []database.WorkspaceAgentMetadatum) []codersdk.WorkspaceAgentMetadataDescription {
+	result := make([]codersdk.WorkspaceAgentMetadataDescription, 0, len(db))
+	for _, md := range convertWorkspaceAgentMetadata(db) {
+		result = append(result, md.Description)
+	}
+	return result
+}
+
+// truncateWorkspaceAgentMetadata trims s to the maximum length of a metadata
+// value, removing any partial UTF-8 character at the end.
+func truncateWorkspaceAgentMetadata(s string) string {
+	if len(s) <= maxWorkspaceAgentMetadataLength {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxWorkspaceAgentMetadataLength], "")
+}
+
+func watchWorkspaceAgentMetadataChannel(id uuid.UUID) string {
+	return fmt.Sprintf("workspace_agent_metadata:%s", id)
+}
+
 // @Summary Submit workspace agent application health
 // @ID submit-workspace-agent-application-health
 // @Security CoderSessionToken
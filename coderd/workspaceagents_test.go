@@ -482,7 +482,8 @@ func TestWorkspaceAgentPTY(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 	defer cancel()
 
-	conn, err := client.WorkspaceAgentReconnectingPTY(ctx, resources[0].Agents[0].ID, uuid.New(), 80, 80, "/bin/bash")
+	id := uuid.New()
+	conn, err := client.WorkspaceAgentReconnectingPTY(ctx, resources[0].Agents[0].ID, id, 80, 80, "/bin/bash")
 	require.NoError(t, err)
 	defer conn.Close()
 
@@ -526,6 +527,19 @@ func TestWorkspaceAgentPTY(t *testing.T) {
 
 	expectLine(matchEchoCommand)
 	expectLine(matchEchoOutput)
+
+	// Reconnecting from an offset replays the output after it.
+	_ = conn.Close()
+	conn, err = client.WorkspaceAgentReconnectingPTY(ctx, resources[0].Agents[0].ID, id, 80, 80, "/bin/bash", codersdk.ReconnectingPTYWithOffset(0))
+	require.NoError(t, err)
+	defer conn.Close()
+	offset, err := codersdk.ReadReconnectingPTYOffset(conn)
+	require.NoError(t, err)
+	require.Zero(t, offset)
+	bufRead = bufio.NewReader(conn)
+
+	expectLine(matchEchoCommand)
+	expectLine(matchEchoOutput)
 }
 
 func TestWorkspaceAgentListeningPorts(t *testing.T) {
@@ -1262,3 +1276,156 @@ func TestWorkspaceAgent_LifecycleState(t *testing.T) {
 		}
 	})
 }
+
+func TestWorkspaceAgent_Metadata(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Metadata: []*proto.Agent_Metadata{
+								{
+									DisplayName: "First Meta",
+									Key:         "foo1",
+									Script:      "echo hi",
+									Interval:    10,
+									Timeout:     3,
+								},
+								{
+									DisplayName: "Second Meta",
+									Key:         "foo2",
+									Script:      "echo howdy",
+									Interval:    10,
+									Timeout:     3,
+								},
+							},
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	build := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	agentID := build.Resources[0].Agents[0].ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+
+	// The agent receives the metadata definitions with its manifest.
+	manifest, err := agentClient.Metadata(ctx)
+	require.NoError(t, err)
+	require.Len(t, manifest.Metadata, 2)
+	require.Equal(t, "foo1", manifest.Metadata[0].Key)
+	require.Equal(t, "echo hi", manifest.Metadata[0].Script)
+	require.EqualValues(t, 10, manifest.Metadata[0].Interval)
+	require.EqualValues(t, 3, manifest.Metadata[0].Timeout)
+
+	updates, err := client.WatchWorkspaceAgentMetadata(ctx, agentID)
+	require.NoError(t, err)
+
+	recvUpdate := func() []codersdk.WorkspaceAgentMetadata {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("context done: %v", ctx.Err())
+		case md, ok := <-updates:
+			require.True(t, ok, "watch channel closed")
+			return md
+		}
+		return nil
+	}
+
+	// The initial update contains the definitions without results.
+	update := recvUpdate()
+	require.Len(t, update, 2)
+	require.Equal(t, "foo1", update[0].Description.Key)
+	require.Equal(t, "First Meta", update[0].Description.DisplayName)
+	require.Empty(t, update[0].Result.Value)
+	require.True(t, update[0].Result.CollectedAt.IsZero())
+
+	collectedAt := database.Now()
+	err = agentClient.PostMetadata(ctx, "foo1", agentsdk.PostMetadataRequest{
+		CollectedAt: collectedAt,
+		Value:       "bar",
+	})
+	require.NoError(t, err)
+
+	update = recvUpdate()
+	require.Equal(t, "bar", update[0].Result.Value)
+	require.Empty(t, update[0].Result.Error)
+	require.WithinDuration(t, collectedAt, update[0].Result.CollectedAt, time.Millisecond)
+	require.Empty(t, update[1].Result.Value)
+
+	err = agentClient.PostMetadata(ctx, "foo2", agentsdk.PostMetadataRequest{
+		CollectedAt: database.Now(),
+		Error:       "exit status 1",
+	})
+	require.NoError(t, err)
+
+	update = recvUpdate()
+	require.Equal(t, "exit status 1", update[1].Result.Error)
+
+	// The latest results are included when fetching the agent.
+	agent, err := client.WorkspaceAgent(ctx, agentID)
+	require.NoError(t, err)
+	require.Len(t, agent.Metadata, 2)
+	require.Equal(t, "bar", agent.Metadata[0].Result.Value)
+	require.Equal(t, "exit status 1", agent.Metadata[1].Result.Error)
+
+	// Values that exceed the maximum length are truncated.
+	err = agentClient.PostMetadata(ctx, "foo1", agentsdk.PostMetadataRequest{
+		CollectedAt: database.Now(),
+		Value:       strings.Repeat("a", 70000),
+	})
+	require.NoError(t, err)
+	update = recvUpdate()
+	require.Len(t, update[0].Result.Value, 65535)
+}
+
+func TestWorkspaceAgentRateLimitUnauthenticated(t *testing.T) {
+	t.Parallel()
+
+	const limit = 3
+	client := coderdtest.New(t, &coderdtest.Options{
+		APIRateLimit: limit,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// Requests with an invalid agent token are limited before the token is
+	// looked up.
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(uuid.NewString())
+	for i := 0; i < limit; i++ {
+		res, err := agentClient.Request(ctx, http.MethodGet, "/api/v2/workspaceagents/me/metadata", nil)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	}
+	res, err := agentClient.Request(ctx, http.MethodGet, "/api/v2/workspaceagents/me/metadata", nil)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+}
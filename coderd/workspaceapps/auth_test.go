@@ -107,6 +107,10 @@ func Test_ResolveRequest(t *testing.T) {
 								Auth: &proto.Agent_Token{
 									Token: agentAuthToken,
 								},
+								PortShares: []*proto.Agent_PortShare{{
+									Port:       9091,
+									ShareLevel: proto.AppSharingLevel_AUTHENTICATED,
+								}},
 								Apps: []*proto.App{
 									{
 										Slug:         appNameOwner,
@@ -560,6 +564,53 @@ func Test_ResolveRequest(t *testing.T) {
 		require.Equal(t, "http://127.0.0.1:9090", ticket.AppURL)
 	})
 
+	t.Run("PortSubdomainShared", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitMedium)
+		defer cancel()
+		_, err := client.UpsertWorkspaceAgentPortShare(ctx, agentID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+			Port:       9092,
+			ShareLevel: codersdk.WorkspaceAppSharingLevelPublic,
+		})
+		require.NoError(t, err)
+
+		cases := []struct {
+			name  string
+			port  string
+			token string
+			ok    bool
+		}{
+			// Shared by the template.
+			{name: "AuthenticatedOtherUser", port: "9091", token: secondUserClient.SessionToken(), ok: true},
+			{name: "Unauthenticated", port: "9091", ok: false},
+			// Shared by the owner.
+			{name: "PublicUnauthenticated", port: "9092", ok: true},
+			// Not shared.
+			{name: "NotShared", port: "9090", token: secondUserClient.SessionToken(), ok: false},
+		}
+		for _, c := range cases {
+			req := workspaceapps.Request{
+				AccessMethod:      workspaceapps.AccessMethodSubdomain,
+				BasePath:          "/",
+				UsernameOrID:      me.Username,
+				WorkspaceNameOrID: workspace.Name,
+				AgentNameOrID:     agentName,
+				AppSlugOrPort:     c.port,
+			}
+
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			if c.token != "" {
+				r.Header.Set(codersdk.SessionTokenHeader, c.token)
+			}
+
+			_, ok := api.WorkspaceAppsProvider.ResolveRequest(rw, r, req)
+			_ = rw.Result().Body.Close()
+			require.Equal(t, c.ok, ok, c.name)
+		}
+	})
+
 	t.Run("Terminal", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,56 @@
+package workspaceapps
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+)
+
+// PortShares returns the ports shared on a workspace agent. Ports the owner
+// of the workspace shared override the port sharing rules of the template.
+func PortShares(ctx context.Context, db database.Store, workspaceID uuid.UUID, agent database.WorkspaceAgent) (codersdk.WorkspaceAgentPortShares, error) {
+	rules, err := db.GetWorkspaceAgentPortShareRules(ctx, agent.ID)
+	if err != nil {
+		return codersdk.WorkspaceAgentPortShares{}, xerrors.Errorf("get port share rules: %w", err)
+	}
+	ownerShares, err := db.GetWorkspaceAgentPortShares(ctx, database.GetWorkspaceAgentPortSharesParams{
+		WorkspaceID: workspaceID,
+		AgentName:   agent.Name,
+	})
+	if err != nil {
+		return codersdk.WorkspaceAgentPortShares{}, xerrors.Errorf("get port shares: %w", err)
+	}
+
+	shares := make(map[uint16]codersdk.WorkspaceAgentPortShare, len(rules)+len(ownerShares))
+	for _, rule := range rules {
+		shares[uint16(rule.Port)] = codersdk.WorkspaceAgentPortShare{
+			Port:       uint16(rule.Port),
+			ShareLevel: codersdk.WorkspaceAppSharingLevel(rule.ShareLevel),
+			Source:     codersdk.WorkspaceAgentPortShareSourceTemplate,
+		}
+	}
+	for _, share := range ownerShares {
+		shares[uint16(share.Port)] = codersdk.WorkspaceAgentPortShare{
+			Port:       uint16(share.Port),
+			ShareLevel: codersdk.WorkspaceAppSharingLevel(share.ShareLevel),
+			Source:     codersdk.WorkspaceAgentPortShareSourceOwner,
+		}
+	}
+
+	resp := codersdk.WorkspaceAgentPortShares{
+		Restricted: len(rules) > 0,
+		Shares:     make([]codersdk.WorkspaceAgentPortShare, 0, len(shares)),
+	}
+	for _, share := range shares {
+		resp.Shares = append(resp.Shares, share)
+	}
+	slices.SortFunc(resp.Shares, func(a, b codersdk.WorkspaceAgentPortShare) bool {
+		return a.Port < b.Port
+	})
+	return resp, nil
+}
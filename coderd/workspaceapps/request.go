@@ -224,9 +224,9 @@ func (r Request) getDatabase(ctx context.Context, db database.Store) (*databaseR
 		// If the app slug is a port number, then route to the port as an
 		// "anonymous app". We only support HTTP for port-based URLs.
 		//
-		// This is only supported for subdomain-based applications.
+		// This is only supported for subdomain-based applications. The
+		// sharing level of the port is determined once the agent is known.
 		appURL = fmt.Sprintf("http://127.0.0.1:%d", portUint)
-		appSharingLevel = database.AppSharingLevelOwner
 	} else {
 		for _, app := range apps {
 			if app.Slug == r.AppSlugOrPort {
@@ -276,6 +276,15 @@ func (r Request) getDatabase(ctx context.Context, db database.Store) (*databaseR
 		}
 	}
 
+	if portUintErr == nil {
+		shares, err := PortShares(ctx, db, workspace.ID, agent)
+		if err != nil {
+			return nil, xerrors.Errorf("get port shares: %w", err)
+		}
+		shareLevel, _ := shares.ShareLevel(uint16(portUint))
+		appSharingLevel = database.AppSharingLevel(shareLevel)
+	}
+
 	return &databaseRequest{
 		Request:         r,
 		User:            user,
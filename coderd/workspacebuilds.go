@@ -331,102 +331,166 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	workspaceBuild, provisionerJob, err := api.createWorkspaceBuild(ctx, func(action rbac.Action, object rbac.Objecter) bool {
+		return api.Authorize(r, action, object)
+	}, workspace, apiKey.UserID, createBuild)
+	if err != nil {
+		writeWorkspaceBuildError(ctx, rw, err)
+		return
+	}
+
+	users, err := api.Database.GetUsersByIDs(ctx, []uuid.UUID{
+		workspace.OwnerID,
+		workspaceBuild.InitiatorID,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error getting user.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	apiBuild, err := api.convertWorkspaceBuild(
+		workspaceBuild,
+		workspace,
+		provisionerJob,
+		users,
+		[]database.WorkspaceResource{},
+		[]database.WorkspaceResourceMetadatum{},
+		[]database.WorkspaceAgent{},
+		[]database.WorkspaceApp{},
+		database.TemplateVersion{},
+	)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	api.publishWorkspaceUpdate(ctx, workspace.ID)
+
+	httpapi.Write(ctx, rw, http.StatusCreated, apiBuild)
+}
+
+// workspaceBuildError is returned by createWorkspaceBuild with the response
+// that explains why the build couldn't be created.
+type workspaceBuildError struct {
+	status   int
+	response codersdk.Response
+}
+
+func (e *workspaceBuildError) Error() string {
+	if e.response.Detail == "" {
+		return e.response.Message
+	}
+	return e.response.Message + " " + e.response.Detail
+}
+
+func writeWorkspaceBuildError(ctx context.Context, rw http.ResponseWriter, err error) {
+	var buildErr *workspaceBuildError
+	if xerrors.As(err, &buildErr) {
+		httpapi.Write(ctx, rw, buildErr.status, buildErr.response)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		Message: "Internal error creating workspace build.",
+		Detail:  err.Error(),
+	})
+}
+
+// createWorkspaceBuild validates the build request and inserts the build and
+// its provisioner job. Parameters that aren't in the request are taken from
+// the prior build, or migrated from legacy parameters. authorize checks an
+// action against the initiator of the build. Errors are *workspaceBuildError.
+// nolint:gocyclo
+func (api *API) createWorkspaceBuild(ctx context.Context, authorize func(rbac.Action, rbac.Objecter) bool, workspace database.Workspace, initiatorID uuid.UUID, createBuild codersdk.CreateWorkspaceBuildRequest) (database.WorkspaceBuild, database.ProvisionerJob, error) {
 	if createBuild.TemplateVersionID == uuid.Nil {
 		latestBuild, latestBuildErr := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
 		if latestBuildErr != nil {
-			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusInternalServerError, codersdk.Response{
 				Message: "Internal error fetching the latest workspace build.",
 				Detail:  latestBuildErr.Error(),
-			})
-			return
+			}}
 		}
 		createBuild.TemplateVersionID = latestBuild.TemplateVersionID
 	}
 
 	templateVersion, err := api.Database.GetTemplateVersionByID(ctx, createBuild.TemplateVersionID)
 	if errors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusBadRequest, codersdk.Response{
 			Message: "Template version not found.",
 			Validations: []codersdk.ValidationError{{
 				Field:  "template_version_id",
 				Detail: "template version not found",
 			}},
-		})
-		return
+		}}
 	}
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching template version.",
 			Detail:  err.Error(),
-		})
-		return
+		}}
 	}
 
 	template, err := api.Database.GetTemplateByID(ctx, templateVersion.TemplateID.UUID)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusInternalServerError, codersdk.Response{
 			Message: "Failed to get template",
 			Detail:  err.Error(),
-		})
-		return
+		}}
 	}
 
 	var state []byte
 	// If custom state, deny request since user could be corrupting or leaking
 	// cloud state.
 	if createBuild.ProvisionerState != nil || createBuild.Orphan {
-		if !api.Authorize(r, rbac.ActionUpdate, template.RBACObject()) {
-			httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+		if !authorize(rbac.ActionUpdate, template.RBACObject()) {
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusForbidden, codersdk.Response{
 				Message: "Only template managers may provide custom state",
-			})
-			return
+			}}
 		}
 		state = createBuild.ProvisionerState
 	}
 
 	if createBuild.Orphan {
 		if createBuild.Transition != codersdk.WorkspaceTransitionDelete {
-			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusBadRequest, codersdk.Response{
 				Message: "Orphan is only permitted when deleting a workspace.",
-				Detail:  err.Error(),
-			})
-			return
+			}}
 		}
 
 		if createBuild.ProvisionerState != nil && createBuild.Orphan {
-			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusBadRequest, codersdk.Response{
 				Message: "ProvisionerState cannot be set alongside Orphan since state intent is unclear.",
-			})
-			return
+			}}
 		}
 		state = []byte{}
 	}
 
 	templateVersionJob, err := api.Database.GetProvisionerJobByID(ctx, templateVersion.JobID)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching provisioner job.",
 			Detail:  err.Error(),
-		})
-		return
+		}}
 	}
 	templateVersionJobStatus := convertProvisionerJob(templateVersionJob).Status
 	switch templateVersionJobStatus {
 	case codersdk.ProvisionerJobPending, codersdk.ProvisionerJobRunning:
-		httpapi.Write(ctx, rw, http.StatusNotAcceptable, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusNotAcceptable, codersdk.Response{
 			Message: fmt.Sprintf("The provided template version is %s. Wait for it to complete importing!", templateVersionJobStatus),
-		})
-		return
+		}}
 	case codersdk.ProvisionerJobFailed:
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusBadRequest, codersdk.Response{
 			Message: fmt.Sprintf("The provided template version %q has failed to import: %q. You cannot build workspaces with it!", templateVersion.Name, templateVersionJob.Error.String),
-		})
-		return
+		}}
 	case codersdk.ProvisionerJobCanceled:
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusBadRequest, codersdk.Response{
 			Message: "The provided template version was canceled during import. You cannot builds workspaces with it!",
-		})
-		return
+		}}
 	}
 
 	tags := provisionerdserver.MutateTags(workspace.OwnerID, templateVersionJob.Tags)
@@ -437,19 +501,17 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 	if err == nil {
 		priorJob, err := api.Database.GetProvisionerJobByID(ctx, priorHistory.JobID)
 		if err == nil && convertProvisionerJob(priorJob).Status.Active() {
-			httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusConflict, codersdk.Response{
 				Message: "A workspace build is already active.",
-			})
-			return
+			}}
 		}
 
 		priorBuildNum = priorHistory.BuildNumber
 	} else if !errors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching prior workspace build.",
 			Detail:  err.Error(),
-		})
-		return
+		}}
 	}
 
 	if state == nil {
@@ -458,28 +520,25 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 
 	dbTemplateVersionParameters, err := api.Database.GetTemplateVersionParameters(ctx, createBuild.TemplateVersionID)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching template version parameters.",
 			Detail:  err.Error(),
-		})
-		return
+		}}
 	}
 	templateVersionParameters, err := convertTemplateVersionParameters(dbTemplateVersionParameters)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error converting template version parameters.",
 			Detail:  err.Error(),
-		})
-		return
+		}}
 	}
 
 	lastBuildParameters, err := api.Database.GetWorkspaceBuildParameters(ctx, priorHistory.ID)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching prior workspace build parameters.",
 			Detail:  err.Error(),
-		})
-		return
+		}}
 	}
 	apiLastBuildParameters := convertWorkspaceBuildParameters(lastBuildParameters)
 
@@ -488,11 +547,10 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		ScopeIds: []uuid.UUID{workspace.ID},
 	})
 	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusInternalServerError, codersdk.Response{
 			Message: "Error fetching previous legacy parameters.",
 			Detail:  err.Error(),
-		})
-		return
+		}}
 	}
 
 	// Rich parameters migration: include legacy variables to the last build parameters
@@ -518,11 +576,10 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 
 	err = codersdk.ValidateWorkspaceBuildParameters(templateVersionParameters, createBuild.RichParameterValues, apiLastBuildParameters)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusBadRequest, codersdk.Response{
 			Message: "Error validating workspace build parameters.",
 			Detail:  err.Error(),
-		})
-		return
+		}}
 	}
 
 	var parameters []codersdk.WorkspaceBuildParameter
@@ -530,10 +587,9 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		// Check if parameter value is in request
 		if buildParameter, found := findWorkspaceBuildParameter(createBuild.RichParameterValues, templateVersionParameter.Name); found {
 			if !templateVersionParameter.Mutable {
-				httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusBadRequest, codersdk.Response{
 					Message: fmt.Sprintf("Parameter %q is not mutable, so it can't be updated after creating a workspace.", templateVersionParameter.Name),
-				})
-				return
+				}}
 			}
 			parameters = append(parameters, *buildParameter)
 			continue
@@ -545,11 +601,10 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if createBuild.LogLevel != "" && !api.Authorize(r, rbac.ActionUpdate, template) {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+	if createBuild.LogLevel != "" && !authorize(rbac.ActionUpdate, template) {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusBadRequest, codersdk.Response{
 			Message: "Workspace builds with a custom log level are restricted to template authors only.",
-		})
-		return
+		}}
 	}
 
 	var workspaceBuild database.WorkspaceBuild
@@ -598,7 +653,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 			ID:             uuid.New(),
 			CreatedAt:      database.Now(),
 			UpdatedAt:      database.Now(),
-			InitiatorID:    apiKey.UserID,
+			InitiatorID:    initiatorID,
 			OrganizationID: template.OrganizationID,
 			Provisioner:    template.Provisioner,
 			Type:           database.ProvisionerJobTypeWorkspaceBuild,
@@ -606,6 +661,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 			FileID:         templateVersionJob.FileID,
 			Input:          input,
 			Tags:           tags,
+			Priority:       provisionerdserver.WorkspaceBuildPriority(database.WorkspaceTransition(createBuild.Transition)),
 		})
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
@@ -619,7 +675,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 			TemplateVersionID: templateVersion.ID,
 			BuildNumber:       priorBuildNum + 1,
 			ProvisionerState:  state,
-			InitiatorID:       apiKey.UserID,
+			InitiatorID:       initiatorID,
 			Transition:        database.WorkspaceTransition(createBuild.Transition),
 			JobID:             provisionerJob.ID,
 			Reason:            database.BuildReasonInitiator,
@@ -646,47 +702,12 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		return nil
 	}, nil)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &workspaceBuildError{http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error inserting workspace build.",
 			Detail:  err.Error(),
-		})
-		return
-	}
-
-	users, err := api.Database.GetUsersByIDs(ctx, []uuid.UUID{
-		workspace.OwnerID,
-		workspaceBuild.InitiatorID,
-	})
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error getting user.",
-			Detail:  err.Error(),
-		})
-		return
-	}
-
-	apiBuild, err := api.convertWorkspaceBuild(
-		workspaceBuild,
-		workspace,
-		provisionerJob,
-		users,
-		[]database.WorkspaceResource{},
-		[]database.WorkspaceResourceMetadatum{},
-		[]database.WorkspaceAgent{},
-		[]database.WorkspaceApp{},
-		database.TemplateVersion{},
-	)
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error converting workspace build.",
-			Detail:  err.Error(),
-		})
-		return
+		}}
 	}
-
-	api.publishWorkspaceUpdate(ctx, workspace.ID)
-
-	httpapi.Write(ctx, rw, http.StatusCreated, apiBuild)
+	return workspaceBuild, provisionerJob, nil
 }
 
 // @Summary Cancel workspace build
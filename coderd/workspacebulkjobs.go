@@ -0,0 +1,618 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/searchquery"
+	"github.com/coder/coder/codersdk"
+)
+
+const (
+	defaultWorkspaceBulkJobBatchSize = 10
+	// workspaceBulkJobPollInterval is how often the builds of a batch are
+	// checked for completion.
+	workspaceBulkJobPollInterval = time.Second
+	// workspaceBulkJobHeartbeatInterval is how often a running bulk job
+	// updates its updated_at column to show that it's still being run.
+	workspaceBulkJobHeartbeatInterval = 30 * time.Second
+	// workspaceBulkJobStaleTimeout is how long a running bulk job can go
+	// without a heartbeat before it's reaped as failed.
+	workspaceBulkJobStaleTimeout = 5 * time.Minute
+)
+
+// @Summary Create workspace bulk job
+// @Description Creates builds for every workspace matching the filter that
+// @Description the user is allowed to perform the action on. Builds are
+// @Description created in batches, and the next batch is started once every
+// @Description build in the current batch has completed.
+// @ID create-workspace-bulk-job
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Workspaces
+// @Param request body codersdk.CreateWorkspaceBulkJobRequest true "Create workspace bulk job request"
+// @Success 200 {object} codersdk.WorkspaceBulkJob "Dry run"
+// @Success 201 {object} codersdk.WorkspaceBulkJob
+// @Router /workspaces/bulk [post]
+func (api *API) postWorkspaceBulkJob(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx    = r.Context()
+		apiKey = httpmw.APIKey(r)
+		req    codersdk.CreateWorkspaceBulkJobRequest
+	)
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if req.BatchSize == 0 {
+		req.BatchSize = defaultWorkspaceBulkJobBatchSize
+	}
+
+	filter, errs := searchquery.Workspaces(req.Filter, codersdk.Pagination{}, api.AgentInactiveDisconnectTimeout)
+	if len(errs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid workspace search query.",
+			Validations: errs,
+		})
+		return
+	}
+	if filter.OwnerUsername == "me" {
+		filter.OwnerID = apiKey.UserID
+		filter.OwnerUsername = ""
+	}
+
+	// Only include workspaces the user is allowed to build, so the job
+	// doesn't fail for workspaces they can merely see.
+	action := rbac.ActionUpdate
+	if req.Action == codersdk.WorkspaceBulkJobActionDelete {
+		action = rbac.ActionDelete
+	}
+	prepared, err := api.HTTPAuth.AuthorizeSQLFilter(r, action, rbac.ResourceWorkspace.Type)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error preparing sql filter.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	workspaceRows, err := api.Database.GetAuthorizedWorkspaces(ctx, filter, prepared)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspaces.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	workspaces := database.ConvertWorkspaceRows(workspaceRows)
+
+	now := database.Now()
+	job := database.WorkspaceBulkJob{
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		InitiatorID: apiKey.UserID,
+		Action:      database.WorkspaceBulkJobAction(req.Action),
+		Filter:      req.Filter,
+		BatchSize:   int32(req.BatchSize),
+		Status:      database.WorkspaceBulkJobStatusPending,
+	}
+	rows := make([]database.GetWorkspaceBulkJobWorkspacesRow, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		skip, err := api.skipWorkspaceBulkJobWorkspace(ctx, job.Action, workspace)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error checking workspace state.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		row := database.GetWorkspaceBulkJobWorkspacesRow{
+			WorkspaceID:   workspace.ID,
+			UpdatedAt:     now,
+			Status:        database.WorkspaceBulkJobStatusPending,
+			WorkspaceName: workspace.Name,
+		}
+		if skip {
+			row.Status = database.WorkspaceBulkJobStatusSkipped
+		}
+		rows = append(rows, row)
+	}
+	// Fill in the owner names for the response.
+	ownerIDs := make([]uuid.UUID, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		ownerIDs = append(ownerIDs, workspace.OwnerID)
+	}
+	owners, err := api.Database.GetUsersByIDs(ctx, ownerIDs)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace owners.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	for i, workspace := range workspaces {
+		for _, owner := range owners {
+			if owner.ID == workspace.OwnerID {
+				rows[i].OwnerUsername = owner.Username
+				break
+			}
+		}
+	}
+
+	if req.DryRun {
+		httpapi.Write(ctx, rw, http.StatusOK, convertWorkspaceBulkJob(job, rows))
+		return
+	}
+
+	job.ID = uuid.New()
+	job.Status = database.WorkspaceBulkJobStatusRunning
+	//nolint:gocritic // The workspaces were authorized above, and the builds
+	// are authorized as the initiator when they're created.
+	sysCtx := dbauthz.AsSystemRestricted(ctx)
+	err = api.Database.InTx(func(db database.Store) error {
+		job, err = db.InsertWorkspaceBulkJob(sysCtx, database.InsertWorkspaceBulkJobParams{
+			ID:          job.ID,
+			CreatedAt:   job.CreatedAt,
+			UpdatedAt:   job.UpdatedAt,
+			InitiatorID: job.InitiatorID,
+			Action:      job.Action,
+			Filter:      job.Filter,
+			BatchSize:   job.BatchSize,
+			Status:      job.Status,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert bulk job: %w", err)
+		}
+		for i, row := range rows {
+			rows[i].BulkJobID = job.ID
+			_, err = db.InsertWorkspaceBulkJobWorkspace(sysCtx, database.InsertWorkspaceBulkJobWorkspaceParams{
+				BulkJobID:   job.ID,
+				WorkspaceID: row.WorkspaceID,
+				UpdatedAt:   row.UpdatedAt,
+				Status:      row.Status,
+			})
+			if err != nil {
+				return xerrors.Errorf("insert bulk job workspace: %w", err)
+			}
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error inserting workspace bulk job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	workspaceIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		if row.Status == database.WorkspaceBulkJobStatusPending {
+			workspaceIDs = append(workspaceIDs, row.WorkspaceID)
+		}
+	}
+	actor := httpmw.UserAuthorization(r).Actor
+	api.workspaceBulkJobsWaitGroup.Add(1)
+	go func() {
+		defer api.workspaceBulkJobsWaitGroup.Done()
+		api.runWorkspaceBulkJob(actor, job, workspaceIDs)
+	}()
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertWorkspaceBulkJob(job, rows))
+}
+
+// @Summary Get workspace bulk job
+// @ID get-workspace-bulk-job
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Workspaces
+// @Param workspacebulkjob path string true "Workspace bulk job ID" format(uuid)
+// @Success 200 {object} codersdk.WorkspaceBulkJob
+// @Router /workspaces/bulk/{workspacebulkjob} [get]
+func (api *API) workspaceBulkJob(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx    = r.Context()
+		apiKey = httpmw.APIKey(r)
+	)
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "workspacebulkjob"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Workspace bulk job id must be a valid UUID.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Bulk jobs are only visible to their initiator.
+	//nolint:gocritic // The initiator is checked below.
+	sysCtx := dbauthz.AsSystemRestricted(ctx)
+	job, err := api.Database.GetWorkspaceBulkJobByID(sysCtx, jobID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && job.InitiatorID != apiKey.UserID) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace bulk job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	rows, err := api.Database.GetWorkspaceBulkJobWorkspaces(sysCtx, job.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace bulk job workspaces.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertWorkspaceBulkJob(job, rows))
+}
+
+// skipWorkspaceBulkJobWorkspace returns whether the workspace is already in
+// the state the action would put it in.
+func (api *API) skipWorkspaceBulkJobWorkspace(ctx context.Context, action database.WorkspaceBulkJobAction, workspace database.Workspace) (bool, error) {
+	build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return false, xerrors.Errorf("get latest workspace build: %w", err)
+	}
+	job, err := api.Database.GetProvisionerJobByID(ctx, build.JobID)
+	if err != nil {
+		return false, xerrors.Errorf("get provisioner job: %w", err)
+	}
+	status := convertProvisionerJob(job).Status
+	failed := status == codersdk.ProvisionerJobFailed || status == codersdk.ProvisionerJobCanceled
+
+	switch action {
+	case database.WorkspaceBulkJobActionStart:
+		return build.Transition == database.WorkspaceTransitionStart && !failed, nil
+	case database.WorkspaceBulkJobActionStop:
+		return build.Transition == database.WorkspaceTransitionStop && !failed, nil
+	case database.WorkspaceBulkJobActionUpdate:
+		template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+		if err != nil {
+			return false, xerrors.Errorf("get template: %w", err)
+		}
+		return build.TemplateVersionID == template.ActiveVersionID, nil
+	default:
+		return false, nil
+	}
+}
+
+// runWorkspaceBulkJob creates the builds of a bulk job in batches, and waits
+// for every build in a batch to complete before starting the next one.
+func (api *API) runWorkspaceBulkJob(actor rbac.Subject, job database.WorkspaceBulkJob, workspaceIDs []uuid.UUID) {
+	var (
+		ctx    = dbauthz.As(api.ctx, actor)
+		logger = api.Logger.Named("workspace_bulk_job").With(slog.F("bulk_job_id", job.ID), slog.F("action", job.Action))
+		failed bool
+	)
+	//nolint:gocritic // Progress is recorded on behalf of the initiator.
+	sysCtx := dbauthz.AsSystemRestricted(api.ctx)
+	updateWorkspace := func(workspaceID uuid.UUID, buildID uuid.NullUUID, status database.WorkspaceBulkJobStatus, buildErr string) {
+		if status == database.WorkspaceBulkJobStatusFailed {
+			failed = true
+		}
+		err := api.Database.UpdateWorkspaceBulkJobWorkspace(sysCtx, database.UpdateWorkspaceBulkJobWorkspaceParams{
+			BulkJobID:        job.ID,
+			WorkspaceID:      workspaceID,
+			UpdatedAt:        database.Now(),
+			WorkspaceBuildID: buildID,
+			Status:           status,
+			Error:            buildErr,
+		})
+		if err != nil {
+			logger.Warn(ctx, "update bulk job workspace", slog.F("workspace_id", workspaceID), slog.Error(err))
+		}
+	}
+
+	// Bulk jobs run in the replica that created them. The heartbeat keeps
+	// other replicas from reaping the job while it's running.
+	lastHeartbeat := time.Now()
+	heartbeat := func() {
+		lastHeartbeat = time.Now()
+		err := api.Database.UpdateWorkspaceBulkJobByID(sysCtx, database.UpdateWorkspaceBulkJobByIDParams{
+			ID:        job.ID,
+			UpdatedAt: database.Now(),
+			Status:    database.WorkspaceBulkJobStatusRunning,
+		})
+		if err != nil {
+			logger.Warn(ctx, "update bulk job heartbeat", slog.Error(err))
+		}
+	}
+
+	logger.Info(ctx, "starting workspace bulk job", slog.F("workspaces", len(workspaceIDs)))
+	for start := 0; start < len(workspaceIDs) && ctx.Err() == nil; start += int(job.BatchSize) {
+		end := start + int(job.BatchSize)
+		if end > len(workspaceIDs) {
+			end = len(workspaceIDs)
+		}
+
+		// Maps workspace IDs to their builds in this batch.
+		running := map[uuid.UUID]database.WorkspaceBuild{}
+		for _, workspaceID := range workspaceIDs[start:end] {
+			build, err := api.createWorkspaceBulkJobBuild(ctx, actor, job, workspaceID)
+			if err != nil {
+				updateWorkspace(workspaceID, uuid.NullUUID{}, database.WorkspaceBulkJobStatusFailed, err.Error())
+				continue
+			}
+			updateWorkspace(workspaceID, uuid.NullUUID{UUID: build.ID, Valid: true}, database.WorkspaceBulkJobStatusRunning, "")
+			api.publishWorkspaceUpdate(ctx, workspaceID)
+			running[workspaceID] = build
+		}
+
+		ticker := time.NewTicker(workspaceBulkJobPollInterval)
+		for len(running) > 0 && ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case <-ticker.C:
+			}
+			if time.Since(lastHeartbeat) >= workspaceBulkJobHeartbeatInterval {
+				heartbeat()
+			}
+			for workspaceID, build := range running {
+				provisionerJob, err := api.Database.GetProvisionerJobByID(sysCtx, build.JobID)
+				if err != nil {
+					logger.Warn(ctx, "get provisioner job", slog.F("job_id", build.JobID), slog.Error(err))
+					continue
+				}
+				status, buildErr, done := workspaceBulkJobBuildStatus(provisionerJob)
+				if !done {
+					continue
+				}
+				updateWorkspace(workspaceID, uuid.NullUUID{UUID: build.ID, Valid: true}, status, buildErr)
+				delete(running, workspaceID)
+			}
+		}
+		ticker.Stop()
+	}
+
+	status := database.WorkspaceBulkJobStatusSucceeded
+	var jobErr string
+	if failed {
+		status = database.WorkspaceBulkJobStatusFailed
+		jobErr = "One or more workspaces failed to build."
+	}
+	if api.ctx.Err() != nil {
+		// The builds that were created continue, but the remaining batches
+		// are abandoned.
+		status = database.WorkspaceBulkJobStatusFailed
+		jobErr = "The server shut down before the bulk job completed."
+		//nolint:gocritic // Same as above, with a context that isn't canceled.
+		sysCtx = dbauthz.AsSystemRestricted(context.Background())
+	}
+	now := database.Now()
+	if api.ctx.Err() != nil {
+		err := finishWorkspaceBulkJobWorkspaces(sysCtx, api.Database, job.ID, jobErr)
+		if err != nil {
+			logger.Error(sysCtx, "finish bulk job workspaces", slog.Error(err))
+		}
+	}
+	err := api.Database.UpdateWorkspaceBulkJobByID(sysCtx, database.UpdateWorkspaceBulkJobByIDParams{
+		ID:          job.ID,
+		UpdatedAt:   now,
+		CompletedAt: sql.NullTime{Time: now, Valid: true},
+		Status:      status,
+		Error:       jobErr,
+	})
+	if err != nil {
+		logger.Error(sysCtx, "update bulk job", slog.Error(err))
+		return
+	}
+	logger.Info(sysCtx, "completed workspace bulk job", slog.F("status", status))
+}
+
+// reapStaleWorkspaceBulkJobs periodically fails bulk jobs that are running
+// but haven't had a heartbeat within workspaceBulkJobStaleTimeout, because the
+// replica running them shut down or crashed. Builds that were already created
+// continue, but the workspaces of the job are given a final status.
+func (api *API) reapStaleWorkspaceBulkJobs() {
+	defer api.workspaceBulkJobsWaitGroup.Done()
+	//nolint:gocritic // Reaping is done by the system.
+	ctx := dbauthz.AsSystemRestricted(api.ctx)
+	logger := api.Logger.Named("workspace_bulk_job_reaper")
+
+	ticker := time.NewTicker(workspaceBulkJobStaleTimeout / 5)
+	defer ticker.Stop()
+	for {
+		err := api.reapStaleWorkspaceBulkJobsOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error(ctx, "reap stale workspace bulk jobs", slog.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (api *API) reapStaleWorkspaceBulkJobsOnce(ctx context.Context) error {
+	const jobErr = "The server running the bulk job stopped before it completed."
+	jobs, err := api.Database.GetStaleWorkspaceBulkJobs(ctx, database.Now().Add(-workspaceBulkJobStaleTimeout))
+	if err != nil {
+		return xerrors.Errorf("get stale bulk jobs: %w", err)
+	}
+	for _, job := range jobs {
+		err = api.Database.InTx(func(tx database.Store) error {
+			err := finishWorkspaceBulkJobWorkspaces(ctx, tx, job.ID, jobErr)
+			if err != nil {
+				return err
+			}
+			now := database.Now()
+			return tx.UpdateWorkspaceBulkJobByID(ctx, database.UpdateWorkspaceBulkJobByIDParams{
+				ID:          job.ID,
+				UpdatedAt:   now,
+				CompletedAt: sql.NullTime{Time: now, Valid: true},
+				Status:      database.WorkspaceBulkJobStatusFailed,
+				Error:       jobErr,
+			})
+		}, nil)
+		if err != nil {
+			return xerrors.Errorf("reap bulk job %s: %w", job.ID, err)
+		}
+		api.Logger.Info(ctx, "reaped stale workspace bulk job", slog.F("bulk_job_id", job.ID))
+	}
+	return nil
+}
+
+// finishWorkspaceBulkJobWorkspaces sets the final status of the workspaces
+// of a bulk job that stopped before they completed. Pending workspaces fail
+// with jobErr. Running workspaces take the status of their build, or fail
+// with jobErr if it's still in progress, since nothing polls it anymore.
+func finishWorkspaceBulkJobWorkspaces(ctx context.Context, db database.Store, jobID uuid.UUID, jobErr string) error {
+	rows, err := db.GetWorkspaceBulkJobWorkspaces(ctx, jobID)
+	if err != nil {
+		return xerrors.Errorf("get bulk job workspaces: %w", err)
+	}
+	for _, row := range rows {
+		status, rowErr := database.WorkspaceBulkJobStatusFailed, jobErr
+		switch row.Status {
+		case database.WorkspaceBulkJobStatusPending:
+		case database.WorkspaceBulkJobStatusRunning:
+			if row.WorkspaceBuildID.Valid {
+				build, err := db.GetWorkspaceBuildByID(ctx, row.WorkspaceBuildID.UUID)
+				if err != nil {
+					return xerrors.Errorf("get workspace build: %w", err)
+				}
+				provisionerJob, err := db.GetProvisionerJobByID(ctx, build.JobID)
+				if err != nil {
+					return xerrors.Errorf("get provisioner job: %w", err)
+				}
+				if buildStatus, buildErr, done := workspaceBulkJobBuildStatus(provisionerJob); done {
+					status, rowErr = buildStatus, buildErr
+				}
+			}
+		default:
+			continue
+		}
+		err = db.UpdateWorkspaceBulkJobWorkspace(ctx, database.UpdateWorkspaceBulkJobWorkspaceParams{
+			BulkJobID:        jobID,
+			WorkspaceID:      row.WorkspaceID,
+			UpdatedAt:        database.Now(),
+			WorkspaceBuildID: row.WorkspaceBuildID,
+			Status:           status,
+			Error:            rowErr,
+		})
+		if err != nil {
+			return xerrors.Errorf("update bulk job workspace: %w", err)
+		}
+	}
+	return nil
+}
+
+// workspaceBulkJobBuildStatus returns the status of a bulk job workspace from
+// its build's provisioner job, and whether the build completed.
+func workspaceBulkJobBuildStatus(job database.ProvisionerJob) (database.WorkspaceBulkJobStatus, string, bool) {
+	switch convertProvisionerJob(job).Status {
+	case codersdk.ProvisionerJobSucceeded:
+		return database.WorkspaceBulkJobStatusSucceeded, "", true
+	case codersdk.ProvisionerJobFailed, codersdk.ProvisionerJobCanceled:
+		buildErr := job.Error.String
+		if buildErr == "" {
+			buildErr = "The workspace build was canceled."
+		}
+		return database.WorkspaceBulkJobStatusFailed, buildErr, true
+	default:
+		return "", "", false
+	}
+}
+
+// createWorkspaceBulkJobBuild creates a build for the bulk job action. The
+// build reuses the parameters of the previous build, so it fails if a new
+// template version requires parameters without defaults.
+func (api *API) createWorkspaceBulkJobBuild(ctx context.Context, actor rbac.Subject, job database.WorkspaceBulkJob, workspaceID uuid.UUID) (database.WorkspaceBuild, error) {
+	workspace, err := api.Database.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return database.WorkspaceBuild{}, xerrors.Errorf("get workspace: %w", err)
+	}
+	if workspace.Deleted {
+		return database.WorkspaceBuild{}, xerrors.New("The workspace was deleted.")
+	}
+
+	createBuild := codersdk.CreateWorkspaceBuildRequest{
+		Transition: codersdk.WorkspaceTransitionStart,
+	}
+	switch job.Action {
+	case database.WorkspaceBulkJobActionStart:
+	case database.WorkspaceBulkJobActionStop:
+		createBuild.Transition = codersdk.WorkspaceTransitionStop
+	case database.WorkspaceBulkJobActionDelete:
+		createBuild.Transition = codersdk.WorkspaceTransitionDelete
+	case database.WorkspaceBulkJobActionUpdate:
+		template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+		if err != nil {
+			return database.WorkspaceBuild{}, xerrors.Errorf("get template: %w", err)
+		}
+		createBuild.TemplateVersionID = template.ActiveVersionID
+	default:
+		return database.WorkspaceBuild{}, xerrors.Errorf("unsupported action %q", job.Action)
+	}
+
+	build, _, err := api.createWorkspaceBuild(ctx, func(action rbac.Action, object rbac.Objecter) bool {
+		return api.HTTPAuth.Authorizer.Authorize(ctx, actor, action, object.RBACObject()) == nil
+	}, workspace, job.InitiatorID, createBuild)
+	return build, err
+}
+
+func convertWorkspaceBulkJob(job database.WorkspaceBulkJob, rows []database.GetWorkspaceBulkJobWorkspacesRow) codersdk.WorkspaceBulkJob {
+	apiJob := codersdk.WorkspaceBulkJob{
+		ID:          job.ID,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+		InitiatorID: job.InitiatorID,
+		Action:      codersdk.WorkspaceBulkJobAction(job.Action),
+		Filter:      job.Filter,
+		BatchSize:   int(job.BatchSize),
+		Status:      codersdk.WorkspaceBulkJobStatus(job.Status),
+		Error:       job.Error,
+		Progress: codersdk.WorkspaceBulkJobProgress{
+			Total: len(rows),
+		},
+		Workspaces: make([]codersdk.WorkspaceBulkJobWorkspace, 0, len(rows)),
+	}
+	if job.CompletedAt.Valid {
+		apiJob.CompletedAt = &job.CompletedAt.Time
+	}
+	for _, row := range rows {
+		workspace := codersdk.WorkspaceBulkJobWorkspace{
+			WorkspaceID:   row.WorkspaceID,
+			WorkspaceName: row.WorkspaceName,
+			OwnerName:     row.OwnerUsername,
+			Status:        codersdk.WorkspaceBulkJobStatus(row.Status),
+			Error:         row.Error,
+		}
+		if row.WorkspaceBuildID.Valid {
+			workspace.WorkspaceBuildID = &row.WorkspaceBuildID.UUID
+		}
+		apiJob.Workspaces = append(apiJob.Workspaces, workspace)
+
+		switch row.Status {
+		case database.WorkspaceBulkJobStatusPending:
+			apiJob.Progress.Pending++
+		case database.WorkspaceBulkJobStatusRunning:
+			apiJob.Progress.Running++
+		case database.WorkspaceBulkJobStatusSucceeded:
+			apiJob.Progress.Succeeded++
+		case database.WorkspaceBulkJobStatusFailed:
+			apiJob.Progress.Failed++
+		case database.WorkspaceBulkJobStatusSkipped:
+			apiJob.Progress.Skipped++
+		}
+	}
+	return apiJob
+}
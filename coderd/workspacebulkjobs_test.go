@@ -0,0 +1,317 @@
+package coderd_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbgen"
+	"github.com/coder/coder/coderd/database/dbtestutil"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceBulkJobs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Stop", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		running := make([]codersdk.Workspace, 0, 3)
+		for i := 0; i < 3; i++ {
+			workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+			coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+			running = append(running, workspace)
+		}
+		stopped := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, stopped.LatestBuild.ID)
+		build := coderdtest.CreateWorkspaceBuild(t, client, stopped, "stop")
+		coderdtest.AwaitWorkspaceBuildJob(t, client, build.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Dry runs don't create builds.
+		dryRun, err := client.CreateWorkspaceBulkJob(ctx, codersdk.CreateWorkspaceBulkJobRequest{
+			Action: codersdk.WorkspaceBulkJobActionStop,
+			Filter: "owner:me template:" + template.Name,
+			DryRun: true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, uuid.Nil, dryRun.ID)
+		require.Equal(t, codersdk.WorkspaceBulkJobProgress{Total: 4, Pending: 3, Skipped: 1}, dryRun.Progress)
+		workspace, err := client.Workspace(ctx, running[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+
+		job, err := client.CreateWorkspaceBulkJob(ctx, codersdk.CreateWorkspaceBulkJobRequest{
+			Action:    codersdk.WorkspaceBulkJobActionStop,
+			Filter:    "owner:me template:" + template.Name,
+			BatchSize: 2,
+		})
+		require.NoError(t, err)
+		require.NotEqual(t, uuid.Nil, job.ID)
+		require.Equal(t, codersdk.WorkspaceBulkJobStatusRunning, job.Status)
+		require.Equal(t, 2, job.BatchSize)
+
+		job = awaitWorkspaceBulkJob(ctx, t, client, job.ID)
+		require.Equal(t, codersdk.WorkspaceBulkJobStatusSucceeded, job.Status)
+		require.NotNil(t, job.CompletedAt)
+		require.Equal(t, codersdk.WorkspaceBulkJobProgress{Total: 4, Succeeded: 3, Skipped: 1}, job.Progress)
+		for _, bulkWorkspace := range job.Workspaces {
+			if bulkWorkspace.WorkspaceID == stopped.ID {
+				require.Equal(t, codersdk.WorkspaceBulkJobStatusSkipped, bulkWorkspace.Status)
+				require.Nil(t, bulkWorkspace.WorkspaceBuildID)
+				continue
+			}
+			require.Equal(t, codersdk.WorkspaceBulkJobStatusSucceeded, bulkWorkspace.Status)
+			require.NotNil(t, bulkWorkspace.WorkspaceBuildID)
+			require.Equal(t, "testuser", bulkWorkspace.OwnerName)
+		}
+		for _, runningWorkspace := range running {
+			workspace, err := client.Workspace(ctx, runningWorkspace.ID)
+			require.NoError(t, err)
+			require.Equal(t, codersdk.WorkspaceTransitionStop, workspace.LatestBuild.Transition)
+			require.Equal(t, codersdk.WorkspaceStatusStopped, workspace.LatestBuild.Status)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		newVersion := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+			Parse:          echo.ParseComplete,
+			ProvisionPlan:  echo.ProvisionComplete,
+			ProvisionApply: echo.ProvisionComplete,
+		}, template.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, newVersion.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := client.UpdateActiveTemplateVersion(ctx, template.ID, codersdk.UpdateActiveTemplateVersion{
+			ID: newVersion.ID,
+		})
+		require.NoError(t, err)
+
+		job, err := client.CreateWorkspaceBulkJob(ctx, codersdk.CreateWorkspaceBulkJobRequest{
+			Action: codersdk.WorkspaceBulkJobActionUpdate,
+			Filter: "template:" + template.Name,
+		})
+		require.NoError(t, err)
+		job = awaitWorkspaceBulkJob(ctx, t, client, job.ID)
+		require.Equal(t, codersdk.WorkspaceBulkJobStatusSucceeded, job.Status)
+		require.Equal(t, codersdk.WorkspaceBulkJobProgress{Total: 1, Succeeded: 1}, job.Progress)
+
+		workspace, err = client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, newVersion.ID, workspace.LatestBuild.TemplateVersionID)
+		require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+
+		// The workspace is already up to date.
+		job, err = client.CreateWorkspaceBulkJob(ctx, codersdk.CreateWorkspaceBulkJobRequest{
+			Action: codersdk.WorkspaceBulkJobActionUpdate,
+			Filter: "template:" + template.Name,
+		})
+		require.NoError(t, err)
+		job = awaitWorkspaceBulkJob(ctx, t, client, job.ID)
+		require.Equal(t, codersdk.WorkspaceBulkJobProgress{Total: 1, Skipped: 1}, job.Progress)
+	})
+
+	t.Run("OnlyAuthorizedWorkspaces", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		memberClient, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Members can't stop the workspaces of other users.
+		job, err := memberClient.CreateWorkspaceBulkJob(ctx, codersdk.CreateWorkspaceBulkJobRequest{
+			Action: codersdk.WorkspaceBulkJobActionStop,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 0, job.Progress.Total)
+		job = awaitWorkspaceBulkJob(ctx, t, memberClient, job.ID)
+		require.Equal(t, codersdk.WorkspaceBulkJobStatusSucceeded, job.Status)
+
+		// Bulk jobs are only visible to their initiator.
+		_, err = client.WorkspaceBulkJob(ctx, job.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("ReapStale", func(t *testing.T) {
+		t.Parallel()
+
+		// Bulk jobs left running by a replica that went away are failed when
+		// coderd starts.
+		db, pubsub := dbtestutil.NewDB(t)
+		user := dbgen.User(t, db, database.User{})
+		org := dbgen.Organization(t, db, database.Organization{})
+		template := dbgen.Template(t, db, database.Template{
+			OrganizationID: org.ID,
+			CreatedBy:      user.ID,
+		})
+		version := dbgen.TemplateVersion(t, db, database.TemplateVersion{
+			TemplateID:     uuid.NullUUID{UUID: template.ID, Valid: true},
+			OrganizationID: org.ID,
+			CreatedBy:      user.ID,
+		})
+		createBuild := func() (database.Workspace, database.WorkspaceBuild) {
+			workspace := dbgen.Workspace(t, db, database.Workspace{
+				OwnerID:        user.ID,
+				OrganizationID: org.ID,
+				TemplateID:     template.ID,
+			})
+			buildJob := dbgen.ProvisionerJob(t, db, database.ProvisionerJob{
+				OrganizationID: org.ID,
+				InitiatorID:    user.ID,
+				Type:           database.ProvisionerJobTypeWorkspaceBuild,
+			})
+			build := dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+				WorkspaceID:       workspace.ID,
+				TemplateVersionID: version.ID,
+				InitiatorID:       user.ID,
+				JobID:             buildJob.ID,
+			})
+			return workspace, build
+		}
+		// The build of this workspace completed after the replica went away.
+		succeeded, succeededBuild := createBuild()
+		_, err := db.AcquireProvisionerJob(context.Background(), database.AcquireProvisionerJobParams{
+			StartedAt: sql.NullTime{Time: database.Now(), Valid: true},
+			Types:     []database.ProvisionerType{database.ProvisionerTypeEcho},
+		})
+		require.NoError(t, err)
+		err = db.UpdateProvisionerJobWithCompleteByID(context.Background(), database.UpdateProvisionerJobWithCompleteByIDParams{
+			ID:          succeededBuild.JobID,
+			UpdatedAt:   database.Now(),
+			CompletedAt: sql.NullTime{Time: database.Now(), Valid: true},
+		})
+		require.NoError(t, err)
+		pending, _ := createBuild()
+		// Nothing polls the build of this workspace anymore.
+		inProgress, inProgressBuild := createBuild()
+
+		stale := dbgen.WorkspaceBulkJob(t, db, database.WorkspaceBulkJob{
+			InitiatorID: user.ID,
+			UpdatedAt:   database.Now().Add(-time.Hour),
+		})
+		running := dbgen.WorkspaceBulkJob(t, db, database.WorkspaceBulkJob{
+			InitiatorID: user.ID,
+		})
+		_, err = db.InsertWorkspaceBulkJobWorkspace(context.Background(), database.InsertWorkspaceBulkJobWorkspaceParams{
+			BulkJobID:   stale.ID,
+			WorkspaceID: pending.ID,
+			UpdatedAt:   stale.UpdatedAt,
+			Status:      database.WorkspaceBulkJobStatusPending,
+		})
+		require.NoError(t, err)
+		for _, build := range []database.WorkspaceBuild{succeededBuild, inProgressBuild} {
+			_, err = db.InsertWorkspaceBulkJobWorkspace(context.Background(), database.InsertWorkspaceBulkJobWorkspaceParams{
+				BulkJobID:   stale.ID,
+				WorkspaceID: build.WorkspaceID,
+				UpdatedAt:   stale.UpdatedAt,
+				Status:      database.WorkspaceBulkJobStatusPending,
+			})
+			require.NoError(t, err)
+			err = db.UpdateWorkspaceBulkJobWorkspace(context.Background(), database.UpdateWorkspaceBulkJobWorkspaceParams{
+				BulkJobID:        stale.ID,
+				WorkspaceID:      build.WorkspaceID,
+				UpdatedAt:        stale.UpdatedAt,
+				WorkspaceBuildID: uuid.NullUUID{UUID: build.ID, Valid: true},
+				Status:           database.WorkspaceBulkJobStatusRunning,
+			})
+			require.NoError(t, err)
+		}
+
+		_ = coderdtest.New(t, &coderdtest.Options{
+			Database: db,
+			Pubsub:   pubsub,
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		require.Eventually(t, func() bool {
+			job, err := db.GetWorkspaceBulkJobByID(ctx, stale.ID)
+			return err == nil && job.Status == database.WorkspaceBulkJobStatusFailed
+		}, testutil.WaitLong, testutil.IntervalFast)
+		rows, err := db.GetWorkspaceBulkJobWorkspaces(ctx, stale.ID)
+		require.NoError(t, err)
+		statuses := map[uuid.UUID]database.WorkspaceBulkJobStatus{}
+		for _, row := range rows {
+			statuses[row.WorkspaceID] = row.Status
+		}
+		require.Equal(t, map[uuid.UUID]database.WorkspaceBulkJobStatus{
+			pending.ID:    database.WorkspaceBulkJobStatusFailed,
+			succeeded.ID:  database.WorkspaceBulkJobStatusSucceeded,
+			inProgress.ID: database.WorkspaceBulkJobStatusFailed,
+		}, statuses)
+
+		job, err := db.GetWorkspaceBulkJobByID(ctx, running.ID)
+		require.NoError(t, err)
+		require.Equal(t, database.WorkspaceBulkJobStatusRunning, job.Status)
+	})
+
+	t.Run("InvalidFilter", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateWorkspaceBulkJob(ctx, codersdk.CreateWorkspaceBulkJobRequest{
+			Action: codersdk.WorkspaceBulkJobActionStart,
+			Filter: "status:bad",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
+
+func awaitWorkspaceBulkJob(ctx context.Context, t *testing.T, client *codersdk.Client, id uuid.UUID) codersdk.WorkspaceBulkJob {
+	t.Helper()
+
+	var job codersdk.WorkspaceBulkJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = client.WorkspaceBulkJob(ctx, id)
+		require.NoError(t, err)
+		return job.CompletedAt != nil
+	}, testutil.WaitLong, testutil.IntervalMedium)
+	return job
+}
@@ -91,6 +91,7 @@ func (api *API) workspace(rw http.ResponseWriter, r *http.Request) {
 		workspace,
 		data.builds[0],
 		data.templates[0],
+		data.templateSchedules[0],
 		findUser(workspace.OwnerID, data.users),
 	))
 }
@@ -257,6 +258,7 @@ func (api *API) workspaceByOwnerAndName(rw http.ResponseWriter, r *http.Request)
 		workspace,
 		data.builds[0],
 		data.templates[0],
+		data.templateSchedules[0],
 		findUser(workspace.OwnerID, data.users),
 	))
 }
@@ -525,6 +527,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 			FileID:         templateVersionJob.FileID,
 			Input:          input,
 			Tags:           tags,
+			Priority:       provisionerdserver.PriorityWorkspaceStart,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
@@ -609,6 +612,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		workspace,
 		apiBuild,
 		template,
+		templateSchedule,
 		findUser(user.ID, users),
 	))
 }
@@ -981,6 +985,7 @@ func (api *API) watchWorkspace(rw http.ResponseWriter, r *http.Request) {
 				workspace,
 				data.builds[0],
 				data.templates[0],
+				data.templateSchedules[0],
 				findUser(workspace.OwnerID, data.users),
 			),
 		})
@@ -1000,7 +1005,7 @@ func (api *API) watchWorkspace(rw http.ResponseWriter, r *http.Request) {
 	defer cancelWorkspaceSubscribe()
 
 	// This is required to show whether the workspace is up-to-date.
-	cancelTemplateSubscribe, err := api.Pubsub.Subscribe(watchTemplateChannel(workspace.TemplateID), sendUpdate)
+	cancelTemplateSubscribe, err := api.Pubsub.Subscribe(codersdk.TemplateNotifyChannel(workspace.TemplateID), sendUpdate)
 	if err != nil {
 		_ = sendEvent(ctx, codersdk.ServerSentEvent{
 			Type: codersdk.ServerSentEventTypeError,
@@ -1031,8 +1036,10 @@ func (api *API) watchWorkspace(rw http.ResponseWriter, r *http.Request) {
 
 type workspaceData struct {
 	templates []database.Template
-	builds    []codersdk.WorkspaceBuild
-	users     []database.User
+	// templateSchedules is parallel to templates.
+	templateSchedules []schedule.TemplateScheduleOptions
+	builds            []codersdk.WorkspaceBuild
+	users             []database.User
 }
 
 func (api *API) workspaceData(ctx context.Context, workspaces []database.Workspace) (workspaceData, error) {
@@ -1049,6 +1056,23 @@ func (api *API) workspaceData(ctx context.Context, workspaces []database.Workspa
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return workspaceData{}, xerrors.Errorf("get templates: %w", err)
 	}
+	// The schedule store looks up each template, so serve the lookups from
+	// the templates that were just fetched instead of querying them again.
+	templateStore := fetchedTemplatesStore{
+		Store:     api.Database,
+		templates: make(map[uuid.UUID]database.Template, len(templates)),
+	}
+	for _, template := range templates {
+		templateStore.templates[template.ID] = template
+	}
+	templateSchedules := make([]schedule.TemplateScheduleOptions, 0, len(templates))
+	for _, template := range templates {
+		templateSchedule, err := (*api.TemplateScheduleStore.Load()).GetTemplateScheduleOptions(ctx, templateStore, template.ID)
+		if err != nil {
+			return workspaceData{}, xerrors.Errorf("get template schedule options: %w", err)
+		}
+		templateSchedules = append(templateSchedules, templateSchedule)
+	}
 
 	builds, err := api.Database.GetLatestWorkspaceBuildsByWorkspaceIDs(ctx, workspaceIDs)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
@@ -1076,20 +1100,37 @@ func (api *API) workspaceData(ctx context.Context, workspaces []database.Workspa
 	}
 
 	return workspaceData{
-		templates: templates,
-		builds:    apiBuilds,
-		users:     data.users,
+		templates:         templates,
+		templateSchedules: templateSchedules,
+		builds:            apiBuilds,
+		users:             data.users,
 	}, nil
 }
 
+// fetchedTemplatesStore returns templates that were already fetched for a
+// request from GetTemplateByID.
+type fetchedTemplatesStore struct {
+	database.Store
+	templates map[uuid.UUID]database.Template
+}
+
+func (s fetchedTemplatesStore) GetTemplateByID(ctx context.Context, id uuid.UUID) (database.Template, error) {
+	if template, ok := s.templates[id]; ok {
+		return template, nil
+	}
+	return s.Store.GetTemplateByID(ctx, id)
+}
+
 func convertWorkspaces(workspaces []database.Workspace, data workspaceData) ([]codersdk.Workspace, error) {
 	buildByWorkspaceID := map[uuid.UUID]codersdk.WorkspaceBuild{}
 	for _, workspaceBuild := range data.builds {
 		buildByWorkspaceID[workspaceBuild.WorkspaceID] = workspaceBuild
 	}
 	templateByID := map[uuid.UUID]database.Template{}
-	for _, template := range data.templates {
+	templateScheduleByID := map[uuid.UUID]schedule.TemplateScheduleOptions{}
+	for i, template := range data.templates {
 		templateByID[template.ID] = template
+		templateScheduleByID[template.ID] = data.templateSchedules[i]
 	}
 	userByID := map[uuid.UUID]database.User{}
 	for _, user := range data.users {
@@ -1115,6 +1156,7 @@ func convertWorkspaces(workspaces []database.Workspace, data workspaceData) ([]c
 			workspace,
 			build,
 			template,
+			templateScheduleByID[template.ID],
 			&owner,
 		))
 	}
@@ -1134,6 +1176,7 @@ func convertWorkspace(
 	workspace database.Workspace,
 	workspaceBuild codersdk.WorkspaceBuild,
 	template database.Template,
+	templateSchedule schedule.TemplateScheduleOptions,
 	owner *database.User,
 ) codersdk.Workspace {
 	var autostartSchedule *string
@@ -1141,6 +1184,20 @@ func convertWorkspace(
 		autostartSchedule = &workspace.AutostartSchedule.String
 	}
 
+	var dormantAt, deletingAt *time.Time
+	if completedAt := workspaceBuild.Job.CompletedAt; completedAt != nil && workspaceBuild.Job.Status == codersdk.ProvisionerJobSucceeded {
+		switch workspaceBuild.Transition {
+		case codersdk.WorkspaceTransitionStart:
+			if t := templateSchedule.DormantAt(workspace.LastUsedAt, *completedAt); !t.IsZero() {
+				dormantAt = &t
+			}
+		case codersdk.WorkspaceTransitionStop:
+			if t := templateSchedule.DeletingAt(workspace.LastUsedAt, *completedAt); !t.IsZero() {
+				deletingAt = &t
+			}
+		}
+	}
+
 	ttlMillis := convertWorkspaceTTLMillis(workspace.Ttl)
 	return codersdk.Workspace{
 		ID:                                   workspace.ID,
@@ -1160,6 +1217,8 @@ func convertWorkspace(
 		AutostartSchedule:                    autostartSchedule,
 		TTLMillis:                            ttlMillis,
 		LastUsedAt:                           workspace.LastUsedAt,
+		DormantAt:                            dormantAt,
+		DeletingAt:                           deletingAt,
 	}
 }
 
@@ -0,0 +1,277 @@
+package coderd
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+)
+
+// @Summary Upload workspace agent session recording
+// @Description The recording is the body of the request, in asciicast v2 format.
+// @ID upload-workspace-agent-session-recording
+// @Security CoderSessionToken
+// @Accept application/x-asciicast
+// @Produce json
+// @Tags Agents
+// @Param request body string true "Session recording"
+// @Param type query string true "Session type" Enums(ssh,reconnecting_pty)
+// @Param started_at query string true "Session start time" format(date-time)
+// @Param ended_at query string true "Session end time" format(date-time)
+// @Success 201 {object} codersdk.WorkspaceSessionRecording
+// @Router /workspaceagents/me/session-recordings [post]
+func (api *API) postWorkspaceAgentSessionRecording(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgent(r)
+		auditor        = api.Auditor.Load()
+		query          = r.URL.Query()
+		sessionType    = database.WorkspaceSessionType(query.Get("type"))
+		auditParams    = &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		}
+		aReq, commitAudit = audit.InitRequest[database.WorkspaceSessionRecording](rw, auditParams)
+	)
+	defer commitAudit()
+
+	if !sessionType.Valid() {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Invalid session type %q.", sessionType),
+		})
+		return
+	}
+	startedAt, err := time.Parse(time.RFC3339Nano, query.Get("started_at"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid session start time.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	endedAt, err := time.Parse(time.RFC3339Nano, query.Get("ended_at"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid session end time.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if endedAt.Before(startedAt) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Sessions can't end before they start.",
+		})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, agentsdk.MaxSessionRecordingSize))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to read session recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	err = validateAsciicastHeader(data)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Session recordings must be in asciicast v2 format.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	workspace, err := api.Database.GetWorkspaceByAgentID(ctx, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	owner, err := api.Database.GetUserByID(ctx, workspace.OwnerID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace owner.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Agents don't have an API key, so the session is attributed to the
+	// workspace owner. The workspace is used to link the audit log to the
+	// recording.
+	aReq.UserID = owner.ID
+	auditParams.AdditionalFields, err = json.Marshal(audit.AdditionalFields{
+		WorkspaceName:  workspace.Name,
+		WorkspaceOwner: owner.Username,
+	})
+	if err != nil {
+		api.Logger.Warn(ctx, "marshal workspace owner name", slog.Error(err))
+	}
+
+	recording, err := api.Database.InsertWorkspaceSessionRecording(ctx, database.InsertWorkspaceSessionRecordingParams{
+		ID:          uuid.New(),
+		CreatedAt:   database.Now(),
+		WorkspaceID: workspace.ID,
+		AgentID:     workspaceAgent.ID,
+		SessionType: sessionType,
+		StartedAt:   startedAt,
+		EndedAt:     endedAt,
+		Data:        data,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error inserting session recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = recording
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertWorkspaceSessionRecording(database.GetWorkspaceSessionRecordingsByWorkspaceIDRow{
+		ID:          recording.ID,
+		CreatedAt:   recording.CreatedAt,
+		WorkspaceID: recording.WorkspaceID,
+		AgentID:     recording.AgentID,
+		SessionType: recording.SessionType,
+		StartedAt:   recording.StartedAt,
+		EndedAt:     recording.EndedAt,
+		Size:        int64(len(recording.Data)),
+	}))
+}
+
+// @Summary Get workspace session recordings
+// @ID get-workspace-session-recordings
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Success 200 {array} codersdk.WorkspaceSessionRecording
+// @Router /workspaces/{workspace}/session-recordings [get]
+func (api *API) workspaceSessionRecordings(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+	)
+
+	recordings, err := api.Database.GetWorkspaceSessionRecordingsByWorkspaceID(ctx, workspace.ID)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching session recordings.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	apiRecordings := make([]codersdk.WorkspaceSessionRecording, 0, len(recordings))
+	for _, recording := range recordings {
+		apiRecordings = append(apiRecordings, convertWorkspaceSessionRecording(recording))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, apiRecordings)
+}
+
+// @Summary Download workspace session recording
+// @Description The recording is returned in asciicast v2 format.
+// @ID download-workspace-session-recording
+// @Security CoderSessionToken
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Param recording path string true "Recording ID" format(uuid)
+// @Success 200
+// @Router /workspaces/{workspace}/session-recordings/{recording} [get]
+func (api *API) workspaceSessionRecording(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+	)
+
+	recordingID, err := uuid.Parse(chi.URLParam(r, "recording"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Recording id must be a valid UUID.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	recording, err := api.Database.GetWorkspaceSessionRecordingByID(ctx, recordingID)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && recording.WorkspaceID != workspace.ID) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching session recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.Header().Set("Content-Type", codersdk.SessionRecordingContentType)
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", recording.ID.String()+".cast"))
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(recording.Data)
+}
+
+// validateAsciicastHeader checks that a recording starts with an asciicast v2
+// header. The events that follow aren't validated, players skip lines they
+// can't parse.
+func validateAsciicastHeader(data []byte) error {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	var header struct {
+		Version int `json:"version"`
+		Width   int `json:"width"`
+		Height  int `json:"height"`
+	}
+	err := json.Unmarshal(line, &header)
+	if err != nil {
+		return xerrors.Errorf("parse header: %w", err)
+	}
+	if header.Version != 2 {
+		return xerrors.Errorf("unsupported version %d", header.Version)
+	}
+	if header.Width <= 0 || header.Height <= 0 {
+		return xerrors.Errorf("invalid terminal size %dx%d", header.Width, header.Height)
+	}
+	return nil
+}
+
+func convertWorkspaceSessionRecording(recording database.GetWorkspaceSessionRecordingsByWorkspaceIDRow) codersdk.WorkspaceSessionRecording {
+	return codersdk.WorkspaceSessionRecording{
+		ID:          recording.ID,
+		CreatedAt:   recording.CreatedAt,
+		WorkspaceID: recording.WorkspaceID,
+		AgentID:     recording.AgentID,
+		Type:        codersdk.WorkspaceSessionType(recording.SessionType),
+		StartedAt:   recording.StartedAt,
+		EndedAt:     recording.EndedAt,
+		Size:        recording.Size,
+	}
+}
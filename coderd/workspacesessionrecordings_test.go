@@ -0,0 +1,142 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceSessionRecordings(t *testing.T) {
+	t.Parallel()
+
+	const recording = `{"version":2,"width":80,"height":24,"timestamp":1680000000,"env":{"TERM":"xterm"}}
+[0.1,"o","hello\r\n"]
+[0.2,"i",""]
+`
+
+	auditor := audit.NewMock()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+		Auditor:                  auditor,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	memberClient, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:               uuid.NewString(),
+							SessionRecording: true,
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, memberClient, user.OrganizationID, template.ID)
+	build := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+
+	// Recording is enabled by the template.
+	metadata, err := agentClient.Metadata(ctx)
+	require.NoError(t, err)
+	require.True(t, metadata.SessionRecording)
+
+	// Uploads must be asciicast recordings.
+	err = agentClient.PostSessionRecording(ctx, agentsdk.PostSessionRecordingRequest{
+		Type:      codersdk.WorkspaceSessionTypeSSH,
+		StartedAt: time.Now(),
+		EndedAt:   time.Now(),
+		Recording: strings.NewReader("not a recording"),
+	})
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+	// Sessions can't end before they start.
+	err = agentClient.PostSessionRecording(ctx, agentsdk.PostSessionRecordingRequest{
+		Type:      codersdk.WorkspaceSessionTypeSSH,
+		StartedAt: time.Now(),
+		EndedAt:   time.Now().Add(-time.Hour),
+		Recording: strings.NewReader(recording),
+	})
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+	startedAt := time.Now().Add(-time.Minute)
+	err = agentClient.PostSessionRecording(ctx, agentsdk.PostSessionRecordingRequest{
+		Type:      codersdk.WorkspaceSessionTypeReconnectingPTY,
+		StartedAt: startedAt,
+		EndedAt:   time.Now(),
+		Recording: strings.NewReader(recording),
+	})
+	require.NoError(t, err)
+
+	// The upload is audited as the workspace owner.
+	var auditLog *database.AuditLog
+	for _, log := range auditor.AuditLogs() {
+		log := log
+		if log.ResourceType == database.ResourceTypeWorkspaceSessionRecording {
+			auditLog = &log
+		}
+	}
+	require.NotNil(t, auditLog)
+	require.Equal(t, database.AuditActionCreate, auditLog.Action)
+	require.Equal(t, workspace.OwnerID, auditLog.UserID)
+
+	recordings, err := client.WorkspaceSessionRecordings(ctx, workspace.ID)
+	require.NoError(t, err)
+	require.Len(t, recordings, 1)
+	require.Equal(t, auditLog.ResourceID, recordings[0].ID)
+	require.Equal(t, codersdk.WorkspaceSessionTypeReconnectingPTY, recordings[0].Type)
+	require.Equal(t, build.Resources[0].Agents[0].ID, recordings[0].AgentID)
+	require.EqualValues(t, len(recording), recordings[0].Size)
+	require.WithinDuration(t, startedAt, recordings[0].StartedAt, time.Second)
+
+	data, err := client.WorkspaceSessionRecording(ctx, workspace.ID, recordings[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, recording, string(data))
+
+	_, err = client.WorkspaceSessionRecording(ctx, workspace.ID, uuid.New())
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+	// Recordings are for auditors, not the workspace owner.
+	_, err = memberClient.WorkspaceSessionRecordings(ctx, workspace.ID)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	_, err = memberClient.WorkspaceSessionRecording(ctx, workspace.ID, recordings[0].ID)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+}
@@ -253,3 +253,20 @@ func (*client) PostStartup(_ context.Context, _ agentsdk.PostStartupRequest) err
 func (*client) PatchStartupLogs(_ context.Context, _ agentsdk.PatchStartupLogs) error {
 	return nil
 }
+
+func (*client) PostMetadata(_ context.Context, _ string, _ agentsdk.PostMetadataRequest) error {
+	return nil
+}
+
+func (*client) PostSessionRecording(_ context.Context, _ agentsdk.PostSessionRecordingRequest) error {
+	return nil
+}
+
+func (*client) WatchPortShares(ctx context.Context) (<-chan codersdk.WorkspaceAgentPortShares, error) {
+	sharesChan := make(chan codersdk.WorkspaceAgentPortShares)
+	go func() {
+		<-ctx.Done()
+		close(sharesChan)
+	}()
+	return sharesChan, nil
+}
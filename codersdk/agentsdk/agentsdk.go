@@ -80,6 +80,18 @@ type Metadata struct {
 	MOTDFile              string                  `json:"motd_file"`
 	ShutdownScript        string                  `json:"shutdown_script"`
 	ShutdownScriptTimeout time.Duration           `json:"shutdown_script_timeout"`
+	// Metadata describes the metadata items the agent should collect and
+	// report with PostMetadata.
+	Metadata []codersdk.WorkspaceAgentMetadataDescription `json:"metadata"`
+	// ReconnectingPTYBufferSize is the number of bytes of output each
+	// reconnecting PTY keeps for replay. Zero means the agent default.
+	ReconnectingPTYBufferSize int32 `json:"reconnecting_pty_buffer_size"`
+	// SessionRecording makes the agent record PTY sessions and upload them
+	// with PostSessionRecording once they end.
+	SessionRecording bool `json:"session_recording"`
+	// PortShares are the ports shared on the agent. Changes are received
+	// with WatchPortShares.
+	PortShares codersdk.WorkspaceAgentPortShares `json:"port_shares"`
 }
 
 // Metadata fetches metadata for the currently authenticated workspace agent.
@@ -525,6 +537,26 @@ func (c *Client) PostStartup(ctx context.Context, req PostStartupRequest) error
 	return nil
 }
 
+type PostMetadataRequest struct {
+	CollectedAt time.Time `json:"collected_at"`
+	Value       string    `json:"value"`
+	Error       string    `json:"error"`
+}
+
+// PostMetadata reports the result of running the script of the metadata
+// item with the given key.
+func (c *Client) PostMetadata(ctx context.Context, key string, req PostMetadataRequest) error {
+	res, err := c.SDK.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/metadata/"+url.PathEscape(key), req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return codersdk.ReadBodyAsError(res)
+	}
+	return nil
+}
+
 type StartupLog struct {
 	CreatedAt time.Time `json:"created_at"`
 	Output    string    `json:"output"`
@@ -548,6 +580,84 @@ func (c *Client) PatchStartupLogs(ctx context.Context, req PatchStartupLogs) err
 	return nil
 }
 
+// MaxSessionRecordingSize is the largest recording coderd accepts. Agents
+// stop recording a session once its recording reaches this size.
+const MaxSessionRecordingSize = 64 << 20
+
+type PostSessionRecordingRequest struct {
+	Type      codersdk.WorkspaceSessionType
+	StartedAt time.Time
+	EndedAt   time.Time
+	// Recording is the session in asciicast v2 format.
+	Recording io.Reader
+}
+
+// PostSessionRecording uploads the recording of a PTY session that has ended.
+func (c *Client) PostSessionRecording(ctx context.Context, req PostSessionRecordingRequest) error {
+	res, err := c.SDK.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/session-recordings", req.Recording,
+		codersdk.WithQueryParam("type", string(req.Type)),
+		codersdk.WithQueryParam("started_at", req.StartedAt.Format(time.RFC3339Nano)),
+		codersdk.WithQueryParam("ended_at", req.EndedAt.Format(time.RFC3339Nano)),
+		func(r *http.Request) {
+			r.Header.Set("Content-Type", codersdk.SessionRecordingContentType)
+		},
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return codersdk.ReadBodyAsError(res)
+	}
+	return nil
+}
+
+// WatchPortShares streams the ports shared on the agent. The current shares
+// are sent first, and again whenever they change. The channel is closed when
+// the connection to coderd is lost.
+func (c *Client) WatchPortShares(ctx context.Context) (<-chan codersdk.WorkspaceAgentPortShares, error) {
+	//nolint:bodyclose
+	res, err := c.SDK.Request(ctx, http.MethodGet, "/api/v2/workspaceagents/me/port-shares/watch", nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, codersdk.ReadBodyAsError(res)
+	}
+	nextEvent := codersdk.ServerSentEventReader(ctx, res.Body)
+
+	sharesChan := make(chan codersdk.WorkspaceAgentPortShares, 1)
+	go func() {
+		defer close(sharesChan)
+		defer res.Body.Close()
+
+		for {
+			sse, err := nextEvent()
+			if err != nil {
+				return
+			}
+			if sse.Type != codersdk.ServerSentEventTypeData {
+				continue
+			}
+			b, ok := sse.Data.([]byte)
+			if !ok {
+				return
+			}
+			var shares codersdk.WorkspaceAgentPortShares
+			err = json.Unmarshal(b, &shares)
+			if err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case sharesChan <- shares:
+			}
+		}
+	}()
+	return sharesChan, nil
+}
+
 type GitAuthResponse struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
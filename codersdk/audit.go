@@ -14,15 +14,17 @@ import (
 type ResourceType string
 
 const (
-	ResourceTypeTemplate        ResourceType = "template"
-	ResourceTypeTemplateVersion ResourceType = "template_version"
-	ResourceTypeUser            ResourceType = "user"
-	ResourceTypeWorkspace       ResourceType = "workspace"
-	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
-	ResourceTypeGitSSHKey       ResourceType = "git_ssh_key"
-	ResourceTypeAPIKey          ResourceType = "api_key"
-	ResourceTypeGroup           ResourceType = "group"
-	ResourceTypeLicense         ResourceType = "license"
+	ResourceTypeTemplate                  ResourceType = "template"
+	ResourceTypeTemplateVersion           ResourceType = "template_version"
+	ResourceTypeUser                      ResourceType = "user"
+	ResourceTypeWorkspace                 ResourceType = "workspace"
+	ResourceTypeWorkspaceBuild            ResourceType = "workspace_build"
+	ResourceTypeGitSSHKey                 ResourceType = "git_ssh_key"
+	ResourceTypeAPIKey                    ResourceType = "api_key"
+	ResourceTypeGroup                     ResourceType = "group"
+	ResourceTypeLicense                   ResourceType = "license"
+	ResourceTypeWorkspaceSessionRecording ResourceType = "workspace_session_recording"
+	ResourceTypeTemplateVersionPromotion  ResourceType = "template_version_promotion"
 )
 
 func (r ResourceType) FriendlyString() string {
@@ -47,6 +49,10 @@ func (r ResourceType) FriendlyString() string {
 		return "group"
 	case ResourceTypeLicense:
 		return "license"
+	case ResourceTypeWorkspaceSessionRecording:
+		return "workspace session recording"
+	case ResourceTypeTemplateVersionPromotion:
+		return "template version promotion"
 	default:
 		return "unknown"
 	}
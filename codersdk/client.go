@@ -51,6 +51,11 @@ const (
 	// Only owners can bypass rate limits. This is typically used for scale testing.
 	// nolint: gosec
 	BypassRatelimitHeader = "X-Coder-Bypass-Ratelimit"
+
+	// ProvisionerDaemonPSK is the header provisioner daemons use to
+	// authenticate with the deployment's pre-shared key.
+	// nolint: gosec
+	ProvisionerDaemonPSK = "Coder-Provisioner-Daemon-PSK"
 )
 
 // loggableMimeTypes is a list of MIME types that are safe to log
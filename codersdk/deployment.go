@@ -161,6 +161,9 @@ type DeploymentValues struct {
 	GitAuthProviders                clibase.Struct[[]GitAuthConfig] `json:"git_auth,omitempty" typescript:",notnull"`
 	SSHConfig                       SSHConfig                       `json:"config_ssh,omitempty" typescript:",notnull"`
 	WgtunnelHost                    clibase.String                  `json:"wgtunnel_host,omitempty" typescript:",notnull"`
+	Retention                       RetentionConfig                 `json:"retention,omitempty" typescript:",notnull"`
+	AuditExport                     AuditExportConfig               `json:"audit_export,omitempty" typescript:",notnull"`
+	SessionRecording                clibase.Bool                    `json:"session_recording,omitempty" typescript:",notnull"`
 
 	Config      clibase.String `json:"config,omitempty" typescript:",notnull"`
 	WriteConfig clibase.Bool   `json:"write_config,omitempty" typescript:",notnull"`
@@ -247,20 +250,25 @@ type OAuth2GithubConfig struct {
 }
 
 type OIDCConfig struct {
-	AllowSignups        clibase.Bool                      `json:"allow_signups" typescript:",notnull"`
-	ClientID            clibase.String                    `json:"client_id" typescript:",notnull"`
-	ClientSecret        clibase.String                    `json:"client_secret" typescript:",notnull"`
-	EmailDomain         clibase.StringArray               `json:"email_domain" typescript:",notnull"`
-	IssuerURL           clibase.String                    `json:"issuer_url" typescript:",notnull"`
-	Scopes              clibase.StringArray               `json:"scopes" typescript:",notnull"`
-	IgnoreEmailVerified clibase.Bool                      `json:"ignore_email_verified" typescript:",notnull"`
-	UsernameField       clibase.String                    `json:"username_field" typescript:",notnull"`
-	EmailField          clibase.String                    `json:"email_field" typescript:",notnull"`
-	AuthURLParams       clibase.Struct[map[string]string] `json:"auth_url_params" typescript:",notnull"`
-	GroupField          clibase.String                    `json:"groups_field" typescript:",notnull"`
-	GroupMapping        clibase.Struct[map[string]string] `json:"group_mapping" typescript:",notnull"`
-	SignInText          clibase.String                    `json:"sign_in_text" typescript:",notnull"`
-	IconURL             clibase.URL                       `json:"icon_url" typescript:",notnull"`
+	AllowSignups        clibase.Bool                        `json:"allow_signups" typescript:",notnull"`
+	ClientID            clibase.String                      `json:"client_id" typescript:",notnull"`
+	ClientSecret        clibase.String                      `json:"client_secret" typescript:",notnull"`
+	EmailDomain         clibase.StringArray                 `json:"email_domain" typescript:",notnull"`
+	IssuerURL           clibase.String                      `json:"issuer_url" typescript:",notnull"`
+	Scopes              clibase.StringArray                 `json:"scopes" typescript:",notnull"`
+	IgnoreEmailVerified clibase.Bool                        `json:"ignore_email_verified" typescript:",notnull"`
+	UsernameField       clibase.String                      `json:"username_field" typescript:",notnull"`
+	EmailField          clibase.String                      `json:"email_field" typescript:",notnull"`
+	AuthURLParams       clibase.Struct[map[string]string]   `json:"auth_url_params" typescript:",notnull"`
+	GroupField          clibase.String                      `json:"groups_field" typescript:",notnull"`
+	GroupMapping        clibase.Struct[map[string]string]   `json:"group_mapping" typescript:",notnull"`
+	GroupAutoCreate     clibase.Bool                        `json:"group_auto_create" typescript:",notnull"`
+	GroupRegexFilter    clibase.String                      `json:"group_regex_filter" typescript:",notnull"`
+	UserRoleField       clibase.String                      `json:"user_role_field" typescript:",notnull"`
+	UserRoleMapping     clibase.Struct[map[string][]string] `json:"user_role_mapping" typescript:",notnull"`
+	UserRolesDefault    clibase.StringArray                 `json:"user_roles_default" typescript:",notnull"`
+	SignInText          clibase.String                      `json:"sign_in_text" typescript:",notnull"`
+	IconURL             clibase.URL                         `json:"icon_url" typescript:",notnull"`
 }
 
 type TelemetryConfig struct {
@@ -302,15 +310,59 @@ type GitAuthConfig struct {
 }
 
 type ProvisionerConfig struct {
-	Daemons             clibase.Int64    `json:"daemons" typescript:",notnull"`
-	DaemonPollInterval  clibase.Duration `json:"daemon_poll_interval" typescript:",notnull"`
-	DaemonPollJitter    clibase.Duration `json:"daemon_poll_jitter" typescript:",notnull"`
-	ForceCancelInterval clibase.Duration `json:"force_cancel_interval" typescript:",notnull"`
+	Daemons             clibase.Int64       `json:"daemons" typescript:",notnull"`
+	DaemonPollInterval  clibase.Duration    `json:"daemon_poll_interval" typescript:",notnull"`
+	DaemonPollJitter    clibase.Duration    `json:"daemon_poll_jitter" typescript:",notnull"`
+	ForceCancelInterval clibase.Duration    `json:"force_cancel_interval" typescript:",notnull"`
+	DaemonPSK           clibase.String      `json:"daemon_psk" typescript:",notnull"`
+	Plugins             clibase.StringArray `json:"plugins" typescript:",notnull"`
+	CacheMaxSize        clibase.Int64       `json:"cache_max_size" typescript:",notnull"`
+	CacheMirror         clibase.String      `json:"cache_mirror" typescript:",notnull"`
 }
 
 type RateLimitConfig struct {
-	DisableAll clibase.Bool  `json:"disable_all" typescript:",notnull"`
-	API        clibase.Int64 `json:"api" typescript:",notnull"`
+	DisableAll clibase.Bool                      `json:"disable_all" typescript:",notnull"`
+	API        clibase.Int64                     `json:"api" typescript:",notnull"`
+	Shared     clibase.Bool                      `json:"shared" typescript:",notnull"`
+	Policies   clibase.Struct[[]RateLimitPolicy] `json:"policies" typescript:",notnull"`
+}
+
+// RateLimitPolicy overrides the rate limit of a group of API routes.
+type RateLimitPolicy struct {
+	// Name is one of "api", "login", "files", "workspace_builds" or
+	// "agents".
+	Name string `json:"name" yaml:"name"`
+	// Limit is the number of requests allowed per window. Zero keeps the
+	// default limit, and a negative limit disables rate limiting.
+	Limit int64 `json:"limit" yaml:"limit"`
+	// Window is the duration requests are counted over. Zero keeps the
+	// default of a minute.
+	Window time.Duration `json:"window" yaml:"window"`
+	// RoleLimits overrides the limit for users with the given site roles,
+	// e.g. "owner". A zero or negative limit disables rate limiting for the
+	// role.
+	RoleLimits map[string]int64 `json:"role_limits" yaml:"role_limits"`
+}
+
+// RetentionConfig configures how long rows are kept in tables that grow
+// without bound. A zero duration keeps rows forever.
+type RetentionConfig struct {
+	AuditLogs            clibase.Duration `json:"audit_logs" typescript:",notnull"`
+	ProvisionerJobLogs   clibase.Duration `json:"provisioner_job_logs" typescript:",notnull"`
+	WorkspaceBuildStates clibase.Duration `json:"workspace_build_states" typescript:",notnull"`
+	PurgeBatchSize       clibase.Int64    `json:"purge_batch_size" typescript:",notnull"`
+}
+
+type AuditExportConfig struct {
+	WebhookURL           clibase.URL      `json:"webhook_url" typescript:",notnull"`
+	WebhookSecret        clibase.String   `json:"webhook_secret" typescript:",notnull"`
+	WebhookBatchSize     clibase.Int64    `json:"webhook_batch_size" typescript:",notnull"`
+	WebhookFlushInterval clibase.Duration `json:"webhook_flush_interval" typescript:",notnull"`
+	WebhookBufferDir     clibase.String   `json:"webhook_buffer_dir" typescript:",notnull"`
+	FilePath             clibase.String   `json:"file_path" typescript:",notnull"`
+	FileMaxSize          clibase.Int64    `json:"file_max_size" typescript:",notnull"`
+	FileMaxBackups       clibase.Int64    `json:"file_max_backups" typescript:",notnull"`
+	SyslogURL            clibase.URL      `json:"syslog_url" typescript:",notnull"`
 }
 
 type SwaggerConfig struct {
@@ -425,6 +477,14 @@ when required by your organization's security policy.`,
 			Name:        "Provisioning",
 			Description: `Tune the behavior of the provisioner, which is responsible for creating, updating, and deleting workspace resources.`,
 		}
+		deploymentGroupRetention = clibase.Group{
+			Name:        "Retention",
+			Description: `Configure how long old data is kept in the database. Old rows are purged once a day.`,
+		}
+		deploymentGroupAuditExport = clibase.Group{
+			Name:        "Audit Export",
+			Description: `Ship audit logs to external systems, such as a SIEM. Audit logs are always stored in the database.`,
+		}
 		deploymentGroupDangerous = clibase.Group{
 			Name: "⚠️ Dangerous",
 		}
@@ -906,6 +966,55 @@ when required by your organization's security policy.`,
 			Group:       &deploymentGroupOIDC,
 			YAML:        "groupMapping",
 		},
+		{
+			Name:        "OIDC Group Auto Create",
+			Description: "Automatically create groups returned by the OIDC provider that don't exist in Coder.",
+			Flag:        "oidc-group-auto-create",
+			Env:         "CODER_OIDC_GROUP_AUTO_CREATE",
+			Default:     "false",
+			Value:       &c.OIDC.GroupAutoCreate,
+			Group:       &deploymentGroupOIDC,
+			YAML:        "enableGroupAutoCreate",
+		},
+		{
+			Name:        "OIDC Group Regex Filter",
+			Description: "If provided, only groups returned by the OIDC provider that match the regular expression are synced. The expression is matched against the group names in the claim, before they are mapped.",
+			Flag:        "oidc-group-regex-filter",
+			Env:         "CODER_OIDC_GROUP_REGEX_FILTER",
+			Default:     "",
+			Value:       &c.OIDC.GroupRegexFilter,
+			Group:       &deploymentGroupOIDC,
+			YAML:        "groupRegexFilter",
+		},
+		{
+			Name:        "OIDC User Role Field",
+			Description: "The OIDC claim field to sync site roles from. If empty, site roles are not synced and are managed in Coder instead.",
+			Flag:        "oidc-user-role-field",
+			Env:         "CODER_OIDC_USER_ROLE_FIELD",
+			Default:     "",
+			Value:       &c.OIDC.UserRoleField,
+			Group:       &deploymentGroupOIDC,
+			YAML:        "userRoleField",
+		},
+		{
+			Name:        "OIDC User Role Mapping",
+			Description: "A map of the OIDC role claim values and the site roles in Coder they should map to. Values that aren't mapped are used as site role names.",
+			Flag:        "oidc-user-role-mapping",
+			Env:         "CODER_OIDC_USER_ROLE_MAPPING",
+			Default:     "{}",
+			Value:       &c.OIDC.UserRoleMapping,
+			Group:       &deploymentGroupOIDC,
+			YAML:        "userRoleMapping",
+		},
+		{
+			Name:        "OIDC User Roles Default",
+			Description: "Site roles given to every user when site roles are synced from the OIDC provider.",
+			Flag:        "oidc-user-role-default",
+			Env:         "CODER_OIDC_USER_ROLE_DEFAULT",
+			Value:       &c.OIDC.UserRolesDefault,
+			Group:       &deploymentGroupOIDC,
+			YAML:        "userRoleDefault",
+		},
 		{
 			Name:        "OpenID Connect sign in text",
 			Description: "The text to show on the OpenID Connect sign in button.",
@@ -1026,6 +1135,43 @@ when required by your organization's security policy.`,
 			Group:       &deploymentGroupProvisioning,
 			YAML:        "forceCancelInterval",
 		},
+		{
+			Name:        "Provisioner Daemon Pre-shared Key (PSK)",
+			Description: "Pre-shared key to authenticate external provisioner daemons to Coder server.",
+			Flag:        "provisioner-daemon-psk",
+			Env:         "CODER_PROVISIONER_DAEMON_PSK",
+			Value:       &c.Provisioner.DaemonPSK,
+			Annotations: clibase.Annotations{}.Mark(flagSecretKey, "true"),
+			Group:       &deploymentGroupProvisioning,
+		},
+		{
+			Name:        "Provisioner Plugins",
+			Description: "External provisioners that template versions can target, in the form name=path. Provisioner daemons started by the server launch each binary, which must serve the provisioner protocol over stdio.",
+			Flag:        "provisioner-plugins",
+			Env:         "CODER_PROVISIONER_PLUGINS",
+			Value:       &c.Provisioner.Plugins,
+			Group:       &deploymentGroupProvisioning,
+			YAML:        "plugins",
+		},
+		{
+			Name:        "Provisioner Cache Max Size",
+			Description: "The maximum size in megabytes of the Terraform provider and module cache shared by provisioner jobs. The least recently used entries are evicted when the cache grows larger. Set to 0 to disable eviction.",
+			Flag:        "provisioner-cache-max-size",
+			Env:         "CODER_PROVISIONER_CACHE_MAX_SIZE",
+			Default:     "0",
+			Value:       &c.Provisioner.CacheMaxSize,
+			Group:       &deploymentGroupProvisioning,
+			YAML:        "cacheMaxSize",
+		},
+		{
+			Name:        "Provisioner Cache Mirror",
+			Description: "A Terraform provider filesystem mirror used to seed the provider cache on startup. This is useful for air-gapped deployments.",
+			Flag:        "provisioner-cache-mirror",
+			Env:         "CODER_PROVISIONER_CACHE_MIRROR",
+			Value:       &c.Provisioner.CacheMirror,
+			Group:       &deploymentGroupProvisioning,
+			YAML:        "cacheMirror",
+		},
 		// RateLimit settings
 		{
 			Name:        "Disable All Rate Limits",
@@ -1047,6 +1193,65 @@ when required by your organization's security policy.`,
 			Value:   &c.RateLimit.API,
 			Hidden:  true,
 		},
+		{
+			Name:        "Share Rate Limits",
+			Description: "Counts requests against rate limits in the database, so limits apply across all replicas instead of to each replica separately. Every rate limited request makes a database query.",
+			Flag:        "rate-limit-shared",
+			Env:         "CODER_RATE_LIMIT_SHARED",
+			Default:     "false",
+			Value:       &c.RateLimit.Shared,
+			YAML:        "rateLimitShared",
+		},
+		{
+			Name:        "Rate Limit Policies",
+			Description: `Overrides the limit, window and per-role limits of the "api", "login", "files", "workspace_builds" and "agents" rate limit policies, as a YAML or JSON list. e.g. '[{"name": "workspace_builds", "limit": 10, "window": "1m", "role_limits": {"owner": -1}}]'.`,
+			Flag:        "rate-limit-policies",
+			Env:         "CODER_RATE_LIMIT_POLICIES",
+			YAML:        "rateLimitPolicies",
+			Value:       &c.RateLimit.Policies,
+		},
+		// Retention settings
+		{
+			Name:        "Audit Log Retention",
+			Description: "How long audit logs are kept before they are purged. Set to 0 to keep audit logs forever.",
+			Flag:        "audit-log-retention",
+			Env:         "CODER_AUDIT_LOG_RETENTION",
+			Default:     "0",
+			Value:       &c.Retention.AuditLogs,
+			Group:       &deploymentGroupRetention,
+			YAML:        "auditLogs",
+		},
+		{
+			Name:        "Provisioner Job Log Retention",
+			Description: "How long the logs of completed provisioner jobs are kept before they are purged. Set to 0 to keep job logs forever.",
+			Flag:        "provisioner-job-log-retention",
+			Env:         "CODER_PROVISIONER_JOB_LOG_RETENTION",
+			Default:     "0",
+			Value:       &c.Retention.ProvisionerJobLogs,
+			Group:       &deploymentGroupRetention,
+			YAML:        "provisionerJobLogs",
+		},
+		{
+			Name:        "Workspace Build State Retention",
+			Description: "How long the Terraform state of superseded workspace builds is kept before it is purged. The state of the latest build of a workspace is never purged. Set to 0 to keep build states forever.",
+			Flag:        "workspace-build-state-retention",
+			Env:         "CODER_WORKSPACE_BUILD_STATE_RETENTION",
+			Default:     "0",
+			Value:       &c.Retention.WorkspaceBuildStates,
+			Group:       &deploymentGroupRetention,
+			YAML:        "workspaceBuildStates",
+		},
+		{
+			Name:        "Purge Batch Size",
+			Description: "Maximum number of rows deleted by a single statement when purging old data.",
+			Flag:        "purge-batch-size",
+			Env:         "CODER_PURGE_BATCH_SIZE",
+			Hidden:      true,
+			Default:     "1000",
+			Value:       &c.Retention.PurgeBatchSize,
+			Group:       &deploymentGroupRetention,
+			YAML:        "purgeBatchSize",
+		},
 		// Logging settings
 		{
 			Name:          "Verbose",
@@ -1272,6 +1477,99 @@ when required by your organization's security policy.`,
 			Value:       &c.AuditLogging,
 			YAML:        "auditLogging",
 		},
+		{
+			Name:        "Audit Webhook URL",
+			Description: "An HTTP endpoint that receives batches of audit logs as JSON in POST requests. Unset to disable the webhook.",
+			Flag:        "audit-webhook-url",
+			Env:         "CODER_AUDIT_WEBHOOK_URL",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.WebhookURL,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "webhookURL",
+		},
+		{
+			Name:        "Audit Webhook Secret",
+			Description: "A secret used to sign webhook requests. The hex encoded HMAC-SHA256 of the request body is sent in the X-Coder-Signature-256 header, prefixed with \"sha256=\".",
+			Flag:        "audit-webhook-secret",
+			Env:         "CODER_AUDIT_WEBHOOK_SECRET",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true").Mark(flagSecretKey, "true"),
+			Value:       &c.AuditExport.WebhookSecret,
+			Group:       &deploymentGroupAuditExport,
+		},
+		{
+			Name:        "Audit Webhook Batch Size",
+			Description: "The maximum number of audit logs sent in a single webhook request.",
+			Flag:        "audit-webhook-batch-size",
+			Env:         "CODER_AUDIT_WEBHOOK_BATCH_SIZE",
+			Default:     "100",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.WebhookBatchSize,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "webhookBatchSize",
+		},
+		{
+			Name:        "Audit Webhook Flush Interval",
+			Description: "How often pending audit logs are sent to the webhook, even if the batch is not full.",
+			Flag:        "audit-webhook-flush-interval",
+			Env:         "CODER_AUDIT_WEBHOOK_FLUSH_INTERVAL",
+			Default:     (5 * time.Second).String(),
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.WebhookFlushInterval,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "webhookFlushInterval",
+		},
+		{
+			Name:        "Audit Webhook Buffer Directory",
+			Description: "The directory batches that could not be delivered to the webhook are stored in until they can be retried. Defaults to a directory in the cache directory.",
+			Flag:        "audit-webhook-buffer-dir",
+			Env:         "CODER_AUDIT_WEBHOOK_BUFFER_DIR",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.WebhookBufferDir,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "webhookBufferDir",
+		},
+		{
+			Name:        "Audit File Path",
+			Description: "A file audit logs are appended to as JSON lines. Unset to disable the file export.",
+			Flag:        "audit-file-path",
+			Env:         "CODER_AUDIT_FILE_PATH",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.FilePath,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "filePath",
+		},
+		{
+			Name:        "Audit File Max Size",
+			Description: "The size in megabytes at which the audit log file is rotated.",
+			Flag:        "audit-file-max-size",
+			Env:         "CODER_AUDIT_FILE_MAX_SIZE",
+			Default:     "100",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.FileMaxSize,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "fileMaxSize",
+		},
+		{
+			Name:        "Audit File Max Backups",
+			Description: "The number of rotated audit log files to keep. Set to 0 to keep all rotated files.",
+			Flag:        "audit-file-max-backups",
+			Env:         "CODER_AUDIT_FILE_MAX_BACKUPS",
+			Default:     "10",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.FileMaxBackups,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "fileMaxBackups",
+		},
+		{
+			Name:        "Audit Syslog URL",
+			Description: "A syslog server audit logs are sent to as RFC 5424 messages, e.g. udp://localhost:514, tcp://localhost:601 or unixgram:///dev/log. Unset to disable the syslog export.",
+			Flag:        "audit-syslog-url",
+			Env:         "CODER_AUDIT_SYSLOG_URL",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.SyslogURL,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "syslogURL",
+		},
 		{
 			Name:        "Browser Only",
 			Description: "Whether Coder only allows connections to workspaces via the browser.",
@@ -1300,6 +1598,15 @@ when required by your organization's security policy.`,
 			Value: &c.DisablePathApps,
 			YAML:  "disablePathApps",
 		},
+		{
+			Name:        "Session Recording",
+			Description: "Record the PTY sessions of every workspace agent, over SSH and the web terminal, in asciicast format. Recordings can be downloaded by users who can read the audit log.",
+			Flag:        "session-recording",
+			Env:         "CODER_SESSION_RECORDING",
+
+			Value: &c.SessionRecording,
+			YAML:  "sessionRecording",
+		},
 		{
 			Name:        "Session Duration",
 			Description: "The token expiry duration for browser sessions. Sessions may last longer if they are actively making requests, but this functionality can be disabled via --disable-session-expiry-refresh.",
@@ -1485,6 +1792,23 @@ func (c *Client) DeploymentStats(ctx context.Context) (DeploymentStats, error) {
 	return df, json.NewDecoder(res.Body).Decode(&df)
 }
 
+// DeploymentPurgeStats returns the number of rows the next database purge
+// would remove from each table with a retention policy.
+func (c *Client) DeploymentPurgeStats(ctx context.Context) (DeploymentPurgeStats, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/deployment/purge", nil)
+	if err != nil {
+		return DeploymentPurgeStats{}, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return DeploymentPurgeStats{}, ReadBodyAsError(res)
+	}
+
+	var stats DeploymentPurgeStats
+	return stats, json.NewDecoder(res.Body).Decode(&stats)
+}
+
 type AppearanceConfig struct {
 	LogoURL       string              `json:"logo_url"`
 	ServiceBanner ServiceBannerConfig `json:"service_banner"`
@@ -1677,6 +2001,26 @@ type SessionCountDeploymentStats struct {
 	ReconnectingPTY int64 `json:"reconnecting_pty"`
 }
 
+// DeploymentPurgeStats describes the rows the next database purge would remove.
+type DeploymentPurgeStats struct {
+	// CollectedAt is the time in which stats are collected at.
+	CollectedAt time.Time         `json:"collected_at" format:"date-time"`
+	Tables      []PurgeTableStats `json:"tables"`
+}
+
+// PurgeTableStats describes the rows of a single table the next database
+// purge would remove.
+type PurgeTableStats struct {
+	Table string `json:"table"`
+	// RetentionMillis is how long rows are kept before being purged. Zero
+	// means rows are kept forever.
+	RetentionMillis int64 `json:"retention_ms"`
+	// Before is the cutoff time. Rows older than this are purged.
+	Before time.Time `json:"before" format:"date-time"`
+	// Rows is the number of rows that would be purged.
+	Rows int64 `json:"rows"`
+}
+
 type DeploymentStats struct {
 	// AggregatedFrom is the time in which stats are aggregated from.
 	// This might be back in time a specific duration or interval.
@@ -0,0 +1,231 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// NotificationEventType is the type of event a notification webhook can
+// subscribe to.
+type NotificationEventType string
+
+const (
+	NotificationEventWorkspaceBuildSucceeded   NotificationEventType = "workspace_build_succeeded"
+	NotificationEventWorkspaceBuildFailed      NotificationEventType = "workspace_build_failed"
+	NotificationEventWorkspaceAutostopImminent NotificationEventType = "workspace_autostop_imminent"
+	NotificationEventWorkspaceDeleted          NotificationEventType = "workspace_deleted"
+	NotificationEventTemplateVersionPromoted   NotificationEventType = "template_version_promoted"
+)
+
+// NotificationEventTypes lists every event type a webhook can subscribe to.
+var NotificationEventTypes = []NotificationEventType{
+	NotificationEventWorkspaceBuildSucceeded,
+	NotificationEventWorkspaceBuildFailed,
+	NotificationEventWorkspaceAutostopImminent,
+	NotificationEventWorkspaceDeleted,
+	NotificationEventTemplateVersionPromoted,
+}
+
+// NotificationWebhook is an HTTP endpoint that receives a POST request for
+// every subscribed event.
+type NotificationWebhook struct {
+	ID        uuid.UUID               `json:"id" format:"uuid"`
+	CreatedAt time.Time               `json:"created_at" format:"date-time"`
+	UpdatedAt time.Time               `json:"updated_at" format:"date-time"`
+	Name      string                  `json:"name"`
+	URL       string                  `json:"url"`
+	Events    []NotificationEventType `json:"events"`
+	// PayloadTemplate is a Go text/template rendered with a
+	// NotificationEvent. The JSON encoding of the event is sent when it is
+	// empty.
+	PayloadTemplate string `json:"payload_template"`
+	// HasSecret is true when requests are signed with the
+	// X-Coder-Signature-256 header. The secret itself is never returned.
+	HasSecret bool `json:"has_secret"`
+	Enabled   bool `json:"enabled"`
+}
+
+type CreateNotificationWebhookRequest struct {
+	Name            string                  `json:"name" validate:"required"`
+	URL             string                  `json:"url" validate:"required"`
+	Events          []NotificationEventType `json:"events" validate:"required"`
+	PayloadTemplate string                  `json:"payload_template"`
+	Secret          string                  `json:"secret"`
+	// Enabled defaults to true.
+	Enabled *bool `json:"enabled"`
+}
+
+type PatchNotificationWebhookRequest struct {
+	Name            *string                 `json:"name"`
+	URL             *string                 `json:"url"`
+	Events          []NotificationEventType `json:"events"`
+	PayloadTemplate *string                 `json:"payload_template"`
+	// Secret replaces the signing secret. An empty string disables signing.
+	Secret  *string `json:"secret"`
+	Enabled *bool   `json:"enabled"`
+}
+
+// NotificationWebhookDelivery is a single attempt at delivering an event to a
+// webhook.
+type NotificationWebhookDelivery struct {
+	ID          uuid.UUID             `json:"id" format:"uuid"`
+	WebhookID   uuid.UUID             `json:"webhook_id" format:"uuid"`
+	EventID     uuid.UUID             `json:"event_id" format:"uuid"`
+	EventType   NotificationEventType `json:"event_type"`
+	Attempt     int32                 `json:"attempt"`
+	CreatedAt   time.Time             `json:"created_at" format:"date-time"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty" format:"date-time"`
+	RequestBody string                `json:"request_body"`
+	StatusCode  int32                 `json:"status_code"`
+	Error       string                `json:"error"`
+}
+
+// NotificationEvent is the default payload of a notification webhook, and
+// the data a payload template is rendered with.
+type NotificationEvent struct {
+	ID              uuid.UUID                         `json:"id" format:"uuid"`
+	Type            NotificationEventType             `json:"type"`
+	CreatedAt       time.Time                         `json:"created_at" format:"date-time"`
+	Workspace       *NotificationEventWorkspace       `json:"workspace,omitempty"`
+	Build           *NotificationEventWorkspaceBuild  `json:"build,omitempty"`
+	Template        *NotificationEventTemplate        `json:"template,omitempty"`
+	TemplateVersion *NotificationEventTemplateVersion `json:"template_version,omitempty"`
+	// Deadline is set for workspace_autostop_imminent events.
+	Deadline *time.Time `json:"deadline,omitempty" format:"date-time"`
+}
+
+type NotificationEventWorkspace struct {
+	ID        uuid.UUID `json:"id" format:"uuid"`
+	Name      string    `json:"name"`
+	OwnerID   uuid.UUID `json:"owner_id" format:"uuid"`
+	OwnerName string    `json:"owner_name"`
+}
+
+type NotificationEventWorkspaceBuild struct {
+	ID          uuid.UUID            `json:"id" format:"uuid"`
+	BuildNumber int32                `json:"build_number"`
+	Transition  WorkspaceTransition  `json:"transition"`
+	Reason      BuildReason          `json:"reason"`
+	Status      ProvisionerJobStatus `json:"status"`
+	Error       string               `json:"error,omitempty"`
+}
+
+type NotificationEventTemplate struct {
+	ID   uuid.UUID `json:"id" format:"uuid"`
+	Name string    `json:"name"`
+}
+
+type NotificationEventTemplateVersion struct {
+	ID   uuid.UUID `json:"id" format:"uuid"`
+	Name string    `json:"name"`
+}
+
+func (c *Client) NotificationWebhooks(ctx context.Context) ([]NotificationWebhook, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/notifications/webhooks", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var webhooks []NotificationWebhook
+	return webhooks, json.NewDecoder(res.Body).Decode(&webhooks)
+}
+
+func (c *Client) NotificationWebhook(ctx context.Context, id uuid.UUID) (NotificationWebhook, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/notifications/webhooks/%s", id.String()),
+		nil,
+	)
+	if err != nil {
+		return NotificationWebhook{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return NotificationWebhook{}, ReadBodyAsError(res)
+	}
+	var resp NotificationWebhook
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+func (c *Client) CreateNotificationWebhook(ctx context.Context, req CreateNotificationWebhookRequest) (NotificationWebhook, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/notifications/webhooks", req)
+	if err != nil {
+		return NotificationWebhook{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return NotificationWebhook{}, ReadBodyAsError(res)
+	}
+	var resp NotificationWebhook
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+func (c *Client) PatchNotificationWebhook(ctx context.Context, id uuid.UUID, req PatchNotificationWebhookRequest) (NotificationWebhook, error) {
+	res, err := c.Request(ctx, http.MethodPatch,
+		fmt.Sprintf("/api/v2/notifications/webhooks/%s", id.String()),
+		req,
+	)
+	if err != nil {
+		return NotificationWebhook{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return NotificationWebhook{}, ReadBodyAsError(res)
+	}
+	var resp NotificationWebhook
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+func (c *Client) DeleteNotificationWebhook(ctx context.Context, id uuid.UUID) error {
+	res, err := c.Request(ctx, http.MethodDelete,
+		fmt.Sprintf("/api/v2/notifications/webhooks/%s", id.String()),
+		nil,
+	)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
+
+// NotificationWebhookDeliveries returns the most recent delivery attempts of
+// a webhook, newest first. A limit of zero uses the server default.
+func (c *Client) NotificationWebhookDeliveries(ctx context.Context, id uuid.UUID, limit int) ([]NotificationWebhookDelivery, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/notifications/webhooks/%s/deliveries", id.String()),
+		nil,
+		func(r *http.Request) {
+			if limit > 0 {
+				q := r.URL.Query()
+				q.Set("limit", fmt.Sprint(limit))
+				r.URL.RawQuery = q.Encode()
+			}
+		},
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var deliveries []NotificationWebhookDelivery
+	return deliveries, json.NewDecoder(res.Body).Decode(&deliveries)
+}
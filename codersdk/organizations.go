@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,6 +26,46 @@ const (
 	ProvisionerTypeTerraform ProvisionerType = "terraform"
 )
 
+var provisionerPluginNameRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ProvisionerPlugin is an external provisioner that's launched by
+// provisioner daemons. The binary serves the provisionersdk Provisioner
+// service over its stdio.
+type ProvisionerPlugin struct {
+	Type ProvisionerType `json:"type"`
+	Path string          `json:"path"`
+}
+
+// ParseProvisionerPlugins parses external provisioner plugins in the form
+// name=path. Plugin names must be unique, and can't be the name of a
+// built-in provisioner.
+func ParseProvisionerPlugins(plugins []string) ([]ProvisionerPlugin, error) {
+	parsed := make([]ProvisionerPlugin, 0, len(plugins))
+	seen := map[ProvisionerType]struct{}{}
+	for _, plugin := range plugins {
+		name, path, ok := strings.Cut(plugin, "=")
+		if !ok || path == "" {
+			return nil, xerrors.Errorf("invalid provisioner plugin %q: must be in the form name=path", plugin)
+		}
+		if !provisionerPluginNameRegex.MatchString(name) {
+			return nil, xerrors.Errorf("invalid provisioner plugin name %q: must contain only lowercase letters, numbers and dashes", name)
+		}
+		provisionerType := ProvisionerType(name)
+		if provisionerType == ProvisionerTypeEcho || provisionerType == ProvisionerTypeTerraform {
+			return nil, xerrors.Errorf("provisioner plugin %q conflicts with a built-in provisioner", name)
+		}
+		if _, ok := seen[provisionerType]; ok {
+			return nil, xerrors.Errorf("provisioner plugin %q is specified more than once", name)
+		}
+		seen[provisionerType] = struct{}{}
+		parsed = append(parsed, ProvisionerPlugin{
+			Type: provisionerType,
+			Path: path,
+		})
+	}
+	return parsed, nil
+}
+
 // Organization is the JSON representation of a Coder organization.
 type Organization struct {
 	ID        uuid.UUID `json:"id" validate:"required" format:"uuid"`
@@ -48,7 +90,7 @@ type CreateTemplateVersionRequest struct {
 	StorageMethod   ProvisionerStorageMethod `json:"storage_method" validate:"oneof=file,required" enums:"file"`
 	FileID          uuid.UUID                `json:"file_id,omitempty" validate:"required_without=ExampleID" format:"uuid"`
 	ExampleID       string                   `json:"example_id,omitempty" validate:"required_without=FileID"`
-	Provisioner     ProvisionerType          `json:"provisioner" validate:"oneof=terraform echo,required"`
+	Provisioner     ProvisionerType          `json:"provisioner" validate:"required"`
 	ProvisionerTags map[string]string        `json:"tags"`
 
 	// ParameterValues allows for additional parameters to be provided
@@ -91,6 +133,12 @@ type CreateTemplateRequest struct {
 	// MaxTTLMillis allows optionally specifying the max lifetime for
 	// workspaces created from this template.
 	MaxTTLMillis *int64 `json:"max_ttl_ms,omitempty"`
+	// InactivityTTLMillis allows optionally specifying how long a running
+	// workspace may go unused before it is stopped automatically.
+	InactivityTTLMillis *int64 `json:"inactivity_ttl_ms,omitempty"`
+	// DeleteTTLMillis allows optionally specifying how long a stopped
+	// workspace may go unused before it is deleted automatically.
+	DeleteTTLMillis *int64 `json:"delete_ttl_ms,omitempty"`
 
 	// Allow users to cancel in-progress workspace jobs.
 	// *bool as the default value is "true".
@@ -125,9 +173,9 @@ func (c *Client) Organization(ctx context.Context, id uuid.UUID) (Organization,
 }
 
 // ProvisionerDaemonsByOrganization returns provisioner daemons available for an organization.
-func (c *Client) ProvisionerDaemons(ctx context.Context) ([]ProvisionerDaemon, error) {
+func (c *Client) ProvisionerDaemonsByOrganization(ctx context.Context, organizationID uuid.UUID) ([]ProvisionerDaemon, error) {
 	res, err := c.Request(ctx, http.MethodGet,
-		"/api/v2/provisionerdaemons",
+		fmt.Sprintf("/api/v2/organizations/%s/provisionerdaemons", organizationID.String()),
 		nil,
 	)
 	if err != nil {
@@ -143,6 +191,26 @@ func (c *Client) ProvisionerDaemons(ctx context.Context) ([]ProvisionerDaemon, e
 	return daemons, json.NewDecoder(res.Body).Decode(&daemons)
 }
 
+// ProvisionerJobQueue returns the jobs waiting for a provisioner daemon in the
+// order they will be acquired.
+func (c *Client) ProvisionerJobQueue(ctx context.Context, organizationID uuid.UUID) ([]QueuedProvisionerJob, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/organizations/%s/provisionerjobs/queue", organizationID.String()),
+		nil,
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+
+	var jobs []QueuedProvisionerJob
+	return jobs, json.NewDecoder(res.Body).Decode(&jobs)
+}
+
 // CreateTemplateVersion processes source-code and optionally associates the version with a template.
 // Executing without a template is useful for validating source-code.
 func (c *Client) CreateTemplateVersion(ctx context.Context, organizationID uuid.UUID, req CreateTemplateVersionRequest) (TemplateVersion, error) {
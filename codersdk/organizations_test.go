@@ -0,0 +1,52 @@
+package codersdk_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/codersdk"
+)
+
+func TestParseProvisionerPlugins(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		plugins, err := codersdk.ParseProvisionerPlugins([]string{
+			"vm=/usr/local/bin/vm-provisioner",
+			"bare-metal=/opt/provisioners/bare-metal=v2",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []codersdk.ProvisionerPlugin{{
+			Type: "vm",
+			Path: "/usr/local/bin/vm-provisioner",
+		}, {
+			Type: "bare-metal",
+			Path: "/opt/provisioners/bare-metal=v2",
+		}}, plugins)
+	})
+
+	for _, tc := range []struct {
+		Name   string
+		Plugin string
+	}{
+		{Name: "MissingPath", Plugin: "vm"},
+		{Name: "EmptyPath", Plugin: "vm="},
+		{Name: "InvalidName", Plugin: "VM Provisioner=/bin/vm"},
+		{Name: "BuiltIn", Plugin: "terraform=/bin/terraform"},
+	} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			_, err := codersdk.ParseProvisionerPlugins([]string{tc.Plugin})
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("Duplicate", func(t *testing.T) {
+		t.Parallel()
+		_, err := codersdk.ParseProvisionerPlugins([]string{"vm=/bin/a", "vm=/bin/b"})
+		require.ErrorContains(t, err, "more than once")
+	})
+}
@@ -35,13 +35,29 @@ const (
 	LogLevelError LogLevel = "error"
 )
 
+// ProvisionerDaemonStatus represents whether a daemon is alive, and if so,
+// whether it's running a job.
+type ProvisionerDaemonStatus string
+
+const (
+	ProvisionerDaemonIdle    ProvisionerDaemonStatus = "idle"
+	ProvisionerDaemonBusy    ProvisionerDaemonStatus = "busy"
+	ProvisionerDaemonOffline ProvisionerDaemonStatus = "offline"
+)
+
 type ProvisionerDaemon struct {
 	ID           uuid.UUID         `json:"id" format:"uuid"`
 	CreatedAt    time.Time         `json:"created_at" format:"date-time"`
 	UpdatedAt    sql.NullTime      `json:"updated_at" format:"date-time"`
+	LastSeenAt   NullTime          `json:"last_seen_at,omitempty" format:"date-time"`
 	Name         string            `json:"name"`
+	Version      string            `json:"version"`
 	Provisioners []ProvisionerType `json:"provisioners"`
 	Tags         map[string]string `json:"tags"`
+	// CurrentJobID is the job the daemon reported running in its last
+	// heartbeat.
+	CurrentJobID *uuid.UUID              `json:"current_job_id,omitempty" format:"uuid"`
+	Status       ProvisionerDaemonStatus `json:"status" enums:"idle,busy,offline"`
 }
 
 // ProvisionerJobStatus represents the at-time state of a job.
@@ -88,6 +104,63 @@ type ProvisionerJob struct {
 	Tags        map[string]string    `json:"tags"`
 }
 
+// ProvisionerJobType is the kind of work a provisioner job does.
+type ProvisionerJobType string
+
+const (
+	ProvisionerJobTypeTemplateVersionImport ProvisionerJobType = "template_version_import"
+	ProvisionerJobTypeWorkspaceBuild        ProvisionerJobType = "workspace_build"
+	ProvisionerJobTypeTemplateVersionDryRun ProvisionerJobType = "template_version_dry_run"
+)
+
+// QueuedProvisionerJob is a job waiting for a provisioner daemon to acquire
+// it.
+type QueuedProvisionerJob struct {
+	ID          uuid.UUID          `json:"id" format:"uuid"`
+	CreatedAt   time.Time          `json:"created_at" format:"date-time"`
+	Type        ProvisionerJobType `json:"type" enums:"template_version_import,workspace_build,template_version_dry_run"`
+	Provisioner ProvisionerType    `json:"provisioner"`
+	InitiatorID uuid.UUID          `json:"initiator_id" format:"uuid"`
+	Tags        map[string]string  `json:"tags"`
+	// Priority decides the order jobs are acquired in. Jobs with a higher
+	// priority are acquired first, and jobs with the same priority are
+	// acquired in the order they were created.
+	Priority int32 `json:"priority"`
+	// QueuePosition starts at 1 and counts the pending jobs that will be
+	// acquired before this one by the daemons that can run it.
+	QueuePosition int `json:"queue_position"`
+	// AvailableWorkers are the online provisioner daemons that can acquire
+	// the job. A job without any will wait until a matching daemon connects.
+	AvailableWorkers []uuid.UUID `json:"available_workers" format:"uuid"`
+}
+
+// BumpProvisionerJob moves a pending job to the front of the queue.
+func (c *Client) BumpProvisionerJob(ctx context.Context, job uuid.UUID) error {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/provisionerjobs/%s/bump", job), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
+
+// CancelProvisionerJob cancels a pending or running job, regardless of
+// what it was created for.
+func (c *Client) CancelProvisionerJob(ctx context.Context, job uuid.UUID) error {
+	res, err := c.Request(ctx, http.MethodPatch, fmt.Sprintf("/api/v2/provisionerjobs/%s/cancel", job), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
+
 // ProvisionerJobLog represents the provisioner log entry annotated with source and level.
 type ProvisionerJobLog struct {
 	ID        int64     `json:"id"`
@@ -158,36 +231,63 @@ func (c *Client) provisionerJobLogsAfter(ctx context.Context, path string, after
 	}), nil
 }
 
-// ListenProvisionerDaemon returns the gRPC service for a provisioner daemon
+// ServeProvisionerDaemonRequest is the request information needed to serve a
+// provisioner daemon.
+type ServeProvisionerDaemonRequest struct {
+	// Organization is the organization for the URL. At present provisioner
+	// daemons ARE NOT scoped to organizations and so the organization ID is
+	// optional.
+	Organization uuid.UUID `json:"organization" format:"uuid"`
+	// Provisioners is a list of provisioner types hosted by the provisioner
+	// daemon.
+	Provisioners []ProvisionerType `json:"provisioners"`
+	// Tags is a map of key-value pairs that tag the jobs this provisioner
+	// daemon can handle.
+	Tags map[string]string `json:"tags"`
+	// PreSharedKey is the deployment's pre-shared key for provisioner
+	// daemons. If set, it's used to authenticate instead of the session
+	// token.
+	PreSharedKey string `json:"pre_shared_key"`
+}
+
+// ServeProvisionerDaemon returns the gRPC service for a provisioner daemon
 // implementation. The context is during dial, not during the lifetime of the
 // client. Client should be closed after use.
-func (c *Client) ServeProvisionerDaemon(ctx context.Context, organization uuid.UUID, provisioners []ProvisionerType, tags map[string]string) (proto.DRPCProvisionerDaemonClient, error) {
-	serverURL, err := c.URL.Parse(fmt.Sprintf("/api/v2/organizations/%s/provisionerdaemons/serve", organization))
+func (c *Client) ServeProvisionerDaemon(ctx context.Context, req ServeProvisionerDaemonRequest) (proto.DRPCProvisionerDaemonClient, error) {
+	serverURL, err := c.URL.Parse(fmt.Sprintf("/api/v2/organizations/%s/provisionerdaemons/serve", req.Organization))
 	if err != nil {
 		return nil, xerrors.Errorf("parse url: %w", err)
 	}
 	query := serverURL.Query()
-	for _, provisioner := range provisioners {
+	for _, provisioner := range req.Provisioners {
 		query.Add("provisioner", string(provisioner))
 	}
-	for key, value := range tags {
+	for key, value := range req.Tags {
 		query.Add("tag", fmt.Sprintf("%s=%s", key, value))
 	}
 	serverURL.RawQuery = query.Encode()
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return nil, xerrors.Errorf("create cookie jar: %w", err)
-	}
-	jar.SetCookies(serverURL, []*http.Cookie{{
-		Name:  SessionTokenCookie,
-		Value: c.SessionToken(),
-	}})
 	httpClient := &http.Client{
-		Jar:       jar,
 		Transport: c.HTTPClient.Transport,
 	}
+	headers := http.Header{}
+	if req.PreSharedKey == "" {
+		// Authenticate with the session token if no pre-shared key is
+		// provided.
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, xerrors.Errorf("create cookie jar: %w", err)
+		}
+		jar.SetCookies(serverURL, []*http.Cookie{{
+			Name:  SessionTokenCookie,
+			Value: c.SessionToken(),
+		}})
+		httpClient.Jar = jar
+	} else {
+		headers.Set(ProvisionerDaemonPSK, req.PreSharedKey)
+	}
 	conn, res, err := websocket.Dial(ctx, serverURL.String(), &websocket.DialOptions{
 		HTTPClient: httpClient,
+		HTTPHeader: headers,
 		// Need to disable compression to avoid a data-race.
 		CompressionMode: websocket.CompressionDisabled,
 	})
@@ -30,11 +30,25 @@ type Template struct {
 	DefaultTTLMillis int64                  `json:"default_ttl_ms"`
 	// MaxTTLMillis is an enterprise feature. It's value is only used if your
 	// license is entitled to use the advanced template scheduling feature.
-	MaxTTLMillis  int64     `json:"max_ttl_ms"`
-	CreatedByID   uuid.UUID `json:"created_by_id" format:"uuid"`
-	CreatedByName string    `json:"created_by_name"`
+	MaxTTLMillis int64 `json:"max_ttl_ms"`
+	// InactivityTTLMillis and DeleteTTLMillis are enterprise features. Their
+	// values are only used if your license is entitled to use the advanced
+	// template scheduling feature.
+	InactivityTTLMillis int64 `json:"inactivity_ttl_ms"`
+	DeleteTTLMillis     int64 `json:"delete_ttl_ms"`
+	// AutostopRequirement is an enterprise feature. It is a weekly cron
+	// schedule without a timezone, e.g. "0 2 * * 0". Running workspaces must
+	// be stopped at the next occurrence of the schedule in the timezone of
+	// the workspace owner.
+	AutostopRequirement string    `json:"autostop_requirement"`
+	CreatedByID         uuid.UUID `json:"created_by_id" format:"uuid"`
+	CreatedByName       string    `json:"created_by_name"`
 
 	AllowUserCancelWorkspaceJobs bool `json:"allow_user_cancel_workspace_jobs"`
+	// RequiredPromotionApprovals is the number of approvals a version needs
+	// before it can become the active version. If it's zero, the active
+	// version can be updated directly.
+	RequiredPromotionApprovals int32 `json:"required_promotion_approvals"`
 }
 
 type TransitionStats struct {
@@ -86,8 +100,20 @@ type UpdateTemplateMeta struct {
 	// MaxTTLMillis can only be set if your license includes the advanced
 	// template scheduling feature. If you attempt to set this value while
 	// unlicensed, it will be ignored.
-	MaxTTLMillis                 int64 `json:"max_ttl_ms,omitempty"`
-	AllowUserCancelWorkspaceJobs bool  `json:"allow_user_cancel_workspace_jobs,omitempty"`
+	MaxTTLMillis int64 `json:"max_ttl_ms,omitempty"`
+	// InactivityTTLMillis and DeleteTTLMillis can only be set if your license
+	// includes the advanced template scheduling feature. If you attempt to set
+	// these values while unlicensed, they will be ignored.
+	InactivityTTLMillis int64 `json:"inactivity_ttl_ms,omitempty"`
+	DeleteTTLMillis     int64 `json:"delete_ttl_ms,omitempty"`
+	// AutostopRequirement can only be set if your license includes the
+	// advanced template scheduling feature. It is a weekly cron schedule
+	// without a timezone, e.g. "0 2 * * 0". If you attempt to set this value
+	// while unlicensed, it will be ignored.
+	AutostopRequirement          string `json:"autostop_requirement,omitempty"`
+	AllowUserCancelWorkspaceJobs bool   `json:"allow_user_cancel_workspace_jobs,omitempty"`
+	// RequiredPromotionApprovals is left unchanged if it's nil.
+	RequiredPromotionApprovals *int32 `json:"required_promotion_approvals,omitempty"`
 }
 
 type TemplateExample struct {
@@ -270,3 +296,9 @@ func (c *Client) TemplateExamples(ctx context.Context, organizationID uuid.UUID)
 	var templateExamples []TemplateExample
 	return templateExamples, json.NewDecoder(res.Body).Decode(&templateExamples)
 }
+
+// TemplateNotifyChannel is the PostgreSQL NOTIFY channel to listen for
+// template updates on. The payload is empty.
+func TemplateNotifyChannel(id uuid.UUID) string {
+	return fmt.Sprintf("template:%s", id)
+}
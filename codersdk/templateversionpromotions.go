@@ -0,0 +1,95 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TemplateVersionPromotionStatus string
+
+const (
+	TemplateVersionPromotionStatusPending  TemplateVersionPromotionStatus = "pending"
+	TemplateVersionPromotionStatusPromoted TemplateVersionPromotionStatus = "promoted"
+	// TemplateVersionPromotionStatusSuperseded is used for pending promotions
+	// once another version of the template is promoted.
+	TemplateVersionPromotionStatusSuperseded TemplateVersionPromotionStatus = "superseded"
+)
+
+// CreateTemplateVersionPromotionRequest requests that a version becomes the
+// active version of its template. A dry-run of the version is started, and the
+// version is promoted once the dry-run succeeds and enough users approve.
+type CreateTemplateVersionPromotionRequest struct {
+	TemplateVersionID uuid.UUID `json:"template_version_id" validate:"required" format:"uuid"`
+	// RichParameterValues are used for the dry-run. Parameters that aren't
+	// set use their default value.
+	RichParameterValues []WorkspaceBuildParameter `json:"rich_parameter_values,omitempty"`
+}
+
+// TemplateVersionPromotion is a request to make a template version active.
+type TemplateVersionPromotion struct {
+	ID                  uuid.UUID                      `json:"id" format:"uuid"`
+	CreatedAt           time.Time                      `json:"created_at" format:"date-time"`
+	UpdatedAt           time.Time                      `json:"updated_at" format:"date-time"`
+	TemplateID          uuid.UUID                      `json:"template_id" format:"uuid"`
+	TemplateVersionID   uuid.UUID                      `json:"template_version_id" format:"uuid"`
+	TemplateVersionName string                         `json:"template_version_name"`
+	RequesterID         uuid.UUID                      `json:"requester_id" format:"uuid"`
+	Status              TemplateVersionPromotionStatus `json:"status" enums:"pending,promoted,superseded"`
+	PromotedAt          *time.Time                     `json:"promoted_at,omitempty" format:"date-time"`
+	// RequiredApprovals is the current requirement of the template, so it
+	// can change while the promotion is pending.
+	RequiredApprovals int32          `json:"required_approvals"`
+	ApproverIDs       []uuid.UUID    `json:"approver_ids" format:"uuid"`
+	DryRun            ProvisionerJob `json:"dry_run"`
+}
+
+// CreateTemplateVersionPromotion requests the promotion of a template version.
+// If the version already has a pending promotion, its dry-run is restarted if
+// it failed, and the version is promoted if it's ready.
+func (c *Client) CreateTemplateVersionPromotion(ctx context.Context, template uuid.UUID, req CreateTemplateVersionPromotionRequest) (TemplateVersionPromotion, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/templates/%s/promotions", template), req)
+	if err != nil {
+		return TemplateVersionPromotion{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		return TemplateVersionPromotion{}, ReadBodyAsError(res)
+	}
+	var promotion TemplateVersionPromotion
+	return promotion, json.NewDecoder(res.Body).Decode(&promotion)
+}
+
+// TemplateVersionPromotions lists the promotions of a template, newest first.
+func (c *Client) TemplateVersionPromotions(ctx context.Context, template uuid.UUID) ([]TemplateVersionPromotion, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templates/%s/promotions", template), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var promotions []TemplateVersionPromotion
+	return promotions, json.NewDecoder(res.Body).Decode(&promotions)
+}
+
+// ApproveTemplateVersionPromotion records the approval of the authenticated
+// user. The version is promoted if the approval meets the requirement of the
+// template and the dry-run has succeeded.
+func (c *Client) ApproveTemplateVersionPromotion(ctx context.Context, template, promotion uuid.UUID) (TemplateVersionPromotion, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/templates/%s/promotions/%s/approve", template, promotion), nil)
+	if err != nil {
+		return TemplateVersionPromotion{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return TemplateVersionPromotion{}, ReadBodyAsError(res)
+	}
+	var approved TemplateVersionPromotion
+	return approved, json.NewDecoder(res.Body).Decode(&approved)
+}
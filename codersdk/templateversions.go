@@ -203,6 +203,9 @@ type CreateTemplateVersionDryRunRequest struct {
 	ParameterValues     []CreateParameterRequest  `json:"parameter_values"`
 	RichParameterValues []WorkspaceBuildParameter `json:"rich_parameter_values"`
 	UserVariableValues  []VariableValue           `json:"user_variable_values,omitempty"`
+	// WorkspaceID is the workspace of the template to plan the changes
+	// against. Changes are planned against an empty workspace if it's not set.
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty" format:"uuid"`
 }
 
 // CreateTemplateVersionDryRun begins a dry-run provisioner job against the
@@ -253,6 +256,54 @@ func (c *Client) TemplateVersionDryRunResources(ctx context.Context, version, jo
 	return resources, json.NewDecoder(res.Body).Decode(&resources)
 }
 
+type WorkspaceResourceChangeAction string
+
+const (
+	WorkspaceResourceChangeActionCreate  WorkspaceResourceChangeAction = "create"
+	WorkspaceResourceChangeActionUpdate  WorkspaceResourceChangeAction = "update"
+	WorkspaceResourceChangeActionReplace WorkspaceResourceChangeAction = "replace"
+	WorkspaceResourceChangeActionDelete  WorkspaceResourceChangeAction = "delete"
+)
+
+// WorkspaceResourceChange is a change to a resource planned by a template
+// version dry-run.
+type WorkspaceResourceChange struct {
+	Address        string                        `json:"address"`
+	Type           string                        `json:"type"`
+	Name           string                        `json:"name"`
+	Action         WorkspaceResourceChangeAction `json:"action" enums:"create,update,replace,delete"`
+	DailyCost      int32                         `json:"daily_cost"`
+	PriorDailyCost int32                         `json:"prior_daily_cost"`
+}
+
+// TemplateVersionDryRunChanges summarizes the changes to resources planned by
+// a template version dry-run.
+type TemplateVersionDryRunChanges struct {
+	Changes []WorkspaceResourceChange `json:"changes"`
+	// DailyCost is the total daily cost of the changed resources after the
+	// changes are applied.
+	DailyCost int32 `json:"daily_cost"`
+	// PriorDailyCost is the total daily cost of the changed resources before
+	// the changes are applied.
+	PriorDailyCost int32 `json:"prior_daily_cost"`
+}
+
+// TemplateVersionDryRunChanges returns the resource changes planned by a
+// finished template version dry-run.
+func (c *Client) TemplateVersionDryRunChanges(ctx context.Context, version, job uuid.UUID) (TemplateVersionDryRunChanges, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s/dry-run/%s/changes", version, job), nil)
+	if err != nil {
+		return TemplateVersionDryRunChanges{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return TemplateVersionDryRunChanges{}, ReadBodyAsError(res)
+	}
+
+	var changes TemplateVersionDryRunChanges
+	return changes, json.NewDecoder(res.Body).Decode(&changes)
+}
+
 // TemplateVersionDryRunLogsAfter streams logs for a template version dry-run
 // that occurred after a specific log ID.
 func (c *Client) TemplateVersionDryRunLogsAfter(ctx context.Context, version, job uuid.UUID, after int64) (<-chan ProvisionerJobLog, io.Closer, error) {
@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"net/netip"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -159,6 +160,39 @@ type WorkspaceAgentReconnectingPTYInit struct {
 	Height  uint16
 	Width   uint16
 	Command string
+	// Offset is the byte offset in the session output to replay from. When
+	// set, the agent writes ReconnectingPTYOffsetSize bytes with the offset
+	// of the first byte it sends before any output.
+	Offset *int64
+}
+
+// ReconnectingPTYOffsetSize is the size of the offset written at the start of
+// a reconnecting PTY connection that requested an offset.
+const ReconnectingPTYOffsetSize = 8
+
+// ReconnectingPTYOption customizes the initialization of a reconnecting PTY
+// session.
+type ReconnectingPTYOption func(msg *WorkspaceAgentReconnectingPTYInit)
+
+// ReconnectingPTYWithOffset replays the session output starting from offset
+// instead of the whole scrollback. The output kept by the agent may start
+// after offset, so the connection begins with the offset of the first byte
+// that follows. Read it with ReadReconnectingPTYOffset.
+func ReconnectingPTYWithOffset(offset int64) ReconnectingPTYOption {
+	return func(msg *WorkspaceAgentReconnectingPTYInit) {
+		msg.Offset = &offset
+	}
+}
+
+// ReadReconnectingPTYOffset reads the offset written at the start of a
+// reconnecting PTY connection opened with ReconnectingPTYWithOffset.
+func ReadReconnectingPTYOffset(r io.Reader) (int64, error) {
+	var data [ReconnectingPTYOffsetSize]byte
+	_, err := io.ReadFull(r, data[:])
+	if err != nil {
+		return 0, xerrors.Errorf("read offset: %w", err)
+	}
+	return int64(binary.LittleEndian.Uint64(data[:])), nil
 }
 
 // ReconnectingPTYRequest is sent from the client to the server
@@ -173,7 +207,7 @@ type ReconnectingPTYRequest struct {
 // ReconnectingPTY spawns a new reconnecting terminal session.
 // `ReconnectingPTYRequest` should be JSON marshaled and written to the returned net.Conn.
 // Raw terminal output will be read from the returned net.Conn.
-func (c *WorkspaceAgentConn) ReconnectingPTY(ctx context.Context, id uuid.UUID, height, width uint16, command string) (net.Conn, error) {
+func (c *WorkspaceAgentConn) ReconnectingPTY(ctx context.Context, id uuid.UUID, height, width uint16, command string, opts ...ReconnectingPTYOption) (net.Conn, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 	if !c.AwaitReachable(ctx) {
@@ -183,12 +217,16 @@ func (c *WorkspaceAgentConn) ReconnectingPTY(ctx context.Context, id uuid.UUID,
 	if err != nil {
 		return nil, err
 	}
-	data, err := json.Marshal(WorkspaceAgentReconnectingPTYInit{
+	msg := WorkspaceAgentReconnectingPTYInit{
 		ID:      id,
 		Height:  height,
 		Width:   width,
 		Command: command,
-	})
+	}
+	for _, opt := range opts {
+		opt(&msg)
+	}
+	data, err := json.Marshal(msg)
 	if err != nil {
 		_ = conn.Close()
 		return nil, err
@@ -286,6 +324,9 @@ type WorkspaceAgentListeningPort struct {
 	ProcessName string `json:"process_name"` // may be empty
 	Network     string `json:"network"`      // only "tcp" at the moment
 	Port        uint16 `json:"port"`
+	// ShareLevel is the sharing level of the port. It's set by coderd, the
+	// agent leaves it empty.
+	ShareLevel WorkspaceAppSharingLevel `json:"share_level,omitempty" enums:"owner,authenticated,public"`
 }
 
 // ListeningPorts lists the ports that are currently in use by the workspace.
@@ -305,16 +346,118 @@ func (c *WorkspaceAgentConn) ListeningPorts(ctx context.Context) (WorkspaceAgent
 	return resp, json.NewDecoder(res.Body).Decode(&resp)
 }
 
+type WorkspaceAgentFileInfo struct {
+	Name string `json:"name"`
+	// Path is the absolute path of the file in the workspace.
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	Mode    uint32    `json:"mode"`
+	ModTime time.Time `json:"mod_time" format:"date-time"`
+	IsDir   bool      `json:"is_dir"`
+}
+
+// FileMode returns Mode as an os.FileMode.
+func (f WorkspaceAgentFileInfo) FileMode() os.FileMode {
+	return os.FileMode(f.Mode)
+}
+
+type WorkspaceAgentListFilesResponse struct {
+	// Path is the absolute path of the listed directory.
+	Path  string                   `json:"path"`
+	Files []WorkspaceAgentFileInfo `json:"files"`
+}
+
+// StatFile returns information about a file in the workspace. Paths are
+// resolved against the agent directory and must stay within it.
+func (c *WorkspaceAgentConn) StatFile(ctx context.Context, path string) (WorkspaceAgentFileInfo, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, filesAPIPath("stat", path), nil)
+	if err != nil {
+		return WorkspaceAgentFileInfo{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentFileInfo{}, ReadBodyAsError(res)
+	}
+
+	var info WorkspaceAgentFileInfo
+	return info, json.NewDecoder(res.Body).Decode(&info)
+}
+
+// ListFiles lists the contents of a directory in the workspace. Paths are
+// resolved against the agent directory and must stay within it.
+func (c *WorkspaceAgentConn) ListFiles(ctx context.Context, path string) (WorkspaceAgentListFilesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, filesAPIPath("list", path), nil)
+	if err != nil {
+		return WorkspaceAgentListFilesResponse{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentListFilesResponse{}, ReadBodyAsError(res)
+	}
+
+	var resp WorkspaceAgentListFilesResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// ReadFile streams the contents of a file in the workspace. Paths are
+// resolved against the agent directory and must stay within it. The caller
+// must close the returned reader.
+func (c *WorkspaceAgentConn) ReadFile(ctx context.Context, path string) (io.ReadCloser, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, filesAPIPath("read", path), nil)
+	if err != nil {
+		return nil, xerrors.Errorf("do request: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, ReadBodyAsError(res)
+	}
+	return res.Body, nil
+}
+
+// WriteFile streams content to a file in the workspace, replacing it if it
+// exists. Missing parent directories are created. If mode is zero, the mode
+// of the existing file is kept, or 0644 is used for new files. Paths are
+// resolved against the agent directory and must stay within it.
+func (c *WorkspaceAgentConn) WriteFile(ctx context.Context, path string, mode os.FileMode, content io.Reader) (WorkspaceAgentFileInfo, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	apiPath := filesAPIPath("write", path)
+	if mode != 0 {
+		apiPath += "&mode=" + strconv.FormatUint(uint64(mode.Perm()), 8)
+	}
+	res, err := c.apiRequest(ctx, http.MethodPut, apiPath, content)
+	if err != nil {
+		return WorkspaceAgentFileInfo{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentFileInfo{}, ReadBodyAsError(res)
+	}
+
+	var info WorkspaceAgentFileInfo
+	return info, json.NewDecoder(res.Body).Decode(&info)
+}
+
+func filesAPIPath(endpoint, path string) string {
+	return "/api/v0/files/" + endpoint + "?path=" + url.QueryEscape(path)
+}
+
 // apiRequest makes a request to the workspace agent's HTTP API server.
 func (c *WorkspaceAgentConn) apiRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 	host := net.JoinHostPort(WorkspaceAgentIP.String(), strconv.Itoa(WorkspaceAgentHTTPAPIServerPort))
-	url := fmt.Sprintf("http://%s%s", host, path)
+	reqURL := fmt.Sprintf("http://%s%s", host, path)
 
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
 	if err != nil {
-		return nil, xerrors.Errorf("new http api request to %q: %w", url, err)
+		return nil, xerrors.Errorf("new http api request to %q: %w", reqURL, err)
 	}
 
 	return c.apiClient().Do(req)
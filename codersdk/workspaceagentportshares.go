@@ -0,0 +1,96 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type WorkspaceAgentPortShareSource string
+
+const (
+	// WorkspaceAgentPortShareSourceTemplate is a port sharing rule defined by
+	// the template.
+	WorkspaceAgentPortShareSourceTemplate WorkspaceAgentPortShareSource = "template"
+	// WorkspaceAgentPortShareSourceOwner is a port shared by the owner of the
+	// workspace. It overrides the rule of the template for the same port.
+	WorkspaceAgentPortShareSourceOwner WorkspaceAgentPortShareSource = "owner"
+)
+
+// WorkspaceAgentPortShare is a port of a workspace agent that can be accessed
+// at a sharing level, like a workspace app.
+type WorkspaceAgentPortShare struct {
+	Port       uint16                        `json:"port"`
+	ShareLevel WorkspaceAppSharingLevel      `json:"share_level" enums:"owner,authenticated,public"`
+	Source     WorkspaceAgentPortShareSource `json:"source" enums:"template,owner"`
+}
+
+type WorkspaceAgentPortShares struct {
+	// Restricted is true when the template defines port sharing rules. The
+	// agent then only allows dialing shared ports and the ports of apps.
+	// Otherwise any port can be dialed, and ports that aren't shared are
+	// shared with the owner.
+	Restricted bool                      `json:"restricted"`
+	Shares     []WorkspaceAgentPortShare `json:"shares"`
+}
+
+// ShareLevel returns the sharing level of a port, and whether it's shared.
+func (s WorkspaceAgentPortShares) ShareLevel(port uint16) (WorkspaceAppSharingLevel, bool) {
+	for _, share := range s.Shares {
+		if share.Port == port {
+			return share.ShareLevel, true
+		}
+	}
+	return WorkspaceAppSharingLevelOwner, false
+}
+
+type UpsertWorkspaceAgentPortShareRequest struct {
+	Port       uint16                   `json:"port" validate:"required"`
+	ShareLevel WorkspaceAppSharingLevel `json:"share_level" validate:"required,oneof=owner authenticated public" enums:"owner,authenticated,public"`
+}
+
+// WorkspaceAgentPortShares returns the ports shared on a workspace agent.
+func (c *Client) WorkspaceAgentPortShares(ctx context.Context, agentID uuid.UUID) (WorkspaceAgentPortShares, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/port-shares", agentID), nil)
+	if err != nil {
+		return WorkspaceAgentPortShares{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentPortShares{}, ReadBodyAsError(res)
+	}
+	var shares WorkspaceAgentPortShares
+	return shares, json.NewDecoder(res.Body).Decode(&shares)
+}
+
+// UpsertWorkspaceAgentPortShare shares a port of a workspace agent, or
+// changes the sharing level of a shared port.
+func (c *Client) UpsertWorkspaceAgentPortShare(ctx context.Context, agentID uuid.UUID, req UpsertWorkspaceAgentPortShareRequest) (WorkspaceAgentPortShare, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/workspaceagents/%s/port-shares", agentID), req)
+	if err != nil {
+		return WorkspaceAgentPortShare{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentPortShare{}, ReadBodyAsError(res)
+	}
+	var share WorkspaceAgentPortShare
+	return share, json.NewDecoder(res.Body).Decode(&share)
+}
+
+// DeleteWorkspaceAgentPortShare stops sharing a port the owner shared. Ports
+// shared by the template fall back to the template's sharing level.
+func (c *Client) DeleteWorkspaceAgentPortShare(ctx context.Context, agentID uuid.UUID, port uint16) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/workspaceagents/%s/port-shares/%d", agentID, port), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
@@ -107,6 +107,45 @@ type WorkspaceAgent struct {
 	StartupScriptTimeoutSeconds  int32  `json:"startup_script_timeout_seconds"`
 	ShutdownScript               string `json:"shutdown_script,omitempty"`
 	ShutdownScriptTimeoutSeconds int32  `json:"shutdown_script_timeout_seconds"`
+	// ReconnectingPTYBufferSize is the number of bytes of output each
+	// reconnecting PTY keeps for replay. Zero means the agent default.
+	ReconnectingPTYBufferSize int32 `json:"reconnecting_pty_buffer_size"`
+	// SessionRecording is true if the template records the PTY sessions of
+	// the agent. The deployment can also record the sessions of every agent.
+	SessionRecording bool `json:"session_recording"`
+	// Metadata is the latest result of each metadata item defined on the
+	// agent. It is only populated when fetching a single agent, use
+	// WatchWorkspaceAgentMetadata to follow updates.
+	Metadata []WorkspaceAgentMetadata `json:"metadata,omitempty"`
+}
+
+// WorkspaceAgentMetadataDescription is a metadata item defined on the agent
+// by the template. The agent runs Script every Interval seconds and reports
+// the output.
+type WorkspaceAgentMetadataDescription struct {
+	DisplayName string `json:"display_name"`
+	Key         string `json:"key"`
+	Script      string `json:"script"`
+	// Interval is the number of seconds between collections.
+	Interval int64 `json:"interval"`
+	// Timeout is the number of seconds the script may run for.
+	Timeout int64 `json:"timeout"`
+}
+
+// WorkspaceAgentMetadataResult is the latest collected value of a metadata
+// item.
+type WorkspaceAgentMetadataResult struct {
+	CollectedAt time.Time `json:"collected_at" format:"date-time"`
+	// Age is the number of seconds since the metadata was collected.
+	// It is provided in addition to CollectedAt to protect against clock skew.
+	Age   int64  `json:"age"`
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+type WorkspaceAgentMetadata struct {
+	Result      WorkspaceAgentMetadataResult      `json:"result"`
+	Description WorkspaceAgentMetadataDescription `json:"description"`
 }
 
 type DERPRegion struct {
@@ -272,10 +311,63 @@ func (c *Client) WorkspaceAgent(ctx context.Context, id uuid.UUID) (WorkspaceAge
 	return workspaceAgent, json.NewDecoder(res.Body).Decode(&workspaceAgent)
 }
 
+// WatchWorkspaceAgentMetadata streams the metadata of the given agent. The
+// full set of metadata is sent whenever the agent reports a new value. The
+// channel is closed when the context is canceled or the stream ends.
+func (c *Client) WatchWorkspaceAgentMetadata(ctx context.Context, id uuid.UUID) (<-chan []WorkspaceAgentMetadata, error) {
+	//nolint:bodyclose
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/watch-metadata", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, ReadBodyAsError(res)
+	}
+	nextEvent := ServerSentEventReader(ctx, res.Body)
+
+	metadataChan := make(chan []WorkspaceAgentMetadata, 256)
+	go func() {
+		defer close(metadataChan)
+		defer res.Body.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				sse, err := nextEvent()
+				if err != nil {
+					return
+				}
+				if sse.Type != ServerSentEventTypeData {
+					continue
+				}
+				b, ok := sse.Data.([]byte)
+				if !ok {
+					return
+				}
+				var metadata []WorkspaceAgentMetadata
+				err = json.Unmarshal(b, &metadata)
+				if err != nil {
+					return
+				}
+				select {
+				case metadataChan <- metadata:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return metadataChan, nil
+}
+
 // WorkspaceAgentReconnectingPTY spawns a PTY that reconnects using the token provided.
 // It communicates using `agent.ReconnectingPTYRequest` marshaled as JSON.
 // Responses are PTY output that can be rendered.
-func (c *Client) WorkspaceAgentReconnectingPTY(ctx context.Context, agentID, reconnect uuid.UUID, height, width uint16, command string) (net.Conn, error) {
+func (c *Client) WorkspaceAgentReconnectingPTY(ctx context.Context, agentID, reconnect uuid.UUID, height, width uint16, command string, opts ...ReconnectingPTYOption) (net.Conn, error) {
 	serverURL, err := c.URL.Parse(fmt.Sprintf("/api/v2/workspaceagents/%s/pty", agentID))
 	if err != nil {
 		return nil, xerrors.Errorf("parse url: %w", err)
@@ -285,6 +377,13 @@ func (c *Client) WorkspaceAgentReconnectingPTY(ctx context.Context, agentID, rec
 	q.Set("height", strconv.Itoa(int(height)))
 	q.Set("width", strconv.Itoa(int(width)))
 	q.Set("command", command)
+	var msg WorkspaceAgentReconnectingPTYInit
+	for _, opt := range opts {
+		opt(&msg)
+	}
+	if msg.Offset != nil {
+		q.Set("offset", strconv.FormatInt(*msg.Offset, 10))
+	}
 	serverURL.RawQuery = q.Encode()
 
 	jar, err := cookiejar.New(nil)
@@ -46,6 +46,14 @@ const (
 	// "autostop" is used when a build to stop a workspace is triggered by Autostop.
 	// The initiator id/username in this case is the workspace owner and can be ignored.
 	BuildReasonAutostop BuildReason = "autostop"
+	// "dormancy" is used when a build to stop a workspace is triggered because
+	// the workspace has not been used for longer than the template inactivity TTL.
+	// The initiator id/username in this case is the workspace owner and can be ignored.
+	BuildReasonDormancy BuildReason = "dormancy"
+	// "autodelete" is used when a build to delete a workspace is triggered because
+	// the workspace has been stopped and unused for longer than the template delete TTL.
+	// The initiator id/username in this case is the workspace owner and can be ignored.
+	BuildReasonAutodelete BuildReason = "autodelete"
 )
 
 // WorkspaceBuild is an at-point representation of a workspace state.
@@ -65,7 +73,7 @@ type WorkspaceBuild struct {
 	InitiatorID         uuid.UUID           `json:"initiator_id" format:"uuid"`
 	InitiatorUsername   string              `json:"initiator_name"`
 	Job                 ProvisionerJob      `json:"job"`
-	Reason              BuildReason         `db:"reason" json:"reason" enums:"initiator,autostart,autostop"`
+	Reason              BuildReason         `db:"reason" json:"reason" enums:"initiator,autostart,autostop,dormancy,autodelete"`
 	Resources           []WorkspaceResource `json:"resources"`
 	Deadline            NullTime            `json:"deadline,omitempty" format:"date-time"`
 	MaxDeadline         NullTime            `json:"max_deadline,omitempty" format:"date-time"`
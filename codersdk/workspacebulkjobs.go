@@ -0,0 +1,130 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WorkspaceBulkJobAction string
+
+const (
+	WorkspaceBulkJobActionStart WorkspaceBulkJobAction = "start"
+	WorkspaceBulkJobActionStop  WorkspaceBulkJobAction = "stop"
+	// WorkspaceBulkJobActionUpdate starts workspaces on the active version of
+	// their template.
+	WorkspaceBulkJobActionUpdate WorkspaceBulkJobAction = "update"
+	WorkspaceBulkJobActionDelete WorkspaceBulkJobAction = "delete"
+)
+
+type WorkspaceBulkJobStatus string
+
+const (
+	WorkspaceBulkJobStatusPending   WorkspaceBulkJobStatus = "pending"
+	WorkspaceBulkJobStatusRunning   WorkspaceBulkJobStatus = "running"
+	WorkspaceBulkJobStatusSucceeded WorkspaceBulkJobStatus = "succeeded"
+	WorkspaceBulkJobStatusFailed    WorkspaceBulkJobStatus = "failed"
+	// WorkspaceBulkJobStatusSkipped is only used for workspaces that are
+	// already in the state the action would put them in.
+	WorkspaceBulkJobStatusSkipped WorkspaceBulkJobStatus = "skipped"
+)
+
+// Done returns whether the status is final.
+func (s WorkspaceBulkJobStatus) Done() bool {
+	switch s {
+	case WorkspaceBulkJobStatusSucceeded, WorkspaceBulkJobStatusFailed, WorkspaceBulkJobStatusSkipped:
+		return true
+	default:
+		return false
+	}
+}
+
+// CreateWorkspaceBulkJobRequest creates builds for every workspace matching
+// the filter that the user is allowed to perform the action on.
+type CreateWorkspaceBulkJobRequest struct {
+	Action WorkspaceBulkJobAction `json:"action" validate:"required,oneof=start stop update delete" enums:"start,stop,update,delete"`
+	// Filter uses the same syntax as the workspaces search query, e.g.
+	// "template:docker outdated:true".
+	Filter string `json:"filter"`
+	// BatchSize is the number of builds to run at once. The next batch is
+	// started once every build in the current batch has completed. Defaults
+	// to 10.
+	BatchSize int `json:"batch_size,omitempty" validate:"omitempty,min=1,max=1000"`
+	// DryRun returns the workspaces that would be built without creating the
+	// job.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// WorkspaceBulkJob is a persisted bulk operation on many workspaces.
+type WorkspaceBulkJob struct {
+	// ID is empty for dry runs.
+	ID          uuid.UUID                   `json:"id" format:"uuid"`
+	CreatedAt   time.Time                   `json:"created_at" format:"date-time"`
+	UpdatedAt   time.Time                   `json:"updated_at" format:"date-time"`
+	CompletedAt *time.Time                  `json:"completed_at,omitempty" format:"date-time"`
+	InitiatorID uuid.UUID                   `json:"initiator_id" format:"uuid"`
+	Action      WorkspaceBulkJobAction      `json:"action" enums:"start,stop,update,delete"`
+	Filter      string                      `json:"filter"`
+	BatchSize   int                         `json:"batch_size"`
+	Status      WorkspaceBulkJobStatus      `json:"status" enums:"pending,running,succeeded,failed"`
+	Error       string                      `json:"error,omitempty"`
+	Progress    WorkspaceBulkJobProgress    `json:"progress"`
+	Workspaces  []WorkspaceBulkJobWorkspace `json:"workspaces"`
+}
+
+// WorkspaceBulkJobProgress counts the workspaces of a bulk job by status.
+type WorkspaceBulkJobProgress struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Running   int `json:"running"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+}
+
+// Done returns the number of workspaces that have a final status.
+func (p WorkspaceBulkJobProgress) Done() int {
+	return p.Succeeded + p.Failed + p.Skipped
+}
+
+type WorkspaceBulkJobWorkspace struct {
+	WorkspaceID      uuid.UUID              `json:"workspace_id" format:"uuid"`
+	WorkspaceName    string                 `json:"workspace_name"`
+	OwnerName        string                 `json:"owner_name"`
+	WorkspaceBuildID *uuid.UUID             `json:"workspace_build_id,omitempty" format:"uuid"`
+	Status           WorkspaceBulkJobStatus `json:"status" enums:"pending,running,succeeded,failed,skipped"`
+	Error            string                 `json:"error,omitempty"`
+}
+
+// CreateWorkspaceBulkJob starts a bulk operation on the workspaces matching
+// the filter. For dry runs, the returned job isn't persisted.
+func (c *Client) CreateWorkspaceBulkJob(ctx context.Context, req CreateWorkspaceBulkJobRequest) (WorkspaceBulkJob, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaces/bulk", req)
+	if err != nil {
+		return WorkspaceBulkJob{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		return WorkspaceBulkJob{}, ReadBodyAsError(res)
+	}
+	var job WorkspaceBulkJob
+	return job, json.NewDecoder(res.Body).Decode(&job)
+}
+
+// WorkspaceBulkJob returns the progress of a bulk job.
+func (c *Client) WorkspaceBulkJob(ctx context.Context, id uuid.UUID) (WorkspaceBulkJob, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/bulk/%s", id), nil)
+	if err != nil {
+		return WorkspaceBulkJob{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceBulkJob{}, ReadBodyAsError(res)
+	}
+	var job WorkspaceBulkJob
+	return job, json.NewDecoder(res.Body).Decode(&job)
+}
@@ -34,6 +34,12 @@ type Workspace struct {
 	AutostartSchedule                    *string        `json:"autostart_schedule,omitempty"`
 	TTLMillis                            *int64         `json:"ttl_ms,omitempty"`
 	LastUsedAt                           time.Time      `json:"last_used_at" format:"date-time"`
+	// DormantAt is when the workspace will be stopped for inactivity. It is
+	// only set for running workspaces whose template has an inactivity TTL.
+	DormantAt *time.Time `json:"dormant_at,omitempty" format:"date-time"`
+	// DeletingAt is when the workspace will be deleted. It is only set for
+	// stopped workspaces whose template has a delete TTL.
+	DeletingAt *time.Time `json:"deleting_at,omitempty" format:"date-time"`
 }
 
 type WorkspacesRequest struct {
@@ -0,0 +1,67 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionRecordingContentType is the content type of session recordings,
+// which are stored in asciicast v2 format.
+// See https://docs.asciinema.org/manual/asciicast/v2/
+const SessionRecordingContentType = "application/x-asciicast"
+
+type WorkspaceSessionType string
+
+const (
+	WorkspaceSessionTypeSSH             WorkspaceSessionType = "ssh"
+	WorkspaceSessionTypeReconnectingPTY WorkspaceSessionType = "reconnecting_pty"
+)
+
+// WorkspaceSessionRecording describes a recorded PTY session of a workspace
+// agent. The recording itself is fetched with WorkspaceSessionRecording.
+type WorkspaceSessionRecording struct {
+	ID          uuid.UUID            `json:"id" format:"uuid"`
+	CreatedAt   time.Time            `json:"created_at" format:"date-time"`
+	WorkspaceID uuid.UUID            `json:"workspace_id" format:"uuid"`
+	AgentID     uuid.UUID            `json:"agent_id" format:"uuid"`
+	Type        WorkspaceSessionType `json:"type" enums:"ssh,reconnecting_pty"`
+	StartedAt   time.Time            `json:"started_at" format:"date-time"`
+	EndedAt     time.Time            `json:"ended_at" format:"date-time"`
+	// Size is the size of the recording in bytes.
+	Size int64 `json:"size"`
+}
+
+// WorkspaceSessionRecordings lists the recorded sessions of a workspace,
+// most recent first.
+func (c *Client) WorkspaceSessionRecordings(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceSessionRecording, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/session-recordings", workspaceID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var recordings []WorkspaceSessionRecording
+	return recordings, json.NewDecoder(res.Body).Decode(&recordings)
+}
+
+// WorkspaceSessionRecording downloads a recorded session of a workspace in
+// asciicast v2 format.
+func (c *Client) WorkspaceSessionRecording(ctx context.Context, workspaceID, recordingID uuid.UUID) ([]byte, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/session-recordings/%s", workspaceID, recordingID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	return io.ReadAll(res.Body)
+}
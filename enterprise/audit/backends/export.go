@@ -0,0 +1,65 @@
+package backends
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+// exportedLog is the JSON representation of an audit log shared by all
+// backends that ship audit logs to external systems.
+type exportedLog struct {
+	ID               uuid.UUID             `json:"id"`
+	Time             time.Time             `json:"time"`
+	UserID           uuid.UUID             `json:"user_id"`
+	OrganizationID   uuid.UUID             `json:"organization_id"`
+	IP               string                `json:"ip,omitempty"`
+	UserAgent        string                `json:"user_agent,omitempty"`
+	ResourceType     database.ResourceType `json:"resource_type"`
+	ResourceID       uuid.UUID             `json:"resource_id"`
+	ResourceTarget   string                `json:"resource_target"`
+	ResourceIcon     string                `json:"resource_icon,omitempty"`
+	Action           database.AuditAction  `json:"action"`
+	Diff             json.RawMessage       `json:"diff,omitempty"`
+	StatusCode       int32                 `json:"status_code"`
+	AdditionalFields json.RawMessage       `json:"additional_fields,omitempty"`
+	RequestID        uuid.UUID             `json:"request_id"`
+}
+
+func newExportedLog(alog database.AuditLog) exportedLog {
+	l := exportedLog{
+		ID:               alog.ID,
+		Time:             alog.Time,
+		UserID:           alog.UserID,
+		OrganizationID:   alog.OrganizationID,
+		UserAgent:        alog.UserAgent.String,
+		ResourceType:     alog.ResourceType,
+		ResourceID:       alog.ResourceID,
+		ResourceTarget:   alog.ResourceTarget,
+		ResourceIcon:     alog.ResourceIcon,
+		Action:           alog.Action,
+		StatusCode:       alog.StatusCode,
+		RequestID:        alog.RequestID,
+		Diff:             rawOrNil(alog.Diff),
+		AdditionalFields: rawOrNil(alog.AdditionalFields),
+	}
+	if alog.Ip.Valid {
+		l.IP = alog.Ip.IPNet.IP.String()
+	}
+	return l
+}
+
+// rawOrNil avoids marshaling errors for empty, non-nil raw messages.
+func rawOrNil(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	return raw
+}
+
+func marshalLog(alog database.AuditLog) ([]byte, error) {
+	return json.Marshal(newExportedLog(alog))
+}
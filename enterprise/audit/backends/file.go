@@ -0,0 +1,68 @@
+package backends
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/enterprise/audit"
+)
+
+type FileOptions struct {
+	// Path is the file audit logs are appended to as JSON lines.
+	Path string
+	// MaxSizeMB is the size in megabytes at which the file is rotated.
+	// Defaults to 100 when zero.
+	MaxSizeMB int
+	// MaxBackups is the number of rotated files to retain. All rotated
+	// files are retained when zero.
+	MaxBackups int
+}
+
+// File is an audit backend that writes audit logs as JSON lines to a
+// rotating file.
+type File struct {
+	mu     sync.Mutex
+	writer *lumberjack.Logger
+}
+
+func NewFile(opts FileOptions) *File {
+	return &File{
+		writer: &lumberjack.Logger{
+			Filename:   opts.Path,
+			MaxSize:    opts.MaxSizeMB,
+			MaxBackups: opts.MaxBackups,
+		},
+	}
+}
+
+var _ audit.Backend = &File{}
+
+func (*File) Decision() audit.FilterDecision {
+	return audit.FilterDecisionExport
+}
+
+func (f *File) Export(_ context.Context, alog database.AuditLog) error {
+	data, err := marshalLog(alog)
+	if err != nil {
+		return xerrors.Errorf("marshal audit log: %w", err)
+	}
+	data = append(data, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.writer.Write(data)
+	if err != nil {
+		return xerrors.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writer.Close()
+}
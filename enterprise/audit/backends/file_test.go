@@ -0,0 +1,62 @@
+package backends_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/enterprise/audit/audittest"
+	"github.com/coder/coder/enterprise/audit/backends"
+)
+
+func TestFileBackend(t *testing.T) {
+	t.Parallel()
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+
+			path    = filepath.Join(t.TempDir(), "audit.jsonl")
+			backend = backends.NewFile(backends.FileOptions{Path: path})
+
+			alogs = []database.AuditLog{
+				audittest.RandomLog(),
+				audittest.RandomLog(),
+			}
+		)
+		defer cancel()
+
+		for _, alog := range alogs {
+			err := backend.Export(ctx, alog)
+			require.NoError(t, err)
+		}
+		err := backend.Close()
+		require.NoError(t, err)
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		var lines int
+		for scanner.Scan() {
+			var got map[string]interface{}
+			err := json.Unmarshal(scanner.Bytes(), &got)
+			require.NoError(t, err)
+			alog := alogs[lines]
+			require.Equal(t, alog.ID.String(), got["id"])
+			require.Equal(t, alog.Ip.IPNet.IP.String(), got["ip"])
+			require.Equal(t, string(alog.Action), got["action"])
+			lines++
+		}
+		require.NoError(t, scanner.Err())
+		require.Equal(t, len(alogs), lines)
+	})
+}
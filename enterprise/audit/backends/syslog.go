@@ -0,0 +1,241 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/enterprise/audit"
+	"github.com/coder/retry"
+)
+
+const (
+	// syslogFacilityAudit is the "log audit" facility from RFC 5424.
+	syslogFacilityAudit = 13
+	// syslogSeverityInfo is the "informational" severity from RFC 5424.
+	syslogSeverityInfo = 6
+	// syslogTimestampFormat is RFC 3339 limited to the six fractional
+	// digits allowed by RFC 5424.
+	syslogTimestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+	syslogMsgID           = "audit_log"
+	syslogDialTimeout     = 10 * time.Second
+	syslogWriteTimeout    = 10 * time.Second
+	syslogCloseTimeout    = 10 * time.Second
+	// syslogQueueSize is the number of messages kept in memory while the
+	// server is unreachable. Audit logs are dropped when the queue is full.
+	syslogQueueSize = 1000
+)
+
+type SyslogOptions struct {
+	// Network is one of "udp", "tcp", "unix" or "unixgram".
+	Network string
+	// Address is a host:port pair, or a socket path for unix networks.
+	Address string
+	// Hostname is sent as the HOSTNAME header field. Defaults to
+	// os.Hostname().
+	Hostname string
+	// AppName is sent as the APP-NAME header field. Defaults to "coder".
+	AppName string
+}
+
+// Syslog is an audit backend that sends audit logs as RFC 5424 messages
+// to a syslog server. The message body is the audit log as JSON. Export
+// never blocks on the network; messages are queued and written in the
+// background, redialing the server whenever the connection fails.
+//
+// Messages sent over stream connections are framed using octet counting
+// as described in RFC 6587.
+type Syslog struct {
+	logger slog.Logger
+	opts   SyslogOptions
+	pid    int
+
+	queue  chan []byte
+	cancel context.CancelFunc
+	closed chan struct{}
+
+	// conn and unsent are only used by the background writer, and by
+	// Close once the writer has stopped.
+	conn net.Conn
+	// unsent is a message the writer was sending when it was stopped.
+	unsent []byte
+}
+
+func NewSyslog(logger slog.Logger, opts SyslogOptions) (*Syslog, error) {
+	switch opts.Network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return nil, xerrors.Errorf("unsupported syslog network %q", opts.Network)
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if opts.AppName == "" {
+		opts.AppName = "coder"
+	}
+	opts.Hostname = syslogHeaderField(opts.Hostname, 255)
+	opts.AppName = syslogHeaderField(opts.AppName, 48)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Syslog{
+		logger: logger,
+		opts:   opts,
+		pid:    os.Getpid(),
+		queue:  make(chan []byte, syslogQueueSize),
+		cancel: cancel,
+		closed: make(chan struct{}),
+	}
+	go s.run(ctx)
+	return s, nil
+}
+
+var _ audit.Backend = &Syslog{}
+
+func (*Syslog) Decision() audit.FilterDecision {
+	return audit.FilterDecisionExport
+}
+
+func (s *Syslog) Export(ctx context.Context, alog database.AuditLog) error {
+	data, err := marshalLog(alog)
+	if err != nil {
+		return xerrors.Errorf("marshal audit log: %w", err)
+	}
+	select {
+	case s.queue <- s.format(alog.Time, data):
+	default:
+		s.logger.Error(ctx, "syslog queue is full, dropping audit log", slog.F("audit_log_id", alog.ID))
+	}
+	return nil
+}
+
+// Close stops the background writer and attempts to send all queued
+// messages.
+func (s *Syslog) Close() error {
+	s.cancel()
+	<-s.closed
+
+	ctx, cancel := context.WithTimeout(context.Background(), syslogCloseTimeout)
+	defer cancel()
+	if s.unsent != nil {
+		s.send(ctx, s.unsent)
+	}
+	for done := false; !done; {
+		select {
+		case msg := <-s.queue:
+			s.send(ctx, msg)
+		default:
+			done = true
+		}
+	}
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+func (s *Syslog) run(ctx context.Context) {
+	defer close(s.closed)
+	// Connect eagerly so an unreachable server is logged at startup
+	// rather than with the first audit log.
+	if !s.dial(ctx) {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-s.queue:
+			if !s.send(ctx, msg) && ctx.Err() != nil {
+				s.unsent = msg
+				return
+			}
+		}
+	}
+}
+
+// send writes a message, dialing the server first if there is no
+// connection. It returns false if the message was not sent.
+func (s *Syslog) send(ctx context.Context, msg []byte) bool {
+	for attempt := 0; ; attempt++ {
+		if s.conn == nil && !s.dial(ctx) {
+			return false
+		}
+		err := s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+		if err == nil {
+			_, err = s.conn.Write(msg)
+		}
+		if err == nil {
+			return true
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+		// The connection may have been closed by the server, so redial
+		// once before giving up.
+		if attempt > 0 {
+			s.logger.Error(ctx, "write syslog message, dropping audit log", slog.Error(err))
+			return false
+		}
+		s.logger.Warn(ctx, "write syslog message", slog.Error(err))
+	}
+}
+
+// dial connects to the server, retrying with a backoff until it succeeds
+// or ctx is canceled.
+func (s *Syslog) dial(ctx context.Context) bool {
+	dialer := net.Dialer{Timeout: syslogDialTimeout}
+	for r := retry.New(250*time.Millisecond, 30*time.Second); r.Wait(ctx); {
+		conn, err := dialer.DialContext(ctx, s.opts.Network, s.opts.Address)
+		if err == nil {
+			s.conn = conn
+			return true
+		}
+		s.logger.Warn(ctx, "dial syslog",
+			slog.F("network", s.opts.Network), slog.F("address", s.opts.Address), slog.Error(err))
+	}
+	return false
+}
+
+// format renders an RFC 5424 message. Structured data is never sent.
+func (s *Syslog) format(t time.Time, body []byte) []byte {
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		syslogFacilityAudit*8+syslogSeverityInfo,
+		t.UTC().Format(syslogTimestampFormat),
+		s.opts.Hostname,
+		s.opts.AppName,
+		s.pid,
+		syslogMsgID,
+		body,
+	)
+	switch s.opts.Network {
+	case "tcp", "unix":
+		return []byte(fmt.Sprintf("%d %s", len(msg), msg))
+	default:
+		return []byte(msg)
+	}
+}
+
+// syslogHeaderField restricts a header field to printable US-ASCII
+// without spaces, as required by RFC 5424. Empty fields are sent as the
+// NILVALUE "-".
+func syslogHeaderField(s string, maxLen int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,155 @@
+package backends_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/enterprise/audit/audittest"
+	"github.com/coder/coder/enterprise/audit/backends"
+	"github.com/coder/coder/testutil"
+)
+
+// rfc5424Header matches the header of a message without structured data.
+var rfc5424Header = regexp.MustCompile(`^<110>1 \S+ test-host coder \d+ audit_log - `)
+
+func TestSyslogBackend(t *testing.T) {
+	t.Parallel()
+	t.Run("UDP", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			alog        = audittest.RandomLog()
+		)
+		defer cancel()
+
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		backend, err := backends.NewSyslog(slogtest.Make(t, nil), backends.SyslogOptions{
+			Network:  "udp",
+			Address:  conn.LocalAddr().String(),
+			Hostname: "test-host",
+		})
+		require.NoError(t, err)
+		defer backend.Close()
+
+		err = backend.Export(ctx, alog)
+		require.NoError(t, err)
+
+		buf := make([]byte, 64<<10)
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		requireSyslogMessage(t, string(buf[:n]), alog.ID.String())
+	})
+
+	t.Run("TCP", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		backend, err := backends.NewSyslog(slogtest.Make(t, nil), backends.SyslogOptions{
+			Network:  "tcp",
+			Address:  ln.Addr().String(),
+			Hostname: "test-host",
+		})
+		require.NoError(t, err)
+		defer backend.Close()
+
+		conn, err := ln.Accept()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		ids := make([]string, 0, 2)
+		for i := 0; i < 2; i++ {
+			alog := audittest.RandomLog()
+			ids = append(ids, alog.ID.String())
+			err = backend.Export(ctx, alog)
+			require.NoError(t, err)
+		}
+
+		r := bufio.NewReader(conn)
+		for _, id := range ids {
+			requireSyslogMessage(t, readSyslogFrame(t, r), id)
+		}
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Reserve an address with nothing listening on it.
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		// An unreachable server must not prevent startup.
+		backend, err := backends.NewSyslog(slogtest.Make(t, nil), backends.SyslogOptions{
+			Network:  "tcp",
+			Address:  address,
+			Hostname: "test-host",
+		})
+		require.NoError(t, err)
+		defer backend.Close()
+
+		alog := audittest.RandomLog()
+		err = backend.Export(ctx, alog)
+		require.NoError(t, err)
+
+		// Queued messages are sent once the server is reachable.
+		ln, err = net.Listen("tcp", address)
+		require.NoError(t, err)
+		defer ln.Close()
+
+		conn, err := ln.Accept()
+		require.NoError(t, err)
+		defer conn.Close()
+		deadline, _ := ctx.Deadline()
+		err = conn.SetReadDeadline(deadline)
+		require.NoError(t, err)
+
+		requireSyslogMessage(t, readSyslogFrame(t, bufio.NewReader(conn)), alog.ID.String())
+	})
+}
+
+// readSyslogFrame reads a message framed using octet counting.
+func readSyslogFrame(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	length, err := r.ReadString(' ')
+	require.NoError(t, err)
+	n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+	require.NoError(t, err)
+	msg := make([]byte, n)
+	_, err = io.ReadFull(r, msg)
+	require.NoError(t, err)
+	return string(msg)
+}
+
+func requireSyslogMessage(t *testing.T, msg string, id string) {
+	t.Helper()
+	require.Regexp(t, rfc5424Header, msg)
+	body := rfc5424Header.ReplaceAllString(msg, "")
+	var got map[string]interface{}
+	err := json.Unmarshal([]byte(body), &got)
+	require.NoError(t, err, "body: %q", body)
+	require.Equal(t, id, got["id"])
+}
@@ -0,0 +1,389 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/webhook"
+	"github.com/coder/coder/enterprise/audit"
+	"github.com/coder/retry"
+)
+
+const (
+	defaultWebhookBatchSize     = 100
+	defaultWebhookFlushInterval = 5 * time.Second
+	defaultWebhookMaxAttempts   = 5
+	// webhookMaxPendingBatches is the number of batches kept in memory
+	// before they are written to the buffer directory without waiting for
+	// the next flush.
+	webhookMaxPendingBatches = 10
+	webhookCloseTimeout      = 10 * time.Second
+	webhookBufferExt         = ".json"
+)
+
+type WebhookOptions struct {
+	// URL receives a POST request with a JSON body of the form
+	// {"audit_logs": [...]} for every batch.
+	URL *url.URL
+	// Secret is used to sign request bodies when set. See
+	// webhook.SignatureHeader.
+	Secret string
+	// BatchSize is the maximum number of audit logs sent in a single
+	// request. Defaults to 100.
+	BatchSize int
+	// FlushInterval is how often pending audit logs are sent, even if
+	// the batch is not full. Defaults to 5s.
+	FlushInterval time.Duration
+	// MaxAttempts is the number of times a request is attempted before
+	// the batch is buffered to disk. Defaults to 5.
+	MaxAttempts int
+	// BufferDir stores batches that could not be delivered. They are
+	// resent, oldest first, before newer audit logs. Undeliverable batches
+	// are dropped if empty.
+	BufferDir string
+	// HTTPClient defaults to a client with a 30s timeout.
+	HTTPClient *http.Client
+}
+
+// Webhook is an audit backend that sends batches of audit logs to an HTTP
+// endpoint. Export never blocks on the network; audit logs are delivered
+// in the background.
+type Webhook struct {
+	logger slog.Logger
+	opts   WebhookOptions
+
+	mu      sync.Mutex
+	pending []json.RawMessage
+
+	// sendMu ensures a single flush runs at a time so batches are
+	// delivered in order.
+	sendMu    sync.Mutex
+	bufferSeq atomic.Int64
+
+	flushNow chan struct{}
+	cancel   context.CancelFunc
+	closed   chan struct{}
+}
+
+func NewWebhook(logger slog.Logger, opts WebhookOptions) (*Webhook, error) {
+	if opts.URL == nil || opts.URL.String() == "" {
+		return nil, xerrors.New("webhook url is required")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultWebhookBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultWebhookFlushInterval
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultWebhookMaxAttempts
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.BufferDir != "" {
+		err := os.MkdirAll(opts.BufferDir, 0o700)
+		if err != nil {
+			return nil, xerrors.Errorf("create buffer dir: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Webhook{
+		logger:   logger,
+		opts:     opts,
+		flushNow: make(chan struct{}, 1),
+		cancel:   cancel,
+		closed:   make(chan struct{}),
+	}
+	go w.run(ctx)
+	return w, nil
+}
+
+var _ audit.Backend = &Webhook{}
+
+func (*Webhook) Decision() audit.FilterDecision {
+	return audit.FilterDecisionExport
+}
+
+func (w *Webhook) Export(ctx context.Context, alog database.AuditLog) error {
+	data, err := marshalLog(alog)
+	if err != nil {
+		return xerrors.Errorf("marshal audit log: %w", err)
+	}
+
+	w.mu.Lock()
+	w.pending = append(w.pending, data)
+	n := len(w.pending)
+	var overflow []json.RawMessage
+	if w.opts.BufferDir != "" && n >= w.opts.BatchSize*webhookMaxPendingBatches {
+		overflow = w.pending
+		w.pending = nil
+	}
+	w.mu.Unlock()
+
+	if overflow != nil {
+		// The endpoint can't keep up, so avoid growing memory
+		// unbounded by writing to the buffer directly.
+		w.bufferLogs(ctx, overflow)
+	}
+	if n >= w.opts.BatchSize {
+		select {
+		case w.flushNow <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close stops the background flusher and attempts to deliver all pending
+// audit logs. Audit logs that could not be delivered are buffered to
+// disk.
+func (w *Webhook) Close() error {
+	w.cancel()
+	<-w.closed
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookCloseTimeout)
+	defer cancel()
+	w.flush(ctx)
+	return nil
+}
+
+func (w *Webhook) run(ctx context.Context) {
+	defer close(w.closed)
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.flushNow:
+		}
+		w.flush(ctx)
+	}
+}
+
+func (w *Webhook) flush(ctx context.Context) {
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+
+	// Buffered batches are always older than pending audit logs, so they
+	// are delivered first.
+	delivered := w.sendBuffered(ctx)
+
+	w.mu.Lock()
+	logs := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	for len(logs) > 0 {
+		n := len(logs)
+		if n > w.opts.BatchSize {
+			n = w.opts.BatchSize
+		}
+		batch := logs[:n]
+		logs = logs[n:]
+
+		body, err := json.Marshal(webhookPayload{AuditLogs: batch})
+		if err != nil {
+			w.logger.Error(ctx, "marshal audit log batch", slog.Error(err))
+			continue
+		}
+		if !delivered {
+			w.buffer(ctx, body)
+			continue
+		}
+		err = w.send(ctx, body)
+		if err == nil {
+			continue
+		}
+		if isPermanentWebhookError(err) {
+			w.logger.Error(ctx, "webhook rejected audit logs, dropping batch",
+				slog.F("count", len(batch)), slog.Error(err))
+			continue
+		}
+		w.logger.Warn(ctx, "send audit logs to webhook", slog.F("count", len(batch)), slog.Error(err))
+		// Don't hammer an unhealthy endpoint with the remaining
+		// batches, buffer them until the next flush instead.
+		delivered = false
+		w.buffer(ctx, body)
+	}
+}
+
+// sendBuffered delivers batches from the buffer directory in the order
+// they were written. It returns false if a batch could not be delivered.
+func (w *Webhook) sendBuffered(ctx context.Context) bool {
+	if w.opts.BufferDir == "" {
+		return true
+	}
+	names, err := w.bufferedBatches()
+	if err != nil {
+		w.logger.Error(ctx, "list buffered audit logs", slog.Error(err))
+		return true
+	}
+	for _, name := range names {
+		path := filepath.Join(w.opts.BufferDir, name)
+		body, err := os.ReadFile(path)
+		if err != nil {
+			w.logger.Error(ctx, "read buffered audit logs", slog.F("path", path), slog.Error(err))
+			continue
+		}
+		err = w.send(ctx, body)
+		if err != nil && !isPermanentWebhookError(err) {
+			w.logger.Warn(ctx, "send buffered audit logs to webhook", slog.F("path", path), slog.Error(err))
+			return false
+		}
+		if err != nil {
+			w.logger.Error(ctx, "webhook rejected buffered audit logs, dropping batch",
+				slog.F("path", path), slog.Error(err))
+		}
+		err = os.Remove(path)
+		if err != nil {
+			w.logger.Error(ctx, "remove buffered audit logs", slog.F("path", path), slog.Error(err))
+		}
+	}
+	return true
+}
+
+func (w *Webhook) bufferedBatches() ([]string, error) {
+	entries, err := os.ReadDir(w.opts.BufferDir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != webhookBufferExt {
+			continue
+		}
+		names = append(names, name)
+	}
+	// Names are prefixed with a zero-padded timestamp, so sorting them
+	// sorts batches by age.
+	sort.Strings(names)
+	return names, nil
+}
+
+func (w *Webhook) bufferLogs(ctx context.Context, logs []json.RawMessage) {
+	for len(logs) > 0 {
+		n := len(logs)
+		if n > w.opts.BatchSize {
+			n = w.opts.BatchSize
+		}
+		body, err := json.Marshal(webhookPayload{AuditLogs: logs[:n]})
+		if err != nil {
+			w.logger.Error(ctx, "marshal audit log batch", slog.Error(err))
+		} else {
+			w.buffer(ctx, body)
+		}
+		logs = logs[n:]
+	}
+}
+
+// buffer writes a batch to the buffer directory so it can be delivered by a
+// later flush.
+func (w *Webhook) buffer(ctx context.Context, body []byte) {
+	if w.opts.BufferDir == "" {
+		w.logger.Error(ctx, "no webhook buffer directory configured, dropping audit logs")
+		return
+	}
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), w.bufferSeq.Add(1), webhookBufferExt)
+	// Write to a temporary file first so partially written batches are
+	// never sent.
+	f, err := os.CreateTemp(w.opts.BufferDir, ".tmp-*")
+	if err != nil {
+		w.logger.Error(ctx, "create buffered audit logs file", slog.Error(err))
+		return
+	}
+	_, err = f.Write(body)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(w.opts.BufferDir, name))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		w.logger.Error(ctx, "write buffered audit logs", slog.Error(err))
+	}
+}
+
+// send delivers a single batch, retrying with a backoff.
+func (w *Webhook) send(ctx context.Context, body []byte) error {
+	var (
+		err      error
+		attempts int
+	)
+	for r := retry.New(250*time.Millisecond, 10*time.Second); r.Wait(ctx); {
+		attempts++
+		err = w.post(ctx, body)
+		if err == nil || isPermanentWebhookError(err) || attempts >= w.opts.MaxAttempts {
+			return err
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+func (w *Webhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opts.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.opts.Secret != "" {
+		req.Header.Set(webhook.SignatureHeader, webhook.Signature(w.opts.Secret, body))
+	}
+	res, err := w.opts.HTTPClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<20))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &webhookStatusError{StatusCode: res.StatusCode}
+	}
+	return nil
+}
+
+type webhookPayload struct {
+	AuditLogs []json.RawMessage `json:"audit_logs"`
+}
+
+type webhookStatusError struct {
+	StatusCode int
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
+// isPermanentWebhookError returns true for client errors that won't succeed
+// when retried.
+func isPermanentWebhookError(err error) bool {
+	var statusErr *webhookStatusError
+	if !xerrors.As(err, &statusErr) {
+		return false
+	}
+	return webhook.IsPermanentStatus(statusErr.StatusCode)
+}
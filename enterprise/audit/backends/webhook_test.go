@@ -0,0 +1,176 @@
+package backends_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/webhook"
+	"github.com/coder/coder/enterprise/audit"
+	"github.com/coder/coder/enterprise/audit/audittest"
+	"github.com/coder/coder/enterprise/audit/backends"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWebhookBackend(t *testing.T) {
+	t.Parallel()
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			secret      = "hunter2"
+			receiver    = &webhookReceiver{t: t, secret: secret}
+			srv         = httptest.NewServer(receiver)
+		)
+		defer cancel()
+		defer srv.Close()
+
+		backend, err := backends.NewWebhook(slogtest.Make(t, nil), backends.WebhookOptions{
+			URL:           mustURL(t, srv.URL),
+			Secret:        secret,
+			BatchSize:     2,
+			FlushInterval: time.Hour,
+		})
+		require.NoError(t, err)
+		require.Equal(t, audit.FilterDecisionExport, backend.Decision())
+
+		ids := make([]uuid.UUID, 0, 3)
+		export := func() {
+			alog := audittest.RandomLog()
+			ids = append(ids, alog.ID)
+			err := backend.Export(ctx, alog)
+			require.NoError(t, err)
+		}
+		export()
+		export()
+
+		// A full batch is sent without waiting for the flush interval.
+		require.Eventually(t, func() bool {
+			return len(receiver.IDs()) == 2
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		// Closing flushes the remaining audit logs.
+		export()
+		err = backend.Close()
+		require.NoError(t, err)
+		require.Equal(t, ids, receiver.IDs())
+	})
+
+	t.Run("Buffer", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			bufferDir   = t.TempDir()
+			receiver    = &webhookReceiver{t: t}
+			srv         = httptest.NewServer(receiver)
+		)
+		defer cancel()
+		defer srv.Close()
+		receiver.failing.Store(true)
+
+		opts := backends.WebhookOptions{
+			URL:           mustURL(t, srv.URL),
+			FlushInterval: time.Hour,
+			MaxAttempts:   1,
+			BufferDir:     bufferDir,
+		}
+		backend, err := backends.NewWebhook(slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}), opts)
+		require.NoError(t, err)
+
+		alog := audittest.RandomLog()
+		err = backend.Export(ctx, alog)
+		require.NoError(t, err)
+		err = backend.Close()
+		require.NoError(t, err)
+		require.Empty(t, receiver.IDs())
+
+		entries, err := os.ReadDir(bufferDir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+
+		// Buffered audit logs are delivered before new ones once the
+		// endpoint recovers.
+		receiver.failing.Store(false)
+		backend, err = backends.NewWebhook(slogtest.Make(t, nil), opts)
+		require.NoError(t, err)
+		next := audittest.RandomLog()
+		err = backend.Export(ctx, next)
+		require.NoError(t, err)
+		err = backend.Close()
+		require.NoError(t, err)
+		require.Equal(t, []uuid.UUID{alog.ID, next.ID}, receiver.IDs())
+
+		entries, err = os.ReadDir(bufferDir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+}
+
+type webhookReceiver struct {
+	t       *testing.T
+	secret  string
+	failing atomic.Bool
+
+	mu  sync.Mutex
+	ids []uuid.UUID
+}
+
+func (r *webhookReceiver) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if r.failing.Load() {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if !assert.NoError(r.t, err) {
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if r.secret != "" {
+		assert.Equal(r.t, webhook.Signature(r.secret, body), req.Header.Get(webhook.SignatureHeader))
+	}
+
+	var payload struct {
+		AuditLogs []struct {
+			ID uuid.UUID `json:"id"`
+		} `json:"audit_logs"`
+	}
+	err = json.Unmarshal(body, &payload)
+	if !assert.NoError(r.t, err) {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	for _, alog := range payload.AuditLogs {
+		r.ids = append(r.ids, alog.ID)
+	}
+	r.mu.Unlock()
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (r *webhookReceiver) IDs() []uuid.UUID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]uuid.UUID(nil), r.ids...)
+}
+
+func mustURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
@@ -14,15 +14,17 @@ import (
 // AuditableResources map (below) as our documentation - generated in scripts/auditdocgen/main.go -
 // depends upon it.
 var AuditActionMap = map[string][]codersdk.AuditAction{
-	"GitSSHKey":       {codersdk.AuditActionCreate},
-	"Template":        {codersdk.AuditActionWrite, codersdk.AuditActionDelete},
-	"TemplateVersion": {codersdk.AuditActionCreate, codersdk.AuditActionWrite},
-	"User":            {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
-	"Workspace":       {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
-	"WorkspaceBuild":  {codersdk.AuditActionStart, codersdk.AuditActionStop},
-	"Group":           {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
-	"APIKey":          {codersdk.AuditActionLogin, codersdk.AuditActionLogout, codersdk.AuditActionCreate, codersdk.AuditActionDelete},
-	"License":         {codersdk.AuditActionCreate, codersdk.AuditActionDelete},
+	"GitSSHKey":                 {codersdk.AuditActionCreate},
+	"Template":                  {codersdk.AuditActionWrite, codersdk.AuditActionDelete},
+	"TemplateVersion":           {codersdk.AuditActionCreate, codersdk.AuditActionWrite},
+	"User":                      {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
+	"Workspace":                 {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
+	"WorkspaceBuild":            {codersdk.AuditActionStart, codersdk.AuditActionStop},
+	"Group":                     {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
+	"APIKey":                    {codersdk.AuditActionLogin, codersdk.AuditActionLogout, codersdk.AuditActionCreate, codersdk.AuditActionDelete},
+	"License":                   {codersdk.AuditActionCreate, codersdk.AuditActionDelete},
+	"WorkspaceSessionRecording": {codersdk.AuditActionCreate},
+	"TemplateVersionPromotion":  {codersdk.AuditActionCreate, codersdk.AuditActionWrite},
 }
 
 type Action string
@@ -73,6 +75,10 @@ var auditableResourcesTypes = map[any]map[string]Action{
 		"user_acl":                         ActionTrack,
 		"allow_user_cancel_workspace_jobs": ActionTrack,
 		"max_ttl":                          ActionTrack,
+		"inactivity_ttl":                   ActionTrack,
+		"delete_ttl":                       ActionTrack,
+		"autostop_requirement":             ActionTrack,
+		"required_promotion_approvals":     ActionTrack,
 	},
 	&database.TemplateVersion{}: {
 		"id":                 ActionTrack,
@@ -160,6 +166,28 @@ var auditableResourcesTypes = map[any]map[string]Action{
 		"exp":         ActionTrack,
 		"uuid":        ActionTrack,
 	},
+	&database.WorkspaceSessionRecording{}: {
+		"id":           ActionTrack,
+		"created_at":   ActionIgnore, // Never changes.
+		"workspace_id": ActionTrack,
+		"agent_id":     ActionTrack,
+		"session_type": ActionTrack,
+		"started_at":   ActionTrack,
+		"ended_at":     ActionTrack,
+		"data":         ActionIgnore, // Too large, it can be downloaded instead.
+	},
+	&database.TemplateVersionPromotion{}: {
+		"id":                  ActionTrack,
+		"created_at":          ActionIgnore, // Never changes.
+		"updated_at":          ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"template_id":         ActionTrack,
+		"template_version_id": ActionTrack,
+		"requester_id":        ActionTrack,
+		"dry_run_job_id":      ActionTrack,
+		"approver_ids":        ActionTrack,
+		"status":              ActionTrack,
+		"promoted_at":         ActionIgnore, // Implied by the status.
+	},
 }
 
 // auditMap converts a map of struct pointers to a map of struct names as
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"time"
 
 	"golang.org/x/xerrors"
@@ -38,16 +39,22 @@ func (r *RootCmd) provisionerDaemons() *clibase.Cmd {
 func (r *RootCmd) provisionerDaemonStart() *clibase.Cmd {
 	var (
 		cacheDir     string
+		cacheMaxSize int64
+		cacheMirror  string
 		rawTags      []string
+		rawPlugins   []string
 		pollInterval time.Duration
 		pollJitter   time.Duration
+		preSharedKey string
 	)
 	client := new(codersdk.Client)
 	cmd := &clibase.Cmd{
 		Use:   "start",
 		Short: "Run a provisioner daemon",
 		Middleware: clibase.Chain(
-			r.InitClient(client),
+			// Daemons authenticated with a pre-shared key don't need a
+			// session.
+			r.InitClientMissingTokenOK(client),
 		),
 		Handler: func(inv *clibase.Invocation) error {
 			ctx, cancel := context.WithCancel(inv.Context())
@@ -56,9 +63,16 @@ func (r *RootCmd) provisionerDaemonStart() *clibase.Cmd {
 			notifyCtx, notifyStop := signal.NotifyContext(ctx, agpl.InterruptSignals...)
 			defer notifyStop()
 
-			org, err := agpl.CurrentOrganization(inv, client)
-			if err != nil {
-				return xerrors.Errorf("get current organization: %w", err)
+			var org codersdk.Organization
+			if preSharedKey == "" {
+				if client.SessionToken() == "" {
+					return xerrors.New("You must be logged in or provide a pre-shared key with --psk to start a provisioner daemon.")
+				}
+				var err error
+				org, err = agpl.CurrentOrganization(inv, client)
+				if err != nil {
+					return xerrors.Errorf("get current organization: %w", err)
+				}
 			}
 
 			tags, err := agpl.ParseProvisionerTags(rawTags)
@@ -66,11 +80,27 @@ func (r *RootCmd) provisionerDaemonStart() *clibase.Cmd {
 				return err
 			}
 
+			plugins, err := codersdk.ParseProvisionerPlugins(rawPlugins)
+			if err != nil {
+				return err
+			}
+
 			err = os.MkdirAll(cacheDir, 0o700)
 			if err != nil {
 				return xerrors.Errorf("mkdir %q: %w", cacheDir, err)
 			}
 
+			logger := slog.Make(sloghuman.Sink(inv.Stderr))
+			terraformCache, err := terraform.NewCache(ctx, terraform.CacheOptions{
+				Path:       filepath.Join(cacheDir, "terraform"),
+				MaxSize:    cacheMaxSize << 20,
+				MirrorPath: cacheMirror,
+				Logger:     logger.Named("terraform-cache"),
+			})
+			if err != nil {
+				return xerrors.Errorf("create terraform cache: %w", err)
+			}
+
 			terraformClient, terraformServer := provisionersdk.MemTransportPipe()
 			go func() {
 				<-ctx.Done()
@@ -78,7 +108,6 @@ func (r *RootCmd) provisionerDaemonStart() *clibase.Cmd {
 				_ = terraformServer.Close()
 			}()
 
-			logger := slog.Make(sloghuman.Sink(inv.Stderr))
 			errCh := make(chan error, 1)
 			go func() {
 				defer cancel()
@@ -88,6 +117,7 @@ func (r *RootCmd) provisionerDaemonStart() *clibase.Cmd {
 						Listener: terraformServer,
 					},
 					CachePath: cacheDir,
+					Cache:     terraformCache,
 					Logger:    logger.Named("terraform"),
 				})
 				if err != nil && !xerrors.Is(err, context.Canceled) {
@@ -108,10 +138,24 @@ func (r *RootCmd) provisionerDaemonStart() *clibase.Cmd {
 			provisioners := provisionerd.Provisioners{
 				string(database.ProvisionerTypeTerraform): proto.NewDRPCProvisionerClient(terraformClient),
 			}
+			provisionerTypes := []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeTerraform,
+			}
+			pluginProvisioners, err := agpl.ExecProvisionerPlugins(ctx, logger, plugins)
+			if err != nil {
+				return err
+			}
+			for _, plugin := range plugins {
+				provisioners[string(plugin.Type)] = pluginProvisioners[string(plugin.Type)]
+				provisionerTypes = append(provisionerTypes, plugin.Type)
+			}
 			srv := provisionerd.New(func(ctx context.Context) (provisionerdproto.DRPCProvisionerDaemonClient, error) {
-				return client.ServeProvisionerDaemon(ctx, org.ID, []codersdk.ProvisionerType{
-					codersdk.ProvisionerTypeTerraform,
-				}, tags)
+				return client.ServeProvisionerDaemon(ctx, codersdk.ServeProvisionerDaemonRequest{
+					Organization: org.ID,
+					Provisioners: provisionerTypes,
+					Tags:         tags,
+					PreSharedKey: preSharedKey,
+				})
 			}, &provisionerd.Options{
 				Logger:          logger,
 				JobPollInterval: pollInterval,
@@ -158,6 +202,19 @@ func (r *RootCmd) provisionerDaemonStart() *clibase.Cmd {
 			Default:       codersdk.DefaultCacheDir(),
 			Value:         clibase.StringOf(&cacheDir),
 		},
+		{
+			Flag:        "cache-max-size",
+			Env:         "CODER_PROVISIONERD_CACHE_MAX_SIZE",
+			Description: "The maximum size in megabytes of the Terraform provider and module cache. Set to 0 to disable eviction.",
+			Default:     "0",
+			Value:       clibase.Int64Of(&cacheMaxSize),
+		},
+		{
+			Flag:        "cache-mirror",
+			Env:         "CODER_PROVISIONERD_CACHE_MIRROR",
+			Description: "A Terraform provider filesystem mirror used to seed the provider cache on startup.",
+			Value:       clibase.StringOf(&cacheMirror),
+		},
 		{
 			Flag:          "tag",
 			FlagShorthand: "t",
@@ -165,6 +222,12 @@ func (r *RootCmd) provisionerDaemonStart() *clibase.Cmd {
 			Description:   "Tags to filter provisioner jobs by.",
 			Value:         clibase.StringArrayOf(&rawTags),
 		},
+		{
+			Flag:        "plugin",
+			Env:         "CODER_PROVISIONERD_PLUGINS",
+			Description: "External provisioners to serve in the form name=path. Each name must also be declared with --provisioner-plugins on the Coder server.",
+			Value:       clibase.StringArrayOf(&rawPlugins),
+		},
 		{
 			Flag:        "poll-interval",
 			Env:         "CODER_PROVISIONERD_POLL_INTERVAL",
@@ -179,6 +242,12 @@ func (r *RootCmd) provisionerDaemonStart() *clibase.Cmd {
 			Default:     (100 * time.Millisecond).String(),
 			Value:       clibase.DurationOf(&pollJitter),
 		},
+		{
+			Flag:        "psk",
+			Env:         "CODER_PROVISIONER_DAEMON_PSK",
+			Description: "Pre-shared key to authenticate with Coder server.",
+			Value:       clibase.StringOf(&preSharedKey),
+		},
 	}
 
 	return cmd
@@ -8,6 +8,8 @@ import (
 	"errors"
 	"io"
 	"net/url"
+	"path/filepath"
+	"strings"
 
 	"golang.org/x/xerrors"
 	"tailscale.com/derp"
@@ -50,11 +52,21 @@ func (r *RootCmd) server() *clibase.Cmd {
 		}
 		options.DERPServer.SetMeshKey(meshKey)
 
+		var closers []io.Closer
 		if options.DeploymentValues.AuditLogging.Value() {
-			options.Auditor = audit.NewAuditor(audit.DefaultFilter,
+			auditBackends := []audit.Backend{
 				backends.NewPostgres(options.Database, true),
 				backends.NewSlog(options.Logger),
-			)
+			}
+			exportBackends, err := auditExportBackends(options)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, backend := range exportBackends {
+				auditBackends = append(auditBackends, backend)
+				closers = append(closers, backend)
+			}
+			options.Auditor = audit.NewAuditor(audit.DefaultFilter, auditBackends...)
 		}
 
 		options.TrialGenerator = trialer.New(options.Database, "https://v2-licensor.coder.com/trial", coderd.Keys)
@@ -66,14 +78,95 @@ func (r *RootCmd) server() *clibase.Cmd {
 			RBAC:                   true,
 			DERPServerRelayAddress: options.DeploymentValues.DERP.Server.RelayURL.String(),
 			DERPServerRegionID:     int(options.DeploymentValues.DERP.Server.RegionID.Value()),
+			ProvisionerDaemonPSK:   options.DeploymentValues.Provisioner.DaemonPSK.Value(),
 			Options:                options,
 		}
 
 		api, err := coderd.New(ctx, o)
 		if err != nil {
+			for _, closer := range closers {
+				_ = closer.Close()
+			}
 			return nil, nil, err
 		}
-		return api.AGPL, api, nil
+		return api.AGPL, closerFunc(func() error {
+			err := api.Close()
+			// Close audit backends after the API so audit logs of
+			// in-flight requests are exported.
+			for _, closer := range closers {
+				_ = closer.Close()
+			}
+			return err
+		}), nil
 	})
 	return cmd
 }
+
+type auditExportBackend interface {
+	audit.Backend
+	io.Closer
+}
+
+// auditExportBackends returns the backends that ship audit logs to external
+// systems, as configured by the deployment values.
+func auditExportBackends(options *agplcoderd.Options) ([]auditExportBackend, error) {
+	var (
+		cfg    = options.DeploymentValues.AuditExport
+		result []auditExportBackend
+	)
+	closeAll := func() {
+		for _, backend := range result {
+			_ = backend.Close()
+		}
+	}
+
+	if cfg.WebhookURL.String() != "" {
+		bufferDir := cfg.WebhookBufferDir.String()
+		if bufferDir == "" {
+			bufferDir = filepath.Join(options.DeploymentValues.CacheDir.String(), "audit-webhook")
+		}
+		webhook, err := backends.NewWebhook(options.Logger.Named("audit_webhook"), backends.WebhookOptions{
+			URL:           cfg.WebhookURL.Value(),
+			Secret:        cfg.WebhookSecret.String(),
+			BatchSize:     int(cfg.WebhookBatchSize.Value()),
+			FlushInterval: cfg.WebhookFlushInterval.Value(),
+			BufferDir:     bufferDir,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("create audit webhook backend: %w", err)
+		}
+		result = append(result, webhook)
+	}
+
+	if cfg.FilePath.String() != "" {
+		result = append(result, backends.NewFile(backends.FileOptions{
+			Path:       cfg.FilePath.String(),
+			MaxSizeMB:  int(cfg.FileMaxSize.Value()),
+			MaxBackups: int(cfg.FileMaxBackups.Value()),
+		}))
+	}
+
+	if cfg.SyslogURL.String() != "" {
+		syslogURL := cfg.SyslogURL.Value()
+		address := syslogURL.Host
+		if strings.HasPrefix(syslogURL.Scheme, "unix") {
+			address = syslogURL.Path
+		}
+		syslog, err := backends.NewSyslog(options.Logger.Named("audit_syslog"), backends.SyslogOptions{
+			Network: syslogURL.Scheme,
+			Address: address,
+		})
+		if err != nil {
+			closeAll()
+			return nil, xerrors.Errorf("create audit syslog backend: %w", err)
+		}
+		result = append(result, syslog)
+	}
+	return result, nil
+}
+
+type closerFunc func() error
+
+func (c closerFunc) Close() error {
+	return c()
+}
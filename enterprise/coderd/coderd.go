@@ -38,6 +38,9 @@ func New(ctx context.Context, options *Options) (*API, error) {
 	if options.EntitlementsUpdateInterval == 0 {
 		options.EntitlementsUpdateInterval = 10 * time.Minute
 	}
+	if options.ProvisionerDaemonReapInterval == 0 {
+		options.ProvisionerDaemonReapInterval = time.Minute
+	}
 	if options.Keys == nil {
 		options.Keys = Keys
 	}
@@ -98,13 +101,20 @@ func New(ctx context.Context, options *Options) (*API, error) {
 			})
 		})
 		r.Route("/organizations/{organization}/provisionerdaemons", func(r chi.Router) {
-			r.Use(
-				api.provisionerDaemonsEnabledMW,
+			r.Use(api.provisionerDaemonsEnabledMW)
+			r.With(
 				apiKeyMiddleware,
 				httpmw.ExtractOrganizationParam(api.Database),
-			)
-			r.Get("/", api.provisionerDaemons)
-			r.Get("/serve", api.provisionerDaemonServe)
+			).Get("/", api.provisionerDaemons)
+			// Daemons authenticated with the pre-shared key aren't
+			// associated with a user, so they skip the organization
+			// lookup that session token authenticated daemons go through.
+			r.With(
+				api.provisionerDaemonAuthMW(
+					apiKeyMiddleware,
+					httpmw.ExtractOrganizationParam(api.Database),
+				),
+			).Get("/serve", api.provisionerDaemonServe)
 		})
 		r.Route("/templates/{template}/acl", func(r chi.Router) {
 			r.Use(
@@ -197,6 +207,7 @@ func New(ctx context.Context, options *Options) (*API, error) {
 		return nil, xerrors.Errorf("update entitlements: %w", err)
 	}
 	go api.runEntitlementsLoop(ctx)
+	go api.runProvisionerDaemonReaper(ctx)
 
 	return api, nil
 }
@@ -216,6 +227,14 @@ type Options struct {
 
 	EntitlementsUpdateInterval time.Duration
 	Keys                       map[string]ed25519.PublicKey
+
+	// ProvisionerDaemonPSK is the pre-shared key external provisioner
+	// daemons can authenticate with. If empty, daemons must authenticate
+	// with a session token.
+	ProvisionerDaemonPSK string
+	// ProvisionerDaemonReapInterval is how often provisioner daemons that
+	// have stopped sending heartbeats are deleted.
+	ProvisionerDaemonReapInterval time.Duration
 }
 
 type API struct {
@@ -324,7 +343,7 @@ func (api *API) updateEntitlements(ctx context.Context) error {
 	if changed, enabled := featureChanged(codersdk.FeatureHighAvailability); changed {
 		coordinator := agpltailnet.NewCoordinator()
 		if enabled {
-			haCoordinator, err := tailnet.NewCoordinator(api.Logger, api.Pubsub)
+			haCoordinator, err := tailnet.NewCoordinator(api.Logger, api.Database, api.Pubsub)
 			if err != nil {
 				api.Logger.Error(ctx, "unable to set up high availability coordinator", slog.Error(err))
 				// If we try to setup the HA coordinator and it fails, nothing
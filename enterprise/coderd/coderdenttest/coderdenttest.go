@@ -48,6 +48,9 @@ type Options struct {
 	EntitlementsUpdateInterval time.Duration
 	SCIMAPIKey                 []byte
 	UserWorkspaceQuota         int
+	ProvisionerDaemonPSK       string
+	// ProvisionerDaemonReapInterval defaults to the production interval.
+	ProvisionerDaemonReapInterval time.Duration
 }
 
 // New constructs a codersdk client connected to an in-memory Enterprise API instance.
@@ -65,15 +68,17 @@ func NewWithAPI(t *testing.T, options *Options) (*codersdk.Client, io.Closer, *c
 	}
 	setHandler, cancelFunc, serverURL, oop := coderdtest.NewOptions(t, options.Options)
 	coderAPI, err := coderd.New(context.Background(), &coderd.Options{
-		RBAC:                       true,
-		AuditLogging:               options.AuditLogging,
-		BrowserOnly:                options.BrowserOnly,
-		SCIMAPIKey:                 options.SCIMAPIKey,
-		DERPServerRelayAddress:     oop.AccessURL.String(),
-		DERPServerRegionID:         oop.DERPMap.RegionIDs()[0],
-		Options:                    oop,
-		EntitlementsUpdateInterval: options.EntitlementsUpdateInterval,
-		Keys:                       Keys,
+		RBAC:                          true,
+		AuditLogging:                  options.AuditLogging,
+		BrowserOnly:                   options.BrowserOnly,
+		SCIMAPIKey:                    options.SCIMAPIKey,
+		DERPServerRelayAddress:        oop.AccessURL.String(),
+		DERPServerRegionID:            oop.DERPMap.RegionIDs()[0],
+		Options:                       oop,
+		EntitlementsUpdateInterval:    options.EntitlementsUpdateInterval,
+		Keys:                          Keys,
+		ProvisionerDaemonPSK:          options.ProvisionerDaemonPSK,
+		ProvisionerDaemonReapInterval: options.ProvisionerDaemonReapInterval,
 	})
 	assert.NoError(t, err)
 	setHandler(coderAPI.AGPL.RootHandler)
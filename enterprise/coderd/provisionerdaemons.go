@@ -2,6 +2,7 @@ package coderd
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -12,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/hashicorp/yamux"
 	"github.com/moby/moby/pkg/namesgenerator"
@@ -23,15 +25,21 @@ import (
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/provisionerdserver"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/schedule"
+	"github.com/coder/coder/coderd/util/slice"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/provisionerd/proto"
 )
 
+// provisionerDaemonReapAfter is how long a daemon can go without sending a
+// heartbeat before it's deleted.
+const provisionerDaemonReapAfter = time.Hour
+
 func (api *API) provisionerDaemonsEnabledMW(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		api.entitlementsMu.RLock()
@@ -49,6 +57,45 @@ func (api *API) provisionerDaemonsEnabledMW(next http.Handler) http.Handler {
 	})
 }
 
+type provisionerDaemonPSKContextKey struct{}
+
+// provisionerDaemonAuthMW authenticates provisioner daemons with the
+// deployment's pre-shared key. Requests that don't provide a key are
+// passed through the given session token middlewares instead, which
+// aren't run for daemons authenticated with the key.
+func (api *API) provisionerDaemonAuthMW(sessionTokenMiddlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withSessionToken := chi.Chain(sessionTokenMiddlewares...).Handler(next)
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			psk := r.Header.Get(codersdk.ProvisionerDaemonPSK)
+			if psk == "" {
+				withSessionToken.ServeHTTP(rw, r)
+				return
+			}
+			if api.ProvisionerDaemonPSK == "" || subtle.ConstantTimeCompare([]byte(psk), []byte(api.ProvisionerDaemonPSK)) != 1 {
+				httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+					Message: "Invalid provisioner daemon pre-shared key.",
+				})
+				return
+			}
+			// Daemons authenticated with the pre-shared key aren't
+			// associated with a user.
+			//nolint:gocritic // Provisioner daemons are a system function.
+			ctx = dbauthz.AsSystemRestricted(ctx)
+			ctx = context.WithValue(ctx, provisionerDaemonPSKContextKey{}, true)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
+
+// provisionerDaemonPSKAuthenticated returns whether the request was
+// authenticated with the provisioner daemon pre-shared key.
+func provisionerDaemonPSKAuthenticated(r *http.Request) bool {
+	ok, _ := r.Context().Value(provisionerDaemonPSKContextKey{}).(bool)
+	return ok
+}
+
 // @Summary Get provisioner daemons
 // @ID get-provisioner-daemons
 // @Security CoderSessionToken
@@ -81,14 +128,17 @@ func (api *API) provisionerDaemons(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	now := database.Now()
 	apiDaemons := make([]codersdk.ProvisionerDaemon, 0)
 	for _, daemon := range daemons {
-		apiDaemons = append(apiDaemons, convertProvisionerDaemon(daemon))
+		apiDaemons = append(apiDaemons, convertProvisionerDaemon(daemon, now))
 	}
 	httpapi.Write(ctx, rw, http.StatusOK, apiDaemons)
 }
 
-// Serves the provisioner daemon protobuf API over a WebSocket.
+// Serves the provisioner daemon protobuf API over a WebSocket. Daemons can
+// authenticate with the deployment's pre-shared key in the
+// Coder-Provisioner-Daemon-PSK header instead of a session token.
 //
 // @Summary Serve provisioner daemon
 // @ID serve-provisioner-daemon
@@ -120,43 +170,46 @@ func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	provisionersMap := map[codersdk.ProvisionerType]struct{}{}
+	provisioners := make([]database.ProvisionerType, 0)
 	for _, provisioner := range r.URL.Query()["provisioner"] {
-		switch provisioner {
-		case string(codersdk.ProvisionerTypeEcho):
-			provisionersMap[codersdk.ProvisionerTypeEcho] = struct{}{}
-		case string(codersdk.ProvisionerTypeTerraform):
-			provisionersMap[codersdk.ProvisionerTypeTerraform] = struct{}{}
-		default:
+		provisionerType := database.ProvisionerType(provisioner)
+		// Daemons can serve the built-in provisioners, and any provisioner
+		// plugin declared in the deployment config.
+		if !slice.Contains(api.AGPL.ProvisionerTypes, provisionerType) {
 			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 				Message: fmt.Sprintf("Unknown provisioner type %q", provisioner),
 			})
 			return
 		}
+		if !slice.Contains(provisioners, provisionerType) {
+			provisioners = append(provisioners, provisionerType)
+		}
 	}
 
-	// Any authenticated user can create provisioner daemons scoped
-	// for jobs that they own, but only authorized users can create
-	// globally scoped provisioners that attach to all jobs.
-	apiKey := httpmw.APIKey(r)
-	tags = provisionerdserver.MutateTags(apiKey.UserID, tags)
-
-	if tags[provisionerdserver.TagScope] == provisionerdserver.ScopeOrganization {
-		if !api.AGPL.Authorize(r, rbac.ActionCreate, rbac.ResourceProvisionerDaemon) {
-			httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
-				Message: "You aren't allowed to create provisioner daemons for the organization.",
+	if provisionerDaemonPSKAuthenticated(r) {
+		// Daemons authenticated with the pre-shared key don't belong to a
+		// user, so they can only serve the organization.
+		if tags[provisionerdserver.TagScope] == provisionerdserver.ScopeUser {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Provisioner daemons authenticated with a pre-shared key can't be scoped to a user.",
 			})
 			return
 		}
-	}
+		tags = provisionerdserver.MutateTags(uuid.Nil, tags)
+	} else {
+		// Any authenticated user can create provisioner daemons scoped
+		// for jobs that they own, but only authorized users can create
+		// globally scoped provisioners that attach to all jobs.
+		apiKey := httpmw.APIKey(r)
+		tags = provisionerdserver.MutateTags(apiKey.UserID, tags)
 
-	provisioners := make([]database.ProvisionerType, 0)
-	for p := range provisionersMap {
-		switch p {
-		case codersdk.ProvisionerTypeTerraform:
-			provisioners = append(provisioners, database.ProvisionerTypeTerraform)
-		case codersdk.ProvisionerTypeEcho:
-			provisioners = append(provisioners, database.ProvisionerTypeEcho)
+		if tags[provisionerdserver.TagScope] == provisionerdserver.ScopeOrganization {
+			if !api.AGPL.Authorize(r, rbac.ActionCreate, rbac.ResourceProvisionerDaemon) {
+				httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+					Message: "You aren't allowed to create provisioner daemons for the organization.",
+				})
+				return
+			}
 		}
 	}
 
@@ -227,7 +280,8 @@ func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request)
 		Provisioners:          daemon.Provisioners,
 		Telemetry:             api.Telemetry,
 		Auditor:               &api.AGPL.Auditor,
-		TemplateScheduleStore: &api.AGPL.TemplateScheduleStore,
+		TemplateScheduleStore: api.AGPL.TemplateScheduleStore,
+		Metrics:               api.AGPL.ProvisionerdMetrics,
 		Logger:                api.Logger.Named(fmt.Sprintf("provisionerd-%s", daemon.Name)),
 		Tags:                  rawTags,
 	})
@@ -252,20 +306,59 @@ func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request)
 	_ = conn.Close(websocket.StatusGoingAway, "")
 }
 
-func convertProvisionerDaemon(daemon database.ProvisionerDaemon) codersdk.ProvisionerDaemon {
+func convertProvisionerDaemon(daemon database.ProvisionerDaemon, now time.Time) codersdk.ProvisionerDaemon {
 	result := codersdk.ProvisionerDaemon{
-		ID:        daemon.ID,
-		CreatedAt: daemon.CreatedAt,
-		UpdatedAt: daemon.UpdatedAt,
-		Name:      daemon.Name,
-		Tags:      daemon.Tags,
+		ID:         daemon.ID,
+		CreatedAt:  daemon.CreatedAt,
+		UpdatedAt:  daemon.UpdatedAt,
+		LastSeenAt: codersdk.NullTime{NullTime: daemon.LastSeenAt},
+		Name:       daemon.Name,
+		Version:    daemon.Version,
+		Tags:       daemon.Tags,
+		Status:     codersdk.ProvisionerDaemonIdle,
 	}
 	for _, provisionerType := range daemon.Provisioners {
 		result.Provisioners = append(result.Provisioners, codersdk.ProvisionerType(provisionerType))
 	}
+	if daemon.CurrentJobID.Valid {
+		result.CurrentJobID = &daemon.CurrentJobID.UUID
+		result.Status = codersdk.ProvisionerDaemonBusy
+	}
+	lastSeen := daemon.CreatedAt
+	if daemon.LastSeenAt.Valid {
+		lastSeen = daemon.LastSeenAt.Time
+	}
+	if now.Sub(lastSeen) > provisionerdserver.DaemonOfflineAfter {
+		result.Status = codersdk.ProvisionerDaemonOffline
+	}
 	return result
 }
 
+// runProvisionerDaemonReaper periodically deletes provisioner daemons that
+// have stopped sending heartbeats.
+func (api *API) runProvisionerDaemonReaper(ctx context.Context) {
+	//nolint:gocritic // Reaping provisioner daemons is a system function.
+	ctx = dbauthz.AsSystemRestricted(ctx)
+	ticker := time.NewTicker(api.ProvisionerDaemonReapInterval)
+	defer ticker.Stop()
+	for {
+		deleted, err := api.Database.DeleteStaleProvisionerDaemons(ctx, database.Now().Add(-provisionerDaemonReapAfter))
+		if err != nil {
+			if !xerrors.Is(err, context.Canceled) {
+				api.Logger.Warn(ctx, "reap stale provisioner daemons", slog.Error(err))
+			}
+		} else if deleted > 0 {
+			api.Logger.Info(ctx, "reaped stale provisioner daemons", slog.F("count", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // wsNetConn wraps net.Conn created by websocket.NetConn(). Cancel func
 // is called if a read or write error is encountered.
 type wsNetConn struct {
@@ -322,15 +415,21 @@ func (*enterpriseTemplateScheduleStore) GetTemplateScheduleOptions(ctx context.C
 		UserSchedulingEnabled: true,
 		DefaultTTL:            time.Duration(tpl.DefaultTTL),
 		MaxTTL:                time.Duration(tpl.MaxTTL),
+		InactivityTTL:         time.Duration(tpl.InactivityTTL),
+		DeleteTTL:             time.Duration(tpl.DeleteTTL),
+		AutostopRequirement:   tpl.AutostopRequirement,
 	}, nil
 }
 
 func (*enterpriseTemplateScheduleStore) SetTemplateScheduleOptions(ctx context.Context, db database.Store, tpl database.Template, opts schedule.TemplateScheduleOptions) (database.Template, error) {
 	template, err := db.UpdateTemplateScheduleByID(ctx, database.UpdateTemplateScheduleByIDParams{
-		ID:         tpl.ID,
-		UpdatedAt:  database.Now(),
-		DefaultTTL: int64(opts.DefaultTTL),
-		MaxTTL:     int64(opts.MaxTTL),
+		ID:                  tpl.ID,
+		UpdatedAt:           database.Now(),
+		DefaultTTL:          int64(opts.DefaultTTL),
+		MaxTTL:              int64(opts.MaxTTL),
+		InactivityTTL:       int64(opts.InactivityTTL),
+		DeleteTTL:           int64(opts.DeleteTTL),
+		AutostopRequirement: opts.AutostopRequirement,
 	})
 	if err != nil {
 		return database.Template{}, xerrors.Errorf("update template schedule: %w", err)
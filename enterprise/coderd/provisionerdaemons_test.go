@@ -3,13 +3,19 @@ package coderd_test
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/coder/coder/buildinfo"
+	"github.com/coder/coder/cli/clibase"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
 	"github.com/coder/coder/coderd/provisionerdserver"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
@@ -17,6 +23,7 @@ import (
 	"github.com/coder/coder/enterprise/coderd/license"
 	"github.com/coder/coder/provisioner/echo"
 	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
 )
 
 func TestProvisionerDaemonServe(t *testing.T) {
@@ -30,20 +37,72 @@ func TestProvisionerDaemonServe(t *testing.T) {
 				codersdk.FeatureExternalProvisionerDaemons: 1,
 			},
 		})
-		srv, err := client.ServeProvisionerDaemon(context.Background(), user.OrganizationID, []codersdk.ProvisionerType{
-			codersdk.ProvisionerTypeEcho,
-		}, map[string]string{})
+		srv, err := client.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: user.OrganizationID,
+			Provisioners: []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeEcho,
+			},
+			Tags: map[string]string{},
+		})
 		require.NoError(t, err)
 		srv.DRPCConn().Close()
 	})
 
+	t.Run("ProvisionerPlugin", func(t *testing.T) {
+		t.Parallel()
+		dv := coderdtest.DeploymentValues(t)
+		dv.Provisioner.Plugins = clibase.StringArray{"vm=/usr/local/bin/vm-provisioner"}
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			Options: &coderdtest.Options{
+				DeploymentValues: dv,
+			},
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureExternalProvisionerDaemons: 1,
+			},
+		})
+		srv, err := client.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: user.OrganizationID,
+			Provisioners: []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeTerraform,
+				"vm",
+			},
+			Tags: map[string]string{},
+		})
+		require.NoError(t, err)
+		srv.DRPCConn().Close()
+
+		daemons, err := client.ProvisionerDaemonsByOrganization(context.Background(), user.OrganizationID)
+		require.NoError(t, err)
+		require.Len(t, daemons, 1)
+		require.ElementsMatch(t, []codersdk.ProvisionerType{codersdk.ProvisionerTypeTerraform, "vm"}, daemons[0].Provisioners)
+
+		// Plugins that aren't declared in the deployment config are rejected.
+		_, err = client.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: user.OrganizationID,
+			Provisioners: []codersdk.ProvisionerType{
+				"container",
+			},
+			Tags: map[string]string{},
+		})
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusBadRequest, apiError.StatusCode())
+	})
+
 	t.Run("NoLicense", func(t *testing.T) {
 		t.Parallel()
 		client := coderdenttest.New(t, nil)
 		user := coderdtest.CreateFirstUser(t, client)
-		_, err := client.ServeProvisionerDaemon(context.Background(), user.OrganizationID, []codersdk.ProvisionerType{
-			codersdk.ProvisionerTypeEcho,
-		}, map[string]string{})
+		_, err := client.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: user.OrganizationID,
+			Provisioners: []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeEcho,
+			},
+			Tags: map[string]string{},
+		})
 		require.Error(t, err)
 		var apiError *codersdk.Error
 		require.ErrorAs(t, err, &apiError)
@@ -60,10 +119,14 @@ func TestProvisionerDaemonServe(t *testing.T) {
 			},
 		})
 		another, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleOrgAdmin(user.OrganizationID))
-		_, err := another.ServeProvisionerDaemon(context.Background(), user.OrganizationID, []codersdk.ProvisionerType{
-			codersdk.ProvisionerTypeEcho,
-		}, map[string]string{
-			provisionerdserver.TagScope: provisionerdserver.ScopeOrganization,
+		_, err := another.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: user.OrganizationID,
+			Provisioners: []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeEcho,
+			},
+			Tags: map[string]string{
+				provisionerdserver.TagScope: provisionerdserver.ScopeOrganization,
+			},
 		})
 		require.Error(t, err)
 		var apiError *codersdk.Error
@@ -81,10 +144,14 @@ func TestProvisionerDaemonServe(t *testing.T) {
 			},
 		})
 		another, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
-		_, err := another.ServeProvisionerDaemon(context.Background(), user.OrganizationID, []codersdk.ProvisionerType{
-			codersdk.ProvisionerTypeEcho,
-		}, map[string]string{
-			provisionerdserver.TagScope: provisionerdserver.ScopeOrganization,
+		_, err := another.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: user.OrganizationID,
+			Provisioners: []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeEcho,
+			},
+			Tags: map[string]string{
+				provisionerdserver.TagScope: provisionerdserver.ScopeOrganization,
+			},
 		})
 		require.Error(t, err)
 		var apiError *codersdk.Error
@@ -92,6 +159,135 @@ func TestProvisionerDaemonServe(t *testing.T) {
 		require.Equal(t, http.StatusForbidden, apiError.StatusCode())
 	})
 
+	t.Run("OrganizationNotFound", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureExternalProvisionerDaemons: 1,
+			},
+		})
+		_, err := client.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: uuid.New(),
+			Provisioners: []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeEcho,
+			},
+			Tags: map[string]string{},
+		})
+		require.Error(t, err)
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusNotFound, apiError.StatusCode())
+	})
+
+	t.Run("PSK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureExternalProvisionerDaemons: 1,
+			},
+		})
+		another := codersdk.New(client.URL)
+		srv, err := another.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: user.OrganizationID,
+			Provisioners: []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeEcho,
+			},
+			Tags:         map[string]string{},
+			PreSharedKey: "provisionersftw",
+		})
+		require.NoError(t, err)
+		srv.DRPCConn().Close()
+
+		daemons, err := client.ProvisionerDaemonsByOrganization(context.Background(), user.OrganizationID)
+		require.NoError(t, err)
+		require.Len(t, daemons, 1)
+		require.Equal(t, provisionerdserver.ScopeOrganization, daemons[0].Tags[provisionerdserver.TagScope])
+	})
+
+	t.Run("BadPSK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureExternalProvisionerDaemons: 1,
+			},
+		})
+		another := codersdk.New(client.URL)
+		_, err := another.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: user.OrganizationID,
+			Provisioners: []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeEcho,
+			},
+			Tags:         map[string]string{},
+			PreSharedKey: "the wrong key",
+		})
+		require.Error(t, err)
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusUnauthorized, apiError.StatusCode())
+	})
+
+	t.Run("NoPSKConfigured", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureExternalProvisionerDaemons: 1,
+			},
+		})
+		another := codersdk.New(client.URL)
+		_, err := another.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: user.OrganizationID,
+			Provisioners: []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeEcho,
+			},
+			Tags:         map[string]string{},
+			PreSharedKey: "provisionersftw",
+		})
+		require.Error(t, err)
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusUnauthorized, apiError.StatusCode())
+	})
+
+	t.Run("PSKUserScope", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureExternalProvisionerDaemons: 1,
+			},
+		})
+		another := codersdk.New(client.URL)
+		_, err := another.ServeProvisionerDaemon(context.Background(), codersdk.ServeProvisionerDaemonRequest{
+			Organization: user.OrganizationID,
+			Provisioners: []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeEcho,
+			},
+			Tags: map[string]string{
+				provisionerdserver.TagScope: provisionerdserver.ScopeUser,
+			},
+			PreSharedKey: "provisionersftw",
+		})
+		require.Error(t, err)
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusBadRequest, apiError.StatusCode())
+	})
+
 	t.Run("UserLocal", func(t *testing.T) {
 		t.Parallel()
 		client := coderdenttest.New(t, nil)
@@ -151,3 +347,83 @@ func TestProvisionerDaemonServe(t *testing.T) {
 		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
 	})
 }
+
+func TestProvisionerDaemons(t *testing.T) {
+	t.Parallel()
+	t.Run("Heartbeat", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureExternalProvisionerDaemons: 1,
+			},
+		})
+		closer := coderdtest.NewExternalProvisionerDaemon(t, client, user.OrganizationID, nil)
+		defer closer.Close()
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		var daemons []codersdk.ProvisionerDaemon
+		require.Eventually(t, func() bool {
+			var err error
+			daemons, err = client.ProvisionerDaemonsByOrganization(ctx, user.OrganizationID)
+			return err == nil && len(daemons) == 1 && daemons[0].LastSeenAt.Valid
+		}, testutil.WaitLong, testutil.IntervalFast)
+		require.Equal(t, buildinfo.Version(), daemons[0].Version)
+		require.Equal(t, codersdk.ProvisionerDaemonIdle, daemons[0].Status)
+		require.Nil(t, daemons[0].CurrentJobID)
+	})
+
+	t.Run("Reap", func(t *testing.T) {
+		t.Parallel()
+		client, _, api := coderdenttest.NewWithAPI(t, &coderdenttest.Options{
+			ProvisionerDaemonReapInterval: testutil.IntervalFast,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureExternalProvisionerDaemons: 1,
+			},
+		})
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		//nolint:gocritic // Inserting provisioner daemons is a system function.
+		sysCtx := dbauthz.AsSystemRestricted(ctx)
+		stale, err := api.Database.InsertProvisionerDaemon(sysCtx, database.InsertProvisionerDaemonParams{
+			ID:        uuid.New(),
+			CreatedAt: database.Now().Add(-2 * time.Hour),
+			Name:      "stale",
+		})
+		require.NoError(t, err)
+		offline, err := api.Database.InsertProvisionerDaemon(sysCtx, database.InsertProvisionerDaemonParams{
+			ID:        uuid.New(),
+			CreatedAt: database.Now().Add(-2 * time.Hour),
+			Name:      "offline",
+		})
+		require.NoError(t, err)
+		// Daemons that went offline recently are kept, but reported
+		// as offline.
+		err = api.Database.UpdateProvisionerDaemonHeartbeat(sysCtx, database.UpdateProvisionerDaemonHeartbeatParams{
+			ID:         offline.ID,
+			LastSeenAt: sql.NullTime{Time: database.Now().Add(-5 * time.Minute), Valid: true},
+		})
+		require.NoError(t, err)
+
+		var daemons []codersdk.ProvisionerDaemon
+		require.Eventually(t, func() bool {
+			daemons, err = client.ProvisionerDaemonsByOrganization(ctx, user.OrganizationID)
+			if err != nil {
+				return false
+			}
+			for _, daemon := range daemons {
+				if daemon.ID == stale.ID {
+					return false
+				}
+			}
+			return true
+		}, testutil.WaitLong, testutil.IntervalFast)
+		require.Len(t, daemons, 1)
+		require.Equal(t, offline.ID, daemons[0].ID)
+		require.Equal(t, codersdk.ProvisionerDaemonOffline, daemons[0].Status)
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/cryptorand"
 	"github.com/coder/coder/enterprise/coderd/coderdenttest"
@@ -93,6 +94,121 @@ func TestTemplates(t *testing.T) {
 		require.Nil(t, workspace3.TTLMillis)
 	})
 
+	t.Run("SetDormancyTTLs", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			Options: &coderdtest.Options{
+				IncludeProvisionerDaemon: true,
+			},
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureAdvancedTemplateScheduling: 1,
+			},
+		})
+
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		require.EqualValues(t, 0, template.InactivityTTLMillis)
+		require.EqualValues(t, 0, template.DeleteTTLMillis)
+
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		updated, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			Name:                         template.Name,
+			DisplayName:                  template.DisplayName,
+			Description:                  template.Description,
+			Icon:                         template.Icon,
+			AllowUserCancelWorkspaceJobs: template.AllowUserCancelWorkspaceJobs,
+			DefaultTTLMillis:             template.DefaultTTLMillis,
+			InactivityTTLMillis:          (24 * time.Hour).Milliseconds(),
+			DeleteTTLMillis:              (7 * 24 * time.Hour).Milliseconds(),
+		})
+		require.NoError(t, err)
+		require.Equal(t, 24*time.Hour, time.Duration(updated.InactivityTTLMillis)*time.Millisecond)
+		require.Equal(t, 7*24*time.Hour, time.Duration(updated.DeleteTTLMillis)*time.Millisecond)
+
+		template, err = client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		require.Equal(t, 24*time.Hour, time.Duration(template.InactivityTTLMillis)*time.Millisecond)
+		require.Equal(t, 7*24*time.Hour, time.Duration(template.DeleteTTLMillis)*time.Millisecond)
+
+		// The running workspace reports when it will be stopped for
+		// inactivity.
+		workspace, err = client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.NotNil(t, workspace.DormantAt)
+		require.NotNil(t, workspace.LatestBuild.Job.CompletedAt)
+		require.WithinDuration(t, workspace.LatestBuild.Job.CompletedAt.Add(24*time.Hour), *workspace.DormantAt, time.Second)
+		require.Nil(t, workspace.DeletingAt)
+	})
+
+	t.Run("SetAutostopRequirement", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			Options: &coderdtest.Options{
+				IncludeProvisionerDaemon: true,
+			},
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureAdvancedTemplateScheduling: 1,
+			},
+		})
+
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		require.Empty(t, template.AutostopRequirement)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		updated, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			Name:                         template.Name,
+			DisplayName:                  template.DisplayName,
+			Description:                  template.Description,
+			Icon:                         template.Icon,
+			AllowUserCancelWorkspaceJobs: template.AllowUserCancelWorkspaceJobs,
+			DefaultTTLMillis:             template.DefaultTTLMillis,
+			AutostopRequirement:          "0 2 * * 0",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "0 2 * * 0", updated.AutostopRequirement)
+
+		// Workspaces started after the change must stop at the next
+		// occurrence of the requirement in the owner's timezone.
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.AutostartSchedule = ptr.Ref("CRON_TZ=Europe/Dublin 30 9 * * 1-5")
+			cwr.TTLMillis = nil
+		})
+		build := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+		require.NotZero(t, build.MaxDeadline)
+		require.Equal(t, build.MaxDeadline, build.Deadline)
+		loc, err := time.LoadLocation("Europe/Dublin")
+		require.NoError(t, err)
+		maxDeadline := build.MaxDeadline.Time.In(loc)
+		require.Equal(t, time.Sunday, maxDeadline.Weekday())
+		require.Equal(t, 2, maxDeadline.Hour())
+		require.WithinDuration(t, *build.Job.CompletedAt, maxDeadline, 8*24*time.Hour)
+
+		// A timezone is not allowed, since the owner's timezone is used.
+		_, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			Name:                template.Name,
+			AutostopRequirement: "CRON_TZ=UTC 0 2 * * 0",
+		})
+		var sdkErr *codersdk.Error
+		require.ErrorAs(t, err, &sdkErr)
+		require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+		require.Len(t, sdkErr.Validations, 1)
+		require.Equal(t, "autostop_requirement", sdkErr.Validations[0].Field)
+	})
+
 	t.Run("CreateUpdateWorkspaceMaxTTL", func(t *testing.T) {
 		t.Parallel()
 		client := coderdenttest.New(t, &coderdenttest.Options{
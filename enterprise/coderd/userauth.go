@@ -6,20 +6,28 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 
+	"cdr.dev/slog"
+	agplcoderd "github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/util/slice"
 	"github.com/coder/coder/codersdk"
 )
 
-func (api *API) setUserGroups(ctx context.Context, db database.Store, userID uuid.UUID, groupNames []string) error {
+// setUserGroups makes the user a member of exactly the groups with the given
+// names in their organization. If createMissingGroups is true, groups that
+// don't exist are created. It returns the groups whose members changed.
+func (api *API) setUserGroups(ctx context.Context, db database.Store, userID uuid.UUID, groupNames []string, createMissingGroups bool) ([]agplcoderd.GroupChange, error) {
 	api.entitlementsMu.RLock()
 	enabled := api.entitlements.Features[codersdk.FeatureTemplateRBAC].Enabled
 	api.entitlementsMu.RUnlock()
 
 	if !enabled {
-		return nil
+		return nil, nil
 	}
 
-	return db.InTx(func(tx database.Store) error {
+	var changes []agplcoderd.GroupChange
+	err := db.InTx(func(tx database.Store) error {
+		changes = nil
 		orgs, err := tx.GetOrganizationsByUserID(ctx, userID)
 		if err != nil {
 			return xerrors.Errorf("get user orgs: %w", err)
@@ -27,11 +35,72 @@ func (api *API) setUserGroups(ctx context.Context, db database.Store, userID uui
 		if len(orgs) != 1 {
 			return xerrors.Errorf("expected 1 org, got %d", len(orgs))
 		}
+		orgID := orgs[0].ID
+
+		groups, err := tx.GetGroupsByOrganizationID(ctx, orgID)
+		if err != nil {
+			return xerrors.Errorf("get org groups: %w", err)
+		}
+		userGroups, err := tx.GetGroupsByOrganizationAndUserID(ctx, database.GetGroupsByOrganizationAndUserIDParams{
+			OrganizationID: orgID,
+			UserID:         userID,
+		})
+		if err != nil {
+			return xerrors.Errorf("get user groups: %w", err)
+		}
+
+		// Find the groups the user is added to or removed from, and keep
+		// their members before the change for the audit log.
+		groupNames = slice.Unique(groupNames)
+		changed := make(map[uuid.UUID]database.Group)
+		for _, group := range userGroups {
+			if !slice.Contains(groupNames, group.Name) {
+				changed[group.ID] = group
+			}
+		}
+		for _, group := range groups {
+			if slice.Contains(groupNames, group.Name) && !slice.ContainsCompare(userGroups, group, func(a, b database.Group) bool {
+				return a.ID == b.ID
+			}) {
+				changed[group.ID] = group
+			}
+		}
+		oldMembers := make(map[uuid.UUID][]database.User, len(changed))
+		for id := range changed {
+			members, err := tx.GetGroupMembers(ctx, id)
+			if err != nil {
+				return xerrors.Errorf("get group members: %w", err)
+			}
+			oldMembers[id] = members
+		}
+
+		if createMissingGroups {
+			for _, name := range groupNames {
+				if name == "" || name == database.AllUsersGroup || slice.ContainsCompare(groups, database.Group{Name: name}, func(a, b database.Group) bool {
+					return a.Name == b.Name
+				}) {
+					continue
+				}
+				group, err := tx.InsertGroup(ctx, database.InsertGroupParams{
+					ID:             uuid.New(),
+					Name:           name,
+					OrganizationID: orgID,
+				})
+				if err != nil {
+					return xerrors.Errorf("insert group %q: %w", name, err)
+				}
+				api.Logger.Info(ctx, "created group from oidc claims",
+					slog.F("group_id", group.ID),
+					slog.F("group_name", group.Name),
+				)
+				changed[group.ID] = group
+			}
+		}
 
 		// Delete all groups the user belongs to.
 		err = tx.DeleteGroupMembersByOrgAndUser(ctx, database.DeleteGroupMembersByOrgAndUserParams{
 			UserID:         userID,
-			OrganizationID: orgs[0].ID,
+			OrganizationID: orgID,
 		})
 		if err != nil {
 			return xerrors.Errorf("delete user groups: %w", err)
@@ -40,13 +109,31 @@ func (api *API) setUserGroups(ctx context.Context, db database.Store, userID uui
 		// Re-add the user to all groups returned by the auth provider.
 		err = tx.InsertUserGroupsByName(ctx, database.InsertUserGroupsByNameParams{
 			UserID:         userID,
-			OrganizationID: orgs[0].ID,
+			OrganizationID: orgID,
 			GroupNames:     groupNames,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert user groups: %w", err)
 		}
 
+		for id, group := range changed {
+			members, err := tx.GetGroupMembers(ctx, id)
+			if err != nil {
+				return xerrors.Errorf("get group members: %w", err)
+			}
+			change := agplcoderd.GroupChange{
+				New: group.Auditable(members),
+			}
+			if old, ok := oldMembers[id]; ok {
+				change.Old = group.Auditable(old)
+			}
+			changes = append(changes, change)
+		}
+
 		return nil
 	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
 }
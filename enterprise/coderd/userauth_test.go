@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 	"testing"
 
 	"github.com/golang-jwt/jwt"
@@ -13,7 +14,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/coder/coder/coderd"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/enterprise/coderd/coderdenttest"
 	"github.com/coder/coder/testutil"
@@ -178,6 +181,103 @@ func TestUserOIDC(t *testing.T) {
 			require.ElementsMatchf(t, expected, []uuid.UUID{firstUser.UserID, extra.ID}, "expected members")
 		})
 
+		t.Run("AutoCreate", func(t *testing.T) {
+			t.Parallel()
+
+			ctx := testutil.Context(t, testutil.WaitLong)
+			conf := coderdtest.NewOIDCConfig(t, "")
+
+			config := conf.OIDCConfig(t, jwt.MapClaims{}, func(cfg *coderd.OIDCConfig) {
+				cfg.CreateMissingGroups = true
+			})
+			config.AllowSignups = true
+
+			auditor := audit.NewMock()
+			client := coderdenttest.New(t, &coderdenttest.Options{
+				AuditLogging: true,
+				Options: &coderdtest.Options{
+					Auditor:    auditor,
+					OIDCConfig: config,
+				},
+			})
+			firstUser := coderdtest.CreateFirstUser(t, client)
+			coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+				AllFeatures: true,
+			})
+
+			existing, err := client.CreateGroup(ctx, firstUser.OrganizationID, codersdk.CreateGroupRequest{
+				Name: "bingbong",
+			})
+			require.NoError(t, err)
+
+			resp := oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+				"email":  "colin@coder.com",
+				"groups": []string{existing.Name, "pingpong", database.AllUsersGroup},
+			}))
+			assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+			groups, err := client.GroupsByOrganization(ctx, firstUser.OrganizationID)
+			require.NoError(t, err)
+			members := map[string]int{}
+			for _, group := range groups {
+				members[group.Name] = len(group.Members)
+			}
+			require.Equal(t, map[string]int{
+				existing.Name: 1,
+				"pingpong":    1,
+			}, members)
+
+			// Every group change made by the sync is audited.
+			actions := map[string]database.AuditAction{}
+			for _, log := range auditor.AuditLogs() {
+				if log.ResourceType == database.ResourceTypeGroup {
+					actions[log.ResourceTarget] = log.Action
+				}
+			}
+			require.Equal(t, map[string]database.AuditAction{
+				existing.Name: database.AuditActionWrite,
+				"pingpong":    database.AuditActionCreate,
+			}, actions)
+		})
+
+		t.Run("RegexFilter", func(t *testing.T) {
+			t.Parallel()
+
+			ctx := testutil.Context(t, testutil.WaitLong)
+			conf := coderdtest.NewOIDCConfig(t, "")
+
+			config := conf.OIDCConfig(t, jwt.MapClaims{}, func(cfg *coderd.OIDCConfig) {
+				cfg.CreateMissingGroups = true
+				cfg.GroupFilter = regexp.MustCompile("^coder-")
+				cfg.GroupMapping = map[string]string{"coder-admins": "admins"}
+			})
+			config.AllowSignups = true
+
+			client := coderdenttest.New(t, &coderdenttest.Options{
+				Options: &coderdtest.Options{
+					OIDCConfig: config,
+				},
+			})
+			firstUser := coderdtest.CreateFirstUser(t, client)
+			coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+				AllFeatures: true,
+			})
+
+			resp := oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+				"email":  "colin@coder.com",
+				"groups": []string{"coder-admins", "coder-users", "sales"},
+			}))
+			assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+			groups, err := client.GroupsByOrganization(ctx, firstUser.OrganizationID)
+			require.NoError(t, err)
+			names := make([]string, 0, len(groups))
+			for _, group := range groups {
+				names = append(names, group.Name)
+			}
+			require.ElementsMatch(t, []string{"admins", "coder-users"}, names)
+		})
+
 		t.Run("NoneMatch", func(t *testing.T) {
 			t.Parallel()
 
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"net"
 	"net/http"
@@ -18,13 +19,29 @@ import (
 
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
 	agpl "github.com/coder/coder/tailnet"
 )
 
+const (
+	// HeartbeatInterval is how often coordinators heartbeat in the
+	// database.
+	HeartbeatInterval = 3 * time.Second
+	// HeartbeatTimeout is how long a coordinator can go without a
+	// heartbeat before it's considered dead. The nodes of the connections
+	// it owned are evicted along with it.
+	HeartbeatTimeout = 3 * HeartbeatInterval
+)
+
 // NewCoordinator creates a new high availability coordinator
 // that uses PostgreSQL pubsub to exchange handshakes.
-func NewCoordinator(logger slog.Logger, pubsub database.Pubsub) (agpl.Coordinator, error) {
-	ctx, cancelFunc := context.WithCancel(context.Background())
+//
+// The latest node of every connection is stored in the database along with
+// the coordinator that owns it, so connections can find peers on other
+// replicas without waiting for a handshake, even after a replica restarts.
+func NewCoordinator(logger slog.Logger, db database.Store, pubsub database.Pubsub) (agpl.Coordinator, error) {
+	// nolint:gocritic // The coordinator's state is a system function.
+	ctx, cancelFunc := context.WithCancel(dbauthz.AsSystemRestricted(context.Background()))
 
 	nameCache, err := lru.New[uuid.UUID, string](512)
 	if err != nil {
@@ -34,27 +51,50 @@ func NewCoordinator(logger slog.Logger, pubsub database.Pubsub) (agpl.Coordinato
 	coord := &haCoordinator{
 		id:                       uuid.New(),
 		log:                      logger,
+		db:                       db,
 		pubsub:                   pubsub,
+		ctx:                      ctx,
 		closeFunc:                cancelFunc,
 		close:                    make(chan struct{}),
 		nodes:                    map[uuid.UUID]*agpl.Node{},
 		agentSockets:             map[uuid.UUID]*agpl.TrackedConn{},
 		agentToConnectionSockets: map[uuid.UUID]map[uuid.UUID]*agpl.TrackedConn{},
+		agentPersistedClients:    map[uuid.UUID]map[string]struct{}{},
 		agentNameCache:           nameCache,
+		persistNotify:            make(chan struct{}, 1),
+		pendingAgents:            map[uuid.UUID]*agpl.Node{},
+		pendingClients:           map[uuid.UUID]pendingClient{},
 	}
 
+	_, err = db.UpsertTailnetCoordinator(ctx, database.UpsertTailnetCoordinatorParams{
+		ID:          coord.id,
+		HeartbeatAt: database.Now(),
+	})
+	if err != nil {
+		cancelFunc()
+		return nil, xerrors.Errorf("insert coordinator: %w", err)
+	}
+	coord.evictDeadCoordinators(ctx)
+
 	if err := coord.runPubsub(ctx); err != nil {
+		cancelFunc()
 		return nil, xerrors.Errorf("run coordinator pubsub: %w", err)
 	}
+	go coord.heartbeatLoop(ctx)
+	go coord.persistLoop(ctx)
 
 	return coord, nil
 }
 
 type haCoordinator struct {
-	id        uuid.UUID
-	log       slog.Logger
-	mutex     sync.RWMutex
-	pubsub    database.Pubsub
+	id     uuid.UUID
+	log    slog.Logger
+	mutex  sync.RWMutex
+	db     database.Store
+	pubsub database.Pubsub
+	// ctx is canceled when the coordinator closes, and is authorized to
+	// access the coordinator's state in the database.
+	ctx       context.Context
 	close     chan struct{}
 	closeFunc context.CancelFunc
 
@@ -65,10 +105,23 @@ type haCoordinator struct {
 	// agentToConnectionSockets maps agent IDs to connection IDs of conns that
 	// are subscribed to updates for that agent.
 	agentToConnectionSockets map[uuid.UUID]map[uuid.UUID]*agpl.TrackedConn
+	// agentPersistedClients maps agent IDs to the JSON of the client nodes
+	// that were sent to the agent from the database when it connected.
+	// Other coordinators send the same nodes in reply to the agent's hello,
+	// so those replies are skipped.
+	agentPersistedClients map[uuid.UUID]map[string]struct{}
 
 	// agentNameCache holds a cache of agent names. If one of them disappears,
 	// it's helpful to have a name cached for debugging.
 	agentNameCache *lru.Cache[uuid.UUID, string]
+
+	// persistMutex guards the node updates waiting to be written to the
+	// database by persistLoop. Only the latest update of each connection
+	// is kept, and a nil node deletes it.
+	persistMutex   sync.Mutex
+	persistNotify  chan struct{}
+	pendingAgents  map[uuid.UUID]*agpl.Node
+	pendingClients map[uuid.UUID]pendingClient
 }
 
 // Node returns an in-memory node by ID.
@@ -102,6 +155,11 @@ func (c *haCoordinator) ServeClient(conn net.Conn, id uuid.UUID, agent uuid.UUID
 	// node of the agent. This allows the connection to establish.
 	node, ok := c.nodes[agent]
 	c.mutex.Unlock()
+	if !ok {
+		// The agent may be connected to another replica, in which case
+		// its latest node is in the database.
+		node, ok = c.persistedAgentNode(agent)
+	}
 	if ok {
 		data, err := json.Marshal([]*agpl.Node{node})
 		if err != nil {
@@ -119,6 +177,8 @@ func (c *haCoordinator) ServeClient(conn net.Conn, id uuid.UUID, agent uuid.UUID
 	}
 
 	defer func() {
+		c.deletePersistedClient(id)
+
 		c.mutex.Lock()
 		defer c.mutex.Unlock()
 		// Clean all traces of this connection from the map.
@@ -162,6 +222,7 @@ func (c *haCoordinator) handleNextClientMessage(id, agent uuid.UUID, decoder *js
 	// Write the new node from this client to the actively connected agent.
 	agentSocket, ok := c.agentSockets[agent]
 	c.mutex.Unlock()
+	c.persistClient(id, agent, &node)
 	if !ok {
 		// If we don't own the agent locally, send it over pubsub to a node that
 		// owns the agent.
@@ -195,8 +256,11 @@ func (c *haCoordinator) handleNextClientMessage(id, agent uuid.UUID, decoder *js
 func (c *haCoordinator) ServeAgent(conn net.Conn, id uuid.UUID, name string) error {
 	c.agentNameCache.Add(id, name)
 
-	// Publish all nodes on this instance that want to connect to this agent.
+	// Publish all nodes that want to connect to this agent, including those
+	// of clients connected to other replicas.
 	nodes := c.nodesSubscribedToAgent(id)
+	persistedClients := c.persistedClientNodes(id)
+	nodes = append(nodes, persistedClients...)
 	if len(nodes) > 0 {
 		data, err := json.Marshal(nodes)
 		if err != nil {
@@ -231,6 +295,7 @@ func (c *haCoordinator) ServeAgent(conn net.Conn, id uuid.UUID, name string) err
 		LastWrite:  now,
 		Overwrites: overwrites,
 	}
+	c.agentPersistedClients[id] = nodeSet(persistedClients)
 	c.mutex.Unlock()
 
 	// Tell clients on other instances to send a callmemaybe to us.
@@ -241,14 +306,19 @@ func (c *haCoordinator) ServeAgent(conn net.Conn, id uuid.UUID, name string) err
 
 	defer func() {
 		c.mutex.Lock()
-		defer c.mutex.Unlock()
-
 		// Only delete the connection if it's ours. It could have been
 		// overwritten.
-		if idConn, ok := c.agentSockets[id]; ok && idConn.ID == unique {
+		idConn, ok := c.agentSockets[id]
+		ours := ok && idConn.ID == unique
+		if ours {
 			delete(c.agentSockets, id)
+			delete(c.agentPersistedClients, id)
 			delete(c.nodes, id)
 		}
+		c.mutex.Unlock()
+		if ours {
+			c.deletePersistedAgent(id)
+		}
 	}()
 
 	decoder := json.NewDecoder(conn)
@@ -260,6 +330,7 @@ func (c *haCoordinator) ServeAgent(conn net.Conn, id uuid.UUID, name string) err
 			}
 			return xerrors.Errorf("handle next agent message: %w", err)
 		}
+		c.persistAgent(id, node)
 
 		err = c.publishAgentToNodes(id, node)
 		if err != nil {
@@ -288,6 +359,57 @@ func (c *haCoordinator) nodesSubscribedToAgent(agentID uuid.UUID) []*agpl.Node {
 	return nodes
 }
 
+// skipPersistedClients removes the client nodes that were already sent to an
+// agent from the database. Each node is only skipped once. It returns nil if
+// every node was skipped.
+func (c *haCoordinator) skipPersistedClients(agent uuid.UUID, nodeJSON []byte) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	sent := c.agentPersistedClients[agent]
+	if len(sent) == 0 {
+		return nodeJSON, nil
+	}
+
+	var nodes []*agpl.Node
+	err := json.Unmarshal(nodeJSON, &nodes)
+	if err != nil {
+		return nil, xerrors.Errorf("unmarshal nodes: %w", err)
+	}
+	unsent := make([]*agpl.Node, 0, len(nodes))
+	for _, node := range nodes {
+		data, err := json.Marshal(node)
+		if err != nil {
+			return nil, xerrors.Errorf("marshal node: %w", err)
+		}
+		if _, ok := sent[string(data)]; ok {
+			delete(sent, string(data))
+			continue
+		}
+		unsent = append(unsent, node)
+	}
+	if len(unsent) == 0 {
+		return nil, nil
+	}
+	if len(unsent) == len(nodes) {
+		return nodeJSON, nil
+	}
+	return json.Marshal(unsent)
+}
+
+// nodeSet returns the JSON of each node. The database normalizes the JSON
+// of stored nodes, so nodes are compared after they're marshaled again.
+func nodeSet(nodes []*agpl.Node) map[string]struct{} {
+	set := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		data, err := json.Marshal(node)
+		if err != nil {
+			continue
+		}
+		set[string(data)] = struct{}{}
+	}
+	return set
+}
+
 func (c *haCoordinator) handleClientHello(id uuid.UUID) error {
 	c.mutex.Lock()
 	node, ok := c.nodes[id]
@@ -378,6 +500,17 @@ func (c *haCoordinator) Close() error {
 	}
 
 	wg.Wait()
+
+	// Deleting the coordinator deletes the nodes of its connections, so
+	// other replicas stop handing them out immediately rather than once the
+	// coordinator is considered dead.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// nolint:gocritic // The coordinator's state is a system function.
+	err := c.db.DeleteTailnetCoordinator(dbauthz.AsSystemRestricted(ctx), c.id)
+	if err != nil {
+		c.log.Warn(ctx, "delete coordinator", slog.Error(err))
+	}
 	return nil
 }
 
@@ -508,6 +641,15 @@ func (c *haCoordinator) handlePubsubMessage(ctx context.Context, message []byte)
 		}
 		c.mutex.Unlock()
 
+		nodeJSON, err = c.skipPersistedClients(agentUUID, nodeJSON)
+		if err != nil {
+			c.log.Error(ctx, "skip persisted client nodes", slog.Error(err))
+			return
+		}
+		if nodeJSON == nil {
+			return
+		}
+
 		// We get a single node over pubsub, so turn into an array.
 		_, err = agentSocket.Write(nodeJSON)
 		if err != nil {
@@ -617,11 +759,76 @@ func (c *haCoordinator) formatAgentUpdate(id uuid.UUID, node *agpl.Node) ([]byte
 func (c *haCoordinator) ServeHTTPDebug(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
+	// Fetch the cluster-wide state before locking, so a slow database
+	// doesn't block the coordinator.
+	ctx := dbauthz.AsSystemRestricted(r.Context()) // nolint:gocritic // The coordinator's state is a system function.
+	coordinators, coordinatorsErr := c.db.GetTailnetCoordinators(ctx)
+	agents, agentsErr := c.db.GetAllTailnetAgents(ctx)
+	clients, clientsErr := c.db.GetAllTailnetClients(ctx)
+
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
 	_, _ = fmt.Fprintln(w, "<h1>high-availability wireguard coordinator debug</h1>")
-	_, _ = fmt.Fprintln(w, "<h4 style=\"margin-top:-25px\">warning: this only provides info from the node that served the request, if there are multiple replicas this data may be incomplete</h4>")
+	_, _ = fmt.Fprintf(w, "<h4 style=\"margin-top:-25px\">coordinator <code>%s</code>, the connections below are the ones served by this replica</h4>\n", c.id)
 
 	agpl.CoordinatorHTTPDebug(c.agentSockets, c.agentToConnectionSockets, c.agentNameCache)(w, r)
+
+	_, _ = fmt.Fprintln(w, "<h2 id=cluster><a href=#cluster>#</a> cluster</h2>")
+	if err := errors.Join(coordinatorsErr, agentsErr, clientsErr); err != nil {
+		_, _ = fmt.Fprintf(w, "<p>failed to fetch cluster state: %s</p>\n", html.EscapeString(err.Error()))
+		return
+	}
+	clusterHTTPDebug(w, c.id, coordinators, agents, clients, c.agentNameCache)
+}
+
+// clusterHTTPDebug writes the connections of every coordinator in the
+// cluster, as stored in the database.
+func clusterHTTPDebug(w io.Writer, self uuid.UUID, coordinators []database.TailnetCoordinator, agents []database.TailnetAgent, clients []database.TailnetClient, agentNameCache *lru.Cache[uuid.UUID, string]) {
+	now := database.Now()
+	agentsByCoordinator := map[uuid.UUID][]database.TailnetAgent{}
+	for _, agent := range agents {
+		agentsByCoordinator[agent.CoordinatorID] = append(agentsByCoordinator[agent.CoordinatorID], agent)
+	}
+	clientsByCoordinator := map[uuid.UUID][]database.TailnetClient{}
+	for _, client := range clients {
+		clientsByCoordinator[client.CoordinatorID] = append(clientsByCoordinator[client.CoordinatorID], client)
+	}
+
+	_, _ = fmt.Fprintf(w, "<h3>coordinators: total %d</h3>\n", len(coordinators))
+	_, _ = fmt.Fprintln(w, "<ul>")
+	for _, coordinator := range coordinators {
+		var notes string
+		if coordinator.ID == self {
+			notes += " (this replica)"
+		}
+		if now.Sub(coordinator.HeartbeatAt) > HeartbeatTimeout {
+			notes += " (dead, will be evicted)"
+		}
+		_, _ = fmt.Fprintf(w, "<li style=\"margin-top:4px\"><code>%s</code>%s: heartbeat %v ago, agents %d, clients %d</li>\n",
+			coordinator.ID,
+			notes,
+			now.Sub(coordinator.HeartbeatAt).Round(time.Second),
+			len(agentsByCoordinator[coordinator.ID]),
+			len(clientsByCoordinator[coordinator.ID]),
+		)
+		_, _ = fmt.Fprintln(w, "<ul>")
+		for _, agent := range agentsByCoordinator[coordinator.ID] {
+			name, _ := agentNameCache.Get(agent.ID)
+			_, _ = fmt.Fprintf(w, "<li>agent <b>%s</b> (<code>%s</code>): updated %v ago</li>\n",
+				html.EscapeString(name),
+				agent.ID,
+				now.Sub(agent.UpdatedAt).Round(time.Second),
+			)
+		}
+		for _, client := range clientsByCoordinator[coordinator.ID] {
+			_, _ = fmt.Fprintf(w, "<li>client <code>%s</code> to agent <code>%s</code>: updated %v ago</li>\n",
+				client.ID,
+				client.AgentID,
+				now.Sub(client.UpdatedAt).Round(time.Second),
+			)
+		}
+		_, _ = fmt.Fprintln(w, "</ul>")
+	}
+	_, _ = fmt.Fprintln(w, "</ul>")
 }
@@ -1,7 +1,12 @@
 package tailnet_test
 
 import (
+	"context"
+	"encoding/json"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"github.com/google/uuid"
@@ -11,6 +16,7 @@ import (
 	"cdr.dev/slog/sloggers/slogtest"
 
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbfake"
 	"github.com/coder/coder/coderd/database/dbtestutil"
 	"github.com/coder/coder/enterprise/tailnet"
 	agpl "github.com/coder/coder/tailnet"
@@ -21,7 +27,7 @@ func TestCoordinatorSingle(t *testing.T) {
 	t.Parallel()
 	t.Run("ClientWithoutAgent", func(t *testing.T) {
 		t.Parallel()
-		coordinator, err := tailnet.NewCoordinator(slogtest.Make(t, nil), database.NewPubsubInMemory())
+		coordinator, err := tailnet.NewCoordinator(slogtest.Make(t, nil), dbfake.New(), database.NewPubsubInMemory())
 		require.NoError(t, err)
 		defer coordinator.Close()
 
@@ -49,7 +55,7 @@ func TestCoordinatorSingle(t *testing.T) {
 
 	t.Run("AgentWithoutClients", func(t *testing.T) {
 		t.Parallel()
-		coordinator, err := tailnet.NewCoordinator(slogtest.Make(t, nil), database.NewPubsubInMemory())
+		coordinator, err := tailnet.NewCoordinator(slogtest.Make(t, nil), dbfake.New(), database.NewPubsubInMemory())
 		require.NoError(t, err)
 		defer coordinator.Close()
 
@@ -77,7 +83,7 @@ func TestCoordinatorSingle(t *testing.T) {
 	t.Run("AgentWithClient", func(t *testing.T) {
 		t.Parallel()
 
-		coordinator, err := tailnet.NewCoordinator(slogtest.Make(t, nil), database.NewPubsubInMemory())
+		coordinator, err := tailnet.NewCoordinator(slogtest.Make(t, nil), dbfake.New(), database.NewPubsubInMemory())
 		require.NoError(t, err)
 		defer coordinator.Close()
 
@@ -168,9 +174,9 @@ func TestCoordinatorHA(t *testing.T) {
 	t.Run("AgentWithClient", func(t *testing.T) {
 		t.Parallel()
 
-		_, pubsub := dbtestutil.NewDB(t)
+		db, pubsub := dbtestutil.NewDB(t)
 
-		coordinator1, err := tailnet.NewCoordinator(slogtest.Make(t, nil), pubsub)
+		coordinator1, err := tailnet.NewCoordinator(slogtest.Make(t, nil), db, pubsub)
 		require.NoError(t, err)
 		defer coordinator1.Close()
 
@@ -193,7 +199,7 @@ func TestCoordinatorHA(t *testing.T) {
 			return coordinator1.Node(agentID) != nil
 		}, testutil.WaitShort, testutil.IntervalFast)
 
-		coordinator2, err := tailnet.NewCoordinator(slogtest.Make(t, nil), pubsub)
+		coordinator2, err := tailnet.NewCoordinator(slogtest.Make(t, nil), db, pubsub)
 		require.NoError(t, err)
 		defer coordinator2.Close()
 
@@ -259,3 +265,302 @@ func TestCoordinatorHA(t *testing.T) {
 		<-closeClientChan
 	})
 }
+
+func TestCoordinatorHAPersistence(t *testing.T) {
+	t.Parallel()
+
+	t.Run("PeersFromDatabase", func(t *testing.T) {
+		t.Parallel()
+
+		// The coordinators don't share a pubsub, so the only way for them to
+		// find each other's peers is the database.
+		db := dbfake.New()
+		coordinator1, err := tailnet.NewCoordinator(slogtest.Make(t, nil), db, database.NewPubsubInMemory())
+		require.NoError(t, err)
+		defer coordinator1.Close()
+		coordinator2, err := tailnet.NewCoordinator(slogtest.Make(t, nil), db, database.NewPubsubInMemory())
+		require.NoError(t, err)
+		defer coordinator2.Close()
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		sendAgentNode, agentErrChan := agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			return nil
+		})
+		agentID := uuid.New()
+		closeAgentChan := make(chan struct{})
+		go func() {
+			err := coordinator1.ServeAgent(agentServerWS, agentID, "")
+			assert.NoError(t, err)
+			close(closeAgentChan)
+		}()
+		sendAgentNode(&agpl.Node{PreferredDERP: 1})
+		require.Eventually(t, func() bool {
+			agents, err := db.GetTailnetAgents(context.Background(), agentID)
+			return err == nil && len(agents) == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		// A client on the other replica gets the agent node immediately.
+		clientWS, clientServerWS := net.Pipe()
+		defer clientWS.Close()
+		clientNodeChan := make(chan []*agpl.Node, 1)
+		sendClientNode, clientErrChan := agpl.ServeCoordinator(clientWS, func(nodes []*agpl.Node) error {
+			clientNodeChan <- nodes
+			return nil
+		})
+		clientID := uuid.New()
+		closeClientChan := make(chan struct{})
+		go func() {
+			err := coordinator2.ServeClient(clientServerWS, clientID, agentID)
+			assert.NoError(t, err)
+			close(closeClientChan)
+		}()
+		agentNodes := <-clientNodeChan
+		require.Len(t, agentNodes, 1)
+		require.Equal(t, 1, agentNodes[0].PreferredDERP)
+
+		sendClientNode(&agpl.Node{PreferredDERP: 2})
+		require.Eventually(t, func() bool {
+			clients, err := db.GetTailnetClientsForAgent(context.Background(), agentID)
+			return err == nil && len(clients) == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		// When the agent reconnects, it gets the client node immediately.
+		require.NoError(t, agentWS.Close())
+		<-agentErrChan
+		<-closeAgentChan
+		agentWS, agentServerWS = net.Pipe()
+		defer agentWS.Close()
+		agentNodeChan := make(chan []*agpl.Node, 1)
+		_, agentErrChan = agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			agentNodeChan <- nodes
+			return nil
+		})
+		closeAgentChan = make(chan struct{})
+		go func() {
+			err := coordinator1.ServeAgent(agentServerWS, agentID, "")
+			assert.NoError(t, err)
+			close(closeAgentChan)
+		}()
+		clientNodes := <-agentNodeChan
+		require.Len(t, clientNodes, 1)
+		require.Equal(t, 2, clientNodes[0].PreferredDERP)
+
+		require.NoError(t, agentWS.Close())
+		<-agentErrChan
+		<-closeAgentChan
+		require.NoError(t, clientWS.Close())
+		<-clientErrChan
+		<-closeClientChan
+
+		// Nodes are removed once their connections close.
+		require.Eventually(t, func() bool {
+			agents, err := db.GetAllTailnetAgents(context.Background())
+			if err != nil || len(agents) != 0 {
+				return false
+			}
+			clients, err := db.GetAllTailnetClients(context.Background())
+			return err == nil && len(clients) == 0
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+
+	t.Run("CoalesceUpdates", func(t *testing.T) {
+		t.Parallel()
+
+		db := &countingStore{Store: dbfake.New()}
+		coordinator, err := tailnet.NewCoordinator(slogtest.Make(t, nil), db, database.NewPubsubInMemory())
+		require.NoError(t, err)
+		defer coordinator.Close()
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		sendAgentNode, agentErrChan := agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			return nil
+		})
+		agentID := uuid.New()
+		closeAgentChan := make(chan struct{})
+		go func() {
+			err := coordinator.ServeAgent(agentServerWS, agentID, "")
+			assert.NoError(t, err)
+			close(closeAgentChan)
+		}()
+
+		// A burst of updates is written as far fewer upserts, with the
+		// latest node winning.
+		const updates = 100
+		for i := 1; i <= updates; i++ {
+			sendAgentNode(&agpl.Node{PreferredDERP: i})
+		}
+		require.Eventually(t, func() bool {
+			agents, err := db.GetTailnetAgents(context.Background(), agentID)
+			if err != nil || len(agents) != 1 {
+				return false
+			}
+			var node agpl.Node
+			err = json.Unmarshal(agents[0].Node, &node)
+			return err == nil && node.PreferredDERP == updates
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.Less(t, db.agentUpserts.Load(), int64(updates))
+
+		require.NoError(t, agentWS.Close())
+		<-agentErrChan
+		<-closeAgentChan
+	})
+
+	t.Run("EvictDeadCoordinators", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		db := dbfake.New()
+		dead, err := db.UpsertTailnetCoordinator(ctx, database.UpsertTailnetCoordinatorParams{
+			ID:          uuid.New(),
+			HeartbeatAt: database.Now().Add(-2 * tailnet.HeartbeatTimeout),
+		})
+		require.NoError(t, err)
+		agentID := uuid.New()
+		_, err = db.UpsertTailnetAgent(ctx, database.UpsertTailnetAgentParams{
+			ID:            agentID,
+			CoordinatorID: dead.ID,
+			UpdatedAt:     dead.HeartbeatAt,
+			Node:          json.RawMessage("{}"),
+		})
+		require.NoError(t, err)
+
+		coordinator, err := tailnet.NewCoordinator(slogtest.Make(t, nil), db, database.NewPubsubInMemory())
+		require.NoError(t, err)
+
+		coordinators, err := db.GetTailnetCoordinators(ctx)
+		require.NoError(t, err)
+		require.Len(t, coordinators, 1)
+		require.NotEqual(t, dead.ID, coordinators[0].ID)
+		agents, err := db.GetTailnetAgents(ctx, agentID)
+		require.NoError(t, err)
+		require.Empty(t, agents)
+
+		// Closing removes the coordinator right away.
+		require.NoError(t, coordinator.Close())
+		coordinators, err = db.GetTailnetCoordinators(ctx)
+		require.NoError(t, err)
+		require.Empty(t, coordinators)
+	})
+
+	t.Run("SlowDatabase", func(t *testing.T) {
+		t.Parallel()
+
+		// Coordinators fall back to the pubsub handshake when reading the
+		// nodes of other coordinators takes too long.
+		db := &slowStore{Store: dbfake.New()}
+		pubsub := database.NewPubsubInMemory()
+		coordinator1, err := tailnet.NewCoordinator(slogtest.Make(t, nil), db, pubsub)
+		require.NoError(t, err)
+		defer coordinator1.Close()
+		coordinator2, err := tailnet.NewCoordinator(slogtest.Make(t, nil), db, pubsub)
+		require.NoError(t, err)
+		defer coordinator2.Close()
+
+		agentWS, agentServerWS := net.Pipe()
+		defer agentWS.Close()
+		agentNodeChan := make(chan []*agpl.Node)
+		sendAgentNode, agentErrChan := agpl.ServeCoordinator(agentWS, func(nodes []*agpl.Node) error {
+			agentNodeChan <- nodes
+			return nil
+		})
+		agentID := uuid.New()
+		closeAgentChan := make(chan struct{})
+		go func() {
+			err := coordinator1.ServeAgent(agentServerWS, agentID, "")
+			assert.NoError(t, err)
+			close(closeAgentChan)
+		}()
+		sendAgentNode(&agpl.Node{PreferredDERP: 1})
+		require.Eventually(t, func() bool {
+			return coordinator1.Node(agentID) != nil
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		clientWS, clientServerWS := net.Pipe()
+		defer clientWS.Close()
+		clientNodeChan := make(chan []*agpl.Node)
+		sendClientNode, clientErrChan := agpl.ServeCoordinator(clientWS, func(nodes []*agpl.Node) error {
+			clientNodeChan <- nodes
+			return nil
+		})
+		clientID := uuid.New()
+		closeClientChan := make(chan struct{})
+		go func() {
+			err := coordinator2.ServeClient(clientServerWS, clientID, agentID)
+			assert.NoError(t, err)
+			close(closeClientChan)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		select {
+		case agentNodes := <-clientNodeChan:
+			require.Len(t, agentNodes, 1)
+			require.Equal(t, 1, agentNodes[0].PreferredDERP)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the agent node")
+		}
+		sendClientNode(&agpl.Node{PreferredDERP: 2})
+		select {
+		case clientNodes := <-agentNodeChan:
+			require.Len(t, clientNodes, 1)
+			require.Equal(t, 2, clientNodes[0].PreferredDERP)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the client node")
+		}
+
+		require.NoError(t, agentWS.Close())
+		<-agentErrChan
+		<-closeAgentChan
+		require.NoError(t, clientWS.Close())
+		<-clientErrChan
+		<-closeClientChan
+	})
+
+	t.Run("HTTPDebug", func(t *testing.T) {
+		t.Parallel()
+
+		db := dbfake.New()
+		pubsub := database.NewPubsubInMemory()
+		coordinator1, err := tailnet.NewCoordinator(slogtest.Make(t, nil), db, pubsub)
+		require.NoError(t, err)
+		defer coordinator1.Close()
+		coordinator2, err := tailnet.NewCoordinator(slogtest.Make(t, nil), db, pubsub)
+		require.NoError(t, err)
+		defer coordinator2.Close()
+
+		rw := httptest.NewRecorder()
+		coordinator1.ServeHTTPDebug(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusOK, rw.Code)
+		body := rw.Body.String()
+		require.Contains(t, body, "coordinators: total 2")
+		require.Contains(t, body, "(this replica)")
+	})
+}
+
+// countingStore counts the agent node upserts made by a coordinator.
+type countingStore struct {
+	database.Store
+	agentUpserts atomic.Int64
+}
+
+func (s *countingStore) UpsertTailnetAgent(ctx context.Context, arg database.UpsertTailnetAgentParams) (database.TailnetAgent, error) {
+	s.agentUpserts.Add(1)
+	return s.Store.UpsertTailnetAgent(ctx, arg)
+}
+
+// slowStore blocks reads of persisted nodes until they're canceled.
+type slowStore struct {
+	database.Store
+}
+
+func (*slowStore) GetTailnetAgents(ctx context.Context, _ uuid.UUID) ([]database.TailnetAgent, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (*slowStore) GetTailnetClientsForAgent(ctx context.Context, _ uuid.UUID) ([]database.TailnetClient, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
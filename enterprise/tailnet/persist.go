@@ -0,0 +1,288 @@
+package tailnet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	agpl "github.com/coder/coder/tailnet"
+)
+
+// heartbeatLoop keeps the coordinator alive in the database and evicts
+// coordinators that have died.
+func (c *haCoordinator) heartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+
+	// resync is set when a heartbeat fails. If it failed for long enough,
+	// other replicas evicted this coordinator along with its nodes.
+	resync := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		_, err := c.db.UpsertTailnetCoordinator(ctx, database.UpsertTailnetCoordinatorParams{
+			ID:          c.id,
+			HeartbeatAt: database.Now(),
+		})
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				c.log.Warn(ctx, "heartbeat coordinator", slog.Error(err))
+			}
+			resync = true
+			continue
+		}
+		if resync {
+			c.persistAll()
+			resync = false
+		}
+		c.evictDeadCoordinators(ctx)
+	}
+}
+
+// evictDeadCoordinators deletes coordinators that stopped heartbeating, and
+// the nodes of the connections they owned.
+func (c *haCoordinator) evictDeadCoordinators(ctx context.Context) {
+	err := c.db.DeleteTailnetCoordinatorsHeartbeatBefore(ctx, database.Now().Add(-HeartbeatTimeout))
+	if err != nil && !errors.Is(err, context.Canceled) {
+		c.log.Warn(ctx, "evict dead coordinators", slog.Error(err))
+	}
+}
+
+// persistAll queues the nodes of every connection the coordinator owns to be
+// stored.
+func (c *haCoordinator) persistAll() {
+	type clientNode struct {
+		id    uuid.UUID
+		agent uuid.UUID
+		node  *agpl.Node
+	}
+	c.mutex.RLock()
+	agents := map[uuid.UUID]*agpl.Node{}
+	for id := range c.agentSockets {
+		if node, ok := c.nodes[id]; ok {
+			agents[id] = node
+		}
+	}
+	clients := []clientNode{}
+	for agent, sockets := range c.agentToConnectionSockets {
+		for id := range sockets {
+			if node, ok := c.nodes[id]; ok {
+				clients = append(clients, clientNode{id: id, agent: agent, node: node})
+			}
+		}
+	}
+	c.mutex.RUnlock()
+
+	for id, node := range agents {
+		c.persistAgent(id, node)
+	}
+	for _, client := range clients {
+		c.persistClient(client.id, client.agent, client.node)
+	}
+}
+
+// persistDebounce is the minimum time between writes of node updates to
+// the database. Updates to the same connection within it are coalesced, so
+// only the latest node is written.
+const persistDebounce = 100 * time.Millisecond
+
+// persistedLookupTimeout bounds reads of the nodes of other coordinators when
+// a connection is served. They only speed up connecting, since the pubsub
+// handshake exchanges the same nodes, so slow reads are abandoned.
+const persistedLookupTimeout = time.Second
+
+// pendingClient is a client node waiting to be written to the database. A
+// nil node deletes the client.
+type pendingClient struct {
+	agent uuid.UUID
+	node  *agpl.Node
+}
+
+// persistLoop writes queued node updates to the database, so the loops
+// reading from connections never wait on it.
+func (c *haCoordinator) persistLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.persistNotify:
+		}
+		c.flushPersisted(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(persistDebounce):
+		}
+	}
+}
+
+// flushPersisted writes the latest queued node of every connection that
+// changed since the last flush.
+func (c *haCoordinator) flushPersisted(ctx context.Context) {
+	c.persistMutex.Lock()
+	agents, clients := c.pendingAgents, c.pendingClients
+	c.pendingAgents = map[uuid.UUID]*agpl.Node{}
+	c.pendingClients = map[uuid.UUID]pendingClient{}
+	c.persistMutex.Unlock()
+
+	for id, node := range agents {
+		if node == nil {
+			c.deleteAgentNode(ctx, id)
+			continue
+		}
+		c.upsertAgentNode(ctx, id, node)
+	}
+	for id, client := range clients {
+		if client.node == nil {
+			c.deleteClientNode(ctx, id)
+			continue
+		}
+		c.upsertClientNode(ctx, id, client.agent, client.node)
+	}
+}
+
+func (c *haCoordinator) notifyPersist() {
+	select {
+	case c.persistNotify <- struct{}{}:
+	default:
+	}
+}
+
+func (c *haCoordinator) persistAgent(id uuid.UUID, node *agpl.Node) {
+	c.persistMutex.Lock()
+	c.pendingAgents[id] = node
+	c.persistMutex.Unlock()
+	c.notifyPersist()
+}
+
+func (c *haCoordinator) deletePersistedAgent(id uuid.UUID) {
+	c.persistAgent(id, nil)
+}
+
+func (c *haCoordinator) persistClient(id, agent uuid.UUID, node *agpl.Node) {
+	c.persistMutex.Lock()
+	c.pendingClients[id] = pendingClient{agent: agent, node: node}
+	c.persistMutex.Unlock()
+	c.notifyPersist()
+}
+
+func (c *haCoordinator) deletePersistedClient(id uuid.UUID) {
+	c.persistClient(id, uuid.Nil, nil)
+}
+
+func (c *haCoordinator) upsertAgentNode(ctx context.Context, id uuid.UUID, node *agpl.Node) {
+	data, err := json.Marshal(node)
+	if err != nil {
+		c.log.Error(ctx, "marshal agent node", slog.F("agent_id", id), slog.Error(err))
+		return
+	}
+	_, err = c.db.UpsertTailnetAgent(ctx, database.UpsertTailnetAgentParams{
+		ID:            id,
+		CoordinatorID: c.id,
+		UpdatedAt:     database.Now(),
+		Node:          data,
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		c.log.Warn(ctx, "persist agent node", slog.F("agent_id", id), slog.Error(err))
+	}
+}
+
+func (c *haCoordinator) deleteAgentNode(ctx context.Context, id uuid.UUID) {
+	err := c.db.DeleteTailnetAgent(ctx, database.DeleteTailnetAgentParams{
+		ID:            id,
+		CoordinatorID: c.id,
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		c.log.Warn(ctx, "delete persisted agent node", slog.F("agent_id", id), slog.Error(err))
+	}
+}
+
+func (c *haCoordinator) upsertClientNode(ctx context.Context, id, agent uuid.UUID, node *agpl.Node) {
+	data, err := json.Marshal(node)
+	if err != nil {
+		c.log.Error(ctx, "marshal client node", slog.F("client_id", id), slog.Error(err))
+		return
+	}
+	_, err = c.db.UpsertTailnetClient(ctx, database.UpsertTailnetClientParams{
+		ID:            id,
+		CoordinatorID: c.id,
+		AgentID:       agent,
+		UpdatedAt:     database.Now(),
+		Node:          data,
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		c.log.Warn(ctx, "persist client node", slog.F("client_id", id), slog.Error(err))
+	}
+}
+
+func (c *haCoordinator) deleteClientNode(ctx context.Context, id uuid.UUID) {
+	err := c.db.DeleteTailnetClient(ctx, database.DeleteTailnetClientParams{
+		ID:            id,
+		CoordinatorID: c.id,
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		c.log.Warn(ctx, "delete persisted client node", slog.F("client_id", id), slog.Error(err))
+	}
+}
+
+// persistedAgentNode returns the latest node of an agent connected to any
+// coordinator.
+func (c *haCoordinator) persistedAgentNode(agent uuid.UUID) (*agpl.Node, bool) {
+	ctx, cancel := context.WithTimeout(c.ctx, persistedLookupTimeout)
+	defer cancel()
+	agents, err := c.db.GetTailnetAgents(ctx, agent)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			c.log.Warn(c.ctx, "get persisted agent node", slog.F("agent_id", agent), slog.Error(err))
+		}
+		return nil, false
+	}
+	if len(agents) == 0 {
+		return nil, false
+	}
+	var node agpl.Node
+	err = json.Unmarshal(agents[0].Node, &node)
+	if err != nil {
+		c.log.Error(c.ctx, "unmarshal persisted agent node", slog.F("agent_id", agent), slog.Error(err))
+		return nil, false
+	}
+	return &node, true
+}
+
+// persistedClientNodes returns the nodes of clients connected to other
+// coordinators that want to connect to an agent.
+func (c *haCoordinator) persistedClientNodes(agent uuid.UUID) []*agpl.Node {
+	ctx, cancel := context.WithTimeout(c.ctx, persistedLookupTimeout)
+	defer cancel()
+	clients, err := c.db.GetTailnetClientsForAgent(ctx, agent)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			c.log.Warn(c.ctx, "get persisted client nodes", slog.F("agent_id", agent), slog.Error(err))
+		}
+		return nil
+	}
+	nodes := make([]*agpl.Node, 0, len(clients))
+	for _, client := range clients {
+		// Clients of this coordinator are already known.
+		if client.CoordinatorID == c.id {
+			continue
+		}
+		var node agpl.Node
+		err = json.Unmarshal(client.Node, &node)
+		if err != nil {
+			c.log.Error(c.ctx, "unmarshal persisted client node", slog.F("client_id", client.ID), slog.Error(err))
+			continue
+		}
+		nodes = append(nodes, &node)
+	}
+	return nodes
+}
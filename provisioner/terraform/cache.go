@@ -0,0 +1,883 @@
+package terraform
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+const (
+	cacheTypeProvider = "provider"
+	cacheTypeModule   = "module"
+
+	// cacheEvictionGracePeriod protects recently used cache entries from
+	// eviction, since running jobs may still need them after init.
+	cacheEvictionGracePeriod = time.Hour
+)
+
+// CacheMetrics are the Prometheus metrics of a Cache.
+type CacheMetrics struct {
+	Hits      *prometheus.CounterVec
+	Misses    *prometheus.CounterVec
+	SizeBytes prometheus.Gauge
+}
+
+func NewCacheMetrics(reg prometheus.Registerer) *CacheMetrics {
+	auto := promauto.With(reg)
+
+	return &CacheMetrics{
+		Hits: auto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "provisionerd",
+			Name:      "terraform_cache_hits_total",
+			Help:      "The number of Terraform providers and modules that were found in the cache on init.",
+		}, []string{"cache"}),
+		Misses: auto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "provisionerd",
+			Name:      "terraform_cache_misses_total",
+			Help:      "The number of Terraform providers and modules that were downloaded on init.",
+		}, []string{"cache"}),
+		SizeBytes: auto.NewGauge(prometheus.GaugeOpts{
+			Namespace: "coderd",
+			Subsystem: "provisionerd",
+			Name:      "terraform_cache_size_bytes",
+			Help:      "The size of the Terraform provider and module caches in bytes.",
+		}),
+	}
+}
+
+type CacheOptions struct {
+	// Path is the directory the caches are stored in. It can be shared by
+	// provisioner daemons in multiple processes.
+	Path string
+	// MaxSize is the size in bytes that the caches are evicted down to
+	// after each init, least recently used first. Zero disables eviction.
+	MaxSize int64
+	// MirrorPath is a Terraform provider filesystem mirror, in the packed
+	// or unpacked layout, that the provider cache is seeded from.
+	MirrorPath string
+	Logger     slog.Logger
+	Metrics    *CacheMetrics
+}
+
+// Cache is a Terraform provider plugin cache and module cache shared by
+// provisioner jobs. Terraform doesn't support concurrent writes to the plugin
+// cache, so each job installs providers through its own plugin cache
+// directory that links to the shared one. Updates to the shared caches are
+// serialized with a file lock.
+type Cache struct {
+	options CacheOptions
+}
+
+// NewCache creates the cache directories, and seeds the provider cache from
+// the mirror if one is configured.
+func NewCache(ctx context.Context, options CacheOptions) (*Cache, error) {
+	cache := &Cache{options: options}
+	for _, dir := range []string{cache.pluginPath(), filepath.Join(options.Path, "modules"), cache.leasePath()} {
+		err := os.MkdirAll(dir, 0o750)
+		if err != nil {
+			return nil, xerrors.Errorf("mkdir %q: %w", dir, err)
+		}
+	}
+	if options.MirrorPath == "" {
+		return cache, nil
+	}
+
+	unlock, err := cache.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	err = cache.seed(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("seed from mirror %q: %w", options.MirrorPath, err)
+	}
+	return cache, nil
+}
+
+func (c *Cache) pluginPath() string {
+	return filepath.Join(c.options.Path, "plugins")
+}
+
+// jobPluginPath is the plugin cache directory of the job in the working
+// directory. Providers that init downloads are moved to the shared cache
+// afterwards.
+func (*Cache) jobPluginPath(workdir string) string {
+	return filepath.Join(workdir, ".plugin-cache")
+}
+
+func (c *Cache) modulePath(key string) string {
+	return filepath.Join(c.options.Path, "modules", key)
+}
+
+// leasePath is the directory of the leases held by running jobs. Cache
+// entries in a lease are never evicted.
+func (c *Cache) leasePath() string {
+	return filepath.Join(c.options.Path, "leases")
+}
+
+func (c *Cache) lock(ctx context.Context) (func(), error) {
+	// A new flock is created for every lock, since a flock can't be locked
+	// by multiple goroutines at once.
+	lockFilePath := filepath.Join(c.options.Path, "lock")
+	lock := flock.New(lockFilePath)
+	ok, err := lock.TryLockContext(ctx, time.Millisecond*100)
+	if !ok {
+		return nil, xerrors.Errorf("could not acquire flock for %v: %w", lockFilePath, err)
+	}
+	return func() {
+		_ = lock.Close()
+	}, nil
+}
+
+// init restores the cached providers and modules into the working directory
+// and runs initFn, which must run `terraform init` with the plugin cache of
+// the job. The file lock isn't held while initFn runs. Downloaded providers
+// and modules are cached afterwards, and the caches are evicted down to size.
+//
+// The linked providers are leased until the returned function is called,
+// which must happen once the job no longer runs Terraform.
+func (c *Cache) init(ctx context.Context, workdir string, initFn func() error) (func(), error) {
+	moduleKey, err := moduleCacheKey(workdir)
+	if err != nil {
+		return nil, xerrors.Errorf("get module cache key: %w", err)
+	}
+
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cachedProviders, err := c.providerVersions(c.pluginPath())
+	if err != nil {
+		unlock()
+		return nil, xerrors.Errorf("list cached providers: %w", err)
+	}
+	linkedProviders, err := c.linkProviders(c.jobPluginPath(workdir))
+	if err != nil {
+		unlock()
+		return nil, xerrors.Errorf("link cached providers: %w", err)
+	}
+	// Init, plan and apply run the providers through the links, so they
+	// must outlive evictions by other jobs until the job is done.
+	release, err := c.acquireLease(linkedProviders)
+	if err != nil {
+		unlock()
+		return nil, xerrors.Errorf("lease cached providers: %w", err)
+	}
+	moduleHit, err := c.restoreModules(moduleKey, workdir)
+	unlock()
+	if err != nil {
+		release()
+		return nil, xerrors.Errorf("restore cached modules: %w", err)
+	}
+
+	err = initFn()
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	// The job doesn't depend on the cache being updated, so failures are
+	// only logged.
+	err = c.update(ctx, workdir, moduleKey, moduleHit, cachedProviders)
+	if err != nil {
+		c.options.Logger.Warn(ctx, "update terraform cache", slog.F("workdir", workdir), slog.Error(err))
+	}
+	return release, nil
+}
+
+// update caches the providers and modules that init installed, records
+// cache hits and misses, and evicts the caches down to size.
+func (c *Cache) update(ctx context.Context, workdir, moduleKey string, moduleHit bool, cachedProviders map[string]struct{}) error {
+	// Check the modules before taking the lock, since it loads the
+	// configuration of every installed module.
+	modulesCacheable := false
+	if !moduleHit {
+		var err error
+		modulesCacheable, err = modulesPinned(workdir)
+		if err != nil {
+			return xerrors.Errorf("check installed modules: %w", err)
+		}
+	}
+
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = c.saveProviders(c.jobPluginPath(workdir))
+	if err != nil {
+		return xerrors.Errorf("cache providers: %w", err)
+	}
+	err = c.recordProviders(cachedProviders, workdir)
+	if err != nil {
+		return xerrors.Errorf("record cached providers: %w", err)
+	}
+	if moduleHit {
+		c.record(cacheTypeModule, true)
+	} else if modulesCacheable {
+		saved, err := c.saveModules(moduleKey, workdir)
+		if err != nil {
+			return xerrors.Errorf("cache modules: %w", err)
+		}
+		if saved {
+			c.record(cacheTypeModule, false)
+		}
+	}
+
+	err = c.evict(ctx)
+	if err != nil {
+		return xerrors.Errorf("evict cache: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) record(cacheType string, hit bool) {
+	if c.options.Metrics == nil {
+		return
+	}
+	if hit {
+		c.options.Metrics.Hits.WithLabelValues(cacheType).Inc()
+	} else {
+		c.options.Metrics.Misses.WithLabelValues(cacheType).Inc()
+	}
+}
+
+// providerVersions returns the provider versions in a directory with the
+// HOSTNAME/NAMESPACE/TYPE/VERSION layout, relative to the directory.
+func (*Cache) providerVersions(dir string) (map[string]struct{}, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		versions[rel] = struct{}{}
+	}
+	return versions, nil
+}
+
+// linkProviders links the cached providers into the plugin cache directory of
+// a job, so init can install them without writing to the shared cache. The
+// linked provider versions are marked as recently used and returned relative
+// to the shared cache.
+func (c *Cache) linkProviders(dir string) ([]string, error) {
+	if !pluginCacheSupported() {
+		return nil, nil
+	}
+	// Terraform fails if the plugin cache directory doesn't exist.
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, err
+	}
+	// HOSTNAME/NAMESPACE/TYPE/VERSION/TARGET
+	targets, err := filepath.Glob(filepath.Join(c.pluginPath(), "*", "*", "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	linked := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if strings.HasSuffix(target, ".tmp") {
+			continue
+		}
+		rel, err := filepath.Rel(c.pluginPath(), target)
+		if err != nil {
+			return nil, err
+		}
+		link := filepath.Join(dir, rel)
+		err = os.MkdirAll(filepath.Dir(link), 0o750)
+		if err != nil {
+			return nil, err
+		}
+		err = os.Symlink(target, link)
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		err = os.Chtimes(filepath.Dir(target), now, now)
+		if err != nil {
+			return nil, err
+		}
+		linked = append(linked, filepath.Dir(rel))
+	}
+	return linked, nil
+}
+
+// acquireLease writes a lease file with the provider versions of a job, so
+// evict skips them in every process that shares the cache. The lease is
+// renewed until it's removed by the returned function, since jobs can run
+// for longer than the eviction grace period. Leases of jobs that didn't
+// release them, e.g. because the process was killed, expire after the grace
+// period.
+func (c *Cache) acquireLease(providers []string) (func(), error) {
+	if len(providers) == 0 {
+		return func() {}, nil
+	}
+	file, err := os.CreateTemp(c.leasePath(), "lease-*")
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.WriteString(strings.Join(providers, "\n"))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(cacheEvictionGracePeriod / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				now := time.Now()
+				_ = os.Chtimes(file.Name(), now, now)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			_ = os.Remove(file.Name())
+		})
+	}, nil
+}
+
+// leasedPaths returns the cache entries in unexpired leases, and removes the
+// expired leases.
+func (c *Cache) leasedPaths() (map[string]struct{}, error) {
+	leases, err := os.ReadDir(c.leasePath())
+	if err != nil {
+		return nil, err
+	}
+	paths := map[string]struct{}{}
+	for _, lease := range leases {
+		path := filepath.Join(c.leasePath(), lease.Name())
+		info, err := lease.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// Released since the directory was read.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if time.Since(info.ModTime()) > cacheEvictionGracePeriod {
+			_ = os.Remove(path)
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, provider := range strings.Split(string(data), "\n") {
+			if provider == "" {
+				continue
+			}
+			paths[filepath.Join(c.pluginPath(), provider)] = struct{}{}
+		}
+	}
+	return paths, nil
+}
+
+// saveProviders copies the providers that init downloaded into the plugin
+// cache directory of a job to the shared cache.
+func (c *Cache) saveProviders(dir string) error {
+	if !pluginCacheSupported() {
+		return nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*", "*", "*", "*"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			// Linked from the shared cache.
+			continue
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		cached := filepath.Join(c.pluginPath(), rel)
+		if _, err := os.Stat(cached); err == nil {
+			continue
+		}
+		tmp := cached + ".tmp"
+		_ = os.RemoveAll(tmp)
+		err = copyDir(path, tmp)
+		if err != nil {
+			_ = os.RemoveAll(tmp)
+			return err
+		}
+		err = os.Rename(tmp, cached)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordProviders counts the providers used by the working directory as
+// hits if they were cached before init, and marks them as recently used.
+func (c *Cache) recordProviders(cachedProviders map[string]struct{}, workdir string) error {
+	usedProviders, err := c.providerVersions(filepath.Join(workdir, ".terraform", "providers"))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for provider := range usedProviders {
+		path := filepath.Join(c.pluginPath(), provider)
+		err = os.Chtimes(path, now, now)
+		if errors.Is(err, fs.ErrNotExist) {
+			// The plugin cache isn't used on all platforms.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, hit := cachedProviders[provider]
+		c.record(cacheTypeProvider, hit)
+	}
+	return nil
+}
+
+// moduleCacheKey hashes the Terraform files of the working directory,
+// including local modules, since they declare the modules that are installed.
+// Only modules that are pinned to immutable sources are cached, so the same
+// files always install the same modules.
+func moduleCacheKey(workdir string) (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(workdir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			// Skip .terraform and the plugin cache of the job.
+			if path != workdir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || !(strings.HasSuffix(entry.Name(), ".tf") || strings.HasSuffix(entry.Name(), ".tf.json")) {
+			return nil
+		}
+		rel, err := filepath.Rel(workdir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, _ = hash.Write([]byte(filepath.ToSlash(rel)))
+		_, _ = hash.Write([]byte{0})
+		_, _ = hash.Write(data)
+		_, _ = hash.Write([]byte{0})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// modulesPinned reports whether every module call of the working directory
+// and the modules installed by init uses an immutable source. Otherwise,
+// init could install different modules for the same files later on, e.g.
+// when a branch of a Git source moves.
+func modulesPinned(workdir string) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(workdir, ".terraform", "modules", "modules.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		// The template doesn't use any modules.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var manifest struct {
+		Modules []struct {
+			Dir string `json:"Dir"`
+		} `json:"Modules"`
+	}
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return false, xerrors.Errorf("parse module manifest: %w", err)
+	}
+	dirs := []string{"."}
+	for _, module := range manifest.Modules {
+		dirs = append(dirs, module.Dir)
+	}
+	for _, dir := range dirs {
+		module, diags := tfconfig.LoadModule(filepath.Join(workdir, dir))
+		if diags.HasErrors() {
+			return false, xerrors.Errorf("load module %q: %w", dir, diags.Err())
+		}
+		for _, call := range module.ModuleCalls {
+			if !moduleSourcePinned(call.Source, call.Version) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+var gitCommitRegex = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// moduleSourcePinned reports whether a module call always installs the same
+// module. Local paths, registry modules with an exact version and sources
+// with a `ref` of a full Git commit hash are pinned.
+func moduleSourcePinned(source, constraint string) bool {
+	if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+		return true
+	}
+	if constraint != "" {
+		// Only registry modules support a version constraint.
+		_, err := version.NewVersion(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(constraint), "=")))
+		return err == nil
+	}
+	_, query, ok := strings.Cut(source, "?")
+	if !ok {
+		return false
+	}
+	for _, param := range strings.Split(query, "&") {
+		ref, ok := strings.CutPrefix(param, "ref=")
+		if ok && gitCommitRegex.MatchString(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreModules copies the cached modules into the working directory, so
+// init doesn't download them again.
+func (c *Cache) restoreModules(key, workdir string) (bool, error) {
+	cached := c.modulePath(key)
+	if _, err := os.Stat(cached); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	err := copyDir(cached, filepath.Join(workdir, ".terraform", "modules"))
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	return true, os.Chtimes(cached, now, now)
+}
+
+// saveModules caches the modules installed in the working directory.
+func (c *Cache) saveModules(key, workdir string) (bool, error) {
+	installed := filepath.Join(workdir, ".terraform", "modules")
+	if _, err := os.Stat(installed); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// The template doesn't use any modules.
+			return false, nil
+		}
+		return false, err
+	}
+	cached := c.modulePath(key)
+	tmp := cached + ".tmp"
+	_ = os.RemoveAll(tmp)
+	err := copyDir(installed, tmp)
+	if err != nil {
+		_ = os.RemoveAll(tmp)
+		return false, err
+	}
+	return true, os.Rename(tmp, cached)
+}
+
+// pluginCacheSupported reports whether init uses the plugin cache. Only Linux
+// reliably works with the Terraform plugin cache directory. It's unknown why
+// this is.
+func pluginCacheSupported() bool {
+	return runtime.GOOS == "linux"
+}
+
+type cacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// evict removes the least recently used provider versions and modules until
+// the caches fit in the maximum size. Provider versions leased by running
+// jobs are kept.
+func (c *Cache) evict(ctx context.Context) error {
+	leased, err := c.leasedPaths()
+	if err != nil {
+		return xerrors.Errorf("read leases: %w", err)
+	}
+	providers, err := filepath.Glob(filepath.Join(c.pluginPath(), "*", "*", "*", "*"))
+	if err != nil {
+		return err
+	}
+	modules, err := filepath.Glob(c.modulePath("*"))
+	if err != nil {
+		return err
+	}
+	entries := make([]cacheEntry, 0, len(providers)+len(modules))
+	var total int64
+	for _, path := range append(providers, modules...) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		size, err := dirSize(path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, cacheEntry{
+			path:    path,
+			size:    size,
+			modTime: info.ModTime(),
+		})
+		total += size
+	}
+
+	if c.options.MaxSize > 0 && total > c.options.MaxSize {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].modTime.Before(entries[j].modTime)
+		})
+		for _, entry := range entries {
+			if total <= c.options.MaxSize {
+				break
+			}
+			if time.Since(entry.modTime) < cacheEvictionGracePeriod {
+				continue
+			}
+			if _, ok := leased[entry.path]; ok {
+				continue
+			}
+			err = os.RemoveAll(entry.path)
+			if err != nil {
+				return err
+			}
+			total -= entry.size
+			c.options.Logger.Debug(ctx, "evicted terraform cache entry", slog.F("path", entry.path), slog.F("size", entry.size))
+		}
+	}
+	if c.options.Metrics != nil {
+		c.options.Metrics.SizeBytes.Set(float64(total))
+	}
+	return nil
+}
+
+// seed copies the providers for this platform from the mirror into the
+// plugin cache. Both the unpacked layout, and the packed layout that's
+// created by `terraform providers mirror` are supported.
+func (c *Cache) seed(ctx context.Context) error {
+	mirror := c.options.MirrorPath
+	platform := runtime.GOOS + "_" + runtime.GOARCH
+
+	// HOSTNAME/NAMESPACE/TYPE/VERSION/TARGET
+	unpacked, err := filepath.Glob(filepath.Join(mirror, "*", "*", "*", "*", platform))
+	if err != nil {
+		return err
+	}
+	for _, src := range unpacked {
+		rel, err := filepath.Rel(mirror, src)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(c.pluginPath(), rel)
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		err = copyDir(src, dst)
+		if err != nil {
+			return xerrors.Errorf("copy %q: %w", rel, err)
+		}
+		c.options.Logger.Debug(ctx, "seeded terraform provider", slog.F("provider", rel))
+	}
+
+	// HOSTNAME/NAMESPACE/TYPE/terraform-provider-TYPE_VERSION_TARGET.zip
+	packed, err := filepath.Glob(filepath.Join(mirror, "*", "*", "*", "terraform-provider-*_"+platform+".zip"))
+	if err != nil {
+		return err
+	}
+	for _, src := range packed {
+		providerDir := filepath.Dir(src)
+		prefix := "terraform-provider-" + filepath.Base(providerDir) + "_"
+		suffix := "_" + platform + ".zip"
+		name := filepath.Base(src)
+		if !strings.HasPrefix(name, prefix) || len(name) <= len(prefix)+len(suffix) {
+			continue
+		}
+		version := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+		rel, err := filepath.Rel(mirror, providerDir)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(c.pluginPath(), rel, version, platform)
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		err = unzip(src, dst)
+		if err != nil {
+			return xerrors.Errorf("extract %q: %w", name, err)
+		}
+		c.options.Logger.Debug(ctx, "seeded terraform provider", slog.F("provider", filepath.Join(rel, version, platform)))
+	}
+	return nil
+}
+
+// copyDir recursively copies a directory, preserving symlinks.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			source, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer source.Close()
+			return writeFile(target, source, info.Mode().Perm())
+		}
+	})
+}
+
+// unzip extracts the archive to a temporary directory that's renamed to dst
+// once complete, so partially extracted providers are never used.
+func unzip(src, dst string) (err error) {
+	reader, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tmp := dst + ".tmp"
+	_ = os.RemoveAll(tmp)
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(tmp)
+		}
+	}()
+	for _, file := range reader.File {
+		//nolint:gosec // Paths outside of the directory are rejected below.
+		target := filepath.Join(tmp, file.Name)
+		if !strings.HasPrefix(target, filepath.Clean(tmp)+string(os.PathSeparator)) {
+			return xerrors.Errorf("invalid file path %q", file.Name)
+		}
+		if file.FileInfo().IsDir() {
+			err = os.MkdirAll(target, 0o750)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		source, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(target, source, file.Mode().Perm())
+		_ = source.Close()
+		if err != nil {
+			return err
+		}
+	}
+	err = os.MkdirAll(filepath.Dir(dst), 0o750)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+func writeFile(path string, source io.Reader, mode fs.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, source)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// dirSize returns the total size of the files in a directory, without
+// following symlinks.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
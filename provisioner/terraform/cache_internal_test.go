@@ -0,0 +1,334 @@
+package terraform
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	platform := runtime.GOOS + "_" + runtime.GOARCH
+	provider := filepath.Join("registry.terraform.io", "coder", "coder", "0.6.10")
+
+	// fakeInit installs a provider and a module like `terraform init` does
+	// with the plugin cache of the job.
+	fakeInit := func(cache *Cache, workdir string) func() error {
+		return func() error {
+			cached := filepath.Join(cache.jobPluginPath(workdir), provider, platform)
+			if _, err := os.Stat(cached); err != nil {
+				err = os.MkdirAll(cached, 0o750)
+				if err != nil {
+					return err
+				}
+				err = os.WriteFile(filepath.Join(cached, "terraform-provider-coder"), []byte("provider"), 0o600)
+				if err != nil {
+					return err
+				}
+			}
+			installed := filepath.Join(workdir, ".terraform", "providers", provider)
+			err := os.MkdirAll(installed, 0o750)
+			if err != nil {
+				return err
+			}
+			err = os.Symlink(cached, filepath.Join(installed, platform))
+			if err != nil {
+				return err
+			}
+			modules := filepath.Join(workdir, ".terraform", "modules")
+			err = os.MkdirAll(modules, 0o750)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(modules, "modules.json"), []byte(`{"Modules":[]}`), 0o600)
+		}
+	}
+	newWorkdir := func(t *testing.T, version string) string {
+		t.Helper()
+		workdir := t.TempDir()
+		err := os.WriteFile(filepath.Join(workdir, "main.tf"), []byte(`module "example" {
+  source  = "example/name/provider"
+  version = "`+version+`"
+}`), 0o600)
+		require.NoError(t, err)
+		return workdir
+	}
+
+	t.Run("HitsAndMisses", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("symlinks require elevated privileges on Windows")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		metrics := NewCacheMetrics(prometheus.NewRegistry())
+		cache, err := NewCache(ctx, CacheOptions{
+			Path:    t.TempDir(),
+			Logger:  slogtest.Make(t, nil),
+			Metrics: metrics,
+		})
+		require.NoError(t, err)
+
+		workdir := newWorkdir(t, "1.0.0")
+		release, err := cache.init(ctx, workdir, func() error {
+			// The lock isn't held during init.
+			unlock, err := cache.lock(ctx)
+			if err != nil {
+				return err
+			}
+			unlock()
+			return fakeInit(cache, workdir)()
+		})
+		require.NoError(t, err)
+		release()
+		require.FileExists(t, filepath.Join(cache.pluginPath(), provider, platform, "terraform-provider-coder"))
+		require.Equal(t, 0.0, promtestutil.ToFloat64(metrics.Hits.WithLabelValues(cacheTypeProvider)))
+		require.Equal(t, 1.0, promtestutil.ToFloat64(metrics.Misses.WithLabelValues(cacheTypeProvider)))
+		require.Equal(t, 1.0, promtestutil.ToFloat64(metrics.Misses.WithLabelValues(cacheTypeModule)))
+		require.Greater(t, promtestutil.ToFloat64(metrics.SizeBytes), 0.0)
+
+		// The providers and modules are restored before init in a new
+		// working directory with the same Terraform files.
+		workdir = newWorkdir(t, "1.0.0")
+		release, err = cache.init(ctx, workdir, func() error {
+			_, err := os.Stat(filepath.Join(workdir, ".terraform", "modules", "modules.json"))
+			if err != nil {
+				return err
+			}
+			link, err := os.Readlink(filepath.Join(cache.jobPluginPath(workdir), provider, platform))
+			if err != nil {
+				return err
+			}
+			if link != filepath.Join(cache.pluginPath(), provider, platform) {
+				return xerrors.Errorf("unexpected provider link %q", link)
+			}
+			return fakeInit(cache, workdir)()
+		})
+		require.NoError(t, err)
+		release()
+		require.Equal(t, 1.0, promtestutil.ToFloat64(metrics.Hits.WithLabelValues(cacheTypeProvider)))
+		require.Equal(t, 1.0, promtestutil.ToFloat64(metrics.Hits.WithLabelValues(cacheTypeModule)))
+	})
+
+	t.Run("FloatingModules", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("symlinks require elevated privileges on Windows")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		metrics := NewCacheMetrics(prometheus.NewRegistry())
+		cache, err := NewCache(ctx, CacheOptions{
+			Path:    t.TempDir(),
+			Logger:  slogtest.Make(t, nil),
+			Metrics: metrics,
+		})
+		require.NoError(t, err)
+
+		// Modules with a version constraint must be resolved by every init,
+		// since a newer version may have been published.
+		for i := 0; i < 2; i++ {
+			workdir := newWorkdir(t, "~> 1.0")
+			release, err := cache.init(ctx, workdir, func() error {
+				_, err := os.Stat(filepath.Join(workdir, ".terraform", "modules"))
+				if !errors.Is(err, fs.ErrNotExist) {
+					return xerrors.Errorf("modules were restored: %w", err)
+				}
+				return fakeInit(cache, workdir)()
+			})
+			require.NoError(t, err)
+			release()
+		}
+		require.Equal(t, 0.0, promtestutil.ToFloat64(metrics.Hits.WithLabelValues(cacheTypeModule)))
+		matches, err := filepath.Glob(cache.modulePath("*"))
+		require.NoError(t, err)
+		require.Empty(t, matches)
+	})
+
+	t.Run("Evict", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cache, err := NewCache(ctx, CacheOptions{
+			Path:    t.TempDir(),
+			MaxSize: 10,
+			Logger:  slogtest.Make(t, nil),
+		})
+		require.NoError(t, err)
+
+		old := cache.modulePath("old")
+		recent := cache.modulePath("recent")
+		for _, dir := range []string{old, recent} {
+			require.NoError(t, os.MkdirAll(dir, 0o750))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "modules.json"), make([]byte, 8), 0o600))
+		}
+		oldTime := time.Now().Add(-2 * cacheEvictionGracePeriod)
+		require.NoError(t, os.Chtimes(old, oldTime, oldTime))
+
+		err = cache.evict(ctx)
+		require.NoError(t, err)
+		require.NoDirExists(t, old)
+		require.DirExists(t, recent)
+	})
+
+	t.Run("EvictDuringInit", func(t *testing.T) {
+		t.Parallel()
+		if !pluginCacheSupported() {
+			t.Skip("the plugin cache is only used on Linux")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cache, err := NewCache(ctx, CacheOptions{
+			Path:    t.TempDir(),
+			MaxSize: 1,
+			Logger:  slogtest.Make(t, nil),
+		})
+		require.NoError(t, err)
+
+		cached := filepath.Join(cache.pluginPath(), provider)
+		require.NoError(t, os.MkdirAll(filepath.Join(cached, platform), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(cached, platform, "terraform-provider-coder"), []byte("provider"), 0o600))
+		oldTime := time.Now().Add(-2 * cacheEvictionGracePeriod)
+		require.NoError(t, os.Chtimes(cached, oldTime, oldTime))
+
+		workdir := newWorkdir(t, "1.0.0")
+		release, err := cache.init(ctx, workdir, func() error {
+			// Another job evicts the cache after the provider was linked.
+			unlock, err := cache.lock(ctx)
+			if err != nil {
+				return err
+			}
+			// Expire the recent use, so only the lease protects it.
+			err = os.Chtimes(cached, oldTime, oldTime)
+			if err == nil {
+				err = cache.evict(ctx)
+			}
+			unlock()
+			if err != nil {
+				return err
+			}
+			_, err = os.Stat(filepath.Join(cache.jobPluginPath(workdir), provider, platform, "terraform-provider-coder"))
+			if err != nil {
+				return xerrors.Errorf("linked provider was evicted: %w", err)
+			}
+			return fakeInit(cache, workdir)()
+		})
+		require.NoError(t, err)
+		require.DirExists(t, cached)
+
+		// Plan and apply run the linked provider, so it's leased until
+		// the job releases it.
+		require.NoError(t, os.Chtimes(cached, oldTime, oldTime))
+		require.NoError(t, cache.evict(ctx))
+		require.DirExists(t, cached)
+		release()
+		leases, err := os.ReadDir(cache.leasePath())
+		require.NoError(t, err)
+		require.Empty(t, leases)
+		require.NoError(t, cache.evict(ctx))
+		require.NoDirExists(t, cached)
+	})
+
+	t.Run("UpdateFailure", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cache, err := NewCache(ctx, CacheOptions{
+			Path:   t.TempDir(),
+			Logger: slogtest.Make(t, nil),
+		})
+		require.NoError(t, err)
+
+		// The job can run even if the cache can't be updated after init.
+		workdir := newWorkdir(t, "1.0.0")
+		release, err := cache.init(ctx, workdir, func() error {
+			modules := filepath.Join(workdir, ".terraform", "modules")
+			err := os.MkdirAll(modules, 0o750)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(modules, "modules.json"), []byte("invalid"), 0o600)
+		})
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("SeedFromMirror", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		mirror := t.TempDir()
+		// Unpacked layout.
+		unpacked := filepath.Join(mirror, "registry.terraform.io", "hashicorp", "null", "3.2.1", platform)
+		require.NoError(t, os.MkdirAll(unpacked, 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(unpacked, "terraform-provider-null"), []byte("null"), 0o600))
+		// Packed layout.
+		packed := filepath.Join(mirror, "registry.terraform.io", "coder", "coder")
+		require.NoError(t, os.MkdirAll(packed, 0o750))
+		file, err := os.Create(filepath.Join(packed, "terraform-provider-coder_0.6.10_"+platform+".zip"))
+		require.NoError(t, err)
+		writer := zip.NewWriter(file)
+		entry, err := writer.Create("terraform-provider-coder_v0.6.10")
+		require.NoError(t, err)
+		_, err = entry.Write([]byte("coder"))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		require.NoError(t, file.Close())
+
+		cache, err := NewCache(ctx, CacheOptions{
+			Path:       t.TempDir(),
+			MirrorPath: mirror,
+			Logger:     slogtest.Make(t, nil),
+		})
+		require.NoError(t, err)
+		require.FileExists(t, filepath.Join(cache.pluginPath(), "registry.terraform.io", "hashicorp", "null", "3.2.1", platform, "terraform-provider-null"))
+		require.FileExists(t, filepath.Join(cache.pluginPath(), "registry.terraform.io", "coder", "coder", "0.6.10", platform, "terraform-provider-coder_v0.6.10"))
+	})
+}
+
+func TestModuleSourcePinned(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Source     string
+		Constraint string
+		Pinned     bool
+	}{
+		{Source: "./modules/example", Pinned: true},
+		{Source: "../example", Pinned: true},
+		{Source: "example/name/provider", Constraint: "1.2.3", Pinned: true},
+		{Source: "example/name/provider", Constraint: "= 1.2.3", Pinned: true},
+		{Source: "example/name/provider", Constraint: "~> 1.2", Pinned: false},
+		{Source: "example/name/provider", Constraint: ">= 1.0, < 2.0", Pinned: false},
+		{Source: "example/name/provider", Pinned: false},
+		{Source: "git::https://example.com/repo.git?ref=0123456789abcdef0123456789abcdef01234567", Pinned: true},
+		{Source: "github.com/example/repo//modules/example?depth=1&ref=0123456789abcdef0123456789abcdef01234567", Pinned: true},
+		{Source: "git::https://example.com/repo.git?ref=main", Pinned: false},
+		{Source: "git::https://example.com/repo.git?ref=v1.2.3", Pinned: false},
+		{Source: "git::https://example.com/repo.git", Pinned: false},
+		{Source: "https://example.com/module.zip", Pinned: false},
+	} {
+		require.Equal(t, tc.Pinned, moduleSourcePinned(tc.Source, tc.Constraint), "source %q, constraint %q", tc.Source, tc.Constraint)
+	}
+}
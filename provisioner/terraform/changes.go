@@ -0,0 +1,133 @@
+package terraform
+
+import (
+	"sort"
+
+	"github.com/awalterschulze/gographviz"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/mitchellh/mapstructure"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/provisionersdk/proto"
+)
+
+// ConvertPlanChanges consumes a Terraform plan and a GraphViz representation
+// produced by `terraform graph` to summarize the changes to each resource.
+// The daily cost of a resource before and after the change is read from the
+// coder_metadata attached to it.
+func ConvertPlanChanges(plan *tfjson.Plan, rawGraph string) ([]*proto.ResourceChange, error) {
+	parsedGraph, err := gographviz.ParseString(rawGraph)
+	if err != nil {
+		return nil, xerrors.Errorf("parse graph: %w", err)
+	}
+	graph, err := gographviz.NewAnalysedGraph(parsedGraph)
+	if err != nil {
+		return nil, xerrors.Errorf("analyze graph: %w", err)
+	}
+
+	priorCosts := map[string]int32{}
+	if plan.PriorState != nil && plan.PriorState.Values != nil && plan.PriorState.Values.RootModule != nil {
+		priorCosts, err = convertDailyCosts(plan.PriorState.Values.RootModule, graph)
+		if err != nil {
+			return nil, xerrors.Errorf("convert prior daily costs: %w", err)
+		}
+	}
+	plannedCosts := map[string]int32{}
+	if plan.PlannedValues != nil && plan.PlannedValues.RootModule != nil {
+		plannedCosts, err = convertDailyCosts(plan.PlannedValues.RootModule, graph)
+		if err != nil {
+			return nil, xerrors.Errorf("convert planned daily costs: %w", err)
+		}
+	}
+
+	changes := make([]*proto.ResourceChange, 0)
+	for _, resourceChange := range plan.ResourceChanges {
+		if resourceChange.Mode == tfjson.DataResourceMode || resourceChange.Change == nil {
+			continue
+		}
+		// Deposed objects are left over from a previous replacement, and are
+		// always destroyed alongside the change to the current object.
+		if resourceChange.DeposedKey != "" {
+			continue
+		}
+		if resourceChange.Type == "coder_agent" || resourceChange.Type == "coder_agent_instance" || resourceChange.Type == "coder_app" || resourceChange.Type == "coder_metadata" {
+			continue
+		}
+
+		actions := resourceChange.Change.Actions
+		var action proto.ResourceChangeAction
+		switch {
+		case actions.Replace():
+			action = proto.ResourceChangeAction_REPLACE
+		case actions.Create():
+			action = proto.ResourceChangeAction_CREATE
+		case actions.Update():
+			action = proto.ResourceChangeAction_UPDATE
+		case actions.Delete():
+			action = proto.ResourceChangeAction_DELETE
+		default:
+			// No-op and read actions don't change the resource.
+			continue
+		}
+
+		change := &proto.ResourceChange{
+			Address: resourceChange.Address,
+			Type:    resourceChange.Type,
+			Name:    resourceChange.Name,
+			Action:  action,
+		}
+		label := convertAddressToLabel(resourceChange.Address)
+		if action != proto.ResourceChangeAction_CREATE {
+			change.PriorDailyCost = priorCosts[label]
+		}
+		if action != proto.ResourceChangeAction_DELETE {
+			change.DailyCost = plannedCosts[label]
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Address < changes[j].Address
+	})
+	return changes, nil
+}
+
+// convertDailyCosts returns the daily cost of each resource in the module by
+// label, as set by the coder_metadata attached to it.
+func convertDailyCosts(module *tfjson.StateModule, graph *gographviz.Graph) (map[string]int32, error) {
+	tfResourcesByLabel := map[string]map[string]*tfjson.StateResource{}
+	var findTerraformResources func(mod *tfjson.StateModule)
+	findTerraformResources = func(mod *tfjson.StateModule) {
+		for _, module := range mod.ChildModules {
+			findTerraformResources(module)
+		}
+		for _, resource := range mod.Resources {
+			label := convertAddressToLabel(resource.Address)
+			if tfResourcesByLabel[label] == nil {
+				tfResourcesByLabel[label] = map[string]*tfjson.StateResource{}
+			}
+			tfResourcesByLabel[label][resource.Address] = resource
+		}
+	}
+	findTerraformResources(module)
+
+	costs := map[string]int32{}
+	for _, resources := range tfResourcesByLabel {
+		for _, resource := range resources {
+			if resource.Type != "coder_metadata" {
+				continue
+			}
+
+			var attrs metadataAttributes
+			err := mapstructure.Decode(resource.AttributeValues, &attrs)
+			if err != nil {
+				return nil, xerrors.Errorf("decode metadata attributes: %w", err)
+			}
+			targetLabel, ok := findMetadataTarget(graph, tfResourcesByLabel, convertAddressToLabel(resource.Address))
+			if !ok {
+				continue
+			}
+			costs[targetLabel] = attrs.DailyCost
+		}
+	}
+	return costs, nil
+}
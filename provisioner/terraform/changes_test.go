@@ -0,0 +1,123 @@
+package terraform_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/provisioner/terraform"
+	"github.com/coder/coder/provisionersdk/proto"
+)
+
+func TestConvertPlanChanges(t *testing.T) {
+	t.Parallel()
+	// nolint:dogsled
+	_, filename, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(filename), "testdata", "resource-metadata")
+
+	// loadPlan returns a fresh copy of the plan, so each test can edit it.
+	loadPlan := func(t *testing.T) (*tfjson.Plan, string) {
+		t.Helper()
+		tfPlanRaw, err := os.ReadFile(filepath.Join(dir, "resource-metadata.tfplan.json"))
+		require.NoError(t, err)
+		var tfPlan tfjson.Plan
+		err = json.Unmarshal(tfPlanRaw, &tfPlan)
+		require.NoError(t, err)
+		tfPlanGraph, err := os.ReadFile(filepath.Join(dir, "resource-metadata.tfplan.dot"))
+		require.NoError(t, err)
+		return &tfPlan, string(tfPlanGraph)
+	}
+	// setPriorCost adds the planned values as the prior state with a
+	// different daily cost.
+	setPriorCost := func(t *testing.T, tfPlan *tfjson.Plan, cost int) {
+		t.Helper()
+		raw, err := json.Marshal(tfPlan.PlannedValues)
+		require.NoError(t, err)
+		var prior tfjson.StateValues
+		err = json.Unmarshal(raw, &prior)
+		require.NoError(t, err)
+		for _, resource := range prior.RootModule.Resources {
+			if resource.Type == "coder_metadata" {
+				resource.AttributeValues["daily_cost"] = cost
+			}
+		}
+		tfPlan.PriorState = &tfjson.State{Values: &prior}
+	}
+	setActions := func(tfPlan *tfjson.Plan, actions tfjson.Actions) {
+		for _, change := range tfPlan.ResourceChanges {
+			change.Change.Actions = actions
+		}
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		t.Parallel()
+		tfPlan, graph := loadPlan(t)
+
+		changes, err := terraform.ConvertPlanChanges(tfPlan, graph)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, "null_resource.about", changes[0].Address)
+		require.Equal(t, "null_resource", changes[0].Type)
+		require.Equal(t, "about", changes[0].Name)
+		require.Equal(t, proto.ResourceChangeAction_CREATE, changes[0].Action)
+		require.EqualValues(t, 29, changes[0].DailyCost)
+		require.EqualValues(t, 0, changes[0].PriorDailyCost)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+		tfPlan, graph := loadPlan(t)
+		setPriorCost(t, tfPlan, 10)
+		setActions(tfPlan, tfjson.Actions{tfjson.ActionUpdate})
+
+		changes, err := terraform.ConvertPlanChanges(tfPlan, graph)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, proto.ResourceChangeAction_UPDATE, changes[0].Action)
+		require.EqualValues(t, 29, changes[0].DailyCost)
+		require.EqualValues(t, 10, changes[0].PriorDailyCost)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		t.Parallel()
+		tfPlan, graph := loadPlan(t)
+		setPriorCost(t, tfPlan, 10)
+		setActions(tfPlan, tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate})
+
+		changes, err := terraform.ConvertPlanChanges(tfPlan, graph)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, proto.ResourceChangeAction_REPLACE, changes[0].Action)
+		require.EqualValues(t, 29, changes[0].DailyCost)
+		require.EqualValues(t, 10, changes[0].PriorDailyCost)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		t.Parallel()
+		tfPlan, graph := loadPlan(t)
+		setPriorCost(t, tfPlan, 10)
+		setActions(tfPlan, tfjson.Actions{tfjson.ActionDelete})
+
+		changes, err := terraform.ConvertPlanChanges(tfPlan, graph)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, proto.ResourceChangeAction_DELETE, changes[0].Action)
+		require.EqualValues(t, 0, changes[0].DailyCost)
+		require.EqualValues(t, 10, changes[0].PriorDailyCost)
+	})
+
+	t.Run("NoOp", func(t *testing.T) {
+		t.Parallel()
+		tfPlan, graph := loadPlan(t)
+		setActions(tfPlan, tfjson.Actions{tfjson.ActionNoop})
+
+		changes, err := terraform.ConvertPlanChanges(tfPlan, graph)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+}
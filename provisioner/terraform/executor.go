@@ -27,17 +27,22 @@ type executor struct {
 	binaryPath string
 	// cachePath and workdir must not be used by multiple processes at once.
 	cachePath string
+	cache     *Cache
 	workdir   string
+	// releaseCache releases the cache entries that init leased for the job.
+	releaseCache func()
 }
 
 func (e *executor) basicEnv() []string {
 	// Required for "terraform init" to find "git" to
 	// clone Terraform modules.
 	env := safeEnviron()
-	// Only Linux reliably works with the Terraform plugin
-	// cache directory. It's unknown why this is.
-	if e.cachePath != "" && runtime.GOOS == "linux" {
-		env = append(env, "TF_PLUGIN_CACHE_DIR="+e.cachePath)
+	if pluginCacheSupported() {
+		if e.cache != nil {
+			env = append(env, "TF_PLUGIN_CACHE_DIR="+e.cache.jobPluginPath(e.workdir))
+		} else if e.cachePath != "" {
+			env = append(env, "TF_PLUGIN_CACHE_DIR="+e.cachePath)
+		}
 	}
 	return env
 }
@@ -186,7 +191,29 @@ func (e *executor) init(ctx, killCtx context.Context, logr logSink) error {
 		"-input=false",
 	}
 
-	return e.execWriteOutput(ctx, killCtx, args, e.basicEnv(), outWriter, errWriter)
+	if e.cache == nil {
+		return e.execWriteOutput(ctx, killCtx, args, e.basicEnv(), outWriter, errWriter)
+	}
+	release, err := e.cache.init(ctx, e.workdir, func() error {
+		return e.execWriteOutput(ctx, killCtx, args, e.basicEnv(), outWriter, errWriter)
+	})
+	if err != nil {
+		return err
+	}
+	e.releaseCache = release
+	return nil
+}
+
+// release frees the cache entries used by the job. It must be called once
+// the job no longer runs Terraform.
+func (e *executor) release() {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	if e.releaseCache != nil {
+		e.releaseCache()
+		e.releaseCache = nil
+	}
 }
 
 // revive:disable-next-line:flag-parameter
@@ -238,6 +265,7 @@ func (e *executor) plan(ctx, killCtx context.Context, env, vars []string, logr l
 				Resources:        state.Resources,
 				GitAuthProviders: state.GitAuthProviders,
 				Plan:             planFileByt,
+				ResourceChanges:  state.ResourceChanges,
 			},
 		},
 	}, nil
@@ -269,6 +297,10 @@ func (e *executor) planResources(ctx, killCtx context.Context, planfilePath stri
 	if err != nil {
 		return nil, err
 	}
+	state.ResourceChanges, err = ConvertPlanChanges(plan, rawGraph)
+	if err != nil {
+		return nil, xerrors.Errorf("convert plan changes: %w", err)
+	}
 	return state, nil
 }
 
@@ -86,6 +86,7 @@ func (s *server) Provision(stream proto.DRPCProvisioner_ProvisionStream) error {
 	}
 
 	e := s.executor(config.Directory)
+	defer e.release()
 	if err = e.checkMinVersion(ctx); err != nil {
 		return err
 	}
@@ -78,6 +78,8 @@ type State struct {
 	Resources        []*proto.Resource
 	Parameters       []*proto.RichParameter
 	GitAuthProviders []string
+	// ResourceChanges is only set when converting a plan.
+	ResourceChanges []*proto.ResourceChange
 }
 
 // ConvertState consumes Terraform state and a GraphViz representation
@@ -362,41 +364,10 @@ func ConvertState(modules []*tfjson.StateModule, rawGraph string, rawParameterNa
 				return nil, xerrors.Errorf("decode metadata attributes: %w", err)
 			}
 
-			resourceLabel := convertAddressToLabel(resource.Address)
-
-			var attachedNode *gographviz.Node
-			for _, node := range graph.Nodes.Lookup {
-				// The node attributes surround the label with quotes.
-				if strings.Trim(node.Attrs["label"], `"`) != resourceLabel {
-					continue
-				}
-				attachedNode = node
-				break
-			}
-			if attachedNode == nil {
-				continue
-			}
-			var attachedResource *graphResource
-			for _, resource := range findResourcesInGraph(graph, tfResourcesByLabel, attachedNode.Name, 0, false) {
-				if attachedResource == nil {
-					// Default to the first resource because we have nothing to compare!
-					attachedResource = resource
-					continue
-				}
-				if resource.Depth < attachedResource.Depth {
-					// There's a closer resource!
-					attachedResource = resource
-					continue
-				}
-				if resource.Depth == attachedResource.Depth && resource.Label < attachedResource.Label {
-					attachedResource = resource
-					continue
-				}
-			}
-			if attachedResource == nil {
+			targetLabel, ok := findMetadataTarget(graph, tfResourcesByLabel, convertAddressToLabel(resource.Address))
+			if !ok {
 				continue
 			}
-			targetLabel := attachedResource.Label
 
 			resourceHidden[targetLabel] = attrs.Hide
 			resourceIcon[targetLabel] = attrs.Icon
@@ -513,6 +484,45 @@ func convertAddressToLabel(address string) string {
 	return cut
 }
 
+// findMetadataTarget returns the label of the resource a coder_metadata
+// resource is attached to. This is the closest resource it depends on in the
+// graph, using the label to break ties.
+func findMetadataTarget(graph *gographviz.Graph, tfResourcesByLabel map[string]map[string]*tfjson.StateResource, metadataLabel string) (string, bool) {
+	var attachedNode *gographviz.Node
+	for _, node := range graph.Nodes.Lookup {
+		// The node attributes surround the label with quotes.
+		if strings.Trim(node.Attrs["label"], `"`) != metadataLabel {
+			continue
+		}
+		attachedNode = node
+		break
+	}
+	if attachedNode == nil {
+		return "", false
+	}
+	var attachedResource *graphResource
+	for _, resource := range findResourcesInGraph(graph, tfResourcesByLabel, attachedNode.Name, 0, false) {
+		if attachedResource == nil {
+			// Default to the first resource because we have nothing to compare!
+			attachedResource = resource
+			continue
+		}
+		if resource.Depth < attachedResource.Depth {
+			// There's a closer resource!
+			attachedResource = resource
+			continue
+		}
+		if resource.Depth == attachedResource.Depth && resource.Label < attachedResource.Label {
+			attachedResource = resource
+			continue
+		}
+	}
+	if attachedResource == nil {
+		return "", false
+	}
+	return attachedResource.Label, true
+}
+
 type graphResource struct {
 	Label string
 	Depth uint
@@ -25,7 +25,10 @@ type ServeOptions struct {
 	BinaryPath string
 	// CachePath must not be used by multiple processes at once.
 	CachePath string
-	Logger    slog.Logger
+	// Cache is the provider plugin and module cache shared by provisioner
+	// jobs. If nil, providers are cached in CachePath.
+	Cache  *Cache
+	Logger slog.Logger
 
 	// ExitTimeout defines how long we will wait for a running Terraform
 	// command to exit (cleanly) if the provision was stopped. This only
@@ -96,6 +99,7 @@ func Serve(ctx context.Context, options *ServeOptions) error {
 		execMut:     &sync.Mutex{},
 		binaryPath:  options.BinaryPath,
 		cachePath:   options.CachePath,
+		cache:       options.Cache,
 		logger:      options.Logger,
 		exitTimeout: options.ExitTimeout,
 	}, options.ServeOptions)
@@ -105,6 +109,7 @@ type server struct {
 	execMut     *sync.Mutex
 	binaryPath  string
 	cachePath   string
+	cache       *Cache
 	logger      slog.Logger
 	exitTimeout time.Duration
 }
@@ -114,6 +119,7 @@ func (s *server) executor(workdir string) *executor {
 		mut:        s.execMut,
 		binaryPath: s.binaryPath,
 		cachePath:  s.cachePath,
+		cache:      s.cache,
 		workdir:    workdir,
 	}
 }
@@ -21,6 +21,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
+	"github.com/coder/coder/buildinfo"
 	"github.com/coder/coder/coderd/tracing"
 	"github.com/coder/coder/cryptorand"
 	"github.com/coder/coder/provisionerd/proto"
@@ -54,7 +55,10 @@ type Options struct {
 	JobPollInterval     time.Duration
 	JobPollJitter       time.Duration
 	JobPollDebounce     time.Duration
-	Provisioners        Provisioners
+	// HeartbeatInterval is how often the daemon reports to coderd that it's
+	// alive.
+	HeartbeatInterval time.Duration
+	Provisioners      Provisioners
 	// WorkDirectory must not be used by multiple processes at once.
 	WorkDirectory string
 }
@@ -79,6 +83,9 @@ func New(clientDialer Dialer, opts *Options) *Server {
 	if opts.LogBufferInterval == 0 {
 		opts.LogBufferInterval = 50 * time.Millisecond
 	}
+	if opts.HeartbeatInterval == 0 {
+		opts.HeartbeatInterval = 15 * time.Second
+	}
 	if opts.Filesystem == nil {
 		opts.Filesystem = afero.NewOsFs()
 	}
@@ -248,6 +255,50 @@ func (p *Server) connect(ctx context.Context) {
 			}
 		}
 	}()
+
+	go func() {
+		if p.isClosed() {
+			return
+		}
+		ticker := time.NewTicker(p.opts.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			client, ok := p.client()
+			if !ok {
+				return
+			}
+			p.heartbeat(ctx, client)
+			select {
+			case <-p.closeContext.Done():
+				return
+			case <-client.DRPCConn().Closed():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// heartbeat reports to coderd that the daemon is alive, along with its version
+// and the job it's running.
+func (p *Server) heartbeat(ctx context.Context, client proto.DRPCProvisionerDaemonClient) {
+	var jobID string
+	p.mutex.Lock()
+	if p.isRunningJob() {
+		jobID = p.activeJob.JobID()
+	}
+	p.mutex.Unlock()
+
+	_, err := client.Heartbeat(ctx, &proto.HeartbeatRequest{
+		Version: buildinfo.Version(),
+		JobId:   jobID,
+	})
+	if err != nil {
+		if retryable(err) {
+			return
+		}
+		p.opts.Logger.Warn(ctx, "send heartbeat", slog.Error(err))
+	}
 }
 
 func (p *Server) nextInterval() time.Duration {
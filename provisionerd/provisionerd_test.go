@@ -23,6 +23,7 @@ import (
 
 	"cdr.dev/slog"
 	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/buildinfo"
 	"github.com/coder/coder/provisionerd"
 	"github.com/coder/coder/provisionerd/proto"
 	"github.com/coder/coder/provisionerd/runner"
@@ -245,6 +246,57 @@ func TestProvisionerd(t *testing.T) {
 		require.NoError(t, closer.Close())
 	})
 
+	t.Run("HeartbeatRunningJob", func(t *testing.T) {
+		t.Parallel()
+		done := make(chan struct{})
+		t.Cleanup(func() {
+			close(done)
+		})
+		var (
+			completeChan = make(chan struct{})
+			completeOnce sync.Once
+		)
+
+		closer := createProvisionerd(t, func(ctx context.Context) (proto.DRPCProvisionerDaemonClient, error) {
+			return createProvisionerDaemonClient(t, done, provisionerDaemonTestServer{
+				acquireJob: func(ctx context.Context, _ *proto.Empty) (*proto.AcquiredJob, error) {
+					return &proto.AcquiredJob{
+						JobId:       "test",
+						Provisioner: "someprovisioner",
+						TemplateSourceArchive: createTar(t, map[string]string{
+							"test.txt": "content",
+						}),
+						Type: &proto.AcquiredJob_TemplateImport_{
+							TemplateImport: &proto.AcquiredJob_TemplateImport{
+								Metadata: &sdkproto.Provision_Metadata{},
+							},
+						},
+					}, nil
+				},
+				updateJob: noopUpdateJob,
+				failJob: func(ctx context.Context, job *proto.FailedJob) (*proto.Empty, error) {
+					return &proto.Empty{}, nil
+				},
+				heartbeat: func(ctx context.Context, heartbeat *proto.HeartbeatRequest) (*proto.Empty, error) {
+					assert.Equal(t, buildinfo.Version(), heartbeat.Version)
+					if heartbeat.JobId == "test" {
+						completeOnce.Do(func() { close(completeChan) })
+					}
+					return &proto.Empty{}, nil
+				},
+			}), nil
+		}, provisionerd.Provisioners{
+			"someprovisioner": createProvisionerClient(t, done, provisionerTestServer{
+				parse: func(request *sdkproto.Parse_Request, stream sdkproto.DRPCProvisioner_ParseStream) error {
+					<-stream.Context().Done()
+					return nil
+				},
+			}),
+		})
+		require.Condition(t, closedWithin(completeChan, testutil.WaitShort))
+		require.NoError(t, closer.Close())
+	})
+
 	t.Run("TemplateImport", func(t *testing.T) {
 		t.Parallel()
 		done := make(chan struct{})
@@ -1120,11 +1172,12 @@ func createTar(t *testing.T, files map[string]string) []byte {
 // Creates a provisionerd implementation with the provided dialer and provisioners.
 func createProvisionerd(t *testing.T, dialer provisionerd.Dialer, provisioners provisionerd.Provisioners) *provisionerd.Server {
 	server := provisionerd.New(dialer, &provisionerd.Options{
-		Logger:          slogtest.Make(t, nil).Named("provisionerd").Leveled(slog.LevelDebug),
-		JobPollInterval: 50 * time.Millisecond,
-		UpdateInterval:  50 * time.Millisecond,
-		Provisioners:    provisioners,
-		WorkDirectory:   t.TempDir(),
+		Logger:            slogtest.Make(t, nil).Named("provisionerd").Leveled(slog.LevelDebug),
+		JobPollInterval:   50 * time.Millisecond,
+		UpdateInterval:    50 * time.Millisecond,
+		HeartbeatInterval: 50 * time.Millisecond,
+		Provisioners:      provisioners,
+		WorkDirectory:     t.TempDir(),
 	})
 	t.Cleanup(func() {
 		_ = server.Close()
@@ -1233,6 +1286,7 @@ type provisionerDaemonTestServer struct {
 	updateJob   func(ctx context.Context, update *proto.UpdateJobRequest) (*proto.UpdateJobResponse, error)
 	failJob     func(ctx context.Context, job *proto.FailedJob) (*proto.Empty, error)
 	completeJob func(ctx context.Context, job *proto.CompletedJob) (*proto.Empty, error)
+	heartbeat   func(ctx context.Context, heartbeat *proto.HeartbeatRequest) (*proto.Empty, error)
 }
 
 func (p *provisionerDaemonTestServer) AcquireJob(ctx context.Context, empty *proto.Empty) (*proto.AcquiredJob, error) {
@@ -1259,3 +1313,10 @@ func (p *provisionerDaemonTestServer) FailJob(ctx context.Context, job *proto.Fa
 func (p *provisionerDaemonTestServer) CompleteJob(ctx context.Context, job *proto.CompletedJob) (*proto.Empty, error) {
 	return p.completeJob(ctx, job)
 }
+
+func (p *provisionerDaemonTestServer) Heartbeat(ctx context.Context, heartbeat *proto.HeartbeatRequest) (*proto.Empty, error) {
+	if p.heartbeat == nil {
+		return &proto.Empty{}, nil
+	}
+	return p.heartbeat(ctx, heartbeat)
+}
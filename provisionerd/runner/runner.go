@@ -241,6 +241,11 @@ func (r *Runner) Cancel() {
 	r.cancel()
 }
 
+// JobID returns the ID of the job being run.
+func (r *Runner) JobID() string {
+	return r.job.JobId
+}
+
 func (r *Runner) Done() <-chan struct{} {
 	return r.done
 }
@@ -694,18 +699,20 @@ type templateImportProvision struct {
 	Resources        []*sdkproto.Resource
 	Parameters       []*sdkproto.RichParameter
 	GitAuthProviders []string
+	ResourceChanges  []*sdkproto.ResourceChange
 }
 
 // Performs a dry-run provision when importing a template.
 // This is used to detect resources that would be provisioned for a workspace in various states.
 // It doesn't define values for rich parameters as they're unknown during template import.
 func (r *Runner) runTemplateImportProvision(ctx context.Context, values []*sdkproto.ParameterValue, variableValues []*sdkproto.VariableValue, metadata *sdkproto.Provision_Metadata) (*templateImportProvision, error) {
-	return r.runTemplateImportProvisionWithRichParameters(ctx, values, variableValues, nil, metadata)
+	return r.runTemplateImportProvisionWithRichParameters(ctx, values, variableValues, nil, metadata, nil)
 }
 
 // Performs a dry-run provision with provided rich parameters.
 // This is used to detect resources that would be provisioned for a workspace in various states.
-func (r *Runner) runTemplateImportProvisionWithRichParameters(ctx context.Context, values []*sdkproto.ParameterValue, variableValues []*sdkproto.VariableValue, richParameterValues []*sdkproto.RichParameterValue, metadata *sdkproto.Provision_Metadata) (*templateImportProvision, error) {
+// Changes are planned against the given state, or an empty workspace if it's nil.
+func (r *Runner) runTemplateImportProvisionWithRichParameters(ctx context.Context, values []*sdkproto.ParameterValue, variableValues []*sdkproto.VariableValue, richParameterValues []*sdkproto.RichParameterValue, metadata *sdkproto.Provision_Metadata, state []byte) (*templateImportProvision, error) {
 	ctx, span := r.startTrace(ctx, tracing.FuncName())
 	defer span.End()
 
@@ -741,6 +748,7 @@ func (r *Runner) runTemplateImportProvisionWithRichParameters(ctx context.Contex
 				Config: &sdkproto.Provision_Config{
 					Directory: r.workDirectory,
 					Metadata:  metadata,
+					State:     state,
 				},
 				ParameterValues:     values,
 				RichParameterValues: richParameterValues,
@@ -789,6 +797,7 @@ func (r *Runner) runTemplateImportProvisionWithRichParameters(ctx context.Contex
 				Resources:        msgType.Complete.Resources,
 				Parameters:       msgType.Complete.Parameters,
 				GitAuthProviders: msgType.Complete.GitAuthProviders,
+				ResourceChanges:  msgType.Complete.ResourceChanges,
 			}, nil
 		default:
 			return nil, xerrors.Errorf("invalid message type %q received from provisioner",
@@ -810,7 +819,9 @@ func (r *Runner) runTemplateDryRun(ctx context.Context) (*proto.CompletedJob, *p
 	if metadata.WorkspaceName == "" {
 		metadata.WorkspaceName = "dryrun"
 	}
-	metadata.WorkspaceOwner = r.job.UserName
+	if metadata.WorkspaceOwner == "" {
+		metadata.WorkspaceOwner = r.job.UserName
+	}
 	if metadata.WorkspaceOwner == "" {
 		metadata.WorkspaceOwner = "dryrunner"
 	}
@@ -835,6 +846,7 @@ func (r *Runner) runTemplateDryRun(ctx context.Context) (*proto.CompletedJob, *p
 		r.job.GetTemplateDryRun().GetVariableValues(),
 		r.job.GetTemplateDryRun().GetRichParameterValues(),
 		metadata,
+		r.job.GetTemplateDryRun().GetState(),
 	)
 	if err != nil {
 		return nil, r.failedJobf("run dry-run provision job: %s", err)
@@ -844,7 +856,8 @@ func (r *Runner) runTemplateDryRun(ctx context.Context) (*proto.CompletedJob, *p
 		JobId: r.job.JobId,
 		Type: &proto.CompletedJob_TemplateDryRun_{
 			TemplateDryRun: &proto.CompletedJob_TemplateDryRun{
-				Resources: provision.Resources,
+				Resources:       provision.Resources,
+				ResourceChanges: provision.ResourceChanges,
 			},
 		},
 	}, nil
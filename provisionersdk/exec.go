@@ -0,0 +1,80 @@
+package provisionersdk
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/hashicorp/yamux"
+	"golang.org/x/xerrors"
+	"storj.io/drpc"
+)
+
+// ExecOptions are configurations to execute an external provisioner.
+type ExecOptions struct {
+	// Args are passed to the provisioner binary.
+	Args []string
+	// Env is appended to the environment of the current process.
+	Env []string
+	// Stderr receives the standard error of the provisioner. It's
+	// discarded if nil.
+	Stderr io.Writer
+}
+
+// Exec starts the provisioner binary at path and returns a dRPC connection
+// to it. The binary must call Serve without a listener, so the provisioner is
+// served over its stdio. The process is killed when the context is canceled,
+// and exits on its own when the connection is closed.
+func Exec(ctx context.Context, path string, options *ExecOptions) (drpc.Conn, error) {
+	if options == nil {
+		options = &ExecOptions{}
+	}
+	//nolint:gosec // The path is configured by the deployment admin.
+	cmd := exec.CommandContext(ctx, path, options.Args...)
+	cmd.Env = append(os.Environ(), options.Env...)
+	cmd.Stderr = options.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, xerrors.Errorf("create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, xerrors.Errorf("create stdout pipe: %w", err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, xerrors.Errorf("start %q: %w", path, err)
+	}
+
+	config := yamux.DefaultConfig()
+	config.LogOutput = io.Discard
+	session, err := yamux.Client(&stdioConn{
+		ReadCloser:  stdout,
+		WriteCloser: stdin,
+	}, config)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, xerrors.Errorf("create yamux: %w", err)
+	}
+	go func() {
+		// The session is closed when the connection is closed, or when the
+		// process closes its stdout, so all reads from the pipe are done.
+		<-session.CloseChan()
+		_ = cmd.Wait()
+	}()
+	return MultiplexedConn(session), nil
+}
+
+// stdioConn closes both pipes, so the provisioner sees EOF on stdin when
+// the session is closed.
+type stdioConn struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (c *stdioConn) Close() error {
+	_ = c.WriteCloser.Close()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,39 @@
+package provisionersdk_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"storj.io/drpc/drpcerr"
+
+	"github.com/coder/coder/provisionersdk"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestExec(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// The test binary serves the provisioner when executed with execEnv.
+	conn, err := provisionersdk.Exec(ctx, os.Args[0], &provisionersdk.ExecOptions{
+		Env: []string{execEnv + "=true"},
+	})
+	require.NoError(t, err)
+
+	api := proto.NewDRPCProvisionerClient(conn)
+	stream, err := api.Parse(ctx, &proto.Parse_Request{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, drpcerr.Unimplemented, int(drpcerr.Code(err)))
+
+	require.NoError(t, conn.Close())
+	select {
+	case <-conn.Closed():
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the connection to close")
+	}
+}
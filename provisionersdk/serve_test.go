@@ -2,6 +2,8 @@ package provisionersdk_test
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,7 +15,19 @@ import (
 	"github.com/coder/coder/provisionersdk/proto"
 )
 
+// execEnv is set when the test binary is executed as an external
+// provisioner by TestExec.
+const execEnv = "PROVISIONERSDK_TEST_EXEC"
+
 func TestMain(m *testing.M) {
+	if os.Getenv(execEnv) != "" {
+		err := provisionersdk.Serve(context.Background(), &proto.DRPCProvisionerUnimplementedServer{}, nil)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 	goleak.VerifyTestMain(m)
 }
 
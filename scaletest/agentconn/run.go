@@ -63,6 +63,8 @@ func (r *Runner) Run(ctx context.Context, _ string, w io.Writer) error {
 		_, _ = fmt.Fprintln(logs, "\tUsing proxied DERP connection through coder server...")
 	}
 
+	metrics := harness.MetricsFromContext(ctx)
+	dialDone := metrics.StartPhase("agent_dial")
 	conn, err := r.client.DialWorkspaceAgent(ctx, r.cfg.AgentID, &codersdk.DialWorkspaceAgentOptions{
 		Logger: logger.Named("agentconn"),
 		// If the config requested DERP, then force DERP.
@@ -109,6 +111,7 @@ func (r *Runner) Run(ctx context.Context, _ string, w io.Writer) error {
 	}
 
 	_, _ = fmt.Fprint(logs, "\nConnection verified.\n\n")
+	dialDone()
 
 	// Make initial connections sequentially to ensure the services are
 	// reachable before we start spawning a bunch of goroutines and tickers.
@@ -63,6 +63,8 @@ func (r *Runner) Run(ctx context.Context, id string, logs io.Writer) error {
 	_, _ = fmt.Fprintf(logs, "\tUsername: %s\n", r.cfg.User.Username)
 	_, _ = fmt.Fprintf(logs, "\tEmail:    %s\n", r.cfg.User.Email)
 	_, _ = fmt.Fprintf(logs, "\tPassword: ****************\n")
+	metrics := harness.MetricsFromContext(ctx)
+	userCreateDone := metrics.StartPhase("user_create")
 	user, err := r.client.CreateUser(ctx, codersdk.CreateUserRequest{
 		OrganizationID: r.cfg.User.OrganizationID,
 		Username:       r.cfg.User.Username,
@@ -73,6 +75,7 @@ func (r *Runner) Run(ctx context.Context, id string, logs io.Writer) error {
 		return xerrors.Errorf("create user: %w", err)
 	}
 	r.userID = user.ID
+	userCreateDone()
 
 	_, _ = fmt.Fprintln(logs, "\nLogging in as new user...")
 	userClient := codersdk.New(r.client.URL)
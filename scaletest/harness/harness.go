@@ -17,12 +17,13 @@ type TestHarness struct {
 	runStrategy     ExecutionStrategy
 	cleanupStrategy ExecutionStrategy
 
-	mut     *sync.Mutex
-	runIDs  map[string]struct{}
-	runs    []*TestRun
-	started bool
-	done    chan struct{}
-	elapsed time.Duration
+	mut       *sync.Mutex
+	runIDs    map[string]struct{}
+	runs      []*TestRun
+	started   bool
+	done      chan struct{}
+	startedAt time.Time
+	elapsed   time.Duration
 }
 
 // NewTestHarness creates a new TestHarness with the given execution strategies.
@@ -72,6 +73,7 @@ func (h *TestHarness) Run(ctx context.Context) (err error) {
 	defer func() {
 		h.mut.Lock()
 		defer h.mut.Unlock()
+		h.startedAt = start
 		h.elapsed = time.Since(start)
 	}()
 
@@ -0,0 +1,236 @@
+package harness
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coder/coder/coderd/httpapi"
+)
+
+// maxTimelineIntervals is the maximum number of intervals in the timeline of
+// a phase. The interval length grows with the duration of the test.
+const maxTimelineIntervals = 60
+
+type metricsContextKey struct{}
+
+// WithMetrics returns a context that runners can record metrics to with
+// MetricsFromContext. The harness does this for every test run.
+func WithMetrics(ctx context.Context, m *Metrics) context.Context {
+	return context.WithValue(ctx, metricsContextKey{}, m)
+}
+
+// MetricsFromContext returns the metrics of the current test run. Returns nil
+// if the runner is used outside of a harness, which is safe to record to.
+func MetricsFromContext(ctx context.Context) *Metrics {
+	m, _ := ctx.Value(metricsContextKey{}).(*Metrics)
+	return m
+}
+
+// Metrics records the timings of the phases of a test run, such as creating a
+// user or waiting for a workspace build, and counters such as bytes sent or
+// errors. All methods are safe to call on a nil *Metrics.
+type Metrics struct {
+	mut      sync.Mutex
+	phases   []PhaseTiming
+	counters map[string]int64
+}
+
+// PhaseTiming is a single timing of a phase in a test run.
+type PhaseTiming struct {
+	Phase      string           `json:"phase"`
+	StartedAt  time.Time        `json:"started_at"`
+	Duration   httpapi.Duration `json:"duration"`
+	DurationMS int64            `json:"duration_ms"`
+}
+
+// Observe records a timing of the given phase. A phase can be observed more
+// than once in a test run.
+func (m *Metrics) Observe(phase string, startedAt time.Time, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.phases = append(m.phases, PhaseTiming{
+		Phase:      phase,
+		StartedAt:  startedAt,
+		Duration:   httpapi.Duration(duration),
+		DurationMS: duration.Milliseconds(),
+	})
+}
+
+// StartPhase starts timing the given phase and returns a function that
+// records it. Runners should only call the function once the phase has
+// succeeded, so failures don't skew the timings.
+func (m *Metrics) StartPhase(phase string) func() {
+	start := time.Now()
+	return func() {
+		m.Observe(phase, start, time.Since(start))
+	}
+}
+
+// Count adds delta to the given counter.
+func (m *Metrics) Count(counter string, delta int64) {
+	if m == nil {
+		return
+	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.counters == nil {
+		m.counters = map[string]int64{}
+	}
+	m.counters[counter] += delta
+}
+
+// Counters returns a copy of the counters.
+func (m *Metrics) Counters() map[string]int64 {
+	if m == nil {
+		return nil
+	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if len(m.counters) == 0 {
+		return nil
+	}
+	counters := make(map[string]int64, len(m.counters))
+	for counter, value := range m.counters {
+		counters[counter] = value
+	}
+	return counters
+}
+
+// Phases returns a copy of the recorded phase timings.
+func (m *Metrics) Phases() []PhaseTiming {
+	if m == nil {
+		return nil
+	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	phases := make([]PhaseTiming, len(m.phases))
+	copy(phases, m.phases)
+	return phases
+}
+
+// PhaseStats are the aggregated timings of a phase across all test runs.
+type PhaseStats struct {
+	Count int              `json:"count"`
+	Min   httpapi.Duration `json:"min"`
+	Mean  httpapi.Duration `json:"mean"`
+	P50   httpapi.Duration `json:"p50"`
+	P90   httpapi.Duration `json:"p90"`
+	P99   httpapi.Duration `json:"p99"`
+	Max   httpapi.Duration `json:"max"`
+	// Timeline shows how the timings of the phase changed over the course of
+	// the test. Each interval contains the phases that started in it.
+	Timeline []PhaseInterval `json:"timeline"`
+}
+
+// PhaseInterval is the distribution of the timings of a phase that started in
+// an interval of the test.
+type PhaseInterval struct {
+	StartedAt time.Time        `json:"started_at"`
+	Count     int              `json:"count"`
+	P50       httpapi.Duration `json:"p50"`
+	P90       httpapi.Duration `json:"p90"`
+	P99       httpapi.Duration `json:"p99"`
+}
+
+// timelineInterval returns the length of the timeline intervals for a test
+// that took elapsed to run, rounded up to the second.
+func timelineInterval(elapsed time.Duration) time.Duration {
+	interval := elapsed / maxTimelineIntervals
+	if remainder := interval % time.Second; remainder != 0 {
+		interval += time.Second - remainder
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+// aggregatePhases computes the stats of every phase recorded by the runs.
+func aggregatePhases(runs map[string]RunResult, startedAt time.Time, interval time.Duration) map[string]PhaseStats {
+	timings := map[string][]PhaseTiming{}
+	for _, run := range runs {
+		for _, timing := range run.Phases {
+			timings[timing.Phase] = append(timings[timing.Phase], timing)
+		}
+	}
+
+	phases := make(map[string]PhaseStats, len(timings))
+	for phase, phaseTimings := range timings {
+		durations := make([]time.Duration, 0, len(phaseTimings))
+		intervals := map[int][]time.Duration{}
+		var total time.Duration
+		for _, timing := range phaseTimings {
+			duration := time.Duration(timing.Duration)
+			durations = append(durations, duration)
+			total += duration
+
+			i := 0
+			if offset := timing.StartedAt.Sub(startedAt); offset > 0 {
+				i = int(offset / interval)
+			}
+			intervals[i] = append(intervals[i], duration)
+		}
+		sortDurations(durations)
+
+		stats := PhaseStats{
+			Count:    len(durations),
+			Min:      httpapi.Duration(durations[0]),
+			Mean:     httpapi.Duration(total / time.Duration(len(durations))),
+			P50:      httpapi.Duration(percentile(durations, 50)),
+			P90:      httpapi.Duration(percentile(durations, 90)),
+			P99:      httpapi.Duration(percentile(durations, 99)),
+			Max:      httpapi.Duration(durations[len(durations)-1]),
+			Timeline: make([]PhaseInterval, 0, len(intervals)),
+		}
+		for i, intervalDurations := range intervals {
+			sortDurations(intervalDurations)
+			stats.Timeline = append(stats.Timeline, PhaseInterval{
+				StartedAt: startedAt.Add(time.Duration(i) * interval),
+				Count:     len(intervalDurations),
+				P50:       httpapi.Duration(percentile(intervalDurations, 50)),
+				P90:       httpapi.Duration(percentile(intervalDurations, 90)),
+				P99:       httpapi.Duration(percentile(intervalDurations, 99)),
+			})
+		}
+		sort.Slice(stats.Timeline, func(i, j int) bool {
+			return stats.Timeline[i].StartedAt.Before(stats.Timeline[j].StartedAt)
+		})
+		phases[phase] = stats
+	}
+
+	return phases
+}
+
+// aggregateCounters sums the counters of all runs.
+func aggregateCounters(runs map[string]RunResult) map[string]int64 {
+	counters := map[string]int64{}
+	for _, run := range runs {
+		for counter, value := range run.Counters {
+			counters[counter] += value
+		}
+	}
+	return counters
+}
+
+func sortDurations(durations []time.Duration) {
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,72 @@
+package harness_test
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/scaletest/harness"
+)
+
+func Test_Metrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Nil", func(t *testing.T) {
+		t.Parallel()
+
+		// Runners used outside of a harness have no metrics.
+		metrics := harness.MetricsFromContext(context.Background())
+		require.Nil(t, metrics)
+		metrics.StartPhase("phase")()
+		metrics.Count("counter", 1)
+		require.Empty(t, metrics.Phases())
+		require.Empty(t, metrics.Counters())
+	})
+
+	t.Run("Aggregate", func(t *testing.T) {
+		t.Parallel()
+
+		h := harness.NewTestHarness(harness.LinearExecutionStrategy{}, harness.LinearExecutionStrategy{})
+		for i := 1; i <= 100; i++ {
+			duration := time.Duration(i) * time.Millisecond
+			h.AddRun("test", strconv.Itoa(i), testFns{
+				RunFn: func(ctx context.Context, _ string, _ io.Writer) error {
+					metrics := harness.MetricsFromContext(ctx)
+					metrics.Observe("phase", time.Now(), duration)
+					metrics.StartPhase("other")()
+					metrics.Count("requests", 2)
+					return nil
+				},
+			})
+		}
+
+		err := h.Run(context.Background())
+		require.NoError(t, err)
+
+		res := h.Results()
+		require.Len(t, res.Runs["test/1"].Phases, 2)
+		require.Equal(t, "phase", res.Runs["test/1"].Phases[0].Phase)
+		require.EqualValues(t, 1, res.Runs["test/1"].Phases[0].DurationMS)
+
+		require.Len(t, res.Phases, 2)
+		stats := res.Phases["phase"]
+		require.Equal(t, 100, stats.Count)
+		require.EqualValues(t, time.Millisecond, stats.Min)
+		require.EqualValues(t, 50500*time.Microsecond, stats.Mean)
+		require.EqualValues(t, 50*time.Millisecond, stats.P50)
+		require.EqualValues(t, 90*time.Millisecond, stats.P90)
+		require.EqualValues(t, 99*time.Millisecond, stats.P99)
+		require.EqualValues(t, 100*time.Millisecond, stats.Max)
+		require.EqualValues(t, time.Second, res.TimelineInterval)
+		require.Len(t, stats.Timeline, 1)
+		require.Equal(t, 100, stats.Timeline[0].Count)
+		require.EqualValues(t, 50*time.Millisecond, stats.Timeline[0].P50)
+		require.Equal(t, 100, res.Phases["other"].Count)
+		require.EqualValues(t, 2, res.Runs["test/1"].Counters["requests"])
+		require.EqualValues(t, 200, res.Counters["requests"])
+	})
+}
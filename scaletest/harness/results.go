@@ -2,11 +2,21 @@ package harness
 
 import (
 	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
 	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"golang.org/x/xerrors"
+
 	"github.com/coder/coder/coderd/httpapi"
 )
 
@@ -15,10 +25,20 @@ type Results struct {
 	TotalRuns int              `json:"total_runs"`
 	TotalPass int              `json:"total_pass"`
 	TotalFail int              `json:"total_fail"`
+	StartedAt time.Time        `json:"started_at"`
 	Elapsed   httpapi.Duration `json:"elapsed"`
 	ElapsedMS int64            `json:"elapsed_ms"`
 
 	Runs map[string]RunResult `json:"runs"`
+	// Phases are the aggregated timings of each phase recorded by the runs,
+	// by phase name.
+	Phases map[string]PhaseStats `json:"phases"`
+	// TimelineInterval is the length of the intervals in the timeline of
+	// each phase.
+	TimelineInterval httpapi.Duration `json:"timeline_interval"`
+	// Counters are the sums of the counters recorded by the runs, by counter
+	// name.
+	Counters map[string]int64 `json:"counters"`
 }
 
 // RunResult is the result of a single test run.
@@ -31,6 +51,11 @@ type RunResult struct {
 	StartedAt  time.Time        `json:"started_at"`
 	Duration   httpapi.Duration `json:"duration"`
 	DurationMS int64            `json:"duration_ms"`
+	// Phases are the timings recorded by the runner with Metrics, in the
+	// order they were recorded.
+	Phases []PhaseTiming `json:"phases"`
+	// Counters are the counters recorded by the runner with Metrics.
+	Counters map[string]int64 `json:"counters,omitempty"`
 }
 
 // Results returns the results of the test run. Panics if the test run is not
@@ -51,6 +76,8 @@ func (r *TestRun) Result() RunResult {
 		StartedAt:  r.started,
 		Duration:   httpapi.Duration(r.duration),
 		DurationMS: r.duration.Milliseconds(),
+		Phases:     r.metrics.Phases(),
+		Counters:   r.metrics.Counters(),
 	}
 }
 
@@ -65,11 +92,14 @@ func (h *TestHarness) Results() Results {
 		panic("harness has not finished")
 	}
 
+	interval := timelineInterval(h.elapsed)
 	results := Results{
-		TotalRuns: len(h.runs),
-		Runs:      make(map[string]RunResult, len(h.runs)),
-		Elapsed:   httpapi.Duration(h.elapsed),
-		ElapsedMS: h.elapsed.Milliseconds(),
+		TotalRuns:        len(h.runs),
+		StartedAt:        h.startedAt,
+		Runs:             make(map[string]RunResult, len(h.runs)),
+		Elapsed:          httpapi.Duration(h.elapsed),
+		ElapsedMS:        h.elapsed.Milliseconds(),
+		TimelineInterval: httpapi.Duration(interval),
 	}
 	for _, run := range h.runs {
 		runRes := run.Result()
@@ -81,6 +111,8 @@ func (h *TestHarness) Results() Results {
 			results.TotalFail++
 		}
 	}
+	results.Phases = aggregatePhases(results.Runs, h.startedAt, interval)
+	results.Counters = aggregateCounters(results.Runs)
 
 	return results
 }
@@ -120,4 +152,282 @@ func (r *Results) PrintText(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "")
 	_, _ = fmt.Fprintf(w, "\tTotal duration: %s\n", time.Duration(r.Elapsed))
 	_, _ = fmt.Fprintf(w, "\tAvg. duration:  %s\n", totalDuration/time.Duration(r.TotalRuns))
+
+	if len(r.Phases) > 0 {
+		r.printPhases(w)
+	}
+	if len(r.Counters) > 0 {
+		r.printCounters(w)
+	}
+}
+
+func (r *Results) printPhases(w io.Writer) {
+	phases := make([]string, 0, len(r.Phases))
+	for phase := range r.Phases {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+
+	_, _ = fmt.Fprintln(w, "\nPhase timings:")
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	_, _ = fmt.Fprintln(tw, "\tPHASE\tCOUNT\tMIN\tMEAN\tP50\tP90\tP99\tMAX")
+	for _, phase := range phases {
+		stats := r.Phases[phase]
+		_, _ = fmt.Fprintf(tw, "\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			phase,
+			stats.Count,
+			time.Duration(stats.Min),
+			time.Duration(stats.Mean),
+			time.Duration(stats.P50),
+			time.Duration(stats.P90),
+			time.Duration(stats.P99),
+			time.Duration(stats.Max),
+		)
+	}
+	_ = tw.Flush()
+}
+
+// printCounters prints the counters with their rate per second over the
+// whole test, e.g. the throughput in bytes or the rate of errors.
+func (r *Results) printCounters(w io.Writer) {
+	counters := make([]string, 0, len(r.Counters))
+	for counter := range r.Counters {
+		counters = append(counters, counter)
+	}
+	sort.Strings(counters)
+
+	elapsed := time.Duration(r.Elapsed).Seconds()
+	_, _ = fmt.Fprintln(w, "\nCounters:")
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	_, _ = fmt.Fprintln(tw, "\tCOUNTER\tTOTAL\tPER SECOND")
+	for _, counter := range counters {
+		var rate float64
+		if elapsed > 0 {
+			rate = float64(r.Counters[counter]) / elapsed
+		}
+		_, _ = fmt.Fprintf(tw, "\t%s\t%d\t%.2f\n", counter, r.Counters[counter], rate)
+	}
+	_ = tw.Flush()
+}
+
+// sortedRuns returns the runs sorted by test name, then by ID.
+func (r *Results) sortedRuns() []RunResult {
+	runs := make([]RunResult, 0, len(r.Runs))
+	for _, run := range r.Runs {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].TestName != runs[j].TestName {
+			return runs[i].TestName < runs[j].TestName
+		}
+		return runs[i].ID < runs[j].ID
+	})
+	return runs
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitFailure    `xml:"failure,omitempty"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+func junitSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// PrintJUnit prints the results as JUnit XML to the given writer. Each test
+// name is a test suite, and the phase timings of each run are recorded as
+// properties of the test case. Logs are only included for failed runs.
+func (r *Results) PrintJUnit(w io.Writer) error {
+	suites := junitTestSuites{
+		Name:     "scaletest",
+		Tests:    r.TotalRuns,
+		Failures: r.TotalFail,
+		Time:     junitSeconds(time.Duration(r.Elapsed)),
+	}
+	suiteIndex := map[string]int{}
+	suiteTimes := []time.Duration{}
+	for _, run := range r.sortedRuns() {
+		i, ok := suiteIndex[run.TestName]
+		if !ok {
+			i = len(suites.Suites)
+			suiteIndex[run.TestName] = i
+			suites.Suites = append(suites.Suites, junitTestSuite{
+				Name:      run.TestName,
+				Timestamp: run.StartedAt.UTC().Format(time.RFC3339),
+			})
+			suiteTimes = append(suiteTimes, 0)
+		}
+		suite := &suites.Suites[i]
+		suiteTimes[i] += time.Duration(run.Duration)
+
+		testCase := junitTestCase{
+			Name:      run.ID,
+			ClassName: run.TestName,
+			Time:      junitSeconds(time.Duration(run.Duration)),
+		}
+		if len(run.Phases) > 0 {
+			testCase.Properties = &junitProperties{}
+			for _, timing := range run.Phases {
+				testCase.Properties.Properties = append(testCase.Properties.Properties, junitProperty{
+					Name:  "phase." + timing.Phase,
+					Value: junitSeconds(time.Duration(timing.Duration)),
+				})
+			}
+		}
+		if run.Error != nil {
+			testCase.Failure = &junitFailure{Message: run.Error.Error()}
+			testCase.SystemOut = run.Logs
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	for i := range suites.Suites {
+		suites.Suites[i].Time = junitSeconds(suiteTimes[i])
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return xerrors.Errorf("write header: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(suites)
+	if err != nil {
+		return xerrors.Errorf("encode XML: %w", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// PrintCSV prints the results as CSV to the given writer. Every run has a
+// row with an empty phase for the run as a whole, followed by a row for each
+// phase timing it recorded.
+func (r *Results) PrintCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"full_id", "test_name", "id", "phase", "started_at", "duration_ms", "error"})
+	if err != nil {
+		return xerrors.Errorf("write header: %w", err)
+	}
+	for _, run := range r.sortedRuns() {
+		var runErr string
+		if run.Error != nil {
+			runErr = run.Error.Error()
+		}
+		err = cw.Write([]string{
+			run.FullID,
+			run.TestName,
+			run.ID,
+			"",
+			run.StartedAt.UTC().Format(time.RFC3339Nano),
+			strconv.FormatInt(run.DurationMS, 10),
+			runErr,
+		})
+		if err != nil {
+			return xerrors.Errorf("write run %q: %w", run.FullID, err)
+		}
+		for _, timing := range run.Phases {
+			err = cw.Write([]string{
+				run.FullID,
+				run.TestName,
+				run.ID,
+				timing.Phase,
+				timing.StartedAt.UTC().Format(time.RFC3339Nano),
+				strconv.FormatInt(timing.DurationMS, 10),
+				"",
+			})
+			if err != nil {
+				return xerrors.Errorf("write phase %q of run %q: %w", timing.Phase, run.FullID, err)
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// PushPrometheus pushes the results to a Prometheus Pushgateway at the given
+// URL under the given job name.
+func (r *Results) PushPrometheus(ctx context.Context, url string, job string) error {
+	reg := prometheus.NewRegistry()
+	runs := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coder",
+		Subsystem: "scaletest",
+		Name:      "runs",
+		Help:      "The number of test runs by result.",
+	}, []string{"result"})
+	runDurations := prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Namespace:  "coder",
+		Subsystem:  "scaletest",
+		Name:       "run_duration_seconds",
+		Help:       "The duration of test runs by test name.",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+	}, []string{"test_name"})
+	phaseDurations := prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Namespace:  "coder",
+		Subsystem:  "scaletest",
+		Name:       "phase_duration_seconds",
+		Help:       "The duration of the phases recorded by test runs.",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+	}, []string{"phase"})
+	counters := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coder",
+		Subsystem: "scaletest",
+		Name:      "counters",
+		Help:      "The sums of the counters recorded by test runs.",
+	}, []string{"counter"})
+	reg.MustRegister(runs, runDurations, phaseDurations, counters)
+
+	runs.WithLabelValues("pass").Set(float64(r.TotalPass))
+	runs.WithLabelValues("fail").Set(float64(r.TotalFail))
+	for _, run := range r.Runs {
+		runDurations.WithLabelValues(run.TestName).Observe(time.Duration(run.Duration).Seconds())
+		for _, timing := range run.Phases {
+			phaseDurations.WithLabelValues(timing.Phase).Observe(time.Duration(timing.Duration).Seconds())
+		}
+	}
+
+	for counter, value := range r.Counters {
+		counters.WithLabelValues(counter).Set(float64(value))
+	}
+
+	err := push.New(url, job).Gatherer(reg).PushContext(ctx)
+	if err != nil {
+		return xerrors.Errorf("push to %q: %w", url, err)
+	}
+	return nil
 }
@@ -2,6 +2,11 @@ package harness_test
 
 import (
 	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -68,3 +73,131 @@ Test results:
 
 	require.Equal(t, expected, out.String())
 }
+
+func fakeResultsWithPhases() harness.Results {
+	startedAt := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	return harness.Results{
+		TotalRuns: 2,
+		TotalPass: 1,
+		TotalFail: 1,
+		StartedAt: startedAt,
+		Runs: map[string]harness.RunResult{
+			"test/0": {
+				FullID:     "test/0",
+				TestName:   "test",
+				ID:         "0",
+				Logs:       "test/0 log line",
+				Error:      xerrors.New("test/0 error"),
+				StartedAt:  startedAt,
+				Duration:   httpapi.Duration(2 * time.Second),
+				DurationMS: 2000,
+			},
+			"test/1": {
+				FullID:     "test/1",
+				TestName:   "test",
+				ID:         "1",
+				Logs:       "test/1 log line",
+				StartedAt:  startedAt,
+				Duration:   httpapi.Duration(time.Second),
+				DurationMS: 1000,
+				Phases: []harness.PhaseTiming{{
+					Phase:      "user_create",
+					StartedAt:  startedAt,
+					Duration:   httpapi.Duration(500 * time.Millisecond),
+					DurationMS: 500,
+				}},
+			},
+		},
+		Phases: map[string]harness.PhaseStats{
+			"user_create": {
+				Count: 1,
+				Min:   httpapi.Duration(500 * time.Millisecond),
+				Mean:  httpapi.Duration(500 * time.Millisecond),
+				P50:   httpapi.Duration(500 * time.Millisecond),
+				P90:   httpapi.Duration(500 * time.Millisecond),
+				P99:   httpapi.Duration(500 * time.Millisecond),
+				Max:   httpapi.Duration(500 * time.Millisecond),
+			},
+		},
+		Elapsed:   httpapi.Duration(3 * time.Second),
+		ElapsedMS: 3000,
+	}
+}
+
+func Test_ResultsPhases(t *testing.T) {
+	t.Parallel()
+
+	results := fakeResultsWithPhases()
+
+	t.Run("Text", func(t *testing.T) {
+		t.Parallel()
+
+		out := bytes.NewBuffer(nil)
+		results.PrintText(out)
+		require.Contains(t, out.String(), `
+Phase timings:
+	PHASE		COUNT	MIN	MEAN	P50	P90	P99	MAX
+	user_create	1	500ms	500ms	500ms	500ms	500ms	500ms
+`)
+	})
+
+	t.Run("JUnit", func(t *testing.T) {
+		t.Parallel()
+
+		expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="scaletest" tests="2" failures="1" time="3.000">
+  <testsuite name="test" tests="2" failures="1" time="3.000" timestamp="2023-06-01T12:00:00Z">
+    <testcase name="0" classname="test" time="2.000">
+      <failure message="test/0 error"></failure>
+      <system-out>test/0 log line</system-out>
+    </testcase>
+    <testcase name="1" classname="test" time="1.000">
+      <properties>
+        <property name="phase.user_create" value="0.500"></property>
+      </properties>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+
+		out := bytes.NewBuffer(nil)
+		err := results.PrintJUnit(out)
+		require.NoError(t, err)
+		require.Equal(t, expected, out.String())
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		t.Parallel()
+
+		expected := `full_id,test_name,id,phase,started_at,duration_ms,error
+test/0,test,0,,2023-06-01T12:00:00Z,2000,test/0 error
+test/1,test,1,,2023-06-01T12:00:00Z,1000,
+test/1,test,1,user_create,2023-06-01T12:00:00Z,500,
+`
+
+		out := bytes.NewBuffer(nil)
+		err := results.PrintCSV(out)
+		require.NoError(t, err)
+		require.Equal(t, expected, out.String())
+	})
+
+	t.Run("Prometheus", func(t *testing.T) {
+		t.Parallel()
+
+		pushed := make(chan string, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			pushed <- r.URL.Path + "\n" + string(body)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+
+		err := results.PushPrometheus(context.Background(), srv.URL, "coder_scaletest")
+		require.NoError(t, err)
+		body := <-pushed
+		require.True(t, strings.HasPrefix(body, "/metrics/job/coder_scaletest\n"))
+		require.Contains(t, body, "coder_scaletest_runs")
+		require.Contains(t, body, "coder_scaletest_phase_duration_seconds")
+		require.Contains(t, body, "user_create")
+	})
+}
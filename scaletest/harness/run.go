@@ -66,6 +66,7 @@ type TestRun struct {
 	runner   Runnable
 
 	logs     *bytes.Buffer
+	metrics  *Metrics
 	done     chan struct{}
 	started  time.Time
 	duration time.Duration
@@ -88,8 +89,10 @@ func (r *TestRun) FullID() string {
 // error recording and duration recording. The test error is returned.
 func (r *TestRun) Run(ctx context.Context) (err error) {
 	r.logs = new(bytes.Buffer)
+	r.metrics = new(Metrics)
 	r.done = make(chan struct{})
 	defer close(r.done)
+	ctx = WithMetrics(ctx, r.metrics)
 
 	r.started = time.Now()
 	defer func() {
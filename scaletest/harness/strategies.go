@@ -4,6 +4,7 @@ import (
 	"context"
 	cryptorand "crypto/rand"
 	"encoding/binary"
+	"math"
 	"math/rand"
 	"sync"
 	"time"
@@ -109,6 +110,113 @@ func (p ParallelExecutionStrategy) Run(ctx context.Context, fns []TestFn) ([]err
 	return errs.errs, nil
 }
 
+// ArrivalRateExecutionStrategy starts test runs at a constant rate, regardless
+// of whether earlier runs have completed. Unlike the other strategies, it's
+// open-loop: slow runs don't slow down the arrival of new runs, so the number
+// of concurrent runs grows if the system under test can't keep up.
+type ArrivalRateExecutionStrategy struct {
+	// Rate is the number of test runs started per second.
+	Rate float64
+}
+
+var _ ExecutionStrategy = ArrivalRateExecutionStrategy{}
+
+// Run implements ExecutionStrategy.
+func (a ArrivalRateExecutionStrategy) Run(ctx context.Context, fns []TestFn) ([]error, error) {
+	return RampExecutionStrategy{
+		InitialRate: a.Rate,
+		FinalRate:   a.Rate,
+	}.Run(ctx, fns)
+}
+
+// RampExecutionStrategy starts test runs at a rate that increases linearly
+// from InitialRate to FinalRate over Duration, and then stays at FinalRate.
+// Like ArrivalRateExecutionStrategy, runs are started regardless of whether
+// earlier runs have completed.
+type RampExecutionStrategy struct {
+	// InitialRate is the number of test runs started per second at the start
+	// of the ramp. May be 0.
+	InitialRate float64
+	// FinalRate is the number of test runs started per second at the end of
+	// the ramp. Must be greater than 0.
+	FinalRate float64
+	// Duration is how long it takes to ramp from InitialRate to FinalRate.
+	Duration time.Duration
+}
+
+var _ ExecutionStrategy = RampExecutionStrategy{}
+
+// Run implements ExecutionStrategy.
+func (r RampExecutionStrategy) Run(ctx context.Context, fns []TestFn) ([]error, error) {
+	if r.InitialRate < 0 || r.FinalRate <= 0 {
+		return nil, xerrors.Errorf("invalid rate: initial rate %v must not be negative and final rate %v must be greater than 0", r.InitialRate, r.FinalRate)
+	}
+
+	var (
+		wg    sync.WaitGroup
+		errs  = newErrorsList()
+		start = time.Now()
+		timer = time.NewTimer(0)
+	)
+	defer timer.Stop()
+	for i, fn := range fns {
+		i, fn := i, fn
+
+		// If the context is canceled, the remaining runs are started
+		// immediately so they can fail.
+		if ctx.Err() == nil {
+			wait := time.Until(start.Add(r.startOffset(i)))
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(wait)
+			select {
+			case <-ctx.Done():
+			case <-timer.C:
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := fn(ctx)
+			if err != nil {
+				errs.add(xerrors.Errorf("run %d: %w", i, err))
+			}
+		}()
+	}
+
+	wg.Wait()
+	return errs.errs, nil
+}
+
+// startOffset returns how long after the start of the test the nth run should
+// be started. The number of runs started by time t is the integral of the
+// rate, so this solves that for t.
+func (r RampExecutionStrategy) startOffset(n int) time.Duration {
+	var (
+		runs     = float64(n)
+		duration = r.Duration.Seconds()
+		// rampRuns is the number of runs started during the ramp.
+		rampRuns = (r.InitialRate + r.FinalRate) / 2 * duration
+	)
+	if runs >= rampRuns {
+		seconds := duration + (runs-rampRuns)/r.FinalRate
+		return time.Duration(seconds * float64(time.Second))
+	}
+
+	// runs = InitialRate*t + (FinalRate-InitialRate)/(2*duration)*t^2
+	a := (r.FinalRate - r.InitialRate) / (2 * duration)
+	if a == 0 {
+		return time.Duration(runs / r.InitialRate * float64(time.Second))
+	}
+	seconds := (-r.InitialRate + math.Sqrt(r.InitialRate*r.InitialRate+4*a*runs)) / (2 * a)
+	return time.Duration(seconds * float64(time.Second))
+}
+
 // TimeoutExecutionStrategyWrapper is an ExecutionStrategy that wraps another
 // ExecutionStrategy and applies a timeout to each test run's context.
 type TimeoutExecutionStrategyWrapper struct {
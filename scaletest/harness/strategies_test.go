@@ -124,6 +124,95 @@ func Test_ParallelExecutionStrategy(t *testing.T) {
 	require.Equal(t, 5, withinRange)
 }
 
+//nolint:paralleltest // this tests uses timings to determine if it's working
+func Test_ArrivalRateExecutionStrategy(t *testing.T) {
+	runs, fns := strategyTestData(10, func(_ context.Context, i int, _ io.Writer) error {
+		time.Sleep(1 * time.Second)
+		if i%2 == 0 {
+			return xerrors.New("error")
+		}
+		return nil
+	})
+	strategy := harness.ArrivalRateExecutionStrategy{
+		Rate: 20,
+	}
+
+	startTime := time.Now()
+	runErrs, err := strategy.Run(context.Background(), fns)
+	require.NoError(t, err)
+	require.Len(t, runErrs, 5)
+
+	// Runs should be started every 50ms without waiting for earlier runs to
+	// complete, so the last run should start after 450ms and the test should
+	// take less than 2 seconds.
+	require.True(t, time.Since(startTime) < 2*time.Second)
+	for i, run := range runs {
+		expected := startTime.Add(time.Duration(i) * 50 * time.Millisecond)
+		require.WithinRange(t, run.Result().StartedAt, expected, expected.Add(250*time.Millisecond))
+	}
+}
+
+//nolint:paralleltest // this tests uses timings to determine if it's working
+func Test_RampExecutionStrategy(t *testing.T) {
+	t.Run("Ramp", func(t *testing.T) {
+		runs, fns := strategyTestData(30, nil)
+		strategy := harness.RampExecutionStrategy{
+			InitialRate: 10,
+			FinalRate:   30,
+			Duration:    time.Second,
+		}
+
+		startTime := time.Now()
+		runErrs, err := strategy.Run(context.Background(), fns)
+		require.NoError(t, err)
+		require.Len(t, runErrs, 0)
+
+		// 20 runs are started during the 1 second ramp, 10 of them in the
+		// first ~618ms while the rate is lower. The remaining 9 runs are
+		// started at 30 per second.
+		expected := map[int]time.Duration{
+			10: 618 * time.Millisecond,
+			20: time.Second,
+			29: 1300 * time.Millisecond,
+		}
+		for i, offset := range expected {
+			start := startTime.Add(offset)
+			require.WithinRange(t, runs[i].Result().StartedAt, start, start.Add(250*time.Millisecond), "run %d", i)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		var count int64
+		_, fns := strategyTestData(10, func(_ context.Context, _ int, _ io.Writer) error {
+			atomic.AddInt64(&count, 1)
+			return nil
+		})
+		strategy := harness.RampExecutionStrategy{
+			FinalRate: 0.1,
+			Duration:  time.Minute,
+		}
+
+		// All runs should be executed immediately once the context is
+		// canceled.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		startTime := time.Now()
+		_, err := strategy.Run(ctx, fns)
+		require.NoError(t, err)
+		require.EqualValues(t, 10, atomic.LoadInt64(&count))
+		require.True(t, time.Since(startTime) < time.Second)
+	})
+
+	t.Run("InvalidRate", func(t *testing.T) {
+		_, fns := strategyTestData(1, nil)
+		strategy := harness.RampExecutionStrategy{
+			InitialRate: 1,
+		}
+		_, err := strategy.Run(context.Background(), fns)
+		require.Error(t, err)
+	})
+}
+
 //nolint:paralleltest // this tests uses timings to determine if it's working
 func Test_TimeoutExecutionStrategy(t *testing.T) {
 	runs, fns := strategyTestData(1, func(ctx context.Context, _ int, _ io.Writer) error {
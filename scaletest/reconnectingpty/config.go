@@ -22,7 +22,9 @@ type Config struct {
 	// Init is the initial packet to send to the agent when launching the TTY.
 	// If the ID is not set, defaults to a random UUID. If the width or height
 	// is not set, defaults to 80x24. If the command is not set, defaults to
-	// opening a login shell. Command runs in the default shell.
+	// opening a login shell. Command runs in the default shell. If the offset
+	// is set, output before it isn't replayed when joining an existing
+	// session.
 	Init codersdk.WorkspaceAgentReconnectingPTYInit `json:"init"`
 	// Timeout is the duration to wait for the command to exit. Defaults to
 	// 5 minutes.
@@ -38,6 +40,10 @@ type Config struct {
 	// avoid loadtest OOMs. All log output is still read and discarded if this
 	// is false.
 	LogOutput bool `json:"log_output"`
+	// Reconnects is the number of times to reconnect if the connection is
+	// lost before the command exits. The output is resumed from where the
+	// lost connection left off, so none of it is read twice.
+	Reconnects int `json:"reconnects"`
 }
 
 func (c Config) Validate() error {
@@ -47,6 +53,12 @@ func (c Config) Validate() error {
 	if c.Timeout < 0 {
 		return xerrors.New("timeout must be a positive value")
 	}
+	if c.Init.Offset != nil && *c.Init.Offset < 0 {
+		return xerrors.New("init.offset must not be negative")
+	}
+	if c.Reconnects < 0 {
+		return xerrors.New("reconnects must not be negative")
+	}
 
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/scaletest/reconnectingpty"
 )
@@ -41,6 +42,7 @@ func Test_Config(t *testing.T) {
 				ExpectTimeout: false,
 				ExpectOutput:  "hello world",
 				LogOutput:     true,
+				Reconnects:    3,
 			},
 		},
 		{
@@ -58,6 +60,24 @@ func Test_Config(t *testing.T) {
 			},
 			errContains: "timeout must be a positive value",
 		},
+		{
+			name: "NegativeOffset",
+			config: reconnectingpty.Config{
+				AgentID: id,
+				Init: codersdk.WorkspaceAgentReconnectingPTYInit{
+					Offset: ptr.Ref(int64(-1)),
+				},
+			},
+			errContains: "init.offset must not be negative",
+		},
+		{
+			name: "NegativeReconnects",
+			config: reconnectingpty.Config{
+				AgentID:    id,
+				Reconnects: -1,
+			},
+			errContains: "reconnects must not be negative",
+		},
 	}
 
 	for _, c := range cases {
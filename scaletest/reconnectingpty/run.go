@@ -3,8 +3,10 @@ package reconnectingpty
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"strings"
 	"time"
 
@@ -64,11 +66,24 @@ func (r *Runner) Run(ctx context.Context, _ string, logs io.Writer) error {
 	_, _ = fmt.Fprintf(logs, "\tHeight:  %d\n", height)
 	_, _ = fmt.Fprintf(logs, "\tCommand: %q\n\n", r.cfg.Init.Command)
 
-	conn, err := r.client.WorkspaceAgentReconnectingPTY(ctx, r.cfg.AgentID, id, width, height, r.cfg.Init.Command)
+	conn := &resumingConn{
+		logs:       logs,
+		reconnects: r.cfg.Reconnects,
+		dial: func(ctx context.Context, offset int64) (net.Conn, error) {
+			return r.client.WorkspaceAgentReconnectingPTY(ctx, r.cfg.AgentID, id, width, height, r.cfg.Init.Command, codersdk.ReconnectingPTYWithOffset(offset))
+		},
+	}
+	if r.cfg.Init.Offset != nil {
+		conn.offset = *r.cfg.Init.Offset
+	}
+	metrics := harness.MetricsFromContext(ctx)
+	connectDone := metrics.StartPhase("pty_connect")
+	err := conn.connect(ctx)
 	if err != nil {
 		return xerrors.Errorf("open reconnecting PTY: %w", err)
 	}
 	defer conn.Close()
+	connectDone()
 
 	var (
 		copyTimeout = r.cfg.Timeout
@@ -84,7 +99,8 @@ func (r *Runner) Run(ctx context.Context, _ string, logs io.Writer) error {
 
 	copyCtx, copyCancel := context.WithTimeout(ctx, time.Duration(copyTimeout))
 	defer copyCancel()
-	matched, err := copyContext(copyCtx, copyOutput, conn, r.cfg.ExpectOutput)
+	echoDone := metrics.StartPhase("pty_echo")
+	matched, err := copyContext(copyCtx, copyOutput, conn.reader(copyCtx), r.cfg.ExpectOutput)
 	if r.cfg.ExpectTimeout {
 		if err == nil {
 			return xerrors.Errorf("expected timeout, but the command exited successfully")
@@ -98,6 +114,11 @@ func (r *Runner) Run(ctx context.Context, _ string, logs io.Writer) error {
 	if !matched {
 		return xerrors.Errorf("expected string %q not found in output", r.cfg.ExpectOutput)
 	}
+	// A command that is expected to time out always takes the full timeout,
+	// so it isn't worth recording.
+	if !r.cfg.ExpectTimeout {
+		echoDone()
+	}
 
 	return nil
 }
@@ -156,3 +177,65 @@ func copyContext(ctx context.Context, dst io.Writer, src io.Reader, expectOutput
 		return matched, err
 	}
 }
+
+// resumingConn reads the output of a reconnecting PTY, reconnecting from the
+// offset it has read up to when the connection is lost.
+type resumingConn struct {
+	logs       io.Writer
+	dial       func(ctx context.Context, offset int64) (net.Conn, error)
+	reconnects int
+
+	conn net.Conn
+	// offset is the offset in the session output of the next byte to read.
+	offset int64
+}
+
+func (c *resumingConn) connect(ctx context.Context) error {
+	conn, err := c.dial(ctx, c.offset)
+	if err != nil {
+		return err
+	}
+	start, err := codersdk.ReadReconnectingPTYOffset(conn)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	if start > c.offset {
+		_, _ = fmt.Fprintf(c.logs, "Output between offset %d and %d is no longer available.\n", c.offset, start)
+	}
+	c.conn = conn
+	c.offset = start
+	return nil
+}
+
+func (c *resumingConn) reader(ctx context.Context) io.Reader {
+	return readerFunc(func(p []byte) (int, error) {
+		for {
+			n, err := c.conn.Read(p)
+			c.offset += int64(n)
+			// A closed connection means the command exited, reconnecting
+			// would start it again.
+			if err == nil || n > 0 || errors.Is(err, io.EOF) || c.reconnects == 0 || ctx.Err() != nil {
+				return n, err
+			}
+
+			c.reconnects--
+			_, _ = fmt.Fprintf(c.logs, "Connection lost, resuming from offset %d: %s\n", c.offset, err)
+			_ = c.conn.Close()
+			err = c.connect(ctx)
+			if err != nil {
+				return 0, xerrors.Errorf("reconnect: %w", err)
+			}
+		}
+	})
+}
+
+func (c *resumingConn) Close() error {
+	return c.conn.Close()
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
@@ -57,6 +57,8 @@ func (r *Runner) Run(ctx context.Context, _ string, logs io.Writer) error {
 		req.Name = "test-" + randName
 	}
 
+	metrics := harness.MetricsFromContext(ctx)
+	buildDone := metrics.StartPhase("workspace_build")
 	workspace, err := r.client.CreateWorkspace(ctx, r.cfg.OrganizationID, r.cfg.UserID, req)
 	if err != nil {
 		return xerrors.Errorf("create workspace: %w", err)
@@ -67,15 +69,18 @@ func (r *Runner) Run(ctx context.Context, _ string, logs io.Writer) error {
 	if err != nil {
 		return xerrors.Errorf("wait for build: %w", err)
 	}
+	buildDone()
 
 	if r.cfg.NoWaitForAgents {
 		_, _ = fmt.Fprintln(logs, "Skipping agent connectivity check.")
 	} else {
 		_, _ = fmt.Fprintln(logs, "")
+		agentConnectDone := metrics.StartPhase("agent_connect")
 		err = waitForAgents(ctx, logs, r.client, workspace.ID)
 		if err != nil {
 			return xerrors.Errorf("wait for agent: %w", err)
 		}
+		agentConnectDone()
 	}
 
 	return nil
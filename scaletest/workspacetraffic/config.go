@@ -0,0 +1,64 @@
+package workspacetraffic
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/httpapi"
+)
+
+const (
+	DefaultTickInterval = httpapi.Duration(100 * time.Millisecond)
+	DefaultBytesPerTick = 1024
+)
+
+type Config struct {
+	// AgentID is the ID of the agent to send traffic to.
+	AgentID uuid.UUID `json:"agent_id"`
+	// Duration is how long to send traffic for.
+	Duration httpapi.Duration `json:"duration"`
+	// TickInterval is how often traffic is sent. Each tick writes
+	// BytesPerTick over SSH and the reconnecting PTY, and sends a request to
+	// each app. Defaults to 100ms.
+	TickInterval httpapi.Duration `json:"tick_interval"`
+	// BytesPerTick is the number of bytes written over SSH and the
+	// reconnecting PTY on each tick. Defaults to 1024.
+	BytesPerTick int64 `json:"bytes_per_tick"`
+	// SSH echoes bytes over an SSH session to the agent, and records the
+	// round trip latency of each tick.
+	SSH bool `json:"ssh"`
+	// ReconnectingPTY types into a reconnecting PTY on the agent, like the
+	// web terminal does.
+	ReconnectingPTY bool `json:"reconnecting_pty"`
+	// AppPaths are the paths of the workspace apps to send requests to
+	// through the path-based app proxy, e.g. "/@user/workspace.agent/apps/code-server/".
+	AppPaths []string `json:"app_paths"`
+}
+
+func (c Config) Validate() error {
+	if c.AgentID == uuid.Nil {
+		return xerrors.New("agent_id must be set")
+	}
+	if c.Duration <= 0 {
+		return xerrors.New("duration must be greater than 0")
+	}
+	if c.TickInterval < 0 {
+		return xerrors.New("tick_interval must be a positive value")
+	}
+	if c.BytesPerTick < 0 {
+		return xerrors.New("bytes_per_tick must not be negative")
+	}
+	if !c.SSH && !c.ReconnectingPTY && len(c.AppPaths) == 0 {
+		return xerrors.New("at least one of ssh, reconnecting_pty or app_paths must be set")
+	}
+	for i, path := range c.AppPaths {
+		if !strings.HasPrefix(path, "/") {
+			return xerrors.Errorf("app_paths[%d] must be an absolute path", i)
+		}
+	}
+
+	return nil
+}
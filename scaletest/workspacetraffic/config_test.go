@@ -0,0 +1,114 @@
+package workspacetraffic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/scaletest/workspacetraffic"
+)
+
+func Test_Config(t *testing.T) {
+	t.Parallel()
+
+	id := uuid.New()
+	cases := []struct {
+		name        string
+		config      workspacetraffic.Config
+		errContains string
+	}{
+		{
+			name: "OKBasic",
+			config: workspacetraffic.Config{
+				AgentID:  id,
+				Duration: httpapi.Duration(time.Minute),
+				SSH:      true,
+			},
+		},
+		{
+			name: "OKFull",
+			config: workspacetraffic.Config{
+				AgentID:         id,
+				Duration:        httpapi.Duration(time.Minute),
+				TickInterval:    httpapi.Duration(time.Second),
+				BytesPerTick:    4096,
+				SSH:             true,
+				ReconnectingPTY: true,
+				AppPaths:        []string{"/@user/workspace.agent/apps/code-server/"},
+			},
+		},
+		{
+			name: "NoAgentID",
+			config: workspacetraffic.Config{
+				AgentID:  uuid.Nil,
+				Duration: httpapi.Duration(time.Minute),
+				SSH:      true,
+			},
+			errContains: "agent_id must be set",
+		},
+		{
+			name: "NoDuration",
+			config: workspacetraffic.Config{
+				AgentID: id,
+				SSH:     true,
+			},
+			errContains: "duration must be greater than 0",
+		},
+		{
+			name: "NegativeTickInterval",
+			config: workspacetraffic.Config{
+				AgentID:      id,
+				Duration:     httpapi.Duration(time.Minute),
+				TickInterval: httpapi.Duration(-time.Second),
+				SSH:          true,
+			},
+			errContains: "tick_interval must be a positive value",
+		},
+		{
+			name: "NegativeBytesPerTick",
+			config: workspacetraffic.Config{
+				AgentID:      id,
+				Duration:     httpapi.Duration(time.Minute),
+				BytesPerTick: -1,
+				SSH:          true,
+			},
+			errContains: "bytes_per_tick must not be negative",
+		},
+		{
+			name: "NoTraffic",
+			config: workspacetraffic.Config{
+				AgentID:  id,
+				Duration: httpapi.Duration(time.Minute),
+			},
+			errContains: "at least one of ssh, reconnecting_pty or app_paths must be set",
+		},
+		{
+			name: "RelativeAppPath",
+			config: workspacetraffic.Config{
+				AgentID:  id,
+				Duration: httpapi.Duration(time.Minute),
+				AppPaths: []string{"apps/code-server/"},
+			},
+			errContains: "app_paths[0] must be an absolute path",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := c.config.Validate()
+			if c.errContains != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), c.errContains)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package workspacetraffic
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+
+	"github.com/coder/coder/coderd/tracing"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/cryptorand"
+	"github.com/coder/coder/scaletest/harness"
+	"github.com/coder/coder/scaletest/loadtestutil"
+)
+
+type Runner struct {
+	client *codersdk.Client
+	cfg    Config
+}
+
+var _ harness.Runnable = &Runner{}
+
+func NewRunner(client *codersdk.Client, cfg Config) *Runner {
+	return &Runner{
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+// Run implements Runnable. Traffic is sent until the configured duration has
+// passed. Failed app requests are counted as errors, but don't fail the run.
+// Failing to connect, or losing the connection, fails the run.
+func (r *Runner) Run(ctx context.Context, _ string, logs io.Writer) error {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+
+	logs = loadtestutil.NewSyncWriter(logs)
+	logger := slog.Make(sloghuman.Sink(logs)).Leveled(slog.LevelDebug)
+
+	var (
+		metrics      = harness.MetricsFromContext(ctx)
+		tickInterval = time.Duration(r.cfg.TickInterval)
+		bytesPerTick = r.cfg.BytesPerTick
+	)
+	if tickInterval == 0 {
+		tickInterval = time.Duration(DefaultTickInterval)
+	}
+	if bytesPerTick == 0 {
+		bytesPerTick = DefaultBytesPerTick
+	}
+
+	_, _ = fmt.Fprintln(logs, "Sending workspace traffic:")
+	_, _ = fmt.Fprintf(logs, "\tAgent ID:       %s\n", r.cfg.AgentID)
+	_, _ = fmt.Fprintf(logs, "\tDuration:       %s\n", time.Duration(r.cfg.Duration))
+	_, _ = fmt.Fprintf(logs, "\tTick interval:  %s\n", tickInterval)
+	_, _ = fmt.Fprintf(logs, "\tBytes per tick: %d\n", bytesPerTick)
+	_, _ = fmt.Fprintf(logs, "\tSSH:            %t\n", r.cfg.SSH)
+	_, _ = fmt.Fprintf(logs, "\tPTY:            %t\n", r.cfg.ReconnectingPTY)
+	_, _ = fmt.Fprintf(logs, "\tApps:           %d\n\n", len(r.cfg.AppPaths))
+
+	trafficCtx, cancel := context.WithTimeout(ctx, time.Duration(r.cfg.Duration))
+	defer cancel()
+	eg, egCtx := errgroup.WithContext(trafficCtx)
+	if r.cfg.SSH {
+		eg.Go(func() error {
+			err := r.sshTraffic(egCtx, logger, metrics, tickInterval, bytesPerTick)
+			if err != nil {
+				return xerrors.Errorf("ssh traffic: %w", err)
+			}
+			return nil
+		})
+	}
+	if r.cfg.ReconnectingPTY {
+		eg.Go(func() error {
+			err := r.ptyTraffic(egCtx, metrics, tickInterval, bytesPerTick)
+			if err != nil {
+				return xerrors.Errorf("reconnecting pty traffic: %w", err)
+			}
+			return nil
+		})
+	}
+	if len(r.cfg.AppPaths) > 0 {
+		eg.Go(func() error {
+			r.appTraffic(egCtx, metrics, tickInterval)
+			return nil
+		})
+	}
+	err := eg.Wait()
+	if err != nil {
+		return err
+	}
+	// The traffic stops without an error when the context is canceled, so
+	// check whether the test was canceled before the duration passed.
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	_, _ = fmt.Fprintln(logs, "Done sending workspace traffic.")
+	return nil
+}
+
+// sshTraffic writes random bytes to `cat` over an SSH session on every tick,
+// and reads them back to record the round trip latency.
+func (r *Runner) sshTraffic(ctx context.Context, logger slog.Logger, metrics *harness.Metrics, tickInterval time.Duration, bytesPerTick int64) error {
+	conn, err := r.client.DialWorkspaceAgent(ctx, r.cfg.AgentID, &codersdk.DialWorkspaceAgentOptions{
+		Logger: logger.Named("agentconn"),
+	})
+	if err != nil {
+		return xerrors.Errorf("dial workspace agent: %w", err)
+	}
+	defer conn.Close()
+
+	sshClient, err := conn.SSHClient(ctx)
+	if err != nil {
+		return xerrors.Errorf("create ssh client: %w", err)
+	}
+	defer sshClient.Close()
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return xerrors.Errorf("create ssh session: %w", err)
+	}
+	defer session.Close()
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return xerrors.Errorf("get stdin pipe: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return xerrors.Errorf("get stdout pipe: %w", err)
+	}
+	err = session.Start("cat")
+	if err != nil {
+		return xerrors.Errorf("start cat: %w", err)
+	}
+	// Unblock reads when the traffic stops.
+	go func() {
+		<-ctx.Done()
+		_ = session.Close()
+	}()
+
+	var (
+		sent     = make([]byte, bytesPerTick)
+		received = make([]byte, bytesPerTick)
+		ticker   = time.NewTicker(tickInterval)
+	)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		_, err = rand.Read(sent)
+		if err != nil {
+			return xerrors.Errorf("generate random bytes: %w", err)
+		}
+		start := time.Now()
+		_, err = stdin.Write(sent)
+		if err == nil {
+			_, err = io.ReadFull(stdout, received)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			metrics.Count("ssh_errors", 1)
+			return xerrors.Errorf("echo bytes: %w", err)
+		}
+		if !bytes.Equal(sent, received) {
+			metrics.Count("ssh_errors", 1)
+			return xerrors.New("echoed bytes don't match the sent bytes")
+		}
+		metrics.Observe("ssh_echo", start, time.Since(start))
+		metrics.Count("ssh_bytes_written", bytesPerTick)
+		metrics.Count("ssh_bytes_read", bytesPerTick)
+	}
+}
+
+// ptyTraffic types an echo command with a random token into a reconnecting
+// PTY on every tick, and records the time until the token is echoed back.
+func (r *Runner) ptyTraffic(ctx context.Context, metrics *harness.Metrics, tickInterval time.Duration, bytesPerTick int64) error {
+	conn, err := r.client.WorkspaceAgentReconnectingPTY(ctx, r.cfg.AgentID, uuid.New(), 24, 80, "")
+	if err != nil {
+		return xerrors.Errorf("open reconnecting pty: %w", err)
+	}
+	defer conn.Close()
+	// Unblock reads when the traffic stops.
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	const prefix = "echo "
+	tokenLength := int(bytesPerTick) - len(prefix) - 1
+	if tokenLength < 1 {
+		tokenLength = 1
+	}
+	var (
+		encoder = json.NewEncoder(conn)
+		buf     = make([]byte, 32*1024)
+		ticker  = time.NewTicker(tickInterval)
+	)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		token, err := cryptorand.String(tokenLength)
+		if err != nil {
+			return xerrors.Errorf("generate token: %w", err)
+		}
+		data := prefix + token + "\r"
+		start := time.Now()
+		err = encoder.Encode(codersdk.ReconnectingPTYRequest{
+			Data: data,
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			metrics.Count("pty_errors", 1)
+			return xerrors.Errorf("write to pty: %w", err)
+		}
+		metrics.Count("pty_bytes_written", int64(len(data)))
+
+		// Keep the end of the previous read, in case the token is split
+		// across reads.
+		var output []byte
+		for !bytes.Contains(output, []byte(token)) {
+			if len(output) > len(token) {
+				output = output[len(output)-len(token):]
+			}
+			n, err := conn.Read(buf)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				metrics.Count("pty_errors", 1)
+				return xerrors.Errorf("read from pty: %w", err)
+			}
+			metrics.Count("pty_bytes_read", int64(n))
+			output = append(output, buf[:n]...)
+		}
+		metrics.Observe("pty_type", start, time.Since(start))
+	}
+}
+
+// appTraffic sends a request to each app on every tick. Requests are sent one
+// at a time, so ticks are skipped when the apps respond slower than the tick
+// interval.
+func (r *Runner) appTraffic(ctx context.Context, metrics *harness.Metrics, tickInterval time.Duration) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, path := range r.cfg.AppPaths {
+			start := time.Now()
+			res, err := r.client.Request(ctx, http.MethodGet, path, nil)
+			if ctx.Err() != nil {
+				return
+			}
+			metrics.Count("app_requests", 1)
+			if err != nil {
+				metrics.Count("app_errors", 1)
+				continue
+			}
+			n, _ := io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+			metrics.Count("app_bytes_read", n)
+			if res.StatusCode >= http.StatusBadRequest {
+				metrics.Count("app_errors", 1)
+				continue
+			}
+			metrics.Observe("app_request", start, time.Since(start))
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package workspacetraffic_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/scaletest/harness"
+	"github.com/coder/coder/scaletest/workspacetraffic"
+	"github.com/coder/coder/testutil"
+)
+
+func Test_Runner(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("The SSH traffic echoes through cat, which isn't available on Windows")
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("hello"))
+		}))
+		t.Cleanup(app.Close)
+
+		client, agentID, appPath := setupRunnerTest(t, app.URL)
+
+		runner := workspacetraffic.NewRunner(client, workspacetraffic.Config{
+			AgentID:         agentID,
+			Duration:        httpapi.Duration(3 * time.Second),
+			TickInterval:    httpapi.Duration(100 * time.Millisecond),
+			BytesPerTick:    128,
+			SSH:             true,
+			ReconnectingPTY: true,
+			AppPaths:        []string{appPath},
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitSuperLong)
+		defer cancel()
+		metrics := new(harness.Metrics)
+		ctx = harness.WithMetrics(ctx, metrics)
+
+		logs := bytes.NewBuffer(nil)
+		err := runner.Run(ctx, "1", logs)
+		t.Log("Runner logs:\n\n" + logs.String())
+		require.NoError(t, err)
+
+		phases := map[string]int{}
+		for _, timing := range metrics.Phases() {
+			phases[timing.Phase]++
+		}
+		require.Greater(t, phases["ssh_echo"], 0)
+		require.Greater(t, phases["pty_type"], 0)
+		require.Greater(t, phases["app_request"], 0)
+
+		counters := metrics.Counters()
+		require.EqualValues(t, int64(phases["ssh_echo"])*128, counters["ssh_bytes_read"])
+		require.Greater(t, counters["pty_bytes_read"], int64(0))
+		require.Greater(t, counters["app_requests"], int64(0))
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		t.Parallel()
+
+		client, agentID, _ := setupRunnerTest(t, "")
+
+		runner := workspacetraffic.NewRunner(client, workspacetraffic.Config{
+			AgentID:  agentID,
+			Duration: httpapi.Duration(time.Hour),
+			SSH:      true,
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		err := runner.Run(ctx, "1", bytes.NewBuffer(nil))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// setupRunnerTest creates a workspace with a running agent. If appURL is set,
+// the agent has an app proxied to it, and the path of the app is returned.
+func setupRunnerTest(t *testing.T, appURL string) (client *codersdk.Client, agentID uuid.UUID, appPath string) {
+	t.Helper()
+
+	client = coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+
+	authToken := uuid.NewString()
+	apps := []*proto.App{}
+	if appURL != "" {
+		apps = append(apps, &proto.App{
+			Slug:         "app",
+			DisplayName:  "app",
+			SharingLevel: proto.AppSharingLevel_OWNER,
+			Url:          appURL,
+		})
+	}
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:   uuid.NewString(),
+							Name: "agent",
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							Apps: apps,
+						}},
+					}},
+				},
+			},
+		}},
+	})
+
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+	appPath = fmt.Sprintf("/@%s/%s.%s/apps/app/", coderdtest.FirstUserParams.Username, workspace.Name, "agent")
+	return client, resources[0].Agents[0].ID, appPath
+}
@@ -73,15 +73,19 @@ export const provisioners: TypesGen.ProvisionerDaemon[] = [
     id: "terraform",
     name: "Terraform",
     created_at: "",
+    version: "",
     provisioners: [],
     tags: {},
+    status: "idle",
   },
   {
     id: "cdr-basic",
     name: "Basic",
     created_at: "",
+    version: "",
     provisioners: [],
     tags: {},
+    status: "idle",
   },
 ]
 
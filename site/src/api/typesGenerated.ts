@@ -62,6 +62,19 @@ export interface AuditDiffField {
   readonly secret: boolean
 }
 
+// From codersdk/deployment.go
+export interface AuditExportConfig {
+  readonly webhook_url: string
+  readonly webhook_secret: string
+  readonly webhook_batch_size: number
+  readonly webhook_flush_interval: number
+  readonly webhook_buffer_dir: string
+  readonly file_path: string
+  readonly file_max_size: number
+  readonly file_max_backups: number
+  readonly syslog_url: string
+}
+
 // From codersdk/audit.go
 export interface AuditLog {
   readonly id: string
@@ -165,6 +178,16 @@ export interface CreateGroupRequest {
   readonly quota_allowance: number
 }
 
+// From codersdk/notifications.go
+export interface CreateNotificationWebhookRequest {
+  readonly name: string
+  readonly url: string
+  readonly events: NotificationEventType[]
+  readonly payload_template: string
+  readonly secret: string
+  readonly enabled?: boolean
+}
+
 // From codersdk/users.go
 export interface CreateOrganizationRequest {
   readonly name: string
@@ -189,6 +212,8 @@ export interface CreateTemplateRequest {
   readonly parameter_values?: CreateParameterRequest[]
   readonly default_ttl_ms?: number
   readonly max_ttl_ms?: number
+  readonly inactivity_ttl_ms?: number
+  readonly delete_ttl_ms?: number
   readonly allow_user_cancel_workspace_jobs?: boolean
 }
 
@@ -198,6 +223,13 @@ export interface CreateTemplateVersionDryRunRequest {
   readonly parameter_values: CreateParameterRequest[]
   readonly rich_parameter_values: WorkspaceBuildParameter[]
   readonly user_variable_values?: VariableValue[]
+  readonly workspace_id?: string
+}
+
+// From codersdk/templateversionpromotions.go
+export interface CreateTemplateVersionPromotionRequest {
+  readonly template_version_id: string
+  readonly rich_parameter_values?: WorkspaceBuildParameter[]
 }
 
 // From codersdk/organizations.go
@@ -251,6 +283,14 @@ export interface CreateWorkspaceBuildRequest {
   readonly log_level?: ProvisionerLogLevel
 }
 
+// From codersdk/workspacebulkjobs.go
+export interface CreateWorkspaceBulkJobRequest {
+  readonly action: WorkspaceBulkJobAction
+  readonly filter: string
+  readonly batch_size?: number
+  readonly dry_run?: boolean
+}
+
 // From codersdk/organizations.go
 export interface CreateWorkspaceRequest {
   readonly template_id: string
@@ -307,6 +347,12 @@ export interface DeploymentDAUsResponse {
   readonly entries: DAUEntry[]
 }
 
+// From codersdk/deployment.go
+export interface DeploymentPurgeStats {
+  readonly collected_at: string
+  readonly tables: PurgeTableStats[]
+}
+
 // From codersdk/deployment.go
 export interface DeploymentStats {
   readonly aggregated_from: string
@@ -369,6 +415,9 @@ export interface DeploymentValues {
   readonly git_auth?: any
   readonly config_ssh?: SSHConfig
   readonly wgtunnel_host?: string
+  readonly retention?: RetentionConfig
+  readonly audit_export?: AuditExportConfig
+  readonly session_recording?: boolean
   readonly config?: string
   readonly write_config?: boolean
   // Named type "github.com/coder/coder/cli/clibase.HostPort" unknown, using "any"
@@ -481,6 +530,75 @@ export interface LoginWithPasswordResponse {
   readonly session_token: string
 }
 
+// From codersdk/notifications.go
+export interface NotificationEvent {
+  readonly id: string
+  readonly type: NotificationEventType
+  readonly created_at: string
+  readonly workspace?: NotificationEventWorkspace
+  readonly build?: NotificationEventWorkspaceBuild
+  readonly template?: NotificationEventTemplate
+  readonly template_version?: NotificationEventTemplateVersion
+  readonly deadline?: string
+}
+
+// From codersdk/notifications.go
+export interface NotificationEventTemplate {
+  readonly id: string
+  readonly name: string
+}
+
+// From codersdk/notifications.go
+export interface NotificationEventTemplateVersion {
+  readonly id: string
+  readonly name: string
+}
+
+// From codersdk/notifications.go
+export interface NotificationEventWorkspace {
+  readonly id: string
+  readonly name: string
+  readonly owner_id: string
+  readonly owner_name: string
+}
+
+// From codersdk/notifications.go
+export interface NotificationEventWorkspaceBuild {
+  readonly id: string
+  readonly build_number: number
+  readonly transition: WorkspaceTransition
+  readonly reason: BuildReason
+  readonly status: ProvisionerJobStatus
+  readonly error?: string
+}
+
+// From codersdk/notifications.go
+export interface NotificationWebhook {
+  readonly id: string
+  readonly created_at: string
+  readonly updated_at: string
+  readonly name: string
+  readonly url: string
+  readonly events: NotificationEventType[]
+  readonly payload_template: string
+  readonly has_secret: boolean
+  readonly enabled: boolean
+}
+
+// From codersdk/notifications.go
+export interface NotificationWebhookDelivery {
+  readonly id: string
+  readonly webhook_id: string
+  readonly event_id: string
+  readonly event_type: NotificationEventType
+  readonly attempt: number
+  readonly created_at: string
+  readonly completed_at?: string
+  readonly request_body: string
+  readonly status_code: number
+  readonly error: string
+}
+
 // From codersdk/deployment.go
 export interface OAuth2Config {
   readonly github: OAuth2GithubConfig
@@ -525,6 +643,14 @@ export interface OIDCConfig {
   // Named type "github.com/coder/coder/cli/clibase.Struct[map[string]string]" unknown, using "any"
   // eslint-disable-next-line @typescript-eslint/no-explicit-any -- External type
   readonly group_mapping: any
+  readonly group_auto_create: boolean
+  readonly group_regex_filter: string
+  readonly user_role_field: string
+  // Named type "github.com/coder/coder/cli/clibase.Struct[map[string][]string]" unknown, using "any"
+  // eslint-disable-next-line @typescript-eslint/no-explicit-any -- External type
+  readonly user_role_mapping: any
+  // This is likely an enum in an external package ("github.com/coder/coder/cli/clibase.StringArray")
+  readonly user_roles_default: string[]
   readonly sign_in_text: string
   readonly icon_url: string
 }
@@ -595,6 +721,16 @@ export interface PatchGroupRequest {
   readonly quota_allowance?: number
 }
 
+// From codersdk/notifications.go
+export interface PatchNotificationWebhookRequest {
+  readonly name?: string
+  readonly url?: string
+  readonly events: NotificationEventType[]
+  readonly payload_template?: string
+  readonly secret?: string
+  readonly enabled?: boolean
+}
+
 // From codersdk/templateversions.go
 export interface PatchTemplateVersionRequest {
   readonly name: string
@@ -622,6 +758,11 @@ export interface ProvisionerConfig {
   readonly daemon_poll_interval: number
   readonly daemon_poll_jitter: number
   readonly force_cancel_interval: number
+  readonly daemon_psk: string
+  // This is likely an enum in an external package ("github.com/coder/coder/cli/clibase.StringArray")
+  readonly plugins: string[]
+  readonly cache_max_size: number
+  readonly cache_mirror: string
 }
 
 // From codersdk/provisionerdaemons.go
@@ -629,9 +770,13 @@ export interface ProvisionerDaemon {
   readonly id: string
   readonly created_at: string
   readonly updated_at?: string
+  readonly last_seen_at?: string
   readonly name: string
+  readonly version: string
   readonly provisioners: ProvisionerType[]
   readonly tags: Record<string, string>
+  readonly current_job_id?: string
+  readonly status: ProvisionerDaemonStatus
 }
 
 // From codersdk/provisionerdaemons.go
@@ -659,15 +804,55 @@ export interface ProvisionerJobLog {
   readonly output: string
 }
 
+// From codersdk/organizations.go
+export interface ProvisionerPlugin {
+  readonly type: ProvisionerType
+  readonly path: string
+}
+
+// From codersdk/deployment.go
+export interface PurgeTableStats {
+  readonly table: string
+  readonly retention_ms: number
+  readonly before: string
+  readonly rows: number
+}
+
 // From codersdk/workspaces.go
 export interface PutExtendWorkspaceRequest {
   readonly deadline: string
 }
 
+// From codersdk/provisionerdaemons.go
+export interface QueuedProvisionerJob {
+  readonly id: string
+  readonly created_at: string
+  readonly type: ProvisionerJobType
+  readonly provisioner: ProvisionerType
+  readonly initiator_id: string
+  readonly tags: Record<string, string>
+  readonly priority: number
+  readonly queue_position: number
+  readonly available_workers: string[]
+}
+
 // From codersdk/deployment.go
 export interface RateLimitConfig {
   readonly disable_all: boolean
   readonly api: number
+  readonly shared: boolean
+  // Named type "github.com/coder/coder/cli/clibase.Struct[[]github.com/coder/coder/codersdk.RateLimitPolicy]" unknown, using "any"
+  // eslint-disable-next-line @typescript-eslint/no-explicit-any -- External type
+  readonly policies: any
+}
+
+// From codersdk/deployment.go
+export interface RateLimitPolicy {
+  readonly name: string
+  readonly limit: number
+  // This is likely an enum in an external package ("time.Duration")
+  readonly window: number
+  readonly role_limits: Record<string, number>
 }
 
 // From codersdk/replicas.go
@@ -688,6 +873,14 @@ export interface Response {
   readonly validations?: ValidationError[]
 }
 
+// From codersdk/deployment.go
+export interface RetentionConfig {
+  readonly audit_logs: number
+  readonly provisioner_job_logs: number
+  readonly workspace_build_states: number
+  readonly purge_batch_size: number
+}
+
 // From codersdk/roles.go
 export interface Role {
   readonly name: string
@@ -707,6 +900,14 @@ export interface SSHConfigResponse {
   readonly ssh_config_options: Record<string, string>
 }
 
+// From codersdk/provisionerdaemons.go
+export interface ServeProvisionerDaemonRequest {
+  readonly organization: string
+  readonly provisioners: ProvisionerType[]
+  readonly tags: Record<string, string>
+  readonly pre_shared_key: string
+}
+
 // From codersdk/serversentevents.go
 export interface ServerSentEvent {
   readonly type: ServerSentEventType
@@ -783,9 +984,13 @@ export interface Template {
   readonly icon: string
   readonly default_ttl_ms: number
   readonly max_ttl_ms: number
+  readonly inactivity_ttl_ms: number
+  readonly delete_ttl_ms: number
+  readonly autostop_requirement: string
   readonly created_by_id: string
   readonly created_by_name: string
   readonly allow_user_cancel_workspace_jobs: boolean
+  readonly required_promotion_approvals: number
 }
 
 // From codersdk/templates.go
@@ -839,6 +1044,13 @@ export interface TemplateVersion {
   readonly created_by: User
 }
 
+// From codersdk/templateversions.go
+export interface TemplateVersionDryRunChanges {
+  readonly changes: WorkspaceResourceChange[]
+  readonly daily_cost: number
+  readonly prior_daily_cost: number
+}
+
 // From codersdk/templateversions.go
 export interface TemplateVersionGitAuth {
   readonly id: string
@@ -874,6 +1086,22 @@ export interface TemplateVersionParameterOption {
   readonly icon: string
 }
 
+// From codersdk/templateversionpromotions.go
+export interface TemplateVersionPromotion {
+  readonly id: string
+  readonly created_at: string
+  readonly updated_at: string
+  readonly template_id: string
+  readonly template_version_id: string
+  readonly template_version_name: string
+  readonly requester_id: string
+  readonly status: TemplateVersionPromotionStatus
+  readonly promoted_at?: string
+  readonly required_approvals: number
+  readonly approver_ids: string[]
+  readonly dry_run: ProvisionerJob
+}
+
 // From codersdk/templateversions.go
 export interface TemplateVersionVariable {
   readonly name: string
@@ -951,7 +1179,11 @@ export interface UpdateTemplateMeta {
   readonly icon?: string
   readonly default_ttl_ms?: number
   readonly max_ttl_ms?: number
+  readonly inactivity_ttl_ms?: number
+  readonly delete_ttl_ms?: number
+  readonly autostop_requirement?: string
   readonly allow_user_cancel_workspace_jobs?: boolean
+  readonly required_promotion_approvals?: number
 }
 
 // From codersdk/users.go
@@ -985,6 +1217,12 @@ export interface UploadResponse {
   readonly hash: string
 }
 
+// From codersdk/workspaceagentportshares.go
+export interface UpsertWorkspaceAgentPortShareRequest {
+  readonly port: number
+  readonly share_level: WorkspaceAppSharingLevel
+}
+
 // From codersdk/users.go
 export interface User {
   readonly id: string
@@ -1040,6 +1278,8 @@ export interface Workspace {
   readonly autostart_schedule?: string
   readonly ttl_ms?: number
   readonly last_used_at: string
+  readonly dormant_at?: string
+  readonly deleting_at?: string
 }
 
 // From codersdk/workspaceagents.go
@@ -1072,6 +1312,25 @@ export interface WorkspaceAgent {
   readonly startup_script_timeout_seconds: number
   readonly shutdown_script?: string
   readonly shutdown_script_timeout_seconds: number
+  readonly reconnecting_pty_buffer_size: number
+  readonly session_recording: boolean
+  readonly metadata?: WorkspaceAgentMetadata[]
+}
+
+// From codersdk/workspaceagentconn.go
+export interface WorkspaceAgentFileInfo {
+  readonly name: string
+  readonly path: string
+  readonly size: number
+  readonly mode: number
+  readonly mod_time: string
+  readonly is_dir: boolean
+}
+
+// From codersdk/workspaceagentconn.go
+export interface WorkspaceAgentListFilesResponse {
+  readonly path: string
+  readonly files: WorkspaceAgentFileInfo[]
 }
 
 // From codersdk/workspaceagentconn.go
@@ -1079,6 +1338,7 @@ export interface WorkspaceAgentListeningPort {
   readonly process_name: string
   readonly network: string
   readonly port: number
+  readonly share_level?: WorkspaceAppSharingLevel
 }
 
 // From codersdk/workspaceagentconn.go
@@ -1086,6 +1346,42 @@ export interface WorkspaceAgentListeningPortsResponse {
   readonly ports: WorkspaceAgentListeningPort[]
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadata {
+  readonly result: WorkspaceAgentMetadataResult
+  readonly description: WorkspaceAgentMetadataDescription
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadataDescription {
+  readonly display_name: string
+  readonly key: string
+  readonly script: string
+  readonly interval: number
+  readonly timeout: number
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadataResult {
+  readonly collected_at: string
+  readonly age: number
+  readonly value: string
+  readonly error: string
+}
+
+// From codersdk/workspaceagentportshares.go
+export interface WorkspaceAgentPortShare {
+  readonly port: number
+  readonly share_level: WorkspaceAppSharingLevel
+  readonly source: WorkspaceAgentPortShareSource
+}
+
+// From codersdk/workspaceagentportshares.go
+export interface WorkspaceAgentPortShares {
+  readonly restricted: boolean
+  readonly shares: WorkspaceAgentPortShare[]
+}
+
 // From codersdk/workspaceagents.go
 export interface WorkspaceAgentStartupLog {
   readonly id: number
@@ -1144,6 +1440,42 @@ export interface WorkspaceBuildsRequest extends Pagination {
   readonly Since: string
 }
 
+// From codersdk/workspacebulkjobs.go
+export interface WorkspaceBulkJob {
+  readonly id: string
+  readonly created_at: string
+  readonly updated_at: string
+  readonly completed_at?: string
+  readonly initiator_id: string
+  readonly action: WorkspaceBulkJobAction
+  readonly filter: string
+  readonly batch_size: number
+  readonly status: WorkspaceBulkJobStatus
+  readonly error?: string
+  readonly progress: WorkspaceBulkJobProgress
+  readonly workspaces: WorkspaceBulkJobWorkspace[]
+}
+
+// From codersdk/workspacebulkjobs.go
+export interface WorkspaceBulkJobProgress {
+  readonly total: number
+  readonly pending: number
+  readonly running: number
+  readonly succeeded: number
+  readonly failed: number
+  readonly skipped: number
+}
+
+// From codersdk/workspacebulkjobs.go
+export interface WorkspaceBulkJobWorkspace {
+  readonly workspace_id: string
+  readonly workspace_name: string
+  readonly owner_name: string
+  readonly workspace_build_id?: string
+  readonly status: WorkspaceBulkJobStatus
+  readonly error?: string
+}
+
 // From codersdk/deployment.go
 export interface WorkspaceConnectionLatencyMS {
   readonly P50: number
@@ -1193,6 +1525,16 @@ export interface WorkspaceResource {
   readonly daily_cost: number
 }
 
+// From codersdk/templateversions.go
+export interface WorkspaceResourceChange {
+  readonly address: string
+  readonly type: string
+  readonly name: string
+  readonly action: WorkspaceResourceChangeAction
+  readonly daily_cost: number
+  readonly prior_daily_cost: number
+}
+
 // From codersdk/workspacebuilds.go
 export interface WorkspaceResourceMetadata {
   readonly key: string
@@ -1200,6 +1542,18 @@ export interface WorkspaceResourceMetadata {
   readonly sensitive: boolean
 }
 
+// From codersdk/workspacesessionrecordings.go
+export interface WorkspaceSessionRecording {
+  readonly id: string
+  readonly created_at: string
+  readonly workspace_id: string
+  readonly agent_id: string
+  readonly type: WorkspaceSessionType
+  readonly started_at: string
+  readonly ended_at: string
+  readonly size: number
+}
+
 // From codersdk/workspaces.go
 export interface WorkspacesRequest extends Pagination {
   readonly q?: string
@@ -1235,10 +1589,17 @@ export const AuditActions: AuditAction[] = [
 ]
 
 // From codersdk/workspacebuilds.go
-export type BuildReason = "autostart" | "autostop" | "initiator"
+export type BuildReason =
+  | "autodelete"
+  | "autostart"
+  | "autostop"
+  | "dormancy"
+  | "initiator"
 export const BuildReasons: BuildReason[] = [
+  "autodelete",
   "autostart",
   "autostop",
+  "dormancy",
   "initiator",
 ]
 
@@ -1309,6 +1670,21 @@ export const LogSources: LogSource[] = ["provisioner", "provisioner_daemon"]
 export type LoginType = "github" | "oidc" | "password" | "token"
 export const LoginTypes: LoginType[] = ["github", "oidc", "password", "token"]
 
+// From codersdk/notifications.go
+export type NotificationEventType =
+  | "template_version_promoted"
+  | "workspace_autostop_imminent"
+  | "workspace_build_failed"
+  | "workspace_build_succeeded"
+  | "workspace_deleted"
+export const NotificationEventTypes: NotificationEventType[] = [
+  "template_version_promoted",
+  "workspace_autostop_imminent",
+  "workspace_build_failed",
+  "workspace_build_succeeded",
+  "workspace_deleted",
+]
+
 // From codersdk/parameters.go
 export type ParameterDestinationScheme =
   | "environment_variable"
@@ -1336,6 +1712,14 @@ export const ParameterSourceSchemes: ParameterSourceScheme[] = ["data", "none"]
 export type ParameterTypeSystem = "hcl" | "none"
 export const ParameterTypeSystems: ParameterTypeSystem[] = ["hcl", "none"]
 
+// From codersdk/provisionerdaemons.go
+export type ProvisionerDaemonStatus = "busy" | "idle" | "offline"
+export const ProvisionerDaemonStatuses: ProvisionerDaemonStatus[] = [
+  "busy",
+  "idle",
+  "offline",
+]
+
 // From codersdk/provisionerdaemons.go
 export type ProvisionerJobStatus =
   | "canceled"
@@ -1353,6 +1737,17 @@ export const ProvisionerJobStatuses: ProvisionerJobStatus[] = [
   "succeeded",
 ]
 
+// From codersdk/provisionerdaemons.go
+export type ProvisionerJobType =
+  | "template_version_dry_run"
+  | "template_version_import"
+  | "workspace_build"
+export const ProvisionerJobTypes: ProvisionerJobType[] = [
+  "template_version_dry_run",
+  "template_version_import",
+  "workspace_build",
+]
+
 // From codersdk/workspaces.go
 export type ProvisionerLogLevel = "debug"
 export const ProvisionerLogLevels: ProvisionerLogLevel[] = ["debug"]
@@ -1373,9 +1768,11 @@ export type ResourceType =
   | "license"
   | "template"
   | "template_version"
+  | "template_version_promotion"
   | "user"
   | "workspace"
   | "workspace_build"
+  | "workspace_session_recording"
 export const ResourceTypes: ResourceType[] = [
   "api_key",
   "git_ssh_key",
@@ -1383,9 +1780,11 @@ export const ResourceTypes: ResourceType[] = [
   "license",
   "template",
   "template_version",
+  "template_version_promotion",
   "user",
   "workspace",
   "workspace_build",
+  "workspace_session_recording",
 ]
 
 // From codersdk/serversentevents.go
@@ -1400,6 +1799,17 @@ export const ServerSentEventTypes: ServerSentEventType[] = [
 export type TemplateRole = "" | "admin" | "use"
 export const TemplateRoles: TemplateRole[] = ["", "admin", "use"]
 
+// From codersdk/templateversionpromotions.go
+export type TemplateVersionPromotionStatus =
+  | "pending"
+  | "promoted"
+  | "superseded"
+export const TemplateVersionPromotionStatuses: TemplateVersionPromotionStatus[] = [
+  "pending",
+  "promoted",
+  "superseded",
+]
+
 // From codersdk/users.go
 export type UserStatus = "active" | "suspended"
 export const UserStatuses: UserStatus[] = ["active", "suspended"]
@@ -1434,6 +1844,13 @@ export const WorkspaceAgentLifecycles: WorkspaceAgentLifecycle[] = [
   "starting",
 ]
 
+// From codersdk/workspaceagentportshares.go
+export type WorkspaceAgentPortShareSource = "owner" | "template"
+export const WorkspaceAgentPortShareSources: WorkspaceAgentPortShareSource[] = [
+  "owner",
+  "template",
+]
+
 // From codersdk/workspaceagents.go
 export type WorkspaceAgentStatus =
   | "connected"
@@ -1468,6 +1885,50 @@ export const WorkspaceAppSharingLevels: WorkspaceAppSharingLevel[] = [
   "public",
 ]
 
+// From codersdk/workspacebulkjobs.go
+export type WorkspaceBulkJobAction = "delete" | "start" | "stop" | "update"
+export const WorkspaceBulkJobActions: WorkspaceBulkJobAction[] = [
+  "delete",
+  "start",
+  "stop",
+  "update",
+]
+
+// From codersdk/workspacebulkjobs.go
+export type WorkspaceBulkJobStatus =
+  | "failed"
+  | "pending"
+  | "running"
+  | "skipped"
+  | "succeeded"
+export const WorkspaceBulkJobStatuses: WorkspaceBulkJobStatus[] = [
+  "failed",
+  "pending",
+  "running",
+  "skipped",
+  "succeeded",
+]
+
+// From codersdk/templateversions.go
+export type WorkspaceResourceChangeAction =
+  | "create"
+  | "delete"
+  | "replace"
+  | "update"
+export const WorkspaceResourceChangeActions: WorkspaceResourceChangeAction[] = [
+  "create",
+  "delete",
+  "replace",
+  "update",
+]
+
+// From codersdk/workspacesessionrecordings.go
+export type WorkspaceSessionType = "reconnecting_pty" | "ssh"
+export const WorkspaceSessionTypes: WorkspaceSessionType[] = [
+  "reconnecting_pty",
+  "ssh",
+]
+
 // From codersdk/workspacebuilds.go
 export type WorkspaceStatus =
   | "canceled"
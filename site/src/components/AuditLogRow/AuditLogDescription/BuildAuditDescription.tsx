@@ -17,7 +17,12 @@ export const BuildAuditDescription: FC<{ auditLog: AuditLog }> = ({
       ? "Coder automatically"
       : auditLog.user?.username.trim()
 
-  const action = auditLog.action === "start" ? "started" : "stopped"
+  const action =
+    auditLog.action === "start"
+      ? "started"
+      : auditLog.action === "delete"
+      ? "deleted"
+      : "stopped"
 
   if (auditLog.resource_link) {
     return (
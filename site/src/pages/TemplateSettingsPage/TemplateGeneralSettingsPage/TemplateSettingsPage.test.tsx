@@ -36,7 +36,16 @@ const fillAndSubmitForm = async ({
   description,
   icon,
   allow_user_cancel_workspace_jobs,
-}: Required<Omit<UpdateTemplateMeta, "default_ttl_ms" | "max_ttl_ms">>) => {
+}: Required<
+  Omit<
+    UpdateTemplateMeta,
+    | "default_ttl_ms"
+    | "max_ttl_ms"
+    | "inactivity_ttl_ms"
+    | "delete_ttl_ms"
+    | "autostop_requirement"
+  >
+>) => {
   const label = t("nameLabel", { ns: "templateSettingsPage" })
   const nameField = await screen.findByLabelText(label)
   await userEvent.clear(nameField)
@@ -21,20 +21,16 @@ export const Language = {
   websocketErrorMessagePrefix: "WebSocket failed: ",
 }
 
-const useReloading = (isDisconnected: boolean) => {
-  const [status, setStatus] = useState<"reloading" | "notReloading">(
-    "notReloading",
-  )
-
-  // Retry connection on key press when it is disconnected
+// Retry connection on key press when it is disconnected. Unlike reloading
+// the page, reconnecting resumes the output where it stopped.
+const useReconnecting = (isDisconnected: boolean, reconnect: () => void) => {
   useEffect(() => {
     if (!isDisconnected) {
       return
     }
 
     const keyDownHandler = () => {
-      setStatus("reloading")
-      window.location.reload()
+      reconnect()
     }
 
     document.addEventListener("keydown", keyDownHandler)
@@ -42,11 +38,7 @@ const useReloading = (isDisconnected: boolean) => {
     return () => {
       document.removeEventListener("keydown", keyDownHandler)
     }
-  }, [isDisconnected])
-
-  return {
-    status,
-  }
+  }, [isDisconnected, reconnect])
 }
 
 const TerminalPage: FC<
@@ -99,7 +91,13 @@ const TerminalPage: FC<
     websocketError,
     applicationsHost,
   } = terminalState.context
-  const reloading = useReloading(isDisconnected)
+  const reconnect = useCallback(() => {
+    sendEvent({ type: "CONNECT" })
+  }, [sendEvent])
+  useReconnecting(isDisconnected, reconnect)
+  // Output is only cleared on the first connection, reconnecting resumes
+  // after the output the terminal already shows.
+  const hasConnected = useRef(false)
 
   // handleWebLink handles opening of URLs in the terminal!
   const handleWebLink = useCallback(
@@ -253,9 +251,12 @@ const TerminalPage: FC<
       return
     }
 
-    // The terminal should be cleared on each reconnect
-    // because all data is re-rendered from the backend.
-    terminal.clear()
+    // The terminal should be cleared on the first connection
+    // because all buffered data is rendered from the backend.
+    if (!hasConnected.current) {
+      terminal.clear()
+      hasConnected.current = true
+    }
 
     // Focusing on connection allows users to reload the
     // page and start typing immediately.
@@ -298,16 +299,10 @@ const TerminalPage: FC<
       {/* This overlay makes it more obvious that the terminal is disconnected. */}
       {/* It's nice for situations where Coder restarts, and they are temporarily disconnected. */}
       <div className={`${styles.overlay} ${isDisconnected ? "" : "connected"}`}>
-        {reloading.status === "reloading" ? (
-          <span className={styles.overlayText}>Reloading...</span>
-        ) : (
-          <Stack spacing={0.5} alignItems="center">
-            <span className={styles.overlayText}>Disconnected</span>
-            <span className={styles.overlaySubtext}>
-              Press any key to retry
-            </span>
-          </Stack>
-        )}
+        <Stack spacing={0.5} alignItems="center">
+          <span className={styles.overlayText}>Disconnected</span>
+          <span className={styles.overlaySubtext}>Press any key to retry</span>
+        </Stack>
       </div>
       <div className={styles.terminal} ref={xtermRef} data-testid="terminal" />
     </>
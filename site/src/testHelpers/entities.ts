@@ -197,8 +197,10 @@ export const MockProvisioner: TypesGen.ProvisionerDaemon = {
   created_at: "",
   id: "test-provisioner",
   name: "Test Provisioner",
+  version: "",
   provisioners: ["echo"],
   tags: {},
+  status: "idle",
 }
 
 export const MockProvisionerJob: TypesGen.ProvisionerJob = {
@@ -292,10 +294,14 @@ export const MockTemplate: TypesGen.Template = {
   description: "This is a test description.",
   default_ttl_ms: 24 * 60 * 60 * 1000,
   max_ttl_ms: 2 * 24 * 60 * 60 * 1000,
+  inactivity_ttl_ms: 0,
+  delete_ttl_ms: 0,
+  autostop_requirement: "",
   created_by_id: "test-creator-id",
   created_by_name: "test_creator",
   icon: "/icon/code.svg",
   allow_user_cancel_workspace_jobs: true,
+  required_promotion_approvals: 0,
 }
 
 export const MockTemplateVersionFiles: TemplateVersionFiles = {
@@ -409,6 +415,8 @@ export const MockWorkspaceAgent: TypesGen.WorkspaceAgent = {
   startup_logs_overflowed: false,
   startup_script_timeout_seconds: 120,
   shutdown_script_timeout_seconds: 120,
+  reconnecting_pty_buffer_size: 0,
+  session_recording: false,
 }
 
 export const MockWorkspaceAgentDisconnected: TypesGen.WorkspaceAgent = {
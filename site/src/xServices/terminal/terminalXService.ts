@@ -3,6 +3,15 @@ import * as API from "../../api/api"
 import * as Types from "../../api/types"
 import * as TypesGen from "../../api/typesGenerated"
 
+// The agent starts the output with the offset of its first byte, encoded as
+// a little-endian uint64.
+const offsetSize = 8
+
+const readOffset = (data: Uint8Array): number => {
+  const view = new DataView(data.buffer, data.byteOffset, offsetSize)
+  return view.getUint32(0, true) + view.getUint32(4, true) * 2 ** 32
+}
+
 export interface TerminalContext {
   workspaceError?: Error | unknown
   workspace?: TypesGen.Workspace
@@ -20,6 +29,9 @@ export interface TerminalContext {
   workspaceName?: string
   reconnection?: string
   command?: string
+  // The offset of the next byte of output. Reconnecting resumes the output
+  // from here, so nothing is written to the terminal twice.
+  offset?: number
 }
 
 export type TerminalEvent =
@@ -32,6 +44,7 @@ export type TerminalEvent =
     }
   | { type: "WRITE"; request: Types.ReconnectingPTYRequest }
   | { type: "READ"; data: ArrayBuffer }
+  | { type: "OFFSET"; offset: number }
   | { type: "DISCONNECT" }
 
 export const terminalMachine =
@@ -158,7 +171,10 @@ export const terminalMachine =
               actions: "sendMessage",
             },
             READ: {
-              actions: "readMessage",
+              actions: ["readMessage", "incrementOffset"],
+            },
+            OFFSET: {
+              actions: "assignOffset",
             },
             DISCONNECT: {
               actions: "disconnect",
@@ -170,7 +186,7 @@ export const terminalMachine =
           on: {
             CONNECT: {
               actions: "assignConnection",
-              target: "gettingWorkspaceAgent",
+              target: "setup",
             },
           },
         },
@@ -222,7 +238,7 @@ export const terminalMachine =
             const commandQuery = context.command
               ? `&command=${encodeURIComponent(context.command)}`
               : ""
-            const url = `${proto}//${location.host}/api/v2/workspaceagents/${context.workspaceAgent.id}/pty?reconnect=${context.reconnection}${commandQuery}`
+            const url = `${proto}//${location.host}/api/v2/workspaceagents/${context.workspaceAgent.id}/pty?reconnect=${context.reconnection}&offset=${context.offset ?? 0}${commandQuery}`
             const socket = new WebSocket(url)
             socket.binaryType = "arraybuffer"
             socket.addEventListener("open", () => {
@@ -236,10 +252,32 @@ export const terminalMachine =
                 type: "DISCONNECT",
               })
             })
+            // Holds the start of the output until the offset has been read.
+            let header: Uint8Array | undefined = new Uint8Array(0)
             socket.addEventListener("message", (event) => {
+              let data = event.data
+              // Strings are only sent when testing, and have no offset.
+              if (header && data instanceof ArrayBuffer) {
+                const bytes = new Uint8Array(header.length + data.byteLength)
+                bytes.set(header)
+                bytes.set(new Uint8Array(data), header.length)
+                if (bytes.length < offsetSize) {
+                  header = bytes
+                  return
+                }
+                header = undefined
+                send({
+                  type: "OFFSET",
+                  offset: readOffset(bytes),
+                })
+                data = bytes.slice(offsetSize).buffer
+                if (data.byteLength === 0) {
+                  return
+                }
+              }
               send({
                 type: "READ",
-                data: event.data,
+                data,
               })
             })
           })
@@ -279,6 +317,15 @@ export const terminalMachine =
           ...context,
           workspaceAgentError: undefined,
         })),
+        assignOffset: assign({
+          offset: (_, event) => event.offset,
+        }),
+        incrementOffset: assign({
+          offset: (context, event) =>
+            typeof event.data === "string"
+              ? context.offset
+              : (context.offset ?? 0) + event.data.byteLength,
+        }),
         assignWebsocket: assign({
           websocket: (_, event) => event.data,
         }),
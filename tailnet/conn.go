@@ -11,6 +11,7 @@ import (
 	"reflect"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -23,6 +24,7 @@ import (
 	"tailscale.com/net/connstats"
 	"tailscale.com/net/dns"
 	"tailscale.com/net/netns"
+	"tailscale.com/net/packet"
 	"tailscale.com/net/tsdial"
 	"tailscale.com/net/tstun"
 	"tailscale.com/tailcfg"
@@ -208,6 +210,7 @@ func NewConn(options *Options) (conn *Conn, err error) {
 		peerMap:                  map[tailcfg.NodeID]*tailcfg.Node{},
 		lastDERPForcedWebsockets: map[int]string{},
 		tunDevice:                tunDevice,
+		localIPs:                 localIPs,
 		netMap:                   netMap,
 		netStack:                 netStack,
 		wireguardMonitor:         wireguardMonitor,
@@ -265,6 +268,7 @@ func NewConn(options *Options) (conn *Conn, err error) {
 		server.sendNode()
 	})
 	netStack.ForwardTCPIn = server.forwardTCP
+	tunDevice.PreFilterIn = server.filterInboundUDP
 
 	err = netStack.Start(nil)
 	if err != nil {
@@ -310,6 +314,8 @@ type Conn struct {
 	wireguardEngine    wgengine.Engine
 	listeners          map[listenKey]*listener
 	forwardTCPCallback func(conn net.Conn, listenerExists bool) net.Conn
+	forwardUDPCallback atomic.Pointer[func(port uint16) bool]
+	localIPs           *netipx.IPSet
 
 	lastMutex   sync.Mutex
 	nodeSending bool
@@ -329,13 +335,38 @@ type Conn struct {
 // listenerExists is true if a listener is registered for the target port. If there
 // isn't one, traffic is forwarded to the local listening port.
 //
-// This allows wrapping a Conn to track reads and writes.
+// This allows wrapping a Conn to track reads and writes. The callback can
+// reject the connection by closing it and returning nil.
 func (c *Conn) SetForwardTCPCallback(callback func(conn net.Conn, listenerExists bool) net.Conn) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	c.forwardTCPCallback = callback
 }
 
+// SetForwardUDPCallback is called for every inbound UDP packet to the
+// addresses of the connection, which are forwarded to the local port. The
+// callback can reject the packet by returning false. It's called for every
+// packet, so it must be fast.
+func (c *Conn) SetForwardUDPCallback(callback func(port uint16) bool) {
+	c.forwardUDPCallback.Store(&callback)
+}
+
+// filterInboundUDP drops inbound UDP packets rejected by the forward UDP
+// callback, before netstack forwards them to the local port.
+func (c *Conn) filterInboundUDP(p *packet.Parsed, _ *tstun.Wrapper) filter.Response {
+	if p.IPProto != ipproto.UDP {
+		return filter.Accept
+	}
+	callback := c.forwardUDPCallback.Load()
+	if callback == nil || !c.localIPs.Contains(p.Dst.Addr()) {
+		return filter.Accept
+	}
+	if (*callback)(p.Dst.Port()) {
+		return filter.Accept
+	}
+	return filter.Drop
+}
+
 func (c *Conn) SetNodeCallback(callback func(node *Node)) {
 	c.lastMutex.Lock()
 	c.nodeCallback = callback
@@ -745,6 +776,9 @@ func (c *Conn) forwardTCP(conn net.Conn, port uint16) {
 		conn = c.forwardTCPCallback(conn, ok)
 	}
 	c.mutex.Unlock()
+	if conn == nil {
+		return
+	}
 	if !ok {
 		c.forwardTCPToLocal(conn, port)
 		return